@@ -23,8 +23,8 @@ func newAdamCmd(configName, verbosity *string) *cobra.Command {
 			Message: "Basic Commands",
 			Commands: []*cobra.Command{
 				newStartAdamCmd(cfg),
-				newStopAdamCmd(),
-				newStatusAdamCmd(),
+				newStopAdamCmd(cfg),
+				newStatusAdamCmd(cfg),
 				newChangeCertCmd(),
 			},
 		},
@@ -42,7 +42,7 @@ func newStartAdamCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Long:  `Start adam.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.AdamStart(); err != nil {
-				log.Fatalf("Adam start failed: %s", err)
+				fatalf("Adam start failed: %s", err)
 			}
 		},
 	}
@@ -57,7 +57,7 @@ func newStartAdamCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	return startAdamCmd
 }
 
-func newStopAdamCmd() *cobra.Command {
+func newStopAdamCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var adamRm bool
 
 	var stopAdamCmd = &cobra.Command{
@@ -65,7 +65,7 @@ func newStopAdamCmd() *cobra.Command {
 		Short: "stop adam",
 		Long:  `Stop adam.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := eden.StopAdam(adamRm); err != nil {
+			if err := eden.StopAdam(cfg.Containers().Adam, adamRm); err != nil {
 				log.Errorf("cannot stop adam: %s", err)
 			}
 		},
@@ -76,13 +76,13 @@ func newStopAdamCmd() *cobra.Command {
 	return stopAdamCmd
 }
 
-func newStatusAdamCmd() *cobra.Command {
+func newStatusAdamCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var statusAdamCmd = &cobra.Command{
 		Use:   "status",
 		Short: "status of adam",
 		Long:  `Status of adam.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			statusAdam, err := eden.StatusAdam()
+			statusAdam, err := eden.StatusAdam(cfg.Containers().Adam)
 			if err != nil {
 				log.Errorf("cannot obtain status of adam: %s", err)
 			} else {
@@ -104,11 +104,11 @@ func newChangeCertCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			certData, err := os.ReadFile(certFile)
 			if err != nil {
-				log.Fatalf("Failed to read certificate file: %s", err)
+				fatalf("Failed to read certificate file: %s", err)
 			}
 
 			if err := openEVEC.ChangeSigningCert(certData); err != nil {
-				log.Fatalf("Failed to upload certificate to adam: %s", err)
+				fatalf("Failed to upload certificate to adam: %s", err)
 			}
 		},
 	}
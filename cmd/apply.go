@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newApplyCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var path string
+	var prune, dryRun, watch bool
+	reconcileArgs := openevec.ReconcileArgs{}
+
+	var applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Reconcile environment with declared resources",
+		Long: `Reads declarative resources (Network, Volume, App, Device and ConfigItems) from yaml files of directory
+or from single file, computes difference with the current state of controller and reconciles it.
+Resources applied before and not declared anymore are deleted with --prune.
+Resources may be loaded from git repository with source in format [git::]<URL>[//<subdir>][?ref=<ref>].
+With --watch resources are applied every --interval until interrupted and drift from declared state is
+recorded into events and metrics.`,
+		Example: `eden apply -f env/ --prune
+eden apply -f https://github.com/org/envs.git//demo?ref=main --prune --watch --metrics-addr :9119`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			if watch {
+				if dryRun {
+					log.Fatal("--dry-run cannot be used with --watch")
+				}
+				reconcileArgs.Prune = prune
+				ctx, stop := interruptContext(cmd)
+				defer stop()
+				if err := openEVEC.Reconcile(ctx, path, reconcileArgs); err != nil {
+					fatalf("Reconcile failed: %s", err)
+				}
+				return
+			}
+			if err := openEVEC.Apply(path, prune, dryRun); err != nil {
+				fatalf("Apply failed: %s", err)
+			}
+		},
+	}
+
+	applyCmd.Flags().StringVarP(&path, "file", "f", "", "directory with yaml files of resources, single yaml file or git repository")
+	applyCmd.Flags().BoolVar(&prune, "prune", false, "delete resources applied before and not declared anymore")
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print planned actions without performing them")
+	applyCmd.Flags().BoolVar(&watch, "watch", false, "keep applying resources every interval until interrupted")
+	applyCmd.Flags().DurationVar(&reconcileArgs.Interval, "interval", time.Minute, "interval of reconcile with --watch")
+	applyCmd.Flags().StringVar(&reconcileArgs.MetricsAddr, "metrics-addr", "", "address to serve metrics of reconcile in Prometheus format with --watch")
+	applyCmd.Flags().StringVar(&reconcileArgs.DiagnosticsAddr, "diagnostics-addr", "", "address to serve pprof and expvar endpoints with --watch (together with metrics if the same as --metrics-addr)")
+	applyCmd.Flags().StringVar(&reconcileArgs.Events, "events", "", "file to append events of reconcile into (artifacts of the current context by default)")
+	_ = applyCmd.MarkFlagRequired("file")
+
+	return applyCmd
+}
@@ -30,7 +30,7 @@ func newCertsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	certsCmd.Flags().StringVarP(&cfg.Adam.Tag, "adam-tag", "", defaults.DefaultAdamTag, "tag on adam container to pull")
@@ -42,6 +42,7 @@ func newCertsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	certsCmd.Flags().StringVarP(&cfg.Adam.CertsIP, "ip", "i", defaults.DefaultIP, "IP address to use")
 	certsCmd.Flags().StringVarP(&cfg.Adam.CertsEVEIP, "eve-ip", "", defaults.DefaultEVEIP, "IP address to use for EVE")
 	certsCmd.Flags().StringVarP(&cfg.Eve.CertsUUID, "uuid", "u", defaults.DefaultUUID, "UUID to use for device")
+	_ = certsCmd.RegisterFlagCompletionFunc("uuid", completeDevices)
 	certsCmd.Flags().StringVar(&cfg.Eve.Ssid, "ssid", "", "SSID for wifi")
 	certsCmd.Flags().StringVar(&cfg.Eve.Password, "password", "", "password for wifi")
 	certsCmd.Flags().StringArrayVar(&grubOptions, "grub-options", []string{}, "append lines to grub options")
@@ -67,7 +68,7 @@ func newGenSigningCertCmd() *cobra.Command {
 
 	edenHome, err := utils.DefaultEdenDir()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	certsCmd.Flags().StringVarP(&certPath, "out", "o", filepath.Join(edenHome, defaults.DefaultCertsDist, "signing-new.pem"), "certificate output path")
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/spf13/cobra"
+)
+
+func newChaosCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	chaosArgs := openevec.ChaosArgs{}
+
+	var chaosCmd = &cobra.Command{
+		Use:   "chaos [-- <command> [args]]",
+		Short: "Inject random faults into EVE and its environment",
+		Long: `Injects random faults (EVE reboots, link flaps, Adam restarts and registry outages) with random
+intervals until duration passes, it is interrupted or command passed after '--' (e.g. eden test) exits.
+Command is stopped together with its children if it still runs. Links are flapped on ports of Eden-SDN
+if it is used, otherwise on interfaces of EVE VM. Timeline of faults is saved into artifacts of the current context to be analyzed together with test results.`,
+		Example:           `eden chaos --faults link-flap,adam-restart --interval 5m -- eden test tests/eclient/`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.Chaos(ctx, chaosArgs, args); err != nil {
+				fatalf("Chaos failed: %s", err)
+			}
+		},
+	}
+
+	chaosCmd.Flags().StringSliceVar(&chaosArgs.Faults, "faults", openevec.ChaosFaults,
+		"faults to inject: "+strings.Join(openevec.ChaosFaults, ", "))
+	chaosCmd.Flags().DurationVar(&chaosArgs.Duration, "duration", 0, "duration of injecting of faults (0 to run until interrupted or command exits)")
+	chaosCmd.Flags().DurationVar(&chaosArgs.Interval, "interval", 5*time.Minute, "mean interval between faults, randomized by ±50%")
+	chaosCmd.Flags().DurationVar(&chaosArgs.Outage, "outage", 30*time.Second, "duration of link flaps, Adam restarts and registry outages")
+	chaosCmd.Flags().Int64Var(&chaosArgs.Seed, "seed", 0, "seed to reproduce sequence of faults (chosen from the current time if 0)")
+	chaosCmd.Flags().StringVar(&chaosArgs.Timeline, "timeline", "", "file to save timeline of faults into (inside artifacts of the current context by default)")
+	chaosCmd.Flags().StringSliceVar(&chaosArgs.Interfaces, "interfaces", []string{"eth0", "eth1"}, "EVE interfaces for link flaps")
+	_ = chaosCmd.RegisterFlagCompletionFunc("interfaces", completeList(completeEveInterfaces))
+	chaosCmd.Flags().StringVar(&chaosArgs.VMName, "vmname", defaults.DefaultVBoxVMName, "name of the EVE VBox VM")
+
+	return chaosCmd
+}
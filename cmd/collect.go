@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/spf13/cobra"
+)
+
+func newCollectCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var output string
+	var tail uint
+	var pcapDuration time.Duration
+
+	var collectCmd = &cobra.Command{
+		Use:   "collect",
+		Short: "Collect support bundle for bug report",
+		Long: `Gathers everything relevant for a bug report into one archive: eden config (with secrets redacted),
+versions of eden, EVE, QEMU and docker, Adam logs, device config and last info, metric and log messages,
+EVE console log and QEMU command line, SDN network model, logs and optionally captured traffic.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			if output == "" {
+				output = fmt.Sprintf("eden-collect-%s.tar.gz", time.Now().Format("20060102-150405"))
+			}
+			if err := openEVEC.EdenCollect(output, tail, pcapDuration); err != nil {
+				fatalf("Eden collect failed: %s", err)
+			}
+		},
+	}
+
+	collectCmd.Flags().StringVarP(&output, "output", "o", "", "archive to save bundle into (eden-collect-<time>.tar.gz by default)")
+	collectCmd.Flags().UintVar(&tail, "tail", 1000, "number of the last messages and Adam log lines to collect (0 for all)")
+	collectCmd.Flags().DurationVar(&pcapDuration, "pcap", 0, "capture traffic inside SDN for this duration (e.g. 30s)")
+
+	return collectCmd
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completionOpenEVEC returns OpenEVEC for config selected by flags of cmd,
+// PersistentPreRunE of commands is not called during completion
+func completionOpenEVEC(cmd *cobra.Command) (*openevec.OpenEVEC, error) {
+	configName := defaults.DefaultContext
+	if flag := cmd.Flag("config"); flag != nil {
+		configName = flag.Value.String()
+	}
+	// logs are printed into stdout and must not mix with completions
+	cfg, err := openevec.FromViper(configName, log.PanicLevel.String())
+	if err != nil {
+		return nil, err
+	}
+	return openevec.CreateOpenEVEC(cfg), nil
+}
+
+// completeValues returns completion of values returned by list, there is
+// nothing to complete if list fails (e.g. controller is not running)
+func completeValues(list func(cmd *cobra.Command) ([]string, error)) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		values, err := list(cmd)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeFirstArg completes only the first argument with complete
+func completeFirstArg(complete completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+// completeList completes the last element of comma-separated values of slice flags
+func completeList(complete completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		prefix := toComplete[:strings.LastIndex(toComplete, ",")+1]
+		values, directive := complete(cmd, args, strings.TrimPrefix(toComplete, prefix))
+		for i := range values {
+			values[i] = prefix + values[i]
+		}
+		return values, directive | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+var completeContexts = completeValues(func(*cobra.Command) ([]string, error) {
+	return openevec.ContextNames()
+})
+
+var completeDevModels = completeValues(func(*cobra.Command) ([]string, error) {
+	return models.DevModelTypes(), nil
+})
+
+var completeDevices = completeValues(func(cmd *cobra.Command) ([]string, error) {
+	evec, err := completionOpenEVEC(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return evec.DeviceUUIDs()
+})
+
+var completeApps = completeValues(func(cmd *cobra.Command) ([]string, error) {
+	evec, err := completionOpenEVEC(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return evec.AppNames()
+})
+
+// completeDirs completes directories
+func completeDirs(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completeEscripts completes names of escripts of test suite in directory of the first argument
+var completeEscripts = completeValues(func(cmd *cobra.Command) ([]string, error) {
+	dir := "."
+	if args := cmd.Flags().Args(); len(args) > 0 {
+		dir = args[0]
+	}
+	return openevec.EscriptNames(dir)
+})
+
+var completeEveInterfaces = completeValues(func(cmd *cobra.Command) ([]string, error) {
+	evec, err := completionOpenEVEC(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return evec.EveInterfaceNames()
+})
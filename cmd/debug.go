@@ -46,17 +46,17 @@ func newDebugStartEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 				commandToRun := fmt.Sprintf("perf record %s -o %s", perfOptions, perfLocation)
 				commandToRun = fmt.Sprintf("sh -c 'nohup %s > /dev/null 2>&1 &'", commandToRun)
 				if err = openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 			} else {
-				log.Fatalf("SSH key problem: %s", err)
+				fatalf("SSH key problem: %s", err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	debugStartEveCmd.Flags().StringVarP(&eveSSHKey, "ssh-key", "", filepath.Join(currentPath, defaults.DefaultCertsDist, "id_rsa"), "file to use for ssh access")
@@ -80,17 +80,17 @@ func newDebugStopEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 			if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
 				commandToRun := "killall -SIGINT perf"
 				if err = openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 			} else {
-				log.Fatalf("SSH key problem: %s", err)
+				fatalf("SSH key problem: %s", err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	debugStopEveCmd.Flags().StringVarP(&eveSSHKey, "ssh-key", "", filepath.Join(currentPath, defaults.DefaultCertsDist, "id_rsa"), "file to use for ssh access")
 	debugStopEveCmd.Flags().StringVarP(&eveHost, "eve-host", "", defaults.DefaultEVEHost, "IP of eve")
@@ -112,36 +112,36 @@ func newDebugSaveEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			absPath, err := filepath.Abs(args[0])
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			tmpFile := fmt.Sprintf("%s.tmp", absPath)
 			if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
 				commandToRun := fmt.Sprintf("perf script -i %s > %s", perfLocation, defaults.DefaultPerfScriptEVELocation)
 				if err = openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 				err = openEVEC.SdnForwardSCPFromEve(defaults.DefaultPerfScriptEVELocation, tmpFile)
 				if err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 				image := fmt.Sprintf("%s:%s", defaults.DefaultProcContainerRef, defaults.DefaultProcTag)
 				commandToRun = fmt.Sprintf("-i /in/%s -o /out/%s svg", filepath.Base(tmpFile), filepath.Base(absPath))
 				volumeMap := map[string]string{"/in": filepath.Dir(tmpFile), "/out": filepath.Dir(absPath)}
 				var result string
 				if result, err = utils.RunDockerCommand(image, commandToRun, volumeMap); err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 				fmt.Println(result)
 				log.Infof("Please see output inside %s", absPath)
 			} else {
-				log.Fatalf("SSH key problem: %s", err)
+				fatalf("SSH key problem: %s", err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	debugSaveEveCmd.Flags().StringVarP(&eveSSHKey, "ssh-key", "", filepath.Join(currentPath, defaults.DefaultCertsDist, "id_rsa"), "file to use for ssh access")
@@ -166,7 +166,7 @@ func newDebugHardwareEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			absPath, err := filepath.Abs(args[0])
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
 				commandToRun := "lshw"
@@ -175,10 +175,10 @@ func newDebugHardwareEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 				}
 				commandToRun += ">" + hwLocation
 				if err = openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 				if err = openEVEC.SdnForwardSCPFromEve(hwLocation, absPath); err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 			}
 		},
@@ -186,7 +186,7 @@ func newDebugHardwareEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	debugHardwareEveCmd.Flags().StringVarP(&eveSSHKey, "ssh-key", "", filepath.Join(currentPath, defaults.DefaultCertsDist, "id_rsa"), "file to use for ssh access")
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -37,7 +36,7 @@ func newDisksLayoutCmd() *cobra.Command {
 		Long:  `Get disks layout`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if layout, err := openEVEC.GetDisksLayout(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			} else {
 				fmt.Println(layout)
 			}
@@ -55,7 +54,7 @@ func newSetDisksLayoutCmd() *cobra.Command {
 		Long:  `Set disks layout`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.SetDiskLayout(dc); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
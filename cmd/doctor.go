@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+
+	var doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check environment of eden",
+		Long: `Checks environment of the current context: availability of KVM and nested virtualization,
+versions of QEMU, swtpm and docker, free disk space and memory for EVE, ports used by other
+processes and stale pid files. Prints fixes of found problems and fails if some check failed.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.Doctor(); err != nil {
+				fatalf("Eden doctor found problems: %s", err)
+			}
+		},
+	}
+
+	return doctorCmd
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/openevec"
 	"github.com/lf-edge/eden/pkg/utils"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -46,7 +45,7 @@ func newDownloadEVERootFSCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 			}
 			model, err := models.GetDevModelByName(cfg.Eve.DevModel)
 			if err != nil {
-				log.Fatalf("GetDevModelByName: %s", err)
+				fatalf("GetDevModelByName: %s", err)
 			}
 			format := model.DiskFormat()
 			eveDesc := utils.EVEDescription{
@@ -60,7 +59,7 @@ func newDownloadEVERootFSCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 			}
 			image, err := utils.DownloadEveRootFS(eveDesc, outputDir)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			fmt.Println(image)
 		},
@@ -82,7 +81,7 @@ func newDownloadEVECmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Long:  `Download eve live image from docker.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.DownloadEve(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
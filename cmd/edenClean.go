@@ -5,37 +5,48 @@ import (
 	"path/filepath"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
 	"github.com/lf-edge/eden/pkg/openevec"
 	"github.com/lf-edge/eden/pkg/utils"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func newCleanCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
 	var configDist, vmName string
-	var currentContext bool
+	var currentContext, dryRun bool
+	var opts eden.CleanOptions
 
 	var cleanCmd = &cobra.Command{
-		Use:               "clean",
-		Short:             "clean harness",
-		Long:              `Clean harness.`,
+		Use:   "clean",
+		Short: "clean harness",
+		Long: `Clean harness.
+By default removes EVE, its images, certificates and state of the current context, with --current-context=false
+removes containers of services and files of all contexts as well. Use --keep-images, --keep-certs and --keep-state
+to keep parts of them, --containers-only to remove only containers of services (with their volumes if --volumes
+is set) and --dry-run to list what would be removed.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenClean(*configName, configDist, vmName, currentContext); err != nil {
-				log.Fatalf("Setup eden failed: %s", err)
+			utils.SetDryRun(dryRun)
+			if opts.Volumes && !opts.ContainersOnly {
+				fatalf("--volumes can be used only with --containers-only, full cleanup removes volumes unless --keep-state is set")
+			}
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.EdenClean(ctx, *configName, configDist, vmName, currentContext, opts); err != nil {
+				fatalf("Setup eden failed: %s", err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	configDist, err = utils.DefaultEdenDir()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	cleanCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file with EVE pid")
@@ -49,6 +60,12 @@ func newCleanCmd(configName, verbosity *string) *cobra.Command {
 	cleanCmd.Flags().StringVarP(&configDist, "config-dist", "", configDist, "directory with eden config to cleanup")
 	cleanCmd.Flags().BoolVar(&currentContext, "current-context", true, "clean only current context")
 	cleanCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	cleanCmd.Flags().BoolVar(&opts.KeepImages, "keep-images", false, "keep downloaded and built images of EVE and images of eserver and registry")
+	cleanCmd.Flags().BoolVar(&opts.KeepCerts, "keep-certs", false, "keep certificates")
+	cleanCmd.Flags().BoolVar(&opts.KeepState, "keep-state", false, "keep configs and state of eden, device in controller, data and volumes of services")
+	cleanCmd.Flags().BoolVar(&opts.ContainersOnly, "containers-only", false, "remove only containers of services")
+	cleanCmd.Flags().BoolVar(&opts.Volumes, "volumes", false, "remove volumes of containers of services with --containers-only")
+	cleanCmd.Flags().BoolVar(&dryRun, "dry-run", false, "list what would be removed without removing it")
 
 	addSdnPidOpt(cleanCmd, cfg)
 
@@ -3,24 +3,33 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
 
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
 )
 
+var configOutputFormatIds = map[types.OutputFormat][]string{
+	types.OutputFormatLines: {"lines"},
+	types.OutputFormatJSON:  {"json"},
+	types.OutputFormatYAML:  {"yaml"},
+}
+
 func newConfigCmd(configName, verbosity *string) *cobra.Command {
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	cfg, err := openevec.GetDefaultConfig(currentPath)
 	if err != nil {
-		log.Fatalf("Failed to generate default config %v\n", err)
+		fatalf("Failed to generate default config %v\n", err)
 	}
 	var configCmd = &cobra.Command{
 		Use:               "config",
+		Aliases:           []string{"context"},
 		Short:             "work with config",
 		Long:              `Work with config.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
@@ -31,10 +40,14 @@ func newConfigCmd(configName, verbosity *string) *cobra.Command {
 			Message: "Basic Commands",
 			Commands: []*cobra.Command{
 				newConfigAddCmd(cfg),
+				newConfigCloneCmd(),
+				newConfigExportCmd(),
+				newConfigImportCmd(),
 				newConfigDeleteCmd(cfg),
 				newConfigGetCmd(),
 				newConfigSetCmd(),
 				newConfigListCmd(),
+				newConfigPortsCmd(),
 				newConfigResetCmd(),
 				newConfigEditCmd(),
 			},
@@ -47,7 +60,7 @@ func newConfigCmd(configName, verbosity *string) *cobra.Command {
 }
 
 func newConfigAddCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
-	var force bool
+	var force, isolated, allocatePorts bool
 	var contextFile string
 
 	var configAddCmd = &cobra.Command{
@@ -67,15 +80,17 @@ func newConfigAddCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 			if len(args) > 0 {
 				configName = args[0]
 			}
-			if err := openevec.ConfigAdd(cfg, configName, contextFile, force); err != nil {
-				log.Fatal(err)
+			if err := addContext(cfg, configName, contextFile, force, allocatePorts, isolated); err != nil {
+				fatal(err)
 			}
 		},
 	}
 
 	configAddCmd.Flags().StringVar(&cfg.Eve.DevModel, "devmodel", defaults.DefaultQemuModel,
-		fmt.Sprintf("device model (%s/%s/%s/%s)",
-			defaults.DefaultQemuModel, defaults.DefaultRPIModel, defaults.DefaultGCPModel, defaults.DefaultGeneralModel))
+		fmt.Sprintf("device model (%s/%s/%s/%s/%s)",
+			defaults.DefaultQemuModel, defaults.DefaultRPIModel, defaults.DefaultGCPModel, defaults.DefaultGeneralModel,
+			defaults.DefaultPhysicalModel))
+	_ = configAddCmd.RegisterFlagCompletionFunc("devmodel", completeDevModels)
 	configAddCmd.Flags().StringVar(&contextFile, "file", "", "file with config to add")
 	//not used in function
 	configAddCmd.Flags().StringVarP(&cfg.Eve.QemuFileToSave, "qemu-config", "", defaults.DefaultQemuFileToSave, "file to save config")
@@ -89,17 +104,98 @@ func newConfigAddCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	configAddCmd.Flags().StringVar(&cfg.Eve.Arch, "arch", cfg.Eve.Arch, "arch of EVE (amd64 or arm64)")
 	configAddCmd.Flags().StringVar(&cfg.Eve.ModelFile, "devmodel-file", cfg.Eve.ModelFile, "File to use for overwrite of model defaults")
 	configAddCmd.Flags().BoolVarP(&force, "force", "", false, "force overwrite config file")
+	configAddCmd.Flags().BoolVar(&isolated, "isolated", false, "use own ports, files and containers of services to run next to other contexts")
+	configAddCmd.Flags().BoolVar(&allocatePorts, "allocate-ports", true, "reserve ports not used by other workspaces on host in port registry")
 
 	return configAddCmd
 }
 
+// addContext adds context with name reserving its ports in port registry if allocatePorts is set,
+// so it does not use the same ports as contexts of other workspaces on host
+func addContext(cfg *openevec.EdenSetupArgs, configName, contextFile string, force, allocatePorts, isolated bool) error {
+	switch {
+	case isolated:
+		if err := openevec.IsolateContext(cfg, configName); err != nil {
+			return err
+		}
+	case allocatePorts:
+		if err := openevec.AllocatePorts(cfg, configName); err != nil {
+			return err
+		}
+	}
+	if err := openevec.ConfigAdd(cfg, configName, contextFile, force); err != nil {
+		if isolated || allocatePorts {
+			_ = openevec.ReleasePorts(configName)
+		}
+		return err
+	}
+	return nil
+}
+
+func newConfigCloneCmd() *cobra.Command {
+	var configCloneCmd = &cobra.Command{
+		Use:               "clone <src> <dst>",
+		Short:             "Clone context into isolated one",
+		Long:              "Clone context src into dst with own ports, files and containers of services, so both can run at the same time",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeFirstArg(completeContexts),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.ConfigClone(args[0], args[1]); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return configCloneCmd
+}
+
+func newConfigExportCmd() *cobra.Command {
+	var withImages bool
+
+	var configExportCmd = &cobra.Command{
+		Use:   "export <file.tar.gz>",
+		Short: "Export context into archive",
+		Long:  "Export config, certificates and state of device of the current context (or one set with --config) into archive to move it to another host, certificates of controller are not exported",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ContextExport(args[0], withImages); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	configExportCmd.Flags().BoolVar(&withImages, "images", false, "export images of EVE as well")
+
+	return configExportCmd
+}
+
+func newConfigImportCmd() *cobra.Command {
+	var force bool
+
+	var configImportCmd = &cobra.Command{
+		Use:   "import <file.tar.gz>",
+		Short: "Import context from archive",
+		Long:  "Import context from archive created by export, paths are moved into eden root of the current context",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ContextImport(args[0], force); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	configImportCmd.Flags().BoolVar(&force, "force", false, "overwrite existing context and its certificates")
+
+	return configImportCmd
+}
+
 func newConfigListCmd() *cobra.Command {
 	var configListCmd = &cobra.Command{
 		Use:   "list",
 		Short: "List config contexts",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openevec.ConfigList(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -107,17 +203,34 @@ func newConfigListCmd() *cobra.Command {
 	return configListCmd
 }
 
+func newConfigPortsCmd() *cobra.Command {
+	var configPortsCmd = &cobra.Command{
+		Use:   "ports",
+		Short: "List ports reserved by contexts of workspaces of user",
+		Long: `List ports reserved in port registry shared by workspaces (eden directories) of user.
+Ports are reserved on creation of context and released on its deletion, path of registry can be set with EDEN_PORT_REGISTRY.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.PrintPortReservations(); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return configPortsCmd
+}
+
 func newConfigSetCmd() *cobra.Command {
 	var contextKeySet, contextValueSet string
 
 	var configSetCmd = &cobra.Command{
-		Use:   "set <name>",
-		Short: "Set current context to name",
-		Long:  "Set current context to name \n\t will only modify key for name context if --key not empty",
-		Args:  cobra.ExactValidArgs(1),
+		Use:               "set <name>",
+		Short:             "Set current context to name",
+		Long:              "Set current context to name \n\t will only modify key for name context if --key not empty, value is checked against type of key",
+		Args:              cobra.ExactValidArgs(1),
+		ValidArgsFunction: completeFirstArg(completeContexts),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openevec.ConfigSet(args[0], contextKeySet, contextValueSet); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -130,16 +243,17 @@ func newConfigSetCmd() *cobra.Command {
 
 func newConfigEditCmd() *cobra.Command {
 	var configEditCmd = &cobra.Command{
-		Use:   "edit [name]",
-		Short: "Edit current or context with defined name with $EDITOR",
-		Args:  cobra.RangeArgs(0, 1),
+		Use:               "edit [name]",
+		Short:             "Edit current or context with defined name with $EDITOR",
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: completeFirstArg(completeContexts),
 		Run: func(cmd *cobra.Command, args []string) {
 			target := ""
 			if len(args) == 1 {
 				target = args[0]
 			}
 			if err := openevec.ConfigEdit(target); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -148,16 +262,17 @@ func newConfigEditCmd() *cobra.Command {
 
 func newConfigResetCmd() *cobra.Command {
 	var configResetCmd = &cobra.Command{
-		Use:   "reset [name]",
-		Short: "Reset current or context with defined name to defaults",
-		Args:  cobra.RangeArgs(0, 1),
+		Use:               "reset [name]",
+		Short:             "Reset current or context with defined name to defaults",
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: completeFirstArg(completeContexts),
 		Run: func(cmd *cobra.Command, args []string) {
 			target := ""
 			if len(args) == 1 {
 				target = args[0]
 			}
 			if err := openevec.ConfigReset(target); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -167,38 +282,59 @@ func newConfigResetCmd() *cobra.Command {
 func newConfigGetCmd() *cobra.Command {
 	var contextKeyGet string
 	var contextAllGet bool
+	var outputFormat types.OutputFormat
 
 	var configGetCmd = &cobra.Command{
-		Use:   "get [name]",
+		Use:   "get [name] [query]",
 		Short: "get config context for current or defined name",
-		Long:  "Get config context for current or defined name. \n\tif --key set will show selected key only\n\tif --all set will return complete config",
-		Args:  cobra.RangeArgs(0, 1),
+		Long: `Get config context for current or defined name.
+	if query (or --key) set will show value selected by JSONPath-like query only (e.g. eve.hostfwd, eve.firmware[0])
+	if --all set will return complete config`,
+		Args:              cobra.RangeArgs(0, 2),
+		ValidArgsFunction: completeFirstArg(completeContexts),
 		Run: func(cmd *cobra.Command, args []string) {
 			contextNameGet := ""
-			if len(args) == 1 {
-				contextNameGet = args[0]
+			switch len(args) {
+			case 1:
+				// single argument is a query if there is no context with such name
+				contexts, err := openevec.ContextNames()
+				if err != nil {
+					fatal(err)
+				}
+				if slices.Contains(contexts, args[0]) || contextKeyGet != "" {
+					contextNameGet = args[0]
+				} else {
+					contextKeyGet = args[0]
+				}
+			case 2:
+				contextNameGet, contextKeyGet = args[0], args[1]
 			}
-			if err := openevec.ConfigGet(contextNameGet, contextKeyGet, contextAllGet); err != nil {
-				log.Fatal(err)
+			if err := openevec.ConfigGet(contextNameGet, contextKeyGet, contextAllGet, outputFormat); err != nil {
+				fatal(err)
 			}
 		},
 	}
 
 	configGetCmd.Flags().StringVar(&contextKeyGet, "key", "", "will return value of key from current config context")
 	configGetCmd.Flags().BoolVar(&contextAllGet, "all", false, "will return config context")
+	configGetCmd.Flags().VarP(
+		enumflag.New(&outputFormat, "output", configOutputFormatIds, enumflag.EnumCaseInsensitive),
+		"output", "o",
+		"Format to print values, supports: lines, json, yaml")
 
 	return configGetCmd
 }
 
 func newConfigDeleteCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var configDeleteCmd = &cobra.Command{
-		Use:   "delete <name>",
-		Short: "delete config context",
-		Args:  cobra.ExactArgs(1),
+		Use:               "delete <name>",
+		Short:             "delete config context",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeContexts),
 		Run: func(cmd *cobra.Command, args []string) {
 			contextNameDel := args[0]
 			if err := openevec.ConfigDelete(contextNameDel, cfg); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
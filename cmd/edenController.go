@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
 )
 
 func newControllerCmd(configName, verbosity *string) *cobra.Command {
@@ -35,8 +43,11 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 				newEdgeNodeUpdate(controllerMode),
 				newEdgeNodeGetConfig(controllerMode),
 				newEdgeNodeSetConfig(),
+				newEdgeNodeGet(controllerMode),
+				newEdgeNodeApply(),
 				newEdgeNodeGetOptions(controllerMode),
 				newEdgeNodeSetOptions(controllerMode),
+				newEdgeNodeWwan(controllerMode),
 			},
 		},
 	}
@@ -45,6 +56,12 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 
 	controllerCmd.AddCommand(edgeNode)
 
+	controllerCmd.AddCommand(newControllerConfigItem(controllerMode))
+	controllerCmd.AddCommand(newControllerCheckpoint())
+	controllerCmd.AddCommand(newControllerDiff())
+	controllerCmd.AddCommand(newControllerAttestation())
+	controllerCmd.AddCommand(newControllerRequests())
+	controllerCmd.AddCommand(newControllerFaults())
 	controllerCmd.AddCommand(newControllerGetOptions())
 	controllerCmd.AddCommand(newControllerSetOptions())
 
@@ -60,7 +77,7 @@ func newEdgeNodeReboot(controllerMode string) *cobra.Command {
 		Long:  `reboot EVE instance.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeReboot(controllerMode); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -74,7 +91,7 @@ func newEdgeNodeEVEImageUpdateRetry(controllerMode string) *cobra.Command {
 		Long:  `Update EVE image retry.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeEVEImageUpdateRetry(controllerMode); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -88,7 +105,7 @@ func newEdgeNodeShutdown(controllerMode string) *cobra.Command {
 		Long:  `shutdown EVE app instances.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeShutdown(controllerMode); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -108,7 +125,7 @@ func newEdgeNodeEVEImageUpdate(controllerMode string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			baseOSImage := args[0]
 			if err := openEVEC.EdgeNodeEVEImageUpdate(baseOSImage, baseOSVersion, registry, controllerMode, baseOSImageActivate, baseOSVDrive); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -132,7 +149,7 @@ func newEdgeNodeEVEImageRemove(controllerMode string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			baseOSImage := args[0]
 			if err := openEVEC.EdgeNodeEVEImageRemove(controllerMode, baseOSVersion, baseOSImage); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -151,7 +168,7 @@ func newEdgeNodeUpdate(controllerMode string) *cobra.Command {
 		Long:  `Update EVE config.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeUpdate(controllerMode, deviceItems, configItems); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -175,7 +192,7 @@ func newEdgeNodeGetOptions(controllerMode string) *cobra.Command {
 		Long:  `Fetch EVE options.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeGetOptions(controllerMode, fileWithConfig); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -194,7 +211,7 @@ func newEdgeNodeSetOptions(controllerMode string) *cobra.Command {
 		Long:  `Set EVE options.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeSetOptions(controllerMode, fileWithConfig); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -213,7 +230,7 @@ func newControllerGetOptions() *cobra.Command {
 		Long:  `Fetch controller options.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.ControllerGetOptions(fileWithConfig); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -232,7 +249,7 @@ func newControllerSetOptions() *cobra.Command {
 		Long:  `Set controller options.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.ControllerSetOptions(fileWithConfig); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -251,7 +268,7 @@ func newEdgeNodeGetConfig(controllerMode string) *cobra.Command {
 		Long:  `Fetch EVE config.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeGetConfig(controllerMode, fileWithConfig); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -270,7 +287,7 @@ func newEdgeNodeSetConfig() *cobra.Command {
 		Long:  `Set EVE config.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdgeNodeSetConfig(fileWithConfig); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -279,3 +296,567 @@ func newEdgeNodeSetConfig() *cobra.Command {
 
 	return edgeNodeSetConfig
 }
+
+func newEdgeNodeWwan(controllerMode string) *cobra.Command {
+	var edgeNodeWwan = &cobra.Command{
+		Use:   "wwan",
+		Short: "manage cellular ports of EVE",
+		Long:  `Manage APNs, SIM slots and other cellular options of wwan ports of EVE.`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newEdgeNodeWwanSet(controllerMode),
+				newEdgeNodeWwanGet(controllerMode),
+				newEdgeNodeWwanRemove(controllerMode),
+			},
+		},
+	}
+
+	groups.AddTo(edgeNodeWwan)
+
+	return edgeNodeWwan
+}
+
+func newEdgeNodeWwanSet(controllerMode string) *cobra.Command {
+	var port models.CellularPort
+	var apns, preferredPLMNs, preferredRATs []string
+	var authProtocol string
+	var forbidRoaming bool
+
+	var edgeNodeWwanSet = &cobra.Command{
+		Use:   "set <logical label>",
+		Short: "set cellular config of wwan port",
+		Long:  `Set cellular config of wwan port, system adapter for the port is created if it does not exist.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			port.LogicalLabel = args[0]
+			for _, el := range apns {
+				ap, err := models.ParseCellularAccessPoint(el)
+				if err != nil {
+					fatal(err)
+				}
+				ap.AuthProtocol = authProtocol
+				ap.PreferredPLMNs = preferredPLMNs
+				ap.PreferredRATs = preferredRATs
+				ap.ForbidRoaming = forbidRoaming
+				port.AccessPoints = append(port.AccessPoints, ap)
+			}
+			if err := openEVEC.EdgeNodeWwanSet(controllerMode, port); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	edgeNodeWwanSet.Flags().StringArrayVar(&apns, "apn", nil, "access point in format [SIM_SLOT:]APN, may be repeated for several SIM slots")
+	edgeNodeWwanSet.Flags().Uint32Var(&port.ActivatedSimSlot, "sim-slot", 0, "SIM slot to activate (0 for the default one)")
+	edgeNodeWwanSet.Flags().StringVar(&authProtocol, "auth-protocol", "none", "authentication protocol of access points: none, pap, chap or pap-and-chap")
+	edgeNodeWwanSet.Flags().StringSliceVar(&preferredPLMNs, "preferred-plmns", nil, "preferred networks (MCC+MNC)")
+	edgeNodeWwanSet.Flags().StringSliceVar(&preferredRATs, "preferred-rats", nil, "preferred radio access technologies: gsm, umts, lte, 5gnr")
+	edgeNodeWwanSet.Flags().BoolVar(&forbidRoaming, "forbid-roaming", false, "forbid roaming")
+	edgeNodeWwanSet.Flags().BoolVar(&port.LocationTracking, "location-tracking", false, "enable location tracking with GNSS receiver of modem")
+	edgeNodeWwanSet.Flags().BoolVar(&port.Uplink, "uplink", false, "use port for management")
+
+	return edgeNodeWwanSet
+}
+
+func newEdgeNodeWwanGet(controllerMode string) *cobra.Command {
+	var outputFormat types.OutputFormat
+
+	var edgeNodeWwanGet = &cobra.Command{
+		Use:   "get",
+		Short: "show cellular config and status of wwan ports",
+		Long:  `Show cellular config of wwan ports and their status reported by EVE.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeWwanGet(controllerMode, outputFormat); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	edgeNodeWwanGet.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print, supports: lines, json")
+
+	return edgeNodeWwanGet
+}
+
+func newEdgeNodeWwanRemove(controllerMode string) *cobra.Command {
+	var edgeNodeWwanRemove = &cobra.Command{
+		Use:   "remove <logical label>",
+		Short: "remove wwan port",
+		Long:  `Remove system adapter of wwan port with its cellular config.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeWwanRemove(controllerMode, args[0]); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return edgeNodeWwanRemove
+}
+
+func newControllerConfigItem(controllerMode string) *cobra.Command {
+	var configItemCmd = &cobra.Command{
+		Use:   "config-item",
+		Short: "manage config items of EVE",
+		Long: `Manage config items of EVE in bulk.
+Changes are recorded in config-items-audit.log inside eden directory.
+Supported keys are defined in https://github.com/lf-edge/eve/blob/master/docs/CONFIG-PROPERTIES.md`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newControllerConfigItemSet(controllerMode),
+				newControllerConfigItemGet(controllerMode),
+				newControllerConfigItemUnset(controllerMode),
+			},
+		},
+	}
+
+	groups.AddTo(configItemCmd)
+
+	return configItemCmd
+}
+
+// configItemKeys returns keys from args and from yaml file with items
+func configItemKeys(args []string, fileName string) ([]string, error) {
+	keys := args
+	if fileName != "" {
+		items, err := openevec.LoadConfigItemsFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		for key := range items {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func newControllerConfigItemSet(controllerMode string) *cobra.Command {
+	var fileName string
+
+	var configItemSet = &cobra.Command{
+		Use:   "set [key=value...]",
+		Short: "set config items",
+		Long:  `Set config items from yaml file with key: value mapping and from arguments.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			items := make(map[string]string)
+			if fileName != "" {
+				var err error
+				if items, err = openevec.LoadConfigItemsFile(fileName); err != nil {
+					fatal(err)
+				}
+			}
+			for _, arg := range args {
+				key, val, found := strings.Cut(arg, "=")
+				if !found || key == "" {
+					fatalf("cannot parse %s, use key=value", arg)
+				}
+				items[key] = val
+			}
+			if len(items) == 0 {
+				log.Fatal("please provide config items with --file or arguments")
+			}
+			if err := openEVEC.ConfigItemsSet(controllerMode, items); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	configItemSet.Flags().StringVarP(&fileName, "file", "f", "", "yaml file with config items")
+
+	return configItemSet
+}
+
+func newControllerConfigItemGet(controllerMode string) *cobra.Command {
+	var fileName string
+	var outputFormat types.OutputFormat
+
+	var configItemGet = &cobra.Command{
+		Use:   "get [key...]",
+		Short: "show config items",
+		Long: `Show configured values of config items and values acknowledged by EVE.
+All configured items are shown if no keys provided in arguments or file.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			keys, err := configItemKeys(args, fileName)
+			if err != nil {
+				fatal(err)
+			}
+			if err := openEVEC.ConfigItemsGet(controllerMode, keys, outputFormat); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	configItemGet.Flags().StringVarP(&fileName, "file", "f", "", "yaml file with config items to show")
+	configItemGet.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print, supports: lines, json")
+
+	return configItemGet
+}
+
+func newControllerConfigItemUnset(controllerMode string) *cobra.Command {
+	var fileName string
+
+	var configItemUnset = &cobra.Command{
+		Use:   "unset [key...]",
+		Short: "unset config items",
+		Long:  `Unset config items to return them to defaults of EVE.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			keys, err := configItemKeys(args, fileName)
+			if err != nil {
+				fatal(err)
+			}
+			if len(keys) == 0 {
+				log.Fatal("please provide keys with --file or arguments")
+			}
+			if err := openEVEC.ConfigItemsUnset(controllerMode, keys); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	configItemUnset.Flags().StringVarP(&fileName, "file", "f", "", "yaml file with config items to unset")
+
+	return configItemUnset
+}
+
+func newEdgeNodeGet(controllerMode string) *cobra.Command {
+	var fileWithConfig string
+
+	var edgeNodeGet = &cobra.Command{
+		Use:   "get",
+		Short: "fetch EVE config in yaml format",
+		Long: `Fetch EVE config in yaml format with stable order of fields.
+Comments of existing file are kept if config is saved with --file.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeGet(controllerMode, fileWithConfig); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	edgeNodeGet.Flags().StringVarP(&fileWithConfig, "file", "f", "", "save config to file")
+
+	return edgeNodeGet
+}
+
+func newEdgeNodeApply() *cobra.Command {
+	var fileWithConfig string
+
+	var edgeNodeApply = &cobra.Command{
+		Use:   "apply",
+		Short: "apply EVE config from yaml file",
+		Long: `Apply EVE config from yaml file produced by 'edge-node get'.
+Version of config is incremented automatically if config differs from the current one.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeApply(fileWithConfig); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	edgeNodeApply.Flags().StringVarP(&fileWithConfig, "file", "f", "", "apply config from file")
+
+	return edgeNodeApply
+}
+
+func newControllerCheckpoint() *cobra.Command {
+	var force bool
+
+	var checkpointCmd = &cobra.Command{
+		Use:   "checkpoint",
+		Short: "manage checkpoints of EVE config",
+		Long: `Manage named checkpoints of full config of device in controller.
+Rollback to checkpoint sends saved config with new version and epoch, so experiments can be reverted exactly.`,
+	}
+
+	checkpointCreate := &cobra.Command{
+		Use:   "create <name>",
+		Short: "save config of device as checkpoint",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ConfigCheckpointCreate(args[0], force); err != nil {
+				fatalf("checkpoint create failed: %s", err)
+			}
+		},
+	}
+	checkpointCreate.Flags().BoolVar(&force, "force", false, "overwrite existing checkpoint")
+
+	checkpointCmd.AddCommand(checkpointCreate, &cobra.Command{
+		Use:   "rollback <name>",
+		Short: "restore config of device from checkpoint",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ConfigCheckpointRollback(args[0]); err != nil {
+				fatalf("checkpoint rollback failed: %s", err)
+			}
+		},
+	}, &cobra.Command{
+		Use:   "delete <name>",
+		Short: "delete checkpoint",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ConfigCheckpointDelete(args[0]); err != nil {
+				fatalf("checkpoint delete failed: %s", err)
+			}
+		},
+	}, &cobra.Command{
+		Use:   "list",
+		Short: "list checkpoints",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ConfigCheckpointList(); err != nil {
+				fatalf("checkpoint list failed: %s", err)
+			}
+		},
+	})
+
+	return checkpointCmd
+}
+
+func newControllerDiff() *cobra.Command {
+	var diffContext int
+
+	var diffCmd = &cobra.Command{
+		Use:   "diff <checkpoint> [<checkpoint>]",
+		Short: "show differences of config of device",
+		Long: `Show differences of config of device from checkpoint to the current config in controller,
+or between two checkpoints. Changed words are highlighted unless --no-color is set or output is not terminal.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			name2 := ""
+			if len(args) > 1 {
+				name2 = args[1]
+			}
+			opts := utils.DefaultDiffOptions()
+			opts.Context = diffContext
+			if err := openEVEC.ConfigDiff(args[0], name2, opts); err != nil {
+				fatalf("controller diff failed: %s", err)
+			}
+		},
+	}
+	diffCmd.Flags().IntVarP(&diffContext, "context", "U", 3, "number of lines of context, all lines are shown if negative")
+
+	return diffCmd
+}
+
+func newControllerAttestation() *cobra.Command {
+	var fileName, eveVersion, firmwareVersion string
+	var pcrValues []string
+	var outputFormat types.OutputFormat
+	var attested bool
+	var timeout time.Duration
+
+	var attestationCmd = &cobra.Command{
+		Use:   "attestation",
+		Short: "manage attestation policy of controller",
+		Long: `Manage attestation policy of controller: mode (enforce or log) and expected PCR templates.
+In enforce mode controller rejects attestation of device with PCRs not matching any template of policy,
+so EVE does not receive its keys and keeps vault locked, in log mode mismatch is only logged by controller.`,
+	}
+
+	attestationMode := &cobra.Command{
+		Use:       "mode <enforce|log>",
+		Short:     "set mode of attestation policy",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{"enforce", "log"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.AttestationSetMode(args[0] == "enforce"); err != nil {
+				fatalf("attestation mode failed: %s", err)
+			}
+		},
+	}
+
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "manage expected PCR templates",
+	}
+	policyAdd := &cobra.Command{
+		Use:   "add",
+		Short: "add PCR template into policy",
+		Long: `Add PCR template into policy replacing template for the same EVE and firmware versions.
+Template is read from file in format of controller options or taken from PCRs received from device.
+Values of template may be overridden with --pcr, e.g. to make attestation fail.`,
+		Example: `eden controller attestation policy add
+eden controller attestation policy add --pcr 14=0000000000000000000000000000000000000000000000000000000000000000
+eden controller attestation policy add --pcr 14=*`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.AttestationPolicyAdd(fileName, pcrValues); err != nil {
+				fatalf("attestation policy add failed: %s", err)
+			}
+		},
+	}
+	policyAdd.Flags().StringVarP(&fileName, "file", "f", "", "json file with PCR template")
+	policyAdd.Flags().StringSliceVar(&pcrValues, "pcr", nil, "override PCR value in format <index>=<value>, '*' matches any value")
+	policyRemove := &cobra.Command{
+		Use:   "remove",
+		Short: "remove PCR templates from policy",
+		Long:  `Remove PCR templates for provided EVE and firmware versions, all templates are removed without flags.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.AttestationPolicyRemove(eveVersion, firmwareVersion); err != nil {
+				fatalf("attestation policy remove failed: %s", err)
+			}
+		},
+	}
+	policyRemove.Flags().StringVar(&eveVersion, "eve-version", "", "remove templates for EVE version")
+	policyRemove.Flags().StringVar(&firmwareVersion, "firmware-version", "", "remove templates for firmware version")
+	policyCmd.AddCommand(policyAdd, policyRemove)
+
+	attestationStatus := &cobra.Command{
+		Use:   "status",
+		Short: "show attestation state of controller and device",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.AttestationStatusShow(outputFormat); err != nil {
+				fatalf("attestation status failed: %s", err)
+			}
+		},
+	}
+	attestationStatus.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print, supports: lines, json")
+
+	attestationWait := &cobra.Command{
+		Use:   "wait",
+		Short: "wait for result of attestation of device",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.AttestationWait(ctx, attested, timeout); err != nil {
+				fatalf("attestation wait failed: %s", err)
+			}
+		},
+	}
+	attestationWait.Flags().BoolVar(&attested, "attested", true, "expected result of attestation")
+	attestationWait.Flags().DurationVar(&timeout, "timeout", 0, "timeout of waiting (timeouts.attestation of config if 0)")
+
+	attestationCmd.AddCommand(attestationMode, policyCmd, attestationStatus, attestationWait)
+
+	return attestationCmd
+}
+
+func newControllerRequests() *cobra.Command {
+	var args openevec.RequestsArgs
+
+	var requestsCmd = &cobra.Command{
+		Use:   "requests",
+		Short: "show requests of EVE to controller",
+		Long: `Show requests of EVE to controller with time, method, URL and client IP.
+With --follow new requests are printed as they come, requests of config are annotated with version,
+size and hash of config served by controller at that moment.
+With --record requests are appended into requests.json and every served config is saved into configs
+inside requests directory of artifacts of the current context, recorded requests are printed with --recorded.`,
+		Example: `eden controller requests
+eden controller requests --follow --record
+eden controller requests --recorded --format json`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := openEVEC.ControllerRequests(args); err != nil {
+				fatalf("controller requests failed: %s", err)
+			}
+		},
+	}
+	requestsCmd.Flags().BoolVarP(&args.Follow, "follow", "f", false, "wait for new requests")
+	requestsCmd.Flags().BoolVar(&args.Record, "record", false, "record requests and served configs")
+	requestsCmd.Flags().BoolVar(&args.Recorded, "recorded", false, "print recorded requests")
+	requestsCmd.Flags().Var(
+		enumflag.New(&args.Format, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print, supports: lines, json")
+
+	return requestsCmd
+}
+
+func newControllerFaults() *cobra.Command {
+	var fault eden.ControllerFault
+	var duration time.Duration
+	var outputFormat types.OutputFormat
+	var adamPort int
+
+	var faultsCmd = &cobra.Command{
+		Use:   "faults",
+		Short: "inject faults into responses of controller to EVE",
+		Long: `Inject faults into responses of controller to EVE to test retries and fallbacks of EVE.
+Faults are applied by proxy started with 'eden controller faults proxy', which takes port of Adam,
+faults may be added and removed while proxy is running.`,
+	}
+
+	faultsAdd := &cobra.Command{
+		Use:   "add <delay|error|stale|oversize>",
+		Short: "add fault for requests matching endpoint",
+		Long: `Add fault for requests with path matching endpoint regular expression:
+delay    delays response for --delay
+error    responds with --status without forwarding request to Adam
+stale    responds with the last successful response of Adam for the same request
+oversize pads body of response of Adam up to --size bytes`,
+		Example: `eden controller faults add delay --endpoint /config$ --delay 30s --duration 10m
+eden controller faults add error --status 503 --count 5
+eden controller faults add stale --endpoint /config$`,
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{string(eden.FaultDelay), string(eden.FaultError), string(eden.FaultStale), string(eden.FaultOversize)},
+		Run: func(cmd *cobra.Command, args []string) {
+			fault.Kind = eden.ControllerFaultKind(args[0])
+			if err := openEVEC.ControllerFaultAdd(&fault, duration); err != nil {
+				fatalf("faults add failed: %s", err)
+			}
+		},
+	}
+	faultsAdd.Flags().StringVar(&fault.Endpoint, "endpoint", "^/api/", "regular expression for path of requests")
+	faultsAdd.Flags().DurationVar(&fault.Delay, "delay", 0, "delay of responses")
+	faultsAdd.Flags().IntVar(&fault.Status, "status", 503, "HTTP status of responses")
+	faultsAdd.Flags().IntVar(&fault.Size, "size", 0, "size of responses in bytes")
+	faultsAdd.Flags().IntVar(&fault.Count, "count", 0, "number of requests to affect, 0 for unlimited")
+	faultsAdd.Flags().DurationVar(&duration, "duration", 0, "duration of fault, 0 for unlimited")
+
+	faultsList := &cobra.Command{
+		Use:   "list",
+		Short: "list faults",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ControllerFaultList(outputFormat); err != nil {
+				fatalf("faults list failed: %s", err)
+			}
+		},
+	}
+	faultsList.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print, supports: lines, json")
+
+	faultsRemove := &cobra.Command{
+		Use:   "remove [id...]",
+		Short: "remove faults, all faults without arguments",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ControllerFaultRemove(args); err != nil {
+				fatalf("faults remove failed: %s", err)
+			}
+		},
+	}
+
+	faultsProxy := &cobra.Command{
+		Use:   "proxy",
+		Short: "serve proxy injecting faults on port of Adam",
+		Long: `Move API of Adam to another port and serve proxy injecting faults on port of Adam until interrupted.
+Adam is moved back to its port when proxy stops.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.ControllerFaultProxy(ctx, adamPort); err != nil {
+				fatalf("faults proxy failed: %s", err)
+			}
+		},
+	}
+	faultsProxy.Flags().IntVar(&adamPort, "adam-port", 0, "port to move Adam to, adam.port+1 by default")
+
+	faultsCmd.AddCommand(faultsAdd, faultsList, faultsRemove, faultsProxy)
+
+	return faultsCmd
+}
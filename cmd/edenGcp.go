@@ -41,7 +41,7 @@ func newGcpImageDelete(gcpKey, gcpProjectName *string) *cobra.Command {
 		Short: "delete image from gcp",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.GcpImageDelete(*gcpKey, *gcpProjectName, gcpImageName, gcpBucketName); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -60,11 +60,11 @@ func newGcpImageListCmd(gcpKey, gcpProjectName *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			gcpClient, err := linuxkit.NewGCPClient(*gcpKey, *gcpProjectName)
 			if err != nil {
-				log.Fatalf("Unable to connect to GCP: %v", err)
+				fatalf("Unable to connect to GCP: %v", err)
 			}
 			imageList, err := gcpClient.ListImages()
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			for _, el := range imageList {
 				fmt.Println(el)
@@ -83,7 +83,7 @@ func newGcpImageUploadCmd(cfg *openevec.EdenSetupArgs, gcpKey, gcpProjectName *s
 		Run: func(cmd *cobra.Command, args []string) {
 			err := openEVEC.GcpImageUpload(*gcpKey, *gcpProjectName, gcpImageName, gcpBucketName, cfg.Eve.ImageFile, cfg.Eve.TPM)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -129,7 +129,7 @@ func newGcpRunCmd(cfg *openevec.EdenSetupArgs, gcpKey, gcpProjectName *string) *
 		Run: func(cmd *cobra.Command, args []string) {
 			err := openEVEC.GcpRun(*gcpKey, *gcpProjectName, gcpImageName, gcpVMName, gcpZone, gcpMachineType, cfg.Eve.TPM, cfg.Eve.Disks, cfg.Eve.ImageSizeMB)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -152,7 +152,7 @@ func newGcpDeleteCmd(gcpKey, gcpProjectName *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			err := openEVEC.GcpDelete(*gcpKey, *gcpProjectName, gcpVMName, gcpZone)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -172,10 +172,10 @@ func newGcpConsoleCmd(gcpKey, gcpProjectName *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			gcpClient, err := linuxkit.NewGCPClient(*gcpKey, *gcpProjectName)
 			if err != nil {
-				log.Fatalf("Unable to connect to GCP: %v", err)
+				fatalf("Unable to connect to GCP: %v", err)
 			}
 			if err := gcpClient.ConnectToInstanceSerialPort(gcpVMName, gcpZone); err != nil {
-				log.Fatalf("ConnectToInstanceSerialPort: %s", err)
+				fatalf("ConnectToInstanceSerialPort: %s", err)
 			}
 		},
 	}
@@ -196,10 +196,10 @@ func newGcpLogCmd(gcpKey, gcpProjectName *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			gcpClient, err := linuxkit.NewGCPClient(*gcpKey, *gcpProjectName)
 			if err != nil {
-				log.Fatalf("Unable to connect to GCP: %v", err)
+				fatalf("Unable to connect to GCP: %v", err)
 			}
 			if err := gcpClient.GetInstanceSerialOutput(gcpVMName, gcpZone, follow); err != nil {
-				log.Fatalf("GetInstanceSerialOutput: %s", err)
+				fatalf("GetInstanceSerialOutput: %s", err)
 			}
 		},
 	}
@@ -220,11 +220,11 @@ func newGcpGetIPCmd(gcpKey, gcpProjectName *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			gcpClient, err := linuxkit.NewGCPClient(*gcpKey, *gcpProjectName)
 			if err != nil {
-				log.Fatalf("Unable to connect to GCP: %v", err)
+				fatalf("Unable to connect to GCP: %v", err)
 			}
 			natIP, err := gcpClient.GetInstanceNatIP(gcpVMName, gcpZone)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			fmt.Println(natIP)
 		},
@@ -257,13 +257,13 @@ func newGcpAddFirewallRule(gcpKey, gcpProjectName *string) *cobra.Command {
 			}
 			gcpClient, err := linuxkit.NewGCPClient(*gcpKey, *gcpProjectName)
 			if err != nil {
-				log.Fatalf("Unable to connect to GCP: %v", err)
+				fatalf("Unable to connect to GCP: %v", err)
 			}
 			if err := gcpClient.DeleteFirewallAllowRule(gcpFirewallRuleName); err != nil {
 				log.Warning(err)
 			}
 			if err := gcpClient.SetFirewallAllowRule(gcpFirewallRuleName, gcpFirewallRulePriority, gcpFirewallRuleSources); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			log.Info("Rules added")
 		},
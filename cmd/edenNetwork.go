@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"github.com/lf-edge/eden/pkg/controller/types"
-	log "github.com/sirupsen/logrus"
+	"github.com/lf-edge/eden/pkg/openevec"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -20,6 +20,9 @@ func newNetworkCmd() *cobra.Command {
 				newNetworkDeleteCmd(),
 				newNetworkNetstatCmd(),
 				newNetworkCreateCmd(),
+				newNetworkModifyCmd(),
+				newNetworkRouteCmd(),
+				newNetworkReservationCmd(),
 			},
 		},
 	}
@@ -37,7 +40,7 @@ func newNetworkLsCmd() *cobra.Command {
 		Short: "List networks",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.NetworkLs(outputFormat); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -49,6 +52,8 @@ func newNetworkLsCmd() *cobra.Command {
 }
 
 func newNetworkDeleteCmd() *cobra.Command {
+	var force bool
+
 	//networkDeleteCmd is a command to delete network instance from EVE
 	var networkDeleteCmd = &cobra.Command{
 		Use:   "delete <name>",
@@ -56,11 +61,14 @@ func newNetworkDeleteCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			niName := args[0]
-			if err := openEVEC.NetworkDelete(niName); err != nil {
-				log.Fatal(err)
+			if err := openEVEC.NetworkDelete(niName, force); err != nil {
+				fatal(err)
 			}
 		},
 	}
+
+	networkDeleteCmd.Flags().BoolVar(&force, "force", false, "Delete network even if it is used by applications")
+
 	return networkDeleteCmd
 }
 
@@ -76,7 +84,7 @@ func newNetworkNetstatCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			niName := args[0]
 			if err := openEVEC.NetworkNetstat(niName, outputFormat, outputTail); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -91,8 +99,8 @@ func newNetworkNetstatCmd() *cobra.Command {
 }
 
 func newNetworkCreateCmd() *cobra.Command {
-	var networkType, networkName, uplinkAdapter string
-	var staticDNSEntries []string
+	var networkType, networkName, uplinkAdapter, gateway, dhcpRange string
+	var staticDNSEntries, dnsServers []string
 	var enableFlowlog bool
 
 	//networkCreateCmd is command for create network instance in EVE
@@ -106,8 +114,8 @@ func newNetworkCreateCmd() *cobra.Command {
 				subnet = args[0]
 			}
 			if err := openEVEC.NetworkCreate(subnet, networkType, networkName, uplinkAdapter,
-				staticDNSEntries, enableFlowlog); err != nil {
-				log.Fatal(err)
+				staticDNSEntries, enableFlowlog, gateway, dnsServers, dhcpRange); err != nil {
+				fatal(err)
 			}
 		},
 	}
@@ -117,6 +125,193 @@ func newNetworkCreateCmd() *cobra.Command {
 	networkCreateCmd.Flags().StringVarP(&uplinkAdapter, "uplink", "u", "eth0", "Name of uplink adapter, set to 'none' to not use uplink")
 	networkCreateCmd.Flags().StringArrayVarP(&staticDNSEntries, "static-dns-entries", "s", []string{}, "List of static DNS entries in format HOSTNAME:IP_ADDR,IP_ADDR,...")
 	networkCreateCmd.Flags().BoolVar(&enableFlowlog, "enable-flowlog", false, "enable flow logging (EVE collecting and publishing records of application network flows)")
+	networkCreateCmd.Flags().StringVar(&gateway, "gateway", "", "Gateway of local network (the first address of subnet by default)")
+	networkCreateCmd.Flags().StringSliceVar(&dnsServers, "dns", nil, "DNS servers of local network (gateway by default)")
+	networkCreateCmd.Flags().StringVar(&dhcpRange, "dhcp-range", "", "DHCP range of local network in format START-END (derived from subnet by default)")
 
 	return networkCreateCmd
 }
+
+func newNetworkModifyCmd() *cobra.Command {
+	var modifyArgs openevec.NetworkModifyArgs
+	var enableFlowlog bool
+
+	//networkModifyCmd is command for modify network instance in EVE
+	var networkModifyCmd = &cobra.Command{
+		Use:   "modify <name>",
+		Short: "Modify network instance in EVE",
+		Long:  "Modify network instance in EVE, settings which are not defined in flags remain unchanged",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			niName := args[0]
+			if !cmd.Flags().Changed("static-dns-entries") {
+				modifyArgs.StaticDNSEntries = nil
+			}
+			if cmd.Flags().Changed("enable-flowlog") {
+				modifyArgs.Flowlog = &enableFlowlog
+			}
+			if err := openEVEC.NetworkModify(niName, modifyArgs); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	networkModifyCmd.Flags().StringVarP(&modifyArgs.Uplink, "uplink", "u", "", "Name of uplink adapter, set to 'none' to not use uplink")
+	networkModifyCmd.Flags().StringArrayVarP(&modifyArgs.StaticDNSEntries, "static-dns-entries", "s", []string{}, "List of static DNS entries in format HOSTNAME:IP_ADDR,IP_ADDR,... to replace the current ones")
+	networkModifyCmd.Flags().BoolVar(&enableFlowlog, "enable-flowlog", false, "enable or disable (with --enable-flowlog=false) flow logging")
+	networkModifyCmd.Flags().StringVar(&modifyArgs.Gateway, "gateway", "", "Gateway of local network")
+	networkModifyCmd.Flags().StringSliceVar(&modifyArgs.DNSServers, "dns", nil, "DNS servers of local network")
+	networkModifyCmd.Flags().StringVar(&modifyArgs.DHCPRange, "dhcp-range", "", "DHCP range of local network in format START-END")
+
+	return networkModifyCmd
+}
+
+func newNetworkRouteCmd() *cobra.Command {
+	var networkRouteCmd = &cobra.Command{
+		Use:   "route",
+		Short: "Manage static routes of network instance",
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newNetworkRouteAddCmd(),
+				newNetworkRouteDeleteCmd(),
+				newNetworkRouteLsCmd(),
+			},
+		},
+	}
+
+	groups.AddTo(networkRouteCmd)
+
+	return networkRouteCmd
+}
+
+func newNetworkRouteAddCmd() *cobra.Command {
+	var gateway, port string
+
+	//networkRouteAddCmd is command for add static route into network instance
+	var networkRouteAddCmd = &cobra.Command{
+		Use:   "add <network> <destination>",
+		Short: "Add static route to destination network in CIDR notation",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkRouteAdd(args[0], args[1], gateway, port); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	networkRouteAddCmd.Flags().StringVar(&gateway, "gateway", "", "Gateway IP address of route")
+	networkRouteAddCmd.Flags().StringVar(&port, "port", "", "Logical label or shared label of output port of route")
+
+	return networkRouteAddCmd
+}
+
+func newNetworkRouteDeleteCmd() *cobra.Command {
+	//networkRouteDeleteCmd is command for delete static route from network instance
+	var networkRouteDeleteCmd = &cobra.Command{
+		Use:   "delete <network> <destination>",
+		Short: "Delete static route to destination network",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkRouteDelete(args[0], args[1]); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return networkRouteDeleteCmd
+}
+
+func newNetworkRouteLsCmd() *cobra.Command {
+	//networkRouteLsCmd is command for list routes of network instance
+	var networkRouteLsCmd = &cobra.Command{
+		Use:   "ls <network>",
+		Short: "List static routes of network and routes reported by EVE",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkRouteLs(args[0]); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return networkRouteLsCmd
+}
+
+func newNetworkReservationCmd() *cobra.Command {
+	var networkReservationCmd = &cobra.Command{
+		Use:   "reservation",
+		Short: "Manage IP reservations of applications in network instance",
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newNetworkReservationAddCmd(),
+				newNetworkReservationDeleteCmd(),
+				newNetworkReservationLsCmd(),
+			},
+		},
+	}
+
+	groups.AddTo(networkReservationCmd)
+
+	return networkReservationCmd
+}
+
+func newNetworkReservationAddCmd() *cobra.Command {
+	var mac string
+
+	//networkReservationAddCmd is command for reserve IP address for application
+	var networkReservationAddCmd = &cobra.Command{
+		Use:   "add <network> <app> <ip>",
+		Short: "Reserve IP address for application in network",
+		Long:  "Reserve IP address for application in network, application is purged to apply it",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkReservationAdd(args[0], args[1], args[2], mac); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	networkReservationAddCmd.Flags().StringVar(&mac, "mac", "", "MAC address of application interface (generated by EVE if not set)")
+
+	return networkReservationAddCmd
+}
+
+func newNetworkReservationDeleteCmd() *cobra.Command {
+	//networkReservationDeleteCmd is command for delete IP reservation of application
+	var networkReservationDeleteCmd = &cobra.Command{
+		Use:   "delete <network> <app>",
+		Short: "Delete IP reservation of application in network",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkReservationDelete(args[0], args[1]); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return networkReservationDeleteCmd
+}
+
+func newNetworkReservationLsCmd() *cobra.Command {
+	//networkReservationLsCmd is command for list IP reservations in network instance
+	var networkReservationLsCmd = &cobra.Command{
+		Use:   "ls <network>",
+		Short: "List IP addresses reserved for applications and assigned to them by EVE",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkReservationLs(args[0]); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return networkReservationLsCmd
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -55,7 +54,7 @@ func newPacketRunCmd(packetKey, packetProjectName *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			err := openEVEC.PacketRun(*packetKey, *packetProjectName, packetVMName, packetZone, packetMachineType, packetIPXEUrl)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -76,7 +75,7 @@ func newPacketDeleteCmd(packetKey, packetProjectName *string) *cobra.Command {
 		Short: "delete vm from packet",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.PacketDelete(*packetKey, *packetProjectName, packetVMName); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -94,7 +93,7 @@ func newPacketGetIPCmd(packetKey, packetProjectName *string) *cobra.Command {
 		Short: "print IP of VM in packet",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.PacketGetIP(*packetKey, *packetProjectName, packetVMName); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
 	edgeRegistry "github.com/lf-edge/edge-containers/pkg/registry"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -36,6 +38,7 @@ func newPodCmd(configName, verbosity *string) *cobra.Command {
 			Message: "Printing Commands",
 			Commands: []*cobra.Command{
 				newPodPsCmd(),
+				newPodProbeCmd(),
 				newPodLogsCmd(cfg),
 			},
 		},
@@ -57,8 +60,10 @@ func newPodPublishCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
-			if err := openEVEC.PodPublish(appName, kernelFile, initrdFile, rootFile, formatStr, arch, local, disks); err != nil {
-				log.Fatal(err)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.PodPublish(ctx, appName, kernelFile, initrdFile, rootFile, formatStr, arch, local, disks); err != nil {
+				fatal(err)
 			}
 		},
 	}
@@ -76,16 +81,37 @@ func newPodPublishCmd() *cobra.Command {
 
 func newPodDeployCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var pc openevec.PodConfig
+	var buildDir string
+	var dryRun bool
 
 	var podDeployCmd = &cobra.Command{
 		Use:   "deploy (docker|http(s)|file|directory)://(<TAG|PATH>[:<VERSION>] | <URL for qcow2 image> | <path to qcow2 image>)",
 		Short: "Deploy app in pod",
-		Long:  `Deploy app in pod.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Deploy app in pod.
+With --build <dir> image is built from Dockerfile in dir, loaded into local registry and deployed from it.
+With --show-config config of app instance with its volumes, content trees and network instances is printed as JSON
+as it is pushed to controller. With --dry-run nothing is changed: images are not uploaded into eserver, built
+or pushed into registry and config is not sent to controller, planned actions are printed instead.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if buildDir != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			appLink := args[0]
+			utils.SetDryRun(dryRun)
+			var appLink string
+			if buildDir != "" {
+				var err error
+				if appLink, err = openEVEC.PodBuild(buildDir); err != nil {
+					fatal(err)
+				}
+				pc.Registry = "local"
+			} else {
+				appLink = args[0]
+			}
 			if err := openEVEC.PodDeploy(appLink, pc, cfg); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -102,7 +128,7 @@ func newPodDeployCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	podDeployCmd.Flags().Uint32Var(&pc.AppCpus, "cpus", defaults.DefaultAppCPU, "cpu number for app")
 	podDeployCmd.Flags().StringSliceVar(&pc.AppAdapters, "adapters", nil, "adapters to assign to the application instance")
 	podDeployCmd.Flags().StringSliceVar(&pc.Networks, "networks", nil, "Networks to connect to app (ports will be mapped to first network). May have <name:[MAC address]> notation.")
-	podDeployCmd.Flags().StringVar(&pc.ImageFormat, "format", "", "format for image, one of 'container','qcow2','raw','qcow','vmdk','vhdx','iso'; if not provided, defaults to container image for docker and oci transports, qcow2 for file and http/s transports")
+	podDeployCmd.Flags().StringVar(&pc.ImageFormat, "format", "", "format for image, one of 'container','wasm','qcow2','raw','qcow','vmdk','vhdx','iso'; if not provided, defaults to container image for docker and oci transports (WASM module or disk image for OCI artifacts), qcow2 for file and http/s transports")
 	podDeployCmd.Flags().BoolVar(&pc.ACLOnlyHost, "only-host", false, "Allow access only to host and external networks")
 	podDeployCmd.Flags().BoolVar(&pc.NoHyper, "no-hyper", false, "Run pod without hypervisor")
 	podDeployCmd.Flags().StringVar(&pc.Registry, "registry", "remote", "Select registry to use for containers (remote/local)")
@@ -123,18 +149,25 @@ You can set access VLAN ID (VID) for a particular network in the format '<networ
 	podDeployCmd.Flags().StringVar(&pc.DatastoreOverride, "datastoreOverride", "", "Override datastore path for disks (when we use different URL for Eden and EVE or for local datastore)")
 	podDeployCmd.Flags().Uint32Var(&pc.StartDelay, "start-delay", 0, "The amount of time (in seconds) that EVE waits (after boot finish) before starting application")
 	podDeployCmd.Flags().BoolVar(&pc.PinCpus, "pin-cpus", false, "Pin the CPUs used by the pod")
+	podDeployCmd.Flags().StringVar(&pc.Probe, "probe", "", `Health probe of pod in format <http|tcp>:<EVE port>[/path][@ifname], for example: http:8028/health.
+Probe runs against published port of EVE and its result is shown in 'eden pod ps'`)
+
+	podDeployCmd.Flags().StringVar(&buildDir, "build", "", "directory with Dockerfile to build image from and deploy it via local registry")
+	podDeployCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print actions of deploy instead of performing them")
+	podDeployCmd.Flags().BoolVar(&pc.ShowConfig, "show-config", false, "print config of pod with its volumes and network instances as JSON")
 
 	return podDeployCmd
 }
 
 func newPodPsCmd() *cobra.Command {
 	var outputFormat types.OutputFormat
+	var offline bool
 	var podPsCmd = &cobra.Command{
 		Use:   "ps",
 		Short: "List pods",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.PodPs(outputFormat); err != nil {
-				log.Fatalf("EVE pod deploy failed: %s", err)
+			if err := openEVEC.PodPs(outputFormat, offline); err != nil {
+				fatalf("EVE pod deploy failed: %s", err)
 			}
 		},
 	}
@@ -142,19 +175,47 @@ func newPodPsCmd() *cobra.Command {
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+	podPsCmd.Flags().BoolVar(&offline, "offline", false, "Use data saved locally by previous online commands instead of controller")
 
 	return podPsCmd
 }
 
+func newPodProbeCmd() *cobra.Command {
+	var wait bool
+	var timeout time.Duration
+
+	var podProbeCmd = &cobra.Command{
+		Use:               "probe <name>",
+		Short:             "Run health probe of pod",
+		Long:              `Run health probe of pod defined with --probe flag of deploy. Exits with error if pod is unhealthy.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
+		Run: func(cmd *cobra.Command, args []string) {
+			appName := args[0]
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.PodProbe(ctx, appName, wait, timeout); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	podProbeCmd.Flags().BoolVar(&wait, "wait", false, "wait for pod to become healthy")
+	podProbeCmd.Flags().DurationVar(&timeout, "timeout", 0, "timeout for waiting (timeouts.app-probe of config if 0)")
+
+	return podProbeCmd
+}
+
 func newPodStopCmd() *cobra.Command {
 	var podStopCmd = &cobra.Command{
-		Use:   "stop",
-		Short: "Stop pod",
-		Args:  cobra.ExactArgs(1),
+		Use:               "stop",
+		Short:             "Stop pod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
 			if err := openEVEC.PodStop(appName); err != nil {
-				log.Fatalf("EVE pod stop failed: %s", err)
+				fatalf("EVE pod stop failed: %s", err)
 			}
 		},
 	}
@@ -166,14 +227,15 @@ func newPodPurgeCmd() *cobra.Command {
 	var volumesToPurge []string
 
 	var podPurgeCmd = &cobra.Command{
-		Use:   "purge",
-		Short: "Purge pod",
-		Args:  cobra.ExactArgs(1),
+		Use:               "purge",
+		Short:             "Purge pod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
 			explicitVolumes := cmd.Flags().Changed("volumes")
 			if err := openEVEC.PodPurge(volumesToPurge, appName, explicitVolumes); err != nil {
-				log.Fatalf("EVE pod purge failed: %s", err)
+				fatalf("EVE pod purge failed: %s", err)
 			}
 		},
 	}
@@ -185,13 +247,14 @@ func newPodPurgeCmd() *cobra.Command {
 
 func newPodRestartCmd() *cobra.Command {
 	var podRestartCmd = &cobra.Command{
-		Use:   "restart",
-		Short: "Restart pod",
-		Args:  cobra.ExactArgs(1),
+		Use:               "restart",
+		Short:             "Restart pod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
 			if err := openEVEC.PodRestart(appName); err != nil {
-				log.Fatalf("EVE pod restart failed: %s", err)
+				fatalf("EVE pod restart failed: %s", err)
 			}
 		},
 	}
@@ -201,13 +264,14 @@ func newPodRestartCmd() *cobra.Command {
 
 func newPodStartCmd() *cobra.Command {
 	var podStartCmd = &cobra.Command{
-		Use:   "start",
-		Short: "Start pod",
-		Args:  cobra.ExactArgs(1),
+		Use:               "start",
+		Short:             "Start pod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
 			if err := openEVEC.PodStart(appName); err != nil {
-				log.Fatalf("EVE pod start failed: %s", err)
+				fatalf("EVE pod start failed: %s", err)
 			}
 		},
 	}
@@ -219,13 +283,14 @@ func newPodDeleteCmd() *cobra.Command {
 	var deleteVolumes bool
 
 	var podDeleteCmd = &cobra.Command{
-		Use:   "delete",
-		Short: "Delete pod",
-		Args:  cobra.ExactArgs(1),
+		Use:               "delete",
+		Short:             "Delete pod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
 			if _, err := openEVEC.PodDelete(appName, deleteVolumes); err != nil {
-				log.Fatalf("EVE pod start failed: %s", err)
+				fatalf("EVE pod start failed: %s", err)
 			}
 		},
 	}
@@ -243,13 +308,14 @@ func newPodLogsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	)
 
 	var podLogsCmd = &cobra.Command{
-		Use:   "logs <name>",
-		Short: "Logs of pod",
-		Args:  cobra.ExactArgs(1),
+		Use:               "logs <name>",
+		Short:             "Logs of pod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
 			if err := openEVEC.PodLogs(appName, outputTail, outputFields, outputFormat); err != nil {
-				log.Fatalf("EVE pod start failed: %s", err)
+				fatalf("EVE pod start failed: %s", err)
 			}
 		},
 	}
@@ -269,13 +335,14 @@ func newPodModifyCmd() *cobra.Command {
 	var startDelay uint32
 
 	var podModifyCmd = &cobra.Command{
-		Use:   "modify <app>",
-		Short: "Modify pod",
-		Args:  cobra.ExactArgs(1),
+		Use:               "modify <app>",
+		Short:             "Modify pod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeApps),
 		Run: func(cmd *cobra.Command, args []string) {
 			appName := args[0]
 			if err := openEVEC.PodModify(appName, podNetworks, portPublish, acl, vlans, startDelay); err != nil {
-				log.Fatalf("EVE pod start failed: %s", err)
+				fatalf("EVE pod start failed: %s", err)
 			}
 		},
 	}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -60,7 +59,7 @@ func newGetRentConsoleOutputCmd(rolProjectID *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			output, err := openEVEC.GetRentConsoleOutput(*rolProjectID, rolRentID)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			fmt.Println(output)
 		},
@@ -83,7 +82,7 @@ func newCreateRentCmd(rolProjectID *string) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			err := openEVEC.CreateRent(*rolProjectID, rolRentName, rolModel, rolManufacturer, rolIPXEUrl)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -108,7 +107,7 @@ func newGetRentCmd(rolProjectID *string) *cobra.Command {
 		Long:  `Get the device rent`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.GetRent(*rolProjectID, rolRentID); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -128,7 +127,7 @@ func newCloseRentCmd(rolProjectID *string) *cobra.Command {
 		Long:  `Close the device rent`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.CloseRent(*rolProjectID, rolRentID); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
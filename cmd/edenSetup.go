@@ -7,7 +7,7 @@ import (
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
+	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -15,7 +15,7 @@ func newSetupCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
 	var configDir, softSerial, zedControlURL, ipxeOverride string
 	var grubOptions []string
-	var netboot, installer bool
+	var netboot, installer, dryRun bool
 
 	var setupCmd = &cobra.Command{
 		Use:               "setup",
@@ -23,16 +23,19 @@ func newSetupCmd(configName, verbosity *string) *cobra.Command {
 		Long:              `Setup harness.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.SetupEden(*configName, configDir, softSerial, zedControlURL, ipxeOverride, grubOptions, netboot, installer); err != nil {
+			utils.SetDryRun(dryRun)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.SetupEden(ctx, *configName, configDir, softSerial, zedControlURL, ipxeOverride, grubOptions, netboot, installer); err != nil {
 
-				log.Fatalf("Setup eden failed: %s", err)
+				fatalf("Setup eden failed: %s", err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	setupCmd.Flags().BoolVarP(&cfg.Eden.Download, "download", "", cfg.Eden.Download, "download EVE or build")
@@ -43,12 +46,14 @@ func newSetupCmd(configName, verbosity *string) *cobra.Command {
 	setupCmd.Flags().StringVar(&zedControlURL, "zedcontrol", "", "Use provided zedcontrol domain instead of adam (as example: zedcloud.alpha.zededa.net)")
 	setupCmd.Flags().StringVar(&ipxeOverride, "ipxe-override", "", "override lines inside ipxe, please use || as delimiter")
 	setupCmd.Flags().StringArrayVar(&grubOptions, "grub-options", []string{}, "append lines to grub options")
+	setupCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print actions of setup without performing them")
 
 	setupCmd.Flags().StringVarP(&cfg.Eden.CertsDir, "certs-dist", "o", cfg.Eden.CertsDir, "directory with certs")
 	setupCmd.Flags().StringVarP(&cfg.Adam.CertsDomain, "domain", "d", defaults.DefaultDomain, "FQDN for certificates")
 	setupCmd.Flags().StringVarP(&cfg.Adam.CertsIP, "ip", "i", defaults.DefaultIP, "IP address to use")
 	setupCmd.Flags().StringVarP(&cfg.Adam.CertsEVEIP, "eve-ip", "", defaults.DefaultEVEIP, "IP address to use for EVE")
 	setupCmd.Flags().StringVarP(&cfg.Eve.CertsUUID, "uuid", "u", defaults.DefaultUUID, "UUID to use for device")
+	_ = setupCmd.RegisterFlagCompletionFunc("uuid", completeDevices)
 
 	setupCmd.Flags().StringVarP(&cfg.Adam.Tag, "adam-tag", "", defaults.DefaultAdamTag, "Adam tag")
 	setupCmd.Flags().StringVarP(&cfg.Adam.Dist, "adam-dist", "", cfg.Adam.Dist, "adam dist to start (required)")
@@ -60,6 +65,8 @@ func newSetupCmd(configName, verbosity *string) *cobra.Command {
 	setupCmd.Flags().StringVarP(&cfg.Eve.ImageFile, "image-file", "", cfg.Eve.ImageFile, "path for image drive (required)")
 	setupCmd.Flags().StringVarP(&cfg.Eve.Dist, "eve-dist", "", cfg.Eve.Dist, "directory to save EVE")
 	setupCmd.Flags().StringVarP(&cfg.Eve.Repo, "eve-repo", "", defaults.DefaultEveRepo, "EVE repo")
+	setupCmd.Flags().StringVarP(&cfg.Eve.Source, "eve-source", "", cfg.Eve.Source, "git repo or local directory of EVE to build image from")
+	setupCmd.Flags().StringVarP(&cfg.Eve.Ref, "eve-ref", "", cfg.Eve.Ref, "branch, tag or sha of EVE source to build (master by default)")
 	setupCmd.Flags().StringVarP(&cfg.Eve.Registry, "eve-registry", "", defaults.DefaultEveRegistry, "EVE registry")
 	setupCmd.Flags().StringVarP(&cfg.Eve.Tag, "eve-tag", "", defaults.DefaultEVETag, "EVE tag")
 	setupCmd.Flags().StringVarP(&cfg.Eve.UefiTag, "eve-uefi-tag", "", defaults.DefaultEVETag, "EVE UEFI tag")
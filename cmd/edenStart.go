@@ -7,13 +7,14 @@ import (
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
+	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 func newStartCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
 	var zedControlURL, vmName, tapInterface string
+	var dryRun bool
 
 	var startCmd = &cobra.Command{
 		Use:               "start",
@@ -21,15 +22,18 @@ func newStartCmd(configName, verbosity *string) *cobra.Command {
 		Long:              `Start harness.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.StartEden(vmName, zedControlURL, tapInterface); err != nil {
-				log.Fatalf("Start eden failed: %s", err)
+			utils.SetDryRun(dryRun)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.StartEden(ctx, vmName, zedControlURL, tapInterface); err != nil {
+				fatalf("Start eden failed: %s", err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	startCmd.Flags().StringVarP(&cfg.Adam.Tag, "adam-tag", "", defaults.DefaultAdamTag, "tag on adam container to pull")
@@ -58,6 +62,8 @@ func newStartCmd(configName, verbosity *string) *cobra.Command {
 	startCmd.Flags().StringVarP(&cfg.Eve.Arch, "eve-arch", "", runtime.GOARCH, "arch of system")
 	startCmd.Flags().StringVarP(&cfg.Eve.QemuOS, "eve-os", "", runtime.GOOS, "os to run on")
 	startCmd.Flags().BoolVarP(&cfg.Eve.Accel, "eve-accel", "", cfg.Eve.Accel, "use acceleration")
+	startCmd.Flags().StringVarP(&cfg.Eve.TCG, "eve-tcg", "", cfg.Eve.TCG, "TCG emulation of EVE instead of acceleration (auto, on or off)")
+	startCmd.Flags().StringVarP(&cfg.Eve.Accelerator, "eve-accelerator", "", cfg.Eve.Accelerator, "accelerator of EVE (auto, kvm, hvf, whpx or tcg)")
 	startCmd.Flags().StringVarP(&cfg.Eve.Serial, "eve-serial", "", defaults.DefaultEVESerial, "SMBIOS serial")
 	startCmd.Flags().StringVarP(&cfg.Eve.QemuConfigPath, "qemu-config", "", filepath.Join(currentPath, defaults.DefaultDist, defaults.DefaultQemuFileToSave), "config file to use")
 	startCmd.Flags().IntVarP(&cfg.Eve.QemuConfig.MonitorPort, "qemu-monitor-port", "", defaults.DefaultQemuMonitorPort, "Port for access to QEMU monitor")
@@ -66,6 +72,7 @@ func newStartCmd(configName, verbosity *string) *cobra.Command {
 	startCmd.Flags().StringVarP(&tapInterface, "with-tap", "", "", "use tap interface in QEMU as the third")
 	startCmd.Flags().StringVarP(&cfg.Eve.ImageFile, "image-file", "", cfg.Eve.ImageFile, "path to image drive, overrides default setting")
 	startCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	startCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print containers and commands to start without running them")
 	startCmd.Flags().StringVar(&zedControlURL, "zedcontrol", "", "Use provided zedcontrol domain instead of adam (as example: zedcloud.alpha.zededa.net)")
 
 	startCmd.Flags().StringVarP(&cfg.Eve.UsbNetConfFile, "eve-usbnetconf-file", "", "", "path to device network config (aka usb.json) applied in runtime using a USB stick")
@@ -85,4 +92,5 @@ func addSdnStartOpts(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	addSdnImageOpt(parentCmd, cfg)
 	addSdnDisableOpt(parentCmd, cfg)
 	addSdnIPv6Opt(parentCmd, cfg)
+	addSdnIPv4Opt(parentCmd, cfg)
 }
@@ -3,37 +3,59 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func newStatusCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
-	var allConfigs bool
+	var allConfigs, offline, watch bool
 	var vmName string
+	watchArgs := openevec.StatusWatchArgs{}
 
 	var statusCmd = &cobra.Command{
-		Use:               "status",
-		Short:             "status of harness",
-		Long:              `Status of harness.`,
+		Use:   "status",
+		Short: "status of harness",
+		Long: `Status of harness.
+With --watch status of services and EVE is refreshed every --interval until interrupted, values changed recently
+are highlighted with their previous values. With --json transitions of values are printed as JSON events,
+one per line, instead, e.g. to wait for onboarding or update of EVE in scripts.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.Status(vmName, allConfigs); err != nil {
-				log.Fatal(err)
+			if watch {
+				if offline {
+					fatalf("--offline cannot be used with --watch")
+				}
+				ctx, stop := interruptContext(cmd)
+				defer stop()
+				if err := openEVEC.StatusWatch(ctx, vmName, allConfigs, watchArgs); err != nil {
+					fatal(err)
+				}
+				return
+			}
+			if watchArgs.JSON {
+				fatalf("--json can be used only with --watch")
+			}
+			if err := openEVEC.Status(vmName, allConfigs, offline); err != nil {
+				fatal(err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	statusCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file with EVE pid")
 	statusCmd.Flags().BoolVar(&allConfigs, "all", true, "show status for all configs")
+	statusCmd.Flags().BoolVar(&offline, "offline", false, "show status of EVE saved locally by previous runs without access to controller")
 	statusCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	statusCmd.Flags().BoolVarP(&watch, "watch", "w", false, "refresh status every interval until interrupted")
+	statusCmd.Flags().DurationVar(&watchArgs.Interval, "interval", 5*time.Second, "interval of refresh with --watch")
+	statusCmd.Flags().BoolVar(&watchArgs.JSON, "json", false, "print transitions of status as JSON events with --watch")
 
 	addSdnPidOpt(statusCmd, cfg)
 	addSdnPortOpts(statusCmd, cfg)
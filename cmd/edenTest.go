@@ -3,11 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
 )
 
 func newTestCmd(configName, verbosity *string) *cobra.Command {
@@ -21,14 +23,40 @@ func newTestCmd(configName, verbosity *string) *cobra.Command {
 
 test <test_dir> [-s <scenario>] [-t <timewait>] [-v <level>]
 test <test_dir> -l <regexp>
+test <test_dir> -l <regexp> --format <lines|json>
 test <test_dir> -o
 test <test_dir> -r <regexp> [-t <timewait>] [-v <level>]
+test <test_dir> -r <pattern>
+test <test_dir> -e <regexp> --resume
+test <test_dir> -e <regexp> --seed <seed>
+test <test_dir> [-e <regexp>] --watch
+test <test_dir> [-e <regexp>] --bench <baseline.json> [--bench-threshold <percent>] [--bench-fail|--bench-update]
+test <test_dir> [-s <scenario>] --report <file.html>
+test <test_dir> [-s <scenario>|-e <regexp>] --snapshot <name>
+test <test_dir> [-s <scenario>] --nodes <context1>,<context2>
+test <test_dir> [-s <scenario>] --devmodels <devmodel1>,<devmodel2>
 
+With --run pattern selects escripts of test_dir by name or tag: pattern with wildcards (e.g. 'port*forward')
+matches as glob, otherwise letters of pattern must appear in name in the same order (e.g. 'pfwd').
+If several escripts match, they are listed to pick ones to run (all of them run if input is not terminal).
+If no escript matches, pattern is regular expression of tests of test binary.
 `,
 		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeFirstArg(completeDirs),
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			var err error
+			// resolve before changing of directory to test_dir
+			if tstCfg.Report != "" {
+				if tstCfg.Report, err = filepath.Abs(tstCfg.Report); err != nil {
+					return err
+				}
+			}
+			if tstCfg.Bench != "" {
+				if tstCfg.Bench, err = filepath.Abs(tstCfg.Bench); err != nil {
+					return err
+				}
+			}
 			if len(args) != 0 {
 				log.Debug("DIR: ", args[0])
 				tstCfg.CurDir, err = os.Getwd()
@@ -61,26 +89,57 @@ test <test_dir> -r <regexp> [-t <timewait>] [-v <level>]
 			if tstCfg.TestScenario == "" && tstCfg.TestProg == "" && tstCfg.TestRun == "" {
 				return fmt.Errorf("Please set the --scenario option or environment variable eden.test-scenario in the EDEN configuration.")
 			}
+			tstCfg.ListMetadata = cmd.Flags().Changed("format")
+			if tstCfg.ListMetadata && tstCfg.TestList == "" {
+				return fmt.Errorf("--format is supported only with --list")
+			}
 			tstCfg.ConfigFile = cfg.ConfigFile
+			tstCfg.ArtifactsUpload = cfg.Eden.ArtifactsUpload
 			tstCfg.Verbosity = *verbosity
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 
-			if err := openevec.Test(&tstCfg); err != nil {
-				log.Fatal(err)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openevec.Test(ctx, &tstCfg); err != nil {
+				fatal(err)
 			}
 		},
 	}
 
 	testCmd.Flags().StringVarP(&tstCfg.TestEscript, "escript", "e", "", "run EScript matching the regular expression")
 	testCmd.Flags().StringVarP(&tstCfg.TestProg, "prog", "p", "", "program binary to run tests")
-	testCmd.Flags().StringVarP(&tstCfg.TestRun, "run", "r", "", "run only those tests matching the regular expression")
+	testCmd.Flags().StringVarP(&tstCfg.TestRun, "run", "r", "", "run escripts matching the pattern by name or tag, or tests of test binary matching the regular expression")
+	_ = testCmd.RegisterFlagCompletionFunc("escript", completeEscripts)
+	_ = testCmd.RegisterFlagCompletionFunc("run", completeEscripts)
 	testCmd.Flags().StringVarP(&tstCfg.TestTimeout, "timeout", "t", "", "panic if test exceded the timeout")
 	testCmd.Flags().StringVarP(&tstCfg.TestArgs, "args", "a", "", "Arguments for test binary")
 	testCmd.Flags().StringVarP(&tstCfg.TestList, "list", "l", "", "list tests matching the regular expression")
+	testCmd.Flags().Var(
+		enumflag.New(&tstCfg.ListFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"list scenarios and escripts found in test_dir with their metadata instead of tests of test binary, supports: lines, json")
 	testCmd.Flags().StringVarP(&tstCfg.TestScenario, "scenario", "s", "", "scenario for tests bunch running")
 	testCmd.Flags().StringVarP(&tstCfg.FailScenario, "fail_scenario", "f", "cfg.FailScenario.txt", "scenario for test failing")
+	testCmd.Flags().StringSliceVar(&tstCfg.Nodes, "nodes", nil, "run tests concurrently against EVE nodes defined by these contexts")
+	testCmd.Flags().StringSliceVar(&tstCfg.DevModels, "devmodels", nil, "provision EVE with every devmodel (e.g. qemu,vbox,parallels) one by one and run tests against it")
+	_ = testCmd.RegisterFlagCompletionFunc("nodes", completeList(completeContexts))
+	_ = testCmd.RegisterFlagCompletionFunc("devmodels", completeList(completeDevModels))
+	testCmd.Flags().StringVar(&tstCfg.MatrixPrefix, "devmodels-prefix", "matrix", "prefix of contexts created for devmodels")
+	testCmd.Flags().BoolVar(&tstCfg.MatrixKeep, "devmodels-keep", false, "do not tear down environments of devmodels after tests")
+	testCmd.Flags().StringVar(&tstCfg.LogDir, "log-dir", "", "directory to save logs and report of tests run with --nodes or --devmodels (temporary directory by default)")
+	testCmd.Flags().Int64Var(&tstCfg.Seed, "seed", 0, "seed of random values generated by rand command of escripts, printed into logs of escripts to replay them")
+	testCmd.Flags().BoolVar(&tstCfg.Watch, "watch", false, "watch escripts of test_dir and helper binaries and rerun affected escripts (matching --escript) after they change")
+	testCmd.Flags().StringVar(&tstCfg.Bench, "bench", "", "compare measurements recorded by bench command of escripts with baseline file (created if it does not exist)")
+	testCmd.Flags().Float64Var(&tstCfg.BenchThreshold, "bench-threshold", 10, "change of measurement for the worse against baseline (in percent) considered as regression")
+	testCmd.Flags().BoolVar(&tstCfg.BenchUpdate, "bench-update", false, "save measurements into baseline file")
+	testCmd.Flags().BoolVar(&tstCfg.BenchFail, "bench-fail", false, "fail if measurements regress instead of warning")
+	testCmd.Flags().BoolVar(&tstCfg.Resume, "resume", false, "resume failed escripts from checkpoints of phases they failed at instead of running them from the beginning")
+	testCmd.Flags().StringVar(&tstCfg.Snapshot, "snapshot", "", "restore EVE VM from snapshot with name (saved from the current state if it does not exist) before every test of suites marked stateless")
+	testCmd.Flags().StringVar(&tstCfg.Profile, "profile", "", "save CPU and heap profiles of eden and test binaries into directory")
+	testCmd.Flags().DurationVar(&tstCfg.Resources, "resources", 0, "sample CPU, memory and disk I/O of the host and EVE VM with this interval (e.g. 5s) during every escript and add their usage to results")
+	testCmd.Flags().StringVar(&tstCfg.Report, "report", "", "save self-contained HTML report with results, phases and logs of tests into file")
 	testCmd.Flags().BoolVarP(&tstCfg.TestOpts, "opts", "o", false, "Options description for test binary which may be used in test scenarious and '-a|--args' option")
 
 	return testCmd
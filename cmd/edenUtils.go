@@ -7,7 +7,6 @@ import (
 
 	"github.com/lf-edge/eden/pkg/openevec"
 	"github.com/lf-edge/eden/pkg/utils"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -37,6 +36,8 @@ func newUtilsCmd(configName, verbosity *string) *cobra.Command {
 				newUploadGitCmd(),
 				newImportCmd(),
 				newExportCmd(),
+				newEveCacheCmd(),
+				newConvertImageCmd(),
 			},
 		},
 	}
@@ -56,14 +57,14 @@ func newSdInfoEveCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			devicePath := args[0]
 			if err := openEVEC.SDInfoEve(devicePath, syslogOutput, eveReleaseOutput); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	sdInfoEveCmd.Flags().StringVar(&syslogOutput, "syslog-out", filepath.Join(currentPath, "syslog.txt"), "File to save syslog.txt")
@@ -81,12 +82,12 @@ func newUploadGitCmd() *cobra.Command {
 		Args: cobra.RangeArgs(3, 4),
 		Run: func(cmd *cobra.Command, args []string) {
 			if _, err := os.Stat(args[0]); os.IsNotExist(err) {
-				log.Fatal(err)
+				fatal(err)
 			}
 
 			absPath, err := filepath.Abs(args[0])
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			directoryToSaveOnGit := args[2]
 			if len(args) == 4 {
@@ -94,7 +95,7 @@ func newUploadGitCmd() *cobra.Command {
 			}
 
 			if err := openEVEC.UploadGit(absPath, args[1], args[2], directoryToSaveOnGit); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -181,11 +182,11 @@ func newTemplateCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			tmpl, err := os.ReadFile(args[0])
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			out, err := utils.RenderTemplate(cfg.ConfigFile, string(tmpl))
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			fmt.Println(out)
 		},
@@ -202,7 +203,7 @@ func newExportCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			tarFile := args[0]
 			if err := openEVEC.EdenExport(tarFile); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -222,7 +223,7 @@ func newImportCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			tarFile := args[0]
 			if err := openEVEC.EdenImport(tarFile, rewriteRoot); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -231,3 +232,26 @@ func newImportCmd() *cobra.Command {
 
 	return importCmd
 }
+
+func newConvertImageCmd() *cobra.Command {
+	var input, output, format string
+
+	var convertImageCmd = &cobra.Command{
+		Use:   "convert-image",
+		Short: "convert image of EVE for cloud and virtualization platforms",
+		Long: `Convert qcow2 image of EVE into raw (GCP), fixed VHD (Azure), streamOptimized VMDK (VMware, AWS)
+or OVA (VirtualBox, VMware) with size aligned as required by the platform.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ConvertImage(input, output, format); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	convertImageCmd.Flags().StringVarP(&input, "input", "i", "", "image to convert (image of EVE of context if not set)")
+	convertImageCmd.Flags().StringVarP(&output, "output", "o", "", "file to save converted image (input with extension of format if not set)")
+	convertImageCmd.Flags().StringVarP(&format, "format", "f", "",
+		"format of image: raw, gcp, vhd, vmdk or ova (chosen by devmodel for GCP and VBox if not set)")
+
+	return convertImageCmd
+}
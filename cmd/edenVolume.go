@@ -4,7 +4,6 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -42,7 +41,7 @@ func newVolumeLsCmd() *cobra.Command {
 		Short: "List volumes",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.VolumeLs(outputFormat); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -67,7 +66,7 @@ func newVolumeCreateCmd() *cobra.Command {
 			err := openEVEC.VolumeCreate(appLink, registry, diskSize, volumeName,
 				volumeType, datastoreOverride, sftpLoad, directLoad)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -92,7 +91,7 @@ func newVolumeDeleteCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			volumeName := args[0]
 			if err := openEVEC.VolumeDelete(volumeName); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -109,7 +108,7 @@ func newVolumeDetachCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			volumeName := args[0]
 			if err := openEVEC.VolumeDetach(volumeName); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -132,7 +131,7 @@ func newVolumeAttachCmd() *cobra.Command {
 			}
 
 			if err := openEVEC.VolumeAttach(appName, volumeName, mountPoint); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
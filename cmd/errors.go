@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+)
+
+// fatal logs err and terminates eden with exit code of category of err
+func fatal(err error) {
+	exit(err, err.Error())
+}
+
+// fatalf logs message and terminates eden with exit code of category
+// of the last error in args
+func fatalf(format string, args ...interface{}) {
+	var err error
+	for _, arg := range args {
+		if e, ok := arg.(error); ok {
+			err = e
+		}
+	}
+	exit(err, fmt.Sprintf(format, args...))
+}
+
+// exit logs message with category of err, so it can be matched in logs
+// with JSON format, and terminates eden with exit code of category
+func exit(err error, message string) {
+	code := openevec.CodeOf(err)
+	entry := log.NewEntry(log.StandardLogger())
+	if code != openevec.ErrorCodeGeneric {
+		entry = entry.WithFields(log.Fields{"code": code.Name, "exit_code": code.ExitCode})
+	}
+	entry.Log(log.FatalLevel, message)
+	log.StandardLogger().Exit(code.ExitCode)
+}
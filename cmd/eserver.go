@@ -23,7 +23,7 @@ func newEserverCmd(configName, verbosity *string) *cobra.Command {
 			Message: "Basic Commands",
 			Commands: []*cobra.Command{
 				newStartEserverCmd(cfg),
-				newStopEserverCmd(),
+				newStopEserverCmd(cfg),
 				newStatusEserverCmd(cfg),
 			},
 		},
@@ -43,11 +43,11 @@ func newStartEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			command, err := os.Executable()
 			if err != nil {
-				log.Fatalf("cannot obtain executable path: %s", err)
+				fatalf("cannot obtain executable path: %s", err)
 			}
 			log.Infof("Executable path: %s", command)
 
-			if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist,
+			if err := eden.StartEServer(cfg.Containers().EServer, cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist,
 				cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 				log.Errorf("cannot start eserver: %s", err)
 			} else {
@@ -64,7 +64,7 @@ func newStartEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	return startEserverCmd
 }
 
-func newStopEserverCmd() *cobra.Command {
+func newStopEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var eServerRm bool
 
 	var stopEserverCmd = &cobra.Command{
@@ -72,7 +72,7 @@ func newStopEserverCmd() *cobra.Command {
 		Short: "stop eserver",
 		Long:  `Stop eserver.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := eden.StopEServer(eServerRm); err != nil {
+			if err := eden.StopEServer(cfg.Containers().EServer, eServerRm); err != nil {
 				log.Errorf("cannot stop eserver: %s", err)
 			}
 		},
@@ -89,7 +89,7 @@ func newStatusEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "status of eserver",
 		Long:  `Status of eserver.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			statusEServer, err := eden.StatusEServer()
+			statusEServer, err := eden.StatusEServer(cfg.Containers().EServer)
 			if err != nil {
 				log.Errorf("cannot obtain status of eserver: %s", err)
 			} else {
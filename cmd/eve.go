@@ -6,11 +6,14 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
+	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
 )
 
 func newEveCmd(configName, verbosity *string) *cobra.Command {
@@ -34,6 +37,9 @@ func newEveCmd(configName, verbosity *string) *cobra.Command {
 				newVersionEveCmd(),
 				newEpochEveCmd(),
 				newLinkEveCmd(cfg),
+				newNetdumpEveCmd(cfg),
+				newSnapshotEveCmd(),
+				newRemoteAccessEveCmd(),
 			},
 		},
 	}
@@ -54,29 +60,36 @@ func swtpmPidFile(cfg *openevec.EdenSetupArgs) string {
 
 func newStartEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var vmName, tapInterface string
+	var dryRun bool
 
 	var startEveCmd = &cobra.Command{
 		Use:   "start",
 		Short: "start eve",
 		Long:  `Start eve.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.StartEve(vmName, tapInterface); err != nil {
-				log.Fatal(err)
+			utils.SetDryRun(dryRun)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.StartEve(ctx, vmName, tapInterface); err != nil {
+				fatal(err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	startEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	startEveCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print commands to start EVE without running them")
 
 	startEveCmd.Flags().StringVarP(&cfg.Eve.ImageFile, "image-file", "", "", "path for image drive (required)")
 	startEveCmd.Flags().StringVarP(&cfg.Eve.Arch, "eve-arch", "", runtime.GOARCH, "arch of system")
 	startEveCmd.Flags().StringVarP(&cfg.Eve.QemuOS, "eve-os", "", runtime.GOOS, "os to run on")
 	startEveCmd.Flags().BoolVarP(&cfg.Eve.Accel, "eve-accel", "", cfg.Eve.Accel, "use acceleration")
+	startEveCmd.Flags().StringVarP(&cfg.Eve.TCG, "eve-tcg", "", cfg.Eve.TCG, "TCG emulation of EVE instead of acceleration (auto, on or off)")
+	startEveCmd.Flags().StringVarP(&cfg.Eve.Accelerator, "eve-accelerator", "", cfg.Eve.Accelerator, "accelerator of EVE (auto, kvm, hvf, whpx or tcg)")
 	startEveCmd.Flags().StringVarP(&cfg.Eve.Serial, "eve-serial", "", cfg.Eve.Serial, "SMBIOS serial")
 	startEveCmd.Flags().StringVarP(&cfg.Eve.QemuConfigPath, "qemu-config", "", filepath.Join(currentPath, defaults.DefaultDist, "qemu.conf"), "config file to use")
 	startEveCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
@@ -99,15 +112,17 @@ func newStopEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "stop eve",
 		Long:  `Stop eve.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.StopEve(vmName); err != nil {
-				log.Fatal(err)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.StopEve(ctx, vmName); err != nil {
+				fatal(err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	stopEveCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
@@ -123,7 +138,7 @@ func newVersionEveCmd() *cobra.Command {
 		Long:  `Version of eve.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.VersionEve(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -140,14 +155,14 @@ func newStatusEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Long:  `Status of eve.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.StatusEve(vmName); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	statusEveCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
@@ -170,21 +185,53 @@ func newIpEveCmd() *cobra.Command {
 }
 
 func newConsoleEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
-	var host string
+	var host, listen, logDir string
+	var web, all bool
+	var nodes []string
 
 	var consoleEveCmd = &cobra.Command{
 		Use:   "console",
 		Short: "telnet into eve",
-		Long:  `Telnet into eve.`,
+		Long: `Telnet into eve.
+With --web serves page with terminal connected to console of EVE with WebSocket on --listen address,
+so console can be opened in browser and shared, all opened pages show the same console.
+With --all attaches to consoles of EVE of all contexts (or of contexts set with --nodes) in multiplexed
+console: Ctrl-B n/p or Ctrl-B 1-9 switch between nodes, Ctrl-B [ and Ctrl-B ] scroll output of node,
+Ctrl-B d detaches. Output of every node is written into <node>.log in --log-dir.`,
 		Run: func(cmd *cobra.Command, args []string) {
+			if web && (all || len(nodes) > 0) {
+				fatalf("--web cannot be used with --all or --nodes")
+			}
+			if all || len(nodes) > 0 {
+				ctx, stop := interruptContext(cmd)
+				defer stop()
+				if err := openEVEC.ConsoleEveAll(ctx, host, nodes, logDir); err != nil {
+					fatal(err)
+				}
+				return
+			}
+			if web {
+				ctx, stop := interruptContext(cmd)
+				defer stop()
+				if err := openEVEC.ConsoleEveWeb(ctx, host, listen); err != nil {
+					fatal(err)
+				}
+				return
+			}
 			if err := openEVEC.ConsoleEve(host); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
 
 	consoleEveCmd.Flags().StringVarP(&host, "eve-host", "", defaults.DefaultEVEHost, "IP of eve")
 	consoleEveCmd.Flags().IntVarP(&cfg.Eve.TelnetPort, "eve-telnet-port", "", defaults.DefaultTelnetPort, "Port for telnet access")
+	consoleEveCmd.Flags().BoolVar(&web, "web", false, "serve console in browser instead of telnet")
+	consoleEveCmd.Flags().StringVar(&listen, "listen", defaults.DefaultWebConsoleListen, "address to serve page of console with --web")
+	consoleEveCmd.Flags().BoolVar(&all, "all", false, "attach to consoles of EVE of all contexts in multiplexed console")
+	consoleEveCmd.Flags().StringSliceVar(&nodes, "nodes", nil, "contexts of EVE nodes to attach to in multiplexed console")
+	consoleEveCmd.Flags().StringVar(&logDir, "log-dir", "", "directory for logs of consoles of nodes (temporary directory if not set)")
+	_ = consoleEveCmd.RegisterFlagCompletionFunc("nodes", completeList(completeContexts))
 
 	return consoleEveCmd
 }
@@ -200,14 +247,14 @@ func newSshEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 				commandToRun = strings.Join(args, " ")
 			}
 			if err := openEVEC.SSHEve(commandToRun); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
 
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	sshEveCmd.Flags().StringVarP(&cfg.Eden.SSHKey, "ssh-key", "", filepath.Join(currentPath, defaults.DefaultCertsDist, "id_rsa"), "file to use for ssh access")
@@ -216,31 +263,54 @@ func newSshEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 }
 
 func newOnboardEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var wait bool
+	var timeout time.Duration
+
 	var onboardEveCmd = &cobra.Command{
 		Use:   "onboard",
 		Short: "OnBoard EVE in Adam",
-		Long:  `Adding an EVE onboarding certificate to Adam and waiting for EVE to register.`,
+		Long: `Adding an EVE onboarding certificate to Adam and waiting for EVE to register.
+With --wait stages of onboarding are reported and diagnostics are printed if EVE is not onboarded in --timeout.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.OnboardEve(cfg.Eve.CertsUUID); err != nil {
-				log.Fatalf("Eve onboard failed: %s", err)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if wait {
+				if err := openEVEC.OnboardEveWait(ctx, cfg.Eve.CertsUUID, timeout); err != nil {
+					fatalf("Eve onboard failed: %s", err)
+				}
+				return
+			}
+			if err := openEVEC.OnboardEve(ctx, cfg.Eve.CertsUUID); err != nil {
+				fatalf("Eve onboard failed: %s", err)
 			}
 		},
 	}
 
+	onboardEveCmd.Flags().BoolVar(&wait, "wait", false, "watch controller and report stages of onboarding")
+	onboardEveCmd.Flags().DurationVar(&timeout, "timeout", 0, "time to wait for onboarding with --wait (timeouts.onboard of config if 0)")
+
 	return onboardEveCmd
 }
 
 func newResetEveCmd() *cobra.Command {
+	var disk bool
+
 	var resetEveCmd = &cobra.Command{
 		Use:   "reset",
 		Short: "Reset EVE to initial config",
+		Long: `Reset EVE to initial config.
+With --disk EVE VM is restarted on new overlay of pristine image and onboarded again.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.ResetEve(); err != nil {
-				log.Fatalf("EVE reset failed: %s", err)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.ResetEve(ctx, disk); err != nil {
+				fatalf("EVE reset failed: %s", err)
 			}
 		},
 	}
 
+	resetEveCmd.Flags().BoolVar(&disk, "disk", false, "recreate disk of EVE VM from pristine image (QEMU with eve.qemu.overlay only)")
+
 	return resetEveCmd
 }
 
@@ -252,7 +322,7 @@ func newEpochEveCmd() *cobra.Command {
 		Short: "Set new epoch of EVE",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.NewEpochEve(eveConfigFromFile); err != nil {
-				log.Fatalf("EVE new epoch failed: %s", err)
+				fatalf("EVE new epoch failed: %s", err)
 			}
 		},
 	}
@@ -262,20 +332,57 @@ func newEpochEveCmd() *cobra.Command {
 	return epochEveCmd
 }
 
+func newNetdumpEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var outputDir string
+	var last int
+	var trigger bool
+	var interval, timeout time.Duration
+
+	var netdumpEveCmd = &cobra.Command{
+		Use:   "netdump",
+		Short: "download and decode network debug tarballs of EVE",
+		Long: `Download network debug tarballs (netdumps) published by EVE, unpack them
+and print summary of interface states, DNS results and controller connectivity attempts.
+With --trigger EVE is configured to publish netdump more often until new netdump appears.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.EdenNetdump(ctx, outputDir, last, trigger, interval, timeout); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	currentPath, err := os.Getwd()
+	if err != nil {
+		fatal(err)
+	}
+
+	netdumpEveCmd.Flags().StringVarP(&outputDir, "output", "o", filepath.Join(currentPath, "netdump"), "directory to save netdumps into")
+	netdumpEveCmd.Flags().IntVar(&last, "last", 1, "number of the newest netdumps to download (0 for all)")
+	netdumpEveCmd.Flags().BoolVar(&trigger, "trigger", false, "wait for EVE to publish new netdump")
+	netdumpEveCmd.Flags().DurationVar(&interval, "interval", time.Minute, "netdump publishing interval to set on EVE with --trigger")
+	netdumpEveCmd.Flags().DurationVar(&timeout, "timeout", 0, "timeout for new netdump with --trigger (timeouts.netdump of config if 0)")
+	netdumpEveCmd.Flags().StringVarP(&cfg.Eden.SSHKey, "ssh-key", "", filepath.Join(currentPath, defaults.DefaultCertsDist, "id_rsa"), "file to use for ssh access")
+
+	return netdumpEveCmd
+}
+
 func newLinkEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var eveInterfaceName, vmName string
 
 	var linkEveCmd = &cobra.Command{
-		Use:   "link up|down|status",
-		Short: "manage EVE interface link state",
-		Long:  `Manage EVE interface link state. Supported for QEMU and VirtualBox.`,
+		Use:       "link up|down|status",
+		Short:     "manage EVE interface link state",
+		ValidArgs: []string{"up", "down", "status"},
+		Long:      `Manage EVE interface link state. Supported for QEMU and VirtualBox.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			command := "status"
 			if len(args) > 0 {
 				command = args[0]
 			}
 			if err := openEVEC.NewLinkEve(command, eveInterfaceName, vmName); err != nil {
-				log.Fatalf("EVE new link failed: %s", err)
+				fatalf("EVE new link failed: %s", err)
 			}
 		},
 	}
@@ -283,6 +390,124 @@ func newLinkEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	linkEveCmd.Flags().IntVarP(&cfg.Eve.QemuConfig.MonitorPort, "qemu-monitor-port", "", defaults.DefaultQemuMonitorPort, "Port for access to QEMU monitor")
 	linkEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "name of the EVE VBox VM")
 	linkEveCmd.Flags().StringVarP(&eveInterfaceName, "interface-name", "i", "", "EVE interface to get/change the link state of")
+	_ = linkEveCmd.RegisterFlagCompletionFunc("interface-name", completeEveInterfaces)
 
 	return linkEveCmd
 }
+
+func newSnapshotEveCmd() *cobra.Command {
+	var snapshotEveCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "manage snapshots of EVE VM",
+		Long: `Manage snapshots of state of EVE VM (disks and memory) together with config of device in controller.
+Restore of snapshot is much faster than reboot and onboarding of EVE. Supported for QEMU with qcow2 disks.`,
+	}
+
+	snapshotName := func(args []string) string {
+		if len(args) > 0 {
+			return args[0]
+		}
+		return defaults.DefaultEveSnapshot
+	}
+
+	snapshotEveCmd.AddCommand(&cobra.Command{
+		Use:   "save [name]",
+		Short: "save snapshot of EVE VM",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SnapshotSaveEve(snapshotName(args)); err != nil {
+				fatalf("EVE snapshot save failed: %s", err)
+			}
+		},
+	}, &cobra.Command{
+		Use:   "restore [name]",
+		Short: "restore EVE VM from snapshot",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.SnapshotRestoreEve(ctx, snapshotName(args)); err != nil {
+				fatalf("EVE snapshot restore failed: %s", err)
+			}
+		},
+	}, &cobra.Command{
+		Use:   "delete [name]",
+		Short: "delete snapshot of EVE VM",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SnapshotDeleteEve(snapshotName(args)); err != nil {
+				fatalf("EVE snapshot delete failed: %s", err)
+			}
+		},
+	}, &cobra.Command{
+		Use:   "list",
+		Short: "list snapshots of EVE VM",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SnapshotListEve(); err != nil {
+				fatalf("EVE snapshot list failed: %s", err)
+			}
+		},
+	})
+
+	return snapshotEveCmd
+}
+
+func newRemoteAccessEveCmd() *cobra.Command {
+	var sshKey string
+	var timeout time.Duration
+	var outputFormat types.OutputFormat
+
+	var remoteAccessEveCmd = &cobra.Command{
+		Use:   "remote-access",
+		Short: "manage remote access to EVE",
+		Long: `Enable or disable remote access to EVE (ssh, console of EVE and VNC consoles of apps) as a group of
+config items, verify that EVE acknowledged them and log changes into artifacts of the current context.`,
+	}
+
+	remoteAccessEnable := &cobra.Command{
+		Use:   "enable",
+		Short: "enable remote access to EVE",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.RemoteAccessSet(ctx, true, sshKey, timeout); err != nil {
+				fatalf("remote access enable failed: %s", err)
+			}
+		},
+	}
+	remoteAccessEnable.Flags().StringVar(&sshKey, "ssh-key", "", "public key file for ssh access (key of eden by default)")
+
+	remoteAccessDisable := &cobra.Command{
+		Use:   "disable",
+		Short: "disable remote access to EVE",
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.RemoteAccessSet(ctx, false, "", timeout); err != nil {
+				fatalf("remote access disable failed: %s", err)
+			}
+		},
+	}
+
+	for _, command := range []*cobra.Command{remoteAccessEnable, remoteAccessDisable} {
+		command.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "time to wait for EVE to acknowledge changes, 0 to not wait")
+	}
+
+	remoteAccessStatus := &cobra.Command{
+		Use:   "status",
+		Short: "show state of remote access to EVE",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.RemoteAccessStatus(outputFormat); err != nil {
+				fatalf("remote access status failed: %s", err)
+			}
+		},
+	}
+	remoteAccessStatus.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print, supports: lines, json")
+
+	remoteAccessEveCmd.AddCommand(remoteAccessEnable, remoteAccessDisable, remoteAccessStatus)
+
+	return remoteAccessEveCmd
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newEveCacheCmd() *cobra.Command {
+	var eveCacheCmd = &cobra.Command{
+		Use:   "eve-cache",
+		Short: "manage cache of EVE images",
+		Long: `Manage cache of EVE images, UEFI and installers generated during setup.
+Entries are keyed by version and hash of content of EVE docker image, config directory and options,
+so setup of contexts and branches with the same EVE reuses them.`,
+	}
+
+	eveCacheCmd.AddCommand(newEveCacheListCmd())
+	eveCacheCmd.AddCommand(newEveCachePruneCmd())
+
+	return eveCacheCmd
+}
+
+func newEveCacheListCmd() *cobra.Command {
+	var eveCacheListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list entries of cache of EVE images",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EveCacheList(); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	return eveCacheListCmd
+}
+
+func newEveCachePruneCmd() *cobra.Command {
+	var olderThan time.Duration
+	var all bool
+
+	var eveCachePruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "remove entries of cache of EVE images not used recently",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if all {
+				olderThan = 0
+			}
+			if err := openEVEC.EveCachePrune(olderThan); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	eveCachePruneCmd.Flags().DurationVar(&olderThan, "older-than", 30*24*time.Hour, "remove entries not used for this duration")
+	eveCachePruneCmd.Flags().BoolVar(&all, "all", false, "remove all entries")
+
+	return eveCachePruneCmd
+}
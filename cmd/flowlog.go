@@ -3,7 +3,6 @@ package cmd
 import (
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -23,7 +22,7 @@ func newNetStatCmd(configName, verbosity *string) *cobra.Command {
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdenNetStat(outputFormat, follow, logTail, printFields, args); err != nil {
-				log.Fatalf("Setup eden failed: %s", err)
+				fatalf("Setup eden failed: %s", err)
 			}
 		},
 	}
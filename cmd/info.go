@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/lf-edge/eden/pkg/controller/types"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -10,27 +12,82 @@ import (
 func newInfoCmd() *cobra.Command {
 	var outputFormat types.OutputFormat
 	var infoTail uint
-	var follow bool
-	var printFields []string
+	var follow, offline bool
+	var printFields, filters []string
 
 	var infoCmd = &cobra.Command{
 		Use:   "info [field:regexp ...]",
 		Short: "Get information reports from a running EVE device",
 		Long:  ` Scans the ADAM Info for correspondence with regular expressions requests to json fields.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenInfo(outputFormat, infoTail, follow, printFields, args); err != nil {
-				log.Fatal("Eden info failed ", err)
+			if err := openEVEC.EdenInfo(outputFormat, infoTail, follow, offline, printFields, filters, args); err != nil {
+				fatalf("Eden info failed %s", err)
 			}
 		},
 	}
 
 	infoCmd.Flags().UintVar(&infoTail, "tail", 0, "Show only last N lines")
 	infoCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
+	infoCmd.Flags().BoolVar(&offline, "offline", false, "Use data saved locally by previous online commands instead of controller")
 	infoCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	infoCmd.Flags().StringArrayVar(&filters, "filter", nil,
+		"JSONPath-like expression to select fields from JSON form of info message (e.g. '.dinfo.network[].IPAddrs'). "+
+			"With --follow only changes of selected values are printed.")
 
 	infoCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+
+	infoCmd.AddCommand(newInfoDiffCmd())
+
 	return infoCmd
 }
+
+func newInfoDiffCmd() *cobra.Command {
+	var last uint
+	var since, until string
+	var showTimestamps bool
+
+	var infoDiffCmd = &cobra.Command{
+		Use:   "diff [field:regexp ...]",
+		Short: "Show differences between consecutive info messages",
+		Long: `Compares consecutive info messages about the same object (device, app, network instance, volume...)
+and prints changed, added and removed fields. Timestamp fields are hidden by default.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			sinceTime, err := parseTimeFlag(since)
+			if err != nil {
+				fatalf("Bad --since: %s", err)
+			}
+			untilTime, err := parseTimeFlag(until)
+			if err != nil {
+				fatalf("Bad --until: %s", err)
+			}
+			if err := openEVEC.EdenInfoDiff(last, sinceTime, untilTime, showTimestamps, args); err != nil {
+				fatalf("Eden info diff failed %s", err)
+			}
+		},
+	}
+
+	infoDiffCmd.Flags().UintVar(&last, "last", 2, "Compare last N info messages of every object")
+	infoDiffCmd.Flags().StringVar(&since, "since", "", "Compare messages starting from time (RFC3339 or duration ago, e.g. 10m)")
+	infoDiffCmd.Flags().StringVar(&until, "until", "", "Compare messages till time (RFC3339 or duration ago, e.g. 5m)")
+	infoDiffCmd.Flags().BoolVar(&showTimestamps, "show-timestamps", false, "Do not hide timestamp fields")
+
+	return infoDiffCmd
+}
+
+// parseTimeFlag parses time in RFC3339 format or as a duration back from now
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC3339 time nor duration", value)
+	}
+	return time.Now().Add(-d), nil
+}
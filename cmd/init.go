@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newInitCmd() *cobra.Command {
+	var interactive, force, allocatePorts bool
+
+	currentPath, err := os.Getwd()
+	if err != nil {
+		fatal(err)
+	}
+	cfg, err := openevec.GetDefaultConfig(currentPath)
+	if err != nil {
+		fatalf("Failed to generate default config %v\n", err)
+	}
+
+	var initCmd = &cobra.Command{
+		Use:   "init [name]",
+		Short: "Generate config context",
+		Long: `Generate config context with defined name ('default' by default) as 'eden config add' does.
+With --interactive asks for devmodel, version and arch of EVE, resources of VM, SDN and TPM,
+validating answers against capabilities of host, and writes them into config of context.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeFirstArg(completeContexts),
+		// config of context may not exist yet
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			configName := "default"
+			if len(args) > 0 {
+				configName = args[0]
+			}
+			if _, err := os.Stat(utils.GetConfig(configName)); err == nil && !force {
+				fatalf("config of context %s already exists, use --force to overwrite it", configName)
+			}
+			if interactive {
+				if err := openevec.ConfigWizard(cfg, os.Stdin, os.Stdout); err != nil {
+					fatal(err)
+				}
+			}
+			if err := addContext(cfg, configName, "", force, allocatePorts, false); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	initCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "ask for settings of context")
+	initCmd.Flags().BoolVar(&force, "force", false, "force overwrite config file")
+	initCmd.Flags().BoolVar(&allocatePorts, "allocate-ports", true, "reserve ports not used by other workspaces on host in port registry")
+
+	return initCmd
+}
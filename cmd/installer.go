@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newInstallerCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+
+	var installerCmd = &cobra.Command{
+		Use:               "installer",
+		Short:             "boot and write EVE installer",
+		Long:              `Boot EVE installer over network or write it for installation on devices.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newInstallerNetbootCmd(),
+				newInstallerUSBCmd(),
+			},
+		},
+	}
+
+	groups.AddTo(installerCmd)
+
+	return installerCmd
+}
+
+func newInstallerNetbootCmd() *cobra.Command {
+	var serverIP, bootloader string
+
+	var netbootCmd = &cobra.Command{
+		Use:   "netboot",
+		Short: "run netboot server for EVE installer",
+		Long: `Run proxy DHCP and TFTP servers to boot EVE installer prepared with 'eden setup --netboot'
+on VMs and physical devices in the network with PXE or iPXE. Addresses are leased by DHCP server
+of the network, iPXE script and EVE artifacts are served by eserver. Root privileges are required.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.InstallerNetboot(ctx, serverIP, bootloader); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	netbootCmd.Flags().StringVar(&serverIP, "ip", "", "IP of netboot server announced to clients (adam.eve-ip if not set)")
+	netbootCmd.Flags().StringVar(&bootloader, "bootloader", "", "iPXE bootloader for PXE clients (downloaded from boot.ipxe.org if not set)")
+
+	return netbootCmd
+}
+
+func newInstallerUSBCmd() *cobra.Command {
+	var output string
+	var conf utils.InstallerConfig
+	var force bool
+
+	var usbCmd = &cobra.Command{
+		Use:   "usb",
+		Short: "write EVE installer to USB image or stick",
+		Long: `Generate installer of EVE with config of context and write it into image file or USB stick
+(block device). Writing into device requires confirmation (or --force), devices which are mounted
+or used by LVM, LUKS or RAID are refused. Controller, certificates and network config of the installer can be overridden
+to produce artifacts for provisioning of devices in the field.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.InstallerUSB(output, conf, force); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	usbCmd.Flags().StringVarP(&output, "output", "o", "installer-usb.raw", "image file or USB device (e.g. /dev/sdb) to write installer into")
+	usbCmd.Flags().StringVar(&conf.Server, "server", "", "FQDN and port of controller (server of context if not set)")
+	usbCmd.Flags().StringVar(&conf.RootCert, "root-cert", "", "root certificate of controller")
+	usbCmd.Flags().StringVar(&conf.DeviceCert, "device-cert", "", "certificate of device (DevID)")
+	usbCmd.Flags().StringVar(&conf.DeviceKey, "device-key", "", "key of device (DevID)")
+	usbCmd.Flags().StringVar(&conf.NetConf, "net-conf", "", "DevicePortConfig to save as override.json (eve.usbnetconf-file if not set)")
+	usbCmd.Flags().StringVar(&conf.Port, "port", "eth0", "management interface of device")
+	usbCmd.Flags().StringVar(&conf.StaticIP, "static-ip", "", "static IP of management interface in CIDR notation (DHCP if not set)")
+	usbCmd.Flags().StringVar(&conf.Gateway, "gateway", "", "gateway for static IP")
+	usbCmd.Flags().StringSliceVar(&conf.DNSServers, "dns", nil, "DNS servers for static IP")
+	usbCmd.Flags().BoolVar(&force, "force", false, "write into device without confirmation, even if it is not removable")
+	usbCmd.Flags().StringVar(&conf.WPASupplicant, "wpa-supplicant", "", "wpa_supplicant.conf with WiFi networks for wlan0")
+
+	return usbCmd
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"github.com/lf-edge/eden/pkg/controller/types"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -24,7 +23,7 @@ func newLogCmd() *cobra.Command {
 		Long:  ` Scans the ADAM logs for correspondence with regular expressions requests to json fields.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdenLog(outputFormat, follow, logTail, printFields, args); err != nil {
-				log.Fatalf("Log eden failed: %s", err)
+				fatalf("Log eden failed: %s", err)
 			}
 		},
 	}
@@ -1,9 +1,10 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
@@ -23,7 +24,7 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.EdenMetric(outputFormat, follow, metricTail, printFields, args); err != nil {
-				log.Fatalf("Metric eden failed: %s", err)
+				fatalf("Metric eden failed: %s", err)
 			}
 		},
 	}
@@ -36,5 +37,35 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+
+	metricCmd.AddCommand(newMetricAlertCmd())
+
 	return metricCmd
 }
+
+func newMetricAlertCmd() *cobra.Command {
+	var rulesFile string
+	var timeout time.Duration
+
+	var metricAlertCmd = &cobra.Command{
+		Use:   "alert",
+		Short: "Evaluate alert rules against incoming metrics",
+		Long: `Evaluates threshold rules from yaml file against incoming metrics of EVE.
+Every rule has expression in form '<path> <operator> <number>' (e.g. '.dm.memory.usedPercentage > 80'),
+duration the condition must hold ('for', e.g. 5m) and action: log, webhook or fail.
+Rule with fail action stops the command with non-zero exit code, so it can fail a running test.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.EdenMetricAlert(ctx, rulesFile, timeout); err != nil {
+				fatalf("Metric alert: %s", err)
+			}
+		},
+	}
+
+	metricAlertCmd.Flags().StringVar(&rulesFile, "rules", "", "yaml file with alert rules")
+	metricAlertCmd.Flags().DurationVar(&timeout, "timeout", 0, "stop evaluation after timeout, 0 to run forever")
+	_ = metricAlertCmd.MarkFlagRequired("rules")
+
+	return metricAlertCmd
+}
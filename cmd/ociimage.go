@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"github.com/lf-edge/eden/pkg/defaults"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -19,8 +18,10 @@ func newOciImageCmd() *cobra.Command {
 		Short: "do oci image manipulations",
 		Long:  `Do oci image manipulations.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.OciImage(fileToSave, image, registry, isLocal); err != nil {
-				log.Fatal(err)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.OciImage(ctx, fileToSave, image, registry, isLocal); err != nil {
+				fatal(err)
 			}
 		},
 	}
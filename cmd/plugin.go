@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newPluginCmd() *cobra.Command {
+	var pluginCmd = &cobra.Command{
+		Use:   "plugin",
+		Short: "work with plugins",
+		Long: `Work with plugins.
+Any executable named eden-<name> on PATH runs as 'eden <name>' with all arguments after the name,
+dashes in name define nested commands (eden-foo-bar runs as 'eden foo bar').
+Built-in commands cannot be overridden. Context of eden (or one set with --config) is passed in
+environment variables EDEN_CONFIG, EDEN_CONFIG_FILE, EDEN_HOME, EDEN_BIN, EDEN_PROG and EDEN_VERSION,
+and in JSON file set in EDEN_CONTEXT_DUMP.`,
+	}
+
+	pluginCmd.AddCommand(newPluginListCmd())
+
+	return pluginCmd
+}
+
+func newPluginListCmd() *cobra.Command {
+	var pluginListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List plugins found on PATH",
+		Args:  cobra.NoArgs,
+		// plugins do not need config
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, plugin := range openevec.ListPlugins() {
+				name := strings.TrimPrefix(filepath.Base(plugin), openevec.PluginPrefix)
+				fmt.Printf("%s\t%s\n", strings.ReplaceAll(name, "-", " "), plugin)
+			}
+		},
+	}
+
+	return pluginListCmd
+}
+
+// pluginConfigName returns value of --config flag in args of plugin
+func pluginConfigName(args []string) string {
+	for i, arg := range args {
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			return value
+		}
+	}
+	return defaults.DefaultContext
+}
+
+// runPlugin runs plugin for args if they do not select built-in command of rootCmd,
+// exit code of plugin is returned if plugin is found
+func runPlugin(rootCmd *cobra.Command, args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch args[0] {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return 0, false
+	}
+	if cmd, _, err := rootCmd.Find(args); err == nil && cmd != rootCmd {
+		return 0, false
+	}
+	plugin, consumed := openevec.FindPlugin(args)
+	if plugin == "" {
+		return 0, false
+	}
+	var pluginCtx *openevec.PluginContext
+	// plugins may not need context, so they run without it if config cannot be loaded
+	if cfg, err := openevec.FromViper(pluginConfigName(args), log.InfoLevel.String()); err != nil {
+		log.Debugf("plugin runs without context: %s", err)
+	} else if pluginCtx, err = openevec.CreateOpenEVEC(cfg).PluginContext(); err != nil {
+		log.Debugf("plugin runs without context: %s", err)
+	}
+	code, err := openevec.RunPlugin(plugin, args[consumed:], pluginCtx)
+	if err != nil {
+		fatal(err)
+	}
+	return code, true
+}
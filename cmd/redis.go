@@ -23,8 +23,8 @@ func newRedisCmd(configName, verbosity *string) *cobra.Command {
 			Message: "Basic Commands",
 			Commands: []*cobra.Command{
 				newStartRedisCmd(cfg),
-				newStopRedisCmd(),
-				newStatusRedisCmd(),
+				newStopRedisCmd(cfg),
+				newStatusRedisCmd(cfg),
 			},
 		},
 	}
@@ -42,10 +42,10 @@ func newStartRedisCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			command, err := os.Executable()
 			if err != nil {
-				log.Fatalf("cannot obtain executable path: %s", err)
+				fatalf("cannot obtain executable path: %s", err)
 			}
 			log.Infof("Executable path: %s", command)
-			if err := eden.StartRedis(cfg.Redis.Port, cfg.Redis.Dist, cfg.Redis.Force, cfg.Redis.Tag,
+			if err := eden.StartRedis(cfg.Containers().Redis, cfg.Redis.Port, cfg.Redis.Dist, cfg.Redis.Force, cfg.Redis.Tag,
 				cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 				log.Errorf("cannot start redis: %s", err)
 			} else {
@@ -62,7 +62,7 @@ func newStartRedisCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	return startRedisCmd
 }
 
-func newStopRedisCmd() *cobra.Command {
+func newStopRedisCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var redisRm bool
 
 	var stopRedisCmd = &cobra.Command{
@@ -70,7 +70,7 @@ func newStopRedisCmd() *cobra.Command {
 		Short: "stop redis",
 		Long:  `Stop redis.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := eden.StopRedis(redisRm); err != nil {
+			if err := eden.StopRedis(cfg.Containers().Redis, redisRm); err != nil {
 				log.Errorf("cannot stop redis: %s", err)
 			}
 		},
@@ -80,13 +80,13 @@ func newStopRedisCmd() *cobra.Command {
 	return stopRedisCmd
 }
 
-func newStatusRedisCmd() *cobra.Command {
+func newStatusRedisCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var statusRedisCmd = &cobra.Command{
 		Use:   "status",
 		Short: "status of redis",
 		Long:  `Status of redis.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			statusRedis, err := eden.StatusRedis()
+			statusRedis, err := eden.StatusRedis(cfg.Containers().Redis)
 			if err != nil {
 				log.Errorf("cannot obtain status of redis: %s", err)
 			} else {
@@ -22,8 +22,8 @@ func newRegistryCmd(configName, verbosity *string) *cobra.Command {
 			Message: "Basic Commands",
 			Commands: []*cobra.Command{
 				newStartRegistryCmd(cfg),
-				newStopRegistryCmd(),
-				newStatusRegistryCmd(),
+				newStopRegistryCmd(cfg),
+				newStatusRegistryCmd(cfg),
 				newLoadRegistryCmd(cfg),
 			},
 		},
@@ -41,7 +41,7 @@ func newStartRegistryCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Long:  `Start OCI/docker registry.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.RegistryStart(); err != nil {
-				log.Fatalf("Registry start failed %s", err)
+				fatalf("Registry start failed %s", err)
 			}
 		},
 	}
@@ -53,7 +53,7 @@ func newStartRegistryCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	return startRegistryCmd
 }
 
-func newStopRegistryCmd() *cobra.Command {
+func newStopRegistryCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var registryRm bool
 
 	var stopRegistryCmd = &cobra.Command{
@@ -61,7 +61,7 @@ func newStopRegistryCmd() *cobra.Command {
 		Short: "stop registry",
 		Long:  `Stop OCI/docker registry.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := eden.StopRegistry(registryRm); err != nil {
+			if err := eden.StopRegistry(cfg.Containers().Registry, registryRm); err != nil {
 				log.Errorf("cannot stop registry: %s", err)
 			}
 		},
@@ -72,13 +72,13 @@ func newStopRegistryCmd() *cobra.Command {
 	return stopRegistryCmd
 }
 
-func newStatusRegistryCmd() *cobra.Command {
+func newStatusRegistryCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var statusRegistryCmd = &cobra.Command{
 		Use:   "status",
 		Short: "status of registry",
 		Long:  `Status of OCI/docker registry.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			statusRegistry, err := eden.StatusRegistry()
+			statusRegistry, err := eden.StatusRegistry(cfg.Containers().Registry)
 			if err != nil {
 				log.Errorf("cannot obtain status of registry: %s", err)
 			} else {
@@ -99,7 +99,7 @@ func newLoadRegistryCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			ref := args[0]
 			if err := openEVEC.RegistryLoad(ref); err != nil {
-				log.Fatalf("Load registry failed %s", err)
+				fatalf("Load registry failed %s", err)
 			}
 		},
 	}
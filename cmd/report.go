@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/spf13/cobra"
+)
+
+func newReportCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var output string
+	var results []string
+	var tail uint
+	var pcapDuration time.Duration
+
+	var reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Assemble report bundle of test or demo session",
+		Long: `Assembles a single bundle for a test or demo session suitable for attaching to release sign-off:
+HTML index with results of tests, device info timeline, charts of device metrics and versions of environment,
+together with EVE and SDN console logs, SDN traffic, device config and messages and Adam logs.
+Results of tests are read from JSON files saved next to reports of 'eden test --report'
+or from verbose output of tests. The bundle is an archive if output ends with .tar.gz or .tgz
+and a directory otherwise.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			if output == "" {
+				output = fmt.Sprintf("eden-report-%s.tar.gz", time.Now().Format("20060102-150405"))
+			}
+			if err := openEVEC.EdenReport(output, results, tail, pcapDuration); err != nil {
+				fatalf("Eden report failed: %s", err)
+			}
+		},
+	}
+
+	reportCmd.Flags().StringVarP(&output, "output", "o", "", "archive (.tar.gz or .tgz) or directory to save bundle into (eden-report-<time>.tar.gz by default)")
+	reportCmd.Flags().StringSliceVar(&results, "results", nil, "files with results of tests (JSON saved by eden test --report or verbose output of tests)")
+	reportCmd.Flags().UintVar(&tail, "tail", 1000, "number of the last messages, timeline events and Adam log lines to include (0 for all)")
+	reportCmd.Flags().DurationVar(&pcapDuration, "pcap", 0, "capture traffic inside SDN for this duration (e.g. 30s)")
+
+	return reportCmd
+}
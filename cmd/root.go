@@ -4,8 +4,13 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
 	"reflect"
+	"syscall"
 
+	"github.com/fatih/color"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
 	log "github.com/sirupsen/logrus"
@@ -16,6 +21,7 @@ var openEVEC *openevec.OpenEVEC
 
 func NewEdenCommand() *cobra.Command {
 	var configName, verbosity string
+	var noColor bool
 	cfg := &openevec.EdenSetupArgs{}
 
 	rootCmd := &cobra.Command{
@@ -27,6 +33,7 @@ func NewEdenCommand() *cobra.Command {
 		{
 			Message: "Basic Commands",
 			Commands: []*cobra.Command{
+				newInitCmd(),
 				newSetupCmd(&configName, &verbosity),
 				newStartCmd(&configName, &verbosity),
 				newEveCmd(&configName, &verbosity),
@@ -36,6 +43,9 @@ func NewEdenCommand() *cobra.Command {
 				newCleanCmd(&configName, &verbosity),
 				newConfigCmd(&configName, &verbosity),
 				newSdnCmd(&configName, &verbosity),
+				newDoctorCmd(&configName, &verbosity),
+				newVersionCmd(),
+				newUpgradeCmd(),
 			},
 		},
 		{
@@ -45,6 +55,12 @@ func NewEdenCommand() *cobra.Command {
 				newLogCmd(),
 				newNetStatCmd(&configName, &verbosity),
 				newMetricCmd(&configName, &verbosity),
+				newTopCmd(&configName, &verbosity),
+				newCollectCmd(&configName, &verbosity),
+				newReportCmd(&configName, &verbosity),
+				newChaosCmd(&configName, &verbosity),
+				newSoakCmd(&configName, &verbosity),
+				newApplyCmd(&configName, &verbosity),
 				newAdamCmd(&configName, &verbosity),
 				newRegistryCmd(&configName, &verbosity),
 				newRedisCmd(&configName, &verbosity),
@@ -57,6 +73,8 @@ func NewEdenCommand() *cobra.Command {
 				newDisksCmd(),
 				newPacketCmd(&configName, &verbosity),
 				newRolCmd(&configName, &verbosity),
+				newInstallerCmd(&configName, &verbosity),
+				newPluginCmd(),
 			},
 		},
 	}
@@ -64,7 +82,16 @@ func NewEdenCommand() *cobra.Command {
 	groups.AddTo(rootCmd)
 
 	rootCmd.PersistentFlags().StringVar(&configName, "config", defaults.DefaultContext, "Name of config")
+	_ = rootCmd.RegisterFlagCompletionFunc("config", completeContexts)
 	rootCmd.PersistentFlags().StringVarP(&verbosity, "verbosity", "v", log.InfoLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
+	rootCmd.PersistentFlags().StringVar(&cfg.Log.Format, "log-format", "", "Format of logs (text, json), log.format of config if not set")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colors in output (also disabled if output is not terminal or NO_COLOR is set)")
+	// commands may override persistent pre-run, initializers run for all of them
+	cobra.OnInitialize(func() {
+		if noColor {
+			color.NoColor = true
+		}
+	})
 
 	return rootCmd
 }
@@ -77,13 +104,34 @@ func preRunViperLoadFunction(cfg *openevec.EdenSetupArgs, configName, verbosity
 		}
 		openevec.Merge(reflect.ValueOf(viperCfg).Elem(), reflect.ValueOf(*cfg), cmd.Flags())
 		*cfg = *viperCfg
+		if cmd.Flags().Changed("log-format") {
+			if err := openevec.SetUpLogs(*verbosity, cfg.Log); err != nil {
+				return err
+			}
+		}
 		openEVEC = openevec.CreateOpenEVEC(cfg)
 		return nil
 	}
 }
 
+// interruptContext returns context of cmd cancelled on the first interrupt or termination
+// of eden, so long operations stop cleanly, the next signal terminates eden as usual
+func interruptContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
 // Execute primary function for cobra
 func Execute() {
 	rootCmd := NewEdenCommand()
-	_ = rootCmd.Execute()
+	if code, ok := runPlugin(rootCmd, os.Args[1:]); ok {
+		os.Exit(code)
+	}
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(openevec.CodeOf(err).ExitCode)
+	}
 }
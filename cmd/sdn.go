@@ -8,7 +8,6 @@ import (
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -73,7 +72,7 @@ func newSdnModelGetCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			model, err := openEVEC.SdnNetModelGet()
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			} else {
 				fmt.Println(model)
 			}
@@ -94,7 +93,7 @@ Use string \"default\" instead of a file path to apply the default network model
 		Run: func(cmd *cobra.Command, args []string) {
 			ref := args[0]
 			if err := openEVEC.SdnNetModelApply(ref); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -115,7 +114,7 @@ Alternatively, visualize using the online tool: https://dreampuf.github.io/Graph
 		Run: func(cmd *cobra.Command, args []string) {
 			graph, err := openEVEC.SdnNetConfigGraph()
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			fmt.Println(graph)
 		},
@@ -131,7 +130,7 @@ func newSdnStatusCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.
 				SdnStatus(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -148,7 +147,7 @@ func newSdnSshCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "SSH into the running Eden-SDN VM",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openEVEC.SdnSsh(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -164,7 +163,7 @@ func newSdnLogsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "Get all logs from running Eden-SDN VM",
 		Run: func(cmd *cobra.Command, args []string) {
 			if logs, err := openEVEC.SdnLogs(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			} else {
 				fmt.Println(logs)
 			}
@@ -182,7 +181,7 @@ func newSdnMgmtIPCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "Get IP address assigned to Eden-SDN VM for management",
 		Run: func(cmd *cobra.Command, args []string) {
 			if mgmtIp, err := openEVEC.SdnMgmtIp(); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			} else {
 				fmt.Println(mgmtIp)
 			}
@@ -231,7 +230,7 @@ the EVE's port forwarding capability.`,
 			args = args[2:]
 
 			if err := openEVEC.SdnEpExec(epName, command, args); err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -264,19 +263,20 @@ Note that in this case the command must be installed in Eden-SDN VM (see sdn/Doc
 
 The target interface should be referenced by its name inside the kernel of EVE VM (e.g. "eth0").
 This is currently limited to TCP port forwarding (i.e. not working with UDP)!`,
-		Args: cobra.MinimumNArgs(3),
+		Args:              cobra.MinimumNArgs(3),
+		ValidArgsFunction: completeFirstArg(completeEveInterfaces),
 		Run: func(cmd *cobra.Command, args []string) {
 			eveIfName := args[0]
 			targetPort, err := strconv.Atoi(args[1])
 			if err != nil {
-				log.Fatalf("Failed to parse target port: %v", err)
+				fatalf("Failed to parse target port: %v", err)
 			}
 			command := args[2]
 			args = args[3:]
 
 			err = openEVEC.SdnForwardCmd(sdnFwdFromEp, eveIfName, targetPort, command, args...)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		},
 	}
@@ -292,7 +292,7 @@ This is currently limited to TCP port forwarding (i.e. not working with UDP)!`,
 func addSdnPidOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	parentCmd.Flags().StringVarP(&cfg.Sdn.PidFile, "sdn-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "sdn.pid"), "file for saving SDN pid")
 }
@@ -315,7 +315,7 @@ func addSdnPortOpts(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 func addSdnLogOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	currentPath, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	parentCmd.Flags().StringVarP(&cfg.Sdn.ConsoleLogFile, "sdn-console-log", "", filepath.Join(currentPath, defaults.DefaultDist, "sdn-console.log"), "log file for SDN console output")
 }
@@ -344,3 +344,7 @@ func addSdnIPv6Opt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().BoolVarP(&cfg.Sdn.EnableIPv6, "sdn-enable-ipv6", "", false, "Enable IPv6 connectivity for Eden-SDN")
 	parentCmd.Flags().StringVarP(&cfg.Sdn.IPv6Subnet, "sdn-ipv6-subnet", "", defaults.DefaultSdnIPv6Subnet, "IPv6 subnet to use between Eden-SDN and the host")
 }
+
+func addSdnIPv4Opt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
+	parentCmd.Flags().StringVarP(&cfg.Sdn.IPv4Subnet, "sdn-ipv4-subnet", "", "", "IPv4 subnet to use between Eden-SDN and the host (the free 192.168.x.0/24 subnet of slot of context by default)")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/spf13/cobra"
+)
+
+// parseCheckPeriod parses period of checks in form every=<duration> or <duration>
+func parseCheckPeriod(check string) (time.Duration, error) {
+	period, err := time.ParseDuration(strings.TrimPrefix(check, "every="))
+	if err != nil {
+		return 0, fmt.Errorf("invalid check period %q, expected every=<duration>: %w", check, err)
+	}
+	return period, nil
+}
+
+func newSoakCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	soakArgs := openevec.SoakArgs{}
+	var specFile, check string
+
+	var soakCmd = &cobra.Command{
+		Use:   "soak",
+		Short: "Run longevity test checking invariants periodically",
+		Long: `Deploys workload defined in specification file (if not deployed yet), keeps it running for duration
+and periodically checks invariants (apps are running, memory of EVE is below threshold, EVE does not reboot,
+conditions on metrics). With --redeploy apps of workload are deleted and deployed again periodically
+and checked to become running after every cycle. Timeline of checks is saved into artifacts of the current context.`,
+		Example:           `eden soak --duration 72h --check every=10m -f invariants.yml --rules alerts.yml`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if soakArgs.Check, err = parseCheckPeriod(check); err != nil {
+				fatal(err)
+			}
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.Soak(ctx, specFile, soakArgs); err != nil {
+				fatalf("Soak failed: %s", err)
+			}
+		},
+	}
+
+	soakCmd.Flags().StringVarP(&specFile, "file", "f", "", "yaml file with workload and invariants")
+	soakCmd.Flags().DurationVar(&soakArgs.Duration, "duration", 24*time.Hour, "duration of soak run (0 to run until interrupted)")
+	soakCmd.Flags().StringVar(&check, "check", "every=10m", "period of checks of invariants")
+	soakCmd.Flags().StringVar(&soakArgs.Report, "report", "", "file to save timeline of checks into (inside artifacts of the current context by default)")
+	soakCmd.Flags().StringVar(&soakArgs.Rules, "rules", "", "yaml file with alert rules evaluated against device metrics, rule with fail action stops soak run")
+	soakCmd.Flags().BoolVar(&soakArgs.Cleanup, "cleanup", false, "delete apps of workload after soak run")
+	soakCmd.Flags().DurationVar(&soakArgs.Redeploy, "redeploy", 0, "period of deletion and deployment of apps of workload (0 to keep them deployed)")
+	soakCmd.Flags().DurationVar(&soakArgs.RedeployTimeout, "redeploy-timeout", 10*time.Minute, "time for apps to become running after redeployment")
+	soakCmd.Flags().StringVar(&soakArgs.DiagnosticsAddr, "diagnostics-addr", "", "address to serve pprof and expvar endpoints during soak run")
+	_ = soakCmd.MarkFlagRequired("file")
+
+	return soakCmd
+}
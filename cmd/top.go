@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/spf13/cobra"
+)
+
+func newTopCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var once bool
+
+	var topCmd = &cobra.Command{
+		Use:   "top",
+		Short: "Display resource usage of EVE and its applications",
+		Long: `Displays continuously updated CPU, memory and disk usage of EVE device and CPU and memory usage
+of applications obtained from metric messages. Screen is redrawn on every new metric,
+applications are sorted by keys: c (cpu), m (memory), n (name), r reverses order, q quits.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.EdenTop(ctx, once); err != nil {
+				fatalf("Eden top failed: %s", err)
+			}
+		},
+	}
+
+	topCmd.Flags().BoolVar(&once, "once", false, "print usage from the last metrics and exit")
+
+	return topCmd
+}
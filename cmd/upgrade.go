@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
+)
+
+func newVersionCmd() *cobra.Command {
+	var outputFormat types.OutputFormat
+
+	var versionCmd = &cobra.Command{
+		Use:   "version",
+		Short: "Print version of eden",
+		Long:  "Print version of eden and format of state of contexts it supports.",
+		Args:  cobra.NoArgs,
+		// used by 'eden upgrade' to check new binaries, so does not need config
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.PrintVersion(outputFormat); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	versionCmd.Flags().VarP(
+		enumflag.New(&outputFormat, "output", configOutputFormatIds, enumflag.EnumCaseInsensitive),
+		"output", "o",
+		"Format to print version, supports: lines, json, yaml")
+
+	return versionCmd
+}
+
+func newUpgradeCmd() *cobra.Command {
+	var rollback bool
+	opts := openevec.UpgradeOptions{}
+
+	var upgradeCmd = &cobra.Command{
+		Use:   "upgrade [version]",
+		Short: "Upgrade eden to release",
+		Long: `Download release of eden with defined version (the latest one by default), verify its checksum,
+its signature against the key pinned into eden (or set with --public-key) and that it can read state of the current context (or one set with --config) and replace the binary of eden with it.
+The previous binary is kept and can be restored with --rollback.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if rollback {
+				if err := openevec.EdenRollback(opts.Executable); err != nil {
+					fatal(err)
+				}
+				return
+			}
+			if len(args) > 0 {
+				opts.Version = args[0]
+			}
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if err := openEVEC.EdenUpgrade(ctx, opts); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	upgradeCmd.Flags().BoolVar(&rollback, "rollback", false, "restore binary of eden replaced by the last upgrade")
+	upgradeCmd.Flags().StringVar(&opts.SHA256, "sha256", "", "expected checksum of archive of release (checksum published with release by default)")
+	upgradeCmd.Flags().StringVar(&opts.PublicKey, "public-key", "", "base64 DER of ed25519 key to verify signature of release (the key pinned into eden by default)")
+	upgradeCmd.Flags().StringVar(&opts.Repo, "repo", defaults.DefaultEdenRepo, "GitHub repository with releases of eden")
+	upgradeCmd.Flags().StringVar(&opts.GitHubURL, "github-url", defaults.DefaultGitHubURL, "address of GitHub")
+	upgradeCmd.Flags().StringVar(&opts.APIURL, "github-api-url", defaults.DefaultGitHubAPIURL, "address of GitHub API")
+	upgradeCmd.Flags().StringVar(&opts.Executable, "executable", "", "binary of eden to replace (the running one by default)")
+	upgradeCmd.Flags().BoolVar(&opts.Force, "force", false, "install release even if it is the current one or cannot read state of context")
+
+	return upgradeCmd
+}
@@ -0,0 +1,68 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+// gcsUploader uploads objects into Google Cloud Storage
+type gcsUploader struct {
+	cfg     Config
+	storage *storage.Service
+}
+
+func newGCSUploader(ctx context.Context, cfg Config) (*gcsUploader, error) {
+	var opts []option.ClientOption
+	if cfg.Credentials != "" {
+		jsonKey, err := os.ReadFile(cfg.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		config, err := google.JWTConfigFromJSON(jsonKey, storage.DevstorageReadWriteScope)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse key of service account: %w", err)
+		}
+		client := config.Client(ctx)
+		client.Timeout = uploadTimeout
+		opts = append(opts, option.WithHTTPClient(client))
+	} else {
+		client, err := google.DefaultClient(ctx, storage.DevstorageReadWriteScope)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get application default credentials: %w", err)
+		}
+		client.Timeout = uploadTimeout
+		opts = append(opts, option.WithHTTPClient(client))
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+	service, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &gcsUploader{cfg: cfg, storage: service}, nil
+}
+
+// URL returns URL of object in console of Google Cloud Storage
+func (g *gcsUploader) URL(name string) string {
+	return fmt.Sprintf("https://storage.cloud.google.com/%s/%s", g.cfg.Bucket, escapePath(name))
+}
+
+// Upload uploads file into object with name
+func (g *gcsUploader) Upload(ctx context.Context, file, name string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := g.storage.Objects.Insert(g.cfg.Bucket, &storage.Object{Name: name}).
+		Media(f).Context(ctx).Do(); err != nil {
+		return "", err
+	}
+	return g.URL(name), nil
+}
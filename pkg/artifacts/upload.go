@@ -0,0 +1,136 @@
+// Package artifacts uploads artifacts of tests (logs, reports, captures and
+// snapshots) into object storage to share them outside of the host running tests.
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Providers of object storage
+const (
+	ProviderS3  = "s3"
+	ProviderGCS = "gcs"
+)
+
+// uploadTimeout limits request to object storage including transfer of file,
+// it is large enough to upload snapshots of disk of EVE
+const uploadTimeout = 30 * time.Minute
+
+// Config defines object storage to upload artifacts into
+type Config struct {
+	// Provider is s3 or gcs
+	Provider string
+	Bucket   string
+	// Prefix is prepended to names of uploaded objects
+	Prefix string
+	// Endpoint of S3-compatible storage, AWS is used if empty
+	Endpoint string
+	// Region of S3 bucket, us-east-1 is used if empty
+	Region string
+	// Credentials is path to file with credentials: shared credentials file
+	// of AWS for s3 or key of service account for gcs, if empty credentials
+	// are taken from environment
+	Credentials string
+}
+
+// Uploader uploads files into object storage
+type Uploader interface {
+	// Upload uploads file into object with name and returns URL of the object
+	Upload(ctx context.Context, file, name string) (string, error)
+	// URL returns URL of object with name
+	URL(name string) string
+}
+
+// NewUploader creates Uploader for provider defined in config
+func NewUploader(ctx context.Context, cfg Config) (Uploader, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is not defined")
+	}
+	switch cfg.Provider {
+	case ProviderS3:
+		return newS3Uploader(cfg)
+	case ProviderGCS:
+		return newGCSUploader(ctx, cfg)
+	}
+	return nil, fmt.Errorf("not supported provider %q, use %s or %s", cfg.Provider, ProviderS3, ProviderGCS)
+}
+
+// Uploaded describes local path uploaded into storage
+type Uploaded struct {
+	Path string
+	// URL of object for file or of the common prefix of objects for directory
+	URL   string
+	Files int
+}
+
+// UploadPath uploads file or directory (recursively) into objects with
+// name or names beginning with name followed by relative paths of files
+func UploadPath(ctx context.Context, uploader Uploader, p, name string) (Uploaded, error) {
+	return UploadPathSince(ctx, uploader, p, name, time.Time{})
+}
+
+// UploadPathSince uploads files as UploadPath, but skips files of directory
+// which were not modified since the given time
+func UploadPathSince(ctx context.Context, uploader Uploader, p, name string, since time.Time) (Uploaded, error) {
+	uploaded := Uploaded{Path: p}
+	info, err := os.Stat(p)
+	if err != nil {
+		return uploaded, err
+	}
+	if !info.IsDir() {
+		if uploaded.URL, err = uploader.Upload(ctx, p, name); err != nil {
+			return uploaded, fmt.Errorf("cannot upload %s: %w", p, err)
+		}
+		uploaded.Files = 1
+		return uploaded, nil
+	}
+	uploaded.URL = uploader.URL(name + "/")
+	err = filepath.Walk(p, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || info.ModTime().Before(since) {
+			return nil
+		}
+		rel, err := filepath.Rel(p, file)
+		if err != nil {
+			return err
+		}
+		if _, err := uploader.Upload(ctx, file, path.Join(name, filepath.ToSlash(rel))); err != nil {
+			return fmt.Errorf("cannot upload %s: %w", file, err)
+		}
+		uploaded.Files++
+		return nil
+	})
+	return uploaded, err
+}
+
+// escapePath escapes segments of object name for use in URL
+func escapePath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode encodes string as required by AWS signature version 4
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -1,11 +1,11 @@
 package adam
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
-	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -29,12 +29,6 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-const (
-	mimeProto     = "application/x-proto-binary"
-	mimeTextPlain = "text/plain"
-	mimeJSON      = "application/json"
-)
-
 // Ctx stores controller settings
 type Ctx struct {
 	dir               string
@@ -47,6 +41,7 @@ type Ctx struct {
 	AdamCaching       bool   // enable caching of adam`s logs/info
 	AdamCachingRedis  bool   // caching to redis instead of files
 	AdamCachingPrefix string // custom prefix for file or stream naming for cache
+	AdamOfflineDir    string // directory with offline device twin to load logs/info/metrics from instead of adam
 }
 
 // parseRedisURL try to use string from config to obtain redis url
@@ -74,6 +69,16 @@ func parseRedisURL(s string) (addr, password string, databaseID int, err error)
 
 // getLoader return loader object from Adam`s config
 func (adam *Ctx) getLoader() (loader loaders.Loader) {
+	if adam.AdamOfflineDir != "" {
+		log.Debug("will use offline twin loader")
+		dirGetters := types.DirGetters{
+			LogsGetter:    adam.getLogsDirOffline,
+			InfoGetter:    adam.getInfoDirOffline,
+			MetricsGetter: adam.getMetricsDirOffline,
+			RequestGetter: adam.getRequestDirOffline,
+		}
+		return loaders.NewFileLoader(dirGetters)
+	}
 	if adam.AdamRemote {
 		log.Debug("will use remote adam loader")
 		if adam.AdamRemoteRedis {
@@ -89,7 +94,12 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 				StreamRequest: adam.getRequestRedisStream,
 				StreamApps:    adam.getAppsLogsRedisStream,
 			}
-			loader = loaders.NewRedisLoader(addr, password, databaseID, streamGetters)
+			redisLoader := loaders.NewRedisLoader(addr, password, databaseID, streamGetters)
+			if group := os.Getenv(defaults.DefaultRedisGroupEnv); group != "" {
+				hostname, _ := os.Hostname()
+				redisLoader.SetConsumerGroup(group, fmt.Sprintf("%s-%d", hostname, os.Getpid()))
+			}
+			loader = redisLoader
 		} else {
 			urlGetters := types.URLGetters{
 				URLLogs:    adam.getLogsURL,
@@ -149,6 +159,7 @@ func (adam *Ctx) InitWithVars(vars *utils.ConfigVars) error {
 	adam.AdamCachingRedis = vars.AdamCachingRedis
 	adam.AdamCachingPrefix = vars.AdamCachingPrefix
 	adam.AdamRedisURLEden = vars.AdamRedisURLEden
+	adam.AdamOfflineDir = vars.AdamOfflineDir
 	return nil
 }
 
@@ -178,35 +189,42 @@ func (adam *Ctx) Register(device *device.Ctx) error {
 		log.Printf("error encoding json: %v", err)
 		return err
 	}
-	return adam.postObj("/admin/onboard", body, mimeJSON)
+	_, err = adam.api().CreateOnboard(context.Background(), body)
+	return err
 }
 
 // DeviceList return device list
 func (adam *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err error) {
 	if filter == types.RegisteredDeviceFilter || filter == types.AllDevicesFilter {
-		return adam.getList("/admin/device", mimeJSON)
+		list, err := adam.api().ListDevices(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return strings.Fields(string(list)), nil
 	}
 	return []string{}, nil
 }
 
 // ConfigSet set config for devID
 func (adam *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
-	return adam.putObj(path.Join("/admin/device", devUUID.String(), "config"), devConfig, mimeProto)
+	_, err = adam.api().SetDeviceConfig(context.Background(), devUUID.String(), devConfig)
+	return err
 }
 
 // ConfigGet get config for devID
 func (adam *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
-	return adam.getObj(path.Join("/admin/device", devUUID.String(), "config"), mimeProto)
+	config, err := adam.api().GetDeviceConfig(context.Background(), devUUID.String())
+	return string(config), err
 }
 
 // GetECDHCert get cert for ECDH exchange for devID
 func (adam *Ctx) GetECDHCert(devUUID uuid.UUID) ([]byte, error) {
-	attestData, err := adam.getObj(path.Join("/admin/device", devUUID.String(), "certs"), mimeJSON)
+	attestData, err := adam.api().GetDeviceCerts(context.Background(), devUUID.String())
 	if err != nil {
 		return nil, fmt.Errorf("cannot get attestation certificates from cloud for %s", devUUID)
 	}
 	req := &types.Zcerts{}
-	if err := json.Unmarshal([]byte(attestData), req); err != nil {
+	if err := json.Unmarshal(attestData, req); err != nil {
 		return nil, fmt.Errorf("cannot unmarshal attest: %w", err)
 	}
 	var devCert []byte
@@ -228,6 +246,11 @@ func (adam *Ctx) RequestLastCallback(devUUID uuid.UUID, q map[string]string, han
 	return erequest.RequestLast(loader, q, handler)
 }
 
+// RequestChecker check requests by pattern from existence records with RequestLast and use RequestWatch with timeout for observe new records
+func (adam *Ctx) RequestChecker(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc, mode erequest.RequestCheckerMode, timeout time.Duration) (err error) {
+	return erequest.RequestChecker(adam.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
 // LogAppsChecker check app logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
 func (adam *Ctx) LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error) {
 	return eapps.LogChecker(adam.getLoader(), devUUID, appUUID, q, handler, mode, timeout)
@@ -270,6 +293,20 @@ func (adam *Ctx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler ein
 	return einfo.InfoChecker(adam.getLoader(), devUUID, q, handler, mode, timeout)
 }
 
+// InfoWatch processes new info messages by pattern with handler until it returns true, ctx is done or reading fails
+func (adam *Ctx) InfoWatch(ctx context.Context, devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
+	loader := adam.getLoader()
+	loader.SetUUID(devUUID)
+	return einfo.InfoWatchContext(ctx, loader, q, handler)
+}
+
+// LogWatch processes new logs by pattern with handler until it returns true, ctx is done or reading fails
+func (adam *Ctx) LogWatch(ctx context.Context, devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc) (err error) {
+	loader := adam.getLoader()
+	loader.SetUUID(devUUID)
+	return elog.LogWatchContext(ctx, loader, q, handler)
+}
+
 // InfoLastCallback check info by pattern from existence files with callback
 func (adam *Ctx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
 	var loader = adam.getLoader()
@@ -282,6 +319,13 @@ func (adam *Ctx) MetricChecker(devUUID uuid.UUID, q map[string]string, handler e
 	return emetric.MetricChecker(adam.getLoader(), devUUID, q, handler, mode, timeout)
 }
 
+// MetricWatch processes new metrics by pattern with handler until it returns true, ctx is done or reading fails
+func (adam *Ctx) MetricWatch(ctx context.Context, devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
+	loader := adam.getLoader()
+	loader.SetUUID(devUUID)
+	return emetric.MetricWatchContext(ctx, loader, q, handler)
+}
+
 // MetricLastCallback check metrics by pattern from existence files with callback
 func (adam *Ctx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
 	var loader = adam.getLoader()
@@ -291,22 +335,24 @@ func (adam *Ctx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, hand
 
 // OnboardRemove remove onboard by onboardUUID
 func (adam *Ctx) OnboardRemove(onboardUUID string) (err error) {
-	return adam.deleteObj(path.Join("/admin/onboard", onboardUUID))
+	_, err = adam.api().DeleteOnboard(context.Background(), onboardUUID)
+	return err
 }
 
 // DeviceRemove remove device by devUUID
 func (adam *Ctx) DeviceRemove(devUUID uuid.UUID) (err error) {
-	return adam.deleteObj(path.Join("/admin/device", devUUID.String()))
+	_, err = adam.api().DeleteDevice(context.Background(), devUUID.String())
+	return err
 }
 
 // DeviceGetOnboard get device onboardUUID for devUUID
 func (adam *Ctx) DeviceGetOnboard(devUUID uuid.UUID) (onboardUUID uuid.UUID, err error) {
 	var devCert types.DeviceCert
-	devInfo, err := adam.getObj(path.Join("/admin/device", devUUID.String()), mimeJSON)
+	devInfo, err := adam.api().GetDevice(context.Background(), devUUID.String())
 	if err != nil {
 		return uuid.Nil, err
 	}
-	if err = json.Unmarshal([]byte(devInfo), &devCert); err != nil {
+	if err = json.Unmarshal(devInfo, &devCert); err != nil {
 		return uuid.Nil, err
 	}
 	cert, err := utils.ParseFirstCertFromBlock(devCert.Onboard)
@@ -357,17 +403,17 @@ func (adam *Ctx) DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID,
 			return uuid.Nil, err
 		}
 	}
-	return uuid.Nil, fmt.Errorf("no device found")
+	return uuid.Nil, types.ErrDeviceNotFound
 }
 
 // GetDeviceCert gets deviceCert contains certificates and serial
 func (adam *Ctx) GetDeviceCert(device *device.Ctx) (deviceCert *types.DeviceCert, err error) {
-	devInfo, err := adam.getObj(path.Join("/admin/device", device.GetID().String()), mimeJSON)
+	devInfo, err := adam.api().GetDevice(context.Background(), device.GetID().String())
 	if err != nil {
 		return nil, err
 	}
 	var devCert types.DeviceCert
-	if err = json.Unmarshal([]byte(devInfo), &devCert); err != nil {
+	if err = json.Unmarshal(devInfo, &devCert); err != nil {
 		return nil, err
 	}
 	return &devCert, nil
@@ -379,7 +425,8 @@ func (adam *Ctx) UploadDeviceCert(deviceCert types.DeviceCert) error {
 	if err != nil {
 		return err
 	}
-	return adam.postObj("/admin/device", body, mimeTextPlain)
+	_, err = adam.api().CreateDevice(context.Background(), body)
+	return err
 }
 
 // SetDeviceOptions sets options for provided devUUID
@@ -388,17 +435,18 @@ func (adam *Ctx) SetDeviceOptions(devUUID uuid.UUID, options *types.DeviceOption
 	if err != nil {
 		return err
 	}
-	return adam.putObj(path.Join("/admin/device", devUUID.String(), "options"), body, mimeJSON)
+	_, err = adam.api().SetDeviceOptions(context.Background(), devUUID.String(), body)
+	return err
 }
 
 // GetDeviceOptions returns DeviceOptions for provided devUUID
 func (adam *Ctx) GetDeviceOptions(devUUID uuid.UUID) (*types.DeviceOptions, error) {
-	devInfo, err := adam.getObj(path.Join("/admin/device", devUUID.String(), "options"), mimeJSON)
+	devInfo, err := adam.api().GetDeviceOptions(context.Background(), devUUID.String())
 	if err != nil {
 		return nil, err
 	}
 	var devOptions types.DeviceOptions
-	if err = json.Unmarshal([]byte(devInfo), &devOptions); err != nil {
+	if err = json.Unmarshal(devInfo, &devOptions); err != nil {
 		return nil, err
 	}
 	return &devOptions, nil
@@ -410,17 +458,18 @@ func (adam *Ctx) SetGlobalOptions(options *types.GlobalOptions) error {
 	if err != nil {
 		return err
 	}
-	return adam.putObj("/admin/options", body, mimeJSON)
+	_, err = adam.api().SetGlobalOptions(context.Background(), body)
+	return err
 }
 
 // GetGlobalOptions returns global options from controller
 func (adam *Ctx) GetGlobalOptions() (*types.GlobalOptions, error) {
-	devInfo, err := adam.getObj("/admin/options", mimeJSON)
+	devInfo, err := adam.api().GetGlobalOptions(context.Background())
 	if err != nil {
 		return nil, err
 	}
 	var globalOptions types.GlobalOptions
-	if err = json.Unmarshal([]byte(devInfo), &globalOptions); err != nil {
+	if err = json.Unmarshal(devInfo, &globalOptions); err != nil {
 		return nil, err
 	}
 	return &globalOptions, nil
@@ -428,12 +477,12 @@ func (adam *Ctx) GetGlobalOptions() (*types.GlobalOptions, error) {
 
 // SigningCertGet gets signing certificate from Adam
 func (adam *Ctx) SigningCertGet() (signCert []byte, err error) {
-	certsData, err := adam.getObj("/api/v2/edgedevice/certs", mimeProto)
+	certsData, err := adam.api().GetControllerCerts(context.Background())
 	if err != nil {
 		return nil, err
 	}
 	zcloudMsg := &auth.AuthContainer{}
-	err = proto.Unmarshal([]byte(certsData), zcloudMsg)
+	err = proto.Unmarshal(certsData, zcloudMsg)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,94 @@
+// Package api is the client of Adam admin API used by eden. The API is described
+// in openapi.yaml and methods of Client are generated from it into client.gen.go,
+// so any change of the API must be made in the specification and the client must
+// be regenerated with go generate.
+package api
+
+//go:generate go run ../../../openapi/openapi-gen -spec openapi.yaml -package api -out client.gen.go
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Spec is OpenAPI specification of Adam admin API
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// Doer sends request and returns response of server
+type Doer func(req *http.Request) (*http.Response, error)
+
+// Client calls operations of Adam admin API on server
+type Client struct {
+	server string
+	doer   Doer
+}
+
+// StatusError is returned if server responds to operation with unsuccessful status
+type StatusError struct {
+	Operation  string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: status code: %d", e.Operation, e.StatusCode)
+}
+
+// NewClient returns client of Adam with server URL which sends requests with doer,
+// http.DefaultClient is used if doer is nil
+func NewClient(server string, doer Doer) *Client {
+	if doer == nil {
+		doer = http.DefaultClient.Do
+	}
+	return &Client{server: server, doer: doer}
+}
+
+// resolve returns URL of path p on server
+func (c *Client) resolve(p string) (string, error) {
+	ref, err := url.Parse(p)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse path %s: %w", p, err)
+	}
+	base, err := url.Parse(c.server)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse server URL %s: %w", c.server, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// do sends request of operation op and returns body of successful response
+func (c *Client) do(ctx context.Context, op, method, u, accept, contentType string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to create new http request: %w", op, err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.doer(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to send request: %w", op, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to read response from %s: %w", op, u, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &StatusError{Operation: op, StatusCode: resp.StatusCode}
+	}
+	return data, nil
+}
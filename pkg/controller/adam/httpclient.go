@@ -1,22 +1,40 @@
 package adam
 
 import (
-	"bytes"
 	"crypto/tls"
 	"crypto/x509"
-	"fmt"
-	"io"
 	"net/http"
 	"os"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/adam/api"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// httpClientKey identifies TLS settings of http client
+type httpClientKey struct {
+	serverCA    string
+	insecureTLS bool
+}
+
+var (
+	httpClientsMu sync.Mutex
+	// httpClients are shared by all controllers of process with the same TLS settings,
+	// so connections to controller are reused instead of opening new ones for every call
+	httpClients = map[httpClientKey]*http.Client{}
+)
+
 // http client with correct config
 func (adam *Ctx) getHTTPClient() *http.Client {
+	key := httpClientKey{serverCA: adam.serverCA, insecureTLS: adam.insecureTLS}
+	httpClientsMu.Lock()
+	defer httpClientsMu.Unlock()
+	if client, ok := httpClients[key]; ok {
+		return client
+	}
 	tlsConfig := &tls.Config{}
 	if adam.serverCA != "" {
 		caCert, err := os.ReadFile(adam.serverCA)
@@ -36,122 +54,21 @@ func (adam *Ctx) getHTTPClient() *http.Client {
 			TLSClientConfig:       tlsConfig,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ResponseHeaderTimeout: 10 * time.Second,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          defaults.DefaultControllerMaxIdleConns,
+			MaxIdleConnsPerHost:   defaults.DefaultControllerMaxIdleConns,
+			IdleConnTimeout:       defaults.DefaultControllerIdleConnTimeout,
 		},
 	}
+	httpClients[key] = client
 	return client
 }
 
-func (adam *Ctx) deleteObj(path string) (err error) {
-	u, err := utils.ResolveURL(adam.url, path)
-	if err != nil {
-		log.Printf("error constructing URL: %v", err)
-		return err
-	}
+// api returns client of Adam admin API which sends requests with shared http client
+// and repeats them on failures
+func (adam *Ctx) api() *api.Client {
 	client := adam.getHTTPClient()
-	req, err := http.NewRequest("DELETE", u, nil)
-	if err != nil {
-		return fmt.Errorf("unable to create new http request: %v", err)
-	}
-
-	response, err := utils.RepeatableAttempt(client, req)
-	if err != nil {
-		return fmt.Errorf("unable to send request: %v", err)
-	}
-	if response.StatusCode != http.StatusOK {
-		return fmt.Errorf("status code: %d", response.StatusCode)
-	}
-	return nil
-}
-
-func (adam *Ctx) getObj(path string, acceptMime string) (out string, err error) {
-	u, err := utils.ResolveURL(adam.url, path)
-	if err != nil {
-		log.Printf("error constructing URL: %v", err)
-		return "", err
-	}
-	client := adam.getHTTPClient()
-	req, err := http.NewRequest("GET", u, nil)
-	if err != nil {
-		log.Fatalf("unable to create new http request: %v", err)
-	}
-	if acceptMime != "" {
-		req.Header.Set("Accept", acceptMime)
-	}
-
-	response, err := utils.RepeatableAttempt(client, req)
-	if err != nil {
-		log.Fatalf("unable to send request: %v", err)
-	}
-	buf, err := io.ReadAll(response.Body)
-	if err != nil {
-		log.Printf("unable to read data from URL %s: %v", u, err)
-		return "", err
-	}
-	return string(buf), nil
-}
-
-func (adam *Ctx) getList(path string, acceptMime string) (out []string, err error) {
-	u, err := utils.ResolveURL(adam.url, path)
-	if err != nil {
-		log.Printf("error constructing URL: %v", err)
-		return nil, err
-	}
-	client := adam.getHTTPClient()
-	req, err := http.NewRequest("GET", u, nil)
-	if err != nil {
-		log.Fatalf("unable to create new http request: %v", err)
-	}
-	if acceptMime != "" {
-		req.Header.Set("Accept", acceptMime)
-	}
-
-	response, err := utils.RepeatableAttempt(client, req)
-	if err != nil {
-		log.Fatalf("unable to send request: %v", err)
-	}
-	buf, err := io.ReadAll(response.Body)
-	if err != nil {
-		log.Printf("unable to read data from URL %s: %v", u, err)
-		return nil, err
-	}
-	return strings.Fields(string(buf)), nil
-}
-
-func (adam *Ctx) postObj(path string, obj []byte, mimeType string) (err error) {
-	u, err := utils.ResolveURL(adam.url, path)
-	if err != nil {
-		log.Printf("error constructing URL: %v", err)
-		return err
-	}
-	client := adam.getHTTPClient()
-	req, err := http.NewRequest("POST", u, bytes.NewBuffer(obj))
-	if err != nil {
-		log.Fatalf("unable to create new http request: %v", err)
-	}
-	req.Header.Set("Content-Type", mimeType)
-
-	_, err = utils.RepeatableAttempt(client, req)
-	if err != nil {
-		log.Fatalf("unable to send request: %v", err)
-	}
-	return nil
-}
-
-func (adam *Ctx) putObj(path string, obj []byte, mimeType string) (err error) {
-	u, err := utils.ResolveURL(adam.url, path)
-	if err != nil {
-		log.Printf("error constructing URL: %v", err)
-		return err
-	}
-	client := adam.getHTTPClient()
-	req, err := http.NewRequest("PUT", u, bytes.NewBuffer(obj))
-	if err != nil {
-		log.Fatalf("unable to create new http request: %v", err)
-	}
-	req.Header.Set("Content-Type", mimeType)
-	_, err = utils.RepeatableAttempt(client, req)
-	if err != nil {
-		log.Fatalf("unable to send request: %v", err)
-	}
-	return nil
+	return api.NewClient(adam.url, func(req *http.Request) (*http.Response, error) {
+		return utils.RepeatableAttempt(client, req)
+	})
 }
@@ -5,7 +5,6 @@ import (
 	"path"
 
 	"github.com/lf-edge/eden/pkg/defaults"
-	"github.com/lf-edge/eden/pkg/utils"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -104,6 +103,26 @@ func (adam *Ctx) getRequestDirCache(devUUID uuid.UUID) (dir string) {
 	return path.Join(adam.dir, adam.AdamCachingPrefix, devUUID.String(), "requests")
 }
 
+//getLogsDirOffline return logs directory for devUUID inside offline twin
+func (adam *Ctx) getLogsDirOffline(devUUID uuid.UUID) (dir string) {
+	return path.Join(adam.AdamOfflineDir, devUUID.String(), "logs")
+}
+
+//getInfoDirOffline return info directory for devUUID inside offline twin
+func (adam *Ctx) getInfoDirOffline(devUUID uuid.UUID) (dir string) {
+	return path.Join(adam.AdamOfflineDir, devUUID.String(), "info")
+}
+
+//getMetricsDirOffline return metrics directory for devUUID inside offline twin
+func (adam *Ctx) getMetricsDirOffline(devUUID uuid.UUID) (dir string) {
+	return path.Join(adam.AdamOfflineDir, devUUID.String(), "metrics")
+}
+
+//getRequestDirOffline return request directory for devUUID inside offline twin
+func (adam *Ctx) getRequestDirOffline(devUUID uuid.UUID) (dir string) {
+	return path.Join(adam.AdamOfflineDir, devUUID.String(), "requests")
+}
+
 //getLogsDir return logs directory for devUUID
 func (adam *Ctx) getLogsDir(devUUID uuid.UUID) (dir string) {
 	return path.Join(adam.dir, "run", "adam", "device", devUUID.String(), "logs")
@@ -126,36 +145,36 @@ func (adam *Ctx) getRequestDir(devUUID uuid.UUID) (dir string) {
 
 //getLogsURL return logs url for devUUID
 func (adam *Ctx) getLogsURL(devUUID uuid.UUID) string {
-	resURL, err := utils.ResolveURL(adam.url, path.Join("/admin/device", devUUID.String(), "logs"))
+	resURL, err := adam.api().GetDeviceLogsURL(devUUID.String())
 	if err != nil {
-		log.Fatalf("ResolveURL: %s", err)
+		log.Fatalf("GetDeviceLogsURL: %s", err)
 	}
 	return resURL
 }
 
 //getInfoURL return info url for devUUID
 func (adam *Ctx) getInfoURL(devUUID uuid.UUID) string {
-	resURL, err := utils.ResolveURL(adam.url, path.Join("/admin/device", devUUID.String(), "info"))
+	resURL, err := adam.api().GetDeviceInfoURL(devUUID.String())
 	if err != nil {
-		log.Fatalf("ResolveURL: %s", err)
+		log.Fatalf("GetDeviceInfoURL: %s", err)
 	}
 	return resURL
 }
 
 //getMetricsURL return metrics url for devUUID
 func (adam *Ctx) getMetricsURL(devUUID uuid.UUID) string {
-	resURL, err := utils.ResolveURL(adam.url, path.Join("/admin/device", devUUID.String(), "metrics"))
+	resURL, err := adam.api().GetDeviceMetricsURL(devUUID.String())
 	if err != nil {
-		log.Fatalf("ResolveURL: %s", err)
+		log.Fatalf("GetDeviceMetricsURL: %s", err)
 	}
 	return resURL
 }
 
 //getRequestURL return request url for devUUID
 func (adam *Ctx) getRequestURL(devUUID uuid.UUID) string {
-	resURL, err := utils.ResolveURL(adam.url, path.Join("/admin/device", devUUID.String(), "requests"))
+	resURL, err := adam.api().GetDeviceRequestsURL(devUUID.String())
 	if err != nil {
-		log.Fatalf("ResolveURL: %s", err)
+		log.Fatalf("GetDeviceRequestsURL: %s", err)
 	}
 	return resURL
 }
@@ -1,9 +1,11 @@
 package cachers
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/go-redis/redis/v9"
 	"github.com/lf-edge/eden/pkg/controller/types"
@@ -16,6 +18,15 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// savedReadCount is number of messages read at once while loading timestamps of stream
+const savedReadCount = 1000
+
+// timestampKey identifies object saved into stream
+type timestampKey struct {
+	seconds int64
+	nanos   int32
+}
+
 // RedisCache object provides caching objects from controller into redis
 type RedisCache struct {
 	addr          string
@@ -23,6 +34,11 @@ type RedisCache struct {
 	databaseID    int
 	streamGetters types.StreamGetters
 	client        *redis.Client
+
+	mu sync.Mutex
+	// saved are timestamps of objects of streams, every stream is read and decoded
+	// once instead of comparing every new object with all objects of stream
+	saved map[string]map[timestampKey]struct{}
 }
 
 // NewRedisCache creates new RedisCache with provided settings
@@ -32,6 +48,7 @@ func NewRedisCache(addr string, password string, databaseID int, streamGetters t
 		password:      password,
 		databaseID:    databaseID,
 		streamGetters: streamGetters,
+		saved:         make(map[string]map[timestampKey]struct{}),
 	}
 }
 
@@ -45,8 +62,72 @@ func (cacher *RedisCache) newRedisClient() (*redis.Client, error) {
 	return client, err
 }
 
+// objectTimestamp returns timestamp of object of typeToProcess
+func objectTimestamp(typeToProcess types.LoaderObjectType, data []byte) (timestampKey, error) {
+	var itemTimeStamp *timestamppb.Timestamp
+	switch typeToProcess {
+	case types.LogsType:
+		var emp logs.LogBundle
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return timestampKey{}, err
+		}
+		itemTimeStamp = emp.Timestamp
+	case types.InfoType:
+		var emp info.ZInfoMsg
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return timestampKey{}, err
+		}
+		itemTimeStamp = emp.AtTimeStamp
+	case types.MetricsType:
+		var emp metrics.ZMetricMsg
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return timestampKey{}, err
+		}
+		itemTimeStamp = emp.AtTimeStamp
+	default:
+		return timestampKey{}, fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+	return timestampKey{seconds: itemTimeStamp.GetSeconds(), nanos: itemTimeStamp.GetNanos()}, nil
+}
+
+// savedTimestamps returns timestamps of objects of stream, they are read in batches on the first call
+func (cacher *RedisCache) savedTimestamps(stream string, typeToProcess types.LoaderObjectType) (map[timestampKey]struct{}, error) {
+	if saved, ok := cacher.saved[stream]; ok {
+		return saved, nil
+	}
+	saved := make(map[timestampKey]struct{})
+	for start := "-"; ; {
+		rr, err := cacher.client.XRangeN(context.Background(), stream, start, "+", savedReadCount).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rr {
+			object, ok := r.Values["object"].(string)
+			if !ok {
+				continue
+			}
+			key, err := objectTimestamp(typeToProcess, []byte(object))
+			if err != nil {
+				return nil, err
+			}
+			saved[key] = struct{}{}
+		}
+		if len(rr) < savedReadCount {
+			break
+		}
+		// start after the last read message
+		splitted := strings.Split(rr[len(rr)-1].ID, "-")
+		counter, _ := strconv.Atoi(splitted[1])
+		start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
+	}
+	cacher.saved[stream] = saved
+	return saved, nil
+}
+
 // CheckAndSave process LoaderObjectType from data
 func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) (err error) {
+	cacher.mu.Lock()
+	defer cacher.mu.Unlock()
 	if cacher.client == nil {
 		if cacher.client, err = cacher.newRedisClient(); err != nil {
 			return err
@@ -54,63 +135,26 @@ func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.Lo
 	}
 
 	var streamToWrite string
-	var itemTimeStamp *timestamppb.Timestamp
-	var buf bytes.Buffer
-	buf.Write(data)
 	switch typeToProcess {
 	case types.LogsType:
 		streamToWrite = cacher.streamGetters.StreamLogs(devUUID)
-		var emp logs.LogBundle
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.Timestamp
 	case types.InfoType:
 		streamToWrite = cacher.streamGetters.StreamInfo(devUUID)
-		var emp info.ZInfoMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
 	case types.MetricsType:
 		streamToWrite = cacher.streamGetters.StreamMetrics(devUUID)
-		var emp metrics.ZMetricMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
 	default:
 		return fmt.Errorf("not implemented type %d", typeToProcess)
 	}
-	rr, err := cacher.client.XRange(context.Background(), streamToWrite, "-", "+").Result()
+	key, err := objectTimestamp(typeToProcess, data)
 	if err != nil {
 		return err
 	}
-	for _, r := range rr {
-		switch typeToProcess {
-		case types.LogsType:
-			var buf bytes.Buffer
-			buf.Write([]byte(r.Values["object"].(string)))
-			var emp logs.LogBundle
-			if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-				return err
-			}
-			if emp.Timestamp.GetSeconds() == itemTimeStamp.GetSeconds() && emp.Timestamp.GetNanos() == itemTimeStamp.GetNanos() {
-				return
-			}
-		case types.InfoType:
-			var buf bytes.Buffer
-			buf.Write([]byte(r.Values["object"].(string)))
-			var emp info.ZInfoMsg
-			if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-				return err
-			}
-			if emp.AtTimeStamp.GetSeconds() == itemTimeStamp.GetSeconds() && emp.AtTimeStamp.GetNanos() == itemTimeStamp.GetNanos() {
-				return
-			}
-		default:
-			return fmt.Errorf("not implemented type %d", typeToProcess)
-		}
+	saved, err := cacher.savedTimestamps(streamToWrite, typeToProcess)
+	if err != nil {
+		return err
+	}
+	if _, ok := saved[key]; ok {
+		return nil
 	}
 
 	strCMD := cacher.client.XAdd(context.Background(), &redis.XAddArgs{
@@ -119,10 +163,11 @@ func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.Lo
 			"object": data,
 		},
 	})
-	var key string
-	if key, err = strCMD.Result(); err != nil {
+	var id string
+	if id, err = strCMD.Result(); err != nil {
 		return fmt.Errorf("error in XAdd:%v", err)
 	}
-	log.Debugf("ready with write to redis %s: %s", key, data)
+	saved[key] = struct{}{}
+	log.Debugf("ready with write to redis %s: %s", id, data)
 	return nil
 }
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"context"
+
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -82,7 +84,8 @@ type Cloud interface {
 	ListApplicationInstanceConfig() []*config.AppInstanceConfig
 	StateUpdate(dev *device.Ctx) (err error)
 	ResetDev(node *device.Ctx) error
-	OnBoardDev(node *device.Ctx) error
+	OnBoardDev(ctx context.Context, node *device.Ctx) error
+	OnBoardDevWait(ctx context.Context, node *device.Ctx, poll func() error) error
 	GetVars() *utils.ConfigVars
 	SetVars(*utils.ConfigVars)
 	GetAllNodes()
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/eapps"
@@ -29,9 +30,13 @@ type Controller interface {
 	FlowLogLastCallback(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc) (err error)
 	InfoChecker(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc, mode einfo.InfoCheckerMode, timeout time.Duration) (err error)
 	InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error)
+	InfoWatch(ctx context.Context, devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error)
+	LogWatch(ctx context.Context, devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc) (err error)
 	MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error)
 	MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error)
+	MetricWatch(ctx context.Context, devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error)
 	RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error)
+	RequestChecker(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc, mode erequest.RequestCheckerMode, timeout time.Duration) (err error)
 	DeviceList(types.DeviceStateFilter) (out []string, err error)
 	DeviceGetByOnboard(eveCert string) (devUUID uuid.UUID, err error)
 	DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error)
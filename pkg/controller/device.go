@@ -246,6 +246,14 @@ func (cloud *CloudCtx) ConfigSync(dev *device.Ctx) (err error) {
 	if err != nil {
 		return err
 	}
+	var edgeDevConfig config.EdgeDevConfig
+	if err = proto.Unmarshal(devConfig, &edgeDevConfig); err != nil {
+		return fmt.Errorf("unmarshal error: %w", err)
+	}
+	// refuse to push config which EVE will not be able to apply
+	if err = ValidateEdgeDevConfig(&edgeDevConfig); err != nil {
+		return err
+	}
 	hash := sha256.Sum256(devConfig)
 	if dev.CheckHash(hash) {
 		fmt.Println("config changed, to see config run 'eden controller edge-node get-config'")
@@ -279,7 +287,7 @@ func (cloud *CloudCtx) GetDeviceCurrent() (dev *device.Ctx, err error) {
 		return nil, err
 	}
 	if len(cloud.devices) == 0 {
-		return nil, errors.New("no device found")
+		return nil, types.ErrDeviceNotFound
 	}
 	return cloud.GetDeviceUUID(id)
 }
@@ -5,7 +5,6 @@ package eapps
 import (
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
@@ -54,7 +53,7 @@ func LogItemFind(le *logs.LogEntry, query map[string]string) bool {
 		}
 		var clb = func(inp reflect.Value) {
 			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
+			newMatched, err := utils.MatchRegexp(v, []byte(f))
 			if err != nil {
 				log.Debug(err)
 			}
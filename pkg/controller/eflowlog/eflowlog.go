@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
@@ -75,7 +74,7 @@ func FlowLogItemFind(le *flowlog.FlowMessage, query map[string]string) bool {
 		}
 		var clb = func(inp reflect.Value) {
 			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
+			newMatched, err := utils.MatchRegexp(v, []byte(f))
 			if err != nil {
 				log.Debug(err)
 			}
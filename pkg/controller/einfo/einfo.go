@@ -3,9 +3,9 @@
 package einfo
 
 import (
+	"context"
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
@@ -78,7 +78,7 @@ func processElem(value reflect.Value, query map[string]string) bool {
 			if b, ok := inp.Interface().([]byte); ok {
 				f = fmt.Sprintf("%s", b)
 			}
-			newMatched, err := regexp.Match(v, []byte(f))
+			newMatched, err := utils.MatchRegexp(v, []byte(f))
 			if err != nil {
 				log.Debug(err)
 			}
@@ -117,6 +117,80 @@ func ZInfoPrintFiltered(im *info.ZInfoMsg, query []string) *types.PrintResult {
 	return &result
 }
 
+// ZInfoSelect resolves JSONPath-like expressions from 'filters' against JSON
+// representation of ZInfoMsg (e.g. '.dinfo.network[].IPAddrs')
+func ZInfoSelect(im *info.ZInfoMsg, filters []string) (*types.PrintResult, error) {
+	b, err := protojson.Marshal(im)
+	if err != nil {
+		return nil, err
+	}
+	result := make(types.PrintResult)
+	for _, filter := range filters {
+		values, err := utils.SelectJSONPathFromBytes(b, filter)
+		if err != nil {
+			return nil, fmt.Errorf("cannot apply filter %q: %w", filter, err)
+		}
+		for _, v := range values {
+			result[filter] = append(result[filter], utils.JSONPathValueString(v))
+		}
+	}
+	return &result, nil
+}
+
+// ZInfoObjectKey returns key of object described by ZInfoMsg,
+// it consists of ztype and identifier of object if it exists (app, volume, etc.)
+func ZInfoObjectKey(im *info.ZInfoMsg) string {
+	var id string
+	switch {
+	case im.GetAinfo() != nil:
+		id = im.GetAinfo().GetAppID()
+	case im.GetNiinfo() != nil:
+		id = im.GetNiinfo().GetNetworkID()
+	case im.GetVinfo() != nil:
+		id = im.GetVinfo().GetUuid()
+	case im.GetCinfo() != nil:
+		id = im.GetCinfo().GetUuid()
+	case im.GetAmdinfo() != nil:
+		id = im.GetAmdinfo().GetUuid()
+	}
+	if id == "" {
+		return im.GetZtype().String()
+	}
+	return fmt.Sprintf("%s %s", im.GetZtype(), id)
+}
+
+// noisyInfoFields contains fields which change with every info message
+var noisyInfoFields = map[string]struct{}{
+	"lastsucceeded": {},
+	"lastfailed":    {},
+	"timepriority":  {},
+	"uptime":        {},
+}
+
+// IsNoisyInfoField returns true for timestamp fields which are updated in every info message
+func IsNoisyInfoField(field string) bool {
+	field = strings.ToLower(field)
+	if strings.Contains(field, "timestamp") {
+		return true
+	}
+	_, ok := noisyInfoFields[field]
+	return ok
+}
+
+// ZInfoDiff returns differences between JSON representations of two ZInfoMsg,
+// fields for which 'skip' returns true are not compared
+func ZInfoDiff(old, new *info.ZInfoMsg, skip func(field string) bool) ([]utils.JSONDiff, error) {
+	oldBytes, err := protojson.Marshal(old)
+	if err != nil {
+		return nil, err
+	}
+	newBytes, err := protojson.Marshal(new)
+	if err != nil {
+		return nil, err
+	}
+	return utils.DiffJSONBytes(oldBytes, newBytes, skip)
+}
+
 // ZInfoFind finds ZInfoMsg records with 'devid' and ZInfoDevSWF structure fields
 // by reqexps in 'query'
 func ZInfoFind(im *info.ZInfoMsg, query map[string]string) bool {
@@ -166,15 +240,23 @@ func InfoWatch(loader loaders.Loader, query map[string]string, qhandler QHandler
 	return loader.ProcessStream(infoProcess(query, qhandler, handler), types.InfoType, timeoutSeconds)
 }
 
+// InfoWatchContext processes new info messages found by 'query' with 'handler' until it returns true, ctx is done or reading fails.
+func InfoWatchContext(ctx context.Context, loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	return loader.Watch(ctx, infoProcess(query, ZInfoFind, handler), types.InfoType)
+}
+
 // InfoChecker checks the information in the regular expression pattern 'query' and processes the info.ZInfoMsg found by the function 'handler' from existing files (mode=InfoExist), new files (mode=InfoNew) or any of them (mode=InfoAny) with timeout (0 for infinite).
 func InfoChecker(loader loaders.Loader, devUUID uuid.UUID, query map[string]string, handler HandlerFunc, mode InfoCheckerMode, timeout time.Duration) (err error) {
 	loader.SetUUID(devUUID)
-	done := make(chan error)
+	// buffered, so goroutines finished after return do not block
+	done := make(chan error, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// observe new files
 	if mode == InfoNew || mode == InfoAny {
 		go func() {
-			done <- InfoWatch(loader.Clone(), query, ZInfoFind, handler, timeout)
+			done <- loaders.WatchWithTimeout(ctx, loader.Clone(), infoProcess(query, ZInfoFind, handler), types.InfoType, timeout)
 		}()
 	}
 	// check info by pattern in existing files
@@ -3,6 +3,8 @@
 package elog
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -68,33 +70,52 @@ func LogItemPrint(le *FullLogEntry, _ types.OutputFormat, query []string) *types
 	return &result
 }
 
-// LogItemFind find LogItem records by reqexps in 'query' corresponded to LogItem structure.
-func LogItemFind(le *FullLogEntry, query map[string]string) bool {
-	matched := true
+// logFieldQuery matches field of FullLogEntry by path with regexp
+type logFieldQuery struct {
+	path string
+	re   *regexp.Regexp
+}
+
+// compileLogQuery converts 'query' into matchers compiled once, so they are not
+// compiled again for every log entry. Fields with invalid regexp do not match.
+func compileLogQuery(query map[string]string) []logFieldQuery {
+	caser := cases.Title(language.English, cases.NoLower)
+	result := make([]logFieldQuery, 0, len(query))
 	for k, v := range query {
 		// Uppercase of filed's name first letter
 		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
 		for _, pathElement := range strings.Split(k, ".") {
 			n = append(n, caser.String(pathElement))
 		}
+		re, err := regexp.Compile(v)
+		if err != nil {
+			log.Debug(err)
+		}
+		result = append(result, logFieldQuery{path: strings.Join(n, "."), re: re})
+	}
+	return result
+}
+
+// matchLogQuery returns true if all fields of le are matched by compiled query
+func matchLogQuery(le *FullLogEntry, query []logFieldQuery) bool {
+	for _, q := range query {
+		matched := false
 		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
+			if !matched && q.re != nil {
+				matched = q.re.MatchString(fmt.Sprint(inp))
 			}
 		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
+		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), q.path, clb)
 		if !matched {
-			return matched
+			return false
 		}
 	}
-	return matched
+	return true
+}
+
+// LogItemFind find LogItem records by reqexps in 'query' corresponded to LogItem structure.
+func LogItemFind(le *FullLogEntry, query map[string]string) bool {
+	return matchLogQuery(le, compileLogQuery(query))
 }
 
 // HandleFactory implements HandlerFunc which prints log in the provided format
@@ -133,14 +154,14 @@ func LogPrn(le *FullLogEntry, format types.OutputFormat) {
 type HandlerFunc func(*FullLogEntry) bool
 
 func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFunction {
-	_, ok := query["devId"]
-	if ok {
-		delete(query, "devId")
-	}
-	eveVersion, ok := query["eveVersion"]
-	if ok {
-		delete(query, "eveVersion")
+	fields := make(map[string]string, len(query))
+	for k, v := range query {
+		fields[k] = v
 	}
+	delete(fields, "devId")
+	eveVersion := fields["eveVersion"]
+	delete(fields, "eveVersion")
+	compiled := compileLogQuery(fields)
 	return func(bytes []byte) (bool, error) {
 		lb, err := ParseFullLogEntry(bytes)
 		if err != nil {
@@ -149,7 +170,7 @@ func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFun
 		if eveVersion != "" && eveVersion != lb.EveVersion {
 			return true, nil
 		}
-		if LogItemFind(lb, query) {
+		if matchLogQuery(lb, compiled) {
 			if handler(lb) {
 				return false, nil
 			}
@@ -164,6 +185,11 @@ func LogWatch(loader loaders.Loader, query map[string]string, handler HandlerFun
 	return loader.ProcessStream(logProcess(query, handler), types.LogsType, timeoutSeconds)
 }
 
+// LogWatchContext processes new log entries found by 'query' with 'handler' until it returns true, ctx is done or reading fails.
+func LogWatchContext(ctx context.Context, loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	return loader.Watch(ctx, logProcess(query, handler), types.LogsType)
+}
+
 // LogLast function process Log files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func LogLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
@@ -173,12 +199,18 @@ func LogLast(loader loaders.Loader, query map[string]string, handler HandlerFunc
 // LogChecker check logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
 func LogChecker(loader loaders.Loader, devUUID uuid.UUID, q map[string]string, handler HandlerFunc, mode LogCheckerMode, timeout time.Duration) (err error) {
 	loader.SetUUID(devUUID)
-	done := make(chan error)
+	if mode > 0 {
+		return logTail(loader.Clone(), q, handler, int(mode))
+	}
+	// buffered, so goroutines finished after return do not block
+	done := make(chan error, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// observe new files
 	if mode == LogNew || mode == LogAny {
 		go func() {
-			done <- LogWatch(loader.Clone(), q, handler, timeout)
+			done <- loaders.WatchWithTimeout(ctx, loader.Clone(), logProcess(q, handler), types.LogsType, timeout)
 		}()
 	}
 	// check info by pattern in existing files
@@ -193,26 +225,42 @@ func LogChecker(loader loaders.Loader, devUUID uuid.UUID, q map[string]string, h
 			done <- LogLast(loader.Clone(), q, handler)
 		}()
 	}
-	// use for process only defined count of last messages
-	if mode > 0 {
-		logQueue := utils.InitQueueWithCapacity(int(mode))
-		handlerLocal := func(item *FullLogEntry) (result bool) {
-			if err = logQueue.Enqueue(item); err != nil {
-				done <- err
-			}
+	return <-done
+}
+
+// logTail processes the last count log entries found by 'query' with 'handler' in order of their arrival.
+// Entries are read from the newest one if loader supports it, so only the tail of history is read,
+// otherwise the whole history is read keeping only count entries in memory.
+func logTail(loader loaders.Loader, q map[string]string, handler HandlerFunc, count int) error {
+	entries := make([]*FullLogEntry, 0, count)
+	collect := func(item *FullLogEntry) bool {
+		entries = append(entries, item)
+		return len(entries) >= count
+	}
+	err := loader.ProcessExistingReverse(logProcess(q, collect), types.LogsType)
+	if errors.Is(err, loaders.ErrReverseNotSupported) {
+		logQueue := utils.InitQueueWithCapacity(count)
+		enqueue := func(item *FullLogEntry) bool {
+			_ = logQueue.Enqueue(item)
 			return false
 		}
-		if err = LogLast(loader.Clone(), q, handlerLocal); err != nil {
-			done <- err
+		if err := LogLast(loader, q, enqueue); err != nil {
+			return err
 		}
-		el, err := logQueue.Dequeue()
-		for err == nil {
-			if result := handler(el.(*FullLogEntry)); result {
+		for el, err := logQueue.Dequeue(); err == nil; el, err = logQueue.Dequeue() {
+			if handler(el.(*FullLogEntry)) {
 				return nil
 			}
-			el, err = logQueue.Dequeue()
 		}
 		return nil
 	}
-	return <-done
+	if err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if handler(entries[i]) {
+			return nil
+		}
+	}
+	return nil
 }
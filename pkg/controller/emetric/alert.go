@@ -0,0 +1,271 @@
+package emetric
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v2"
+)
+
+// AlertAction is action to run when alert rule fires
+type AlertAction string
+
+// Actions supported by alert rules
+const (
+	AlertActionLog     AlertAction = "log"     // print warning
+	AlertActionWebhook AlertAction = "webhook" // post alert to url
+	AlertActionFail    AlertAction = "fail"    // stop processing with error
+)
+
+// alertOperators ordered to match two-symbols operators first
+var alertOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// AlertRule describes condition on metric value
+type AlertRule struct {
+	Name    string      `yaml:"name"`
+	Expr    string      `yaml:"expr"`    // JSONPath-like expression, operator and threshold, e.g. '.dm.memory.usedPercentage > 80'
+	For     string      `yaml:"for"`     // duration condition must hold before rule fires, e.g. '5m'
+	Action  AlertAction `yaml:"action"`  // log, webhook or fail
+	Webhook string      `yaml:"webhook"` // url for webhook action
+
+	path      string
+	operator  string
+	threshold float64
+	duration  time.Duration
+}
+
+// AlertRules is a set of rules loaded from yaml
+type AlertRules struct {
+	Rules []*AlertRule `yaml:"rules"`
+}
+
+// Alert is a fired rule with metric value caused it
+type Alert struct {
+	Rule      *AlertRule
+	Value     float64
+	Timestamp time.Time
+}
+
+// String returns description of alert
+func (alert *Alert) String() string {
+	return fmt.Sprintf("alert %s: %s (value %v) at %s", alert.Rule.Name, alert.Rule.Expr, alert.Value,
+		alert.Timestamp.Format(time.RFC3339))
+}
+
+// PostWebhook sends alert in json to webhook of rule
+func (alert *Alert) PostWebhook() error {
+	body, err := json.Marshal(map[string]interface{}{
+		"rule":      alert.Rule.Name,
+		"expr":      alert.Rule.Expr,
+		"value":     alert.Value,
+		"timestamp": alert.Timestamp.Format(time.RFC3339),
+		"message":   alert.String(),
+	})
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Post(alert.Rule.Webhook, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("webhook %s failed: %w", alert.Rule.Webhook, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s failed with code=%d", alert.Rule.Webhook, resp.StatusCode)
+	}
+	return nil
+}
+
+func (rule *AlertRule) parse() error {
+	for _, op := range alertOperators {
+		ind := strings.Index(rule.Expr, op)
+		if ind == -1 {
+			continue
+		}
+		rule.path = strings.TrimSpace(rule.Expr[:ind])
+		rule.operator = op
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(rule.Expr[ind+len(op):]), 64)
+		if err != nil {
+			return fmt.Errorf("rule %s: cannot parse threshold: %w", rule.Name, err)
+		}
+		rule.threshold = threshold
+		break
+	}
+	if rule.operator == "" || rule.path == "" {
+		return fmt.Errorf("rule %s: expression %q must be in form '<path> <operator> <number>'", rule.Name, rule.Expr)
+	}
+	if rule.For != "" {
+		duration, err := time.ParseDuration(rule.For)
+		if err != nil {
+			return fmt.Errorf("rule %s: cannot parse duration: %w", rule.Name, err)
+		}
+		rule.duration = duration
+	}
+	switch rule.Action {
+	case "":
+		rule.Action = AlertActionLog
+	case AlertActionLog, AlertActionFail:
+	case AlertActionWebhook:
+		if rule.Webhook == "" {
+			return fmt.Errorf("rule %s: webhook action requires webhook url", rule.Name)
+		}
+	default:
+		return fmt.Errorf("rule %s: unknown action %q", rule.Name, rule.Action)
+	}
+	return nil
+}
+
+func (rule *AlertRule) compare(value float64) bool {
+	switch rule.operator {
+	case ">":
+		return value > rule.threshold
+	case ">=":
+		return value >= rule.threshold
+	case "<":
+		return value < rule.threshold
+	case "<=":
+		return value <= rule.threshold
+	case "==":
+		return value == rule.threshold
+	case "!=":
+		return value != rule.threshold
+	}
+	return false
+}
+
+// ParseAlertRules parses and validates rules from yaml
+func ParseAlertRules(data []byte) (*AlertRules, error) {
+	var rules AlertRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for i, rule := range rules.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i)
+		}
+		if err := rule.parse(); err != nil {
+			return nil, err
+		}
+	}
+	return &rules, nil
+}
+
+// LoadAlertRules reads rules from yaml file
+func LoadAlertRules(fileName string) (*AlertRules, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAlertRules(data)
+}
+
+type alertState struct {
+	since time.Time
+	fired bool
+}
+
+// AlertEvaluator checks metrics against rules and tracks how long conditions hold
+type AlertEvaluator struct {
+	rules  *AlertRules
+	states map[*AlertRule]*alertState
+}
+
+// NewAlertEvaluator creates evaluator for rules
+func NewAlertEvaluator(rules *AlertRules) *AlertEvaluator {
+	return &AlertEvaluator{rules: rules, states: make(map[*AlertRule]*alertState)}
+}
+
+// Process evaluates rules against metric message and returns alerts fired by it.
+// Rule fires once when condition holds for its duration and may fire again
+// only after condition stops to hold.
+func (evaluator *AlertEvaluator) Process(mm *metrics.ZMetricMsg) ([]*Alert, error) {
+	data, err := protojson.Marshal(mm)
+	if err != nil {
+		return nil, err
+	}
+	ts := mm.GetAtTimeStamp().AsTime()
+	var alerts []*Alert
+	for _, rule := range evaluator.rules.Rules {
+		matched, matchedValue, err := rule.match(data)
+		if err != nil {
+			return nil, err
+		}
+		state, ok := evaluator.states[rule]
+		if !matched {
+			delete(evaluator.states, rule)
+			continue
+		}
+		if !ok {
+			state = &alertState{since: ts}
+			evaluator.states[rule] = state
+		}
+		if !state.fired && ts.Sub(state.since) >= rule.duration {
+			state.fired = true
+			alerts = append(alerts, &Alert{Rule: rule, Value: matchedValue, Timestamp: ts})
+		}
+	}
+	return alerts, nil
+}
+
+// match checks condition of rule against metric message encoded into json
+// and returns value matched it
+func (rule *AlertRule) match(data []byte) (bool, float64, error) {
+	values, err := utils.SelectJSONPathFromBytes(data, rule.path)
+	if err != nil {
+		return false, 0, fmt.Errorf("rule %s: %w", rule.Name, err)
+	}
+	for _, v := range values {
+		f, ok := alertValue(v)
+		if ok && rule.compare(f) {
+			return true, f, nil
+		}
+	}
+	return false, 0, nil
+}
+
+// ParseExpr parses expression in form of expression of alert rule,
+// e.g. '.dm.memory.usedPercentage < 90', to check metrics with Match
+func ParseExpr(expr string) (*AlertRule, error) {
+	rule := &AlertRule{Name: expr, Expr: expr}
+	if err := rule.parse(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Match checks if condition of rule holds for metric message
+func (rule *AlertRule) Match(mm *metrics.ZMetricMsg) (bool, error) {
+	data, err := protojson.Marshal(mm)
+	if err != nil {
+		return false, err
+	}
+	matched, _, err := rule.match(data)
+	return matched, err
+}
+
+// alertValue converts selected value into number,
+// protojson encodes 64-bit integers as strings
+func alertValue(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		return f, err == nil
+	case bool:
+		if val {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -3,9 +3,9 @@
 package emetric
 
 import (
+	"context"
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
@@ -83,7 +83,7 @@ func MetricItemFind(mm *metrics.ZMetricMsg, query map[string]string) bool {
 		}
 		var clb = func(inp reflect.Value) {
 			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
+			newMatched, err := utils.MatchRegexp(v, []byte(f))
 			if err != nil {
 				log.Debug(err)
 			}
@@ -151,6 +151,11 @@ func MetricWatch(loader loaders.Loader, query map[string]string, handler Handler
 	return loader.ProcessStream(metricProcess(query, handler), types.MetricsType, timeoutSeconds)
 }
 
+// MetricWatchContext processes new metrics found by 'query' with 'handler' until it returns true, ctx is done or reading fails.
+func MetricWatchContext(ctx context.Context, loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	return loader.Watch(ctx, metricProcess(query, handler), types.MetricsType)
+}
+
 // MetricLast function process Metric files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func MetricLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
@@ -7,12 +7,13 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -47,7 +48,7 @@ func RequestItemFind(le *types.APIRequest, query map[string]string) bool {
 		}
 		var clb = func(inp reflect.Value) {
 			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
+			newMatched, err := utils.MatchRegexp(v, []byte(f))
 			if err != nil {
 				log.Debug(err)
 			}
@@ -107,3 +108,46 @@ func requestProcess(query map[string]string, handler HandlerFunc) loaders.Proces
 func RequestLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
 	return loader.ProcessExisting(requestProcess(query, handler), types.RequestType)
 }
+
+// RequestWatch function process new Request records according to the 'query' reqexps
+// with handler until it returns true or timeout (0 for infinite)
+func RequestWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
+	return loader.ProcessStream(requestProcess(query, handler), types.RequestType, timeoutSeconds)
+}
+
+// RequestCheckerMode is RequestExist, RequestNew and RequestAny
+type RequestCheckerMode int
+
+// RequestChecker modes RequestExist, RequestNew and RequestAny.
+const (
+	RequestExist RequestCheckerMode = iota // just look to existing records
+	RequestNew                             // wait for new records
+	RequestAny                             // use both mechanisms
+)
+
+// RequestChecker processes APIRequest records found by 'query' reqexps with 'handler' from existing
+// records (mode=RequestExist), new ones (mode=RequestNew) or any of them (mode=RequestAny) with timeout (0 for infinite).
+func RequestChecker(loader loaders.Loader, devUUID uuid.UUID, query map[string]string, handler HandlerFunc, mode RequestCheckerMode, timeout time.Duration) (err error) {
+	loader.SetUUID(devUUID)
+	done := make(chan error)
+
+	// observe new records
+	if mode == RequestNew || mode == RequestAny {
+		go func() {
+			done <- RequestWatch(loader.Clone(), query, handler, timeout)
+		}()
+	}
+	// check requests by pattern in existing records
+	if mode == RequestExist || mode == RequestAny {
+		go func() {
+			handler := func(request *types.APIRequest) (result bool) {
+				if handler(request) {
+					done <- nil
+				}
+				return
+			}
+			done <- RequestLast(loader.Clone(), query, handler)
+		}()
+	}
+	return <-done
+}
@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +13,7 @@ import (
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/adam"
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -32,10 +35,28 @@ func CloudPrepare() (Cloud, error) {
 	if err := ctx.InitWithVars(vars); err != nil {
 		return nil, fmt.Errorf("cloud.InitWithVars: %s", err)
 	}
+	// GetAllNodes terminates eden on failure, so check connection first
+	if _, err := ctx.DeviceList(types.AllDevicesFilter); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrControllerUnreachable, err)
+	}
 	ctx.GetAllNodes()
 	return ctx, nil
 }
 
+// CloudPrepareOffline is for init controller without connection to adam
+// using device config saved before, logs/info/metrics are loaded from vars.AdamOfflineDir
+func CloudPrepareOffline(vars *utils.ConfigVars, devConfig *config.EdgeDevConfig) (Cloud, *device.Ctx, error) {
+	ctx := &CloudCtx{vars: vars, Controller: &adam.Ctx{}}
+	if err := ctx.InitWithVars(vars); err != nil {
+		return nil, nil, fmt.Errorf("cloud.InitWithVars: %s", err)
+	}
+	dev, err := ctx.ConfigParse(devConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("configParse: %s", err)
+	}
+	return ctx, dev, nil
+}
+
 // GetVars returns variables of controller
 func (cloud *CloudCtx) GetVars() *utils.ConfigVars {
 	return cloud.vars
@@ -62,14 +83,15 @@ func (cloud *CloudCtx) ResetDev(node *device.Ctx) error {
 	node.SetDevModel(vars.DevModel)
 	node.SetGlobalProfile("")
 	node.SetLocalProfileServer("")
-	return cloud.OnBoardDev(node)
+	return cloud.OnBoardDev(context.Background(), node)
 }
 
-// OnBoardDev in controller
-func (cloud *CloudCtx) OnBoardDev(node *device.Ctx) error {
+// registerDev registers node in controller, it returns true if node with the
+// same onboarding certificate was onboarded before
+func (cloud *CloudCtx) registerDev(node *device.Ctx) (bool, error) {
 	edenDir, err := utils.DefaultEdenDir()
 	if err != nil {
-		return err
+		return false, err
 	}
 	alreadyRegistered := false
 	oldDevUUID, _ := cloud.DeviceGetByOnboard(node.GetOnboardKey())
@@ -78,18 +100,18 @@ func (cloud *CloudCtx) OnBoardDev(node *device.Ctx) error {
 		switch {
 		case err != nil && os.IsNotExist(err):
 			log.Printf("cert file %s does not exist", node.GetOnboardKey())
-			return err
+			return false, err
 		case err != nil:
 			log.Printf("error reading cert file %s: %v", node.GetOnboardKey(), err)
-			return err
+			return false, err
 		}
 		cert, err := utils.ParseFirstCertFromBlock(b)
 		if err != nil {
-			return err
+			return false, err
 		}
 		uuidToFound, err := uuid.FromString(cert.Subject.CommonName)
 		if err != nil {
-			return err
+			return false, err
 		}
 		fi, err := os.Stat(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", uuidToFound)))
 		if err == nil {
@@ -99,9 +121,79 @@ func (cloud *CloudCtx) OnBoardDev(node *device.Ctx) error {
 			}
 		}
 	}
-	err = cloud.Register(node)
+	if err := cloud.Register(node); err != nil {
+		return false, fmt.Errorf("register: %s", err)
+	}
+	return alreadyRegistered, nil
+}
+
+// onboarded sets node registered by EVE with dev UUID and applies initial config to new node
+func (cloud *CloudCtx) onboarded(node *device.Ctx, dev uuid.UUID, alreadyRegistered bool) error {
+	log.Debug("Done onboarding in adam!")
+	log.Infof("Device uuid: %s", dev.String())
+	node.SetID(dev)
+	node.SetState(device.Onboarded)
+	node.SetRemote(cloud.vars.EveRemote)
+	node.SetRemoteAddr(cloud.vars.EveRemoteAddr)
+	if alreadyRegistered {
+		return nil
+	}
+	node.SetConfigItem("timer.config.interval", "10")
+	node.SetConfigItem("timer.location.app.interval", "10")
+	node.SetConfigItem("timer.location.cloud.interval", "300")
+	node.SetConfigItem("app.allow.vnc", "true")
+	node.SetConfigItem("newlog.allow.fastupload", "true")
+	node.SetConfigItem("timer.download.retry", "60")
+	node.SetConfigItem("debug.enable.console", "true")
+	// TODO: allow to enable/disable:
+	//node.SetConfigItem("network.fallback.any.eth", "disabled")
+	log.Debugf("will apply devModel %s", node.GetDevModel())
+	deviceModel, err := models.GetDevModelByName(node.GetDevModel())
+	if err != nil {
+		log.Fatalf("fail to get dev model %s: %s", node.GetDevModel(), err)
+	}
+	if cloud.vars.EveSSID != "" {
+		ssid := cloud.vars.EveSSID
+		fmt.Printf("Enter password for wifi %s: ", ssid)
+		pass, _ := term.ReadPassword(0)
+		wifiPSK := strings.ToLower(hex.EncodeToString(pbkdf2.Key(pass, []byte(ssid), 4096, 32, sha1.New)))
+		fmt.Println()
+		deviceModel.SetWiFiParams(cloud.vars.EveSSID, wifiPSK)
+	}
+	if cloud.vars.AdamLogLevel != "" {
+		node.SetConfigItem("debug.default.remote.loglevel", cloud.vars.AdamLogLevel)
+	}
+	if cloud.vars.LogLevel != "" {
+		node.SetConfigItem("debug.default.loglevel", cloud.vars.LogLevel)
+	}
+	if cloud.vars.SSHKey != "" {
+		b, err := os.ReadFile(cloud.vars.SSHKey)
+		switch {
+		case err != nil && os.IsNotExist(err):
+			return fmt.Errorf("sshKey file %s does not exist", cloud.vars.SSHKey)
+		case err != nil:
+			return fmt.Errorf("error reading sshKey file %s: %v", cloud.vars.SSHKey, err)
+		}
+		node.SetConfigItem("debug.enable.ssh", string(b))
+	}
+	if err := cloud.ApplyDevModel(node, deviceModel); err != nil {
+		return fmt.Errorf("fail in ApplyDevModel: %s", err)
+	}
+	if err := cloud.ConfigSync(node); err != nil {
+		log.Fatal(err)
+	}
+	// wait for certs
+	if _, err := cloud.GetECDHCert(node.GetID()); err != nil {
+		log.Fatal(err)
+	}
+	return nil
+}
+
+// OnBoardDev in controller
+func (cloud *CloudCtx) OnBoardDev(ctx context.Context, node *device.Ctx) error {
+	alreadyRegistered, err := cloud.registerDev(node)
 	if err != nil {
-		return fmt.Errorf("register: %s", err)
+		return err
 	}
 
 	maxRepeat := 20
@@ -112,70 +204,51 @@ func (cloud *CloudCtx) OnBoardDev(node *device.Ctx) error {
 		if err != nil {
 			log.Debugf("DeviceGetByOnboard %s", err)
 			log.Infof("Adam waiting for EVE registration (%d) of (%d)", i, maxRepeat)
-			time.Sleep(delayTime)
-		} else {
-			log.Debug("Done onboarding in adam!")
-			log.Infof("Device uuid: %s", dev.String())
-			node.SetID(dev)
-			node.SetState(device.Onboarded)
-			node.SetRemote(cloud.vars.EveRemote)
-			node.SetRemoteAddr(cloud.vars.EveRemoteAddr)
-			if !alreadyRegistered { //new node
-				node.SetConfigItem("timer.config.interval", "10")
-				node.SetConfigItem("timer.location.app.interval", "10")
-				node.SetConfigItem("timer.location.cloud.interval", "300")
-				node.SetConfigItem("app.allow.vnc", "true")
-				node.SetConfigItem("newlog.allow.fastupload", "true")
-				node.SetConfigItem("timer.download.retry", "60")
-				node.SetConfigItem("debug.enable.console", "true")
-				// TODO: allow to enable/disable:
-				//node.SetConfigItem("network.fallback.any.eth", "disabled")
-				log.Debugf("will apply devModel %s", node.GetDevModel())
-				deviceModel, err := models.GetDevModelByName(node.GetDevModel())
-				if err != nil {
-					log.Fatalf("fail to get dev model %s: %s", node.GetDevModel(), err)
-				}
-				if cloud.vars.EveSSID != "" {
-					ssid := cloud.vars.EveSSID
-					fmt.Printf("Enter password for wifi %s: ", ssid)
-					pass, _ := term.ReadPassword(0)
-					wifiPSK := strings.ToLower(hex.EncodeToString(pbkdf2.Key(pass, []byte(ssid), 4096, 32, sha1.New)))
-					fmt.Println()
-					deviceModel.SetWiFiParams(cloud.vars.EveSSID, wifiPSK)
-				}
-				if cloud.vars.AdamLogLevel != "" {
-					node.SetConfigItem("debug.default.remote.loglevel", cloud.vars.AdamLogLevel)
-				}
-				if cloud.vars.LogLevel != "" {
-					node.SetConfigItem("debug.default.loglevel", cloud.vars.LogLevel)
-				}
-				if cloud.vars.SSHKey != "" {
-					b, err := os.ReadFile(cloud.vars.SSHKey)
-					switch {
-					case err != nil && os.IsNotExist(err):
-						return fmt.Errorf("sshKey file %s does not exist", cloud.vars.SSHKey)
-					case err != nil:
-						return fmt.Errorf("error reading sshKey file %s: %v", cloud.vars.SSHKey, err)
-					}
-					node.SetConfigItem("debug.enable.ssh", string(b))
-				}
-				if err = cloud.ApplyDevModel(node, deviceModel); err != nil {
-					return fmt.Errorf("fail in ApplyDevModel: %s", err)
-				}
-				if err = cloud.ConfigSync(node); err != nil {
-					log.Fatal(err)
-				}
-				// wait for certs
-				if _, err = cloud.GetECDHCert(node.GetID()); err != nil {
-					log.Fatal(err)
-				}
+			if err := utils.SleepContext(ctx, delayTime); err != nil {
+				return err
 			}
-			return nil
+		} else {
+			return cloud.onboarded(node, dev, alreadyRegistered)
 		}
 	}
 	return fmt.Errorf("onboarding timeout. You may try to run 'eden eve onboard' command again in several minutes. If not successful see logs of adam/eve")
 }
 
+// ErrControllerUnreachable is returned by CloudPrepare if controller does not respond
+var ErrControllerUnreachable = errors.New("controller is unreachable")
+
+// ErrOnboardTimeout is returned by OnBoardDevWait if EVE is not registered in time
+var ErrOnboardTimeout = errors.New("onboarding timeout")
+
+// OnBoardDevWait registers node in controller and waits for EVE registration
+// until ctx is done calling poll between checks of controller to observe progress,
+// error returned by poll aborts waiting. ErrOnboardTimeout is returned if deadline
+// of ctx is exceeded.
+func (cloud *CloudCtx) OnBoardDevWait(ctx context.Context, node *device.Ctx, poll func() error) error {
+	alreadyRegistered, err := cloud.registerDev(node)
+	if err != nil {
+		return err
+	}
+	delayTime := 5 * time.Second
+	for {
+		dev, err := cloud.DeviceGetByOnboard(node.GetOnboardKey())
+		if err == nil {
+			return cloud.onboarded(node, dev, alreadyRegistered)
+		}
+		log.Debugf("DeviceGetByOnboard %s", err)
+		if poll != nil {
+			if err := poll(); err != nil {
+				return err
+			}
+		}
+		if err := utils.SleepContext(ctx, delayTime); errors.Is(err, context.DeadlineExceeded) {
+			return ErrOnboardTimeout
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
 // VersionIncrement use []byte with config.EdgeDevConfig and increment config version
 func VersionIncrement(configOld []byte) ([]byte, error) {
 	var deviceConfig config.EdgeDevConfig
@@ -1,6 +1,8 @@
 package loaders
 
 import (
+	"context"
+
 	"github.com/lf-edge/eden/pkg/controller/cachers"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	uuid "github.com/satori/go.uuid"
@@ -12,7 +14,9 @@ type Loader interface {
 	SetAppUUID(devUUID uuid.UUID)
 	SetUUID(devUUID uuid.UUID)
 	ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error
+	Watch(ctx context.Context, process ProcessFunction, typeToProcess types.LoaderObjectType) error
 	ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error
+	ProcessExistingReverse(process ProcessFunction, typeToProcess types.LoaderObjectType) error
 	SetRemoteCache(cache cachers.CacheProcessor)
 	Clone() Loader
 }
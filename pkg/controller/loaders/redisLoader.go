@@ -2,6 +2,7 @@ package loaders
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -26,8 +27,21 @@ type RedisLoader struct {
 	cache         cachers.CacheProcessor
 	devUUID       uuid.UUID
 	appUUID       uuid.UUID
+	// group is consumer group to read streams in, streams are read without group if empty
+	group    string
+	consumer string
 }
 
+// watchBlock is maximal duration of blocking read of stream, context of Watch is checked between reads
+const watchBlock = time.Second
+
+// watchCount is maximal number of messages read from stream at once by Watch
+const watchCount = 100
+
+// groupClaimIdle is time after which messages delivered to another consumer of group
+// and not acknowledged by it (e.g. if process of consumer was killed) are processed again
+const groupClaimIdle = time.Minute
+
 // NewRedisLoader return loader from redis
 func NewRedisLoader(addr string, password string, databaseID int, streamGetters types.StreamGetters) *RedisLoader {
 	log.Debugf("NewRedisLoader init")
@@ -44,6 +58,14 @@ func (loader *RedisLoader) SetRemoteCache(cache cachers.CacheProcessor) {
 	loader.cache = cache
 }
 
+// SetConsumerGroup makes stream reading use consumer group with provided name, so every message
+// of stream is delivered to one of consumers of group and new consumers of group continue from
+// the last message read in group. Consumer is name of the current reader inside group.
+func (loader *RedisLoader) SetConsumerGroup(group, consumer string) {
+	loader.group = group
+	loader.consumer = consumer
+}
+
 // Clone create copy
 func (loader *RedisLoader) Clone() Loader {
 	return &RedisLoader{
@@ -55,6 +77,8 @@ func (loader *RedisLoader) Clone() Loader {
 		cache:         loader.cache,
 		devUUID:       loader.devUUID,
 		appUUID:       loader.appUUID,
+		group:         loader.group,
+		consumer:      loader.consumer,
 	}
 }
 
@@ -87,115 +111,168 @@ func (loader *RedisLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
-func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, found bool, err error) {
-	OrderStream := loader.getStream(typeToProcess)
-	log.Debugf("XRead from %s", OrderStream)
-	if !stream {
-		start := "-"
-		for {
-			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, "+", 10).Result()
-			if err != nil {
-				return false, false, fmt.Errorf("XRange error: %s", err)
-			}
+// xrangeBatch is result of reading of batch of messages of stream
+type xrangeBatch struct {
+	messages []redis.XMessage
+	err      error
+}
 
-			if len(rr) == 0 {
-				return true, false, nil
-			}
+// fetchBatch reads batch of messages of stream starting from start in background
+func (loader *RedisLoader) fetchBatch(stream, start string) <-chan xrangeBatch {
+	batch := make(chan xrangeBatch, 1)
+	client := loader.client
+	go func() {
+		rr, err := client.XRangeN(context.Background(), stream, start, "+", watchCount).Result()
+		batch <- xrangeBatch{messages: rr, err: err}
+	}()
+	return batch
+}
 
-			for _, r := range rr {
-				loader.lastID = r.ID
-				dataString, ok := r.Values["object"].(string)
-				if !ok {
-					continue
-				}
-				data := []byte(dataString)
-				tocontinue, err := process(data)
-				if err != nil {
-					return false, false, fmt.Errorf("process: %s", err)
-				}
-				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
-						log.Errorf("error in cache: %s", err)
-					}
-				}
-				if !tocontinue {
-					return true, true, nil
-				}
-			}
-			splitted := strings.Split(loader.lastID, "-")
-			counter, _ := strconv.Atoi(splitted[1])
-			start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
+// process calls process for existing messages of stream, the next batch of messages
+// is read while the current one is processed to not wait for round trip to redis
+func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType) (processed, found bool, err error) {
+	OrderStream := loader.getStream(typeToProcess)
+	log.Debugf("XRange from %s", OrderStream)
+	next := loader.fetchBatch(OrderStream, "-")
+	for next != nil {
+		batch := <-next
+		if batch.err != nil {
+			return false, false, fmt.Errorf("XRange error: %s", batch.err)
 		}
-	} else {
-		start := "$"
-		rr, err := loader.client.XRead(context.Background(), &redis.XReadArgs{
-			Streams: []string{OrderStream, start},
-			Count:   1,
-			Block:   0,
-		}).Result()
-		if err != nil {
-			return false, false, fmt.Errorf("XRead error: %s", err)
+		rr := batch.messages
+		next = nil
+		if len(rr) == watchCount {
+			splitted := strings.Split(rr[len(rr)-1].ID, "-")
+			counter, _ := strconv.Atoi(splitted[1])
+			next = loader.fetchBatch(OrderStream, fmt.Sprintf("%s-%v", splitted[0], counter+1))
 		}
-
-		for _, r := range rr[0].Messages {
+		for _, r := range rr {
 			loader.lastID = r.ID
-			dataString, ok := r.Values["object"].(string)
-			if !ok {
-				continue
-			}
-			data := []byte(dataString)
-			tocontinue, err := process(data)
+			tocontinue, err := loader.processMessage(process, typeToProcess, r)
 			if err != nil {
-				return false, false, fmt.Errorf("process first: %s", err)
-			}
-			if loader.cache != nil {
-				if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
-					log.Errorf("error in cache: %s", err)
-				}
+				return false, false, err
 			}
 			if !tocontinue {
 				return true, true, nil
 			}
 		}
-		splitted := strings.Split(loader.lastID, "-")
-		counter, _ := strconv.Atoi(splitted[1])
-		start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
-		for {
-			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, "+", 100).Result()
-			if err != nil {
-				return false, false, fmt.Errorf("XRange error: %s", err)
-			}
+	}
+	return true, false, nil
+}
 
-			for _, r := range rr {
-				loader.lastID = r.ID
-				dataString, ok := r.Values["object"].(string)
-				if !ok {
-					continue
-				}
-				data := []byte(dataString)
-				tocontinue, err := process(data)
-				if err != nil {
-					return false, false, fmt.Errorf("process: %s", err)
-				}
-				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
-						log.Errorf("error in cache: %s", err)
-					}
-				}
-				if !tocontinue {
-					return true, true, nil
-				}
+// processMessage runs process for message of stream and saves it into cache
+func (loader *RedisLoader) processMessage(process ProcessFunction, typeToProcess types.LoaderObjectType, message redis.XMessage) (bool, error) {
+	dataString, ok := message.Values["object"].(string)
+	if !ok {
+		return true, nil
+	}
+	data := []byte(dataString)
+	tocontinue, err := process(data)
+	if err != nil {
+		return false, fmt.Errorf("process: %s", err)
+	}
+	if loader.cache != nil {
+		if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+			log.Errorf("error in cache: %s", err)
+		}
+	}
+	return tocontinue, nil
+}
+
+// processGroup reads new messages of stream in consumer group. Messages are acknowledged after
+// processing, messages not acknowledged by other consumers of group for groupClaimIdle are claimed first.
+func (loader *RedisLoader) processGroup(ctx context.Context, process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	OrderStream := loader.getStream(typeToProcess)
+	log.Debugf("XReadGroup from %s in group %s as %s", OrderStream, loader.group, loader.consumer)
+	err := loader.client.XGroupCreateMkStream(ctx, OrderStream, loader.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("XGroupCreate error: %s", err)
+	}
+	handle := func(message redis.XMessage) (bool, error) {
+		loader.lastID = message.ID
+		tocontinue, err := loader.processMessage(process, typeToProcess, message)
+		if err != nil {
+			// message stays pending to be claimed by another consumer
+			return false, err
+		}
+		if err := loader.client.XAck(ctx, OrderStream, loader.group, message.ID).Err(); err != nil {
+			return false, fmt.Errorf("XAck error: %s", err)
+		}
+		return tocontinue, nil
+	}
+	for start := "0-0"; ; {
+		messages, next, err := loader.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   OrderStream,
+			Group:    loader.group,
+			Consumer: loader.consumer,
+			MinIdle:  groupClaimIdle,
+			Start:    start,
+			Count:    1,
+		}).Result()
+		if err != nil {
+			return fmt.Errorf("XAutoClaim error: %s", err)
+		}
+		for _, message := range messages {
+			if tocontinue, err := handle(message); err != nil || !tocontinue {
+				return err
 			}
-			splitted := strings.Split(loader.lastID, "-")
-			counter, _ := strconv.Atoi(splitted[1])
-			start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
-			time.Sleep(time.Second) //sleep for second to reduce the load of redis
 		}
+		if next == "0-0" || len(messages) == 0 {
+			break
+		}
+		start = next
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// read one message at once to not leave unprocessed messages pending after stop of processing
+		rr, err := loader.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    loader.group,
+			Consumer: loader.consumer,
+			Streams:  []string{OrderStream, ">"},
+			Count:    1,
+			Block:    watchBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("XReadGroup error: %s", err)
+		}
+		for _, message := range rr[0].Messages {
+			if tocontinue, err := handle(message); err != nil || !tocontinue {
+				return err
+			}
+		}
+	}
+}
+
+// leaveGroup removes consumer from group if it has no pending messages
+func (loader *RedisLoader) leaveGroup(typeToProcess types.LoaderObjectType) {
+	ctx := context.Background()
+	OrderStream := loader.getStream(typeToProcess)
+	pending, err := loader.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   OrderStream,
+		Group:    loader.group,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: loader.consumer,
+	}).Result()
+	if err != nil || len(pending) > 0 {
+		return
+	}
+	if err := loader.client.XGroupDelConsumer(ctx, OrderStream, loader.group, loader.consumer).Err(); err != nil {
+		log.Debugf("XGroupDelConsumer error: %s", err)
 	}
 }
 
-func (loader *RedisLoader) repeatableConnection(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) error {
-	if _, _, err := loader.process(process, typeToProcess, stream); err != nil {
+func (loader *RedisLoader) repeatableConnection(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	if _, _, err := loader.process(process, typeToProcess); err != nil {
 		log.Errorf("RedisLoader repeatableConnection error: %s", err)
 	}
 	return nil
@@ -221,26 +298,67 @@ func (loader *RedisLoader) ProcessExisting(process ProcessFunction, typeToProces
 	if _, err := loader.getOrCreateClient(); err != nil {
 		return err
 	}
-	return loader.repeatableConnection(process, typeToProcess, false)
+	return loader.repeatableConnection(process, typeToProcess)
 }
 
 // ProcessStream for observe new files
 func (loader *RedisLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) (err error) {
+	return WatchWithTimeout(context.Background(), loader, process, typeToProcess, timeoutSeconds)
+}
+
+// Watch calls process for every new message of stream with blocking reads until process returns false,
+// ctx is done or reading fails. Messages are read in batches of watchCount after the previous batch is
+// processed, so slow process leaves them in redis instead of memory.
+func (loader *RedisLoader) Watch(ctx context.Context, process ProcessFunction, typeToProcess types.LoaderObjectType) error {
 	if _, err := loader.getOrCreateClient(); err != nil {
 		return err
 	}
-	done := make(chan error)
-	if timeoutSeconds != 0 {
-		time.AfterFunc(timeoutSeconds, func() {
-			done <- fmt.Errorf("timeout")
-		})
-	}
-
-	go func() {
-		done <- loader.repeatableConnection(process, typeToProcess, true)
+	defer func() {
+		_ = loader.client.Close()
+		loader.client = nil
 	}()
-	if err = <-done; err != nil {
-		return err
+	if loader.group != "" {
+		defer loader.leaveGroup(typeToProcess)
+		return loader.processGroup(ctx, process, typeToProcess)
+	}
+	OrderStream := loader.getStream(typeToProcess)
+	log.Debugf("XRead from %s", OrderStream)
+	// start from the last message existing now, "$" would skip messages added between reads
+	start := "0-0"
+	last, err := loader.client.XRevRangeN(ctx, OrderStream, "+", "-", 1).Result()
+	if err != nil {
+		return fmt.Errorf("XRevRange error: %s", err)
+	}
+	if len(last) > 0 {
+		start = last[0].ID
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rr, err := loader.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{OrderStream, start},
+			Count:   watchCount,
+			Block:   watchBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("XRead error: %s", err)
+		}
+		for _, r := range rr[0].Messages {
+			loader.lastID, start = r.ID, r.ID
+			tocontinue, err := loader.processMessage(process, typeToProcess, r)
+			if err != nil {
+				return err
+			}
+			if !tocontinue {
+				return nil
+			}
+		}
 	}
-	return loader.client.Close()
 }
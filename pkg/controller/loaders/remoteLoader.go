@@ -27,6 +27,13 @@ const (
 
 type getClient = func() *http.Client
 
+// ownClient returns copy of client returned by getClient, so loader can change its timeout
+// while connections of transport are still shared with other users of the client
+func ownClient(getClient getClient) *http.Client {
+	client := *getClient()
+	return &client
+}
+
 // RemoteLoader implements loader from http backend of controller
 type RemoteLoader struct {
 	curCount     uint64
@@ -49,7 +56,7 @@ func NewRemoteLoader(getClient getClient, urlGetters types.URLGetters) *RemoteLo
 		getClient:    getClient,
 		firstLoad:    true,
 		lastTimesamp: nil,
-		client:       getClient(),
+		client:       ownClient(getClient),
 	}
 }
 
@@ -67,7 +74,7 @@ func (loader *RemoteLoader) Clone() Loader {
 		lastTimesamp: nil,
 		devUUID:      loader.devUUID,
 		appUUID:      loader.appUUID,
-		client:       loader.getClient(),
+		client:       ownClient(loader.getClient),
 		cache:        loader.cache,
 	}
 }
@@ -154,6 +161,7 @@ func (loader *RemoteLoader) process(process ProcessFunction, typeToProcess types
 	if err != nil {
 		return false, false, fmt.Errorf("error reading URL %s: %v", u, err)
 	}
+	defer response.Body.Close()
 	dec := json.NewDecoder(response.Body)
 	for {
 		processed, doContinue, err := loader.processNext(dec, process, typeToProcess, stream)
@@ -234,7 +242,5 @@ func (loader *RemoteLoader) ProcessStream(process ProcessFunction, typeToProcess
 	go func() {
 		done <- loader.repeatableConnection(process, typeToProcess, true)
 	}()
-	err = <-done
-	loader.client.CloseIdleConnections()
-	return err
+	return <-done
 }
@@ -0,0 +1,87 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrReverseNotSupported is returned by ProcessExistingReverse of loaders
+// which can read objects only from the oldest one
+var ErrReverseNotSupported = errors.New("reading from the newest object is not supported")
+
+// previousID returns the greatest ID of redis stream less than id
+func previousID(id string) (string, error) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("malformed ID of stream: %s", id)
+	}
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("malformed ID of stream %s: %w", id, err)
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("malformed ID of stream %s: %w", id, err)
+	}
+	if seq > 0 {
+		return fmt.Sprintf("%d-%d", ms, seq-1), nil
+	}
+	if ms == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("%d-%d", ms-1, uint64(1<<64-1)), nil
+}
+
+// ProcessExistingReverse calls process for existing messages of stream from the newest one
+// until process returns false. Messages are read in batches of watchCount, so only the tail
+// of stream is read if process stops early. Messages are not saved into cache.
+func (loader *RedisLoader) ProcessExistingReverse(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	if _, err := loader.getOrCreateClient(); err != nil {
+		return err
+	}
+	OrderStream := loader.getStream(typeToProcess)
+	log.Debugf("XRevRange from %s", OrderStream)
+	end := "+"
+	for {
+		rr, err := loader.client.XRevRangeN(context.Background(), OrderStream, end, "-", watchCount).Result()
+		if err != nil {
+			return fmt.Errorf("XRevRange error: %s", err)
+		}
+		for _, r := range rr {
+			dataString, ok := r.Values["object"].(string)
+			if !ok {
+				continue
+			}
+			tocontinue, err := process([]byte(dataString))
+			if err != nil {
+				return fmt.Errorf("process: %s", err)
+			}
+			if !tocontinue {
+				return nil
+			}
+		}
+		if len(rr) < watchCount {
+			return nil
+		}
+		if end, err = previousID(rr[len(rr)-1].ID); err != nil || end == "" {
+			return err
+		}
+	}
+}
+
+// ProcessExistingReverse calls process for existing files from the newest one until process returns false
+func (loader *FileLoader) ProcessExistingReverse(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	// files are processed sorted by time of modification from the newest one
+	return loader.ProcessExisting(process, typeToProcess)
+}
+
+// ProcessExistingReverse is not supported as controller returns objects from the oldest one
+func (loader *RemoteLoader) ProcessExistingReverse(_ ProcessFunction, _ types.LoaderObjectType) error {
+	return ErrReverseNotSupported
+}
@@ -0,0 +1,59 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+)
+
+// WatchWithTimeout runs Watch of loader inside ctx for timeout (0 for infinite),
+// expiration of timeout is returned as "timeout" error like ProcessStream does
+func WatchWithTimeout(ctx context.Context, loader Loader, process ProcessFunction, typeToProcess types.LoaderObjectType, timeout time.Duration) error {
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := loader.Watch(ctx, process, typeToProcess)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timeout")
+	}
+	return err
+}
+
+// watchStream implements Watch for loaders which cannot interrupt reading of stream:
+// processStream runs in background and stops with the next object after ctx is done
+func watchStream(ctx context.Context, processStream func(process ProcessFunction) error, process ProcessFunction) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- processStream(func(data []byte) (bool, error) {
+			if ctx.Err() != nil {
+				return false, nil
+			}
+			return process(data)
+		})
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Watch calls process for every new object until it returns false, ctx is done or reading fails
+func (loader *FileLoader) Watch(ctx context.Context, process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	return watchStream(ctx, func(process ProcessFunction) error {
+		return loader.ProcessStream(process, typeToProcess, 0)
+	}, process)
+}
+
+// Watch calls process for every new object until it returns false, ctx is done or reading fails
+func (loader *RemoteLoader) Watch(ctx context.Context, process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	return watchStream(ctx, func(process ProcessFunction) error {
+		return loader.ProcessStream(process, typeToProcess, 0)
+	}, process)
+}
@@ -1,7 +1,9 @@
 package types
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lf-edge/eve-api/go/attest"
@@ -9,6 +11,9 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
+// ErrDeviceNotFound is returned if device is not registered in controller
+var ErrDeviceNotFound = errors.New("no device found")
+
 // DeviceStateFilter for filter device by state
 type DeviceStateFilter int
 
@@ -167,6 +172,33 @@ type PCRTemplate struct {
 	PCRValues       []*PCRValue `json:"PCRValues"`
 }
 
+// Match checks if received PCRTemplate from device satisfies template,
+// empty versions and '*' values of template match any
+func (template *PCRTemplate) Match(received *PCRTemplate) bool {
+	if received == nil {
+		return false
+	}
+	if template.EveVersion != "" && template.EveVersion != received.EveVersion {
+		return false
+	}
+	if template.FirmwareVersion != "" && template.FirmwareVersion != received.FirmwareVersion {
+		return false
+	}
+	for _, pcr := range template.PCRValues {
+		found := false
+		for _, value := range received.PCRValues {
+			if value.Index == pcr.Index {
+				found = pcr.Value == "*" || strings.EqualFold(pcr.Value, value.Value)
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // GlobalOptions configure controller behaviour for attestation requests
 type GlobalOptions struct {
 	EnforceTemplateAttestation bool           `json:"enforceTemplateAttestation"`
@@ -191,4 +223,6 @@ const (
 	OutputFormatLines OutputFormat = iota
 	//OutputFormatJSON returns in JSON format
 	OutputFormatJSON
+	//OutputFormatYAML returns in YAML format
+	OutputFormatYAML
 )
@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/lf-edge/eve-api/go/config"
+)
+
+// ConfigValidationError lists problems found in config of device
+type ConfigValidationError struct {
+	Problems []string
+}
+
+func (e *ConfigValidationError) Error() string {
+	return fmt.Sprintf("invalid config of device:\n\t%s", strings.Join(e.Problems, "\n\t"))
+}
+
+// configValidator collects problems of config
+type configValidator struct {
+	problems []string
+}
+
+func (v *configValidator) addf(format string, a ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, a...))
+}
+
+// ids returns set of unique IDs reporting duplicates of kind
+func (v *configValidator) ids(kind string, ids []string) map[string]bool {
+	result := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			v.addf("%s without UUID", kind)
+			continue
+		}
+		if result[id] {
+			v.addf("duplicate %s %s", kind, id)
+		}
+		result[id] = true
+	}
+	return result
+}
+
+func (v *configValidator) cidr(what, value string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		v.addf("%s: invalid CIDR %q", what, value)
+		return nil
+	}
+	return ipNet
+}
+
+func (v *configValidator) ipInSubnet(what, value string, subnet *net.IPNet) {
+	if value == "" {
+		return
+	}
+	ip := net.ParseIP(value)
+	switch {
+	case ip == nil:
+		v.addf("%s: invalid IP address %q", what, value)
+	case subnet != nil && !subnet.Contains(ip):
+		v.addf("%s: %s is out of subnet %s", what, value, subnet)
+	}
+}
+
+func (v *configValidator) acls(what string, acls []*config.ACE) {
+	aclIDs := make(map[int32]bool)
+	for _, ace := range acls {
+		if ace.Id != 0 {
+			if aclIDs[ace.Id] {
+				v.addf("%s: duplicate ACL id %d", what, ace.Id)
+			}
+			aclIDs[ace.Id] = true
+		}
+		for _, match := range ace.Matches {
+			if match.Type != "ip" {
+				continue
+			}
+			if net.ParseIP(match.Value) == nil {
+				if _, _, err := net.ParseCIDR(match.Value); err != nil {
+					v.addf("%s: ACL %d matches invalid IP %q", what, ace.Id, match.Value)
+				}
+			}
+		}
+	}
+}
+
+func (v *configValidator) networkInstance(ni *config.NetworkInstanceConfig) {
+	what := fmt.Sprintf("network instance %s", ni.GetDisplayname())
+	if ni.InstType != config.ZNetworkInstType_ZnetInstLocal || ni.Ip == nil {
+		return
+	}
+	subnet := v.cidr(what+" subnet", ni.Ip.Subnet)
+	v.ipInSubnet(what+" gateway", ni.Ip.Gateway, subnet)
+	if r := ni.Ip.DhcpRange; r != nil {
+		v.ipInSubnet(what+" DHCP range start", r.Start, subnet)
+		v.ipInSubnet(what+" DHCP range end", r.End, subnet)
+	}
+	for _, dns := range ni.Ip.Dns {
+		if net.ParseIP(dns) == nil {
+			v.addf("%s: invalid DNS server %q", what, dns)
+		}
+	}
+	for _, route := range ni.StaticRoutes {
+		v.cidr(what+" static route", route.DestinationNetwork)
+		v.ipInSubnet(what+" static route gateway", route.Gateway, nil)
+	}
+}
+
+// ValidateEdgeDevConfig checks references between objects of config (volumes,
+// content trees, datastores, network instances and networks), uniqueness of
+// UUIDs and ACL ids and validity of addresses in it
+func ValidateEdgeDevConfig(devConfig *config.EdgeDevConfig) error {
+	v := &configValidator{}
+	var ids []string
+	for _, ds := range devConfig.Datastores {
+		ids = append(ids, ds.Id)
+	}
+	datastores := v.ids("datastore", ids)
+	ids = nil
+	for _, ct := range devConfig.ContentInfo {
+		ids = append(ids, ct.Uuid)
+		dsIDs := ct.DsIdsList
+		if ct.DsId != "" {
+			dsIDs = append(dsIDs, ct.DsId)
+		}
+		for _, dsID := range dsIDs {
+			if !datastores[dsID] {
+				v.addf("content tree %s (%s) refers to unknown datastore %s", ct.Uuid, ct.DisplayName, dsID)
+			}
+		}
+	}
+	contentTrees := v.ids("content tree", ids)
+	ids = nil
+	for _, vol := range devConfig.Volumes {
+		ids = append(ids, vol.Uuid)
+		if vol.GetOrigin().GetType() == config.VolumeContentOriginType_VCOT_DOWNLOAD &&
+			!contentTrees[vol.GetOrigin().GetDownloadContentTreeID()] {
+			v.addf("volume %s (%s) refers to unknown content tree %s",
+				vol.Uuid, vol.DisplayName, vol.GetOrigin().GetDownloadContentTreeID())
+		}
+	}
+	volumes := v.ids("volume", ids)
+	ids = nil
+	for _, ni := range devConfig.NetworkInstances {
+		ids = append(ids, ni.GetUuidandversion().GetUuid())
+		v.networkInstance(ni)
+	}
+	networkInstances := v.ids("network instance", ids)
+	ids = nil
+	for _, network := range devConfig.Networks {
+		ids = append(ids, network.Id)
+	}
+	networks := v.ids("network", ids)
+	for _, adapter := range devConfig.SystemAdapterList {
+		if adapter.NetworkUUID != "" && !networks[adapter.NetworkUUID] {
+			v.addf("system adapter %s refers to unknown network %s", adapter.Name, adapter.NetworkUUID)
+		}
+	}
+	ids = nil
+	for _, app := range devConfig.Apps {
+		ids = append(ids, app.GetUuidandversion().GetUuid())
+		for _, ref := range app.VolumeRefList {
+			if !volumes[ref.Uuid] {
+				v.addf("app %s refers to unknown volume %s", app.Displayname, ref.Uuid)
+			}
+		}
+		for _, drive := range app.Drives {
+			if dsID := drive.GetImage().GetDsId(); dsID != "" && !datastores[dsID] {
+				v.addf("app %s drive refers to unknown datastore %s", app.Displayname, dsID)
+			}
+		}
+		for _, intf := range app.Interfaces {
+			what := fmt.Sprintf("app %s interface %s", app.Displayname, intf.Name)
+			if !networkInstances[intf.NetworkId] {
+				v.addf("%s refers to unknown network instance %s", what, intf.NetworkId)
+			}
+			v.ipInSubnet(what+" address", intf.Addr, nil)
+			v.acls(what, intf.Acls)
+		}
+	}
+	v.ids("app", ids)
+	if baseOS := devConfig.Baseos; baseOS != nil && baseOS.ContentTreeUuid != "" && !contentTrees[baseOS.ContentTreeUuid] {
+		v.addf("base OS refers to unknown content tree %s", baseOS.ContentTreeUuid)
+	}
+	if len(v.problems) > 0 {
+		return &ConfigValidationError{Problems: v.problems}
+	}
+	return nil
+}
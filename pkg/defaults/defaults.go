@@ -23,17 +23,36 @@ const (
 	DefaultCurrentDirConfig = "eden-config.yml"  //file for search config in current directory
 	DefaultContextFile      = "context.yml"      //file for saving current context inside DefaultEdenHomeDir
 	DefaultContextDirectory = "contexts"         //directory for saving contexts inside DefaultEdenHomeDir
+	DefaultTwinDirectory    = "twin"             //directory for saving offline device twin of contexts inside DefaultEdenHomeDir
+	DefaultArtifactsDir     = "artifacts"        //directory for saving artifacts (e.g. reboot forensics) of contexts inside DefaultEdenHomeDir
+	DefaultProbesDirectory  = "probes"           //directory for saving health probes of apps of contexts inside DefaultEdenHomeDir
+	DefaultCheckpointsDir   = "checkpoints"      //directory for saving checkpoints of escripts of contexts inside DefaultEdenHomeDir
+	DefaultFixturesDir      = "fixtures"         //directory for caching large fixtures of escripts by digest inside DefaultEdenHomeDir
+	DefaultSnapshotsDir     = "snapshots"        //directory for saving controller config of snapshots of EVE VM of contexts inside DefaultEdenHomeDir
+	DefaultConfigCkptDir    = "cfg-checkpoints"  //directory for saving named checkpoints of controller config of contexts inside DefaultEdenHomeDir
+	DefaultApplyStateDir    = "applied"          //directory for saving state of resources applied by 'eden apply' to contexts inside DefaultEdenHomeDir
+	DefaultEveCacheDir      = "eve-cache"        //directory for caching images of EVE generated from docker images inside DefaultEdenHomeDir
 	DefaultQemuFileToSave   = "qemu.conf"        //qemu config file inside DefaultEdenHomeDir
 	DefaultSSHKey           = "certs/id_rsa.pub" //file for save ssh key
 	DefaultConfigHidden     = ".eden-config.yml" //file to save config get --all
 	DefaultConfigSaved      = "config_saved.yml" //file to save config during 'eden setup'
 	DefaultSwtpmSockFile    = "swtpm-sock"       //file to communicate with swtpm
 	DefaultAdditionalDisks  = 0                  //number of disks to use alongside with bootable one
+	DefaultTwinLogTail      = 100                //number of last log entries to save into offline device twin
+	DefaultRebootLogWindow  = 5 * time.Minute    //logs before and after unexpected reboot to save into artifacts
 
 	DefaultContext = "default" //default context name
 
-	DefaultConfigEnv   = "EDEN_CONFIG"    //default env for set config
-	DefaultTestArgsEnv = "EDEN_TEST_ARGS" //default env for test arguments
+	DefaultConfigEnv     = "EDEN_CONFIG"      //default env for set config
+	DefaultTestArgsEnv   = "EDEN_TEST_ARGS"   //default env for test arguments
+	DefaultTestResumeEnv = "EDEN_TEST_RESUME" //env to resume escripts from checkpoints
+	DefaultTestSeedEnv   = "EDEN_TEST_SEED"   //env with seed of rand command of escripts
+	DefaultTestBenchEnv  = "EDEN_TEST_BENCH"  //env with file to save measurements of escripts into
+	DefaultTestUsageEnv  = "EDEN_TEST_USAGE"  //env with interval of sampling of resources used by escripts
+	DefaultRedisGroupEnv = "EDEN_REDIS_GROUP" //env with consumer group to read redis streams of controller in
+
+	DefaultPortRegistryEnv  = "EDEN_PORT_REGISTRY"      //env with file of port registry shared by workspaces of user
+	DefaultPortRegistryFile = "eden-port-registry.json" //file of port registry inside runtime directory of user or DefaultEdenHomeDir
 )
 
 // domains, ips, ports
@@ -45,8 +64,10 @@ const (
 	DefaultTelnetPort           = 17777
 	DefaultQemuMonitorPort      = 7788
 	DefaultQemuNetdevSocketPort = 7790
+	DefaultEveSnapshot          = "clean" //name of snapshot of EVE VM restored between tests of stateless suites
 	DefaultSSHPort              = 2222
 	DefaultEVEHost              = "127.0.0.1"
+	DefaultWebConsoleListen     = "localhost:7681" //address of page of 'eden eve console --web'
 	DefaultRedisHost            = "localhost"
 	DefaultRedisPort            = 6379
 	DefaultAdamPort             = 3333
@@ -59,7 +80,7 @@ const (
 	DefaultRegistryTag          = "2.7"
 	DefaultProcTag              = "83cfe07"
 	DefaultMkimageTag           = "8.5.0"
-	DefaultSDNVersion           = "v1.0.0"
+	DefaultSDNVersion           = "v1.0.1"
 	DefaultImage                = "library/alpine"
 	DefaultAdamContainerRef     = "lfedge/adam"
 	DefaultRedisContainerRef    = "redis"
@@ -68,6 +89,9 @@ const (
 	DefaultMkimageContainerRef  = "lfedge/eve-mkimage-raw-efi"
 	DefaultEdenSDNContainerRef  = "lfedge/eden-sdn"
 	DefaultEveRepo              = "https://github.com/lf-edge/eve.git"
+	DefaultEdenRepo             = "lf-edge/eden"
+	DefaultGitHubURL            = "https://github.com"
+	DefaultGitHubAPIURL         = "https://api.github.com"
 	DefaultEveRegistry          = "lfedge/eve"
 	DefaultRegistry             = "docker.io"
 
@@ -85,10 +109,21 @@ const (
 	DefaultEClientTag          = "b1c1de6"
 	DefaultEClientContainerRef = "lfedge/eden-eclient"
 
+	// iPXE bootloaders loaded by PXE clients of netboot server
+	DefaultIPXEBootloaderAmd64 = "https://boot.ipxe.org/ipxe.efi"
+	DefaultIPXEBootloaderArm64 = "https://boot.ipxe.org/arm64-efi/ipxe.efi"
+
 	//DefaultRepeatCount is repeat count for requests
 	DefaultRepeatCount = 20
 	//DefaultRepeatTimeout is time wait for next attempt
-	DefaultRepeatTimeout         = 5 * time.Second
+	DefaultRepeatTimeout = 5 * time.Second
+	//DefaultControllerMaxIdleConns is number of keep-alive connections to controller kept open for reuse
+	DefaultControllerMaxIdleConns = 16
+	//DefaultControllerIdleConnTimeout is time after which unused keep-alive connection to controller is closed
+	DefaultControllerIdleConnTimeout = 90 * time.Second
+	//DefaultContainerReadyTimeout is time to wait for container of eden service to run and pass its health check
+	DefaultContainerReadyTimeout = time.Minute
+
 	DefaultUUID                  = "1"
 	DefaultFileToSave            = "./test.tar"
 	DefaultIsLocal               = false
@@ -124,14 +159,30 @@ const (
 	DefaultEveLogLevel  = "info" // min level of logs saved in files on EVE device
 	DefaultAdamLogLevel = "info" // min level of logs sent from EVE to Adam
 
+	// GICv3 is emulated for arm64 EVE on Apple Silicon to run it with more than 8 vCPUs
 	DefaultQemuAccelDarwin      = "-machine q35,accel=hvf -cpu kvm64,kvmclock=off "
-	DefaultQemuAccelDarwinArm64 = "-machine virt,accel=hvf,usb=off,dump-guest-core=off -cpu host "
+	DefaultQemuAccelDarwinArm64 = "-machine virt,accel=hvf,gic-version=3,usb=off,dump-guest-core=off -cpu host "
 	DefaultQemuAccelLinuxAmd64  = "-machine q35,accel=kvm,dump-guest-core=off,kernel-irqchip=split -cpu host,invtsc=on,kvmclock=off -device intel-iommu,intremap=on,caching-mode=on,aw-bits=48 "
 	DefaultQemuAmd64            = "-machine q35,smm=on --cpu SandyBridge "
 
 	DefaultQemuAccelArm64 = "-machine virt,accel=kvm,usb=off,dump-guest-core=off -cpu host "
 	DefaultQemuArm64      = "-machine virt,virtualization=true -cpu cortex-a57 "
 
+	DefaultQemuAccelWHPX = "-machine q35,accel=whpx,kernel-irqchip=off -cpu max "
+	// DefaultQemuAccelerator is accelerator of EVE VM (auto, kvm, hvf, whpx or tcg),
+	// auto is replaced by the best one detected on setup
+	DefaultQemuAccelerator = "auto"
+
+	// tuned TCG emulation used if acceleration is not available for architecture of EVE,
+	// e.g. to run arm64 EVE on amd64 host: translation blocks are executed by one host thread
+	// per vCPU and the cache of translated code is enlarged to avoid retranslation
+	DefaultQemuTCGAmd64 = "-accel tcg,thread=multi,tb-size=1024 -machine q35,smm=on --cpu SandyBridge "
+	DefaultQemuTCGArm64 = "-accel tcg,thread=multi,tb-size=1024 -machine virt,virtualization=true -cpu cortex-a57 "
+	// DefaultQemuTCG is mode of TCG emulation of EVE (auto, on or off)
+	DefaultQemuTCG = "auto"
+	// DefaultTCGTimeoutScale is multiplier of timeouts of escripts when EVE is emulated with TCG
+	DefaultTCGTimeoutScale = 5
+
 	DefaultAppSubnet        = "10.11.12.0/24"
 	DefaultHostOnlyNotation = "host-only-acl"
 
@@ -151,6 +202,8 @@ const (
 
 	DefaultGeneralModel = "general"
 
+	DefaultPhysicalModel = "physical"
+
 	DefaultEVERemote = false
 
 	DefaultEVEImageSize = 8192
@@ -206,7 +259,30 @@ const (
 	DefaultSdnIPv6Subnet = "fd59:9c46:bc86:2222::/64"
 )
 
+// default timeouts of waits of eden, can be changed in timeouts section of config
+const (
+	DefaultSdnStartTimeout        = 3 * time.Minute  // wait for SDN VM to start
+	DefaultOnboardTimeout         = 15 * time.Minute // wait for onboarding of EVE
+	DefaultSnapshotRestoreTimeout = 3 * time.Minute  // wait for EVE to reconnect after restore of snapshot
+	DefaultAttestationTimeout     = 5 * time.Minute  // wait for result of attestation
+	DefaultAppProbeTimeout        = 5 * time.Minute  // wait for app to become healthy
+	DefaultNetdumpTimeout         = 10 * time.Minute // wait for new netdump of EVE
+	DefaultHookTimeout            = 5 * time.Minute  // wait for command or webhook of hook
+	DefaultPollInterval           = 5 * time.Second  // interval between checks of waits
+)
+
+// StateFormat is version of format of state files of context, it is incremented
+// on changes which older versions of eden cannot read
+const StateFormat = 1
+
 var (
+	//EdenVersion is version of eden, it is set with ldflags during build
+	EdenVersion = "dev"
+
+	//EdenReleaseKey is base64 DER of ed25519 public key which signs releases of eden,
+	//it is set with ldflags during build and used by 'eden upgrade' to verify releases
+	EdenReleaseKey = ""
+
 	//DefaultQemuHostFwd represents port forward for ssh
 	DefaultQemuHostFwd = map[string]string{strconv.Itoa(DefaultSSHPort): "22"}
 )
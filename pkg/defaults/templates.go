@@ -87,6 +87,17 @@ eve:
     #EVE acceleration (set to false if you have problems with qemu)
     accel: {{parse "eve.accel"}}
 
+    #accelerator of EVE VM (auto/kvm/hvf/whpx/tcg),
+    #auto is replaced with the best one detected by eden setup
+    accelerator: '{{parse "eve.accelerator"}}'
+
+    #nested virtualization is available for EVE VM (detected by eden setup)
+    nested: {{parse "eve.nested"}}
+
+    #TCG emulation of EVE instead of acceleration (auto/on/off),
+    #auto emulates EVE of architecture different from the host one (e.g. arm64 on amd64)
+    tcg: '{{parse "eve.tcg"}}'
+
     #variant of hypervisor of EVE (kvm/xen)
     hv: '{{parse "eve.hv"}}'
 
@@ -111,12 +122,24 @@ eve:
     #eve repo used in clone mode (eden.download = false)
     repo: '{{parse "eve.repo"}}'
 
+    #git repo or local directory of EVE to build image from during setup
+    source: '{{parse "eve.source"}}'
+
+    #branch, tag or sha of eve.source to build
+    ref: '{{parse "eve.ref"}}'
+
     #eve registry to use
     registry: '{{parse "eve.registry"}}'
 
     #eve tag
     tag: '{{parse "eve.tag"}}'
 
+    #expected digest of docker image of EVE (sha256:...), setup fails on mismatch
+    digest: '{{parse "eve.digest"}}'
+
+    #public key to verify signature of docker image of EVE with cosign
+    cosign-key: '{{parse "eve.cosign-key"}}'
+
     #port forwarding for EVE VM [(HOST:EVE)] when running without Eden-SDN
     hostfwd: {{  parsemap "eve.hostfwd" }}
 
@@ -142,6 +165,18 @@ eve:
         #format of the installer image (should be "raw" or "iso")
         format: '{{parse "eve.custom-installer.format"}}'
 
+    #out-of-band control of real hardware for physical devmodel
+    #shell commands run with 'sh -c'
+    physical:
+        #command to power on the device (e.g. with PDU or IPMI)
+        power-on: '{{parse "eve.physical.power-on"}}'
+
+        #command to power off the device
+        power-off: '{{parse "eve.physical.power-off"}}'
+
+        #command to connect to serial console of the device
+        console: '{{parse "eve.physical.console"}}'
+
     #dtb directory of EVE
     dtb-part: '{{parse "eve.dtb-part"}}'
 
@@ -181,6 +216,13 @@ eve:
     #additional disks count
     disks: {{parse "eve.disks"}}
 
+    #layout of additional disks of EVE VM (overrides disks count), e.g.
+    #- size: 4096     #size of disk (MB), disk size of EVE if not set
+    #  bus: nvme      #virtio, sata, nvme, scsi or usb (default interface if not set)
+    #  format: qcow2  #format of disk file for QEMU
+    #  persist: true  #place persist of EVE on disk (ZFS pool for several disks), installer only
+    disk-layout: {{parse "eve.disk-layout"}}
+
     #configuration specific to QEMU-emulated device
     qemu:
         #port for QEMU Monitor
@@ -189,6 +231,9 @@ eve:
         #base port for socket-based ethernet interfaces used in QEMU
         netdev-socket-port: {{parse "eve.qemu.netdev-socket-port"}}
 
+        #run VM on qcow2 overlay of image-file, which is kept pristine
+        overlay: {{parse "eve.qemu.overlay"}}
+
 eden:
     #root directory of eden
     root: '{{parse "eden.root"}}'
@@ -201,6 +246,44 @@ eden:
     #download eve instead of build
     download: {{parse "eden.download"}}
 
+    #slot of isolated context, ports of its services are shifted by slot,
+    #0 means that services are shared with other contexts
+    slot: {{parse "eden.slot"}}
+
+    #credentials of private registries to deploy apps from, e.g.
+    #- registry: ghcr.io
+    #  user: name
+    #  password-env: GHCR_TOKEN
+    #- registry: quay.io
+    #  user: robot
+    #  password-file: /run/secrets/quay-password
+    #- registry: 123456789012.dkr.ecr.us-east-1.amazonaws.com
+    #  helper: ecr-login
+    #- registry: gcr.io
+    #  docker-config: /home/user/.docker/config.json
+    registry-auth:
+
+    #object storage to upload artifacts of failed tests into
+    artifacts-upload:
+        #s3 or gcs, upload is disabled if empty
+        provider: '{{parse "eden.artifacts-upload.provider"}}'
+
+        #bucket to upload into
+        bucket: '{{parse "eden.artifacts-upload.bucket"}}'
+
+        #prefix of names of uploaded objects
+        prefix: '{{parse "eden.artifacts-upload.prefix"}}'
+
+        #endpoint of S3-compatible storage (AWS if empty)
+        endpoint: '{{parse "eden.artifacts-upload.endpoint"}}'
+
+        #region of S3 bucket
+        region: '{{parse "eden.artifacts-upload.region"}}'
+
+        #file with credentials (AWS shared credentials or key of GCP service account),
+        #taken from environment if empty
+        credentials: '{{parse "eden.artifacts-upload.credentials"}}'
+
     #eserver is tool for serve images
     eserver:
         #ip (domain name) of eserver for EVE access
@@ -327,6 +410,19 @@ sdn:
 
     #IPv6 subnet to use between Eden-SDN and the host
     ipv6-subnet: '{{parse "sdn.ipv6-subnet"}}'
+
+    #IPv4 subnet to use between Eden-SDN and the host
+    #leave empty to use the free 192.168.x.0/24 subnet of slot of context
+    ipv4-subnet: '{{parse "sdn.ipv4-subnet"}}'
+
+#format and levels of logs of eden
+log: {{parsesection "log"}}
+
+#timeouts of waits of eden, defaults are used for missing ones
+timeouts: {{parsesection "timeouts"}}
+
+#commands and webhooks run before and after operations of eden
+hooks: {{parsesection "hooks"}}
 `
 
 // DefaultQemuTemplate is configuration template for qemu
@@ -392,10 +488,31 @@ const DefaultQemuTemplate = `#qemu config file generated by eden
 {{ end }}
 {{- end -}}
 
-{{ range .Disks }}
+{{- if .HasSCSIDisks }}
+
+[device "scsi0"]
+  driver = "virtio-scsi-pci"
+{{ end }}
+{{ range $i, $disk := .Disks }}
+{{- if $disk.Bus }}
+[drive "disk{{ $i }}"]
+  if = "none"
+  format = "{{ $disk.Format }}"
+  file = "{{ $disk.File }}"
+
+[device]
+  driver = "{{ $disk.QemuDriver }}"
+  drive = "disk{{ $i }}"
+{{- if eq $disk.Bus "nvme" }}
+  serial = "disk{{ $i }}"
+{{- else if eq $disk.Bus "scsi" }}
+  bus = "scsi0.0"
+{{- end }}
+{{- else }}
 [drive]
-  format = "qcow2"
-  file = "{{.}}"
+  format = "{{ $disk.Format }}"
+  file = "{{ $disk.File }}"
+{{- end }}
 {{ end }}
 `
 
@@ -589,3 +706,94 @@ endif
 unset do_prompt
 
 rehash`
+
+// DefaultWebConsoleTemplate is page with terminal of 'eden eve console --web'
+const DefaultWebConsoleTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} console</title>
+<style>
+  body { margin: 0; background: #1e1e1e; color: #d4d4d4; font-family: monospace; }
+  #status { padding: 4px 8px; background: #333; font-size: 12px; }
+  #term { margin: 0; padding: 8px; height: calc(100vh - 40px); overflow-y: auto;
+          white-space: pre-wrap; word-break: break-all; outline: none; font-size: 14px; }
+</style>
+</head>
+<body>
+<div id="status">connecting...</div>
+<pre id="term" tabindex="0"></pre>
+<script>
+const term = document.getElementById("term");
+const status = document.getElementById("status");
+const maxLines = 5000;
+let lines = [""], col = 0, esc = "";
+
+// put prints output of console, control sequences of terminal except erase of line are skipped
+function put(s) {
+  for (const ch of s) {
+    if (esc) {
+      esc += ch;
+      if (esc.length === 2 && ch !== "[") {
+        esc = "";
+      } else if (esc.length > 2 && ch >= "@" && ch <= "~") {
+        if (ch === "K") {
+          lines[lines.length - 1] = lines[lines.length - 1].slice(0, col);
+        }
+        esc = "";
+      }
+      continue;
+    }
+    switch (ch) {
+    case "\x1b": esc = ch; break;
+    case "\r": col = 0; break;
+    case "\n": lines.push(""); col = 0; break;
+    case "\b": if (col > 0) col--; break;
+    case "\x07": break;
+    default: {
+      const line = lines[lines.length - 1].padEnd(col);
+      lines[lines.length - 1] = line.slice(0, col) + ch + line.slice(col + 1);
+      col++;
+    }
+    }
+  }
+  if (lines.length > maxLines) lines.splice(0, lines.length - maxLines);
+  const bottom = term.scrollTop + term.clientHeight >= term.scrollHeight - 4;
+  term.textContent = lines.join("\n");
+  if (bottom) term.scrollTop = term.scrollHeight;
+}
+
+const keys = {
+  Enter: "\r", Backspace: "\x7f", Tab: "\t", Escape: "\x1b", Delete: "\x1b[3~",
+  ArrowUp: "\x1b[A", ArrowDown: "\x1b[B", ArrowRight: "\x1b[C", ArrowLeft: "\x1b[D",
+  Home: "\x1b[H", End: "\x1b[F", PageUp: "\x1b[5~", PageDown: "\x1b[6~",
+};
+const encoder = new TextEncoder();
+const decoder = new TextDecoder("utf-8");
+const ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/ws");
+ws.binaryType = "arraybuffer";
+ws.onopen = () => { status.textContent = "connected to {{.Title}}"; term.focus(); };
+ws.onclose = () => { status.textContent = "disconnected, reload page to connect again"; };
+ws.onmessage = (e) => put(decoder.decode(e.data, {stream: true}));
+
+function send(s) {
+  if (ws.readyState === WebSocket.OPEN) ws.send(encoder.encode(s));
+}
+term.addEventListener("keydown", (e) => {
+  let s = keys[e.key];
+  if (e.ctrlKey && e.key.length === 1) {
+    const code = e.key.toUpperCase().charCodeAt(0);
+    if (code >= 64 && code <= 95) s = String.fromCharCode(code - 64);
+  } else if (!s && e.key.length === 1 && !e.metaKey) {
+    s = e.key;
+  }
+  if (s) { e.preventDefault(); send(s); }
+});
+term.addEventListener("paste", (e) => {
+  e.preventDefault();
+  send(e.clipboardData.getData("text"));
+});
+</script>
+</body>
+</html>
+`
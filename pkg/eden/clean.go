@@ -0,0 +1,98 @@
+package eden
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// CleanOptions selects what is removed by cleanup of eden
+type CleanOptions struct {
+	// KeepImages keeps downloaded and built images of EVE and images of eserver and registry
+	KeepImages bool
+	// KeepCerts keeps certificates of context
+	KeepCerts bool
+	// KeepState keeps configs and state of eden, device in controller and data of services
+	KeepState bool
+	// ContainersOnly removes only containers of services
+	ContainersOnly bool
+	// Volumes removes volumes of containers of services with ContainersOnly
+	Volumes bool
+}
+
+// pathSize returns size of files inside path
+func pathSize(path string) uint64 {
+	var size uint64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size
+}
+
+// cleanPath removes path with description what unless keep is set,
+// in dry-run mode path is printed with its size instead
+func cleanPath(path, what string, keep bool) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if keep {
+		log.Infof("keep %s in %s", what, path)
+		return nil
+	}
+	if utils.IsDryRun() {
+		utils.DryRunf("remove %s %s (%s)", what, path, humanize.Bytes(pathSize(path)))
+		return nil
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("error in %s delete: %w", path, err)
+	}
+	return nil
+}
+
+// removeVolumes removes volumes created by eden for containers of services
+func removeVolumes(containers Containers) error {
+	for _, containerName := range []string{containers.EServer, containers.Redis, containers.Adam, containers.Registry} {
+		if err := utils.RemoveGeneratedVolumeOfContainer(containerName); err != nil {
+			return fmt.Errorf("RemoveGeneratedVolumeOfContainer for %s: %w", containerName, err)
+		}
+	}
+	return nil
+}
+
+// CleanContainers stops and removes containers of services,
+// volumes of containers are removed if volumes is set
+func CleanContainers(containers Containers, volumes bool) error {
+	for _, service := range []struct {
+		name      string
+		container string
+		stop      func(containerName string, rm bool) error
+	}{
+		{"adam", containers.Adam, StopAdam},
+		{"redis", containers.Redis, StopRedis},
+		{"registry", containers.Registry, StopRegistry},
+		{"eserver", containers.EServer, StopEServer},
+	} {
+		if err := service.stop(service.container, true); err != nil {
+			return fmt.Errorf("cannot remove %s: %w", service.name, err)
+		}
+		log.Infof("%s removed", service.name)
+	}
+	if volumes {
+		return removeVolumes(containers)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package eden
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ControllerFaultKind is kind of misbehavior of controller injected by ControllerFaultProxy
+type ControllerFaultKind string
+
+// Kinds of faults of controller
+const (
+	FaultDelay    ControllerFaultKind = "delay"    // delay response for Delay
+	FaultError    ControllerFaultKind = "error"    // respond with Status without forwarding request to controller
+	FaultStale    ControllerFaultKind = "stale"    // respond with the last successful response for endpoint
+	FaultOversize ControllerFaultKind = "oversize" // pad body of response up to Size bytes
+)
+
+// ControllerFault describes misbehavior of controller for requests matching Endpoint
+type ControllerFault struct {
+	ID   string              `json:"id"`
+	Kind ControllerFaultKind `json:"kind"`
+	// Endpoint is regular expression for path of request
+	Endpoint string        `json:"endpoint"`
+	Delay    time.Duration `json:"delay,omitempty"`
+	Status   int           `json:"status,omitempty"`
+	Size     int           `json:"size,omitempty"`
+	// Count limits number of requests affected by fault, 0 for unlimited
+	Count int `json:"count,omitempty"`
+	// Until is time of expiration of fault, zero for no expiration
+	Until time.Time `json:"until"`
+
+	endpoint *regexp.Regexp
+}
+
+// Validate checks parameters of fault and compiles its Endpoint
+func (f *ControllerFault) Validate() (err error) {
+	if f.endpoint, err = regexp.Compile(f.Endpoint); err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", f.Endpoint, err)
+	}
+	switch f.Kind {
+	case FaultDelay:
+		if f.Delay <= 0 {
+			return fmt.Errorf("delay fault requires positive delay")
+		}
+	case FaultError:
+		if f.Status < 400 || f.Status > 599 {
+			return fmt.Errorf("error fault requires status between 400 and 599, got %d", f.Status)
+		}
+	case FaultStale:
+	case FaultOversize:
+		if f.Size <= 0 {
+			return fmt.Errorf("oversize fault requires positive size")
+		}
+	default:
+		return fmt.Errorf("unknown kind of fault: %q", f.Kind)
+	}
+	return nil
+}
+
+// String returns one line description of fault
+func (f *ControllerFault) String() string {
+	description := fmt.Sprintf("%s %s %s", f.ID, f.Kind, f.Endpoint)
+	switch f.Kind {
+	case FaultDelay:
+		description += fmt.Sprintf(" delay=%s", f.Delay)
+	case FaultError:
+		description += fmt.Sprintf(" status=%d", f.Status)
+	case FaultOversize:
+		description += fmt.Sprintf(" size=%d", f.Size)
+	}
+	if f.Count > 0 {
+		description += fmt.Sprintf(" count=%d", f.Count)
+	}
+	if !f.Until.IsZero() {
+		description += fmt.Sprintf(" until=%s", f.Until.Format(time.RFC3339))
+	}
+	return description
+}
+
+// LoadControllerFaults reads faults from file, no faults are returned if file does not exist
+func LoadControllerFaults(fileName string) ([]*ControllerFault, error) {
+	data, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var faults []*ControllerFault
+	if err := json.Unmarshal(data, &faults); err != nil {
+		return nil, fmt.Errorf("cannot parse faults %s: %w", fileName, err)
+	}
+	for _, f := range faults {
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("fault %s: %w", f.ID, err)
+		}
+	}
+	return faults, nil
+}
+
+// SaveControllerFaults writes faults into file
+func SaveControllerFaults(fileName string, faults []*ControllerFault) error {
+	data, err := json.MarshalIndent(faults, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, data, 0644)
+}
+
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+type oversizeKey struct{}
+
+// ControllerFaultProxy is reverse proxy to controller which injects faults read from file into
+// its responses, file is re-read on change, so faults can be changed while proxy is running
+type ControllerFaultProxy struct {
+	proxy      *httputil.ReverseProxy
+	faultsFile string
+
+	mu      sync.Mutex
+	faults  []*ControllerFault
+	modTime time.Time
+	hits    map[string]int
+	cache   map[string]*cachedResponse
+}
+
+// NewControllerFaultProxy creates proxy to controller on target with faults from faultsFile
+func NewControllerFaultProxy(target *url.URL, faultsFile string) *ControllerFaultProxy {
+	p := &ControllerFaultProxy{
+		faultsFile: faultsFile,
+		hits:       map[string]int{},
+		cache:      map[string]*cachedResponse{},
+	}
+	p.proxy = httputil.NewSingleHostReverseProxy(target)
+	p.proxy.Transport = &http.Transport{
+		Proxy: nil,
+		// controller is local and uses certificate for domain of EVE
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+	}
+	p.proxy.ModifyResponse = p.modifyResponse
+	return p
+}
+
+// reload re-reads faults if file was changed, must be called with mu held
+func (p *ControllerFaultProxy) reload() {
+	st, err := os.Stat(p.faultsFile)
+	if os.IsNotExist(err) {
+		p.faults, p.modTime = nil, time.Time{}
+		return
+	}
+	if err != nil || st.ModTime().Equal(p.modTime) {
+		return
+	}
+	faults, err := LoadControllerFaults(p.faultsFile)
+	if err != nil {
+		log.Errorf("cannot load faults: %s", err)
+		return
+	}
+	p.faults, p.modTime = faults, st.ModTime()
+	log.Infof("%d faults loaded from %s", len(faults), p.faultsFile)
+}
+
+// activeFaults returns faults to apply to request and counts them as hit
+func (p *ControllerFaultProxy) activeFaults(r *http.Request) []*ControllerFault {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reload()
+	var result []*ControllerFault
+	now := time.Now()
+	for _, f := range p.faults {
+		if !f.Until.IsZero() && now.After(f.Until) {
+			continue
+		}
+		if f.Count > 0 && p.hits[f.ID] >= f.Count {
+			continue
+		}
+		if !f.endpoint.MatchString(r.URL.Path) {
+			continue
+		}
+		p.hits[f.ID]++
+		result = append(result, f)
+	}
+	return result
+}
+
+func cacheKey(r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
+
+// ServeHTTP applies active faults to request and forwards it to controller if needed
+func (p *ControllerFaultProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	oversize := 0
+	for _, f := range p.activeFaults(r) {
+		log.Infof("fault %s (%s) injected into %s %s", f.ID, f.Kind, r.Method, r.URL.Path)
+		switch f.Kind {
+		case FaultDelay:
+			select {
+			case <-time.After(f.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		case FaultError:
+			http.Error(w, http.StatusText(f.Status), f.Status)
+			return
+		case FaultStale:
+			p.mu.Lock()
+			cached := p.cache[cacheKey(r)]
+			p.mu.Unlock()
+			if cached == nil {
+				log.Warnf("no response for %s %s to serve stale, request is forwarded", r.Method, r.URL.Path)
+				continue
+			}
+			for k, v := range cached.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(cached.status)
+			_, _ = w.Write(cached.body)
+			return
+		case FaultOversize:
+			oversize = f.Size
+		}
+	}
+	if oversize > 0 {
+		r = r.WithContext(context.WithValue(r.Context(), oversizeKey{}, oversize))
+	}
+	p.proxy.ServeHTTP(w, r)
+}
+
+// modifyResponse caches successful responses for stale faults and pads body for oversize faults
+func (p *ControllerFaultProxy) modifyResponse(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		p.mu.Lock()
+		p.cache[cacheKey(resp.Request)] = &cachedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body}
+		p.mu.Unlock()
+	}
+	if size, ok := resp.Request.Context().Value(oversizeKey{}).(int); ok && len(body) < size {
+		body = append(body, make([]byte, size-len(body))...)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
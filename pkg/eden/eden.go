@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -36,9 +37,27 @@ import (
 
 const bootstrapFilename = "bootstrap-config.pb"
 
+// Containers are names of docker containers of eden services
+type Containers struct {
+	Adam     string
+	Redis    string
+	Registry string
+	EServer  string
+}
+
+// DefaultContainers returns names of containers shared by contexts
+func DefaultContainers() Containers {
+	return Containers{
+		Adam:     defaults.DefaultAdamContainerName,
+		Redis:    defaults.DefaultRedisContainerName,
+		Registry: defaults.DefaultRegistryContainerName,
+		EServer:  defaults.DefaultEServerContainerName,
+	}
+}
+
 // StartRedis function run redis in docker with mounted redisPath:/data
 // if redisForce is set, it recreates container
-func StartRedis(redisPort int, redisPath string, redisForce bool, redisTag string,
+func StartRedis(containerName string, redisPort int, redisPath string, redisForce bool, redisTag string,
 	enableIPv6 bool, ipv6Subnet string) (err error) {
 	portMap := map[string]string{"6379": strconv.Itoa(redisPort)}
 	volumeMap := map[string]string{"/data": redisPath}
@@ -55,59 +74,63 @@ func StartRedis(redisPort int, redisPath string, redisForce bool, redisTag strin
 	} else {
 		log.Errorf("cannot read redis password: %v", err)
 	}
-	if redisPath != "" {
+	if redisPath != "" && !utils.IsDryRun() {
 		if err = os.MkdirAll(redisPath, 0755); err != nil {
 			return fmt.Errorf("StartRedis: Cannot create directory for redis (%s): %s", redisPath, err)
 		}
 	}
 	if redisForce {
-		_ = utils.StopContainer(defaults.DefaultRedisContainerName, true)
+		_ = utils.StopContainer(containerName, true)
 		if err := utils.CreateAndRunContainer(
-			defaults.DefaultRedisContainerName, defaults.DefaultRedisContainerRef+":"+redisTag,
+			containerName, defaults.DefaultRedisContainerRef+":"+redisTag,
 			portMap, volumeMap, redisServerCommand, nil, enableIPv6, ipv6Subnet); err != nil {
 			return fmt.Errorf("StartRedis: error in create redis container: %s", err)
 		}
 	} else {
-		state, err := utils.StateContainer(defaults.DefaultRedisContainerName)
-		if err != nil {
+		state, err := utils.InspectContainer(containerName)
+		if err != nil && !errors.Is(err, utils.ErrContainerNotFound) {
 			return fmt.Errorf("StartRedis: error in get state of redis container: %s", err)
 		}
-		if state == "" {
+		if state == nil {
 			if err := utils.CreateAndRunContainer(
-				defaults.DefaultRedisContainerName, defaults.DefaultRedisContainerRef+":"+redisTag,
+				containerName, defaults.DefaultRedisContainerRef+":"+redisTag,
 				portMap, volumeMap, redisServerCommand, nil, enableIPv6, ipv6Subnet); err != nil {
 				return fmt.Errorf("StartRedis: error in create redis container: %s", err)
 			}
-		} else if !strings.Contains(state, "running") {
-			if err := utils.StartContainer(defaults.DefaultRedisContainerName); err != nil {
+		} else if !state.Running() {
+			if err := utils.StartContainer(containerName); err != nil {
 				return fmt.Errorf("StartRedis: error in restart redis container: %s", err)
 			}
 		}
 	}
+	if err := utils.WaitContainerReady(containerName, defaults.DefaultContainerReadyTimeout); err != nil {
+		return fmt.Errorf("StartRedis: %w", err)
+	}
 	return nil
 }
 
 // StopRedis function stop redis container
-func StopRedis(redisRm bool) (err error) {
-	state, err := utils.StateContainer(defaults.DefaultRedisContainerName)
+func StopRedis(containerName string, redisRm bool) (err error) {
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("StopRedis: error in get state of redis container: %s", err)
 	}
-	if !strings.Contains(state, "running") {
+	if !state.Running() {
 		if redisRm {
-			if err := utils.StopContainer(defaults.DefaultRedisContainerName, true); err != nil {
+			if err := utils.StopContainer(containerName, true); err != nil {
 				return fmt.Errorf("StopRedis: error in rm redis container: %s", err)
 			}
 		}
-	} else if state == "" {
-		return nil
 	} else {
 		if redisRm {
-			if err := utils.StopContainer(defaults.DefaultRedisContainerName, false); err != nil {
+			if err := utils.StopContainer(containerName, false); err != nil {
 				return fmt.Errorf("StopRedis: error in rm redis container: %s", err)
 			}
 		} else {
-			if err := utils.StopContainer(defaults.DefaultRedisContainerName, true); err != nil {
+			if err := utils.StopContainer(containerName, true); err != nil {
 				return fmt.Errorf("StopRedis: error in rm redis container: %s", err)
 			}
 		}
@@ -116,20 +139,20 @@ func StopRedis(redisRm bool) (err error) {
 }
 
 // StatusRedis function return status of redis
-func StatusRedis() (status string, err error) {
-	state, err := utils.StateContainer(defaults.DefaultRedisContainerName)
+func StatusRedis(containerName string) (status string, err error) {
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return "container doesn't exist", nil
+	}
 	if err != nil {
 		return "", fmt.Errorf("StatusRedis: error in get state of redis container: %s", err)
 	}
-	if state == "" {
-		return "container doesn't exist", nil
-	}
-	return state, nil
+	return state.String(), nil
 }
 
 // StartAdam function run adam in docker with mounted adamPath/run:/adam/run
 // if adamForce is set, it recreates container
-func StartAdam(adamPort int, adamPath string, adamForce bool, adamTag string,
+func StartAdam(containerName string, adamPort int, adamPath string, adamForce bool, adamTag string,
 	adamRemoteRedisURL string, apiV1 bool, enableIPv6 bool, ipv6Subnet string, opts ...string) (err error) {
 	edenHome, err := utils.DefaultEdenDir()
 	if err != nil {
@@ -186,53 +209,57 @@ func StartAdam(adamPort int, adamPath string, adamForce bool, adamTag string,
 	adamServerCommand = append(adamServerCommand, opts...)
 
 	if adamForce {
-		_ = utils.StopContainer(defaults.DefaultAdamContainerName, true)
+		_ = utils.StopContainer(containerName, true)
 		if err := utils.CreateAndRunContainer(
-			defaults.DefaultAdamContainerName, defaults.DefaultAdamContainerRef+":"+adamTag,
+			containerName, defaults.DefaultAdamContainerRef+":"+adamTag,
 			portMap, volumeMap, adamServerCommand, nil, enableIPv6, ipv6Subnet); err != nil {
 			return fmt.Errorf("StartAdam: error in create adam container: %s", err)
 		}
 	} else {
-		state, err := utils.StateContainer(defaults.DefaultAdamContainerName)
-		if err != nil {
+		state, err := utils.InspectContainer(containerName)
+		if err != nil && !errors.Is(err, utils.ErrContainerNotFound) {
 			return fmt.Errorf("StartAdam: error in get state of adam container: %s", err)
 		}
-		if state == "" {
+		if state == nil {
 			if err := utils.CreateAndRunContainer(
-				defaults.DefaultAdamContainerName, defaults.DefaultAdamContainerRef+":"+adamTag,
+				containerName, defaults.DefaultAdamContainerRef+":"+adamTag,
 				portMap, volumeMap, adamServerCommand, nil, enableIPv6, ipv6Subnet); err != nil {
 				return fmt.Errorf("StartAdam: error in create adam container: %s", err)
 			}
-		} else if !strings.Contains(state, "running") {
-			if err := utils.StartContainer(defaults.DefaultAdamContainerName); err != nil {
+		} else if !state.Running() {
+			if err := utils.StartContainer(containerName); err != nil {
 				return fmt.Errorf("StartAdam: error in restart adam container: %s", err)
 			}
 		}
 	}
+	if err := utils.WaitContainerReady(containerName, defaults.DefaultContainerReadyTimeout); err != nil {
+		return fmt.Errorf("StartAdam: %w", err)
+	}
 	return nil
 }
 
 // StopAdam function stop adam container
-func StopAdam(adamRm bool) (err error) {
-	state, err := utils.StateContainer(defaults.DefaultAdamContainerName)
+func StopAdam(containerName string, adamRm bool) (err error) {
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("StopAdam: error in get state of adam container: %s", err)
 	}
-	if !strings.Contains(state, "running") {
+	if !state.Running() {
 		if adamRm {
-			if err := utils.StopContainer(defaults.DefaultAdamContainerName, true); err != nil {
+			if err := utils.StopContainer(containerName, true); err != nil {
 				return fmt.Errorf("StopAdam: error in rm adam container: %s", err)
 			}
 		}
-	} else if state == "" {
-		return nil
 	} else {
 		if adamRm {
-			if err := utils.StopContainer(defaults.DefaultAdamContainerName, false); err != nil {
+			if err := utils.StopContainer(containerName, false); err != nil {
 				return fmt.Errorf("StopAdam: error in rm adam container: %s", err)
 			}
 		} else {
-			if err := utils.StopContainer(defaults.DefaultAdamContainerName, true); err != nil {
+			if err := utils.StopContainer(containerName, true); err != nil {
 				return fmt.Errorf("StopAdam: error in rm adam container: %s", err)
 			}
 		}
@@ -241,59 +268,61 @@ func StopAdam(adamRm bool) (err error) {
 }
 
 // StatusAdam function return status of adam
-func StatusAdam() (status string, err error) {
-	state, err := utils.StateContainer(defaults.DefaultAdamContainerName)
+func StatusAdam(containerName string) (status string, err error) {
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return "container doesn't exist", nil
+	}
 	if err != nil {
 		return "", fmt.Errorf("StatusAdam: error in get state of adam container: %s", err)
 	}
-	if state == "" {
-		return "container doesn't exist", nil
-	}
-	return state, nil
+	return state.String(), nil
 }
 
 // StartRegistry function run registry in docker
-func StartRegistry(port int, tag, registryPath string, enableIPv6 bool, ipv6Subnet string, opts ...string) (err error) {
-	containerName := defaults.DefaultRegistryContainerName
+func StartRegistry(containerName string, port int, tag, registryPath string, enableIPv6 bool, ipv6Subnet string, opts ...string) (err error) {
 	ref := defaults.DefaultRegistryContainerRef
 	serviceName := "registry"
 	portMap := map[string]string{"5000": strconv.Itoa(port)}
 	cmd := []string{}
 	cmd = append(cmd, opts...)
 	volumeMap := map[string]string{"/var/lib/registry": registryPath}
-	state, err := utils.StateContainer(containerName)
-	if err != nil {
+	state, err := utils.InspectContainer(containerName)
+	if err != nil && !errors.Is(err, utils.ErrContainerNotFound) {
 		return fmt.Errorf("StartRegistry: error in get state of %s container: %s", serviceName, err)
 	}
-	if state == "" {
+	if state == nil {
 		if err := utils.CreateAndRunContainer(
 			containerName, ref+":"+tag, portMap, volumeMap, cmd, nil, enableIPv6, ipv6Subnet); err != nil {
 			return fmt.Errorf("StartRegistry: error in create %s container: %s", serviceName, err)
 		}
-	} else if !strings.Contains(state, "running") {
+	} else if !state.Running() {
 		if err := utils.StartContainer(containerName); err != nil {
 			return fmt.Errorf("StartRegistry: error in restart %s container: %s", serviceName, err)
 		}
 	}
+	if err := utils.WaitContainerReady(containerName, defaults.DefaultContainerReadyTimeout); err != nil {
+		return fmt.Errorf("StartRegistry: %w", err)
+	}
 	return nil
 }
 
 // StopRegistry function stop registry container
-func StopRegistry(rm bool) (err error) {
-	containerName := defaults.DefaultRegistryContainerName
+func StopRegistry(containerName string, rm bool) (err error) {
 	serviceName := "registry"
-	state, err := utils.StateContainer(containerName)
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("StopRegistry: error in get state of %s container: %s", serviceName, err)
 	}
-	if !strings.Contains(state, "running") {
+	if !state.Running() {
 		if rm {
 			if err := utils.StopContainer(containerName, true); err != nil {
 				return fmt.Errorf("StopRegistry: error in rm %s container: %s", serviceName, err)
 			}
 		}
-	} else if state == "" {
-		return nil
 	} else {
 		if rm {
 			if err := utils.StopContainer(containerName, false); err != nil {
@@ -309,78 +338,81 @@ func StopRegistry(rm bool) (err error) {
 }
 
 // StatusRegistry function return status of registry
-func StatusRegistry() (status string, err error) {
-	containerName := defaults.DefaultRegistryContainerName
+func StatusRegistry(containerName string) (status string, err error) {
 	serviceName := "registry"
-	state, err := utils.StateContainer(containerName)
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return "container doesn't exist", nil
+	}
 	if err != nil {
 		return "", fmt.Errorf("StatusRegistry: error in get state of %s container: %s", serviceName, err)
 	}
-	if state == "" {
-		return "container doesn't exist", nil
-	}
-	return state, nil
+	return state.String(), nil
 }
 
 // StartEServer function run eserver in docker
 // if eserverForce is set, it recreates container
-func StartEServer(serverPort int, imageDist string, eserverForce bool, eserverTag string,
+func StartEServer(containerName string, serverPort int, imageDist string, eserverForce bool, eserverTag string,
 	enableIPv6 bool, ipv6Subnet string) (err error) {
 	portMap := map[string]string{"8888": strconv.Itoa(serverPort)}
 	volumeMap := map[string]string{"/eserver/run/eserver/": imageDist}
 	eserverServerCommand := strings.Fields("server")
 	// lets make sure eserverImageDist exists
-	if imageDist != "" && os.MkdirAll(imageDist, os.ModePerm) != nil {
+	if imageDist != "" && !utils.IsDryRun() && os.MkdirAll(imageDist, os.ModePerm) != nil {
 		return fmt.Errorf("StartEServer: %s does not exist and can not be created", imageDist)
 	}
 	if eserverForce {
-		_ = utils.StopContainer(defaults.DefaultEServerContainerName, true)
+		_ = utils.StopContainer(containerName, true)
 		if err := utils.CreateAndRunContainer(
-			defaults.DefaultEServerContainerName, defaults.DefaultEServerContainerRef+":"+eserverTag,
+			containerName, defaults.DefaultEServerContainerRef+":"+eserverTag,
 			portMap, volumeMap, eserverServerCommand, nil, enableIPv6, ipv6Subnet); err != nil {
 			return fmt.Errorf("StartEServer: error in create eserver container: %s", err)
 		}
 	} else {
-		state, err := utils.StateContainer(defaults.DefaultEServerContainerName)
-		if err != nil {
+		state, err := utils.InspectContainer(containerName)
+		if err != nil && !errors.Is(err, utils.ErrContainerNotFound) {
 			return fmt.Errorf("StartEServer: error in get state of eserver container: %s", err)
 		}
-		if state == "" {
+		if state == nil {
 			if err := utils.CreateAndRunContainer(
-				defaults.DefaultEServerContainerName, defaults.DefaultEServerContainerRef+":"+eserverTag,
+				containerName, defaults.DefaultEServerContainerRef+":"+eserverTag,
 				portMap, volumeMap, eserverServerCommand, nil, enableIPv6, ipv6Subnet); err != nil {
 				return fmt.Errorf("StartEServer: error in create eserver container: %s", err)
 			}
-		} else if !strings.Contains(state, "running") {
-			if err := utils.StartContainer(defaults.DefaultEServerContainerName); err != nil {
+		} else if !state.Running() {
+			if err := utils.StartContainer(containerName); err != nil {
 				return fmt.Errorf("StartEServer: error in restart eserver container: %s", err)
 			}
 		}
 	}
+	if err := utils.WaitContainerReady(containerName, defaults.DefaultContainerReadyTimeout); err != nil {
+		return fmt.Errorf("StartEServer: %w", err)
+	}
 	return nil
 }
 
 // StopEServer function stop eserver container
-func StopEServer(eserverRm bool) (err error) {
-	state, err := utils.StateContainer(defaults.DefaultEServerContainerName)
+func StopEServer(containerName string, eserverRm bool) (err error) {
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("StopEServer: error in get state of eserver container: %s", err)
 	}
-	if !strings.Contains(state, "running") {
+	if !state.Running() {
 		if eserverRm {
-			if err := utils.StopContainer(defaults.DefaultEServerContainerName, true); err != nil {
+			if err := utils.StopContainer(containerName, true); err != nil {
 				return fmt.Errorf("StopEServer: error in rm eserver container: %s", err)
 			}
 		}
-	} else if state == "" {
-		return nil
 	} else {
 		if eserverRm {
-			if err := utils.StopContainer(defaults.DefaultEServerContainerName, false); err != nil {
+			if err := utils.StopContainer(containerName, false); err != nil {
 				return fmt.Errorf("StopEServer: error in rm eserver container: %s", err)
 			}
 		} else {
-			if err := utils.StopContainer(defaults.DefaultEServerContainerName, true); err != nil {
+			if err := utils.StopContainer(containerName, true); err != nil {
 				return fmt.Errorf("StopEServer: error in rm eserver container: %s", err)
 			}
 		}
@@ -389,15 +421,15 @@ func StopEServer(eserverRm bool) (err error) {
 }
 
 // StatusEServer function return eserver of adam
-func StatusEServer() (status string, err error) {
-	state, err := utils.StateContainer(defaults.DefaultEServerContainerName)
+func StatusEServer(containerName string) (status string, err error) {
+	state, err := utils.InspectContainer(containerName)
+	if errors.Is(err, utils.ErrContainerNotFound) {
+		return "container doesn't exist", nil
+	}
 	if err != nil {
 		return "", fmt.Errorf("StatusEServer: error in get eserver of adam container: %s", err)
 	}
-	if state == "" {
-		return "container doesn't exist", nil
-	}
-	return state, nil
+	return state.String(), nil
 }
 
 // GenerateEveCerts function generates certs for EVE
@@ -769,14 +801,112 @@ func MakeEveInRepo(desc utils.EVEDescription, dist string) (image, additional st
 	return
 }
 
+// CheckoutEveSource prepares source of EVE to build image from: local directory
+// is used as is, git repo is fetched into dist and ref (branch, tag or sha) is
+// checked out there. It returns directory with source of EVE.
+func CheckoutEveSource(source, ref, dist string) (string, error) {
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		if ref != "" {
+			log.Warnf("CheckoutEveSource: ref %s is ignored for local directory %s", ref, source)
+		}
+		return source, nil
+	}
+	if ref == "" {
+		ref = "master"
+	}
+	if _, err := os.Stat(filepath.Join(dist, ".git")); os.IsNotExist(err) {
+		if _, err := os.Stat(dist); !os.IsNotExist(err) {
+			return "", fmt.Errorf("CheckoutEveSource: directory already exists and it is not git repo: %s", dist)
+		}
+		if err := utils.RunCommandWithLogAndWait("git", defaults.DefaultLogLevelToPrint, "init", "-q", dist); err != nil {
+			return "", fmt.Errorf("CheckoutEveSource: %w", err)
+		}
+	}
+	// fetch of ref works for branches, tags and sha
+	for _, args := range [][]string{
+		{"-C", dist, "fetch", "--depth", "1", source, ref},
+		{"-C", dist, "checkout", "--force", "--detach", "FETCH_HEAD"},
+	} {
+		log.Infof("CheckoutEveSource run: %s %s", "git", strings.Join(args, " "))
+		if err := utils.RunCommandWithLogAndWait("git", defaults.DefaultLogLevelToPrint, args...); err != nil {
+			return "", fmt.Errorf("CheckoutEveSource: %w", err)
+		}
+	}
+	return dist, nil
+}
+
+// MakeEveInstallerInRepo build raw installer image of EVE from source
+func MakeEveInstallerInRepo(desc utils.EVEDescription, dist string) (string, error) {
+	if _, err := os.Stat(dist); os.IsNotExist(err) {
+		return "", fmt.Errorf("MakeEveInstallerInRepo: directory not exists: %s", dist)
+	}
+	if desc.Arch != runtime.GOARCH {
+		return "", fmt.Errorf("MakeEveInstallerInRepo: current arch (%s) is not equal target (%s), we do not support cross-builds now", runtime.GOARCH, desc.Arch)
+	}
+	commandArgsString := fmt.Sprintf("-C %s ZARCH=%s HV=%s CONF_DIR=%s installer-raw",
+		dist, desc.Arch, desc.HV, desc.ConfigPath)
+	log.Infof("MakeEveInstallerInRepo run: %s %s", "make", commandArgsString)
+	if err := utils.RunCommandWithLogAndWait("make", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
+		return "", fmt.Errorf("MakeEveInstallerInRepo: %w", err)
+	}
+	return filepath.Join(dist, "dist", desc.Arch, "current", "installer.raw"), nil
+}
+
+// EveBuildVersion returns version of EVE built from source in dir, it includes
+// sha of commit and hypervisor, makefiles of EVE place images into dist/<arch>/<version>
+func EveBuildVersion(desc utils.EVEDescription, dir string) (string, error) {
+	stdout, stderr, err := utils.RunCommandAndWait("make", "-s", "-C", dir,
+		fmt.Sprintf("ZARCH=%s", desc.Arch), fmt.Sprintf("HV=%s", desc.HV), "version")
+	if err != nil {
+		return "", fmt.Errorf("EveBuildVersion: %w (%s)", err, strings.TrimSpace(stderr))
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	version := strings.TrimSpace(lines[len(lines)-1])
+	if version == "" {
+		return "", fmt.Errorf("EveBuildVersion: no version printed by make in %s", dir)
+	}
+	return version, nil
+}
+
+// CheckEveBuild checks that file built from source in dir belongs to build of
+// version, not to the previous build left in dist/<arch>/current
+func CheckEveBuild(file, dir, arch, version string) error {
+	resolved, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return fmt.Errorf("CheckEveBuild: %w", err)
+	}
+	distDir, err := filepath.EvalSymlinks(filepath.Join(dir, "dist", arch))
+	if err != nil {
+		return fmt.Errorf("CheckEveBuild: %w", err)
+	}
+	rel, err := filepath.Rel(distDir, resolved)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("CheckEveBuild: %s is not inside %s", resolved, distDir)
+	}
+	buildDir := strings.Split(rel, string(filepath.Separator))[0]
+	// version printed by older EVE does not include hypervisor and arch
+	if buildDir != version && !strings.HasPrefix(buildDir, version+"-") {
+		return fmt.Errorf("CheckEveBuild: %s is from build %s, expected %s", file, buildDir, version)
+	}
+	return nil
+}
+
 // CleanContext cleanup only context data
-func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, sdnPID, vmName string, configSaved string, remote, sdnDisable bool) (err error) {
+func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, sdnPID, vmName string, configSaved string, remote, sdnDisable bool,
+	opts CleanOptions) (err error) {
 	edenDir, err := utils.DefaultEdenDir()
 	if err != nil {
 		return fmt.Errorf("CleanContext: %s", err)
 	}
 	eveStatusFile := filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", eveUUID))
-	if _, err = os.Stat(eveStatusFile); !os.IsNotExist(err) {
+	_, err = os.Stat(eveStatusFile)
+	switch {
+	case os.IsNotExist(err):
+	case opts.KeepState:
+		log.Infof("keep device in controller and its state in %s", eveStatusFile)
+	case utils.IsDryRun():
+		utils.DryRunf("remove device with onboarding UUID %s from controller and its state %s", eveUUID, eveStatusFile)
+	default:
 		ctrl, err := controller.CloudPrepare()
 		if err != nil {
 			return fmt.Errorf("CleanContext: error in CloudPrepare: %s", err)
@@ -846,48 +976,42 @@ func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, sdnPID, vmNam
 		}
 		StopSDN(devModel, sdnPID, sdnDisable)
 	}
-	if _, err = os.Stat(eveDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(eveDist); err != nil {
-			return fmt.Errorf("CleanContext: error in %s delete: %s", eveDist, err)
-		}
-	}
-	if _, err = os.Stat(certsDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(certsDist); err != nil {
-			return fmt.Errorf("CleanContext: error in %s delete: %s", certsDist, err)
-		}
-	}
-	if _, err = os.Stat(imagesDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(imagesDist); err != nil {
-			return fmt.Errorf("CleanContext: error in %s delete: %s", imagesDist, err)
-		}
-	}
-	if _, err = os.Stat(configSaved); !os.IsNotExist(err) {
-		if err = os.RemoveAll(configSaved); err != nil {
-			return fmt.Errorf("CleanContext: error in %s delete: %s", configSaved, err)
+	for _, item := range []struct {
+		path string
+		what string
+		keep bool
+	}{
+		{eveDist, "EVE", opts.KeepImages},
+		{certsDist, "certificates", opts.KeepCerts},
+		{imagesDist, "images of EVE", opts.KeepImages},
+		{configSaved, "saved config", opts.KeepState},
+	} {
+		if err = cleanPath(item.path, item.what, item.keep); err != nil {
+			return fmt.Errorf("CleanContext: %w", err)
 		}
 	}
 	return nil
 }
 
 // StopEden teardown Eden
-func StopEden(adamRm, redisRm, registryRm, eserverRm, eveRemote bool,
+func StopEden(containers Containers, adamRm, redisRm, registryRm, eserverRm, eveRemote bool,
 	evePidFile, swtpmPidFile, sdnPidFile, devModel, vmName string, sdnDisable bool) {
-	if err := StopAdam(adamRm); err != nil {
+	if err := StopAdam(containers.Adam, adamRm); err != nil {
 		log.Infof("cannot stop adam: %s", err)
 	} else {
 		log.Infof("adam stopped")
 	}
-	if err := StopRedis(redisRm); err != nil {
+	if err := StopRedis(containers.Redis, redisRm); err != nil {
 		log.Infof("cannot stop redis: %s", err)
 	} else {
 		log.Infof("redis stopped")
 	}
-	if err := StopRegistry(registryRm); err != nil {
+	if err := StopRegistry(containers.Registry, registryRm); err != nil {
 		log.Infof("cannot stop registry: %s", err)
 	} else {
 		log.Infof("registry stopped")
 	}
-	if err := StopEServer(eserverRm); err != nil {
+	if err := StopEServer(containers.EServer, eserverRm); err != nil {
 		log.Infof("cannot stop eserver: %s", err)
 	} else {
 		log.Infof("eserver stopped")
@@ -951,70 +1075,38 @@ func StopSDN(devModel, sdnPidFile string, sdnDisable bool) {
 }
 
 // CleanEden teardown Eden and cleanup
-func CleanEden(eveDist, adamDist, certsDist, imagesDist, eserverDist, redisDist,
+func CleanEden(containers Containers, eveDist, adamDist, certsDist, imagesDist, eserverDist, redisDist,
 	registryDist, configDist, evePID, sdnPID, configSaved string, remote bool,
-	devModel, vmName string, sdnDisable bool) (err error) {
+	devModel, vmName string, sdnDisable bool, opts CleanOptions) (err error) {
 	command := "swtpm"
 	swtpmPidFile := filepath.Join(imagesDist, fmt.Sprintf("%s.pid", command))
-	StopEden(true, true, true, true, remote,
+	StopEden(containers, true, true, true, true, remote,
 		evePID, swtpmPidFile, sdnPID, devModel, vmName, sdnDisable)
-	if _, err = os.Stat(eveDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(eveDist); err != nil {
-			return fmt.Errorf("1 CleanEden: error in %s delete: %s", eveDist, err)
-		}
-	}
-	if _, err = os.Stat(certsDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(certsDist); err != nil {
-			return fmt.Errorf("2 CleanEden: error in %s delete: %s", certsDist, err)
-		}
-	}
-	if _, err = os.Stat(imagesDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(imagesDist); err != nil {
-			return fmt.Errorf("3 CleanEden: error in %s delete: %s", imagesDist, err)
-		}
-	}
-	if _, err = os.Stat(eserverDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(eserverDist); err != nil {
-			return fmt.Errorf("4 CleanEden: error in %s delete: %s", eserverDist, err)
-		}
-	}
-	if _, err = os.Stat(adamDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(adamDist); err != nil {
-			return fmt.Errorf("5 CleanEden: error in %s delete: %s", adamDist, err)
-		}
-	}
-	if _, err = os.Stat(redisDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(redisDist); err != nil {
-			return fmt.Errorf("6 CleanEden: error in %s delete: %s", redisDist, err)
-		}
-	}
-	if _, err = os.Stat(registryDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(registryDist); err != nil {
-			return fmt.Errorf("7 CleanEden: error in %s delete: %s", registryDist, err)
-		}
-	}
-	if _, err = os.Stat(configDist); !os.IsNotExist(err) {
-		if err = os.RemoveAll(configDist); err != nil {
-			return fmt.Errorf("8 CleanEden: error in %s delete: %w", configDist, err)
+	for _, item := range []struct {
+		path string
+		what string
+		keep bool
+	}{
+		{eveDist, "EVE", opts.KeepImages},
+		{certsDist, "certificates", opts.KeepCerts},
+		{imagesDist, "images of EVE", opts.KeepImages},
+		{eserverDist, "images of eserver", opts.KeepImages},
+		{adamDist, "data of adam", opts.KeepState},
+		{redisDist, "data of redis", opts.KeepState},
+		{registryDist, "images of registry", opts.KeepImages},
+		{configDist, "configs and state of eden", opts.KeepState},
+		{configSaved, "saved config", opts.KeepState},
+	} {
+		if err = cleanPath(item.path, item.what, item.keep); err != nil {
+			return fmt.Errorf("CleanEden: %w", err)
+		}
+	}
+	// volumes keep data of services
+	if !opts.KeepState {
+		if err = removeVolumes(containers); err != nil {
+			return fmt.Errorf("CleanEden: %w", err)
 		}
 	}
-	if _, err = os.Stat(configSaved); !os.IsNotExist(err) {
-		if err = os.RemoveAll(configSaved); err != nil {
-			return fmt.Errorf("9 CleanEden: error in %s delete: %s", configSaved, err)
-		}
-	}
-	if err = utils.RemoveGeneratedVolumeOfContainer(defaults.DefaultEServerContainerName); err != nil {
-		return fmt.Errorf("CleanEden: RemoveGeneratedVolumeOfContainer for %s: %s", defaults.DefaultEServerContainerName, err)
-	}
-	if err = utils.RemoveGeneratedVolumeOfContainer(defaults.DefaultRedisContainerName); err != nil {
-		return fmt.Errorf("CleanEden: RemoveGeneratedVolumeOfContainer for %s: %s", defaults.DefaultRedisContainerName, err)
-	}
-	if err = utils.RemoveGeneratedVolumeOfContainer(defaults.DefaultAdamContainerName); err != nil {
-		return fmt.Errorf("CleanEden: RemoveGeneratedVolumeOfContainer for %s: %s", defaults.DefaultAdamContainerName, err)
-	}
-	if err = utils.RemoveGeneratedVolumeOfContainer(defaults.DefaultRegistryContainerName); err != nil {
-		return fmt.Errorf("CleanEden: RemoveGeneratedVolumeOfContainer for %s: %s", defaults.DefaultRegistryContainerName, err)
-	}
 	if devModel == defaults.DefaultVBoxModel {
 		if err := DeleteEVEVBox(vmName); err != nil {
 			log.Infof("cannot delete EVE: %s", err)
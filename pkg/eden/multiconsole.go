@@ -0,0 +1,349 @@
+package eden
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// multiConsoleScrollback is size of output of every node kept to show on switch and scroll
+const multiConsoleScrollback = 256 * 1024
+
+// multiConsolePrefix is key to press before command of multiplexed console, Ctrl-B as in tmux
+const multiConsolePrefix = 0x02
+
+// multiConsoleHelp is shown in status bar on prefix key
+const multiConsoleHelp = "C-b: n/p next/prev, 1-9 select, [/] scroll, d detach, C-b send C-b"
+
+// ConsoleNode is console of one EVE node in multiplexed console
+type ConsoleNode struct {
+	Name string
+	// Console is nil if console of node is not available
+	Console io.ReadWriteCloser
+	// Err is reason why console is not available
+	Err error
+}
+
+type muxNode struct {
+	ConsoleNode
+	log        *os.File
+	scrollback []byte
+	activity   bool
+	closed     bool
+}
+
+// MultiConsole shows consoles of EVE nodes in terminal one at a time with status bar of nodes,
+// output of every node is kept in scrollback and written into log file of node
+type MultiConsole struct {
+	mu     sync.Mutex
+	nodes  []*muxNode
+	out    io.Writer
+	active int
+	// scroll is number of lines scrolled back from the end of output of active node
+	scroll  int
+	cols    int
+	rows    int
+	help    bool
+	wg      sync.WaitGroup
+	closing chan struct{}
+}
+
+// NewMultiConsole creates multiplexed console of nodes drawn into out of terminal with size cols x rows,
+// output of every node is appended into <name>.log in logDir, consoles are closed on error
+func NewMultiConsole(nodes []ConsoleNode, logDir string, out io.Writer, cols, rows int) (*MultiConsole, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes to attach")
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, err
+	}
+	mc := &MultiConsole{out: out, cols: cols, rows: rows, closing: make(chan struct{})}
+	for i, node := range nodes {
+		logFile, err := os.OpenFile(filepath.Join(logDir, fmt.Sprintf("%s.log", node.Name)),
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			for _, rest := range nodes[i:] {
+				if rest.Console != nil {
+					_ = rest.Console.Close()
+				}
+			}
+			mc.close()
+			return nil, fmt.Errorf("cannot open log of node %s: %w", node.Name, err)
+		}
+		n := &muxNode{ConsoleNode: node, log: logFile}
+		if node.Console == nil {
+			n.closed = true
+			n.scrollback = []byte(fmt.Sprintf("console of %s is not available: %s\r\n", node.Name, node.Err))
+		}
+		mc.nodes = append(mc.nodes, n)
+	}
+	return mc, nil
+}
+
+// Resize sets size of terminal and redraws it
+func (mc *MultiConsole) Resize(cols, rows int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.cols, mc.rows = cols, rows
+	mc.redraw()
+}
+
+// Run attaches to consoles of nodes and sends input read from in to console of active node
+// until detach key is pressed or ctx is done, consoles and logs are closed on return
+func (mc *MultiConsole) Run(ctx context.Context, in io.Reader) error {
+	defer mc.close()
+	mc.mu.Lock()
+	// alternate screen as tmux uses, so terminal is restored on detach
+	fmt.Fprint(mc.out, "\x1b[?1049h")
+	mc.redraw()
+	mc.mu.Unlock()
+	defer fmt.Fprint(mc.out, "\x1b[r\x1b[?1049l")
+
+	for _, node := range mc.nodes {
+		if node.Console != nil {
+			mc.wg.Add(1)
+			go mc.read(node)
+		}
+	}
+
+	input := make(chan []byte)
+	inputErr := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				select {
+				case input <- append([]byte{}, buf[:n]...):
+				case <-mc.closing:
+					return
+				}
+			}
+			if err != nil {
+				inputErr <- err
+				return
+			}
+		}
+	}()
+
+	prefix := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-inputErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case data := <-input:
+			var forward []byte
+			for _, b := range data {
+				if !prefix {
+					if b == multiConsolePrefix {
+						prefix = true
+						mc.setHelp(true)
+					} else {
+						forward = append(forward, b)
+					}
+					continue
+				}
+				prefix = false
+				mc.setHelp(false)
+				if b == multiConsolePrefix {
+					forward = append(forward, b)
+					continue
+				}
+				if len(forward) > 0 {
+					mc.write(forward)
+					forward = nil
+				}
+				if mc.command(b) {
+					return nil
+				}
+			}
+			if len(forward) > 0 {
+				mc.write(forward)
+			}
+		}
+	}
+}
+
+// command runs command of key pressed after prefix and returns true on detach
+func (mc *MultiConsole) command(key byte) bool {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	switch {
+	case key == 'd' || key == 'q':
+		return true
+	case key == 'n':
+		mc.activate((mc.active + 1) % len(mc.nodes))
+	case key == 'p':
+		mc.activate((mc.active + len(mc.nodes) - 1) % len(mc.nodes))
+	case key >= '1' && key <= '9':
+		if i := int(key - '1'); i < len(mc.nodes) {
+			mc.activate(i)
+		}
+	case key == '[':
+		mc.scroll += mc.height()
+		if lines := bytes.Count(mc.nodes[mc.active].scrollback, []byte{'\n'}); mc.scroll > lines {
+			mc.scroll = lines
+		}
+		mc.redraw()
+	case key == ']':
+		mc.scroll -= mc.height()
+		if mc.scroll < 0 {
+			mc.scroll = 0
+		}
+		mc.redraw()
+	}
+	return false
+}
+
+func (mc *MultiConsole) activate(i int) {
+	mc.active = i
+	mc.scroll = 0
+	mc.nodes[i].activity = false
+	mc.redraw()
+}
+
+func (mc *MultiConsole) setHelp(help bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.help = help
+	mc.drawStatus()
+}
+
+// write sends data into console of active node, input returns view to the end of output
+func (mc *MultiConsole) write(data []byte) {
+	mc.mu.Lock()
+	node := mc.nodes[mc.active]
+	if mc.scroll != 0 {
+		mc.scroll = 0
+		mc.redraw()
+	}
+	mc.mu.Unlock()
+	if node.Console == nil {
+		return
+	}
+	// console is not locked as write may block until node reads input
+	_, _ = node.Console.Write(data)
+}
+
+// read saves output of console of node and shows it if node is active until console is closed
+func (mc *MultiConsole) read(node *muxNode) {
+	defer mc.wg.Done()
+	buf := make([]byte, 4096)
+	for {
+		n, err := node.Console.Read(buf)
+		if n > 0 {
+			mc.output(node, buf[:n])
+		}
+		if err != nil {
+			select {
+			case <-mc.closing:
+			default:
+				mc.output(node, []byte(fmt.Sprintf("\r\n[console of %s is closed: %s]\r\n", node.Name, err)))
+				mc.mu.Lock()
+				node.closed = true
+				mc.drawStatus()
+				mc.mu.Unlock()
+			}
+			return
+		}
+	}
+}
+
+func (mc *MultiConsole) output(node *muxNode, data []byte) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	_, _ = node.log.Write(data)
+	node.scrollback = append(node.scrollback, data...)
+	if len(node.scrollback) > multiConsoleScrollback {
+		node.scrollback = node.scrollback[len(node.scrollback)-multiConsoleScrollback:]
+	}
+	if node != mc.nodes[mc.active] {
+		if !node.activity {
+			node.activity = true
+			mc.drawStatus()
+		}
+		return
+	}
+	if mc.scroll == 0 {
+		_, _ = mc.out.Write(data)
+	}
+}
+
+// height returns number of rows for console, the last row is status bar
+func (mc *MultiConsole) height() int {
+	if mc.rows < 2 {
+		return 1
+	}
+	return mc.rows - 1
+}
+
+// redraw clears terminal and shows lines of active node with status bar
+func (mc *MultiConsole) redraw() {
+	node := mc.nodes[mc.active]
+	lines := strings.Split(string(node.scrollback), "\n")
+	end := len(lines) - mc.scroll
+	start := end - mc.height()
+	if start < 0 {
+		start = 0
+	}
+	// console scrolls in region above status bar
+	fmt.Fprintf(mc.out, "\x1b[r\x1b[H\x1b[2J\x1b[1;%dr\x1b[H%s", mc.height(), strings.Join(lines[start:end], "\n"))
+	mc.drawStatus()
+}
+
+// drawStatus draws status bar with nodes in the last row of terminal keeping position of cursor,
+// active node is marked with *, node with unseen output with #, node with closed console with !
+func (mc *MultiConsole) drawStatus() {
+	var status string
+	if mc.help {
+		status = multiConsoleHelp
+	} else {
+		var names []string
+		for i, node := range mc.nodes {
+			mark := ""
+			switch {
+			case i == mc.active:
+				mark = "*"
+			case node.activity:
+				mark = "#"
+			}
+			if node.closed {
+				mark += "!"
+			}
+			names = append(names, fmt.Sprintf("%d:%s%s", i+1, node.Name, mark))
+		}
+		status = strings.Join(names, " ")
+		if mc.scroll > 0 {
+			status = fmt.Sprintf("[-%d] %s", mc.scroll, status)
+		}
+	}
+	if len(status) > mc.cols {
+		status = status[:mc.cols]
+	}
+	fmt.Fprintf(mc.out, "\x1b7\x1b[%d;1H\x1b[7m%-*s\x1b[0m\x1b8", mc.rows, mc.cols, status)
+}
+
+// close closes consoles and logs of nodes and waits for readers of consoles
+func (mc *MultiConsole) close() {
+	close(mc.closing)
+	for _, node := range mc.nodes {
+		if node.Console != nil {
+			_ = node.Console.Close()
+		}
+	}
+	mc.wg.Wait()
+	for _, node := range mc.nodes {
+		_ = node.log.Close()
+	}
+}
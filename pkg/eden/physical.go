@@ -0,0 +1,44 @@
+package eden
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// runPhysicalHook runs command configured for action of physical device with shell
+func runPhysicalHook(action, command string) error {
+	if command == "" {
+		return fmt.Errorf("%s command for physical device is not defined, please set eve.physical.%s in config", action, action)
+	}
+	log.Debugf("run %s command: %s", action, command)
+	if err := utils.RunCommandWithLogAndWait("sh", defaults.DefaultLogLevelToPrint, "-c", command); err != nil {
+		return fmt.Errorf("%s command %q failed: %w", action, command, err)
+	}
+	return nil
+}
+
+// StartEVEPhysical powers on physical device with configured out-of-band command
+func StartEVEPhysical(powerOn string) error {
+	return runPhysicalHook("power-on", strings.TrimSpace(powerOn))
+}
+
+// StopEVEPhysical powers off physical device with configured out-of-band command
+func StopEVEPhysical(powerOff string) error {
+	return runPhysicalHook("power-off", strings.TrimSpace(powerOff))
+}
+
+// ConsoleEVEPhysical connects to serial console of physical device with configured command
+func ConsoleEVEPhysical(console string) error {
+	console = strings.TrimSpace(console)
+	if console == "" {
+		return fmt.Errorf("console command for physical device is not defined, please set eve.physical.console in config")
+	}
+	if err := utils.RunCommandForeground("sh", "-c", console); err != nil {
+		return fmt.Errorf("console command %q failed: %w", console, err)
+	}
+	return nil
+}
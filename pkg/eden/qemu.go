@@ -2,8 +2,10 @@ package eden
 
 import (
 	"bufio"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
@@ -22,8 +24,10 @@ import (
 
 // StartSWTPM starts swtpm process and use stateDir as state, log, pid and socket location
 func StartSWTPM(stateDir string) error {
-	if err := os.MkdirAll(stateDir, 0777); err != nil {
-		return err
+	if !utils.IsDryRun() {
+		if err := os.MkdirAll(stateDir, 0777); err != nil {
+			return err
+		}
 	}
 	command := "swtpm"
 	logFile := filepath.Join(stateDir, fmt.Sprintf("%s.log", command))
@@ -42,6 +46,63 @@ func StopSWTPM(stateDir string) error {
 	return utils.StopCommandWithPid(pidFile)
 }
 
+// CopySWTPMState replaces state files of swtpm inside dstDir with the ones from stateDir,
+// pid, log and socket of running swtpm are not copied. Files are copied into temporary
+// directory first, so dstDir is not changed if copy fails.
+func CopySWTPMState(stateDir, dstDir string) error {
+	command := "swtpm"
+	skip := map[string]bool{
+		fmt.Sprintf("%s.pid", command): true,
+		fmt.Sprintf("%s.log", command): true,
+		defaults.DefaultSwtpmSockFile:  true,
+	}
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		return err
+	}
+	tmpDir := dstDir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	var files []string
+	for _, entry := range entries {
+		if skip[entry.Name()] || !entry.Type().IsRegular() {
+			continue
+		}
+		if err := utils.CopyFile(filepath.Join(stateDir, entry.Name()), filepath.Join(tmpDir, entry.Name())); err != nil {
+			return fmt.Errorf("cannot copy state of swtpm: %w", err)
+		}
+		files = append(files, entry.Name())
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no state of swtpm in %s", stateDir)
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+	// remove old state, e.g. volatile state absent in the new one
+	if entries, err = os.ReadDir(dstDir); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !skip[entry.Name()] && entry.Type().IsRegular() {
+			if err := os.Remove(filepath.Join(dstDir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range files {
+		if err := os.Rename(filepath.Join(tmpDir, file), filepath.Join(dstDir, file)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func startQMPLogger(qmpSockFile string, qmpLogFile string) error {
 	shellcmd := fmt.Sprintf(
 		"echo '{\"execute\": \"qmp_capabilities\"}' | " +
@@ -70,10 +131,11 @@ func startQMPLogger(qmpSockFile string, qmpLogFile string) error {
 	return nil
 }
 
-// StartEVEQemu function run EVE in qemu
+// StartEVEQemu function run EVE in qemu, qemuAccel is one of utils.QemuAccel*
+// accelerators or empty to emulate EVE without tuning
 func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstaller bool,
 	qemuSMBIOSSerial string, eveTelnetPort, qemuMonitorPort, netDevBasePort int,
-	qemuHostFwd map[string]string, qemuAccel bool, qemuConfigFile, logFile, pidFile string,
+	qemuHostFwd map[string]string, qemuAccel, qemuConfigFile, logFile, pidFile string,
 	netModel sdnapi.NetworkModel, withSDN bool, tapInterface, usbImagePath string,
 	swtpm, foreground bool) (err error) {
 	var qemuCommand, qemuOptions string
@@ -85,35 +147,36 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 	} else {
 		qemuARCH = strings.ToLower(qemuARCH)
 	}
+	if qemuOS == "" {
+		qemuOS = runtime.GOOS
+	} else {
+		qemuOS = strings.ToLower(qemuOS)
+	}
+	if qemuOS != "linux" && qemuOS != "darwin" {
+		return fmt.Errorf("StartEVEQemu: OS not supported: %s", qemuOS)
+	}
+	if qemuAccel == utils.QemuAccelHVF && !utils.QemuHVFSupported() {
+		log.Warn("StartEVEQemu: Hypervisor.framework is not available, EVE will be emulated")
+		qemuAccel = utils.QemuAccelTCG
+	}
 	switch qemuARCH {
 	case "amd64":
 		qemuCommand = "qemu-system-x86_64"
-		if qemuAccel {
-			if qemuOS == "darwin" {
-				qemuOptions += defaults.DefaultQemuAccelDarwin
-			} else {
-				qemuOptions += defaults.DefaultQemuAccelLinuxAmd64
-				// to support pass-through of virtio-net-pci
-				netDev = fmt.Sprintf("%s,disable-legacy=on,disable-modern=off,iommu_platform=on", netDev)
-			}
-		} else {
-			qemuOptions += defaults.DefaultQemuAmd64
+		if qemuAccel == utils.QemuAccelKVM {
+			// to support pass-through of virtio-net-pci
+			netDev = fmt.Sprintf("%s,disable-legacy=on,disable-modern=off,iommu_platform=on", netDev)
 		}
 	case "arm64":
 		qemuCommand = "qemu-system-aarch64"
-		if qemuAccel {
-			if qemuOS == "darwin" {
-				qemuOptions += defaults.DefaultQemuAccelDarwinArm64
-			} else {
-				qemuOptions += defaults.DefaultQemuAccelArm64
-			}
-		} else {
-			qemuOptions += defaults.DefaultQemuArm64
-		}
 		tpmDev = "tpm-tis-device"
 	default:
 		return fmt.Errorf("StartEVEQemu: Arch not supported: %s", qemuARCH)
 	}
+	accelOptions, err := utils.QemuAccelOptions(qemuARCH, qemuAccel)
+	if err != nil {
+		return fmt.Errorf("StartEVEQemu: %w", err)
+	}
+	qemuOptions += accelOptions
 	if qemuSMBIOSSerial != "" {
 		qemuOptions += fmt.Sprintf("-smbios type=1,serial=%s ", qemuSMBIOSSerial)
 	}
@@ -137,6 +200,7 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 			return fmt.Errorf("StartEVEQemu: %s", err)
 		}
 		network := nets[0].Subnet
+		hostFwdAddr := utils.QemuHostFwdAddr(qemuOS)
 		var ip net.IP
 		for i, port := range netModel.Ports {
 			switch i {
@@ -161,7 +225,7 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 					log.Errorf("Failed converting %s to Integer", v)
 					break
 				}
-				qemuOptions += fmt.Sprintf(",hostfwd=tcp::%d-:%d", origPort+(i*10), newPort+(i*10))
+				qemuOptions += fmt.Sprintf(",hostfwd=tcp:%s:%d-:%d", hostFwdAddr, origPort+(i*10), newPort+(i*10))
 			}
 			qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev, i,
 				port.EVEConnect.MAC)
@@ -178,14 +242,6 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 		tpmSocket := filepath.Join(filepath.Dir(eveImageFile), "swtpm", defaults.DefaultSwtpmSockFile)
 		qemuOptions += fmt.Sprintf("-chardev socket,id=chrtpm,path=%s -tpmdev emulator,id=tpm0,chardev=chrtpm -device %s,tpmdev=tpm0 ", tpmSocket, tpmDev)
 	}
-	if qemuOS == "" {
-		qemuOS = runtime.GOOS
-	} else {
-		qemuOS = strings.ToLower(qemuOS)
-	}
-	if qemuOS != "linux" && qemuOS != "darwin" {
-		return fmt.Errorf("StartEVEQemu: OS not supported: %s", qemuOS)
-	}
 	qemuOptions += "-watchdog-action reset "
 
 	if isInstaller {
@@ -222,19 +278,18 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 		qemuOptions += fmt.Sprintf("-readconfig %s ", qemuConfigFile)
 	}
 
-	context, err := utils.ContextLoad()
-	if err != nil {
-		return fmt.Errorf("StartEVEQemu: load context error: %w", err)
-	}
-
-	qmpSockFile := fmt.Sprintf("%s-qmp.sock", strings.ToLower(context.Current))
-	qmpLogFile := fmt.Sprintf("%s-qmp.log", strings.ToLower(context.Current))
+	// name QMP files after pid file, which is unique for context
+	qmpPrefix := strings.TrimSuffix(filepath.Base(pidFile), filepath.Ext(pidFile))
+	qmpSockFile := fmt.Sprintf("%s-qmp.sock", qmpPrefix)
+	qmpLogFile := fmt.Sprintf("%s-qmp.log", qmpPrefix)
 
 	qmpSockFile = filepath.Join(filepath.Dir(pidFile), qmpSockFile)
 	qmpLogFile = filepath.Join(filepath.Dir(pidFile), qmpLogFile)
 
 	// QMP sock
-	qemuOptions += fmt.Sprintf("-qmp unix:%s,server,wait=off", qmpSockFile)
+	qemuOptions += fmt.Sprintf("-qmp unix:%s,server,wait=off ", qmpSockFile)
+	// the first QMP sock is occupied by logger, so statistics are collected with another one
+	qemuOptions += fmt.Sprintf("-qmp unix:%s,server,wait=off", utils.QemuStatsSocket(pidFile))
 
 	log.Infof("Start EVE: %s %s", qemuCommand, qemuOptions)
 	if foreground {
@@ -255,6 +310,68 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 	return nil
 }
 
+// CreateOverlayQemu creates qcow2 overlayFile backed by backingFile, so writes
+// of VM go into overlay and backing file stays pristine and can be shared.
+// Existing overlay is replaced.
+func CreateOverlayQemu(backingFile, backingFormat, overlayFile string) error {
+	backingFile, err := filepath.Abs(backingFile)
+	if err != nil {
+		return fmt.Errorf("CreateOverlayQemu: %w", err)
+	}
+	if utils.IsDryRun() {
+		utils.DryRunf("remove %s", overlayFile)
+	} else if err := os.Remove(overlayFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("CreateOverlayQemu: %w", err)
+	}
+	if err := utils.RunCommandForeground("qemu-img", "create", "-q", "-f", "qcow2",
+		"-F", backingFormat, "-b", backingFile, overlayFile); err != nil {
+		return fmt.Errorf("CreateOverlayQemu: %w", err)
+	}
+	return nil
+}
+
+// RebaseOverlayQemu points overlayFile to backingFile with the same content,
+// e.g. after both of them are moved into another directory
+func RebaseOverlayQemu(overlayFile, backingFile, backingFormat string) error {
+	backingFile, err := filepath.Abs(backingFile)
+	if err != nil {
+		return fmt.Errorf("RebaseOverlayQemu: %w", err)
+	}
+	if err := utils.RunCommandForeground("qemu-img", "rebase", "-u", "-f", "qcow2",
+		"-F", backingFormat, "-b", backingFile, overlayFile); err != nil {
+		return fmt.Errorf("RebaseOverlayQemu: %w", err)
+	}
+	return nil
+}
+
+// BackingFileQemu returns backing file from header of qcow2 file,
+// it is empty if file has no backing file
+func BackingFileQemu(qcow2File string) (string, error) {
+	f, err := os.Open(qcow2File)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	// magic, version, backing_file_offset and backing_file_size of qcow2 header
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return "", fmt.Errorf("BackingFileQemu: %w", err)
+	}
+	if string(header[:4]) != "QFI\xfb" {
+		return "", fmt.Errorf("BackingFileQemu: %s is not qcow2 file", qcow2File)
+	}
+	offset := binary.BigEndian.Uint64(header[8:16])
+	size := binary.BigEndian.Uint32(header[16:20])
+	if offset == 0 {
+		return "", nil
+	}
+	backingFile := make([]byte, size)
+	if _, err := f.ReadAt(backingFile, int64(offset)); err != nil {
+		return "", fmt.Errorf("BackingFileQemu: %w", err)
+	}
+	return string(backingFile), nil
+}
+
 // StopEVEQemu function stop EVE
 func StopEVEQemu(pidFile string) (err error) {
 	return utils.StopCommandWithPid(pidFile)
@@ -267,16 +384,52 @@ func StatusEVEQemu(pidFile string) (status string, err error) {
 
 // SetLinkStateQemu changes the link state of the given interface.
 func SetLinkStateQemu(qemuMonitorPort int, ifName string, up bool) error {
+	linkState := "on"
+	if !up {
+		linkState = "off"
+	}
+	return runQemuMonitorCommand(qemuMonitorPort, fmt.Sprintf("set_link %s %s", ifName, linkState))
+}
+
+// ResetEVEQemu resets EVE VM as with reset button of hardware.
+func ResetEVEQemu(qemuMonitorPort int) error {
+	return runQemuMonitorCommand(qemuMonitorPort, "system_reset")
+}
+
+// PauseQemu pauses execution of EVE VM.
+func PauseQemu(qemuMonitorPort int) error {
+	return runQemuMonitorCommand(qemuMonitorPort, "stop")
+}
+
+// ResumeQemu resumes execution of EVE VM paused with PauseQemu.
+func ResumeQemu(qemuMonitorPort int) error {
+	return runQemuMonitorCommand(qemuMonitorPort, "cont")
+}
+
+// SaveSnapshotQemu saves state of EVE VM (disks and memory) into snapshot with name.
+// All writable disks of VM must support snapshots (e.g. qcow2).
+func SaveSnapshotQemu(qemuMonitorPort int, name string) error {
+	return runQemuMonitorCommand(qemuMonitorPort, fmt.Sprintf("savevm %s", name))
+}
+
+// LoadSnapshotQemu restores state of EVE VM from snapshot with name.
+func LoadSnapshotQemu(qemuMonitorPort int, name string) error {
+	return runQemuMonitorCommand(qemuMonitorPort, fmt.Sprintf("loadvm %s", name))
+}
+
+// DeleteSnapshotQemu removes snapshot with name from disks of EVE VM.
+func DeleteSnapshotQemu(qemuMonitorPort int, name string) error {
+	return runQemuMonitorCommand(qemuMonitorPort, fmt.Sprintf("delvm %s", name))
+}
+
+// runQemuMonitorCommand runs command in QEMU monitor, output of command is treated as error.
+func runQemuMonitorCommand(qemuMonitorPort int, cmd string) error {
 	tcpAddr, _ := net.ResolveTCPAddr("tcp", fmt.Sprintf("localhost:%d", qemuMonitorPort))
 	conn, err := net.DialTCP("tcp", nil, tcpAddr)
 	if err != nil {
 		return err
 	}
-	linkState := "on"
-	if !up {
-		linkState = "off"
-	}
-	cmd := fmt.Sprintf("set_link %s %s", ifName, linkState)
+	defer conn.Close()
 	_, err = conn.Write([]byte(cmd + "\n"))
 	if err == nil {
 		err = conn.CloseWrite()
@@ -18,8 +18,47 @@ import (
 
 const natNetworkName = "natnet1"
 
-//StartEVEVBox function runs EVE in VirtualBox
-func StartEVEVBox(vmName, eveImageFile string, cpus int, mem int, hostFwd map[string]string) (err error) {
+// vboxStorageControllers are arguments of storagectl to add controller for bus of disks
+var vboxStorageControllers = map[string]string{
+	utils.DiskBusVirtio: "--add virtio --controller VirtIO",
+	utils.DiskBusNVMe:   "--add pcie --controller NVMe",
+	utils.DiskBusSCSI:   "--add scsi --controller LSILogic",
+	utils.DiskBusUSB:    "--add usb --controller USB",
+}
+
+// attachDisksVBox creates disks of VM and attaches them to controllers of their buses
+func attachDisksVBox(vmName string, disks []utils.VMDisk) error {
+	ports := map[string]int{utils.DiskBusSATA: 1}
+	for _, disk := range disks {
+		bus := disk.Bus
+		if bus == "" {
+			bus = utils.DiskBusSATA
+		}
+		controller := strings.ToUpper(bus)
+		if bus == utils.DiskBusSATA {
+			controller = "\"SATA\""
+		} else if _, ok := ports[bus]; !ok {
+			commandArgsString := fmt.Sprintf("storagectl %s --name %s %s", vmName, controller, vboxStorageControllers[bus])
+			if err := utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
+				return fmt.Errorf("VBoxManage error for command %s %w", commandArgsString, err)
+			}
+		}
+		commandArgsString := fmt.Sprintf("createmedium disk --filename %s --size %d --format VDI", disk.File, disk.SizeMB)
+		if err := utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
+			return fmt.Errorf("VBoxManage error for command %s %w", commandArgsString, err)
+		}
+		commandArgsString = fmt.Sprintf("storageattach %s --storagectl %s --port %d --device 0 --type hdd --medium %s",
+			vmName, controller, ports[bus], disk.File)
+		if err := utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
+			return fmt.Errorf("VBoxManage error for command %s %w", commandArgsString, err)
+		}
+		ports[bus]++
+	}
+	return nil
+}
+
+// StartEVEVBox function runs EVE in VirtualBox with additional disks
+func StartEVEVBox(vmName, eveImageFile string, cpus int, mem int, hostFwd map[string]string, disks []utils.VMDisk) (err error) {
 	vmStatus, err := getEveVMStatusVbox(vmName)
 	if err != nil {
 		log.Info("No VMs with eve_live name", err)
@@ -39,6 +78,9 @@ func StartEVEVBox(vmName, eveImageFile string, cpus int, mem int, hostFwd map[st
 		if err = utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
 			log.Fatalf("VBoxManage error for command %s %s", commandArgsString, err)
 		}
+		if err := attachDisksVBox(vmName, disks); err != nil {
+			log.Fatal(err)
+		}
 		if err := createNATNetworkVBox(vmName); err != nil {
 			log.Fatal(err)
 		}
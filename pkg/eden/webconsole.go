@@ -0,0 +1,289 @@
+package eden
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+// webConsoleScrollback is size of output of console sent to clients on connect
+const webConsoleScrollback = 64 * 1024
+
+// Bytes of telnet protocol (RFC 854) which QEMU uses for serial console
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetDONT = 254
+	telnetIAC  = 255
+)
+
+// telnetFilter removes commands of telnet protocol from data read from telnet server,
+// it keeps state between reads as commands may be split
+type telnetFilter struct {
+	state int
+}
+
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSub
+	telnetStateSubIAC
+)
+
+// filter returns data of p without telnet commands
+func (f *telnetFilter) filter(p []byte) []byte {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch f.state {
+		case telnetStateData:
+			if b == telnetIAC {
+				f.state = telnetStateIAC
+			} else {
+				out = append(out, b)
+			}
+		case telnetStateIAC:
+			switch {
+			case b == telnetIAC:
+				// escaped 255
+				out = append(out, b)
+				f.state = telnetStateData
+			case b == telnetSB:
+				f.state = telnetStateSub
+			case b >= telnetWILL && b <= telnetDONT:
+				f.state = telnetStateOption
+			default:
+				f.state = telnetStateData
+			}
+		case telnetStateOption:
+			f.state = telnetStateData
+		case telnetStateSub:
+			if b == telnetIAC {
+				f.state = telnetStateSubIAC
+			}
+		case telnetStateSubIAC:
+			if b == telnetSE {
+				f.state = telnetStateData
+			} else {
+				f.state = telnetStateSub
+			}
+		}
+	}
+	return out
+}
+
+// telnetConsole is console of telnet server, e.g. serial console of QEMU
+type telnetConsole struct {
+	net.Conn
+	filter telnetFilter
+}
+
+func (c *telnetConsole) Read(p []byte) (int, error) {
+	for {
+		n, err := c.Conn.Read(p)
+		data := c.filter.filter(p[:n])
+		copy(p, data)
+		if len(data) > 0 || err != nil {
+			return len(data), err
+		}
+	}
+}
+
+func (c *telnetConsole) Write(p []byte) (int, error) {
+	// 255 is escaped in telnet protocol
+	if _, err := c.Conn.Write(bytes.ReplaceAll(p, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// DialTelnetConsole connects to console served with telnet on addr
+func DialTelnetConsole(addr string) (io.ReadWriteCloser, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to console %s: %w", addr, err)
+	}
+	return &telnetConsole{Conn: conn}, nil
+}
+
+// commandConsole is console of command reading input and writing output, e.g. console of physical device
+type commandConsole struct {
+	io.Reader
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func (c *commandConsole) Close() error {
+	c.WriteCloser.Close()
+	if c.cmd.Process != nil {
+		_ = c.cmd.Process.Kill()
+	}
+	return c.cmd.Wait()
+}
+
+// StartCommandConsole runs console command with sh and returns its input and output as console
+func StartCommandConsole(console string) (io.ReadWriteCloser, error) {
+	cmd := exec.Command("sh", "-c", console)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	// errors of command are shown in console
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("console command %q failed: %w", console, err)
+	}
+	return &commandConsole{Reader: stdout, WriteCloser: stdin, cmd: cmd}, nil
+}
+
+// webConsole shares one console between websocket clients, output of console is sent
+// to all of them, input of every client is sent to console
+type webConsole struct {
+	console    io.ReadWriteCloser
+	title      string
+	mu         sync.Mutex
+	clients    map[*websocket.Conn]struct{}
+	scrollback []byte
+}
+
+// read sends output of console to clients until console is closed
+func (wc *webConsole) read() error {
+	buf := make([]byte, 4096)
+	for {
+		n, err := wc.console.Read(buf)
+		if n > 0 {
+			wc.broadcast(buf[:n])
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (wc *webConsole) broadcast(data []byte) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.scrollback = append(wc.scrollback, data...)
+	if len(wc.scrollback) > webConsoleScrollback {
+		wc.scrollback = wc.scrollback[len(wc.scrollback)-webConsoleScrollback:]
+	}
+	for client := range wc.clients {
+		if _, err := client.Write(data); err != nil {
+			log.Debugf("web console client %s: %s", client.Request().RemoteAddr, err)
+			delete(wc.clients, client)
+			client.Close()
+		}
+	}
+}
+
+// serveClient sends scrollback to client and input of client to console until client disconnects
+func (wc *webConsole) serveClient(ws *websocket.Conn) {
+	ws.PayloadType = websocket.BinaryFrame
+	wc.mu.Lock()
+	if _, err := ws.Write(wc.scrollback); err != nil {
+		wc.mu.Unlock()
+		return
+	}
+	wc.clients[ws] = struct{}{}
+	wc.mu.Unlock()
+	log.Infof("web console client %s connected", ws.Request().RemoteAddr)
+	defer func() {
+		wc.mu.Lock()
+		delete(wc.clients, ws)
+		wc.mu.Unlock()
+		ws.Close()
+		log.Infof("web console client %s disconnected", ws.Request().RemoteAddr)
+	}()
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			return
+		}
+		if _, err := wc.console.Write(data); err != nil {
+			log.Errorf("cannot write into console: %s", err)
+			return
+		}
+	}
+}
+
+// checkOrigin accepts WebSocket connections only from the page of web console, as
+// browser lets pages of any other site open WebSocket connection to console otherwise
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return fmt.Errorf("no Origin header")
+	}
+	if origin.Host != r.Host {
+		return fmt.Errorf("origin %s is not allowed", origin)
+	}
+	config.Origin = origin
+	return nil
+}
+
+func (wc *webConsole) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	t, err := template.New("console").Parse(defaults.DefaultWebConsoleTemplate)
+	if err == nil {
+		err = t.Execute(w, struct{ Title string }{Title: wc.title})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ServeWebConsole serves page with terminal connected with WebSocket to console on listen address
+// until ctx is done or console is closed, console is closed on return
+func ServeWebConsole(ctx context.Context, console io.ReadWriteCloser, listen, title string) error {
+	wc := &webConsole{console: console, title: title, clients: map[*websocket.Conn]struct{}{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", wc.servePage)
+	mux.Handle("/ws", websocket.Server{Handler: wc.serveClient, Handshake: checkOrigin})
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		console.Close()
+		return fmt.Errorf("cannot listen on %s: %w", listen, err)
+	}
+	server := &http.Server{Handler: mux}
+	log.Infof("Console of %s is available on http://%s", title, listener.Addr())
+
+	consoleDone := make(chan error, 1)
+	go func() { consoleDone <- wc.read() }()
+	serverDone := make(chan error, 1)
+	go func() { serverDone <- server.Serve(listener) }()
+
+	select {
+	case <-ctx.Done():
+		// interrupt is the usual way to stop serving
+		err = nil
+	case err = <-consoleDone:
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("console is closed")
+		}
+	case err = <-serverDone:
+	}
+	console.Close()
+	_ = server.Close()
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -186,6 +187,27 @@ func (client *SdnClient) GetSdnLogs() (string, error) {
 	return string(output), err
 }
 
+// CaptureTraffic : capture traffic on all interfaces of Eden-SDN VM for the given
+// duration and write it in the pcap format into w.
+func (client *SdnClient) CaptureTraffic(duration time.Duration, w io.Writer) error {
+	var stderr bytes.Buffer
+	seconds := strconv.Itoa(int(duration.Seconds()))
+	command := exec.Command("ssh", client.sshArgs(
+		"timeout", seconds, "tcpdump", "-i", "any", "-U", "-w", "-")...)
+	command.Stdout = w
+	command.Stderr = &stderr
+	err := command.Run()
+	var exitErr *exec.ExitError
+	// timeout exits with 124 when it stops tcpdump
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 124 {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("command %s failed: %v (%s)", command, err, stderr.String())
+	}
+	return nil
+}
+
 // SSHIntoSdnVM : ssh into the running Eden-SDN.
 func (client *SdnClient) SSHIntoSdnVM() error {
 	return utils.RunCommandForeground("ssh", client.sshArgs()...)
@@ -7,7 +7,6 @@ import (
 	"runtime"
 	"strings"
 
-	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/utils"
 	model "github.com/lf-edge/eden/sdn/vm/api"
 	log "github.com/sirupsen/logrus"
@@ -48,29 +47,25 @@ func (vm *SdnVMQemuRunner) Start() error {
 	if qemuArch == "" {
 		qemuArch = runtime.GOARCH
 	}
+	accel := vm.Accelerator
+	if accel == utils.QemuAccelHVF && !utils.QemuHVFSupported() {
+		log.Warn("Hypervisor.framework is not available, SDN VM will be emulated")
+		accel = utils.QemuAccelTCG
+	}
 	switch qemuArch {
 	case "amd64":
 		qemuCommand = "qemu-system-x86_64"
-		if vm.Acceleration {
-			if hostOS == "darwin" {
-				qemuOptions += defaults.DefaultQemuAccelDarwin
-			} else {
-				qemuOptions += defaults.DefaultQemuAccelLinuxAmd64
-			}
-		} else {
-			qemuOptions += defaults.DefaultQemuAmd64
-		}
 	case "arm64":
 		qemuCommand = "qemu-system-aarch64"
-		if vm.Acceleration {
-			qemuOptions += defaults.DefaultQemuAccelArm64
-		} else {
-			qemuOptions += defaults.DefaultQemuArm64
-		}
 		netDev = "virtio-net-pci"
 	default:
 		return fmt.Errorf("architecture not supported for SDN VM: %s", qemuArch)
 	}
+	accelOptions, err := utils.QemuAccelOptions(qemuArch, accel)
+	if err != nil {
+		return fmt.Errorf("cannot start SDN VM: %w", err)
+	}
+	qemuOptions += accelOptions
 
 	// Ports connecting SDN VM with EVE VM.
 	socketPort := vm.NetDevBasePort
@@ -90,9 +85,11 @@ func (vm *SdnVMQemuRunner) Start() error {
 	}
 
 	// Management port.
+	hostFwdAddr := utils.QemuHostFwdAddr(hostOS)
 	qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,ipv4=on,net=%s,"+
-		"dhcpstart=%s,ipv6=%s,hostfwd=tcp::%d-:22,hostfwd=tcp::%d-:6666", len(vm.NetModel.Ports),
-		vm.MgmtSubnet.String(), vm.MgmtSubnet.DHCPStart.String(), ipv6Conf, vm.SSHPort, vm.MgmtPort)
+		"dhcpstart=%s,ipv6=%s,hostfwd=tcp:%s:%d-:22,hostfwd=tcp:%s:%d-:6666", len(vm.NetModel.Ports),
+		vm.MgmtSubnet.String(), vm.MgmtSubnet.DHCPStart.String(), ipv6Conf,
+		hostFwdAddr, vm.SSHPort, hostFwdAddr, vm.MgmtPort)
 	qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev,
 		len(vm.NetModel.Ports), GenerateSdnMgmtMAC())
 	_ = os.Chmod(vm.SSHKeyPath, 0600)
@@ -111,7 +108,7 @@ func (vm *SdnVMQemuRunner) Start() error {
 	}
 	conf, err := settings.GenerateQemuConfig()
 	if err != nil {
-		fmt.Errorf("failed to generate QEMU config: %v", err)
+		return fmt.Errorf("failed to generate QEMU config: %v", err)
 	}
 	err = os.WriteFile(qemuConfigPath, conf, 0664)
 	if err != nil {
@@ -23,7 +23,7 @@ type SdnVMRunner interface {
 // SdnVMConfig : configuration for Eden-SDN VM.
 type SdnVMConfig struct {
 	Architecture   string
-	Acceleration   bool
+	Accelerator    string // one of utils.QemuAccel* or empty for emulation
 	HostOS         string // darwin, linux, etc.
 	ImagePath      string
 	ConfigDir      string
@@ -35,6 +35,7 @@ type AppInstState struct {
 	CPUUsage     int
 	Macs         []string
 	Volumes      map[string]uint32
+	Health       string
 
 	prevCPUNS     uint64
 	prevCPUNSTime time.Time
@@ -43,7 +44,7 @@ type AppInstState struct {
 }
 
 func appStateHeader() string {
-	return "NAME\tIMAGE\tUUID\tINTERNAL\tEXTERNAL\tMEMORY\tSTATE(ADAM)\tLAST_STATE(EVE)"
+	return "NAME\tIMAGE\tUUID\tINTERNAL\tEXTERNAL\tMEMORY\tSTATE(ADAM)\tLAST_STATE(EVE)\tHEALTH"
 }
 
 func (appStateObj *AppInstState) toString() string {
@@ -76,10 +77,14 @@ func (appStateObj *AppInstState) toString() string {
 	memory := fmt.Sprintf("%s/%s",
 		humanize.Bytes((uint64)(appStateObj.MemoryUsed*humanize.MByte)),
 		humanize.Bytes((uint64)(appStateObj.MemoryAvail*humanize.MByte)))
-	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
+	health := appStateObj.Health
+	if health == "" {
+		health = "-"
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
 		appStateObj.Name, appStateObj.Image, appStateObj.UUID,
 		internal, external, memory,
-		appStateObj.AdamState, appStateObj.EVEState)
+		appStateObj.AdamState, appStateObj.EVEState, health)
 }
 
 func getPortMapping(appConfig *config.AppInstanceConfig, qemuPorts map[string]string) (intports, extports string) {
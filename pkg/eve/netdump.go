@@ -0,0 +1,246 @@
+package eve
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// NetdumpDir is the directory on EVE where network debug tarballs are stored
+const NetdumpDir = "/persist/netdump"
+
+// Files and directories inside netdump tarball used to build summary
+const (
+	netdumpVersionFile = "eve/version"
+	netdumpDNSFile     = "eve/dns.json"
+	netdumpRequestsDir = "requests"
+	netdumpLinuxDir    = "linux"
+)
+
+// UnpackNetdump extracts netdump tarball into dir
+// and returns path to root directory of netdump content
+func UnpackNetdump(tgzFile, dir string) (string, error) {
+	f, err := os.Open(tgzFile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gzf, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("cannot read gzip %s: %w", tgzFile, err)
+	}
+	tarReader := tar.NewReader(gzf)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot read tar %s: %w", tgzFile, err)
+		}
+		target := filepath.Join(dir, filepath.Clean("/"+header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return "", err
+			}
+			outFile, err := os.Create(target)
+			if err != nil {
+				return "", err
+			}
+			// Limit the size of the extracted file to prevent decompression bomb
+			bytesCopied, err := io.Copy(outFile, io.LimitReader(tarReader, utils.MaxDecompressedContentSize+1))
+			outFile.Close()
+			if err != nil {
+				return "", err
+			}
+			if bytesCopied > utils.MaxDecompressedContentSize {
+				return "", errors.New("maximum decompressed content size exceeded")
+			}
+		}
+	}
+	return netdumpRoot(dir)
+}
+
+// netdumpRoot returns directory with netdump content,
+// tarball may contain it directly or inside one top-level directory
+func netdumpRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "eve")); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
+
+func readNetdumpJSON(fileName string) (interface{}, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", fileName, err)
+	}
+	return doc, nil
+}
+
+// selectStrings returns values selected with JSONPath-like path as strings
+func selectStrings(doc interface{}, path string) []string {
+	values, err := utils.SelectJSONPath(doc, path)
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, v := range values {
+		if s := utils.JSONPathValueString(v); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func selectString(doc interface{}, path string) string {
+	values := selectStrings(doc, path)
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+// NetdumpSummary writes human-readable summary of unpacked netdump in dir:
+// EVE version, interface states with DNS servers and traced requests of EVE
+// to controller with DNS queries, failed dials and HTTP responses
+func NetdumpSummary(dir string, w io.Writer) error {
+	if version, err := os.ReadFile(filepath.Join(dir, netdumpVersionFile)); err == nil {
+		if _, err := fmt.Fprintf(w, "EVE version: %s\n", strings.TrimSpace(string(version))); err != nil {
+			return err
+		}
+	}
+	if err := netdumpInterfaces(dir, w); err != nil {
+		return err
+	}
+	if err := netdumpRequests(dir, w); err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(filepath.Join(dir, netdumpLinuxDir)); err == nil {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if _, err := fmt.Fprintf(w, "\nLinux network state (in %s): %s\n",
+			filepath.Join(dir, netdumpLinuxDir), strings.Join(names, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func netdumpInterfaces(dir string, w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "\nInterfaces:"); err != nil {
+		return err
+	}
+	doc, err := readNetdumpJSON(filepath.Join(dir, netdumpDNSFile))
+	if err != nil {
+		_, err = fmt.Fprintf(w, "\tno device network status: %s\n", err)
+		return err
+	}
+	ports, err := utils.SelectJSONPath(doc, ".Ports[]")
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	if _, err := fmt.Fprintln(tw, "\tNAME\tLABEL\tUP\tADDRESSES\tDNS\tLAST ERROR"); err != nil {
+		return err
+	}
+	for _, port := range ports {
+		if _, err := fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			selectString(port, ".IfName"), selectString(port, ".Logicallabel"), selectString(port, ".Up"),
+			selectString(port, ".AddrInfoList[].Addr"), selectString(port, ".DNSServers[]"),
+			selectString(port, ".LastError")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func netdumpRequests(dir string, w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "\nController connectivity:"); err != nil {
+		return err
+	}
+	requestsDir := filepath.Join(dir, netdumpRequestsDir)
+	var traces []string
+	err := filepath.Walk(requestsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".json" {
+			traces = append(traces, path)
+		}
+		return nil
+	})
+	if err != nil || len(traces) == 0 {
+		_, err = fmt.Fprintln(w, "\tno traced requests")
+		return err
+	}
+	sort.Strings(traces)
+	for _, trace := range traces {
+		doc, err := readNetdumpJSON(trace)
+		if err != nil {
+			if _, err := fmt.Fprintf(w, "\t%s\n", err); err != nil {
+				return err
+			}
+			continue
+		}
+		name, _ := filepath.Rel(requestsDir, trace)
+		if _, err := fmt.Fprintf(w, "\t%s: %s\n", name, selectString(doc, ".description")); err != nil {
+			return err
+		}
+		for _, query := range selectStrings(doc, ".dnsQueries[].dnsQueryMsgs[].questions[].name") {
+			if _, err := fmt.Fprintf(w, "\t\tdns query: %s\n", query); err != nil {
+				return err
+			}
+		}
+		for _, answer := range selectStrings(doc, ".dnsQueries[].dnsReplyMsgs[].answers[].resolvedVal") {
+			if _, err := fmt.Fprintf(w, "\t\tdns answer: %s\n", answer); err != nil {
+				return err
+			}
+		}
+		dials, _ := utils.SelectJSONPath(doc, ".dials[]")
+		for _, dial := range dials {
+			if dialErr := selectString(dial, ".dialErr"); dialErr != "-" {
+				if _, err := fmt.Fprintf(w, "\t\tdial %s failed: %s\n",
+					selectString(dial, ".dstAddress"), dialErr); err != nil {
+					return err
+				}
+			}
+		}
+		requests, _ := utils.SelectJSONPath(doc, ".httpRequests[]")
+		for _, req := range requests {
+			if _, err := fmt.Fprintf(w, "\t\t%s %s: %s\n", selectString(req, ".reqMethod"),
+				selectString(req, ".reqURL"), selectString(req, ".respStatusCode")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package eve
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Probe types supported by AppProbe
+const (
+	ProbeHTTP = "http"
+	ProbeTCP  = "tcp"
+)
+
+// AppProbe describes health probe of application.
+// Probe is executed against port of EVE forwarded to application.
+type AppProbe struct {
+	Type      string `yaml:"type"`   // http or tcp
+	Port      int    `yaml:"port"`   // port of EVE forwarded to application
+	Path      string `yaml:"path"`   // path for http probe
+	EveIfName string `yaml:"ifname"` // interface of EVE to use
+}
+
+// ParseAppProbe parses probe in format <http|tcp>:<EVE port>[/path][@ifname],
+// for example: http:8028/health or tcp:8027@eth1
+func ParseAppProbe(spec string) (*AppProbe, error) {
+	probe := &AppProbe{EveIfName: "eth0"}
+	if ind := strings.LastIndex(spec, "@"); ind != -1 {
+		probe.EveIfName = spec[ind+1:]
+		spec = spec[:ind]
+	}
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("probe %q must be in format <http|tcp>:<port>[/path][@ifname]", spec)
+	}
+	probe.Type = strings.ToLower(parts[0])
+	portPart := parts[1]
+	if ind := strings.Index(portPart, "/"); ind != -1 {
+		probe.Path = portPart[ind:]
+		portPart = portPart[:ind]
+	}
+	port, err := strconv.Atoi(portPart)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse probe port %q: %w", portPart, err)
+	}
+	probe.Port = port
+	switch probe.Type {
+	case ProbeHTTP:
+		if probe.Path == "" {
+			probe.Path = "/"
+		}
+	case ProbeTCP:
+		if probe.Path != "" {
+			return nil, fmt.Errorf("path is not supported for tcp probe")
+		}
+	default:
+		return nil, fmt.Errorf("unknown probe type %q", probe.Type)
+	}
+	return probe, nil
+}
+
+// String returns probe in format accepted by ParseAppProbe
+func (probe *AppProbe) String() string {
+	return fmt.Sprintf("%s:%d%s@%s", probe.Type, probe.Port, probe.Path, probe.EveIfName)
+}
+
+// Check runs probe against ip and port reachable from host,
+// http probe succeeds for status codes below 400
+func (probe *AppProbe) Check(ip string, port int, timeout time.Duration) error {
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
+	switch probe.Type {
+	case ProbeTCP:
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case ProbeHTTP:
+		client := &http.Client{Timeout: timeout}
+		resp, err := client.Get(fmt.Sprintf("http://%s%s", addr, probe.Path))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown probe type %q", probe.Type)
+}
+
+// SetAppHealth sets result of health probe for application with name
+func (ctx *State) SetAppHealth(name, health string) {
+	for _, app := range ctx.applications {
+		if app.Name == name {
+			app.Health = health
+		}
+	}
+}
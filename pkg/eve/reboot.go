@@ -0,0 +1,87 @@
+package eve
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// RebootEvent describes reboot of EVE reported in device info
+type RebootEvent struct {
+	Time       time.Time
+	Reason     string
+	BootReason info.BootReason
+	Stack      string
+}
+
+// IsUnexpectedBootReason returns true if EVE booted not because of the request
+// of user or controller, i.e. after crash, watchdog or power failure
+func IsUnexpectedBootReason(bootReason info.BootReason) bool {
+	switch bootReason {
+	case info.BootReason_BOOT_REASON_UNSPECIFIED,
+		info.BootReason_BOOT_REASON_FIRST,
+		info.BootReason_BOOT_REASON_REBOOT_CMD,
+		info.BootReason_BOOT_REASON_UPDATE,
+		info.BootReason_BOOT_REASON_FALLBACK,
+		info.BootReason_BOOT_REASON_POWEROFF_CMD:
+		return false
+	}
+	return true
+}
+
+// RebootDetector collects distinct reboots from device info messages
+type RebootDetector struct {
+	events map[int64]*RebootEvent
+}
+
+// NewRebootDetector creates RebootDetector
+func NewRebootDetector() *RebootDetector {
+	return &RebootDetector{events: make(map[int64]*RebootEvent)}
+}
+
+// Process remembers reboot reported in info message if it is device info
+func (detector *RebootDetector) Process(im *info.ZInfoMsg) {
+	dinfo := im.GetDinfo()
+	if dinfo == nil || dinfo.GetLastRebootTime() == nil {
+		return
+	}
+	rebootTime := dinfo.GetLastRebootTime().AsTime()
+	if rebootTime.IsZero() || rebootTime.Unix() <= 0 {
+		return
+	}
+	detector.events[rebootTime.UnixNano()] = &RebootEvent{
+		Time:       rebootTime,
+		Reason:     dinfo.GetLastRebootReason(),
+		BootReason: dinfo.GetLastBootReason(),
+		Stack:      dinfo.GetLastRebootStack(),
+	}
+}
+
+// Unexpected returns unexpected reboots sorted by time
+func (detector *RebootDetector) Unexpected() []*RebootEvent {
+	var result []*RebootEvent
+	for _, event := range detector.events {
+		if IsUnexpectedBootReason(event.BootReason) {
+			result = append(result, event)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+// Since returns reboots after t sorted by time
+func (detector *RebootDetector) Since(t time.Time) []*RebootEvent {
+	var result []*RebootEvent
+	for _, event := range detector.events {
+		if event.Time.After(t) {
+			result = append(result, event)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
@@ -0,0 +1,173 @@
+package eve
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eve-api/go/metrics"
+)
+
+// TopSort is column of applications Top sorts them by
+type TopSort string
+
+// Columns to sort applications by
+const (
+	TopSortCPU    TopSort = "cpu"
+	TopSortMemory TopSort = "memory"
+	TopSortName   TopSort = "name"
+)
+
+// Top collects resource usage of device and applications from metric messages
+// to display it in the way similar to top utility
+type Top struct {
+	state *State
+
+	sortBy TopSort
+	// reverse is ascending order for usage and descending for name
+	reverse bool
+
+	deviceCPUUsage float64
+	prevCPUTotal   uint64
+	prevCPUTime    time.Time
+	lastMetricTime time.Time
+}
+
+// NewTop creates Top based on State
+func NewTop(state *State) *Top {
+	return &Top{state: state, sortBy: TopSortCPU}
+}
+
+// SetSort sorts applications by column, order is reversed if they are already sorted by it
+func (top *Top) SetSort(sortBy TopSort) {
+	if top.sortBy == sortBy {
+		top.reverse = !top.reverse
+		return
+	}
+	top.sortBy = sortBy
+	top.reverse = false
+}
+
+// Reverse reverses order of applications
+func (top *Top) Reverse() {
+	top.reverse = !top.reverse
+}
+
+// less returns true if application a is shown before b
+func (top *Top) less(a, b *AppInstState) bool {
+	var less bool
+	switch {
+	case top.sortBy == TopSortCPU && a.CPUUsage != b.CPUUsage:
+		less = a.CPUUsage > b.CPUUsage
+	case top.sortBy == TopSortMemory && a.MemoryUsed != b.MemoryUsed:
+		less = a.MemoryUsed > b.MemoryUsed
+	case a.Name != b.Name:
+		less = a.Name < b.Name
+	default:
+		return a.UUID < b.UUID
+	}
+	if top.reverse {
+		return !less
+	}
+	return less
+}
+
+// header returns title of column marked if applications are sorted by it
+func (top *Top) header(title string, sortBy TopSort) string {
+	if top.sortBy != sortBy {
+		return title
+	}
+	if top.reverse != (sortBy == TopSortName) {
+		return title + "^"
+	}
+	return title + "v"
+}
+
+// MetricCallback should be assigned to feed new values from metric messages into Top
+func (top *Top) MetricCallback() emetric.HandlerFunc {
+	stateCallback := top.state.MetricCallback()
+	return func(msg *metrics.ZMetricMsg) bool {
+		stateCallback(msg)
+		ts := msg.GetAtTimeStamp().AsTime()
+		top.lastMetricTime = ts
+		if cpuMetric := msg.GetDm().GetCpuMetric(); cpuMetric != nil {
+			// total is cumulative cpu time of all cpus in seconds
+			if !top.prevCPUTime.IsZero() && cpuMetric.GetTotal() >= top.prevCPUTotal && ts.After(top.prevCPUTime) {
+				ncpu := top.state.InfoAndMetrics().GetDinfo().GetNcpu()
+				if ncpu == 0 {
+					ncpu = 1
+				}
+				top.deviceCPUUsage = float64(cpuMetric.GetTotal()-top.prevCPUTotal) /
+					ts.Sub(top.prevCPUTime).Seconds() / float64(ncpu) * 100
+			}
+			top.prevCPUTotal = cpuMetric.GetTotal()
+			top.prevCPUTime = ts
+		}
+		return false
+	}
+}
+
+// Print writes resource usage of device and applications sorted by selected column into w
+func (top *Top) Print(w io.Writer) error {
+	infoAndMetrics := top.state.InfoAndMetrics()
+	if _, err := fmt.Fprintf(w, "EVE %s  last metric: %s\n\n", top.state.device.GetID(),
+		top.lastMetricTime.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	dm := infoAndMetrics.GetDeviceMetrics()
+	if dm == nil {
+		_, err := fmt.Fprintln(w, "waiting for metrics...")
+		return err
+	}
+	memory := dm.GetMemory()
+	if _, err := fmt.Fprintf(w, "CPU: %5.1f%% (%d cpus)  MEM: %s/%s (%.1f%%)\n\n",
+		top.deviceCPUUsage, infoAndMetrics.GetDinfo().GetNcpu(),
+		humanize.Bytes(uint64(memory.GetUsedMem())*humanize.MByte),
+		humanize.Bytes(uint64(memory.GetUsedMem()+memory.GetAvailMem())*humanize.MByte),
+		memory.GetUsedPercentage()); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "MOUNT\tUSED\tTOTAL\tUSED%"); err != nil {
+		return err
+	}
+	for _, disk := range dm.GetDisk() {
+		if disk.GetMountPath() == "" || disk.GetTotal() == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f\n", disk.GetMountPath(),
+			humanize.Bytes(disk.GetUsed()*humanize.MByte), humanize.Bytes(disk.GetTotal()*humanize.MByte),
+			float64(disk.GetUsed())/float64(disk.GetTotal())*100); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+
+	apps := top.state.Applications()
+	sort.Slice(apps, func(i, j int) bool {
+		return top.less(apps[i], apps[j])
+	})
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err := fmt.Fprintf(tw, "%s\tSTATE\t%s\t%s\tMEM AVAIL\n", top.header("APP", TopSortName),
+		top.header("CPU%", TopSortCPU), top.header("MEM USED", TopSortMemory)); err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", app.Name, app.EVEState, app.CPUUsage,
+			humanize.Bytes(uint64(app.MemoryUsed)*humanize.MByte),
+			humanize.Bytes(uint64(app.MemoryAvail)*humanize.MByte)); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
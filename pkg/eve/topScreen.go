@@ -0,0 +1,168 @@
+package eve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// topScreenHelp is shown in status bar of TopScreen
+const topScreenHelp = "q quit  c/m/n sort by cpu/memory/name  r reverse  l redraw"
+
+// TopScreen shows Top in terminal in the way top utility does: screen is redrawn
+// on every update, applications are sorted by keys pressed
+type TopScreen struct {
+	mu     sync.Mutex
+	top    *Top
+	out    io.Writer
+	cols   int
+	rows   int
+	status string
+}
+
+// NewTopScreen creates TopScreen of top drawn into out of terminal with size cols x rows
+func NewTopScreen(top *Top, out io.Writer, cols, rows int) *TopScreen {
+	return &TopScreen{top: top, out: out, cols: cols, rows: rows}
+}
+
+// Resize sets size of terminal and redraws it
+func (ts *TopScreen) Resize(cols, rows int) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.cols, ts.rows = cols, rows
+	ts.redraw()
+}
+
+// Update runs update of state of Top (e.g. callback of info or metric) and redraws screen,
+// updates are serialized with drawing
+func (ts *TopScreen) Update(update func()) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	update()
+	ts.redraw()
+}
+
+// SetStatus shows message (e.g. error) in status bar instead of help
+func (ts *TopScreen) SetStatus(status string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.status = status
+	ts.redraw()
+}
+
+// Run draws screen and handles keys read from in until quit key is pressed or ctx is done
+func (ts *TopScreen) Run(ctx context.Context, in io.Reader) error {
+	ts.mu.Lock()
+	// alternate screen without cursor, so terminal is restored on exit
+	fmt.Fprint(ts.out, "\x1b[?1049h\x1b[?25l")
+	ts.redraw()
+	ts.mu.Unlock()
+	defer fmt.Fprint(ts.out, "\x1b[?25h\x1b[?1049l")
+
+	closing := make(chan struct{})
+	defer close(closing)
+	input := make(chan []byte)
+	inputErr := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				select {
+				case input <- append([]byte{}, buf[:n]...):
+				case <-closing:
+					return
+				}
+			}
+			if err != nil {
+				inputErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-inputErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case data := <-input:
+			for _, key := range data {
+				if ts.command(key) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// command runs command of key and returns true on quit
+func (ts *TopScreen) command(key byte) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	switch key {
+	// Ctrl-C does not send signal in raw mode of terminal
+	case 'q', 0x03:
+		return true
+	case 'c':
+		ts.top.SetSort(TopSortCPU)
+	case 'm':
+		ts.top.SetSort(TopSortMemory)
+	case 'n':
+		ts.top.SetSort(TopSortName)
+	case 'r':
+		ts.top.Reverse()
+	case 'l', 0x0c:
+		ts.status = ""
+	default:
+		return false
+	}
+	ts.redraw()
+	return false
+}
+
+// redraw draws Top clipped to size of terminal with status bar in the last row
+func (ts *TopScreen) redraw() {
+	var buf bytes.Buffer
+	if err := ts.top.Print(&buf); err != nil {
+		fmt.Fprintf(&buf, "\n%s\n", err)
+	}
+	height := ts.rows - 1
+	if height < 1 {
+		height = 1
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	var screen strings.Builder
+	// lines are overwritten in place instead of clearing screen to avoid flicker
+	screen.WriteString("\x1b[H")
+	for _, line := range lines {
+		screen.WriteString(ts.clip(line))
+		screen.WriteString("\x1b[K\r\n")
+	}
+	screen.WriteString("\x1b[J")
+	status := ts.status
+	if status == "" {
+		status = topScreenHelp
+	}
+	fmt.Fprintf(&screen, "\x1b[%d;1H\x1b[7m%-*s\x1b[0m", ts.rows, ts.cols, ts.clip(status))
+	_, _ = io.WriteString(ts.out, screen.String())
+}
+
+// clip truncates line to width of terminal
+func (ts *TopScreen) clip(line string) string {
+	if runes := []rune(line); len(runes) > ts.cols {
+		return string(runes[:ts.cols])
+	}
+	return line
+}
@@ -0,0 +1,137 @@
+package expect
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Media types of WASM modules stored in OCI registries
+const (
+	MediaTypeWasmConfig      = "application/vnd.wasm.config.v0+json"
+	MediaTypeWasmLayer       = "application/vnd.wasm.content.layer.v1+wasm"
+	MediaTypeWasmModuleLayer = "application/vnd.module.wasm.content.layer.v1+wasm"
+	MediaTypeWasm            = "application/wasm"
+)
+
+// annotationTitle is name of file stored in layer of artifact pushed by oras
+const annotationTitle = "org.opencontainers.image.title"
+
+// ArtifactKind is kind of content of OCI artifact
+type ArtifactKind int
+
+// Kinds of OCI artifacts
+const (
+	// ArtifactImage is image of container
+	ArtifactImage ArtifactKind = iota
+	// ArtifactWasm is WASM module
+	ArtifactWasm
+	// ArtifactDisk is disk image stored in the only layer of artifact
+	ArtifactDisk
+)
+
+// diskFormatByName returns format of disk image by extension of file name or suffix of media type
+func diskFormatByName(name string) config.Format {
+	name = strings.ToLower(name)
+	if i := strings.LastIndexAny(name, ".+"); i >= 0 {
+		name = name[i+1:]
+	}
+	switch name {
+	case "qcow2":
+		return config.Format_QCOW2
+	case "qcow":
+		return config.Format_QCOW
+	case "raw", "img":
+		return config.Format_RAW
+	case "vmdk":
+		return config.Format_VMDK
+	case "vhd":
+		return config.Format_VHD
+	case "vhdx":
+		return config.Format_VHDX
+	case "iso":
+		return config.Format_ISO
+	case "ova":
+		return config.Format_OVA
+	}
+	return config.Format_FmtUnknown
+}
+
+func isWasmMediaType(mediaType string) bool {
+	switch mediaType {
+	case MediaTypeWasmConfig, MediaTypeWasmLayer, MediaTypeWasmModuleLayer, MediaTypeWasm:
+		return true
+	}
+	return false
+}
+
+// ClassifyArtifact returns kind of content of OCI manifest and format of disk for ArtifactDisk
+func ClassifyArtifact(manifest *v1.Manifest) (ArtifactKind, config.Format) {
+	if isWasmMediaType(string(manifest.Config.MediaType)) {
+		return ArtifactWasm, config.Format_CONTAINER
+	}
+	for _, layer := range manifest.Layers {
+		if isWasmMediaType(string(layer.MediaType)) {
+			return ArtifactWasm, config.Format_CONTAINER
+		}
+	}
+	switch manifest.Config.MediaType {
+	case types.DockerConfigJSON, types.OCIConfigJSON:
+		// image of container, disks of edge-containers are handled by applyRootFSType
+		return ArtifactImage, config.Format_CONTAINER
+	}
+	if len(manifest.Layers) != 1 {
+		return ArtifactImage, config.Format_CONTAINER
+	}
+	layer := manifest.Layers[0]
+	format := diskFormatByName(layer.Annotations[annotationTitle])
+	if format == config.Format_FmtUnknown {
+		format = diskFormatByName(string(layer.MediaType))
+	}
+	if format == config.Format_FmtUnknown {
+		format = diskFormatByName(string(manifest.Config.MediaType))
+	}
+	if format == config.Format_FmtUnknown {
+		return ArtifactImage, config.Format_CONTAINER
+	}
+	return ArtifactDisk, format
+}
+
+// applyArtifactType inspects manifest of image in registry and sets format of disk
+// image stored as OCI artifact into image or remembers that it is WASM module,
+// format defined by user is not changed
+func (exp *AppExpectation) applyArtifactType(image *config.Image) error {
+	if exp.imageFormat == "wasm" {
+		exp.wasm = true
+		return nil
+	}
+	if exp.appLink == defaults.DefaultDummyExpect || exp.imageFormat != "" {
+		return nil
+	}
+	ref := fmt.Sprintf("%s/%s", exp.getDataStoreFQDN(false), image.Name)
+	data, err := crane.Manifest(ref, exp.craneOptions()...)
+	if err != nil {
+		return err
+	}
+	manifest, err := v1.ParseManifest(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	kind, format := ClassifyArtifact(manifest)
+	switch kind {
+	case ArtifactWasm:
+		log.Infof("%s is WASM module", ref)
+		exp.wasm = true
+	case ArtifactDisk:
+		log.Infof("%s is disk image in %s format", ref, format)
+		image.Iformat = format
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
@@ -73,9 +74,16 @@ func (exp *AppExpectation) checkDataStoreDocker(ds *config.DatastoreConfig) bool
 // createDataStoreDocker creates DatastoreConfig for docker.io with provided id
 func (exp *AppExpectation) createDataStoreDocker(id uuid.UUID) *config.DatastoreConfig {
 	fqdn := exp.getDataStoreFQDN(true)
-	user, password, err := utils.GetDockerAuthPlain(fqdn)
-	if err != nil {
-		log.Errorf("cannot get docker plain auth for %s: %v", fqdn, err)
+	var user, password string
+	creds, err := utils.GetRegistryCredentials(fqdn, exp.registryAuth)
+	switch {
+	case err != nil:
+		log.Errorf("cannot get credentials for %s: %v", fqdn, err)
+	case creds.IdentityToken != "":
+		// datastore of EVE has no field for refresh token and it is not a password
+		log.Errorf("identity token of %s cannot be sent to EVE, define user with password-env or password-file in registry-auth", fqdn)
+	default:
+		user, password = creds.User, creds.Password
 	}
 	return &config.DatastoreConfig{
 		Id:         id.String(),
@@ -89,6 +97,28 @@ func (exp *AppExpectation) createDataStoreDocker(id uuid.UUID) *config.Datastore
 	}
 }
 
+// craneOptions returns options of crane to access registry of image with
+// credentials defined in config, default keychain is used if not defined
+func (exp *AppExpectation) craneOptions() []crane.Option {
+	fqdn := exp.getDataStoreFQDN(false)
+	if utils.FindRegistryAuth(fqdn, exp.registryAuth) == nil {
+		return nil
+	}
+	creds, err := utils.GetRegistryCredentials(fqdn, exp.registryAuth)
+	if err != nil {
+		log.Errorf("cannot get credentials for %s: %v", fqdn, err)
+		return nil
+	}
+	if creds.IdentityToken != "" {
+		// identity token is exchanged for bearer token, not sent as password
+		return []crane.Option{crane.WithAuth(authn.FromConfig(authn.AuthConfig{
+			Username:      creds.User,
+			IdentityToken: creds.IdentityToken,
+		}))}
+	}
+	return []crane.Option{crane.WithAuth(&authn.Basic{Username: creds.User, Password: creds.Password})}
+}
+
 // applyRootFSType try to parse manifest to get Annotations provided in https://github.com/lf-edge/edge-containers/blob/master/docs/annotations.md
 func (exp *AppExpectation) applyRootFSType(image *config.Image) error {
 	if exp.appLink == defaults.DefaultDummyExpect {
@@ -96,7 +126,7 @@ func (exp *AppExpectation) applyRootFSType(image *config.Image) error {
 		return nil
 	}
 	ref := fmt.Sprintf("%s/%s", exp.getDataStoreFQDN(false), image.Name)
-	manifest, err := crane.Manifest(ref)
+	manifest, err := crane.Manifest(ref, exp.craneOptions()...)
 	if err != nil {
 		return err
 	}
@@ -137,7 +167,7 @@ func (exp *AppExpectation) obtainVolumeInfo(image *config.Image) ([]string, erro
 		return nil, nil
 	}
 	ref := fmt.Sprintf("%s/%s", exp.getDataStoreFQDN(false), image.Name)
-	cfg, err := crane.Config(ref)
+	cfg, err := crane.Config(ref, exp.craneOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("error getting config %s: %v", image.Name, err)
 	}
@@ -189,11 +219,15 @@ func (exp *AppExpectation) prepareImage() *config.Image {
 //
 //	it uses name of app and cpu/mem params from AppExpectation
 func (exp *AppExpectation) createAppInstanceConfigDocker(img *config.Image, id uuid.UUID) *appBundle {
-	log.Debugf("Try to obtain info about volumes, please wait")
-	mountPointsList, err := exp.obtainVolumeInfo(img)
-	if err != nil {
-		//if something wrong with info about image, just print information
-		log.Errorf("cannot obtain info about volumes: %v", err)
+	var mountPointsList []string
+	if img.Iformat == config.Format_CONTAINER && !exp.wasm {
+		log.Debugf("Try to obtain info about volumes, please wait")
+		var err error
+		mountPointsList, err = exp.obtainVolumeInfo(img)
+		if err != nil {
+			//if something wrong with info about image, just print information
+			log.Errorf("cannot obtain info about volumes: %v", err)
+		}
 	}
 	log.Debugf("Try to obtain info about disks, please wait")
 	if err := exp.applyRootFSType(img); err != nil {
@@ -258,6 +292,10 @@ func (exp *AppExpectation) createAppInstanceConfigDocker(img *config.Image, id u
 		}
 	}
 	app.Fixedresources.VirtualizationMode = exp.virtualizationMode
+	if exp.wasm {
+		// WASM modules run by runtime of EVE without hypervisor
+		app.Fixedresources.VirtualizationMode = config.VmMode_NOHYPER
+	}
 	return &appBundle{
 		appInstanceConfig: app,
 		contentTrees:      contentTrees,
@@ -48,6 +48,7 @@ type AppExpectation struct {
 	appLink     string
 	appAdapters []string
 	imageFormat string
+	wasm        bool // image is WASM module
 	cpu         uint32
 	mem         uint32
 	metadata    string
@@ -71,7 +72,8 @@ type AppExpectation struct {
 	volumesType VolumeType
 	volumeSize  int64
 
-	registry string
+	registry     string
+	registryAuth []utils.RegistryAuth
 
 	oldAppName string
 
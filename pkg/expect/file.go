@@ -21,20 +21,28 @@ func (exp *AppExpectation) createImageFile(id uuid.UUID, dsID string) *config.Im
 	var fileSize int64
 	sha256 := ""
 	filePath := ""
-	status := server.EServerCheckStatus(filepath.Base(exp.appURL))
-	if !status.ISReady || status.Size != utils.GetFileSize(exp.appURL) || status.Sha256 != utils.SHA256SUM(exp.appURL) {
-		log.Infof("Start uploading into eserver of %s", exp.appLink)
-		status = server.EServerAddFile(exp.appURL, "")
-		if status.Error != "" {
-			log.Error(status.Error)
+	if utils.IsDryRun() {
+		// eserver stores file under its name, so config is the same as after upload
+		utils.DryRunf("upload %s into eserver", exp.appURL)
+		sha256 = utils.SHA256SUM(exp.appURL)
+		fileSize = utils.GetFileSize(exp.appURL)
+		filePath = filepath.Base(exp.appURL)
+	} else {
+		status := server.EServerCheckStatus(filepath.Base(exp.appURL))
+		if !status.ISReady || status.Size != utils.GetFileSize(exp.appURL) || status.Sha256 != utils.SHA256SUM(exp.appURL) {
+			log.Infof("Start uploading into eserver of %s", exp.appLink)
+			status = server.EServerAddFile(exp.appURL, "")
+			if status.Error != "" {
+				log.Error(status.Error)
+			}
+		}
+		sha256 = status.Sha256
+		fileSize = status.Size
+		filePath = status.FileName
+		log.Infof("Image uploaded with size %s and sha256 %s", humanize.Bytes(uint64(status.Size)), status.Sha256)
+		if filePath == "" {
+			log.Fatal("Not uploaded")
 		}
-	}
-	sha256 = status.Sha256
-	fileSize = status.Size
-	filePath = status.FileName
-	log.Infof("Image uploaded with size %s and sha256 %s", humanize.Bytes(uint64(status.Size)), status.Sha256)
-	if filePath == "" {
-		log.Fatal("Not uploaded")
 	}
 	if exp.sftpLoad {
 		filePath = filepath.Join(defaults.DefaultSFTPDirPrefix, filePath)
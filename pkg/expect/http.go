@@ -11,6 +11,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
@@ -29,6 +30,10 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 	if el, stored := defaults.ImageStore[exp.appLink]; exp.httpDirectLoad && stored {
 		sha256 = el.Sha256
 		fileSize = el.Size
+	} else if utils.IsDryRun() {
+		// size and sha256 are known only after download
+		utils.DryRunf("download %s into eserver", exp.appLink)
+		filePath = path.Base(exp.appURL)
 	} else {
 		name := server.EServerAddFileURL(exp.appLink)
 		log.Infof("Start download into eserver of %s", name)
@@ -30,7 +30,12 @@ func (exp *AppExpectation) createImage(dsID string) (*config.Image, error) {
 	}
 	switch exp.appType {
 	case dockerApp:
-		return exp.createImageDocker(id, dsID), nil
+		image := exp.createImageDocker(id, dsID)
+		if err := exp.applyArtifactType(image); err != nil {
+			//if something wrong with manifest, deploy it as container
+			log.Errorf("cannot obtain type of artifact: %v", err)
+		}
+		return image, nil
 	case httpApp, httpsApp:
 		return exp.createImageHTTP(id, dsID), nil
 	case fileApp:
@@ -54,7 +59,7 @@ func (exp *AppExpectation) imageFormatEnum() config.Format {
 		defaultFormat = config.Format_QCOW2
 	}
 	switch exp.imageFormat {
-	case "container", "oci":
+	case "container", "oci", "wasm":
 		actual = config.Format_CONTAINER
 	case "qcow2":
 		actual = config.Format_QCOW2
@@ -111,6 +116,10 @@ func (exp *AppExpectation) Image() (image *config.Image) {
 			log.Fatalf("AddImage: %s", err)
 		}
 		log.Debugf("new image created %s", image.Uuidandversion.Uuid)
+	} else if exp.appType == dockerApp {
+		if err = exp.applyArtifactType(image); err != nil {
+			log.Errorf("cannot obtain type of artifact: %v", err)
+		}
 	}
 	return
 }
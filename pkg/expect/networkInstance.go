@@ -1,9 +1,12 @@
 package expect
 
 import (
+	"bytes"
+	"fmt"
 	"math/rand"
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/pkg/namesgenerator"
@@ -26,6 +29,94 @@ type NetInstanceExpectation struct {
 	uplinkAdapter    string
 	staticDNSEntries map[string][]string
 	enableFlowlog    bool
+	gateway          string
+	dnsServers       []string
+	dhcpRange        string
+}
+
+// NetInstanceIPSpec returns IP config of local network instance in subnet,
+// undefined gateway, DNS servers and DHCP range (in format START-END) are
+// derived from subnet
+func NetInstanceIPSpec(subnet, gateway string, dnsServers []string, dhcpRange string) (*config.Ipspec, error) {
+	gwIP, dhcpStart, dhcpEnd, err := utils.GetNetworkIPs(subnet)
+	if err != nil {
+		return nil, err
+	}
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, err
+	}
+	parseIP := func(addr, kind string) (net.IP, error) {
+		ip := net.ParseIP(strings.TrimSpace(addr))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid %s: %s", kind, addr)
+		}
+		if !ipNet.Contains(ip) {
+			return nil, fmt.Errorf("%s %s is out of subnet %s", kind, addr, subnet)
+		}
+		return ip, nil
+	}
+	if gateway != "" {
+		if gwIP, err = parseIP(gateway, "gateway"); err != nil {
+			return nil, err
+		}
+	}
+	if dhcpRange != "" {
+		start, end, found := strings.Cut(dhcpRange, "-")
+		if !found {
+			return nil, fmt.Errorf("DHCP range must be in format START-END: %s", dhcpRange)
+		}
+		if dhcpStart, err = parseIP(start, "start of DHCP range"); err != nil {
+			return nil, err
+		}
+		if dhcpEnd, err = parseIP(end, "end of DHCP range"); err != nil {
+			return nil, err
+		}
+		if bytes.Compare(dhcpStart.To16(), dhcpEnd.To16()) > 0 {
+			return nil, fmt.Errorf("start of DHCP range %s is after its end %s", dhcpStart, dhcpEnd)
+		}
+	}
+	dns := []string{gwIP.String()}
+	if len(dnsServers) > 0 {
+		dns = nil
+		for _, server := range dnsServers {
+			if net.ParseIP(server) == nil {
+				return nil, fmt.Errorf("invalid DNS server: %s", server)
+			}
+			dns = append(dns, server)
+		}
+	}
+	return &config.Ipspec{
+		Subnet:  subnet,
+		Gateway: gwIP.String(),
+		Dns:     dns,
+		DhcpRange: &config.IpRange{
+			Start: dhcpStart.String(),
+			End:   dhcpEnd.String(),
+		},
+	}, nil
+}
+
+// NetInstanceStaticRoute returns static route of network instance to
+// destination network in CIDR notation via gateway and/or port
+func NetInstanceStaticRoute(destination, gateway, port string) (*config.IPRoute, error) {
+	if _, _, err := net.ParseCIDR(destination); err != nil {
+		return nil, fmt.Errorf("destination must be in CIDR notation: %w", err)
+	}
+	if gateway == "" && port == "" {
+		return nil, fmt.Errorf("gateway or port of route to %s must be defined", destination)
+	}
+	if gateway != "" {
+		ip := net.ParseIP(gateway)
+		if ip == nil || ip.IsUnspecified() {
+			return nil, fmt.Errorf("invalid gateway: %s", gateway)
+		}
+	}
+	return &config.IPRoute{
+		DestinationNetwork: destination,
+		Gateway:            gateway,
+		Port:               port,
+	}, nil
 }
 
 // checkNetworkInstance checks if provided netInst match expectation
@@ -72,19 +163,11 @@ func (exp *AppExpectation) createNetworkInstance(instanceExpect *NetInstanceExpe
 	if instanceExpect.netInstType == "switch" {
 		netInst.InstType = config.ZNetworkInstType_ZnetInstSwitch
 	} else {
-		gwIP, dhcpStart, dhcpEnd, err := utils.GetNetworkIPs(instanceExpect.subnet)
+		netInst.Ip, err = NetInstanceIPSpec(instanceExpect.subnet, instanceExpect.gateway,
+			instanceExpect.dnsServers, instanceExpect.dhcpRange)
 		if err != nil {
 			return nil, err
 		}
-		netInst.Ip = &config.Ipspec{
-			Subnet:  instanceExpect.subnet,
-			Gateway: gwIP.String(),
-			Dns:     []string{gwIP.String()},
-			DhcpRange: &config.IpRange{
-				Start: dhcpStart.String(),
-				End:   dhcpEnd.String(),
-			},
-		}
 	}
 	if instanceExpect.name == "" {
 		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
 )
 
@@ -170,6 +171,21 @@ func WithStaticDNSEntries(networkName string, dnsEntries []string) ExpectationOp
 	}
 }
 
+// WithNetInstanceIPConfig sets gateway, DNS servers and DHCP range in format
+// START-END of the given local network instance
+func WithNetInstanceIPConfig(networkName, gateway string, dnsServers []string, dhcpRange string) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		for _, netInstance := range expectation.netInstances {
+			if netInstance.name != networkName {
+				continue
+			}
+			netInstance.gateway = gateway
+			netInstance.dnsServers = dnsServers
+			netInstance.dhcpRange = dhcpRange
+		}
+	}
+}
+
 // WithFlowlog enables flow logging for the given network instance.
 func WithFlowlog(networkName string) ExpectationOption {
 	return func(expectation *AppExpectation) {
@@ -246,6 +262,13 @@ func WithRegistry(registry string) ExpectationOption {
 	}
 }
 
+// WithRegistryAuth sets credentials of private registries to pull images from
+func WithRegistryAuth(auths []utils.RegistryAuth) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.registryAuth = auths
+	}
+}
+
 // WithOldApp sets old app name to get info from
 func WithOldApp(appName string) ExpectationOption {
 	return func(expectation *AppExpectation) {
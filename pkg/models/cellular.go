@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CellularAccessPoint is APN of SIM card in SIM slot of cellular modem
+type CellularAccessPoint struct {
+	// SimSlot is SIM slot of modem, 0 for the default one
+	SimSlot uint32 `json:"simSlot,omitempty"`
+	APN     string `json:"apn"`
+	// AuthProtocol is one of none, pap, chap and pap-and-chap
+	AuthProtocol string `json:"authProtocol,omitempty"`
+	// PreferredPLMNs are MCC+MNC of preferred networks
+	PreferredPLMNs []string `json:"preferredPlmns,omitempty"`
+	// PreferredRATs are preferred radio access technologies: gsm, umts, lte and 5gnr
+	PreferredRATs []string `json:"preferredRats,omitempty"`
+	ForbidRoaming bool     `json:"forbidRoaming,omitempty"`
+}
+
+// CellularPort is cellular (wwan) port of device with its logical label
+type CellularPort struct {
+	LogicalLabel string `json:"logicallabel"`
+	// Uplink is set to use port for management
+	Uplink bool `json:"uplink,omitempty"`
+	// ActivatedSimSlot is SIM slot to use, 0 for the default one
+	ActivatedSimSlot uint32                `json:"activatedSimSlot,omitempty"`
+	LocationTracking bool                  `json:"locationTracking,omitempty"`
+	AccessPoints     []CellularAccessPoint `json:"accessPoints,omitempty"`
+}
+
+var cellularAuthProtocols = map[string]config.CellularAuthProtocol{
+	"":             config.CellularAuthProtocol_CELLULAR_AUTH_PROTOCOL_NONE,
+	"none":         config.CellularAuthProtocol_CELLULAR_AUTH_PROTOCOL_NONE,
+	"pap":          config.CellularAuthProtocol_CELLULAR_AUTH_PROTOCOL_PAP,
+	"chap":         config.CellularAuthProtocol_CELLULAR_AUTH_PROTOCOL_CHAP,
+	"pap-and-chap": config.CellularAuthProtocol_CELLULAR_AUTH_PROTOCOL_PAP_AND_CHAP,
+}
+
+var radioAccessTechnologies = map[string]evecommon.RadioAccessTechnology{
+	"gsm":  evecommon.RadioAccessTechnology_RADIO_ACCESS_TECHNOLOGY_GSM,
+	"umts": evecommon.RadioAccessTechnology_RADIO_ACCESS_TECHNOLOGY_UMTS,
+	"lte":  evecommon.RadioAccessTechnology_RADIO_ACCESS_TECHNOLOGY_LTE,
+	"5gnr": evecommon.RadioAccessTechnology_RADIO_ACCESS_TECHNOLOGY_5GNR,
+}
+
+// CellularNetworkID returns ID of network config of cellular port with logicalLabel
+func CellularNetworkID(logicalLabel string) string {
+	return uuid.NewV5(uuid.NamespaceOID, "eden-wwan-"+logicalLabel).String()
+}
+
+// NetworkConfig returns network config of EVE for cellular port
+func (port *CellularPort) NetworkConfig() (*config.NetworkConfig, error) {
+	if port.LogicalLabel == "" {
+		return nil, fmt.Errorf("logical label of cellular port is not defined")
+	}
+	cellular := &config.CellularConfig{
+		ActivatedSimSlot: port.ActivatedSimSlot,
+		LocationTracking: port.LocationTracking,
+	}
+	slots := make(map[uint32]bool)
+	for _, ap := range port.AccessPoints {
+		if slots[ap.SimSlot] {
+			return nil, fmt.Errorf("duplicate access point for SIM slot %d of %s", ap.SimSlot, port.LogicalLabel)
+		}
+		slots[ap.SimSlot] = true
+		authProtocol, ok := cellularAuthProtocols[strings.ToLower(ap.AuthProtocol)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported auth protocol %s, use none, pap, chap or pap-and-chap", ap.AuthProtocol)
+		}
+		accessPoint := &config.CellularAccessPoint{
+			SimSlot:        ap.SimSlot,
+			Apn:            ap.APN,
+			AuthProtocol:   authProtocol,
+			PreferredPlmns: ap.PreferredPLMNs,
+			ForbidRoaming:  ap.ForbidRoaming,
+		}
+		for _, rat := range ap.PreferredRATs {
+			value, ok := radioAccessTechnologies[strings.ToLower(rat)]
+			if !ok {
+				return nil, fmt.Errorf("unsupported radio access technology %s, use gsm, umts, lte or 5gnr", rat)
+			}
+			accessPoint.PreferredRats = append(accessPoint.PreferredRats, value)
+		}
+		cellular.AccessPoints = append(cellular.AccessPoints, accessPoint)
+	}
+	if port.ActivatedSimSlot != 0 && len(port.AccessPoints) > 0 && !slots[port.ActivatedSimSlot] {
+		return nil, fmt.Errorf("no access point defined for activated SIM slot %d of %s",
+			port.ActivatedSimSlot, port.LogicalLabel)
+	}
+	return &config.NetworkConfig{
+		Id:   CellularNetworkID(port.LogicalLabel),
+		Type: config.NetworkType_V4,
+		Ip: &config.Ipspec{
+			Dhcp:      config.DHCPType_Client,
+			DhcpRange: &config.IpRange{},
+		},
+		Wireless: &config.WirelessConfig{
+			Type:        config.WirelessType_Cellular,
+			CellularCfg: []*config.CellularConfig{cellular},
+		},
+	}, nil
+}
+
+// applyCellularPorts adds networks of cellular ports into model and points
+// system adapters with their logical labels to them
+func applyCellularPorts(model DevModel, ports []*CellularPort) error {
+	networks := model.Networks()
+	adapters := model.Adapters()
+	for _, port := range ports {
+		network, err := port.NetworkConfig()
+		if err != nil {
+			return err
+		}
+		replaced := false
+		for i, el := range networks {
+			if el.Id == network.Id {
+				networks[i] = network
+				replaced = true
+			}
+		}
+		if !replaced {
+			networks = append(networks, network)
+		}
+		found := false
+		for _, el := range adapters {
+			if el.Name == port.LogicalLabel {
+				el.NetworkUUID = network.Id
+				el.Uplink = port.Uplink
+				found = true
+			}
+		}
+		if !found {
+			adapters = append(adapters, &config.SystemAdapter{
+				Name:        port.LogicalLabel,
+				Uplink:      port.Uplink,
+				NetworkUUID: network.Id,
+			})
+		}
+	}
+	model.SetNetworks(networks)
+	model.SetAdapters(adapters)
+	return nil
+}
+
+// ParseCellularAccessPoint parses access point in format [SIM_SLOT:]APN
+func ParseCellularAccessPoint(s string) (CellularAccessPoint, error) {
+	slot, apn, found := strings.Cut(s, ":")
+	if !found {
+		return CellularAccessPoint{APN: s}, nil
+	}
+	simSlot, err := strconv.ParseUint(slot, 10, 32)
+	if err != nil {
+		return CellularAccessPoint{}, fmt.Errorf("invalid SIM slot in %s: %w", s, err)
+	}
+	return CellularAccessPoint{SimSlot: uint32(simSlot), APN: apn}, nil
+}
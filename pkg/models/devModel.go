@@ -52,6 +52,9 @@ type ModelFile struct {
 	// systemAdapters and to create fully customized configurations.
 	Networks       []*config.NetworkConfig `json:"networks,omitempty"`
 	SystemAdapters []*config.SystemAdapter `json:"systemAdapterList,omitempty"`
+	// Cellular defines APNs and SIM slots of cellular ports in a simpler form,
+	// networks and systemAdapters for them are generated
+	Cellular []*CellularPort `json:"cellular,omitempty"`
 }
 
 // OverwriteDevModelFromFile replace default config with config from provided file
@@ -77,7 +80,7 @@ func OverwriteDevModelFromFile(fileName string, model DevModel) error {
 	if len(mFile.SystemAdapters) > 0 {
 		model.SetAdapters(mFile.SystemAdapters)
 	}
-	return nil
+	return applyCellularPorts(model, mFile.Cellular)
 }
 
 // DevModel is an interface to use for describe device
@@ -101,6 +104,19 @@ type DevModel interface {
 	Config() map[string]interface{}
 }
 
+// DevModelTypes returns names of supported dev models
+func DevModelTypes() []string {
+	return []string{
+		string(devModelTypeQemu),
+		string(devModelTypeGeneral),
+		string(devModelTypeGCP),
+		string(devModelTypeRaspberry),
+		string(devModelTypeVBox),
+		string(devModelTypeParallels),
+		string(devModelTypePhysical),
+	}
+}
+
 // GetDevModelByName return DevModel object by DevModelType string
 func GetDevModelByName(modelType string) (DevModel, error) {
 	return GetDevModel(devModelType(modelType))
@@ -121,6 +137,8 @@ func GetDevModel(devModelType devModelType) (DevModel, error) {
 		return createVBox()
 	case devModelTypeParallels:
 		return createParallels()
+	case devModelTypePhysical:
+		return createPhysical()
 
 	}
 	return nil, fmt.Errorf("not implemented type: %s", devModelType)
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// devModelTypePhysical is model type for real hardware in a lab
+// controlled with out-of-band mechanisms (PDU, IPMI and serial console server)
+const devModelTypePhysical devModelType = defaults.DefaultPhysicalModel
+
+// DevModelPhysical is dev model fields
+type DevModelPhysical struct {
+	//physicalIOs is PhysicalIO slice for DevModel
+	physicalIOs []*config.PhysicalIO
+	//networks is NetworkConfig slice for DevModel
+	networks []*config.NetworkConfig
+	//adapters is SystemAdapter slice for DevModel
+	adapters     []*config.SystemAdapter
+	vlanAdapters []*config.VlanAdapter
+	bondAdapters []*config.BondAdapter
+	//adapterForSwitches is name of adapter for use in switch
+	adapterForSwitches []string
+}
+
+// Config returns map with config overwrites
+func (ctx *DevModelPhysical) Config() map[string]interface{} {
+	cfg := make(map[string]interface{})
+	cfg["eve.serial"] = "*"
+	cfg["eve.remote"] = true
+	cfg["eve.remote-addr"] = ""
+	cfg["eve.hostfwd"] = map[string]string{}
+	cfg["eve.devmodel"] = ctx.DevModelType()
+	return cfg
+}
+
+// DiskReadyMessage to show when image is ready
+func (ctx *DevModelPhysical) DiskReadyMessage() string {
+	return "EVE image ready: %s"
+}
+
+// DiskFormat to use for build image
+func (ctx *DevModelPhysical) DiskFormat() string {
+	return "raw"
+}
+
+// GetPortConfig returns PortConfig overwrite
+func (ctx *DevModelPhysical) GetPortConfig(_ string, _ string) string {
+	return ""
+}
+
+// SetWiFiParams not implemented for physical device
+func (ctx *DevModelPhysical) SetWiFiParams(_ string, _ string) {
+	log.Warning("not implemented for physical device")
+}
+
+// Adapters returns adapters of devModel
+func (ctx *DevModelPhysical) Adapters() []*config.SystemAdapter {
+	return ctx.adapters
+}
+
+// SetAdapters sets systems adapters of devModel
+func (ctx *DevModelPhysical) SetAdapters(adapters []*config.SystemAdapter) {
+	ctx.adapters = adapters
+}
+
+// Networks returns networks of devModel
+func (ctx *DevModelPhysical) Networks() []*config.NetworkConfig {
+	return ctx.networks
+}
+
+// SetNetworks sets networks of devModel
+func (ctx *DevModelPhysical) SetNetworks(networks []*config.NetworkConfig) {
+	ctx.networks = networks
+}
+
+// PhysicalIOs returns physicalIOs of devModel
+func (ctx *DevModelPhysical) PhysicalIOs() []*config.PhysicalIO {
+	return ctx.physicalIOs
+}
+
+// SetPhysicalIOs sets physicalIOs of devModel
+func (ctx *DevModelPhysical) SetPhysicalIOs(physicalIOs []*config.PhysicalIO) {
+	ctx.physicalIOs = physicalIOs
+}
+
+// VlanAdapters returns Vlan adapters of devModel
+func (ctx *DevModelPhysical) VlanAdapters() []*config.VlanAdapter {
+	return ctx.vlanAdapters
+}
+
+// SetVlanAdapters sets Vlan adapters of devModel
+func (ctx *DevModelPhysical) SetVlanAdapters(vlans []*config.VlanAdapter) {
+	ctx.vlanAdapters = vlans
+}
+
+// BondAdapters returns Bond adapters of devModel
+func (ctx *DevModelPhysical) BondAdapters() []*config.BondAdapter {
+	return ctx.bondAdapters
+}
+
+// SetBondAdapters sets Bond adapters of devModel
+func (ctx *DevModelPhysical) SetBondAdapters(bonds []*config.BondAdapter) {
+	ctx.bondAdapters = bonds
+}
+
+// AdapterForSwitches returns adapterForSwitches of devModel
+func (ctx *DevModelPhysical) AdapterForSwitches() []string {
+	return ctx.adapterForSwitches
+}
+
+// DevModelType returns devModelType of devModel
+func (ctx *DevModelPhysical) DevModelType() string {
+	return string(devModelTypePhysical)
+}
+
+func createPhysical() (DevModel, error) {
+	return &DevModelPhysical{
+		physicalIOs:        generatePhysicalIOs(2, 0, 0),
+		networks:           generateNetworkConfigs(2, 0),
+		adapters:           generateSystemAdapters(2, 0),
+		adapterForSwitches: []string{"eth1"},
+	}, nil
+}
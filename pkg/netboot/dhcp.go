@@ -0,0 +1,249 @@
+package netboot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Types of DHCP messages (option 53)
+const (
+	DHCPDiscover = 1
+	DHCPOffer    = 2
+	DHCPRequest  = 3
+	DHCPAck      = 5
+)
+
+// DHCP options used by PXE
+const (
+	OptionVendorSpecific = 43
+	OptionMessageType    = 53
+	OptionServerID       = 54
+	OptionVendorClass    = 60
+	OptionBootfileName   = 67
+	OptionUserClass      = 77
+	OptionClientGUID     = 97
+	OptionIPXEEncap      = 175
+)
+
+const (
+	bootRequest  = 1
+	bootReply    = 2
+	headerLength = 236
+	// minPacketLength is minimal length of BOOTP packet expected by clients
+	minPacketLength = 300
+	optionPad       = 0
+	optionEnd       = 255
+	pxeClient       = "PXEClient"
+)
+
+var magicCookie = []byte{99, 130, 83, 99}
+
+// Packet is DHCP message
+type Packet struct {
+	Op      byte
+	XID     uint32
+	Secs    uint16
+	Flags   uint16
+	CIAddr  net.IP
+	YIAddr  net.IP
+	SIAddr  net.IP
+	GIAddr  net.IP
+	CHAddr  net.HardwareAddr
+	File    string
+	Options map[byte][]byte
+}
+
+// MessageType returns type of DHCP message or 0 for BOOTP
+func (p *Packet) MessageType() byte {
+	if v := p.Options[OptionMessageType]; len(v) == 1 {
+		return v[0]
+	}
+	return 0
+}
+
+// IsPXE returns true if packet is sent by PXE or iPXE client
+func (p *Packet) IsPXE() bool {
+	return bytes.HasPrefix(p.Options[OptionVendorClass], []byte(pxeClient))
+}
+
+// IsIPXE returns true if packet is sent by iPXE (bootloader is already loaded)
+func (p *Packet) IsIPXE() bool {
+	if _, ok := p.Options[OptionIPXEEncap]; ok {
+		return true
+	}
+	return string(p.Options[OptionUserClass]) == "iPXE"
+}
+
+// ParsePacket parses DHCP message
+func ParsePacket(data []byte) (*Packet, error) {
+	if len(data) < headerLength+len(magicCookie) {
+		return nil, fmt.Errorf("packet is too short: %d bytes", len(data))
+	}
+	if !bytes.Equal(data[headerLength:headerLength+len(magicCookie)], magicCookie) {
+		return nil, fmt.Errorf("no magic cookie of DHCP in packet")
+	}
+	hlen := int(data[2])
+	if hlen > 16 {
+		return nil, fmt.Errorf("invalid length of hardware address: %d", hlen)
+	}
+	p := &Packet{
+		Op:      data[0],
+		XID:     binary.BigEndian.Uint32(data[4:8]),
+		Secs:    binary.BigEndian.Uint16(data[8:10]),
+		Flags:   binary.BigEndian.Uint16(data[10:12]),
+		CIAddr:  net.IP(append([]byte{}, data[12:16]...)),
+		YIAddr:  net.IP(append([]byte{}, data[16:20]...)),
+		SIAddr:  net.IP(append([]byte{}, data[20:24]...)),
+		GIAddr:  net.IP(append([]byte{}, data[24:28]...)),
+		CHAddr:  net.HardwareAddr(append([]byte{}, data[28:28+hlen]...)),
+		File:    string(bytes.TrimRight(data[108:236], "\x00")),
+		Options: make(map[byte][]byte),
+	}
+	options := data[headerLength+len(magicCookie):]
+	for i := 0; i < len(options); {
+		code := options[i]
+		if code == optionEnd {
+			break
+		}
+		if code == optionPad {
+			i++
+			continue
+		}
+		if i+1 >= len(options) || i+2+int(options[i+1]) > len(options) {
+			return nil, fmt.Errorf("option %d is truncated", code)
+		}
+		length := int(options[i+1])
+		// the same option may be split into several ones (RFC 3396)
+		p.Options[code] = append(p.Options[code], options[i+2:i+2+length]...)
+		i += 2 + length
+	}
+	return p, nil
+}
+
+// Marshal returns DHCP message encoded to send
+func (p *Packet) Marshal() []byte {
+	data := make([]byte, headerLength, minPacketLength)
+	data[0] = p.Op
+	data[1] = 1 // Ethernet
+	data[2] = byte(len(p.CHAddr))
+	binary.BigEndian.PutUint32(data[4:8], p.XID)
+	binary.BigEndian.PutUint16(data[8:10], p.Secs)
+	binary.BigEndian.PutUint16(data[10:12], p.Flags)
+	for i, ip := range []net.IP{p.CIAddr, p.YIAddr, p.SIAddr, p.GIAddr} {
+		if ip4 := ip.To4(); ip4 != nil {
+			copy(data[12+4*i:16+4*i], ip4)
+		}
+	}
+	copy(data[28:44], p.CHAddr)
+	copy(data[108:236], p.File)
+	data = append(data, magicCookie...)
+	// keep message type first as some clients expect it
+	if v, ok := p.Options[OptionMessageType]; ok {
+		data = append(data, OptionMessageType, byte(len(v)))
+		data = append(data, v...)
+	}
+	for code := 1; code < optionEnd; code++ {
+		v, ok := p.Options[byte(code)]
+		if !ok || code == OptionMessageType {
+			continue
+		}
+		for len(v) > 255 {
+			data = append(data, byte(code), 255)
+			data = append(data, v[:255]...)
+			v = v[255:]
+		}
+		data = append(data, byte(code), byte(len(v)))
+		data = append(data, v...)
+	}
+	data = append(data, optionEnd)
+	for len(data) < minPacketLength {
+		data = append(data, optionPad)
+	}
+	return data
+}
+
+// Reply returns answer of proxy DHCP to request of PXE client: it does not
+// lease address, only points client to bootloader on TFTP (or to iPXE script
+// on HTTP for iPXE). It returns nil if request must be ignored.
+func (s *Server) Reply(req *Packet) *Packet {
+	if req.Op != bootRequest || !req.IsPXE() {
+		return nil
+	}
+	var messageType byte
+	switch req.MessageType() {
+	case DHCPDiscover:
+		messageType = DHCPOffer
+	case DHCPRequest:
+		messageType = DHCPAck
+	default:
+		return nil
+	}
+	ip := s.IP.To4()
+	reply := &Packet{
+		Op:     bootReply,
+		XID:    req.XID,
+		Flags:  req.Flags,
+		CIAddr: req.CIAddr,
+		YIAddr: net.IPv4zero,
+		SIAddr: ip,
+		GIAddr: req.GIAddr,
+		CHAddr: req.CHAddr,
+		Options: map[byte][]byte{
+			OptionMessageType: {messageType},
+			OptionServerID:    ip,
+			OptionVendorClass: []byte(pxeClient),
+		},
+	}
+	if messageType == DHCPOffer {
+		reply.CIAddr = net.IPv4zero
+	}
+	if guid, ok := req.Options[OptionClientGUID]; ok {
+		reply.Options[OptionClientGUID] = guid
+	}
+	if req.IsIPXE() {
+		reply.File = s.ScriptURL
+	} else {
+		reply.File = s.Bootloader
+		// PXE discovery control: boot file from this offer without boot server discovery
+		reply.Options[OptionVendorSpecific] = []byte{6, 1, 8, optionEnd}
+	}
+	reply.Options[OptionBootfileName] = []byte(reply.File)
+	return reply
+}
+
+// serveDHCP answers requests received on conn, replies to requests received on
+// port 67 are broadcast (clients have no address yet) or sent to relay
+func (s *Server) serveDHCP(conn net.PacketConn, broadcast bool) error {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		req, err := ParsePacket(buf[:n])
+		if err != nil {
+			log.Debugf("ignore DHCP packet from %s: %s", addr, err)
+			continue
+		}
+		reply := s.Reply(req)
+		if reply == nil {
+			continue
+		}
+		dst := addr
+		if broadcast {
+			if !req.GIAddr.Equal(net.IPv4zero) {
+				dst = &net.UDPAddr{IP: req.GIAddr, Port: dhcpServerPort}
+			} else {
+				dst = &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpClientPort}
+			}
+		}
+		log.Infof("send %s to %s (%s)", reply.File, req.CHAddr, dst)
+		if _, err := conn.WriteTo(reply.Marshal(), dst); err != nil {
+			log.Errorf("cannot reply to %s: %s", dst, err)
+		}
+	}
+}
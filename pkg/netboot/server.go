@@ -0,0 +1,75 @@
+package netboot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dhcpServerPort = 67
+	dhcpClientPort = 68
+	pxeServerPort  = 4011
+	tftpPort       = 69
+)
+
+// Server boots EVE installer over network: it answers PXE clients as proxy
+// DHCP (addresses are leased by DHCP server of network) and serves bootloader
+// with TFTP, iPXE script and EVE artifacts are served with HTTP by eserver
+type Server struct {
+	// IP is address of server announced to clients
+	IP net.IP
+	// TFTPRoot is directory with files served by TFTP
+	TFTPRoot string
+	// Bootloader is file inside TFTPRoot with iPXE to load by PXE clients
+	Bootloader string
+	// ScriptURL is URL of iPXE script to boot EVE installer
+	ScriptURL string
+	// Timeout is time to wait for acknowledge of TFTP client
+	Timeout time.Duration
+}
+
+// Run serves DHCP and TFTP requests until ctx is done
+func (s *Server) Run(ctx context.Context) error {
+	if s.IP.To4() == nil {
+		return fmt.Errorf("IPv4 address of server expected, got %s", s.IP)
+	}
+	var conns []net.PacketConn
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	errs := make(chan error, 3)
+	for _, srv := range []struct {
+		port  int
+		serve func(conn net.PacketConn) error
+	}{
+		{dhcpServerPort, func(conn net.PacketConn) error { return s.serveDHCP(conn, true) }},
+		{pxeServerPort, func(conn net.PacketConn) error { return s.serveDHCP(conn, false) }},
+		{tftpPort, s.ServeTFTP},
+	} {
+		conn, err := net.ListenPacket("udp4", fmt.Sprintf(":%d", srv.port))
+		if err != nil {
+			return fmt.Errorf("cannot listen on port %d (root privileges are required): %w", srv.port, err)
+		}
+		conns = append(conns, conn)
+		go func(serve func(conn net.PacketConn) error) {
+			errs <- serve(conn)
+		}(srv.serve)
+	}
+	log.Infof("netboot server is running on %s: bootloader %s, iPXE script %s", s.IP, s.Bootloader, s.ScriptURL)
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		return err
+	}
+}
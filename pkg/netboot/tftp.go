@@ -0,0 +1,187 @@
+package netboot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TFTP opcodes
+const (
+	tftpRRQ   = 1
+	tftpWRQ   = 2
+	tftpDATA  = 3
+	tftpACK   = 4
+	tftpERROR = 5
+	tftpOACK  = 6
+)
+
+// TFTP error codes
+const (
+	tftpErrNotDefined     = 0
+	tftpErrNotFound       = 1
+	tftpErrAccess         = 2
+	tftpErrIllegalOp      = 4
+	tftpErrOptionRejected = 8
+)
+
+const (
+	tftpDefaultBlockSize = 512
+	tftpMaxBlockSize     = 65464
+	tftpRetries          = 5
+	tftpDefaultTimeout   = 2 * time.Second
+)
+
+// ServeTFTP answers read requests received on conn with files from TFTPRoot,
+// every transfer is done from separate port (TID) as required by RFC 1350,
+// options blksize and tsize are supported
+func (s *Server) ServeTFTP(conn net.PacketConn) error {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		if n < 2 {
+			continue
+		}
+		switch binary.BigEndian.Uint16(buf[:2]) {
+		case tftpRRQ:
+			fields := strings.Split(strings.TrimSuffix(string(buf[2:n]), "\x00"), "\x00")
+			go s.transfer(addr, fields)
+		case tftpWRQ:
+			_, _ = conn.WriteTo(tftpError(tftpErrAccess, "read only server"), addr)
+		default:
+			_, _ = conn.WriteTo(tftpError(tftpErrIllegalOp, "unexpected request"), addr)
+		}
+	}
+}
+
+func tftpError(code uint16, message string) []byte {
+	data := binary.BigEndian.AppendUint16(nil, tftpERROR)
+	data = binary.BigEndian.AppendUint16(data, code)
+	return append(append(data, message...), 0)
+}
+
+// openFile opens file requested by client preventing access outside of TFTPRoot
+func (s *Server) openFile(name string) (*os.File, error) {
+	name = filepath.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return os.Open(filepath.Join(s.TFTPRoot, name))
+}
+
+// transfer sends file requested with RRQ fields (filename, mode and options) to addr
+func (s *Server) transfer(addr net.Addr, fields []string) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		log.Errorf("cannot open TFTP transfer to %s: %s", addr, err)
+		return
+	}
+	defer conn.Close()
+	if len(fields) < 2 {
+		_, _ = conn.WriteTo(tftpError(tftpErrIllegalOp, "malformed request"), addr)
+		return
+	}
+	file, err := s.openFile(fields[0])
+	if err != nil {
+		log.Warnf("TFTP request of %s from %s: %s", fields[0], addr, err)
+		_, _ = conn.WriteTo(tftpError(tftpErrNotFound, "file not found"), addr)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		_, _ = conn.WriteTo(tftpError(tftpErrNotFound, "file not found"), addr)
+		return
+	}
+	log.Infof("TFTP send %s to %s", fields[0], addr)
+
+	blockSize := tftpDefaultBlockSize
+	var oack []byte
+	for i := 2; i+1 < len(fields); i += 2 {
+		switch strings.ToLower(fields[i]) {
+		case "blksize":
+			size, err := strconv.Atoi(fields[i+1])
+			if err != nil || size < 8 {
+				_, _ = conn.WriteTo(tftpError(tftpErrOptionRejected, "invalid blksize"), addr)
+				return
+			}
+			blockSize = min(size, tftpMaxBlockSize)
+			oack = append(oack, fmt.Sprintf("blksize\x00%d\x00", blockSize)...)
+		case "tsize":
+			oack = append(oack, fmt.Sprintf("tsize\x00%d\x00", info.Size())...)
+		}
+	}
+	if len(oack) > 0 {
+		if err := s.send(conn, addr, append(binary.BigEndian.AppendUint16(nil, tftpOACK), oack...), 0); err != nil {
+			log.Warnf("TFTP transfer of %s to %s: %s", fields[0], addr, err)
+			return
+		}
+	}
+	data := make([]byte, blockSize)
+	for block := uint16(1); ; block++ {
+		n, err := io.ReadFull(file, data)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			_, _ = conn.WriteTo(tftpError(tftpErrNotDefined, "read error"), addr)
+			log.Errorf("TFTP read of %s: %s", fields[0], err)
+			return
+		}
+		packet := binary.BigEndian.AppendUint16(nil, tftpDATA)
+		packet = binary.BigEndian.AppendUint16(packet, block)
+		if err := s.send(conn, addr, append(packet, data[:n]...), block); err != nil {
+			log.Warnf("TFTP transfer of %s to %s: %s", fields[0], addr, err)
+			return
+		}
+		// the last block is shorter than block size
+		if n < blockSize {
+			return
+		}
+	}
+}
+
+// send sends packet to addr and waits for acknowledge of block retransmitting packet on timeout
+func (s *Server) send(conn net.PacketConn, addr net.Addr, packet []byte, block uint16) error {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = tftpDefaultTimeout
+	}
+	buf := make([]byte, 1500)
+	for i := 0; i < tftpRetries; i++ {
+		if _, err := conn.WriteTo(packet, addr); err != nil {
+			return err
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					break
+				}
+				return err
+			}
+			if from.String() != addr.String() || n < 4 {
+				continue
+			}
+			switch binary.BigEndian.Uint16(buf[:2]) {
+			case tftpACK:
+				if binary.BigEndian.Uint16(buf[2:4]) == block {
+					return nil
+				}
+			case tftpERROR:
+				return fmt.Errorf("client error: %s", bytes.TrimRight(buf[4:n], "\x00"))
+			}
+		}
+	}
+	return fmt.Errorf("no acknowledge of block %d", block)
+}
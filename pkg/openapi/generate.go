@@ -0,0 +1,282 @@
+// Package openapi generates Go clients from OpenAPI specifications of HTTP APIs
+// used by eden. Only the subset of OpenAPI 3 needed for such APIs is supported:
+// operations with path parameters, request bodies and responses of single media
+// type, bodies are passed as bytes and their schemas are only documentation.
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is OpenAPI specification
+type Spec struct {
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title   string `yaml:"title"`
+		Version string `yaml:"version"`
+	} `yaml:"info"`
+	Paths map[string]map[string]Operation `yaml:"paths"`
+}
+
+// Operation is API operation on path with method
+type Operation struct {
+	OperationID string              `yaml:"operationId"`
+	Summary     string              `yaml:"summary"`
+	Parameters  []Parameter         `yaml:"parameters"`
+	RequestBody *Body               `yaml:"requestBody"`
+	Responses   map[string]Response `yaml:"responses"`
+}
+
+// Parameter is parameter of operation
+type Parameter struct {
+	Name     string `yaml:"name"`
+	In       string `yaml:"in"`
+	Required bool   `yaml:"required"`
+}
+
+// Body is request body of operation
+type Body struct {
+	Required bool                 `yaml:"required"`
+	Content  map[string]yaml.Node `yaml:"content"`
+}
+
+// Response is response of operation with status code
+type Response struct {
+	Description string               `yaml:"description"`
+	Content     map[string]yaml.Node `yaml:"content"`
+}
+
+// methods are supported HTTP methods in order of generated operations of path
+var methods = []string{"get", "put", "post", "delete", "patch"}
+
+// ParseSpec parses OpenAPI specification in YAML or JSON
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("cannot parse specification: %w", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", spec.OpenAPI)
+	}
+	return &spec, nil
+}
+
+// goName converts name of operation or parameter into Go identifier,
+// exported one if export is set
+func goName(name string, export bool) string {
+	var b strings.Builder
+	upper := export
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0 || export
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+		} else if b.Len() == 0 {
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+		upper = false
+	}
+	return b.String()
+}
+
+// singleContent returns the only media type of content, empty if there is no content
+func singleContent(content map[string]yaml.Node) (string, error) {
+	var types []string
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	switch len(types) {
+	case 0:
+		return "", nil
+	case 1:
+		return types[0], nil
+	default:
+		sort.Strings(types)
+		return "", fmt.Errorf("multiple media types %s are not supported", strings.Join(types, ", "))
+	}
+}
+
+// successContent returns media type of successful response with the lowest status code
+func successContent(responses map[string]Response) (string, error) {
+	var codes []int
+	for code := range responses {
+		c, err := strconv.Atoi(code)
+		if err != nil {
+			return "", fmt.Errorf("unsupported response code %q", code)
+		}
+		if c >= 200 && c < 300 {
+			codes = append(codes, c)
+		}
+	}
+	if len(codes) == 0 {
+		return "", fmt.Errorf("no successful response")
+	}
+	sort.Ints(codes)
+	return singleContent(responses[strconv.Itoa(codes[0])].Content)
+}
+
+// pathExpr returns Go expression building path with escaped path parameters
+func pathExpr(path string, params map[string]string) (string, error) {
+	var parts []string
+	for path != "" {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			parts = append(parts, strconv.Quote(path))
+			break
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated parameter in path")
+		}
+		end += start
+		if start > 0 {
+			parts = append(parts, strconv.Quote(path[:start]))
+		}
+		name := path[start+1 : end]
+		param, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("path parameter %q is not declared", name)
+		}
+		parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", param))
+		path = path[end+1:]
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+// generateOperation writes methods of client for operation on path with method into buf
+func generateOperation(buf *bytes.Buffer, path, method string, op Operation) error {
+	if op.OperationID == "" {
+		return fmt.Errorf("operationId is missing")
+	}
+	name := goName(op.OperationID, true)
+	params := make(map[string]string)
+	var args, names []string
+	for _, param := range op.Parameters {
+		if param.In != "path" {
+			return fmt.Errorf("parameter %q in %s is not supported", param.Name, param.In)
+		}
+		params[param.Name] = goName(param.Name, false)
+		args = append(args, params[param.Name]+" string")
+		names = append(names, params[param.Name])
+	}
+	pathCode, err := pathExpr(path, params)
+	if err != nil {
+		return err
+	}
+	accept, err := successContent(op.Responses)
+	if err != nil {
+		return err
+	}
+	var contentType string
+	body := "nil"
+	if op.RequestBody != nil {
+		if contentType, err = singleContent(op.RequestBody.Content); err != nil {
+			return err
+		}
+		body = "body"
+	}
+	summary := strings.TrimSuffix(op.Summary, ".")
+	if summary == "" {
+		summary = fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+	}
+
+	fmt.Fprintf(buf, "\n// %sURL returns URL of %s operation.\n", name, op.OperationID)
+	fmt.Fprintf(buf, "func (c *Client) %sURL(%s) (string, error) {\n", name, strings.Join(args, ", "))
+	fmt.Fprintf(buf, "\treturn c.resolve(%s)\n}\n", pathCode)
+
+	callArgs := append([]string{"ctx context.Context"}, args...)
+	if body != "nil" {
+		callArgs = append(callArgs, "body []byte")
+	}
+	fmt.Fprintf(buf, "\n// %s calls %s operation: %s.\n", name, op.OperationID, summary)
+	fmt.Fprintf(buf, "func (c *Client) %s(%s) ([]byte, error) {\n", name, strings.Join(callArgs, ", "))
+	fmt.Fprintf(buf, "\tu, err := c.%sURL(%s)\n", name, strings.Join(names, ", "))
+	fmt.Fprintf(buf, "\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(buf, "\treturn c.do(ctx, %q, %s, u, %q, %q, %s)\n}\n", op.OperationID,
+		httpMethodConst(method), accept, contentType, body)
+	return nil
+}
+
+func httpMethodConst(method string) string {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return "http.MethodGet"
+	case http.MethodPut:
+		return "http.MethodPut"
+	case http.MethodPost:
+		return "http.MethodPost"
+	case http.MethodDelete:
+		return "http.MethodDelete"
+	default:
+		return "http.MethodPatch"
+	}
+}
+
+// Generate returns source of Go package pkg with methods of Client for all operations
+// of specification, Client with resolve and do methods must be defined in pkg
+func Generate(specData []byte, pkg, source string) ([]byte, error) {
+	spec, err := ParseSpec(specData)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by openapi-gen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import (\n\t\"context\"\n\t\"net/http\"\n\t\"net/url\"\n)\n\n")
+	fmt.Fprintf(&buf, "// APIVersion is version of %s specification the client is generated from.\n", spec.Info.Title)
+	fmt.Fprintf(&buf, "const APIVersion = %q\n", spec.Info.Version)
+
+	var paths []string
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		item := spec.Paths[path]
+		for method := range item {
+			found := false
+			for _, m := range methods {
+				found = found || m == method
+			}
+			if !found {
+				return nil, fmt.Errorf("%s: unsupported method %q", path, method)
+			}
+		}
+		for _, method := range methods {
+			op, ok := item[method]
+			if !ok {
+				continue
+			}
+			if seen[op.OperationID] {
+				return nil, fmt.Errorf("%s %s: duplicate operationId %q", method, path, op.OperationID)
+			}
+			seen[op.OperationID] = true
+			if err := generateOperation(&buf, path, method, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+	// url package is used only if there are path parameters
+	src := buf.Bytes()
+	if !bytes.Contains(src, []byte("url.PathEscape")) {
+		src = bytes.Replace(src, []byte("\t\"net/url\"\n"), nil, 1)
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot format generated source: %w", err)
+	}
+	return formatted, nil
+}
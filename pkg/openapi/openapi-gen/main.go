@@ -0,0 +1,30 @@
+// openapi-gen generates Go client from OpenAPI specification, it is run with go generate.
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/openapi"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	specFile := flag.String("spec", "openapi.yaml", "OpenAPI specification file")
+	pkg := flag.String("package", "api", "name of Go package of client")
+	out := flag.String("out", "client.gen.go", "file to write generated client into")
+	flag.Parse()
+
+	spec, err := os.ReadFile(*specFile)
+	if err != nil {
+		log.Fatalf("failed to read specification %s: %v", *specFile, err)
+	}
+	src, err := openapi.Generate(spec, *pkg, filepath.Base(*specFile))
+	if err != nil {
+		log.Fatalf("failed to generate client from %s: %v", *specFile, err)
+	}
+	if err = os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalf("failed to write client into %s: %v", *out, err)
+	}
+}
@@ -0,0 +1,615 @@
+package openevec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// kinds of resources supported by 'eden apply' in order of their creation
+const (
+	ApplyKindNetwork     = "Network"
+	ApplyKindVolume      = "Volume"
+	ApplyKindApp         = "App"
+	ApplyKindDevice      = "Device"
+	ApplyKindConfigItems = "ConfigItems"
+	// ApplyKindConfigItem is kind of actions on single config item
+	ApplyKindConfigItem = "ConfigItem"
+)
+
+var applyKindOrder = map[string]int{
+	ApplyKindNetwork:    0,
+	ApplyKindVolume:     1,
+	ApplyKindApp:        2,
+	ApplyKindDevice:     3,
+	ApplyKindConfigItem: 4,
+}
+
+// ApplyNetworkSpec is desired state of network instance
+type ApplyNetworkSpec struct {
+	// Type is local (default) or switch
+	Type      string   `yaml:"type" json:"type,omitempty"`
+	Subnet    string   `yaml:"subnet" json:"subnet,omitempty"`
+	Uplink    string   `yaml:"uplink" json:"uplink,omitempty"`
+	Gateway   string   `yaml:"gateway" json:"gateway,omitempty"`
+	DNS       []string `yaml:"dns" json:"dns,omitempty"`
+	DHCPRange string   `yaml:"dhcp-range" json:"dhcpRange,omitempty"`
+	Flowlog   bool     `yaml:"flowlog" json:"flowlog,omitempty"`
+}
+
+// ApplyVolumeSpec is desired state of volume
+type ApplyVolumeSpec struct {
+	// Image is link to image of volume or 'blank'
+	Image             string `yaml:"image" json:"image"`
+	Registry          string `yaml:"registry" json:"registry,omitempty"`
+	Size              string `yaml:"size" json:"size,omitempty"`
+	Type              string `yaml:"type" json:"type,omitempty"`
+	DatastoreOverride string `yaml:"datastore-override" json:"datastoreOverride,omitempty"`
+}
+
+// ApplyAppSpec is desired state of app
+type ApplyAppSpec struct {
+	Image      string   `yaml:"image" json:"image"`
+	Registry   string   `yaml:"registry" json:"registry,omitempty"`
+	Memory     string   `yaml:"memory" json:"memory,omitempty"`
+	Cpus       uint32   `yaml:"cpus" json:"cpus,omitempty"`
+	Networks   []string `yaml:"networks" json:"networks,omitempty"`
+	Publish    []string `yaml:"publish" json:"publish,omitempty"`
+	ACL        []string `yaml:"acl" json:"acl,omitempty"`
+	Mount      []string `yaml:"mount" json:"mount,omitempty"`
+	VolumeSize string   `yaml:"volume-size" json:"volumeSize,omitempty"`
+	VolumeType string   `yaml:"volume-type" json:"volumeType,omitempty"`
+	Metadata   string   `yaml:"metadata" json:"metadata,omitempty"`
+}
+
+// ApplyResource is resource of environment declared in file for 'eden apply'
+type ApplyResource struct {
+	Kind string
+	Name string
+	// Spec is *ApplyNetworkSpec, *ApplyVolumeSpec, *ApplyAppSpec or map[string]string
+	// with device items for Device and config items for ConfigItems
+	Spec interface{}
+	File string
+}
+
+// key returns identifier of resource in state of applied resources
+func (r *ApplyResource) key() string {
+	return r.Kind + "/" + r.Name
+}
+
+type applyDocument struct {
+	Kind string      `yaml:"kind"`
+	Name string      `yaml:"name"`
+	Spec interface{} `yaml:"spec"`
+}
+
+func decodeApplySpec(doc *applyDocument, fileName string) (*ApplyResource, error) {
+	r := &ApplyResource{Kind: doc.Kind, Name: doc.Name, File: fileName}
+	var spec interface{}
+	switch doc.Kind {
+	case ApplyKindNetwork:
+		spec = &ApplyNetworkSpec{}
+	case ApplyKindVolume:
+		spec = &ApplyVolumeSpec{}
+	case ApplyKindApp:
+		spec = &ApplyAppSpec{}
+	case ApplyKindDevice, ApplyKindConfigItems:
+		// there is one device in context, so name is not required
+		r.Name = strings.ToLower(doc.Kind)
+		spec = &map[string]string{}
+	default:
+		return nil, fmt.Errorf("%s: unsupported kind %q", fileName, doc.Kind)
+	}
+	if r.Name == "" {
+		return nil, fmt.Errorf("%s: name of %s is required", fileName, doc.Kind)
+	}
+	data, err := yaml.Marshal(doc.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, spec); err != nil {
+		return nil, fmt.Errorf("%s: cannot parse spec of %s: %w", fileName, r.key(), err)
+	}
+	switch s := spec.(type) {
+	case *ApplyNetworkSpec:
+		if s.Type == "" {
+			s.Type = "local"
+		}
+		if s.Type == "local" && s.Subnet == "" {
+			return nil, fmt.Errorf("%s: subnet of local network %s is required", fileName, r.Name)
+		}
+		r.Spec = s
+	case *ApplyVolumeSpec:
+		if s.Image == "" {
+			return nil, fmt.Errorf("%s: image of volume %s is required", fileName, r.Name)
+		}
+		r.Spec = s
+	case *ApplyAppSpec:
+		if s.Image == "" {
+			return nil, fmt.Errorf("%s: image of app %s is required", fileName, r.Name)
+		}
+		r.Spec = s
+	case *map[string]string:
+		r.Spec = *s
+	}
+	return r, nil
+}
+
+// applyFiles returns yaml files of directory path or path itself if it is a file
+func applyFiles(path string) ([]string, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fileInfo.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(path, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// LoadApplyResources reads resources from yaml files (*.yml and *.yaml, several
+// documents separated by '---' are allowed in one file) of dir or from single file
+func LoadApplyResources(path string) ([]*ApplyResource, error) {
+	files, err := applyFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	var resources []*ApplyResource
+	seen := make(map[string]string)
+	for _, fileName := range files {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var doc applyDocument
+			if err := decoder.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("cannot parse %s: %w", fileName, err)
+			}
+			if doc.Kind == "" && doc.Name == "" && doc.Spec == nil {
+				continue
+			}
+			r, err := decodeApplySpec(&doc, fileName)
+			if err != nil {
+				return nil, err
+			}
+			if other, ok := seen[r.key()]; ok {
+				return nil, fmt.Errorf("%s is defined in both %s and %s", r.key(), other, fileName)
+			}
+			seen[r.key()] = fileName
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+// ApplyState is state of resources applied by 'eden apply', it defines resources
+// managed declaratively and specs used for them
+type ApplyState struct {
+	Resources   map[string]json.RawMessage `json:"resources"`
+	ConfigItems []string                   `json:"configItems,omitempty"`
+}
+
+// ApplyAction is action on resource planned by 'eden apply'
+type ApplyAction struct {
+	// Op is one of create, update, replace, delete and adopt
+	Op   string
+	Kind string
+	Name string
+}
+
+func (a ApplyAction) String() string {
+	return fmt.Sprintf("%s %s %s", a.Op, a.Kind, a.Name)
+}
+
+// networkNeedsReplace checks if network instance must be recreated to change
+// its spec from old to spec, other fields are changed in place
+func networkNeedsReplace(old json.RawMessage, spec *ApplyNetworkSpec) bool {
+	var oldSpec ApplyNetworkSpec
+	if err := json.Unmarshal(old, &oldSpec); err != nil {
+		return true
+	}
+	return oldSpec.Type != spec.Type || oldSpec.Subnet != spec.Subnet
+}
+
+// PlanApply returns actions to reconcile existing resources (with keys Kind/Name) and
+// config items with resources declared, resources applied before and not declared
+// anymore are deleted if prune is set
+func PlanApply(resources []*ApplyResource, existing map[string]bool, configItems map[string]string,
+	state *ApplyState, prune bool) ([]ApplyAction, error) {
+	var actions []ApplyAction
+	declared := make(map[string]bool)
+	declaredItems := make(map[string]bool)
+	for _, r := range resources {
+		declared[r.key()] = true
+		if r.Kind == ApplyKindConfigItems {
+			for key, val := range r.Spec.(map[string]string) {
+				declaredItems[key] = true
+				if current, ok := configItems[key]; !ok {
+					actions = append(actions, ApplyAction{Op: "create", Kind: ApplyKindConfigItem, Name: key})
+				} else if current != val {
+					actions = append(actions, ApplyAction{Op: "update", Kind: ApplyKindConfigItem, Name: key})
+				}
+			}
+			continue
+		}
+		spec, err := json.Marshal(r.Spec)
+		if err != nil {
+			return nil, err
+		}
+		old, applied := state.Resources[r.key()]
+		switch {
+		case r.Kind == ApplyKindDevice:
+			if !applied || !bytes.Equal(old, spec) {
+				actions = append(actions, ApplyAction{Op: "update", Kind: r.Kind, Name: r.Name})
+			}
+		case !existing[r.key()]:
+			actions = append(actions, ApplyAction{Op: "create", Kind: r.Kind, Name: r.Name})
+		case !applied:
+			actions = append(actions, ApplyAction{Op: "adopt", Kind: r.Kind, Name: r.Name})
+		case bytes.Equal(old, spec):
+		case r.Kind == ApplyKindNetwork && !networkNeedsReplace(old, r.Spec.(*ApplyNetworkSpec)):
+			actions = append(actions, ApplyAction{Op: "update", Kind: r.Kind, Name: r.Name})
+		default:
+			actions = append(actions, ApplyAction{Op: "replace", Kind: r.Kind, Name: r.Name})
+		}
+	}
+	if prune {
+		for key := range state.Resources {
+			kind, name, _ := strings.Cut(key, "/")
+			if !declared[key] && existing[key] {
+				actions = append(actions, ApplyAction{Op: "delete", Kind: kind, Name: name})
+			}
+		}
+		for _, key := range state.ConfigItems {
+			if _, ok := configItems[key]; ok && !declaredItems[key] {
+				actions = append(actions, ApplyAction{Op: "delete", Kind: ApplyKindConfigItem, Name: key})
+			}
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool {
+		if actions[i].Kind != actions[j].Kind {
+			return applyKindOrder[actions[i].Kind] < applyKindOrder[actions[j].Kind]
+		}
+		return actions[i].Name < actions[j].Name
+	})
+	return actions, nil
+}
+
+// applyStateFile returns file with state of resources applied to the current context
+func (openEVEC *OpenEVEC) applyStateFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultApplyStateDir, fmt.Sprintf("%s.json", configName)), nil
+}
+
+func (openEVEC *OpenEVEC) loadApplyState() (*ApplyState, error) {
+	state := &ApplyState{Resources: map[string]json.RawMessage{}}
+	stateFile, err := openEVEC.applyStateFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", stateFile, err)
+	}
+	if state.Resources == nil {
+		state.Resources = map[string]json.RawMessage{}
+	}
+	return state, nil
+}
+
+func (openEVEC *OpenEVEC) saveApplyState(state *ApplyState) error {
+	stateFile, err := openEVEC.applyStateFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(stateFile, data, 0644)
+}
+
+// existingApplyResources returns keys of apps, volumes and network instances of device
+// and its config items
+func (openEVEC *OpenEVEC) existingApplyResources() (map[string]bool, map[string]string, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	existing := make(map[string]bool)
+	for _, el := range dev.GetNetworkInstances() {
+		ni, err := ctrl.GetNetworkInstanceConfig(el)
+		if err != nil {
+			return nil, nil, fmt.Errorf("no network in cloud %s: %w", el, err)
+		}
+		existing[ApplyKindNetwork+"/"+ni.Displayname] = true
+	}
+	for _, el := range dev.GetVolumes() {
+		vol, err := ctrl.GetVolume(el)
+		if err != nil {
+			return nil, nil, fmt.Errorf("no volume in cloud %s: %w", el, err)
+		}
+		existing[ApplyKindVolume+"/"+vol.DisplayName] = true
+	}
+	for _, el := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			return nil, nil, fmt.Errorf("no app in cloud %s: %w", el, err)
+		}
+		existing[ApplyKindApp+"/"+app.Displayname] = true
+	}
+	configItems := make(map[string]string)
+	for key, val := range dev.GetConfigItems() {
+		configItems[key] = val
+	}
+	return existing, configItems, nil
+}
+
+func (openEVEC *OpenEVEC) applyCreate(r *ApplyResource) error {
+	switch spec := r.Spec.(type) {
+	case *ApplyNetworkSpec:
+		return openEVEC.NetworkCreate(spec.Subnet, spec.Type, r.Name, spec.Uplink, nil, spec.Flowlog,
+			spec.Gateway, spec.DNS, spec.DHCPRange)
+	case *ApplyVolumeSpec:
+		registry, size, volumeType := spec.Registry, spec.Size, spec.Type
+		if registry == "" {
+			registry = "remote"
+		}
+		if size == "" {
+			size = humanize.Bytes(0)
+		}
+		if volumeType == "" {
+			volumeType = "qcow2"
+		}
+		return openEVEC.VolumeCreate(spec.Image, registry, size, r.Name, volumeType, spec.DatastoreOverride, false, true)
+	case *ApplyAppSpec:
+		pc := PodConfig{
+			Name:        r.Name,
+			Metadata:    spec.Metadata,
+			Registry:    spec.Registry,
+			Networks:    spec.Networks,
+			PortPublish: spec.Publish,
+			ACL:         spec.ACL,
+			Mount:       spec.Mount,
+			AppMemory:   spec.Memory,
+			AppCpus:     spec.Cpus,
+			VncDisplay:  -1,
+			DiskSize:    humanize.Bytes(0),
+			VolumeSize:  spec.VolumeSize,
+			VolumeType:  spec.VolumeType,
+			DirectLoad:  true,
+		}
+		if pc.Registry == "" {
+			pc.Registry = "remote"
+		}
+		if pc.AppMemory == "" {
+			pc.AppMemory = humanize.Bytes(defaults.DefaultAppMem * 1024)
+		}
+		if pc.AppCpus == 0 {
+			pc.AppCpus = defaults.DefaultAppCPU
+		}
+		if pc.VolumeSize == "" {
+			pc.VolumeSize = humanize.IBytes(defaults.DefaultVolumeSize)
+		}
+		if pc.VolumeType == "" {
+			pc.VolumeType = "qcow2"
+		}
+		return openEVEC.PodDeploy(spec.Image, pc, openEVEC.cfg)
+	}
+	return fmt.Errorf("cannot create %s", r.key())
+}
+
+func (openEVEC *OpenEVEC) applyDelete(kind, name string) error {
+	switch kind {
+	case ApplyKindNetwork:
+		return openEVEC.NetworkDelete(name, false)
+	case ApplyKindVolume:
+		return openEVEC.VolumeDelete(name)
+	case ApplyKindApp:
+		_, err := openEVEC.PodDelete(name, true)
+		return err
+	}
+	return fmt.Errorf("cannot delete %s/%s", kind, name)
+}
+
+func (openEVEC *OpenEVEC) applyUpdate(r *ApplyResource) error {
+	switch spec := r.Spec.(type) {
+	case *ApplyNetworkSpec:
+		// fields removed from spec keep their current values
+		return openEVEC.NetworkModify(r.Name, NetworkModifyArgs{
+			Uplink:     spec.Uplink,
+			Gateway:    spec.Gateway,
+			DNSServers: spec.DNS,
+			DHCPRange:  spec.DHCPRange,
+			Flowlog:    &spec.Flowlog,
+		})
+	case map[string]string:
+		return openEVEC.EdgeNodeUpdate("", spec, nil)
+	}
+	return fmt.Errorf("cannot update %s", r.key())
+}
+
+// Apply reconciles controller state with resources declared in yaml files of source
+// (directory, file or git repository), resources applied before and not declared
+// anymore are deleted if prune is set, with dryRun only planned actions are printed
+func (openEVEC *OpenEVEC) Apply(source string, prune, dryRun bool) error {
+	path, _, err := openEVEC.syncApplySource(source)
+	if err != nil {
+		return err
+	}
+	_, err = openEVEC.applyPath(path, prune, dryRun)
+	return err
+}
+
+// applyPath reconciles controller state with resources declared in yaml files of path
+// and returns actions planned for it
+func (openEVEC *OpenEVEC) applyPath(path string, prune, dryRun bool) ([]ApplyAction, error) {
+	resources, err := LoadApplyResources(path)
+	if err != nil {
+		return nil, err
+	}
+	state, err := openEVEC.loadApplyState()
+	if err != nil {
+		return nil, err
+	}
+	existing, configItems, err := openEVEC.existingApplyResources()
+	if err != nil {
+		return nil, err
+	}
+	actions, err := PlanApply(resources, existing, configItems, state, prune)
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 0 {
+		fmt.Println("environment is up to date")
+	}
+	for _, action := range actions {
+		if dryRun {
+			utils.DryRunf("%s", action)
+		} else {
+			fmt.Println(action)
+		}
+	}
+	if dryRun {
+		return actions, nil
+	}
+	byKey := make(map[string]*ApplyResource)
+	desiredItems := make(map[string]string)
+	for _, r := range resources {
+		byKey[r.key()] = r
+		if r.Kind == ApplyKindConfigItems {
+			for key, val := range r.Spec.(map[string]string) {
+				desiredItems[key] = val
+			}
+		}
+	}
+	recordApplied := func(r *ApplyResource) error {
+		spec, err := json.Marshal(r.Spec)
+		if err != nil {
+			return err
+		}
+		state.Resources[r.key()] = spec
+		return nil
+	}
+	// resources are deleted in reverse order of creation: apps, volumes, networks
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := actions[i]
+		if action.Kind == ApplyKindConfigItem || (action.Op != "delete" && action.Op != "replace") {
+			continue
+		}
+		if err := openEVEC.applyDelete(action.Kind, action.Name); err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot %s: %w", action, err), openEVEC.saveApplyState(state))
+		}
+		delete(state.Resources, action.Kind+"/"+action.Name)
+	}
+	var setItems map[string]string
+	var unsetItems []string
+	for _, action := range actions {
+		r := byKey[action.Kind+"/"+action.Name]
+		var err error
+		switch {
+		case action.Kind == ApplyKindConfigItem && action.Op == "delete":
+			unsetItems = append(unsetItems, action.Name)
+			continue
+		case action.Kind == ApplyKindConfigItem:
+			if setItems == nil {
+				setItems = make(map[string]string)
+			}
+			setItems[action.Name] = desiredItems[action.Name]
+			continue
+		case action.Op == "create" || action.Op == "replace":
+			err = openEVEC.applyCreate(r)
+		case action.Op == "update":
+			err = openEVEC.applyUpdate(r)
+		default:
+			// adopted resources are kept as is
+		}
+		if err == nil && r != nil {
+			err = recordApplied(r)
+		}
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot %s: %w", action, err), openEVEC.saveApplyState(state))
+		}
+	}
+	if len(setItems) > 0 {
+		if err := openEVEC.ConfigItemsSet("", setItems); err != nil {
+			return nil, errors.Join(err, openEVEC.saveApplyState(state))
+		}
+	}
+	if len(unsetItems) > 0 {
+		if err := openEVEC.ConfigItemsUnset("", unsetItems); err != nil {
+			return nil, errors.Join(err, openEVEC.saveApplyState(state))
+		}
+	}
+	managedItems := make(map[string]bool)
+	for key := range desiredItems {
+		managedItems[key] = true
+	}
+	if !prune {
+		// items applied before are still managed until they are pruned
+		for _, key := range state.ConfigItems {
+			if _, ok := configItems[key]; ok {
+				managedItems[key] = true
+			}
+		}
+	}
+	state.ConfigItems = nil
+	for key := range managedItems {
+		state.ConfigItems = append(state.ConfigItems, key)
+	}
+	sort.Strings(state.ConfigItems)
+	for key := range state.Resources {
+		// resources not declared anymore are still managed until they are pruned
+		if _, ok := byKey[key]; !ok && (prune || !existing[key]) {
+			delete(state.Resources, key)
+		}
+	}
+	if err := openEVEC.saveApplyState(state); err != nil {
+		return nil, err
+	}
+	log.Infof("applied %d actions", len(actions))
+	return actions, nil
+}
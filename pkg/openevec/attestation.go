@@ -0,0 +1,292 @@
+package openevec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// AttestationStatus is attestation state of controller and device
+type AttestationStatus struct {
+	// Enforce is set if controller rejects devices with PCRs not matching policy, otherwise mismatch is only logged
+	Enforce bool `json:"enforce"`
+	// Templates is number of PCR templates in policy of controller
+	Templates int `json:"templates"`
+	// PolicyMatch is set if PCRs received from device match one of templates of policy
+	PolicyMatch bool `json:"policyMatch"`
+	// Attested is result of the last attestation of device in controller
+	Attested     bool   `json:"attested"`
+	EveVersion   string `json:"eveVersion,omitempty"`
+	Firmware     string `json:"firmwareVersion,omitempty"`
+	DeviceState  string `json:"deviceState,omitempty"`
+	DeviceError  string `json:"deviceError,omitempty"`
+	VaultStatus  string `json:"vaultStatus,omitempty"`
+	VaultError   string `json:"vaultError,omitempty"`
+	ReceivedPCRs int    `json:"receivedPCRs"`
+}
+
+// ParsePCRValues parses PCR values in format <index>=<value>, value may be '*' to match any
+func ParsePCRValues(values []string) ([]*types.PCRValue, error) {
+	var result []*types.PCRValue
+	for _, el := range values {
+		index, value, found := strings.Cut(el, "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("invalid PCR value %q, expected <index>=<value>", el)
+		}
+		i, err := strconv.ParseUint(index, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index of PCR value %q: %w", el, err)
+		}
+		result = append(result, &types.PCRValue{Index: uint32(i), Value: value})
+	}
+	return result, nil
+}
+
+// SetPCRValues replaces values of template with the same indexes or adds them
+func SetPCRValues(template *types.PCRTemplate, values []*types.PCRValue) {
+	for _, value := range values {
+		found := false
+		for _, pcr := range template.PCRValues {
+			if pcr.Index == value.Index {
+				pcr.Value, found = value.Value, true
+			}
+		}
+		if !found {
+			template.PCRValues = append(template.PCRValues, value)
+		}
+	}
+}
+
+func (openEVEC *OpenEVEC) attestationControllerAndDev() (controller.Cloud, *device.Ctx, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	return ctrl, dev, nil
+}
+
+// AttestationSetMode switches controller between enforcing policy of attestation and only logging mismatches
+func (openEVEC *OpenEVEC) AttestationSetMode(enforce bool) error {
+	ctrl, _, err := openEVEC.attestationControllerAndDev()
+	if err != nil {
+		return err
+	}
+	options, err := ctrl.GetGlobalOptions()
+	if err != nil {
+		return fmt.Errorf("GetGlobalOptions error: %w", err)
+	}
+	if enforce && len(options.PCRTemplates) == 0 {
+		log.Warn("Policy of attestation has no PCR templates, attestation of every device will fail")
+	}
+	options.EnforceTemplateAttestation = enforce
+	if err := ctrl.SetGlobalOptions(options); err != nil {
+		return fmt.Errorf("cannot set global options: %w", err)
+	}
+	if enforce {
+		log.Info("Attestation mode: enforce")
+	} else {
+		log.Info("Attestation mode: log")
+	}
+	return nil
+}
+
+// AttestationPolicyAdd adds PCR template into policy of controller replacing template for the same
+// EVE and firmware versions, template is read from file or taken from the last one received from device
+// if fileName is empty, pcrValues override values of template
+func (openEVEC *OpenEVEC) AttestationPolicyAdd(fileName string, pcrValues []string) error {
+	values, err := ParsePCRValues(pcrValues)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := openEVEC.attestationControllerAndDev()
+	if err != nil {
+		return err
+	}
+	var template *types.PCRTemplate
+	if fileName != "" {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("file reading error: %w", err)
+		}
+		if err := json.Unmarshal(data, &template); err != nil {
+			return fmt.Errorf("cannot unmarshal: %w", err)
+		}
+		if template == nil {
+			return fmt.Errorf("no PCR template in %s", fileName)
+		}
+	} else {
+		devOptions, err := ctrl.GetDeviceOptions(dev.GetID())
+		if err != nil {
+			return fmt.Errorf("GetDeviceOptions error: %w", err)
+		}
+		if devOptions.ReceivedPCRTemplate == nil {
+			return fmt.Errorf("no PCRs received from device yet, please provide template with --file")
+		}
+		template = devOptions.ReceivedPCRTemplate
+	}
+	SetPCRValues(template, values)
+	options, err := ctrl.GetGlobalOptions()
+	if err != nil {
+		return fmt.Errorf("GetGlobalOptions error: %w", err)
+	}
+	templates := []*types.PCRTemplate{template}
+	for _, el := range options.PCRTemplates {
+		if el.EveVersion != template.EveVersion || el.FirmwareVersion != template.FirmwareVersion {
+			templates = append(templates, el)
+		}
+	}
+	options.PCRTemplates = templates
+	if err := ctrl.SetGlobalOptions(options); err != nil {
+		return fmt.Errorf("cannot set global options: %w", err)
+	}
+	log.Infof("PCR template for EVE %s (firmware %s) with %d values added into policy",
+		template.EveVersion, template.FirmwareVersion, len(template.PCRValues))
+	return nil
+}
+
+// AttestationPolicyRemove removes PCR templates for EVE and firmware versions from policy of
+// controller, all templates are removed if both versions are empty
+func (openEVEC *OpenEVEC) AttestationPolicyRemove(eveVersion, firmwareVersion string) error {
+	ctrl, _, err := openEVEC.attestationControllerAndDev()
+	if err != nil {
+		return err
+	}
+	options, err := ctrl.GetGlobalOptions()
+	if err != nil {
+		return fmt.Errorf("GetGlobalOptions error: %w", err)
+	}
+	var templates []*types.PCRTemplate
+	for _, el := range options.PCRTemplates {
+		if (eveVersion != "" && el.EveVersion != eveVersion) ||
+			(firmwareVersion != "" && el.FirmwareVersion != firmwareVersion) {
+			templates = append(templates, el)
+		}
+	}
+	removed := len(options.PCRTemplates) - len(templates)
+	options.PCRTemplates = templates
+	if err := ctrl.SetGlobalOptions(options); err != nil {
+		return fmt.Errorf("cannot set global options: %w", err)
+	}
+	log.Infof("%d PCR templates removed from policy", removed)
+	return nil
+}
+
+// attestationStatus collects attestation state from controller options and the last info of device
+func (openEVEC *OpenEVEC) attestationStatus() (*AttestationStatus, error) {
+	ctrl, dev, err := openEVEC.attestationControllerAndDev()
+	if err != nil {
+		return nil, err
+	}
+	options, err := ctrl.GetGlobalOptions()
+	if err != nil {
+		return nil, fmt.Errorf("GetGlobalOptions error: %w", err)
+	}
+	devOptions, err := ctrl.GetDeviceOptions(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("GetDeviceOptions error: %w", err)
+	}
+	status := &AttestationStatus{
+		Enforce:   options.EnforceTemplateAttestation,
+		Templates: len(options.PCRTemplates),
+		Attested:  devOptions.Attested,
+	}
+	if received := devOptions.ReceivedPCRTemplate; received != nil {
+		status.EveVersion, status.Firmware = received.EveVersion, received.FirmwareVersion
+		status.ReceivedPCRs = len(received.PCRValues)
+		for _, template := range options.PCRTemplates {
+			if template.Match(received) {
+				status.PolicyMatch = true
+				break
+			}
+		}
+	}
+	if err = ctrl.InfoLastCallback(dev.GetID(), map[string]string{"devId": dev.GetID().String()}, func(im *info.ZInfoMsg) bool {
+		if im.GetZtype() != info.ZInfoTypes_ZiDevice {
+			return false
+		}
+		if attestInfo := im.GetDinfo().GetAttestationInfo(); attestInfo != nil {
+			status.DeviceState = attestInfo.GetState().String()
+			status.DeviceError = attestInfo.GetError().GetDescription()
+		}
+		if dataSec := im.GetDinfo().GetDataSecAtRestInfo(); dataSec != nil {
+			status.VaultStatus = dataSec.GetStatus().String()
+			for _, vault := range dataSec.GetVaultList() {
+				if vault.GetVaultErr().GetDescription() != "" {
+					status.VaultError = vault.GetVaultErr().GetDescription()
+				}
+			}
+		}
+		return false
+	}); err != nil {
+		return nil, fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	return status, nil
+}
+
+// AttestationStatusShow prints attestation state of controller and device
+func (openEVEC *OpenEVEC) AttestationStatusShow(outputFormat types.OutputFormat) error {
+	status, err := openEVEC.attestationStatus()
+	if err != nil {
+		return err
+	}
+	switch outputFormat {
+	case types.OutputFormatJSON:
+		result, err := json.MarshalIndent(status, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(result))
+	case types.OutputFormatLines:
+		mode := "log"
+		if status.Enforce {
+			mode = "enforce"
+		}
+		fmt.Printf("Mode: %s\n", mode)
+		fmt.Printf("Policy templates: %d\n", status.Templates)
+		fmt.Printf("Received PCRs: %d (EVE %s, firmware %s)\n", status.ReceivedPCRs, status.EveVersion, status.Firmware)
+		fmt.Printf("Policy match: %t\n", status.PolicyMatch)
+		fmt.Printf("Attested: %t\n", status.Attested)
+		fmt.Printf("Device state: %s %s\n", status.DeviceState, status.DeviceError)
+		fmt.Printf("Vault: %s %s\n", status.VaultStatus, status.VaultError)
+	default:
+		return fmt.Errorf("unknown output format: %v", outputFormat)
+	}
+	return nil
+}
+
+// AttestationWait waits for result of attestation of device to be equal to attested
+// up to timeout (timeouts.attestation of config if zero)
+func (openEVEC *OpenEVEC) AttestationWait(ctx context.Context, attested bool, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = openEVEC.cfg.Timeouts.Attestation
+	}
+	log.Infof("Waiting for attested=%t (up to %s)", attested, timeout)
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := openEVEC.attestationStatus()
+		if err != nil {
+			log.Debug(err)
+		} else if status.Attested == attested {
+			log.Infof("Device attested=%t, state of device: %s %s", status.Attested, status.DeviceState, status.DeviceError)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("device is not attested=%t during %s", attested, timeout)
+		}
+		if err := utils.SleepContext(ctx, openEVEC.cfg.Timeouts.PollInterval); err != nil {
+			return err
+		}
+	}
+}
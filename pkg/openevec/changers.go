@@ -3,10 +3,12 @@ package openevec
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
@@ -127,6 +129,9 @@ func (ctx *adamChanger) getControllerAndDev() (controller.Cloud, *device.Ctx, er
 		return nil, nil, fmt.Errorf("getController error: %w", err)
 	}
 	devFirst, err := ctrl.GetDeviceCurrent()
+	if errors.Is(err, types.ErrDeviceNotFound) {
+		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %w: %w", ErrNotOnboarded, err)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %w", err)
 	}
@@ -144,6 +149,9 @@ func (ctx *adamChanger) getControllerAndDevFromConfig(cfg *EdenSetupArgs) (contr
 	}
 	ctrl.SetVars(vars)
 	devFirst, err := ctrl.GetDeviceCurrent()
+	if errors.Is(err, types.ErrDeviceNotFound) {
+		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %w: %w", ErrNotOnboarded, err)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %w", err)
 	}
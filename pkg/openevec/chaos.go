@@ -0,0 +1,297 @@
+package openevec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Faults injected by eden chaos
+const (
+	ChaosEveReboot      = "eve-reboot"
+	ChaosLinkFlap       = "link-flap"
+	ChaosAdamRestart    = "adam-restart"
+	ChaosRegistryOutage = "registry-outage"
+)
+
+// ChaosFaults is list of faults supported by eden chaos
+var ChaosFaults = []string{ChaosEveReboot, ChaosLinkFlap, ChaosAdamRestart, ChaosRegistryOutage}
+
+// ChaosArgs defines faults to inject and their timing
+type ChaosArgs struct {
+	Faults []string
+	// Duration of injecting of faults, zero means until interrupted or command exits
+	Duration time.Duration
+	// Interval is mean interval between faults, actual one is randomized by ±50%
+	Interval time.Duration
+	// Outage is duration of link flaps, Adam restarts and registry outages
+	Outage time.Duration
+	// Seed of random choice of faults and intervals, chosen from the current time if zero
+	Seed int64
+	// Timeline is file to save timeline of faults into
+	Timeline string
+	// Interfaces of EVE for link flaps
+	Interfaces []string
+	VMName     string
+}
+
+// ChaosEvent is fault injected by eden chaos
+type ChaosEvent struct {
+	Fault  string    `json:"fault"`
+	Target string    `json:"target,omitempty"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// ChaosTimeline is timeline of faults saved by eden chaos
+type ChaosTimeline struct {
+	Seed    int64        `json:"seed"`
+	Command []string     `json:"command,omitempty"`
+	Start   time.Time    `json:"start"`
+	End     time.Time    `json:"end,omitempty"`
+	Events  []ChaosEvent `json:"events"`
+}
+
+func (t *ChaosTimeline) save(file string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
+
+// defaultChaosTimeline returns file in artifacts of the current context to save timeline into,
+// so it is linked from reports and uploaded together with other artifacts
+func defaultChaosTimeline() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return "", fmt.Errorf("load context error: %w", err)
+	}
+	return filepath.Join(edenDir, defaults.DefaultArtifactsDir, context.Current, "chaos",
+		fmt.Sprintf("%s.json", time.Now().Format("20060102-150405"))), nil
+}
+
+// injectFault injects fault and waits for recovery from it (e.g. link is up again),
+// it returns target of fault. Outage is shortened if ctx is done, but recovery is not skipped.
+func (openEVEC *OpenEVEC) injectFault(ctx context.Context, fault string, args *ChaosArgs, r *rand.Rand) (string, error) {
+	cfg := openEVEC.cfg
+	switch fault {
+	case ChaosEveReboot:
+		switch cfg.Eve.DevModel {
+		case defaults.DefaultQemuModel:
+			return "eve", eden.ResetEVEQemu(cfg.Eve.QemuConfig.MonitorPort)
+		case defaults.DefaultPhysicalModel:
+			if err := eden.StopEVEPhysical(cfg.Eve.Physical.PowerOff); err != nil {
+				return "eve", err
+			}
+			return "eve", eden.StartEVEPhysical(cfg.Eve.Physical.PowerOn)
+		}
+		return "eve", fmt.Errorf("reboot is %w %s", ErrDevmodelUnsupported, cfg.Eve.DevModel)
+	case ChaosLinkFlap:
+		ifName := args.Interfaces[r.Intn(len(args.Interfaces))]
+		if err := openEVEC.setChaosLink(args.VMName, ifName, false); err != nil {
+			return ifName, err
+		}
+		_ = utils.SleepContext(ctx, args.Outage)
+		return ifName, openEVEC.setChaosLink(args.VMName, ifName, true)
+	case ChaosAdamRestart:
+		return cfg.Containers().Adam, restartContainer(ctx, cfg.Containers().Adam, args.Outage)
+	case ChaosRegistryOutage:
+		return cfg.Containers().Registry, restartContainer(ctx, cfg.Containers().Registry, args.Outage)
+	}
+	return "", fmt.Errorf("unknown fault %s, supported: %v", fault, ChaosFaults)
+}
+
+// setChaosLink brings link of EVE interface up or down: port of network model
+// connected to it is changed if SDN is used, otherwise link of VM is changed
+func (openEVEC *OpenEVEC) setChaosLink(vmName, ifName string, up bool) error {
+	cfg := openEVEC.cfg
+	if !cfg.IsSdnEnabled() {
+		return openEVEC.setLinkStateEve(vmName, []string{ifName}, up)
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	netModel, err := client.GetNetworkModel()
+	if err != nil {
+		return fmt.Errorf("failed to get network model: %w", err)
+	}
+	// interfaces of EVE are connected to ports of network model in order
+	index, err := strconv.Atoi(strings.TrimPrefix(ifName, "eth"))
+	if err != nil || !strings.HasPrefix(ifName, "eth") || index < 0 || index >= len(netModel.Ports) {
+		return fmt.Errorf("no port of network model connected to %s", ifName)
+	}
+	netModel.Ports[index].AdminUP = up
+	if err := client.ApplyNetworkModel(netModel); err != nil {
+		return fmt.Errorf("failed to apply network model: %w", err)
+	}
+	return nil
+}
+
+// stopChaosCommand terminates process group of command started by Chaos
+// and waits for it to exit, done receives result of Wait of command
+func stopChaosCommand(cmd *exec.Cmd, done <-chan error) {
+	pgid := cmd.Process.Pid
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		log.Debugf("cannot terminate %s: %s", cmd.Path, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		log.Warnf("%s does not exit, killing it", cmd.Path)
+		if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil {
+			log.Debugf("cannot kill %s: %s", cmd.Path, err)
+		}
+		<-done
+	}
+}
+
+// restartContainer stops container and starts it again after outage or when ctx is done
+func restartContainer(ctx context.Context, name string, outage time.Duration) error {
+	if err := utils.StopContainer(name, false); err != nil {
+		return fmt.Errorf("cannot stop %s: %w", name, err)
+	}
+	_ = utils.SleepContext(ctx, outage)
+	if err := utils.StartContainer(name); err != nil {
+		return fmt.Errorf("cannot start %s: %w", name, err)
+	}
+	return nil
+}
+
+// Chaos injects random faults from args.Faults with random intervals until duration
+// passes, ctx is done or command (if defined) exits, and saves timeline of faults
+func (openEVEC *OpenEVEC) Chaos(ctx context.Context, args ChaosArgs, command []string) error {
+	if len(args.Faults) == 0 {
+		return fmt.Errorf("no faults defined")
+	}
+	for _, fault := range args.Faults {
+		if !slices.Contains(ChaosFaults, fault) {
+			return fmt.Errorf("unknown fault %s, supported: %v", fault, ChaosFaults)
+		}
+	}
+	if args.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if slices.Contains(args.Faults, ChaosLinkFlap) && len(args.Interfaces) == 0 {
+		return fmt.Errorf("no interfaces defined for %s", ChaosLinkFlap)
+	}
+	if openEVEC.cfg.Eve.Remote && openEVEC.cfg.Eve.DevModel != defaults.DefaultPhysicalModel {
+		for _, fault := range args.Faults {
+			if fault == ChaosEveReboot || fault == ChaosLinkFlap {
+				return fmt.Errorf("%s is not supported for remote EVE", fault)
+			}
+		}
+	}
+	if args.Seed == 0 {
+		args.Seed = time.Now().UnixNano()
+	}
+	if args.Timeline == "" {
+		var err error
+		if args.Timeline, err = defaultChaosTimeline(); err != nil {
+			return err
+		}
+	}
+	r := rand.New(rand.NewSource(args.Seed))
+	timeline := &ChaosTimeline{Seed: args.Seed, Command: command, Start: time.Now()}
+	log.Infof("Injecting faults %v with seed %d, timeline is saved into %s", args.Faults, args.Seed, args.Timeline)
+
+	done := make(chan error, 1)
+	var cmd *exec.Cmd
+	if len(command) > 0 {
+		cmd = exec.Command(command[0], command[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		// command runs in its own process group to stop it with its children
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("cannot run %s: %w", command[0], err)
+		}
+		go func() {
+			done <- cmd.Wait()
+		}()
+	}
+	var deadline <-chan time.Time
+	if args.Duration > 0 {
+		deadline = time.After(args.Duration)
+	}
+	var result error
+	exited := false
+Loop:
+	for {
+		// randomize interval by ±50%
+		interval := args.Interval/2 + time.Duration(r.Int63n(int64(args.Interval)+1))
+		select {
+		case result = <-done:
+			exited = true
+			break Loop
+		case <-deadline:
+			break Loop
+		case <-ctx.Done():
+			break Loop
+		case <-time.After(interval):
+		}
+		event := ChaosEvent{Fault: args.Faults[r.Intn(len(args.Faults))], Start: time.Now()}
+		log.Infof("Injecting %s", event.Fault)
+		target, err := openEVEC.injectFault(ctx, event.Fault, &args, r)
+		event.Target = target
+		event.End = time.Now()
+		if err != nil {
+			event.Error = err.Error()
+			log.Errorf("cannot inject %s: %s", event.Fault, err)
+		}
+		timeline.Events = append(timeline.Events, event)
+		if err := timeline.save(args.Timeline); err != nil {
+			log.Errorf("cannot save timeline: %s", err)
+		}
+	}
+	if cmd != nil && !exited {
+		log.Infof("Stopping %s", command[0])
+		stopChaosCommand(cmd, done)
+	}
+	timeline.End = time.Now()
+	if err := timeline.save(args.Timeline); err != nil {
+		return fmt.Errorf("cannot save timeline: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "START\tDURATION\tFAULT\tTARGET\tERROR")
+	for _, event := range timeline.Events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", event.Start.Format(time.RFC3339), event.End.Sub(event.Start).Round(time.Second),
+			event.Fault, event.Target, event.Error)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	log.Infof("Timeline saved into %s", args.Timeline)
+	return result
+}
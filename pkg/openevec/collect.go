@@ -0,0 +1,307 @@
+package openevec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v2"
+)
+
+// collectErrorsFile is the file inside support bundle with problems occurred during collection
+const collectErrorsFile = "errors.txt"
+
+// collector saves files into support bundle directory
+// and remembers errors to not stop collection on the first one
+type collector struct {
+	dir    string
+	errors []string
+}
+
+func (c *collector) fail(item string, err error) {
+	log.Warnf("collect %s: %s", item, err)
+	c.errors = append(c.errors, fmt.Sprintf("%s: %s", item, err))
+}
+
+// write saves data into file with name inside support bundle
+func (c *collector) write(name string, data []byte) {
+	fileName := filepath.Join(c.dir, name)
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		c.fail(name, err)
+		return
+	}
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		c.fail(name, err)
+	}
+}
+
+// create creates file with name inside support bundle to stream data into
+func (c *collector) create(name string) (*os.File, error) {
+	fileName := filepath.Join(c.dir, name)
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(fileName)
+}
+
+// copy saves content of file from the host into file with name inside support bundle
+func (c *collector) copy(name, src string) {
+	if src == "" {
+		return
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		c.fail(name, err)
+		return
+	}
+	c.write(name, data)
+}
+
+// run saves output of command into file with name inside support bundle
+func (c *collector) run(name string, command string, args ...string) {
+	stdout, stderr, err := utils.RunCommandAndWait(command, args...)
+	if err != nil {
+		c.fail(name, fmt.Errorf("%s %s: %w (%s)", command, strings.Join(args, " "), err, strings.TrimSpace(stderr)))
+		return
+	}
+	c.write(name, []byte(stdout))
+}
+
+// processCmdline returns command line of process with pid from pidFile
+func processCmdline(pidFile string) (string, error) {
+	content, err := os.ReadFile(pidFile)
+	if err != nil {
+		return "", err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return "", fmt.Errorf("cannot parse pid from file %s: %w", pidFile, err)
+	}
+	cmdline, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " ")), nil
+}
+
+func qemuCommand(arch string) string {
+	if arch == "arm64" {
+		return "qemu-system-aarch64"
+	}
+	return "qemu-system-x86_64"
+}
+
+func (openEVEC *OpenEVEC) collectVersions(c *collector) {
+	cfg := openEVEC.cfg
+	var versions bytes.Buffer
+	fmt.Fprintf(&versions, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			if strings.HasPrefix(setting.Key, "vcs.") {
+				fmt.Fprintf(&versions, "eden %s: %s\n", setting.Key, setting.Value)
+			}
+		}
+	}
+	fmt.Fprintf(&versions, "eve: %s (%s, %s)\n", cfg.Eve.Tag, cfg.Eve.HV, cfg.Eve.Arch)
+	fmt.Fprintf(&versions, "adam: %s\n", cfg.Adam.Tag)
+	fmt.Fprintf(&versions, "sdn: %s\n", cfg.Sdn.Version)
+	c.write("versions.txt", versions.Bytes())
+	c.run("host/qemu-version.txt", qemuCommand(cfg.Eve.Arch), "--version")
+	c.run("host/docker-version.txt", "docker", "version")
+	c.run("host/uname.txt", "uname", "-a")
+}
+
+func (openEVEC *OpenEVEC) collectConfig(c *collector) {
+	data, err := yaml.Marshal(viper.AllSettings())
+	if err != nil {
+		c.fail("config", err)
+		return
+	}
+	redacted, err := utils.RedactConfig(data)
+	if err != nil {
+		c.fail("config", err)
+		return
+	}
+	c.write("config.yml", redacted)
+}
+
+func (openEVEC *OpenEVEC) collectQemu(c *collector) {
+	cfg := openEVEC.cfg
+	if cfg.Eve.Remote || cfg.Eve.DevModel != defaults.DefaultQemuModel {
+		return
+	}
+	c.copy("eve/qemu.conf", cfg.Eve.QemuFileToSave)
+	c.copy("eve/console.log", cfg.Eve.Log)
+	if cmdline, err := processCmdline(cfg.Eve.Pid); err != nil {
+		c.fail("eve/qemu-cmdline.txt", err)
+	} else {
+		c.write("eve/qemu-cmdline.txt", []byte(cmdline+"\n"))
+	}
+}
+
+func (openEVEC *OpenEVEC) collectSdn(c *collector, pcapDuration time.Duration) {
+	cfg := openEVEC.cfg
+	if !cfg.IsSdnEnabled() {
+		return
+	}
+	c.copy("sdn/console.log", cfg.Sdn.ConsoleLogFile)
+	if cmdline, err := processCmdline(cfg.Sdn.PidFile); err != nil {
+		c.fail("sdn/qemu-cmdline.txt", err)
+	} else {
+		c.write("sdn/qemu-cmdline.txt", []byte(cmdline+"\n"))
+	}
+	if netModel, err := openEVEC.SdnNetModelGet(); err != nil {
+		c.fail("sdn/network-model.json", err)
+	} else {
+		c.write("sdn/network-model.json", []byte(netModel))
+	}
+	if netConfig, err := openEVEC.SdnNetConfigGraph(); err != nil {
+		c.fail("sdn/network-config.dot", err)
+	} else {
+		c.write("sdn/network-config.dot", []byte(netConfig))
+	}
+	if sdnLogs, err := openEVEC.SdnLogs(); err != nil {
+		c.fail("sdn/sdn.log", err)
+	} else {
+		c.write("sdn/sdn.log", []byte(sdnLogs))
+	}
+	if pcapDuration > 0 {
+		client := &edensdn.SdnClient{
+			SSHPort:    uint16(cfg.Sdn.SSHPort),
+			SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+			MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+		}
+		log.Infof("Capturing traffic inside SDN for %s", pcapDuration)
+		pcap, err := c.create("sdn/traffic.pcap")
+		if err != nil {
+			c.fail("sdn/traffic.pcap", err)
+			return
+		}
+		err = client.CaptureTraffic(pcapDuration, pcap)
+		if closeErr := pcap.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			c.fail("sdn/traffic.pcap", err)
+		}
+	}
+}
+
+func (openEVEC *OpenEVEC) collectController(c *collector, tail uint) {
+	adamTail := "all"
+	if tail > 0 {
+		adamTail = strconv.Itoa(int(tail))
+	}
+	var adamLogs bytes.Buffer
+	if err := utils.ContainerLogs(openEVEC.cfg.Containers().Adam, adamTail, &adamLogs); err != nil {
+		c.fail("adam.log", err)
+	} else {
+		c.write("adam.log", adamLogs.Bytes())
+	}
+	ctrl, dev, err := openEVEC.getControllerAndDev(false)
+	if err != nil {
+		c.fail("device", err)
+		return
+	}
+	if devConfig, err := ctrl.GetConfigBytes(dev, true); err != nil {
+		c.fail("device/config.json", err)
+	} else {
+		c.write("device/config.json", devConfig)
+	}
+	if request, _, err := openEVEC.eveLastRequest(); err != nil {
+		c.fail("device/requests.txt", err)
+	} else if request != nil {
+		c.write("device/requests.txt", []byte(request.String()))
+	}
+	// keep only tail of messages, marshalled as json lines
+	var lines [][]byte
+	appendLine := func(data []byte, err error) {
+		if err != nil {
+			log.Debugf("cannot marshal message: %s", err)
+			return
+		}
+		lines = append(lines, data)
+		if tail > 0 && len(lines) > int(tail) {
+			lines = lines[1:]
+		}
+	}
+	flush := func(name string, err error) {
+		if err != nil {
+			c.fail(name, err)
+		} else {
+			c.write(name, append(bytes.Join(lines, []byte("\n")), '\n'))
+		}
+		lines = nil
+	}
+	err = ctrl.InfoLastCallback(dev.GetID(), nil, func(im *info.ZInfoMsg) bool {
+		appendLine(protojson.Marshal(im))
+		return false
+	})
+	flush("device/info.json", err)
+	err = ctrl.MetricLastCallback(dev.GetID(), nil, func(mm *metrics.ZMetricMsg) bool {
+		appendLine(protojson.Marshal(mm))
+		return false
+	})
+	flush("device/metrics.json", err)
+	logHandler := func(le *elog.FullLogEntry) bool {
+		appendLine(protojson.Marshal(le))
+		return false
+	}
+	if tail > 0 {
+		// read only tail of logs instead of all of them
+		err = ctrl.LogChecker(dev.GetID(), map[string]string{}, logHandler, elog.LogTail(tail), 0)
+	} else {
+		err = ctrl.LogLastCallback(dev.GetID(), nil, logHandler)
+	}
+	flush("device/logs.json", err)
+}
+
+// EdenCollect gathers state of eden, controller, SDN and EVE useful for bug reports
+// into tar.gz archive tarFile. Problems with collection of particular items
+// do not stop collection and are listed inside the archive.
+func (openEVEC *OpenEVEC) EdenCollect(tarFile string, tail uint, pcapDuration time.Duration) error {
+	stageDir, err := os.MkdirTemp("", "eden-collect")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+	c := &collector{dir: stageDir}
+
+	log.Info("Collecting eden config and versions")
+	openEVEC.collectConfig(c)
+	openEVEC.collectVersions(c)
+	log.Info("Collecting controller and device state")
+	openEVEC.collectController(c, tail)
+	log.Info("Collecting EVE VM state")
+	openEVEC.collectQemu(c)
+	log.Info("Collecting SDN state")
+	openEVEC.collectSdn(c, pcapDuration)
+
+	if len(c.errors) > 0 {
+		c.write(collectErrorsFile, []byte(strings.Join(c.errors, "\n")+"\n"))
+	}
+	bundleName := filepath.Base(tarFile)
+	for _, ext := range []string{".tgz", ".gz", ".tar"} {
+		bundleName = strings.TrimSuffix(bundleName, ext)
+	}
+	if err := utils.CreateTarGz(tarFile, []utils.FileToSave{{Location: stageDir, Destination: bundleName}}); err != nil {
+		return fmt.Errorf("cannot create archive: %w", err)
+	}
+	log.Infof("Support bundle saved into %s", tarFile)
+	return nil
+}
@@ -0,0 +1,73 @@
+package openevec
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// ContextNames returns names of config contexts
+func ContextNames() ([]string, error) {
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return nil, fmt.Errorf("load context error: %w", err)
+	}
+	return context.ListContexts(), nil
+}
+
+// controllerDialTimeout limits waiting for controller in localControllerAndDev
+const controllerDialTimeout = time.Second
+
+// localControllerAndDev returns controller and device of context, data saved by the last
+// online commands are used (offline is true) if controller does not accept connections,
+// so callers do not wait for retries of requests
+func (openEVEC *OpenEVEC) localControllerAndDev() (ctrl controller.Cloud, dev *device.Ctx, offline bool, err error) {
+	vars, err := InitVarsFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("InitVarsFromConfig error: %w", err)
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(vars.AdamIP, vars.AdamPort), controllerDialTimeout)
+	if err == nil {
+		conn.Close()
+		if ctrl, dev, err = openEVEC.getControllerAndDev(false); err == nil {
+			return ctrl, dev, false, nil
+		}
+	}
+	ctrl, dev, err = openEVEC.getControllerAndDev(true)
+	return ctrl, dev, true, err
+}
+
+// DeviceUUIDs returns UUIDs of devices onboarded to controller
+func (openEVEC *OpenEVEC) DeviceUUIDs() ([]string, error) {
+	ctrl, dev, offline, err := openEVEC.localControllerAndDev()
+	if err != nil {
+		return nil, err
+	}
+	if offline {
+		// offline data contains only the device of context
+		return []string{dev.GetID().String()}, nil
+	}
+	return ctrl.DeviceList(types.RegisteredDeviceFilter)
+}
+
+// AppNames returns names of apps deployed to device of context
+func (openEVEC *OpenEVEC) AppNames() ([]string, error) {
+	ctrl, dev, _, err := openEVEC.localControllerAndDev()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, id := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(id)
+		if err != nil {
+			return nil, fmt.Errorf("no app in cloud %s: %w", id, err)
+		}
+		names = append(names, app.Displayname)
+	}
+	return names, nil
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -46,11 +47,26 @@ type EdenConfig struct {
 	Tests        string `mapstructure:"tests"`
 	EnableIPv6   bool   `mapstructure:"enable-ipv6" cobraflag:"enable-ipv6"`
 	IPv6Subnet   string `mapstructure:"ipv6-subnet" cobraflag:"ipv6-subnet"`
+	Slot         int    `mapstructure:"slot"`
 
 	EServer EServerConfig `mapstructure:"eserver"`
 
 	EClient EClientConfig `mapstructure:"eclient"`
 	Images  ImagesConfig  `mapstructure:"images"`
+
+	ArtifactsUpload ArtifactsUploadConfig `mapstructure:"artifacts-upload"`
+
+	RegistryAuth []utils.RegistryAuth `mapstructure:"registry-auth"`
+}
+
+// ArtifactsUploadConfig defines object storage to upload artifacts of failed tests into
+type ArtifactsUploadConfig struct {
+	Provider    string `mapstructure:"provider"`
+	Bucket      string `mapstructure:"bucket"`
+	Prefix      string `mapstructure:"prefix"`
+	Endpoint    string `mapstructure:"endpoint"`
+	Region      string `mapstructure:"region"`
+	Credentials string `mapstructure:"credentials" resolvepath:""`
 }
 
 type RedisConfig struct {
@@ -95,14 +111,32 @@ type CustomInstallerConfig struct {
 	Format string `mapstructure:"format"`
 }
 
+// DiskConfig describes additional disk of EVE VM
+type DiskConfig struct {
+	SizeMB  int    `mapstructure:"size"`
+	Bus     string `mapstructure:"bus"`
+	Format  string `mapstructure:"format"`
+	Persist bool   `mapstructure:"persist"`
+}
+
+// PhysicalConfig contains shell commands used to control real hardware
+// of physical devmodel with out-of-band mechanisms (PDU, IPMI, console server)
+type PhysicalConfig struct {
+	PowerOn  string `mapstructure:"power-on"`
+	PowerOff string `mapstructure:"power-off"`
+	Console  string `mapstructure:"console"`
+}
+
 type QemuConfig struct {
-	MonitorPort      int `mapstructure:"monitor-port" cobraflag:"qemu-monitor-port"`
-	NetDevSocketPort int `mapstructure:"netdev-socket-port" cobraflag:"qemu-netdev-socket-port"`
+	MonitorPort      int  `mapstructure:"monitor-port" cobraflag:"qemu-monitor-port"`
+	NetDevSocketPort int  `mapstructure:"netdev-socket-port" cobraflag:"qemu-netdev-socket-port"`
+	Overlay          bool `mapstructure:"overlay" cobraflag:"qemu-overlay"`
 }
 
 type EveConfig struct {
 	CustomInstaller CustomInstallerConfig `mapstructure:"custom-installer"`
 	QemuConfig      QemuConfig            `mapstructure:"qemu"`
+	Physical        PhysicalConfig        `mapstructure:"physical"`
 
 	QemuFirmware   []string          `mapstructure:"firmware" cobraflag:"eve-firmware"`
 	QemuConfigPath string            `mapstructure:"config-part" cobraflag:"config-path"`
@@ -112,8 +146,12 @@ type EveConfig struct {
 	CertsUUID      string            `mapstructure:"uuid" cobraflag:"uuid"`
 	Dist           string            `mapstructure:"dist" cobraflag:"eve-dist" resolvepath:""`
 	Repo           string            `mapstructure:"repo" cobraflag:"eve-repo"`
+	Source         string            `mapstructure:"source" cobraflag:"eve-source"`
+	Ref            string            `mapstructure:"ref" cobraflag:"eve-ref"`
 	Registry       string            `mapstructure:"registry" cobraflag:"eve-registry"`
 	Tag            string            `mapstructure:"tag" cobraflag:"eve-tag"`
+	Digest         string            `mapstructure:"digest" cobraflag:"eve-digest"`
+	CosignKey      string            `mapstructure:"cosign-key" cobraflag:"eve-cosign-key" resolvepath:""`
 	UefiTag        string            `mapstructure:"uefi-tag" cobraflag:"eve-uefi-tag"`
 	HV             string            `mapstructure:"hv" cobraflag:"eve-hv"`
 	Arch           string            `mapstructure:"arch" cobraflag:"eve-arch"`
@@ -128,22 +166,26 @@ type EveConfig struct {
 	Password       string            `mapstructure:"password" cobraflag:"password"`
 	Serial         string            `mapstructure:"serial" cobraflag:"eve-serial"`
 	Accel          bool              `mapstructure:"accel" cobraflag:"eve-accel"`
-
-	Pid            string `mapstructure:"pid" cobraflag:"eve-pid" resolvepath:""`
-	Log            string `mapstructure:"log" cobraflag:"eve-log" resolvepath:""`
-	TelnetPort     int    `mapstructure:"telnet-port" cobraflag:"eve-telnet-port"`
-	Remote         bool   `mapstructure:"remote"`
-	RemoteAddr     string `mapstructure:"remote-addr"`
-	ModelFile      string `mapstructure:"devmodelfile" cobraflag:"devmodel-file"`
-	Cert           string `mapstructure:"cert" resolvepath:""`
-	DeviceCert     string `mapstructure:"device-cert" resolvepath:""`
-	Name           string `mapstructure:"name"`
-	AdamLogLevel   string `mapstructure:"adam-log-level"`
-	LogLevel       string `mapstructure:"log-level"`
-	Disks          int    `mapstructure:"disks"`
-	BootstrapFile  string `mapstructure:"bootstrap-file" cobraflag:"eve-bootstrap-file"`
-	UsbNetConfFile string `mapstructure:"usbnetconf-file" cobraflag:"eve-usbnetconf-file"`
-	TPM            bool   `mapstructure:"tpm" cobraflag:"tpm"`
+	Accelerator    string            `mapstructure:"accelerator" cobraflag:"eve-accelerator"`
+	Nested         bool              `mapstructure:"nested"`
+	TCG            string            `mapstructure:"tcg" cobraflag:"eve-tcg"`
+
+	Pid            string       `mapstructure:"pid" cobraflag:"eve-pid" resolvepath:""`
+	Log            string       `mapstructure:"log" cobraflag:"eve-log" resolvepath:""`
+	TelnetPort     int          `mapstructure:"telnet-port" cobraflag:"eve-telnet-port"`
+	Remote         bool         `mapstructure:"remote"`
+	RemoteAddr     string       `mapstructure:"remote-addr"`
+	ModelFile      string       `mapstructure:"devmodelfile" cobraflag:"devmodel-file"`
+	Cert           string       `mapstructure:"cert" resolvepath:""`
+	DeviceCert     string       `mapstructure:"device-cert" resolvepath:""`
+	Name           string       `mapstructure:"name"`
+	AdamLogLevel   string       `mapstructure:"adam-log-level"`
+	LogLevel       string       `mapstructure:"log-level"`
+	Disks          int          `mapstructure:"disks"`
+	DiskLayout     []DiskConfig `mapstructure:"disk-layout"`
+	BootstrapFile  string       `mapstructure:"bootstrap-file" cobraflag:"eve-bootstrap-file"`
+	UsbNetConfFile string       `mapstructure:"usbnetconf-file" cobraflag:"eve-usbnetconf-file"`
+	TPM            bool         `mapstructure:"tpm" cobraflag:"tpm"`
 }
 
 type RegistryConfig struct {
@@ -177,6 +219,54 @@ type SdnConfig struct {
 	SSHPort        int    `mapstructure:"ssh-port" cobraflag:"sdn-ssh-port"`
 	EnableIPv6     bool   `mapstructure:"enable-ipv6" cobraflag:"sdn-enable-ipv6"`
 	IPv6Subnet     string `mapstructure:"ipv6-subnet" cobraflag:"sdn-ipv6-subnet"`
+	IPv4Subnet     string `mapstructure:"ipv4-subnet" cobraflag:"sdn-ipv4-subnet"`
+}
+
+// TimeoutsConfig stores durations of waits of eden, slow machines (e.g. CI with
+// nested virtualization) need longer ones, fast ones can detect failures sooner
+type TimeoutsConfig struct {
+	SdnStart        time.Duration `mapstructure:"sdn-start"`
+	Onboard         time.Duration `mapstructure:"onboard"`
+	SnapshotRestore time.Duration `mapstructure:"snapshot-restore"`
+	Attestation     time.Duration `mapstructure:"attestation"`
+	AppProbe        time.Duration `mapstructure:"app-probe"`
+	Netdump         time.Duration `mapstructure:"netdump"`
+	Hook            time.Duration `mapstructure:"hook"`
+	// PollInterval is interval between checks of state while waiting
+	PollInterval time.Duration `mapstructure:"poll-interval"`
+}
+
+// defaultTimeouts returns timeouts used if they are not set in config
+func defaultTimeouts() TimeoutsConfig {
+	return TimeoutsConfig{
+		SdnStart:        defaults.DefaultSdnStartTimeout,
+		Onboard:         defaults.DefaultOnboardTimeout,
+		SnapshotRestore: defaults.DefaultSnapshotRestoreTimeout,
+		Attestation:     defaults.DefaultAttestationTimeout,
+		AppProbe:        defaults.DefaultAppProbeTimeout,
+		Netdump:         defaults.DefaultNetdumpTimeout,
+		Hook:            defaults.DefaultHookTimeout,
+		PollInterval:    defaults.DefaultPollInterval,
+	}
+}
+
+// setDefaults sets timeouts which are not set to their default values
+func (timeouts *TimeoutsConfig) setDefaults() {
+	dst := reflect.ValueOf(timeouts).Elem()
+	src := reflect.ValueOf(defaultTimeouts())
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+// LogConfig stores format of logs of eden and levels of its subsystems
+type LogConfig struct {
+	Format string `mapstructure:"format" cobraflag:"log-format"`
+	// Levels are levels of logs of subsystems (packages of eden relative to pkg
+	// directory, e.g. controller or controller/adam), they override verbosity
+	Levels map[string]string `mapstructure:"levels"`
 }
 
 type EdenSetupArgs struct {
@@ -188,6 +278,9 @@ type EdenSetupArgs struct {
 	Packet   PacketConfig   `mapstructure:"packet"`
 	Gcp      GcpConfig      `mapstructure:"gcp"`
 	Sdn      SdnConfig      `mapstructure:"sdn"`
+	Timeouts TimeoutsConfig `mapstructure:"timeouts"`
+	Hooks    HooksConfig    `mapstructure:"hooks"`
+	Log      LogConfig      `mapstructure:"log"`
 
 	ConfigFile string
 	ConfigName string
@@ -201,6 +294,12 @@ func (setupArgs *EdenSetupArgs) IsSdnEnabled() bool {
 		!setupArgs.Eve.Remote
 }
 
+// QemuAccelerator returns accelerator to run EVE VM with in QEMU, see utils.QemuAccelerator
+func (setupArgs *EdenSetupArgs) QemuAccelerator() (string, error) {
+	return utils.QemuAccelerator(setupArgs.Eve.TCG, setupArgs.Eve.Arch,
+		setupArgs.Eve.Accelerator, setupArgs.Eve.Accel)
+}
+
 // PodConfig store configuration for Pod deployment
 type PodConfig struct {
 	Name              string
@@ -231,6 +330,9 @@ type PodConfig struct {
 	OpenStackMetadata bool
 	DatastoreOverride string
 	ACLOnlyHost       bool
+	Probe             string
+	// ShowConfig prints config of pod with volumes and network instances as JSON
+	ShowConfig bool
 }
 
 func Merge(dst, src reflect.Value, flags *pflag.FlagSet) {
@@ -272,19 +374,22 @@ func FromViper(configName, verbosity string) (*EdenSetupArgs, error) {
 		return nil, err
 	}
 
-	if err := SetUpLogs(verbosity); err != nil {
+	if err := SetUpLogs(verbosity, cfg.Log); err != nil {
 		return nil, err
 	}
 	return cfg, nil
 }
 
-func SetUpLogs(level string) error {
+// SetUpLogs sets level of logs to level and their format and levels of subsystems from logCfg
+func SetUpLogs(level string, logCfg LogConfig) error {
 	log.SetOutput(os.Stdout)
 	lvl, err := log.ParseLevel(level)
 	if err != nil {
 		return err
 	}
-	log.SetLevel(lvl)
+	if err := utils.SetUpLogFormat(logCfg.Format, lvl, logCfg.Levels); err != nil {
+		return fmt.Errorf("cannot set up logs: %w", err)
+	}
 	return nil
 }
 
@@ -306,6 +411,8 @@ func LoadConfig(configFile string) (*EdenSetupArgs, error) {
 	}
 
 	resolvePath(cfg.Eden.Root, reflect.ValueOf(cfg).Elem())
+	// configs created before timeouts were configurable do not have them
+	cfg.Timeouts.setDefaults()
 
 	if configFile == "" {
 		configFile, _ = utils.DefaultConfigPath()
@@ -317,6 +424,7 @@ func LoadConfig(configFile string) (*EdenSetupArgs, error) {
 	}
 
 	viper.SetConfigName(configName)
+	cfg.ConfigName = configName
 
 	return cfg, nil
 }
@@ -376,8 +484,16 @@ func WriteConfig(dst reflect.Value, root string, writer io.Writer, nestLevel int
 			case reflect.Slice:
 				io.WriteString(writer, structTag+":\n")
 				for j := 0; j < f.Len(); j++ {
-					io.WriteString(writer, strings.Repeat("  ", nestLevel+1))
 					elem := f.Index(j)
+					if elem.Kind() == reflect.Struct {
+						// write fields of struct as mapping starting with dash
+						var sb strings.Builder
+						WriteConfig(elem, root, &sb, nestLevel+2)
+						io.WriteString(writer, strings.Repeat("  ", nestLevel+1)+"- ")
+						io.WriteString(writer, strings.TrimPrefix(sb.String(), strings.Repeat("  ", nestLevel+2)))
+						continue
+					}
+					io.WriteString(writer, strings.Repeat("  ", nestLevel+1))
 					io.WriteString(writer, fmt.Sprintf("- %v\n", getValStrRepr(elem)))
 				}
 			case reflect.String:
@@ -0,0 +1,254 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// configCheckpointFile returns file with config of device in controller saved as checkpoint with name
+func (openEVEC *OpenEVEC) configCheckpointFile(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid name of checkpoint: %s", name)
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultConfigCkptDir, configName, fmt.Sprintf("%s.json", name)), nil
+}
+
+// loadConfigCheckpoint reads config of device saved in checkpoint file
+func loadConfigCheckpoint(configFile string) (*config.EdgeDevConfig, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	var devConfig config.EdgeDevConfig
+	if err := protojson.Unmarshal(data, &devConfig); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal config of checkpoint: %w", err)
+	}
+	return &devConfig, nil
+}
+
+// currentDevConfig returns full config of device in controller
+func (openEVEC *OpenEVEC) currentDevConfig() (*config.EdgeDevConfig, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	res, err := ctrl.GetConfigBytes(dev, false)
+	if err != nil {
+		return nil, fmt.Errorf("GetConfigBytes: %w", err)
+	}
+	var devConfig config.EdgeDevConfig
+	if err := proto.Unmarshal(res, &devConfig); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	return &devConfig, nil
+}
+
+// devConfigText returns config of device as indented JSON with sorted fields, stable to be diffed
+func devConfigText(devConfig *config.EdgeDevConfig) (string, error) {
+	data, err := protojson.Marshal(devConfig)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal config: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	if data, err = json.MarshalIndent(value, "", "  "); err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// ConfigCheckpointCreate saves full config of device in controller as checkpoint with name
+func (openEVEC *OpenEVEC) ConfigCheckpointCreate(name string, force bool) error {
+	configFile, err := openEVEC.configCheckpointFile(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(configFile); err == nil && !force {
+		return fmt.Errorf("checkpoint %s already exists, use --force to overwrite it", name)
+	}
+	devConfig, err := openEVEC.currentDevConfig()
+	if err != nil {
+		return err
+	}
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(devConfig)
+	if err != nil {
+		return fmt.Errorf("cannot marshal config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(configFile, data, 0644); err != nil {
+		return fmt.Errorf("cannot save checkpoint: %w", err)
+	}
+	log.Infof("Checkpoint %s created with config version %s", name, devConfig.GetId().GetVersion())
+	return nil
+}
+
+// ConfigCheckpointRollback pushes config of device saved in checkpoint with name into
+// controller, config is sent with the next version and epoch to make EVE apply it
+func (openEVEC *OpenEVEC) ConfigCheckpointRollback(name string) error {
+	configFile, err := openEVEC.configCheckpointFile(name)
+	if err != nil {
+		return err
+	}
+	devConfig, err := loadConfigCheckpoint(configFile)
+	if err != nil {
+		return fmt.Errorf("cannot load checkpoint %s: %w", name, err)
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	if devConfig.GetId().GetUuid() != dev.GetID().String() {
+		return fmt.Errorf("checkpoint %s is for device %s, but current device is %s",
+			name, devConfig.GetId().GetUuid(), dev.GetID())
+	}
+	if err := controller.ValidateEdgeDevConfig(devConfig); err != nil {
+		return err
+	}
+	res, err := ctrl.GetConfigBytes(dev, false)
+	if err != nil {
+		return fmt.Errorf("GetConfigBytes: %w", err)
+	}
+	var current config.EdgeDevConfig
+	if err := proto.Unmarshal(res, &current); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	version, _ := strconv.Atoi(current.GetId().GetVersion())
+	devConfig.Id.Version = strconv.Itoa(version + 1)
+	devConfig.ControllerEpoch = current.ControllerEpoch + 1
+	configBytes, err := proto.Marshal(devConfig)
+	if err != nil {
+		return fmt.Errorf("cannot marshal config: %w", err)
+	}
+	if err := ctrl.ConfigSet(dev.GetID(), configBytes); err != nil {
+		return fmt.Errorf("ConfigSet: %w", err)
+	}
+	if ctrl, dev, err = changer.getControllerAndDevFromConfig(openEVEC.cfg); err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	if err := ctrl.StateUpdate(dev); err != nil {
+		return fmt.Errorf("StateUpdate: %w", err)
+	}
+	log.Infof("Rolled back to checkpoint %s (config version %d, epoch %d)",
+		name, version+1, devConfig.ControllerEpoch)
+	return nil
+}
+
+// ConfigCheckpointDelete removes checkpoint with name
+func (openEVEC *OpenEVEC) ConfigCheckpointDelete(name string) error {
+	configFile, err := openEVEC.configCheckpointFile(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(configFile); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("checkpoint %s not found", name)
+		}
+		return err
+	}
+	log.Infof("Checkpoint %s deleted", name)
+	return nil
+}
+
+// ConfigCheckpointList prints saved checkpoints with summary of their config
+func (openEVEC *OpenEVEC) ConfigCheckpointList() error {
+	configFile, err := openEVEC.configCheckpointFile("")
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(filepath.Dir(configFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err = fmt.Fprintln(w, "NAME\tCREATED\tVERSION\tEPOCH\tAPPS\tNETWORK_INSTANCES\tVOLUMES"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		fileInfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		devConfig, err := loadConfigCheckpoint(filepath.Join(filepath.Dir(configFile), entry.Name()))
+		if err != nil {
+			log.Warnf("skip checkpoint %s: %s", name, err)
+			continue
+		}
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n", name,
+			fileInfo.ModTime().Format("2006-01-02 15:04:05"), devConfig.GetId().GetVersion(),
+			devConfig.GetControllerEpoch(), len(devConfig.GetApps()),
+			len(devConfig.GetNetworkInstances()), len(devConfig.GetVolumes())); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// ConfigDiff prints differences of config of device from checkpoint name1 to checkpoint name2
+// or to the current config of device in controller if name2 is empty
+func (openEVEC *OpenEVEC) ConfigDiff(name1, name2 string, opts utils.DiffOptions) error {
+	load := func(name string) (string, error) {
+		var devConfig *config.EdgeDevConfig
+		if name == "" {
+			var err error
+			if devConfig, err = openEVEC.currentDevConfig(); err != nil {
+				return "", err
+			}
+		} else {
+			configFile, err := openEVEC.configCheckpointFile(name)
+			if err != nil {
+				return "", err
+			}
+			if devConfig, err = loadConfigCheckpoint(configFile); err != nil {
+				return "", fmt.Errorf("cannot load checkpoint %s: %w", name, err)
+			}
+		}
+		return devConfigText(devConfig)
+	}
+	text1, err := load(name1)
+	if err != nil {
+		return err
+	}
+	text2, err := load(name2)
+	if err != nil {
+		return err
+	}
+	if name2 == "" {
+		name2 = "controller"
+	}
+	fmt.Print(utils.RenderDiff(name1, name2, text1, text2, opts))
+	return nil
+}
@@ -0,0 +1,269 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	"gopkg.in/yaml.v2"
+)
+
+// configItemsAuditFile is file inside eden directory where changes of
+// config items are recorded
+const configItemsAuditFile = "config-items-audit.log"
+
+// LoadConfigItemsFile reads config items from yaml file with key: value
+// mapping, values of any scalar type are converted into strings
+func LoadConfigItemsFile(fileName string) (map[string]string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", fileName, err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", fileName, err)
+	}
+	items := make(map[string]string, len(raw))
+	for key, val := range raw {
+		switch val.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return nil, fmt.Errorf("value of %s in %s is not a scalar", key, fileName)
+		case nil:
+			items[key] = ""
+		default:
+			items[key] = fmt.Sprint(val)
+		}
+	}
+	return items, nil
+}
+
+// ConfigItemChange is change of config item recorded in audit log
+type ConfigItemChange struct {
+	Key   string
+	Old   string
+	New   string
+	Unset bool
+}
+
+// AuditRecord returns line of audit log describing change made by who
+func (change ConfigItemChange) AuditRecord(when time.Time, who, context, devID string) string {
+	action := fmt.Sprintf("set %s=%q", change.Key, change.New)
+	if change.Unset {
+		action = fmt.Sprintf("unset %s", change.Key)
+	}
+	if change.Old != "" {
+		action += fmt.Sprintf(" (was %q)", change.Old)
+	}
+	return fmt.Sprintf("%s user=%s context=%s device=%s %s",
+		when.UTC().Format(time.RFC3339), who, context, devID, action)
+}
+
+// auditConfigItems appends records into audit log of config items
+func (openEVEC *OpenEVEC) auditConfigItems(devID string, changes []ConfigItemChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	who := "unknown"
+	if usr, err := user.Current(); err == nil {
+		who = usr.Username
+	}
+	now := time.Now()
+	var records strings.Builder
+	for _, change := range changes {
+		records.WriteString(change.AuditRecord(now, who, openEVEC.cfg.ConfigName, devID))
+		records.WriteString("\n")
+	}
+	f, err := os.OpenFile(filepath.Join(edenDir, configItemsAuditFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open audit log: %w", err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(records.String())
+	return err
+}
+
+// ConfigItemsSet sets config items of device at once and records changed ones in audit log
+func (openEVEC *OpenEVEC) ConfigItemsSet(controllerMode string, items map[string]string) error {
+	changes, err := openEVEC.setConfigItems(controllerMode, items)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("config items are up to date")
+		return nil
+	}
+	fmt.Printf("%d config items changed\n", len(changes))
+	return nil
+}
+
+// setConfigItems sets config items of device, records changed ones in audit log and returns them
+func (openEVEC *OpenEVEC) setConfigItems(controllerMode string, items map[string]string) ([]ConfigItemChange, error) {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return nil, err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var changes []ConfigItemChange
+	for _, key := range keys {
+		old, ok := dev.GetConfigItems()[key]
+		if ok && old == items[key] {
+			continue
+		}
+		dev.SetConfigItem(key, items[key])
+		changes = append(changes, ConfigItemChange{Key: key, Old: old, New: items[key]})
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return nil, fmt.Errorf("setControllerAndDev error: %w", err)
+	}
+	if err = openEVEC.auditConfigItems(dev.GetID().String(), changes); err != nil {
+		return nil, fmt.Errorf("auditConfigItems: %w", err)
+	}
+	return changes, nil
+}
+
+// ConfigItemsUnset removes config items of device to return them to defaults of EVE
+func (openEVEC *OpenEVEC) ConfigItemsUnset(controllerMode string, keys []string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	sort.Strings(keys)
+	var changes []ConfigItemChange
+	for _, key := range keys {
+		old, ok := dev.GetConfigItems()[key]
+		if !ok {
+			continue
+		}
+		dev.UnsetConfigItem(key)
+		changes = append(changes, ConfigItemChange{Key: key, Old: old, Unset: true})
+	}
+	if len(changes) == 0 {
+		fmt.Println("config items are not set")
+		return nil
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev error: %w", err)
+	}
+	if err = openEVEC.auditConfigItems(dev.GetID().String(), changes); err != nil {
+		return fmt.Errorf("auditConfigItems: %w", err)
+	}
+	fmt.Printf("%d config items unset\n", len(changes))
+	return nil
+}
+
+// configItemState is value of config item in controller and the one acknowledged by EVE
+type configItemState struct {
+	Key        string `json:"key"`
+	Configured string `json:"configured,omitempty"`
+	Device     string `json:"device,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// deviceConfigItemStatus returns status of config items from the last info of device
+func deviceConfigItemStatus(ctrl controller.Cloud, dev *device.Ctx) (*info.ZInfoConfigItemStatus, error) {
+	var itemStatus *info.ZInfoConfigItemStatus
+	if err := ctrl.InfoLastCallback(dev.GetID(), map[string]string{"devId": dev.GetID().String()}, func(im *info.ZInfoMsg) bool {
+		if im.GetZtype() == info.ZInfoTypes_ZiDevice && im.GetDinfo().GetConfigItemStatus() != nil {
+			itemStatus = im.GetDinfo().GetConfigItemStatus()
+		}
+		return false
+	}); err != nil {
+		return nil, fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	return itemStatus, nil
+}
+
+// ConfigItemsGet prints config items of device (all configured ones if keys
+// are empty) with values acknowledged by EVE in the last info of device
+func (openEVEC *OpenEVEC) ConfigItemsGet(controllerMode string, keys []string, outputFormat types.OutputFormat) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	itemStatus, err := deviceConfigItemStatus(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		for key := range dev.GetConfigItems() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var items []*configItemState
+	for _, key := range keys {
+		state := &configItemState{Key: key, Configured: dev.GetConfigItems()[key]}
+		if item, ok := itemStatus.GetConfigItems()[key]; ok {
+			state.Device, state.Error = item.GetValue(), item.GetError()
+		} else if item, ok := itemStatus.GetUnknownConfigItems()[key]; ok {
+			state.Device, state.Error = item.GetValue(), item.GetError()
+			if state.Error == "" {
+				state.Error = "unknown config item"
+			}
+		}
+		items = append(items, state)
+	}
+	switch outputFormat {
+	case types.OutputFormatJSON:
+		result, err := json.MarshalIndent(items, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(result))
+		return nil
+	case types.OutputFormatLines:
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		if _, err = fmt.Fprintln(w, "KEY\tCONFIGURED\tDEVICE\tERROR"); err != nil {
+			return err
+		}
+		orDash := func(s string) string {
+			if s == "" {
+				return "-"
+			}
+			return s
+		}
+		for _, item := range items {
+			if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Key, orDash(item.Configured),
+				orDash(item.Device), orDash(item.Error)); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unimplemented output format")
+}
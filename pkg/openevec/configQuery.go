@@ -0,0 +1,142 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// configKeyType returns type of field of EdenSetupArgs for key of config
+// in dot notation (e.g. eve.hostfwd), names of fields are their mapstructure tags
+func configKeyType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(EdenSetupArgs{})
+	for _, name := range strings.Split(key, ".") {
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("unknown key %s: %s is not a section", key, name)
+		}
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).Tag.Get("mapstructure") == name {
+				t = t.Field(i).Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown key %s", key)
+		}
+	}
+	return t, nil
+}
+
+// ParseConfigValue converts value to type of key in config, error is returned if value
+// does not match the type, values of lists, maps and sections are expected in JSON
+func ParseConfigValue(key, value string) (interface{}, error) {
+	t, err := configKeyType(key)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("%s expects duration (e.g. 5m): %w", key, err)
+		}
+		return value, nil
+	case t.Kind() == reflect.String:
+		return value, nil
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects true or false: %w", key, err)
+		}
+		return b, nil
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects integer: %w", key, err)
+		}
+		return i, nil
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects non-negative integer: %w", key, err)
+		}
+		return u, nil
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects number: %w", key, err)
+		}
+		return f, nil
+	}
+	var obj interface{}
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.String {
+			return nil, fmt.Errorf("%s expects JSON: %w", key, err)
+		}
+		// allow comma-separated lists of strings
+		obj = strings.Split(value, ",")
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:  mapstructure.StringToTimeDurationHookFunc(),
+		ErrorUnused: true,
+		Result:      reflect.New(t).Interface(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(obj); err != nil {
+		return nil, fmt.Errorf("invalid value of %s: %w", key, err)
+	}
+	return obj, nil
+}
+
+// configSettings returns settings of loaded config as decoded JSON
+func configSettings() (interface{}, error) {
+	data, err := json.Marshal(viper.AllSettings())
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode config: %w", err)
+	}
+	var settings interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// printConfigValues prints values selected from config with query, lists of values
+// are printed for queries iterating over lists or maps
+func printConfigValues(values []interface{}, query string, format types.OutputFormat) error {
+	var value interface{} = values
+	if len(values) == 1 && !strings.Contains(query, "[]") && !strings.Contains(query, "[*]") {
+		value = values[0]
+	}
+	switch format {
+	case types.OutputFormatJSON:
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case types.OutputFormatYAML:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	default:
+		for _, v := range values {
+			fmt.Println(utils.JSONPathValueString(v))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package openevec_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseConfigValue(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	value, err := openevec.ParseConfigValue("eve.telnet-port", "7778")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(value).To(BeEquivalentTo(7778))
+
+	_, err = openevec.ParseConfigValue("eve.telnet-port", "seven")
+	g.Expect(err).To(HaveOccurred())
+
+	value, err = openevec.ParseConfigValue("eve.tpm", "true")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(value).To(BeTrue())
+
+	value, err = openevec.ParseConfigValue("eve.hostfwd", `{"2223":"22"}`)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(value).To(Equal(map[string]interface{}{"2223": "22"}))
+
+	_, err = openevec.ParseConfigValue("eve.hostfwd", `["2223"]`)
+	g.Expect(err).To(HaveOccurred())
+
+	value, err = openevec.ParseConfigValue("eve.firmware", "a.fd,b.fd")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(value).To(Equal([]string{"a.fd", "b.fd"}))
+
+	_, err = openevec.ParseConfigValue("eve.no-such-key", "1")
+	g.Expect(err).To(MatchError(ContainSubstring("unknown key")))
+}
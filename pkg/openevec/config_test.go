@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
 	. "github.com/onsi/gomega"
 	"github.com/spf13/viper"
@@ -95,3 +97,65 @@ func TestConfigSliceType(t *testing.T) {
 
 	g.Expect(reflect.TypeOf(cfg.Names[0]).Kind()).To(BeEquivalentTo(reflect.String))
 }
+
+type AuthConfig struct {
+	Registry string `mapstructure:"registry"`
+	User     string `mapstructure:"user"`
+}
+
+type AuthListConfig struct {
+	Auths []AuthConfig `mapstructure:"auths"`
+	Name  string       `mapstructure:"name"`
+}
+
+func TestConfigSliceOfStructs(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	cfg := AuthListConfig{
+		Auths: []AuthConfig{
+			{Registry: "ghcr.io", User: "user1"},
+			{Registry: "gcr.io", User: "user2"},
+		},
+		Name: "test",
+	}
+
+	var buf bytes.Buffer
+	openevec.WriteConfig(reflect.ValueOf(cfg), "", &buf, 0)
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(&buf)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gotCfg := &AuthListConfig{}
+	err = v.Unmarshal(&gotCfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*gotCfg).To(BeEquivalentTo(cfg))
+}
+
+func TestConfigTimeouts(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	cfg := openevec.EdenSetupArgs{Timeouts: openevec.TimeoutsConfig{Onboard: 30 * time.Minute}}
+
+	var buf bytes.Buffer
+	openevec.WriteConfig(reflect.ValueOf(cfg), "", &buf, 0)
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(&buf)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gotCfg := &openevec.EdenSetupArgs{}
+	err = v.Unmarshal(&gotCfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(gotCfg.Timeouts.Onboard).To(Equal(30 * time.Minute))
+
+	// timeouts missing in config get default values
+	openevec.CreateOpenEVEC(gotCfg)
+	g.Expect(gotCfg.Timeouts.Onboard).To(Equal(30 * time.Minute))
+	g.Expect(gotCfg.Timeouts.SdnStart).To(Equal(defaults.DefaultSdnStartTimeout))
+	g.Expect(gotCfg.Timeouts.PollInterval).To(Equal(defaults.DefaultPollInterval))
+}
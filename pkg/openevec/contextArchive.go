@@ -0,0 +1,239 @@
+package openevec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// Entries of archive of context
+const (
+	archiveManifest = "manifest.yml"
+	archiveContext  = "context.yml"
+	archiveState    = "state.yml"
+	archiveDigests  = "digests.yml"
+	archiveCerts    = "certs"
+	archiveImages   = "images"
+)
+
+// contextManifest describes context saved into archive
+type contextManifest struct {
+	Name    string `yaml:"name"`
+	Root    string `yaml:"root"`
+	EdenDir string `yaml:"eden-dir"`
+	Images  bool   `yaml:"images"`
+}
+
+// ContextExport saves config of context, its certificates, state of device
+// and images of EVE (if withImages is set) into tarFile. Certificates and keys
+// of controller are shared by all contexts and are not saved.
+func (openEVEC *OpenEVEC) ContextExport(tarFile string, withImages bool) error {
+	cfg := openEVEC.cfg
+	name, err := openEVEC.contextName()
+	if err != nil {
+		return err
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	changer := &adamChanger{}
+	if ctrl, dev, err := changer.getControllerAndDevFromConfig(cfg); err == nil {
+		deviceCert, err := ctrl.GetDeviceCert(dev)
+		if err != nil {
+			log.Warn(err)
+		} else if err = os.WriteFile(ctrl.GetVars().EveDeviceCert, deviceCert.Cert, 0644); err != nil {
+			log.Warn(err)
+		}
+	} else {
+		log.Info("Device not registered, will not save device cert")
+	}
+
+	manifest, err := yaml.Marshal(&contextManifest{
+		Name:    name,
+		Root:    cfg.Eden.Root,
+		EdenDir: edenDir,
+		Images:  withImages,
+	})
+	if err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp("", "eden-context-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	manifestFile := filepath.Join(tmpDir, archiveManifest)
+	if err := os.WriteFile(manifestFile, manifest, 0644); err != nil {
+		return err
+	}
+
+	files := []utils.FileToSave{
+		{Location: manifestFile, Destination: archiveManifest},
+		{Location: utils.GetConfig(name), Destination: archiveContext},
+		{Location: cfg.Eden.CertsDir, Destination: archiveCerts},
+	}
+	stateFile := filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", cfg.Eve.CertsUUID))
+	if _, err := os.Stat(stateFile); err == nil {
+		files = append(files, utils.FileToSave{Location: stateFile, Destination: archiveState})
+	}
+	digestsFile := filepath.Join(edenDir, fmt.Sprintf("digests-%s.yml", cfg.Eve.CertsUUID))
+	if _, err := os.Stat(digestsFile); err == nil {
+		files = append(files, utils.FileToSave{Location: digestsFile, Destination: archiveDigests})
+	}
+	if withImages {
+		files = append(files, utils.FileToSave{Location: filepath.Dir(cfg.Eve.ImageFile), Destination: archiveImages})
+		// running EVE VM is paused to save disks and state of swtpm consistent with each other
+		if openEVEC.checkSnapshotSupported() == nil {
+			monitorPort := cfg.Eve.QemuConfig.MonitorPort
+			if err := eden.PauseQemu(monitorPort); err == nil {
+				defer func() {
+					if err := eden.ResumeQemu(monitorPort); err != nil {
+						log.Errorf("cannot resume EVE VM: %s", err)
+					}
+				}()
+			} else {
+				log.Debugf("EVE VM is not paused: %s", err)
+			}
+		}
+	}
+	if err := utils.CreateTarGz(tarFile, files); err != nil {
+		return fmt.Errorf("cannot create %s: %w", tarFile, err)
+	}
+	log.Infof("Context %s exported into %s", name, tarFile)
+	return nil
+}
+
+// ContextImport adds context saved by ContextExport from tarFile, paths inside
+// eden root and eden directory of exporting host are moved into local ones.
+// Existing context or its certificates are overwritten only if force is set.
+func (openEVEC *OpenEVEC) ContextImport(tarFile string, force bool) error {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp("", "eden-context-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := utils.UnpackTarGz(tarFile, []utils.FileToSave{
+		{Location: archiveManifest, Destination: filepath.Join(tmpDir, archiveManifest)},
+		{Location: archiveContext, Destination: filepath.Join(tmpDir, archiveContext)},
+		{Location: archiveCerts, Destination: filepath.Join(tmpDir, archiveCerts)},
+	}); err != nil {
+		return fmt.Errorf("cannot unpack %s: %w", tarFile, err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, archiveManifest))
+	if err != nil {
+		return fmt.Errorf("%s is not an archive of context: %w", tarFile, err)
+	}
+	var manifest contextManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("cannot parse manifest of %s: %w", tarFile, err)
+	}
+
+	contextFile := utils.GetConfig(manifest.Name)
+	if _, err := os.Stat(contextFile); err == nil && !force {
+		return fmt.Errorf("context %s already exists, use --force to overwrite it", manifest.Name)
+	}
+	config, err := os.ReadFile(filepath.Join(tmpDir, archiveContext))
+	if err != nil {
+		return err
+	}
+	for from, to := range map[string]string{manifest.Root: openEVEC.cfg.Eden.Root, manifest.EdenDir: edenDir} {
+		if from != "" && to != "" {
+			config = bytes.ReplaceAll(config, []byte(from), []byte(to))
+		}
+	}
+	// config is parsed before it is saved to check where files of context go
+	newConfigFile := filepath.Join(tmpDir, filepath.Base(contextFile))
+	if err := os.WriteFile(newConfigFile, config, 0644); err != nil {
+		return err
+	}
+	newCfg, err := LoadConfig(newConfigFile)
+	if err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(newCfg.Eden.CertsDir); err == nil && len(entries) > 0 && !force {
+		return fmt.Errorf("certificates of context exist in %s, use --force to overwrite them", newCfg.Eden.CertsDir)
+	}
+	rootCert := "root-certificate.pem"
+	contextRootCert, err := os.ReadFile(filepath.Join(tmpDir, archiveCerts, rootCert))
+	if err != nil {
+		return fmt.Errorf("no certificates of context in %s: %w", tarFile, err)
+	}
+	globalCertsDir := filepath.Join(edenDir, defaults.DefaultCertsDist)
+	if localRootCert, err := os.ReadFile(filepath.Join(globalCertsDir, rootCert)); err != nil {
+		log.Warnf("no certificates of controller in %s, EVE of context will not trust controller: %s", globalCertsDir, err)
+	} else if !bytes.Equal(contextRootCert, localRootCert) {
+		log.Warnf("EVE of context was onboarded with other controller, it will not trust certificates in %s", globalCertsDir)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(contextFile), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(contextFile, config, 0644); err != nil {
+		return err
+	}
+	cfg, err := LoadConfig(contextFile)
+	if err != nil {
+		return err
+	}
+	files := []utils.FileToSave{
+		{Location: archiveCerts, Destination: cfg.Eden.CertsDir},
+		{Location: archiveState, Destination: filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", cfg.Eve.CertsUUID))},
+		{Location: archiveDigests, Destination: filepath.Join(edenDir, fmt.Sprintf("digests-%s.yml", cfg.Eve.CertsUUID))},
+	}
+	if err := utils.UnpackTarGz(tarFile, files); err != nil {
+		return fmt.Errorf("cannot unpack %s: %w", tarFile, err)
+	}
+	imagesDir := filepath.Dir(cfg.Eve.ImageFile)
+	if manifest.Images {
+		// images of EVE are larger than limit of other files
+		if err := utils.UnpackTarGzWithLimit(tarFile, []utils.FileToSave{
+			{Location: archiveImages, Destination: imagesDir},
+		}, utils.MaxDecompressedImageSize); err != nil {
+			return fmt.Errorf("cannot unpack %s: %w", tarFile, err)
+		}
+		// overlay of EVE image points to its location on exporting host
+		overlayFile := filepath.Join(imagesDir, fmt.Sprintf("%s-overlay.qcow2", manifest.Name))
+		if _, err := os.Stat(overlayFile); err == nil {
+			imageFormat, err := utils.ImageFormat(cfg.Eve.ImageFile)
+			if err != nil {
+				return err
+			}
+			if err := eden.RebaseOverlayQemu(overlayFile, cfg.Eve.ImageFile, imageFormat); err != nil {
+				return err
+			}
+		}
+	}
+	// put information about device into Adam of context as 'eden import' does
+	importedEVEC := CreateOpenEVEC(cfg)
+	if err := importedEVEC.StartRedis(); err != nil {
+		log.Warnf("cannot register device in Adam: %s", err)
+	} else if err := importedEVEC.StartAdam(); err != nil {
+		log.Warnf("cannot register device in Adam: %s", err)
+	} else if err := registerImportedDevice(cfg); err != nil {
+		log.Warnf("cannot register device in Adam: %s", err)
+	}
+	if cfg.Eden.Slot != 0 {
+		slots, err := contextSlots(manifest.Name)
+		if err != nil {
+			return err
+		}
+		if other, used := slots[cfg.Eden.Slot]; used {
+			log.Warnf("slot %d is used by context %s, use 'eden context clone %s <name>' to run them together",
+				cfg.Eden.Slot, other, manifest.Name)
+		}
+	}
+	log.Infof("Context %s imported, use 'eden config set %s' to switch into it", manifest.Name, manifest.Name)
+	return nil
+}
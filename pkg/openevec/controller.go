@@ -7,5 +7,6 @@ type OpenEVEC struct {
 
 // CreateOpenEVEC returns OpenEVEC instance
 func CreateOpenEVEC(cfg *EdenSetupArgs) *OpenEVEC {
+	cfg.Timeouts.setDefaults()
 	return &OpenEVEC{cfg: cfg}
 }
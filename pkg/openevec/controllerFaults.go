@@ -0,0 +1,196 @@
+package openevec
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+func (openEVEC *OpenEVEC) controllerFaultsFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultArtifactsDir, configName, "controller-faults.json"), nil
+}
+
+// ControllerFaultAdd adds fault of controller active for duration (0 for no expiration)
+func (openEVEC *OpenEVEC) ControllerFaultAdd(fault *eden.ControllerFault, duration time.Duration) error {
+	fileName, err := openEVEC.controllerFaultsFile()
+	if err != nil {
+		return err
+	}
+	faults, err := eden.LoadControllerFaults(fileName)
+	if err != nil {
+		return err
+	}
+	if duration > 0 {
+		fault.Until = time.Now().Add(duration)
+	}
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+	last := 0
+	for _, f := range faults {
+		if n, err := strconv.Atoi(strings.TrimPrefix(f.ID, "f")); err == nil && n > last {
+			last = n
+		}
+	}
+	fault.ID = fmt.Sprintf("f%d", last+1)
+	if err := eden.SaveControllerFaults(fileName, append(faults, fault)); err != nil {
+		return fmt.Errorf("cannot save faults: %w", err)
+	}
+	log.Infof("Fault %s added", fault)
+	return nil
+}
+
+// ControllerFaultRemove removes faults of controller with ids, all faults are removed if ids are empty
+func (openEVEC *OpenEVEC) ControllerFaultRemove(ids []string) error {
+	fileName, err := openEVEC.controllerFaultsFile()
+	if err != nil {
+		return err
+	}
+	faults, err := eden.LoadControllerFaults(fileName)
+	if err != nil {
+		return err
+	}
+	var result []*eden.ControllerFault
+	if len(ids) > 0 {
+	faultsLoop:
+		for _, f := range faults {
+			for _, id := range ids {
+				if f.ID == id {
+					continue faultsLoop
+				}
+			}
+			result = append(result, f)
+		}
+	}
+	if err := eden.SaveControllerFaults(fileName, result); err != nil {
+		return fmt.Errorf("cannot save faults: %w", err)
+	}
+	log.Infof("%d faults removed", len(faults)-len(result))
+	return nil
+}
+
+// ControllerFaultList prints faults of controller
+func (openEVEC *OpenEVEC) ControllerFaultList(outputFormat types.OutputFormat) error {
+	fileName, err := openEVEC.controllerFaultsFile()
+	if err != nil {
+		return err
+	}
+	faults, err := eden.LoadControllerFaults(fileName)
+	if err != nil {
+		return err
+	}
+	switch outputFormat {
+	case types.OutputFormatJSON:
+		data, err := json.MarshalIndent(faults, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case types.OutputFormatLines:
+		for _, f := range faults {
+			expired := ""
+			if !f.Until.IsZero() && time.Now().After(f.Until) {
+				expired = " (expired)"
+			}
+			fmt.Printf("%s%s\n", f, expired)
+		}
+	default:
+		return fmt.Errorf("unknown output format: %v", outputFormat)
+	}
+	return nil
+}
+
+// startAdamOnPort recreates container of Adam publishing its API on port
+func (openEVEC *OpenEVEC) startAdamOnPort(port int) error {
+	cfg := openEVEC.cfg
+	redisURL := cfg.Adam.Redis.RemoteURL
+	if !cfg.Adam.Remote.Redis {
+		redisURL = ""
+	}
+	return eden.StartAdam(cfg.Containers().Adam, port, cfg.Adam.Dist, true, cfg.Adam.Tag,
+		redisURL, cfg.Adam.APIv1, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet)
+}
+
+// ControllerFaultProxy moves API of Adam to adamPort and serves proxy injecting faults of controller
+// on port of Adam until interrupted, Adam is moved back to its port on exit, adamPort is adam.port+1 if zero
+func (openEVEC *OpenEVEC) ControllerFaultProxy(ctx context.Context, adamPort int) error {
+	cfg := openEVEC.cfg
+	if adamPort == 0 {
+		adamPort = cfg.Adam.Port + 1
+	}
+	if cfg.Adam.APIv1 {
+		return fmt.Errorf("proxy does not support API v1 of controller which authenticates devices with TLS")
+	}
+	if adamPort == cfg.Adam.Port {
+		return fmt.Errorf("port of Adam behind proxy must differ from adam.port %d", cfg.Adam.Port)
+	}
+	fileName, err := openEVEC.controllerFaultsFile()
+	if err != nil {
+		return err
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	// proxy serves the same certificate as Adam, so EVE does not notice it
+	certsDir := filepath.Join(edenDir, defaults.DefaultCertsDist)
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certsDir, "server.pem"), filepath.Join(certsDir, "server-key.pem"))
+	if err != nil {
+		return fmt.Errorf("cannot load certificate of controller: %w", err)
+	}
+	if err := openEVEC.startAdamOnPort(adamPort); err != nil {
+		return fmt.Errorf("cannot move Adam to port %d: %w", adamPort, err)
+	}
+	defer func() {
+		if err := openEVEC.startAdamOnPort(cfg.Adam.Port); err != nil {
+			log.Errorf("cannot move Adam back to port %d: %s", cfg.Adam.Port, err)
+		} else {
+			log.Infof("Adam is accessible on port %d", cfg.Adam.Port)
+		}
+	}()
+
+	target := &url.URL{Scheme: "https", Host: fmt.Sprintf("127.0.0.1:%d", adamPort)}
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Adam.Port),
+		Handler:           eden.NewControllerFaultProxy(target, fileName),
+		TLSConfig:         &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.ListenAndServeTLS("", "")
+	}()
+	log.Infof("Proxy with faults from %s serves port %d, Adam is moved to port %d", fileName, cfg.Adam.Port, adamPort)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("proxy failed: %w", err)
+		}
+	case <-ctx.Done():
+		log.Info("Proxy stopped")
+	}
+	return server.Close()
+}
@@ -39,12 +39,7 @@ func GetDefaultConfig(projectRootPath string) (*EdenSetupArgs, error) {
 	imageDist := filepath.Join(projectRootPath, defaults.DefaultDist, fmt.Sprintf("%s-%s", defaults.DefaultContext, defaults.DefaultImageDist))
 	certsDist := filepath.Join(projectRootPath, defaults.DefaultDist, fmt.Sprintf("%s-%s", defaults.DefaultContext, defaults.DefaultCertsDist))
 
-	firmware := []string{filepath.Join(imageDist, "eve", "OVMF.fd")}
-	if runtime.GOARCH == "amd64" {
-		firmware = []string{
-			filepath.Join(imageDist, "eve", "firmware", "OVMF_CODE.fd"),
-			filepath.Join(imageDist, "eve", "firmware", "OVMF_VARS.fd")}
-	}
+	firmware := utils.QemuFirmware(imageDist, runtime.GOARCH)
 
 	defaultEdenConfig := &EdenSetupArgs{
 		Eden: EdenConfig{
@@ -116,6 +111,8 @@ func GetDefaultConfig(projectRootPath string) (*EdenSetupArgs, error) {
 			Arch:         runtime.GOARCH,
 			QemuOS:       runtime.GOOS,
 			Accel:        true,
+			TCG:          defaults.DefaultQemuTCG,
+			Accelerator:  defaults.DefaultQemuAccelerator,
 			HV:           defaults.DefaultEVEHV,
 			CertsUUID:    id.String(),
 			Cert:         filepath.Join(certsDist, "onboard.cert.pem"),
@@ -168,6 +165,7 @@ func GetDefaultConfig(projectRootPath string) (*EdenSetupArgs, error) {
 			QemuConfig: QemuConfig{
 				MonitorPort:      defaults.DefaultQemuMonitorPort,
 				NetDevSocketPort: defaults.DefaultQemuNetdevSocketPort,
+				Overlay:          false,
 			},
 		},
 
@@ -200,6 +198,7 @@ func GetDefaultConfig(projectRootPath string) (*EdenSetupArgs, error) {
 			NetModelFile:   "",
 			EnableIPv6:     false,
 			IPv6Subnet:     defaults.DefaultSdnIPv6Subnet,
+			IPv4Subnet:     "",
 		},
 
 		Gcp: GcpConfig{
@@ -210,6 +209,9 @@ func GetDefaultConfig(projectRootPath string) (*EdenSetupArgs, error) {
 			Key: "",
 		},
 
+		Timeouts: defaultTimeouts(),
+		Log:      LogConfig{Format: utils.LogFormatText},
+
 		ConfigName: defaults.DefaultContext,
 		ConfigFile: utils.GetConfig(defaults.DefaultContext),
 		EdenDir:    edenDir,
@@ -0,0 +1,81 @@
+package openevec
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// registerDiagnostics registers pprof endpoints (/debug/pprof/) and expvar
+// endpoint (/debug/vars) to investigate performance of long-running eden
+func registerDiagnostics(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+}
+
+// serveDiagnostics serves diagnostics endpoints on addr until returned function is called
+func serveDiagnostics(addr string) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serve diagnostics: %w", err)
+	}
+	mux := http.NewServeMux()
+	registerDiagnostics(mux)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("diagnostics server: %s", err)
+		}
+	}()
+	log.Infof("Diagnostics are served on http://%s/debug/pprof/ and http://%s/debug/vars", listener.Addr(), listener.Addr())
+	return func() { server.Close() }, nil
+}
+
+// startProfiling writes CPU profile of eden into eden-cpu.pprof inside dir until returned
+// function is called, which writes heap profile into eden-heap.pprof
+func startProfiling(dir string) (func(), error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create directory of profiles: %w", err)
+	}
+	cpu, err := os.Create(filepath.Join(dir, "eden-cpu.pprof"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create CPU profile: %w", err)
+	}
+	if err := runtimepprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, fmt.Errorf("cannot start CPU profile: %w", err)
+	}
+	return func() {
+		runtimepprof.StopCPUProfile()
+		if err := cpu.Close(); err != nil {
+			log.Errorf("cannot save CPU profile: %s", err)
+		}
+		heap, err := os.Create(filepath.Join(dir, "eden-heap.pprof"))
+		if err != nil {
+			log.Errorf("cannot create heap profile: %s", err)
+			return
+		}
+		defer heap.Close()
+		// heap profile reflects the last garbage collection
+		runtime.GC()
+		if err := runtimepprof.WriteHeapProfile(heap); err != nil {
+			log.Errorf("cannot save heap profile: %s", err)
+			return
+		}
+		log.Infof("Profiles are saved into %s", dir)
+	}, nil
+}
@@ -0,0 +1,416 @@
+package openevec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/client"
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// doctorLevel is severity of result of check of environment
+type doctorLevel int
+
+const (
+	doctorOK doctorLevel = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorCheck is result of check of environment with fix of found problem
+type doctorCheck struct {
+	level   doctorLevel
+	name    string
+	message string
+	fix     string
+}
+
+func (check doctorCheck) String() string {
+	mark := statusOK()
+	switch check.level {
+	case doctorWarn:
+		mark = statusWarn()
+	case doctorFail:
+		mark = statusBad()
+	}
+	s := fmt.Sprintf("%s %s: %s", mark, check.name, check.message)
+	if check.fix != "" {
+		s += fmt.Sprintf("\n\tfix: %s", check.fix)
+	}
+	return s
+}
+
+// Doctor checks environment of eden (virtualization, tools, resources, ports and
+// state files) and prints found problems with their fixes, error is returned if
+// some check failed
+func (openEVEC *OpenEVEC) Doctor() error {
+	cfg := openEVEC.cfg
+	var checks []doctorCheck
+	qemu := !cfg.Eve.Remote && cfg.Eve.DevModel == defaults.DefaultQemuModel
+	if qemu {
+		checks = append(checks, doctorKVM(), doctorHVF(), doctorNested(), openEVEC.doctorAccel(), doctorTool("qemu", qemuCommand(cfg.Eve.Arch),
+			fmt.Sprintf("install QEMU (%s), e.g. 'sudo apt install qemu-system-x86' or 'brew install qemu'", qemuCommand(cfg.Eve.Arch))))
+		if cfg.Eve.TPM {
+			checks = append(checks, doctorTool("swtpm", "swtpm",
+				"install swtpm, e.g. 'sudo apt install swtpm' or 'brew install swtpm', or set eve.tpm to false"))
+		}
+	}
+	checks = append(checks, doctorDocker())
+	if qemu {
+		checks = append(checks, openEVEC.doctorDisk(), openEVEC.doctorMemory())
+	}
+	checks = append(checks, openEVEC.doctorPorts()...)
+	checks = append(checks, openEVEC.doctorPidFiles()...)
+
+	failed := 0
+	for _, check := range checks {
+		fmt.Println(check)
+		if check.level == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+func doctorKVM() doctorCheck {
+	check := doctorCheck{name: "KVM"}
+	if runtime.GOOS != "linux" {
+		check.message = fmt.Sprintf("not used on %s", runtime.GOOS)
+		return check
+	}
+	f, err := os.OpenFile("/dev/kvm", os.O_RDWR, 0)
+	switch {
+	case err == nil:
+		f.Close()
+		check.message = "/dev/kvm is available"
+	case os.IsNotExist(err):
+		check.level = doctorFail
+		check.message = "/dev/kvm does not exist, EVE will be emulated and will be very slow"
+		check.fix = "enable virtualization (VT-x/AMD-V) in BIOS or for VM of host and load module with 'sudo modprobe kvm_intel' or 'sudo modprobe kvm_amd'"
+	case os.IsPermission(err):
+		check.level = doctorFail
+		check.message = "no access to /dev/kvm"
+		check.fix = "add user into kvm group with 'sudo usermod -aG kvm $USER' and login again"
+	default:
+		check.level = doctorFail
+		check.message = fmt.Sprintf("cannot open /dev/kvm: %s", err)
+	}
+	return check
+}
+
+func doctorHVF() doctorCheck {
+	check := doctorCheck{name: "HVF"}
+	if runtime.GOOS != "darwin" {
+		check.message = fmt.Sprintf("not used on %s", runtime.GOOS)
+		return check
+	}
+	if utils.QemuHVFSupported() {
+		check.message = fmt.Sprintf("Hypervisor.framework is available on %s", runtime.GOARCH)
+		return check
+	}
+	check.level = doctorFail
+	check.message = "Hypervisor.framework is not available, EVE will be emulated and will be very slow"
+	check.fix = "run on macOS host with virtualization support, not inside of VM without nested virtualization"
+	return check
+}
+
+// doctorAccel reports accelerator EVE VM runs with and if it differs from the recorded one
+func (openEVEC *OpenEVEC) doctorAccel() doctorCheck {
+	cfg := openEVEC.cfg
+	check := doctorCheck{name: "accelerator"}
+	accel, err := cfg.QemuAccelerator()
+	if err != nil {
+		check.level = doctorFail
+		check.message = err.Error()
+		return check
+	}
+	best := utils.DetectAccel().Best(cfg.Eve.Arch)
+	context := strings.TrimSuffix(filepath.Base(cfg.ConfigFile), filepath.Ext(cfg.ConfigFile))
+	switch {
+	case accel == "":
+		check.level = doctorWarn
+		check.message = "acceleration is disabled with eve.accel, EVE will be emulated and will be very slow"
+		check.fix = fmt.Sprintf("run 'eden config set %s --key eve.accel --value true'", context)
+	case accel != best && best != utils.QemuAccelTCG:
+		check.level = doctorWarn
+		check.message = fmt.Sprintf("EVE VM runs with %s, but %s is available", accel, best)
+		check.fix = fmt.Sprintf("run 'eden config set %s --key eve.accelerator --value %s' and 'eden setup'",
+			context, utils.QemuAccelAuto)
+	case accel == utils.QemuAccelTCG:
+		check.message = fmt.Sprintf("EVE %s is emulated with TCG", cfg.Eve.Arch)
+	default:
+		check.message = fmt.Sprintf("EVE VM runs with %s", accel)
+	}
+	return check
+}
+
+func doctorNested() doctorCheck {
+	check := doctorCheck{name: "nested virtualization"}
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		check.message = fmt.Sprintf("not checked on %s/%s", runtime.GOOS, runtime.GOARCH)
+		return check
+	}
+	for _, module := range []string{"kvm_intel", "kvm_amd"} {
+		data, err := os.ReadFile(filepath.Join("/sys/module", module, "parameters", "nested"))
+		if err != nil {
+			continue
+		}
+		if value := strings.TrimSpace(string(data)); value == "Y" || value == "1" {
+			check.message = fmt.Sprintf("enabled in %s", module)
+			return check
+		}
+		check.level = doctorWarn
+		check.message = fmt.Sprintf("disabled in %s, VM-based apps cannot run inside EVE", module)
+		check.fix = fmt.Sprintf("run 'sudo modprobe -r %s && sudo modprobe %s nested=1' with all VMs stopped", module, module)
+		return check
+	}
+	check.level = doctorWarn
+	check.message = "nested parameter of KVM module is not found"
+	return check
+}
+
+// doctorTool checks that tool is installed and reports its version
+func doctorTool(name, command, fix string) doctorCheck {
+	check := doctorCheck{name: name}
+	out, err := exec.Command(command, "--version").Output()
+	if err != nil {
+		check.level = doctorFail
+		check.message = fmt.Sprintf("cannot run %s: %s", command, err)
+		check.fix = fix
+		return check
+	}
+	check.message = strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+	return check
+}
+
+func doctorDocker() doctorCheck {
+	check := doctorCheck{name: "docker"}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err == nil {
+		defer cli.Close()
+		version, err := cli.ServerVersion(context.Background())
+		if err == nil {
+			check.message = fmt.Sprintf("server %s (API %s)", version.Version, version.APIVersion)
+			return check
+		}
+		check.message = fmt.Sprintf("cannot connect to docker: %s", err)
+	} else {
+		check.message = fmt.Sprintf("cannot create docker client: %s", err)
+	}
+	check.level = doctorFail
+	check.fix = "start docker daemon and add user into docker group with 'sudo usermod -aG docker $USER'"
+	return check
+}
+
+func (openEVEC *OpenEVEC) doctorDisk() doctorCheck {
+	cfg := openEVEC.cfg
+	check := doctorCheck{name: "disk"}
+	required := uint64(cfg.Eve.ImageSizeMB)
+	for _, disk := range cfg.Eve.DiskLayout {
+		required += uint64(disk.SizeMB)
+	}
+	required *= humanize.MiByte
+	free, dir, err := freeSpace(filepath.Dir(cfg.Eve.ImageFile))
+	if err != nil {
+		check.level = doctorWarn
+		check.message = fmt.Sprintf("cannot check free space of %s: %s", dir, err)
+		return check
+	}
+	if _, err := os.Stat(cfg.Eve.ImageFile); err == nil {
+		check.message = fmt.Sprintf("%s free in %s, disks of EVE are created", humanize.IBytes(free), dir)
+		return check
+	}
+	check.message = fmt.Sprintf("%s free in %s, disks of EVE need %s", humanize.IBytes(free), dir, humanize.IBytes(required))
+	if free < required {
+		check.level = doctorFail
+		check.fix = "free space or decrease eve.disk in config"
+	}
+	return check
+}
+
+// freeSpace returns free space of file system of dir, directory may be not created
+// yet, so the nearest existing one is checked and returned
+func freeSpace(dir string) (uint64, string, error) {
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, dir, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), dir, nil
+}
+
+// memInfo returns amount of memory from field of /proc/meminfo (e.g. MemAvailable)
+func memInfo(field string) (uint64, error) {
+	if runtime.GOOS != "linux" {
+		return 0, fmt.Errorf("not supported on %s", runtime.GOOS)
+	}
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == field+":" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb * humanize.KiByte, nil
+		}
+	}
+	return 0, fmt.Errorf("%s not found in /proc/meminfo", field)
+}
+
+func (openEVEC *OpenEVEC) doctorMemory() doctorCheck {
+	cfg := openEVEC.cfg
+	check := doctorCheck{name: "memory"}
+	required := uint64(cfg.Eve.QemuMemory)
+	if cfg.IsSdnEnabled() {
+		required += uint64(cfg.Sdn.RAM)
+	}
+	required *= humanize.MiByte
+	available, err := memInfo("MemAvailable")
+	if err != nil {
+		check.level = doctorWarn
+		check.message = fmt.Sprintf("cannot check available memory: %s", err)
+		return check
+	}
+	check.message = fmt.Sprintf("%s available, VMs need %s", humanize.IBytes(available), humanize.IBytes(required))
+	if available < required && !openEVEC.eveRunning() {
+		check.level = doctorFail
+		check.fix = "stop other VMs or decrease eve.ram (and sdn.ram) in config"
+	}
+	return check
+}
+
+// pidRunning returns true if process with pid from pidFile is running,
+// stale is true if pidFile exists, but its process is not running
+func pidRunning(pidFile string) (running, stale bool) {
+	if _, err := os.Stat(pidFile); err != nil {
+		return false, false
+	}
+	status, err := utils.StatusCommandWithPid(pidFile)
+	if err != nil {
+		return false, true
+	}
+	running = strings.HasPrefix(status, "running")
+	return running, !running
+}
+
+func (openEVEC *OpenEVEC) eveRunning() bool {
+	running, _ := pidRunning(openEVEC.cfg.Eve.Pid)
+	return running
+}
+
+func (openEVEC *OpenEVEC) sdnRunning() bool {
+	running, _ := pidRunning(openEVEC.cfg.Sdn.PidFile)
+	return running
+}
+
+func containerRunning(name string) func() bool {
+	return func() bool {
+		state, err := utils.InspectContainer(name)
+		return err == nil && state.Running()
+	}
+}
+
+// doctorPorts checks that ports of context are free or used by eden itself
+func (openEVEC *OpenEVEC) doctorPorts() []doctorCheck {
+	cfg := openEVEC.cfg
+	type port struct {
+		name  string
+		port  int
+		owned func() bool
+	}
+	containers := cfg.Containers()
+	ports := []port{
+		{"adam", cfg.Adam.Port, containerRunning(containers.Adam)},
+		{"redis", cfg.Redis.Port, containerRunning(containers.Redis)},
+		{"registry", cfg.Registry.Port, containerRunning(containers.Registry)},
+		{"eserver", cfg.Eden.EServer.Port, containerRunning(containers.EServer)},
+	}
+	if !cfg.Eve.Remote && cfg.Eve.DevModel == defaults.DefaultQemuModel {
+		ports = append(ports,
+			port{"EVE telnet", cfg.Eve.TelnetPort, openEVEC.eveRunning},
+			port{"QEMU monitor", cfg.Eve.QemuConfig.MonitorPort, openEVEC.eveRunning})
+		if cfg.IsSdnEnabled() {
+			ports = append(ports,
+				port{"SDN telnet", cfg.Sdn.TelnetPort, openEVEC.sdnRunning},
+				port{"SDN ssh", cfg.Sdn.SSHPort, openEVEC.sdnRunning},
+				port{"SDN management", cfg.Sdn.MgmtPort, openEVEC.sdnRunning})
+		} else {
+			for hostPort := range cfg.Eve.HostFwd {
+				if p, err := strconv.Atoi(hostPort); err == nil {
+					ports = append(ports, port{"EVE forwarding", p, openEVEC.eveRunning})
+				}
+			}
+		}
+	}
+	var busy []string
+	for _, p := range ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", p.port))
+		if err == nil {
+			listener.Close()
+			continue
+		}
+		if !p.owned() {
+			busy = append(busy, fmt.Sprintf("%d (%s)", p.port, p.name))
+		}
+	}
+	if len(busy) == 0 {
+		return []doctorCheck{{name: "ports", message: fmt.Sprintf("%d ports are free or used by eden", len(ports))}}
+	}
+	return []doctorCheck{{
+		level:   doctorFail,
+		name:    "ports",
+		message: fmt.Sprintf("used by other processes: %s", strings.Join(busy, ", ")),
+		fix:     "stop processes using ports (see 'sudo ss -tlnp') or create isolated context with own ports with 'eden config add <name> --isolated'",
+	}}
+}
+
+// doctorPidFiles checks pid files of processes of eden left after they stopped
+func (openEVEC *OpenEVEC) doctorPidFiles() []doctorCheck {
+	cfg := openEVEC.cfg
+	pidFiles := map[string]string{
+		"EVE":   cfg.Eve.Pid,
+		"SDN":   cfg.Sdn.PidFile,
+		"swtpm": filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "swtpm", "swtpm.pid"),
+	}
+	var checks []doctorCheck
+	for _, name := range []string{"EVE", "SDN", "swtpm"} {
+		pidFile := pidFiles[name]
+		if _, stale := pidRunning(pidFile); stale {
+			checks = append(checks, doctorCheck{
+				level:   doctorWarn,
+				name:    fmt.Sprintf("%s pid file", name),
+				message: fmt.Sprintf("%s exists, but process is not running", pidFile),
+				fix:     fmt.Sprintf("run 'eden stop' or remove %s", pidFile),
+			})
+		}
+	}
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{name: "pid files", message: "no stale pid files"})
+	}
+	return checks
+}
@@ -0,0 +1,157 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// setupEdenDryRun prints actions of 'eden setup' without performing them
+func setupEdenDryRun(cfg EdenSetupArgs, configDir, zedControlURL string, grubOptions []string, netboot, installer bool) error {
+	if cfg.Eve.DevModel == defaults.DefaultQemuModel {
+		conf, disks, err := qemuConfig(cfg)
+		if err != nil {
+			return err
+		}
+		for _, disk := range disks {
+			utils.DryRunf("create disk %s (%s, %d MB, bus: %s)", disk.File, disk.Format, disk.SizeMB, disk.Bus)
+		}
+		utils.DryRunf("write QEMU config %s:\n%s", cfg.Eve.QemuFileToSave, conf)
+	}
+	if cfg.Eve.CustomInstaller.Path == "" {
+		if _, err := os.Stat(filepath.Join(cfg.Eden.CertsDir, "root-certificate.pem")); os.IsNotExist(err) {
+			utils.DryRunf("generate certificates in %s (domain: %s, IP: %s, EVE IP: %s, UUID: %s)",
+				cfg.Eden.CertsDir, cfg.Adam.CertsDomain, cfg.Adam.CertsIP, cfg.Adam.CertsEVEIP, cfg.Eve.CertsUUID)
+			if len(grubOptions) > 0 {
+				utils.DryRunf("write grub options into %s:\n%s",
+					filepath.Join(cfg.Eden.CertsDir, "grub.cfg"), strings.Join(grubOptions, "\n"))
+			}
+		} else {
+			log.Infof("Certs already exists in certs dir: %s", cfg.Eden.CertsDir)
+		}
+		controller := fmt.Sprintf("%s:%d", cfg.Adam.CertsDomain, cfg.Adam.Port)
+		if zedControlURL != "" {
+			controller = zedControlURL
+		}
+		utils.DryRunf("generate config of EVE in %s for controller %s", cfg.Eden.CertsDir, controller)
+		if _, err := os.Lstat(configDir); err == nil {
+			utils.DryRunf("copy files from %s into %s", configDir, cfg.Eden.CertsDir)
+		}
+	}
+	if err := setupEveDryRun(netboot, installer, cfg); err != nil {
+		return err
+	}
+	utils.DryRunf("write activation scripts into ~/.eden")
+	if cfg.IsSdnEnabled() {
+		utils.DryRunf("pull image %s:%s and extract image of SDN VM into %s",
+			defaults.DefaultEdenSDNContainerRef, cfg.Sdn.Version, cfg.Sdn.ImageFile)
+	}
+	if cfg.Eve.DevModel == defaults.DefaultQemuModel && !cfg.Eve.Remote {
+		caps := utils.DetectAccel()
+		accelerator, err := utils.ParseQemuAccelerator(cfg.Eve.Accelerator)
+		if err != nil {
+			return err
+		}
+		if accelerator == utils.QemuAccelAuto {
+			accelerator = caps.Best(cfg.Eve.Arch)
+		}
+		utils.DryRunf("record capabilities of host (%s) and accelerator %s in %s",
+			caps, accelerator, cfg.ConfigFile)
+	}
+	return nil
+}
+
+// setupEveDryRun prints how image of EVE would be obtained by setup
+func setupEveDryRun(netboot, installer bool, cfg EdenSetupArgs) error {
+	model, err := models.GetDevModelByName(cfg.Eve.DevModel)
+	if err != nil {
+		return fmt.Errorf("GetDevModelByName: %w", err)
+	}
+	imageFormat := model.DiskFormat()
+	eveDesc := setupEveDescription(cfg, imageFormat)
+	imageDir := filepath.Dir(cfg.Eve.ImageFile)
+	switch {
+	case cfg.Eve.CustomInstaller.Path != "":
+		if imageFormat == "qcow2" {
+			utils.DryRunf("extract UEFI firmware of EVE into %s", imageDir)
+		}
+		return nil
+	case cfg.Eve.Source != "":
+		if netboot {
+			return fmt.Errorf("netboot is not supported for EVE built from source")
+		}
+		kind := "live image"
+		if installer {
+			kind = "installer"
+		}
+		utils.DryRunf("checkout %s (ref: %s) into %s, build %s of EVE and copy it into %s",
+			cfg.Eve.Source, cfg.Eve.Ref, cfg.Eve.Dist, kind, cfg.Eve.ImageFile)
+		return nil
+	case !cfg.Eden.Download:
+		if _, err := os.Lstat(cfg.Eve.ImageFile); os.IsNotExist(err) {
+			utils.DryRunf("clone %s (tag: %s) into %s, build EVE and copy it into %s",
+				cfg.Eve.Repo, cfg.Eve.Tag, cfg.Eve.Dist, cfg.Eve.ImageFile)
+		} else {
+			log.Infof("EVE already exists in dir: %s", cfg.Eve.Dist)
+		}
+		return nil
+	}
+	imageTag, err := eveDesc.Image()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(cfg.Eve.ImageFile); err == nil && !netboot {
+		log.Infof("EVE already exists: %s", cfg.Eve.ImageFile)
+		return nil
+	}
+	var verification []string
+	if cfg.Eve.Digest != "" {
+		verification = append(verification, fmt.Sprintf("digest %s", cfg.Eve.Digest))
+	}
+	if cfg.Eve.CosignKey != "" {
+		verification = append(verification, fmt.Sprintf("signature with key %s", cfg.Eve.CosignKey))
+	}
+	if len(verification) > 0 {
+		utils.DryRunf("pull image %s and verify its %s", imageTag, strings.Join(verification, " and "))
+	} else {
+		utils.DryRunf("pull image %s", imageTag)
+	}
+	switch {
+	case netboot:
+		utils.DryRunf("extract netboot artifacts of EVE into %s", imageDir)
+		if err := eden.StartEServer(cfg.Containers().EServer, cfg.Eden.EServer.Port, cfg.Eden.EServer.Images.EServerImageDist,
+			cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
+			return err
+		}
+		utils.DryRunf("upload netboot artifacts and iPXE script %s into eserver",
+			filepath.Join(imageDir, "tftp", "ipxe.efi.cfg"))
+	case installer:
+		utils.DryRunf("extract installer of EVE into %s", cfg.Eve.ImageFile)
+	default:
+		utils.DryRunf("extract live image of EVE (%s, %d MB) into %s", imageFormat, cfg.Eve.ImageSizeMB, cfg.Eve.ImageFile)
+		if imageFormat == "qcow2" {
+			utils.DryRunf("extract UEFI firmware of EVE into %s", imageDir)
+		}
+	}
+	return nil
+}
+
+// sdnDryRun prints SDN VM and network model applied to it instead of starting it
+func sdnDryRun(config edensdn.SdnVMConfig) error {
+	netModel, err := json.MarshalIndent(config.NetModel, "", "  ")
+	if err != nil {
+		return err
+	}
+	utils.DryRunf("start SDN VM from %s (CPUs: %d, RAM: %d MB, management subnet: %s) and apply network model:\n%s",
+		config.ImagePath, config.CPU, config.RAM, config.MgmtSubnet.IPNet, netModel)
+	return nil
+}
@@ -2,17 +2,21 @@ package openevec
 
 import (
 	"compress/gzip"
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
 	"html/template"
 	"io"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/einfo"
@@ -21,6 +25,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/flowlog"
@@ -33,7 +38,18 @@ import (
 	"golang.org/x/term"
 )
 
-func (openEVEC *OpenEVEC) SetupEden(configName, configDir, softSerial, zedControlURL, ipxeOverride string, grubOptions []string, netboot, installer bool) error {
+// SetupEden prepares certificates, configs and images of context running pre-setup and post-setup hooks
+func (openEVEC *OpenEVEC) SetupEden(ctx context.Context, configName, configDir, softSerial, zedControlURL, ipxeOverride string, grubOptions []string, netboot, installer bool) error {
+	if err := openEVEC.runHooks(ctx, HookPreSetup); err != nil {
+		return err
+	}
+	if err := openEVEC.setupEden(configName, configDir, softSerial, zedControlURL, ipxeOverride, grubOptions, netboot, installer); err != nil {
+		return err
+	}
+	return openEVEC.runHooks(ctx, HookPostSetup)
+}
+
+func (openEVEC *OpenEVEC) setupEden(configName, configDir, softSerial, zedControlURL, ipxeOverride string, grubOptions []string, netboot, installer bool) error {
 
 	cfg := *openEVEC.cfg
 
@@ -41,10 +57,14 @@ func (openEVEC *OpenEVEC) SetupEden(configName, configDir, softSerial, zedContro
 		return fmt.Errorf("please use netboot or installer flag, not both")
 	}
 	if netboot || installer {
-		if cfg.Eve.DevModel != defaults.DefaultGeneralModel {
-			return fmt.Errorf("cannot use netboot for devmodel %s, please use general instead", cfg.Eve.DevModel)
+		if cfg.Eve.DevModel != defaults.DefaultGeneralModel && cfg.Eve.DevModel != defaults.DefaultPhysicalModel {
+			return fmt.Errorf("netboot is %w %s, please use general or physical instead", ErrDevmodelUnsupported, cfg.Eve.DevModel)
 		}
 	}
+	grubOptions = append(grubOptions, PersistGrubOptions(cfg.Eve.Arch, cfg.Eve.DiskLayout)...)
+	if utils.IsDryRun() {
+		return setupEdenDryRun(cfg, configDir, zedControlURL, grubOptions, netboot, installer)
+	}
 	if cfg.Eve.DevModel == defaults.DefaultQemuModel {
 		if err := setupQemuConfig(cfg); err != nil {
 			return err
@@ -72,37 +92,79 @@ func (openEVEC *OpenEVEC) SetupEden(configName, configDir, softSerial, zedContro
 		}
 	}
 
+	if cfg.Eve.DevModel == defaults.DefaultQemuModel && !cfg.Eve.Remote {
+		if err := openEVEC.recordAccel(); err != nil {
+			return fmt.Errorf("cannot record acceleration: %w", err)
+		}
+	}
+
 	return nil
 }
 
-func setupQemuConfig(cfg EdenSetupArgs) error {
-	var err error
-	if _, err = os.Stat(cfg.Eve.QemuFileToSave); err == nil || !os.IsNotExist(err) {
-		log.Debugf("QEMU config already exists: %s", cfg.Eve.QemuFileToSave)
+// recordAccel detects virtualization capabilities of the host and records them
+// in the config: the best accelerator of EVE VM replaces auto one and availability
+// of nested virtualization
+func (openEVEC *OpenEVEC) recordAccel() error {
+	cfg := openEVEC.cfg
+	caps := utils.DetectAccel()
+	log.Infof("Virtualization capabilities of host: %s", caps)
+	accelerator, err := utils.ParseQemuAccelerator(cfg.Eve.Accelerator)
+	if err != nil {
+		return err
+	}
+	if accelerator == utils.QemuAccelAuto {
+		accelerator = caps.Best(cfg.Eve.Arch)
+		log.Infof("EVE VM will run with %s accelerator", accelerator)
+	}
+	if accelerator == cfg.Eve.Accelerator && caps.Nested == cfg.Eve.Nested {
+		return nil
+	}
+	viperLoaded, err := utils.LoadConfigFile(cfg.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+	if !viperLoaded {
+		return nil
+	}
+	viper.Set("eve.accelerator", accelerator)
+	viper.Set("eve.nested", caps.Nested)
+	if err = utils.GenerateConfigFileFromViper(); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	cfg.Eve.Accelerator = accelerator
+	cfg.Eve.Nested = caps.Nested
+	return nil
+}
+
+// qemuFirmwareFiles returns absolute paths of firmware files of EVE VM,
+// every item of firmware may contain several files separated by space
+func qemuFirmwareFiles(firmware []string) []string {
+	var files []string
+	for _, line := range firmware {
+		for _, el := range strings.Split(line, " ") {
+			files = append(files, utils.ResolveAbsPath(el))
+		}
 	}
+	return files
+}
+
+// qemuConfig returns QEMU config of EVE VM and its additional disks
+func qemuConfig(cfg EdenSetupArgs) ([]byte, []utils.VMDisk, error) {
+	var err error
 	qemuDTBPathAbsolute := ""
 	if cfg.Eve.QemuDTBPath != "" {
 		qemuDTBPathAbsolute, err = filepath.Abs(cfg.Eve.QemuDTBPath)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
-	var qemuFirmwareParam []string
-	for _, line := range cfg.Eve.QemuFirmware {
-		for _, el := range strings.Split(line, " ") {
-			qemuFirmwareParam = append(qemuFirmwareParam, utils.ResolveAbsPath(el))
-		}
+	qemuFirmwareParam := qemuFirmwareFiles(cfg.Eve.QemuFirmware)
+	qemuDisksParam, err := vmDisks(cfg, "qcow2")
+	if err != nil {
+		return nil, nil, err
 	}
-	if cfg.Eve.CustomInstaller.Path != "" && cfg.Eve.Disks == 0 {
-		return fmt.Errorf("EVE installer requires at least one disK")
-	}
-	var qemuDisksParam []string
-	for ind := 0; ind < cfg.Eve.Disks; ind++ {
-		diskFile := filepath.Join(filepath.Dir(cfg.Eve.ImageFile), fmt.Sprintf("eve-disk-%d.qcow2", ind+1))
-		if err := utils.CreateDisk(diskFile, "qcow2", uint64(cfg.Eve.ImageSizeMB*1024*1024)); err != nil {
-			return err
-		}
-		qemuDisksParam = append(qemuDisksParam, diskFile)
+	if cfg.Eve.CustomInstaller.Path != "" && len(qemuDisksParam) == 0 {
+		return nil, nil, fmt.Errorf("EVE installer requires at least one disK")
 	}
 	settings := utils.QemuSettings{
 		DTBDrive: qemuDTBPathAbsolute,
@@ -112,9 +174,25 @@ func setupQemuConfig(cfg EdenSetupArgs) error {
 		CPUs:     cfg.Eve.QemuCpus,
 	}
 	conf, err := settings.GenerateQemuConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	return conf, qemuDisksParam, nil
+}
+
+func setupQemuConfig(cfg EdenSetupArgs) error {
+	if _, err := os.Stat(cfg.Eve.QemuFileToSave); err == nil || !os.IsNotExist(err) {
+		log.Debugf("QEMU config already exists: %s", cfg.Eve.QemuFileToSave)
+	}
+	conf, disks, err := qemuConfig(cfg)
 	if err != nil {
 		return err
 	}
+	for _, disk := range disks {
+		if err := utils.CreateDisk(disk.File, disk.Format, uint64(disk.SizeMB*1024*1024)); err != nil {
+			return err
+		}
+	}
 	f, err := os.Create(cfg.Eve.QemuFileToSave)
 	if err != nil {
 		return err
@@ -130,13 +208,60 @@ func setupQemuConfig(cfg EdenSetupArgs) error {
 	return nil
 }
 
-func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg EdenSetupArgs) error {
-	model, err := models.GetDevModelByName(cfg.Eve.DevModel)
+// copyBuiltEve copies image of EVE and comma-separated additional files
+// (e.g. firmware) built in repo into place of image of context
+func copyBuiltEve(image, additional, imageFile string) error {
+	if err := utils.CopyFile(image, imageFile); err != nil {
+		return err
+	}
+	for _, additionalFile := range strings.Split(additional, ",") {
+		if additionalFile != "" {
+			if err := utils.CopyFile(additionalFile, filepath.Join(filepath.Dir(imageFile), filepath.Base(additionalFile))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// buildEveFromSource checks out eve.ref of eve.source, builds live (or installer)
+// image of EVE with makefiles of EVE and copies it into eve.image-file.
+// Image is rebuilt on every setup, as make rebuilds only changed packages.
+func buildEveFromSource(installer bool, eveDesc utils.EVEDescription, cfg EdenSetupArgs) error {
+	dir, err := eden.CheckoutEveSource(cfg.Eve.Source, cfg.Eve.Ref, cfg.Eve.Dist)
 	if err != nil {
-		return fmt.Errorf("GetDevModelByName: %w", err)
+		return fmt.Errorf("cannot checkout EVE: %w", err)
 	}
-	imageFormat := model.DiskFormat()
-	eveDesc := utils.EVEDescription{
+	log.Infof("checkout EVE done: %s", dir)
+	version, err := eden.EveBuildVersion(eveDesc, dir)
+	if err != nil {
+		return fmt.Errorf("cannot get version of EVE: %w", err)
+	}
+	var image, additional string
+	if installer {
+		image, err = eden.MakeEveInstallerInRepo(eveDesc, dir)
+	} else {
+		image, additional, err = eden.MakeEveInRepo(eveDesc, dir)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot build EVE: %w", err)
+	}
+	// image of the previous build may be left if make did not produce it
+	for _, file := range append([]string{image}, strings.Split(additional, ",")...) {
+		if file == "" {
+			continue
+		}
+		if err := eden.CheckEveBuild(file, dir, eveDesc.Arch, version); err != nil {
+			return fmt.Errorf("EVE was not built: %w", err)
+		}
+	}
+	log.Infof("build EVE %s done: %s", version, image)
+	return copyBuiltEve(image, additional, cfg.Eve.ImageFile)
+}
+
+// setupEveDescription returns description of EVE image to setup in format
+func setupEveDescription(cfg EdenSetupArgs, imageFormat string) utils.EVEDescription {
+	return utils.EVEDescription{
 		ConfigPath:  cfg.Eden.CertsDir,
 		Arch:        cfg.Eve.Arch,
 		Platform:    cfg.Eve.Platform,
@@ -146,6 +271,15 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		Format:      imageFormat,
 		ImageSizeMB: cfg.Eve.ImageSizeMB,
 	}
+}
+
+func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg EdenSetupArgs) error {
+	model, err := models.GetDevModelByName(cfg.Eve.DevModel)
+	if err != nil {
+		return fmt.Errorf("GetDevModelByName: %w", err)
+	}
+	imageFormat := model.DiskFormat()
+	eveDesc := setupEveDescription(cfg, imageFormat)
 	if cfg.Eve.CustomInstaller.Path != "" {
 		// With installer image already prepared, install only UEFI.
 		if imageFormat == "qcow2" {
@@ -157,6 +291,16 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		}
 		return nil
 	}
+	if cfg.Eve.Source != "" {
+		if netboot {
+			return fmt.Errorf("netboot is not supported for EVE built from source")
+		}
+		if err := buildEveFromSource(installer, eveDesc, cfg); err != nil {
+			return err
+		}
+		log.Infof(model.DiskReadyMessage(), cfg.Eve.ImageFile)
+		return nil
+	}
 	if !cfg.Eden.Download {
 		if _, err := os.Lstat(cfg.Eve.ImageFile); os.IsNotExist(err) {
 			if err := eden.CloneFromGit(cfg.Eve.Dist, cfg.Eve.Repo, cfg.Eve.Tag); err != nil {
@@ -169,17 +313,9 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 				return fmt.Errorf("cannot MakeEveInRepo: %w", err)
 			}
 			log.Info("MakeEveInRepo done")
-			if err = utils.CopyFile(builedImage, cfg.Eve.ImageFile); err != nil {
+			if err = copyBuiltEve(builedImage, builedAdditional, cfg.Eve.ImageFile); err != nil {
 				return err
 			}
-			builedAdditionalSplitted := strings.Split(builedAdditional, ",")
-			for _, additionalFile := range builedAdditionalSplitted {
-				if additionalFile != "" {
-					if err = utils.CopyFile(additionalFile, filepath.Join(filepath.Dir(cfg.Eve.ImageFile), filepath.Base(additionalFile))); err != nil {
-						return err
-					}
-				}
-			}
 			log.Infof(model.DiskReadyMessage(), cfg.Eve.ImageFile)
 		} else {
 			log.Infof("EVE already exists in dir: %s", cfg.Eve.Dist)
@@ -191,11 +327,17 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 	if err != nil {
 		return err
 	}
+	var digests *utils.ArtifactDigests
+	if _, err := os.Lstat(cfg.Eve.ImageFile); netboot || os.IsNotExist(err) {
+		if digests, err = verifyEveImage(cfg, imageTag); err != nil {
+			return err
+		}
+	}
 	if netboot {
 		if err := utils.DownloadEveNetBoot(eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
 			return fmt.Errorf("cannot download EVE: %w", err)
 		}
-		if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.EServer.Images.EServerImageDist,
+		if err := eden.StartEServer(cfg.Containers().EServer, cfg.Eden.EServer.Port, cfg.Eden.EServer.Images.EServerImageDist,
 			cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 			log.Errorf("cannot start eserver: %s", err.Error())
 		} else {
@@ -287,6 +429,9 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		if err != nil {
 			return fmt.Errorf("AddFileIntoEServer: %w", err)
 		}
+		if err := saveEveDigests(cfg, digests); err != nil {
+			return err
+		}
 		log.Infof("download EVE done: %s", imageTag)
 		log.Infof("Please use %s to boot your EVE via ipxe", ipxeConfigFile)
 		log.Infof("ipxe.efi.cfg uploaded to eserver (http://%s:%s/%s). Use it to boot your EVE via network", eServerIP, eServerPort, i.FileName)
@@ -296,6 +441,9 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 			if err := utils.DownloadEveInstaller(eveDesc, cfg.Eve.ImageFile); err != nil {
 				return fmt.Errorf("cannot download EVE: %w", err)
 			}
+			if err := saveEveDigests(cfg, digests, cfg.Eve.ImageFile); err != nil {
+				return err
+			}
 			log.Infof("download EVE done: %s", imageTag)
 			log.Infof(model.DiskReadyMessage(), cfg.Eve.ImageFile)
 		} else {
@@ -309,11 +457,19 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 			}
 			log.Infof("download EVE done: %s", imageTag)
 			log.Infof(model.DiskReadyMessage(), cfg.Eve.ImageFile)
+			files := []string{cfg.Eve.ImageFile}
 			if imageFormat == "qcow2" {
 				if err := utils.DownloadUEFI(eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
 					return fmt.Errorf("cannot download UEFI: %w", err)
 				}
+				if err := utils.VerifyUEFI(eveDesc, filepath.Dir(cfg.Eve.ImageFile), qemuFirmwareFiles(cfg.Eve.QemuFirmware)); err != nil {
+					return fmt.Errorf("verification of UEFI failed: %w", err)
+				}
 				log.Infof("download UEFI done")
+				files = append(files, qemuFirmwareFiles(cfg.Eve.QemuFirmware)...)
+			}
+			if err := saveEveDigests(cfg, digests, files...); err != nil {
+				return err
 			}
 		} else {
 			log.Infof("download EVE done: %s", imageTag)
@@ -323,6 +479,40 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 	return nil
 }
 
+// verifyEveImage pulls docker image of EVE and verifies its digest and signature
+func verifyEveImage(cfg EdenSetupArgs, image string) (*utils.ArtifactDigests, error) {
+	if err := utils.PullImage(image); err != nil {
+		return nil, fmt.Errorf("ImagePull (%s): %w", image, err)
+	}
+	digest, err := utils.VerifyImage(image, cfg.Eve.Digest, cfg.Eve.CosignKey)
+	if err != nil {
+		return nil, fmt.Errorf("verification of EVE failed: %w", err)
+	}
+	return &utils.ArtifactDigests{
+		Image:             image,
+		ImageDigest:       digest,
+		SignatureVerified: cfg.Eve.CosignKey != "",
+	}, nil
+}
+
+// saveEveDigests records digests of image of EVE and SHA256 of files
+// generated from it into digests-<uuid>.yml in eden directory
+func saveEveDigests(cfg EdenSetupArgs, digests *utils.ArtifactDigests, files ...string) error {
+	if digests == nil {
+		return nil
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	digestsFile := filepath.Join(edenDir, fmt.Sprintf("digests-%s.yml", cfg.Eve.CertsUUID))
+	if err := utils.SaveArtifactDigests(digestsFile, digests, files...); err != nil {
+		return fmt.Errorf("cannot save digests of EVE: %w", err)
+	}
+	log.Infof("digests of EVE saved into %s", digestsFile)
+	return nil
+}
+
 func setupEdenScripts(cfg EdenSetupArgs) error {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -456,30 +646,54 @@ func setupSdn(cfg EdenSetupArgs) error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenClean(configName, configDist, vmName string, currentContext bool) error {
+// EdenClean removes EVE, certificates and state of context (or of all contexts with
+// containers of eden if currentContext is not set) running pre-clean and post-clean hooks,
+// opts select what is kept or remove only containers of services
+func (openEVEC *OpenEVEC) EdenClean(ctx context.Context, configName, configDist, vmName string, currentContext bool, opts eden.CleanOptions) error {
+	if opts.Volumes && !opts.ContainersOnly {
+		return fmt.Errorf("volumes can be selected only with containers only, full cleanup removes them unless state is kept")
+	}
+	if err := openEVEC.runHooks(ctx, HookPreClean); err != nil {
+		return err
+	}
+	if err := openEVEC.edenClean(configName, configDist, vmName, currentContext, opts); err != nil {
+		return err
+	}
+	return openEVEC.runHooks(ctx, HookPostClean)
+}
+
+func (openEVEC *OpenEVEC) edenClean(configName, configDist, vmName string, currentContext bool, opts eden.CleanOptions) error {
 	cfg := openEVEC.cfg
 	configSaved := utils.ResolveAbsPath(fmt.Sprintf("%s-%s", configName, defaults.DefaultConfigSaved))
-	if currentContext {
+	switch {
+	case opts.ContainersOnly:
+		log.Info("Cleanup containers of services")
+		if err := eden.CleanContainers(cfg.Containers(), opts.Volumes); err != nil {
+			return fmt.Errorf("cannot CleanContainers: %w", err)
+		}
+	case currentContext:
 		log.Info("Cleanup current context")
 		// we need to delete information about EVE from adam
-		if err := openEVEC.StartRedis(); err != nil {
-			log.Errorf("cannot start redis: %s", err.Error())
-		} else {
-			log.Infof("Redis is running and accessible on port %d", cfg.Redis.Port)
-		}
-		if err := openEVEC.StartAdam(); err != nil {
-			log.Errorf("cannot start adam: %s", err.Error())
-		} else {
-			log.Infof("Adam is running and accessible on port %d", cfg.Adam.Port)
+		if !opts.KeepState && !utils.IsDryRun() {
+			if err := openEVEC.StartRedis(); err != nil {
+				log.Errorf("cannot start redis: %s", err.Error())
+			} else {
+				log.Infof("Redis is running and accessible on port %d", cfg.Redis.Port)
+			}
+			if err := openEVEC.StartAdam(); err != nil {
+				log.Errorf("cannot start adam: %s", err.Error())
+			} else {
+				log.Infof("Adam is running and accessible on port %d", cfg.Adam.Port)
+			}
 		}
 		if err := eden.CleanContext(cfg.Eve.Dist, cfg.Eden.CertsDir, filepath.Dir(cfg.Eve.ImageFile), cfg.Eve.Pid, cfg.Eve.CertsUUID,
-			cfg.Sdn.PidFile, vmName, configSaved, cfg.Eve.Remote, cfg.Sdn.Disable); err != nil {
+			cfg.Sdn.PidFile, vmName, configSaved, cfg.Eve.Remote, cfg.Sdn.Disable, opts); err != nil {
 			return fmt.Errorf("cannot CleanContext: %w", err)
 		}
-	} else {
-		if err := eden.CleanEden(cfg.Eve.Dist, cfg.Adam.Dist, cfg.Eden.CertsDir, filepath.Dir(cfg.Eve.ImageFile),
+	default:
+		if err := eden.CleanEden(cfg.Containers(), cfg.Eve.Dist, cfg.Adam.Dist, cfg.Eden.CertsDir, filepath.Dir(cfg.Eve.ImageFile),
 			cfg.Eden.Images.EServerImageDist, cfg.Redis.Dist, cfg.Registry.Dist, configDist, cfg.Eve.Pid,
-			cfg.Sdn.PidFile, configSaved, cfg.Eve.Remote, cfg.Eve.DevModel, vmName, cfg.Sdn.Disable); err != nil {
+			cfg.Sdn.PidFile, configSaved, cfg.Eve.Remote, cfg.Eve.DevModel, vmName, cfg.Sdn.Disable, opts); err != nil {
 			return fmt.Errorf("cannot CleanEden: %w", err)
 		}
 	}
@@ -487,11 +701,18 @@ func (openEVEC *OpenEVEC) EdenClean(configName, configDist, vmName string, curre
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uint, follow bool, printFields []string, args []string) error {
-	changer := &adamChanger{}
-	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uint, follow, offline bool, printFields, filters []string, args []string) error {
+	if follow && offline {
+		return fmt.Errorf("follow is not supported in offline mode")
+	}
+	for _, filter := range filters {
+		if err := utils.ValidateJSONPath(filter); err != nil {
+			return fmt.Errorf("invalid filter %q: %w", filter, err)
+		}
+	}
+	ctrl, devFirst, err := openEVEC.getControllerAndDev(offline)
 	if err != nil {
-		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+		return err
 	}
 	devUUID := devFirst.GetID()
 	q := make(map[string]string)
@@ -500,11 +721,32 @@ func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uin
 		q[s[0]] = s[1]
 	}
 
+	var lastSelected string
+	var selectErr error
 	handleInfo := func(im *info.ZInfoMsg) bool {
-		if printFields == nil {
-			einfo.ZInfoPrn(im, outputFormat)
-		} else {
+		switch {
+		case filters != nil:
+			selected, err := einfo.ZInfoSelect(im, filters)
+			if err != nil {
+				selectErr = err
+				return true
+			}
+			if len(*selected) == 0 {
+				return false
+			}
+			// in follow mode we want to see only changes of selected fields
+			if follow {
+				current := fmt.Sprint(*selected)
+				if current == lastSelected {
+					return false
+				}
+				lastSelected = current
+			}
+			selected.Print()
+		case printFields != nil:
 			einfo.ZInfoPrintFiltered(im, printFields).Print()
+		default:
+			einfo.ZInfoPrn(im, outputFormat)
 		}
 		return false
 	}
@@ -523,6 +765,73 @@ func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uin
 			}
 		}
 	}
+	return selectErr
+}
+
+// EdenInfoDiff prints differences between consecutive info messages about the same object.
+// If since or until are not zero, only messages inside the time window are compared,
+// otherwise last 'last' messages of every object are used.
+func (openEVEC *OpenEVEC) EdenInfoDiff(last uint, since, until time.Time, showTimestamps bool, args []string) error {
+	changer := &adamChanger{}
+	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	devUUID := devFirst.GetID()
+	q := make(map[string]string)
+	for _, a := range args[0:] {
+		s := strings.Split(a, ":")
+		q[s[0]] = s[1]
+	}
+	if last < 2 {
+		last = 2
+	}
+	window := !since.IsZero() || !until.IsZero()
+
+	// keep order of objects as they appear in info stream
+	var keys []string
+	messages := make(map[string][]*info.ZInfoMsg)
+	handleInfo := func(im *info.ZInfoMsg) bool {
+		if window {
+			ts := im.GetAtTimeStamp().AsTime()
+			if (!since.IsZero() && ts.Before(since)) || (!until.IsZero() && ts.After(until)) {
+				return false
+			}
+		}
+		key := einfo.ZInfoObjectKey(im)
+		if _, ok := messages[key]; !ok {
+			keys = append(keys, key)
+		}
+		messages[key] = append(messages[key], im)
+		if !window && uint(len(messages[key])) > last {
+			messages[key] = messages[key][1:]
+		}
+		return false
+	}
+	if err = ctrl.InfoLastCallback(devUUID, q, handleInfo); err != nil {
+		return fmt.Errorf("InfoLastCallback: %w", err)
+	}
+
+	skip := einfo.IsNoisyInfoField
+	if showTimestamps {
+		skip = nil
+	}
+	for _, key := range keys {
+		objMessages := messages[key]
+		for i := 1; i < len(objMessages); i++ {
+			diffs, err := einfo.ZInfoDiff(objMessages[i-1], objMessages[i], skip)
+			if err != nil {
+				return fmt.Errorf("ZInfoDiff: %w", err)
+			}
+			if len(diffs) == 0 {
+				continue
+			}
+			fmt.Printf("--- %s %s -> %s\n", key,
+				objMessages[i-1].GetAtTimeStamp().AsTime().Format(time.RFC3339),
+				objMessages[i].GetAtTimeStamp().AsTime().Format(time.RFC3339))
+			fmt.Print(utils.RenderJSONDiff(diffs, utils.DefaultDiffOptions()))
+		}
+	}
 	return nil
 }
 
@@ -655,17 +964,142 @@ func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow boo
 	return nil
 }
 
+// EdenTop shows resource usage of device and applications in terminal redrawing it on every
+// new metric until quit key is pressed or ctx is done. If once is set it prints usage from
+// existing metrics and returns.
+func (openEVEC *OpenEVEC) EdenTop(ctx context.Context, once bool) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	state := eve.Init(ctrl, dev)
+	top := eve.NewTop(state)
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+		return fmt.Errorf("InfoLastCallback: %w", err)
+	}
+	metricCallback := top.MetricCallback()
+	if err := ctrl.MetricLastCallback(dev.GetID(), nil, metricCallback); err != nil {
+		return fmt.Errorf("MetricLastCallback: %w", err)
+	}
+	if once {
+		return top.Print(os.Stdout)
+	}
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return fmt.Errorf("top requires terminal, use --once to print usage")
+	}
+	cols, rows, err := term.GetSize(outFd)
+	if err != nil {
+		return fmt.Errorf("cannot get size of terminal: %w", err)
+	}
+	screen := eve.NewTopScreen(top, os.Stdout, cols, rows)
+	oldState, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("cannot set terminal into raw mode: %w", err)
+	}
+	defer func() {
+		_ = term.Restore(inFd, oldState)
+	}()
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer func() {
+		signal.Stop(resize)
+		close(resize)
+	}()
+	go func() {
+		for range resize {
+			if cols, rows, err := term.GetSize(outFd); err == nil {
+				screen.Resize(cols, rows)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// info and metrics are processed in different goroutines, screen serializes updates
+	infoCallback := state.InfoCallback()
+	go func() {
+		err := ctrl.InfoWatch(ctx, dev.GetID(), nil, func(im *info.ZInfoMsg) bool {
+			screen.Update(func() { infoCallback(im) })
+			return false
+		})
+		if err != nil && ctx.Err() == nil {
+			screen.SetStatus(fmt.Sprintf("InfoWatch: %s", err))
+		}
+	}()
+	go func() {
+		err := ctrl.MetricWatch(ctx, dev.GetID(), nil, func(mm *metrics.ZMetricMsg) bool {
+			screen.Update(func() { metricCallback(mm) })
+			return false
+		})
+		if err != nil && ctx.Err() == nil {
+			screen.SetStatus(fmt.Sprintf("MetricWatch: %s", err))
+		}
+	}()
+	return screen.Run(ctx, os.Stdin)
+}
+
+// EdenMetricAlert evaluates alert rules from rulesFile against incoming metrics
+// and runs their actions. It returns error when rule with fail action fires
+// or after timeout if it is not zero.
+func (openEVEC *OpenEVEC) EdenMetricAlert(ctx context.Context, rulesFile string, timeout time.Duration) error {
+	rules, err := emetric.LoadAlertRules(rulesFile)
+	if err != nil {
+		return fmt.Errorf("cannot load alert rules: %w", err)
+	}
+	changer := &adamChanger{}
+	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	evaluator := emetric.NewAlertEvaluator(rules)
+	var failed error
+	handleFunc := func(mm *metrics.ZMetricMsg) bool {
+		alerts, err := evaluator.Process(mm)
+		if err != nil {
+			failed = err
+			return true
+		}
+		for _, alert := range alerts {
+			switch alert.Rule.Action {
+			case emetric.AlertActionWebhook:
+				log.Warn(alert)
+				if err := alert.PostWebhook(); err != nil {
+					log.Errorf("cannot send alert: %s", err)
+				}
+			case emetric.AlertActionFail:
+				failed = fmt.Errorf("%s", alert)
+				return true
+			default:
+				log.Warn(alert)
+			}
+		}
+		return false
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err = ctrl.MetricWatch(ctx, devFirst.GetID(), nil, handleFunc); err != nil && failed == nil {
+		return fmt.Errorf("MetricWatch: %w", err)
+	}
+	return failed
+}
+
 func (openEVEC *OpenEVEC) EdenExport(tarFile string) error {
 	cfg := openEVEC.cfg
 	changer := &adamChanger{}
 	// we need to obtain information about EVE from Adam
-	if err := eden.StartRedis(cfg.Redis.Port, cfg.Redis.Dist, false, cfg.Redis.Tag,
+	if err := eden.StartRedis(cfg.Containers().Redis, cfg.Redis.Port, cfg.Redis.Dist, false, cfg.Redis.Tag,
 		cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		return fmt.Errorf("cannot start redis: %w", err)
 	} else {
 		log.Infof("Redis is running and accessible on port %d", cfg.Redis.Port)
 	}
-	if err := eden.StartAdam(cfg.Adam.Port, cfg.Adam.Dist, false, cfg.Adam.Tag,
+	if err := eden.StartAdam(cfg.Containers().Adam, cfg.Adam.Port, cfg.Adam.Dist, false, cfg.Adam.Tag,
 		cfg.Adam.Redis.RemoteURL, cfg.Adam.APIv1, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		return fmt.Errorf("cannot start adam: %w", err)
 	} else {
@@ -730,18 +1164,24 @@ func (openEVEC *OpenEVEC) EdenImport(tarFile string, rewriteRoot bool) error {
 		}
 	}
 	// we need to put information about EVE into Adam
-	if err := eden.StartRedis(cfg.Redis.Port, cfg.Redis.Dist, false, cfg.Redis.Tag,
+	if err := eden.StartRedis(cfg.Containers().Redis, cfg.Redis.Port, cfg.Redis.Dist, false, cfg.Redis.Tag,
 		cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		log.Errorf("cannot start redis: %s", err.Error())
 	} else {
 		log.Infof("Redis is running and accessible on port %d", cfg.Redis.Port)
 	}
-	if err := eden.StartAdam(cfg.Adam.Port, cfg.Adam.Dist, false, cfg.Adam.Tag,
+	if err := eden.StartAdam(cfg.Containers().Adam, cfg.Adam.Port, cfg.Adam.Dist, false, cfg.Adam.Tag,
 		cfg.Adam.Redis.RemoteURL, cfg.Adam.APIv1, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		log.Errorf("cannot start adam: %s", err.Error())
 	} else {
 		log.Infof("Adam is running and accessible on port %d", cfg.Adam.Port)
 	}
+	return registerImportedDevice(cfg)
+}
+
+// registerImportedDevice uploads certificate of device of imported context
+// into Adam if device is not registered there
+func registerImportedDevice(cfg *EdenSetupArgs) error {
 	changer := &adamChanger{}
 	ctrl, err := changer.getController()
 	if err != nil {
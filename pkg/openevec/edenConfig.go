@@ -1,32 +1,21 @@
 package openevec
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
-// optionsWithMapTypeList contains options that should be encoded
-var optionsWithMapTypeList = []string{"eve.hostfwd"}
-
-func isEncodingNeeded(contextKeySet string) bool {
-	for _, k := range optionsWithMapTypeList {
-		if contextKeySet != k {
-			continue
-		}
-		return true
-	}
-	return false
-}
-
 func ReloadConfigDetails(cfg *EdenSetupArgs) error {
 	viperLoaded, err := utils.LoadConfigFile(cfg.ConfigFile)
 	if err != nil {
@@ -108,13 +97,7 @@ func ConfigAdd(cfg *EdenSetupArgs, currentContext, contextFile string, force boo
 	if cfg.Eve.Arch != "" {
 		viper.Set("eve.arch", cfg.Eve.Arch)
 		imageDist := fmt.Sprintf("%s-%s", context.Current, defaults.DefaultImageDist)
-		switch cfg.Eve.Arch {
-		case "amd64":
-			viper.Set("eve.firmware", []string{filepath.Join(imageDist, "eve", "firmware", "OVMF_CODE.fd"),
-				filepath.Join(imageDist, "eve", "firmware", "OVMF_VARS.fd")})
-		case "arm64":
-			viper.Set("eve.firmware", []string{filepath.Join(imageDist, "eve", "firmware", "OVMF.fd")})
-		}
+		viper.Set("eve.firmware", utils.QemuFirmware(imageDist, cfg.Eve.Arch))
 	}
 	if cfg.Eve.Ssid != "" {
 		viper.Set("eve.ssid", cfg.Eve.Ssid)
@@ -142,6 +125,45 @@ func ConfigAdd(cfg *EdenSetupArgs, currentContext, contextFile string, force boo
 	return nil
 }
 
+// ConfigClone adds context dst with settings of context src, host resources
+// of dst are isolated, so both contexts can run at the same time
+func ConfigClone(src, dst string) error {
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	contexts := context.ListContexts()
+	if !slices.Contains(contexts, src) {
+		return fmt.Errorf("context not found %s", src)
+	}
+	if slices.Contains(contexts, dst) {
+		return fmt.Errorf("context %s already exists", dst)
+	}
+	context.Current = src
+	cfg, err := LoadConfig(context.GetCurrentConfig())
+	if err != nil {
+		return err
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	cfg.Eve.CertsUUID = id.String()
+	if err := IsolateContext(cfg, dst); err != nil {
+		return fmt.Errorf("cannot isolate context %s: %w", dst, err)
+	}
+	context.Current = dst
+	file, err := os.Create(context.GetCurrentConfig())
+	if err != nil {
+		return fmt.Errorf("Error creating file %v", err)
+	}
+	defer file.Close()
+
+	WriteConfig(reflect.ValueOf(cfg), cfg.Eden.Root, file, 0)
+	log.Infof("Context %s cloned into %s (slot %d)", src, dst, cfg.Eden.Slot)
+	return nil
+}
+
 func ConfigList() error {
 	context, err := utils.ContextLoad()
 	if err != nil {
@@ -164,19 +186,13 @@ func ValidateConfigFromViper() error {
 	if err := viper.Unmarshal(cfg); err != nil {
 		return fmt.Errorf("unable to decode into config struct, %w", err)
 	}
-	return nil
-}
-
-func processConfigKeyValue(contextKeySet, contextValueSet string) (interface{}, error) {
-	if isEncodingNeeded(contextKeySet) {
-		obj := make(map[string]interface{})
-		err := json.Unmarshal([]byte(contextValueSet), &obj)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode %s: %s", contextKeySet, err)
-		}
-		return obj, nil
+	if _, err := utils.QemuTCG(cfg.Eve.TCG, cfg.Eve.Arch); err != nil {
+		return err
+	}
+	if _, err := utils.ParseQemuAccelerator(cfg.Eve.Accelerator); err != nil {
+		return err
 	}
-	return contextValueSet, nil
+	return nil
 }
 
 func ConfigSet(target, contextKeySet, contextValueSet string) error {
@@ -188,9 +204,11 @@ func ConfigSet(target, contextKeySet, contextValueSet string) error {
 	if contextKeySet != "" {
 		defer context.SetContext(oldContext) // restore context after modifications
 	}
-	objToStore, err := processConfigKeyValue(contextKeySet, contextValueSet)
-	if err != nil {
-		return fmt.Errorf("processConfigKeyValue error: %w", err)
+	var objToStore interface{}
+	if contextKeySet != "" {
+		if objToStore, err = ParseConfigValue(contextKeySet, contextValueSet); err != nil {
+			return err
+		}
 	}
 	contexts := context.ListContexts()
 	for _, el := range contexts {
@@ -201,6 +219,14 @@ func ConfigSet(target, contextKeySet, contextValueSet string) error {
 				if err != nil {
 					return fmt.Errorf("error reading config: %w", err)
 				}
+				if contextKeySet == "eve.arch" {
+					// switch firmware to the new architecture of EVE unless it is customized
+					imageDist := fmt.Sprintf("%s-%s", el, defaults.DefaultImageDist)
+					if slices.Equal(viper.GetStringSlice("eve.firmware"),
+						utils.QemuFirmware(imageDist, viper.GetString("eve.arch"))) {
+						viper.Set("eve.firmware", utils.QemuFirmware(imageDist, contextValueSet))
+					}
+				}
 				viper.Set(contextKeySet, objToStore)
 				if err = ValidateConfigFromViper(); err != nil {
 					return fmt.Errorf("ValidateConfigFromViper: %w", err)
@@ -272,7 +298,9 @@ func ConfigReset(target string) error {
 	return fmt.Errorf("context not found %s", contextNameReset)
 }
 
-func ConfigGet(target string, contextKeyGet string, contextAllGet bool) error {
+// ConfigGet prints name of context target (or of current one if empty), value of config selected
+// with JSONPath-like query (e.g. eve.hostfwd or eve.disk-layout[0].size) or the whole config if all is set
+func ConfigGet(target, query string, all bool, format types.OutputFormat) error {
 	context, err := utils.ContextLoad()
 	if err != nil {
 		return fmt.Errorf("load context error: %w", err)
@@ -298,20 +326,28 @@ func ConfigGet(target string, contextKeyGet string, contextAllGet bool) error {
 		}
 	}
 	switch {
-	case contextKeyGet == "" && !contextAllGet:
+	case query == "" && !all:
 		fmt.Println(context.Current)
-	case contextKeyGet != "":
-		item := viper.Get(contextKeyGet)
-		if isEncodingNeeded(contextKeyGet) {
-			result, err := json.Marshal(item)
-			if err != nil {
-				return err
-			}
-			fmt.Println(string(result))
-		} else {
-			fmt.Println(item)
+	case query != "":
+		settings, err := configSettings()
+		if err != nil {
+			return err
 		}
-	case contextAllGet:
+		values, err := utils.SelectJSONPath(settings, query)
+		if err != nil {
+			return fmt.Errorf("cannot apply query %q: %w", query, err)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("%s not found in config of context %s", query, context.Current)
+		}
+		return printConfigValues(values, query, format)
+	case format != types.OutputFormatLines:
+		settings, err := configSettings()
+		if err != nil {
+			return err
+		}
+		return printConfigValues([]interface{}{settings}, "", format)
+	default:
 		if err = viper.WriteConfigAs(defaults.DefaultConfigHidden); err != nil {
 			return err
 		}
@@ -354,5 +390,8 @@ func ConfigDelete(target string, cfg *EdenSetupArgs) error {
 	if err := os.Remove(configFile); err != nil {
 		return fmt.Errorf("cannot delete context %s: %s", target, err)
 	}
+	if err := ReleasePorts(target); err != nil {
+		log.Warnf("cannot release ports of context %s: %s", target, err)
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package openevec
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/types"
@@ -9,6 +10,8 @@ import (
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/evecommon"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -31,7 +34,9 @@ func (openEVEC *OpenEVEC) NetworkLs(outputFormat types.OutputFormat) error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) NetworkDelete(niName string) error {
+// NetworkDelete removes network instance with name niName from EVE, it
+// refuses to remove network instance used by applications unless force is set
+func (openEVEC *OpenEVEC) NetworkDelete(niName string, force bool) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -43,6 +48,19 @@ func (openEVEC *OpenEVEC) NetworkDelete(niName string) error {
 			return fmt.Errorf("no network in cloud %s: %w", el, err)
 		}
 		if ni.Displayname == niName {
+			if !force {
+				for _, appID := range dev.GetApplicationInstances() {
+					app, err := ctrl.GetApplicationInstanceConfig(appID)
+					if err != nil {
+						return fmt.Errorf("no app in cloud %s: %w", appID, err)
+					}
+					for _, intf := range app.Interfaces {
+						if intf.NetworkId == el {
+							return fmt.Errorf("network %s is used by app %s, delete the app first or use force", niName, app.Displayname)
+						}
+					}
+				}
+			}
 			configs := dev.GetNetworkInstances()
 			utils.DelEleInSlice(&configs, id)
 			dev.SetNetworkInstanceConfig(configs)
@@ -93,14 +111,20 @@ func (openEVEC *OpenEVEC) NetworkNetstat(niName string, outputFormat types.Outpu
 	return nil
 }
 
+// NetworkCreate creates network instance of networkType (local or switch) in
+// EVE, gateway, DNS servers and DHCP range of local network instance are
+// derived from subnet if not defined
 func (openEVEC *OpenEVEC) NetworkCreate(subnet, networkType, networkName, uplinkAdapter string,
-	staticDNSEntries []string, enableFlowlog bool) error {
+	staticDNSEntries []string, enableFlowlog bool, gateway string, dnsServers []string, dhcpRange string) error {
 	if networkType != "local" && networkType != "switch" {
 		return fmt.Errorf("network type %s not supported now", networkType)
 	}
 	if networkType == "local" && subnet == "" {
 		return fmt.Errorf("you must define subnet as first arg for local network")
 	}
+	if networkType == "switch" && (gateway != "" || len(dnsServers) > 0 || dhcpRange != "") {
+		return fmt.Errorf("gateway, DNS servers and DHCP range are supported only for local network")
+	}
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -109,6 +133,7 @@ func (openEVEC *OpenEVEC) NetworkCreate(subnet, networkType, networkName, uplink
 	var opts []expect.ExpectationOption
 	opts = append(opts, expect.AddNetInstanceAndPortPublish(subnet, networkType, networkName, nil, uplinkAdapter))
 	opts = append(opts, expect.WithStaticDNSEntries(networkName, staticDNSEntries))
+	opts = append(opts, expect.WithNetInstanceIPConfig(networkName, gateway, dnsServers, dhcpRange))
 	if enableFlowlog {
 		opts = append(opts, expect.WithFlowlog(networkName))
 	}
@@ -131,3 +156,87 @@ mainloop:
 
 	return nil
 }
+
+// NetworkModifyArgs defines changes of network instance, empty fields keep
+// current settings
+type NetworkModifyArgs struct {
+	// Uplink is name of uplink adapter, 'none' to not use uplink
+	Uplink string
+	// Gateway, DNSServers and DHCPRange (in format START-END) are applicable
+	// only to local network instance
+	Gateway    string
+	DNSServers []string
+	DHCPRange  string
+	// StaticDNSEntries replace static DNS entries if not nil
+	StaticDNSEntries []string
+	// Flowlog enables or disables flow logging if not nil
+	Flowlog *bool
+}
+
+// NetworkModify changes settings of network instance with name niName and
+// increments its version to make EVE apply them
+func (openEVEC *OpenEVEC) NetworkModify(niName string, args NetworkModifyArgs) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ni, err := findNetworkInstance(ctrl, dev, niName)
+	if err != nil {
+		return err
+	}
+	if args.Gateway != "" || len(args.DNSServers) > 0 || args.DHCPRange != "" {
+		if ni.InstType != config.ZNetworkInstType_ZnetInstLocal {
+			return fmt.Errorf("gateway, DNS servers and DHCP range are supported only for local network")
+		}
+		gateway, dnsServers, dhcpRange := args.Gateway, args.DNSServers, args.DHCPRange
+		if gateway == "" {
+			gateway = ni.Ip.GetGateway()
+		}
+		if len(dnsServers) == 0 {
+			dnsServers = ni.Ip.GetDns()
+		}
+		if dhcpRange == "" && ni.Ip.GetDhcpRange() != nil {
+			dhcpRange = fmt.Sprintf("%s-%s", ni.Ip.DhcpRange.Start, ni.Ip.DhcpRange.End)
+		}
+		ipSpec, err := expect.NetInstanceIPSpec(ni.Ip.GetSubnet(), gateway, dnsServers, dhcpRange)
+		if err != nil {
+			return err
+		}
+		ni.Ip = ipSpec
+	}
+	switch args.Uplink {
+	case "":
+	case "none":
+		ni.Port = nil
+	default:
+		ni.Port = &config.Adapter{
+			Name: args.Uplink,
+			Type: evecommon.PhyIoType_PhyIoNetEth,
+		}
+	}
+	if args.StaticDNSEntries != nil {
+		ni.Dns = nil
+		for _, entry := range args.StaticDNSEntries {
+			hostname, ips, found := strings.Cut(entry, ":")
+			if !found {
+				return fmt.Errorf("static DNS entry must be in format HOSTNAME:IP_ADDR,IP_ADDR,...: %s", entry)
+			}
+			ni.Dns = append(ni.Dns, &config.ZnetStaticDNSEntry{
+				HostName: hostname,
+				Address:  strings.Split(ips, ","),
+			})
+		}
+	}
+	if args.Flowlog != nil {
+		ni.DisableFlowlog = !*args.Flowlog
+	}
+	if err = incNetworkInstanceVersion(ni); err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("network %s modify done", niName)
+	return nil
+}
@@ -87,6 +87,7 @@ func (openEVEC *OpenEVEC) VolumeCreate(appLink, registry, diskSize, volumeName,
 			registryToUse = ""
 		}
 		opts = append(opts, expect.WithRegistry(registryToUse))
+		opts = append(opts, expect.WithRegistryAuth(openEVEC.cfg.Eden.RegistryAuth))
 		expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, volumeName, opts...)
 		volumeConfig := expectation.Volume()
 		log.Infof("create volume %s with %s request sent", volumeConfig.DisplayName, appLink)
@@ -306,6 +306,9 @@ func (openEVEC *OpenEVEC) EdgeNodeSetConfig(fileWithConfig string) error {
 	if err := protojson.Unmarshal(newConfig, &dConfig); err != nil {
 		return fmt.Errorf("cannot unmarshal config: %w", err)
 	}
+	if err := controller.ValidateEdgeDevConfig(&dConfig); err != nil {
+		return err
+	}
 	// Adam expects json type
 	cfg, err := proto.Marshal(&dConfig)
 	if err != nil {
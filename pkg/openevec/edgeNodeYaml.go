@@ -0,0 +1,246 @@
+package openevec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// EdgeDevConfigToYAML returns yaml representation of device config with fields
+// in order of their definition in EVE API and sorted keys of maps, comments
+// of fields existing in previous yaml representation are moved into result
+func EdgeDevConfigToYAML(devConfig *config.EdgeDevConfig, previous []byte) ([]byte, error) {
+	data, err := protojson.Marshal(devConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal config: %w", err)
+	}
+	// json is yaml, so we can obtain yaml tree with order of fields kept
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("cannot convert config to yaml: %w", err)
+	}
+	resetYAMLStyle(&node)
+	if len(previous) > 0 {
+		var previousNode yaml.Node
+		if err := yaml.Unmarshal(previous, &previousNode); err != nil {
+			return nil, fmt.Errorf("cannot parse previous config: %w", err)
+		}
+		copyYAMLComments(&node, &previousNode)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EdgeDevConfigFromYAML parses device config from its yaml representation
+func EdgeDevConfigFromYAML(data []byte) (*config.EdgeDevConfig, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("cannot parse yaml: %w", err)
+	}
+	value, err := yamlNodeValue(&node)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(value); err != nil {
+		return nil, err
+	}
+	var devConfig config.EdgeDevConfig
+	if err := protojson.Unmarshal(data, &devConfig); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	return &devConfig, nil
+}
+
+// resetYAMLStyle drops flow and quoting style inherited from json
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, el := range node.Content {
+		resetYAMLStyle(el)
+	}
+}
+
+// copyYAMLComments moves comments from nodes of src into nodes of dst with the same path
+func copyYAMLComments(dst, src *yaml.Node) {
+	if dst.Kind != src.Kind {
+		return
+	}
+	dst.HeadComment, dst.LineComment, dst.FootComment = src.HeadComment, src.LineComment, src.FootComment
+	switch dst.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i := 0; i < len(dst.Content) && i < len(src.Content); i++ {
+			copyYAMLComments(dst.Content[i], src.Content[i])
+		}
+	case yaml.MappingNode:
+		srcPairs := make(map[string]int, len(src.Content)/2)
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			srcPairs[src.Content[i].Value] = i
+		}
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			if j, ok := srcPairs[dst.Content[i].Value]; ok {
+				copyYAMLComments(dst.Content[i], src.Content[j])
+				copyYAMLComments(dst.Content[i+1], src.Content[j+1])
+			}
+		}
+	}
+}
+
+// yamlNodeValue converts yaml tree into value suitable to marshal into json
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		return yamlNodeValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias)
+	case yaml.MappingNode:
+		result := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlNodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			result[node.Content[i].Value] = value
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := make([]interface{}, 0, len(node.Content))
+		for _, el := range node.Content {
+			value, err := yamlNodeValue(el)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool", "!!int", "!!float":
+			var value interface{}
+			if err := node.Decode(&value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		default:
+			return node.Value, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected yaml node at line %d", node.Line)
+}
+
+// EdgeNodeGet prints config of device in yaml format or writes it into
+// fileWithConfig keeping comments of existing file
+func (openEVEC *OpenEVEC) EdgeNodeGet(controllerMode, fileWithConfig string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	res, err := ctrl.GetConfigBytes(dev, false)
+	if err != nil {
+		return fmt.Errorf("GetConfigBytes error: %w", err)
+	}
+	var devConfig config.EdgeDevConfig
+	if err = proto.Unmarshal(res, &devConfig); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	var previous []byte
+	if fileWithConfig != "" {
+		if previous, err = os.ReadFile(fileWithConfig); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("file reading error: %w", err)
+		}
+	}
+	out, err := EdgeDevConfigToYAML(&devConfig, previous)
+	if err != nil {
+		return err
+	}
+	if fileWithConfig != "" {
+		if err = os.WriteFile(fileWithConfig, out, 0644); err != nil {
+			return fmt.Errorf("writeFile: %w", err)
+		}
+		return nil
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
+// EdgeNodeApply pushes config of device from yaml file (or stdin) into
+// controller, version of config is incremented if it differs from current one
+func (openEVEC *OpenEVEC) EdgeNodeApply(fileWithConfig string) error {
+	var data []byte
+	var err error
+	switch {
+	case fileWithConfig != "" && fileWithConfig != "-":
+		if data, err = os.ReadFile(fileWithConfig); err != nil {
+			return fmt.Errorf("file reading error: %w", err)
+		}
+	case utils.IsInputFromPipe():
+		if data, err = io.ReadAll(os.Stdin); err != nil {
+			return fmt.Errorf("stdin reading error: %w", err)
+		}
+	default:
+		return fmt.Errorf("please run command with --file or use it with pipe")
+	}
+	devConfig, err := EdgeDevConfigFromYAML(data)
+	if err != nil {
+		return err
+	}
+	if err = controller.ValidateEdgeDevConfig(devConfig); err != nil {
+		return err
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	if uuid := devConfig.GetId().GetUuid(); uuid != "" && uuid != dev.GetID().String() {
+		return fmt.Errorf("config is for device %s, but current device is %s", uuid, dev.GetID())
+	}
+	res, err := ctrl.GetConfigBytes(dev, false)
+	if err != nil {
+		return fmt.Errorf("GetConfigBytes error: %w", err)
+	}
+	var current config.EdgeDevConfig
+	if err = proto.Unmarshal(res, &current); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	devConfig.Id = current.Id
+	if proto.Equal(devConfig, &current) {
+		fmt.Println("config is up to date")
+		return nil
+	}
+	version, _ := strconv.Atoi(current.GetId().GetVersion())
+	devConfig.Id = &config.UUIDandVersion{Uuid: dev.GetID().String(), Version: strconv.Itoa(version + 1)}
+	if res, err = proto.Marshal(devConfig); err != nil {
+		return fmt.Errorf("cannot marshal config: %w", err)
+	}
+	if err = ctrl.ConfigSet(dev.GetID(), res); err != nil {
+		return fmt.Errorf("ConfigSet: %w", err)
+	}
+	fmt.Printf("config applied with version %d\n", version+1)
+	return nil
+}
@@ -0,0 +1,52 @@
+package openevec
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lf-edge/eden/pkg/controller"
+)
+
+// Errors returned by methods of OpenEVEC for categories of failures,
+// they are wrapped, so match them with errors.Is instead of messages
+var (
+	// ErrNotOnboarded is returned if EVE is not onboarded to controller
+	ErrNotOnboarded = errors.New("EVE is not onboarded")
+	// ErrControllerUnreachable is returned if controller does not respond
+	ErrControllerUnreachable = controller.ErrControllerUnreachable
+	// ErrDevmodelUnsupported is returned for operations not supported for devmodel of EVE
+	ErrDevmodelUnsupported = errors.New("not supported for devmodel")
+)
+
+// ErrorCode is category of failure of eden
+type ErrorCode struct {
+	// Name of category, e.g. not-onboarded
+	Name string `json:"code"`
+	// ExitCode is exit code of eden for category
+	ExitCode int `json:"exit_code"`
+}
+
+// ErrorCodeGeneric is code of failures without category
+var ErrorCodeGeneric = ErrorCode{Name: "error", ExitCode: 1}
+
+// exit code 2 is left for misuse of commands
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrNotOnboarded, ErrorCode{Name: "not-onboarded", ExitCode: 3}},
+	{ErrControllerUnreachable, ErrorCode{Name: "controller-unreachable", ExitCode: 4}},
+	{ErrDevmodelUnsupported, ErrorCode{Name: "devmodel-unsupported", ExitCode: 5}},
+	{context.DeadlineExceeded, ErrorCode{Name: "timeout", ExitCode: 6}},
+	{context.Canceled, ErrorCode{Name: "interrupted", ExitCode: 130}},
+}
+
+// CodeOf returns category of err, ErrorCodeGeneric is returned for errors without category
+func CodeOf(err error) ErrorCode {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ErrorCodeGeneric
+}
@@ -1,11 +1,18 @@
 package openevec
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
@@ -18,12 +25,30 @@ import (
 	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
 	"github.com/lf-edge/eve-api/go/info"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
 )
 
-const SdnStartTimeout = 3 * time.Minute
+// StartEve starts EVE running pre-eve-start and post-eve-start hooks,
+// ctx cancels waiting for its dependencies (e.g. SDN) to start
+func (openEVEC *OpenEVEC) StartEve(ctx context.Context, vmName, tapInterface string) error {
+	if err := openEVEC.runHooks(ctx, HookPreEveStart); err != nil {
+		return err
+	}
+	if err := openEVEC.startEve(ctx, vmName, tapInterface); err != nil {
+		return err
+	}
+	return openEVEC.runHooks(ctx, HookPostEveStart)
+}
 
-func (openEVEC *OpenEVEC) StartEve(vmName, tapInterface string) error {
+func (openEVEC *OpenEVEC) startEve(ctx context.Context, vmName, tapInterface string) error {
 	cfg := openEVEC.cfg
+	if cfg.Eve.DevModel == defaults.DefaultPhysicalModel {
+		if err := eden.StartEVEPhysical(cfg.Eve.Physical.PowerOn); err != nil {
+			return fmt.Errorf("cannot start eve: %w", err)
+		}
+		log.Infof("EVE is starting on physical device")
+		return nil
+	}
 	if cfg.Eve.Remote {
 		return nil
 	}
@@ -36,20 +61,24 @@ func (openEVEC *OpenEVEC) StartEve(vmName, tapInterface string) error {
 			log.Infof("EVE is starting in Parallels")
 		}
 	case cfg.Eve.DevModel == defaults.DefaultVBoxModel:
-		if err := eden.StartEVEVBox(vmName, cfg.Eve.ImageFile, cfg.Eve.QemuCpus, cfg.Eve.QemuMemory, cfg.Eve.HostFwd); err != nil {
+		disks, err := vmDisks(*cfg, "vdi")
+		if err != nil {
+			return err
+		}
+		if err := eden.StartEVEVBox(vmName, cfg.Eve.ImageFile, cfg.Eve.QemuCpus, cfg.Eve.QemuMemory, cfg.Eve.HostFwd, disks); err != nil {
 			return fmt.Errorf("cannot start eve: %w", err)
 		} else {
 			log.Infof("EVE is starting in Virtual Box")
 		}
 	default:
-		if err := openEVEC.StartEveQemu(tapInterface); err != nil {
+		if err := openEVEC.StartEveQemu(ctx, tapInterface); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
+func (openEVEC *OpenEVEC) StartEveQemu(ctx context.Context, tapInterface string) error {
 	cfg := openEVEC.cfg
 	// Load network model and prepare SDN config.
 	var err error
@@ -76,7 +105,7 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 	}
 	// Start Eden-SDN if enabled.
 	if cfg.IsSdnEnabled() {
-		err = openEVEC.StartEdenSDN(netModel)
+		err = openEVEC.StartEdenSDN(ctx, netModel)
 		if err != nil {
 			return err
 		}
@@ -89,8 +118,9 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 			return err
 		}
 		usbImagePath = filepath.Join(currentPath, defaults.DefaultDist, "usb.img")
-		err = utils.CreateUsbNetConfImg(cfg.Eve.UsbNetConfFile, usbImagePath)
-		if err != nil {
+		if utils.IsDryRun() {
+			utils.DryRunf("create image %s with network config %s", usbImagePath, cfg.Eve.UsbNetConfFile)
+		} else if err = utils.CreateUsbNetConfImg(cfg.Eve.UsbNetConfFile, usbImagePath); err != nil {
 			return err
 		}
 	}
@@ -102,6 +132,15 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 		isInstaller = true
 		imageFile = cfg.Eve.CustomInstaller.Path
 		imageFormat = cfg.Eve.CustomInstaller.Format
+	} else if cfg.Eve.QemuConfig.Overlay && utils.IsDryRun() {
+		if imageFile, err = openEVEC.eveOverlayFile(); err != nil {
+			return err
+		}
+		utils.DryRunf("create overlay %s backed by %s unless it is up to date", imageFile, cfg.Eve.ImageFile)
+	} else if cfg.Eve.QemuConfig.Overlay {
+		if imageFile, err = openEVEC.prepareEveOverlay(false); err != nil {
+			return fmt.Errorf("cannot prepare overlay: %w", err)
+		}
 	}
 	// Start vTPM.
 	if cfg.Eve.TPM {
@@ -112,9 +151,16 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 			log.Infof("swtpm is starting")
 		}
 	}
+	accel, err := cfg.QemuAccelerator()
+	if err != nil {
+		return err
+	}
+	if accel == utils.QemuAccelTCG {
+		log.Infof("EVE %s is emulated with TCG, expect it to be several times slower", cfg.Eve.Arch)
+	}
 	// Start EVE VM.
 	if err = eden.StartEVEQemu(cfg.Eve.Arch, cfg.Eve.QemuOS, imageFile, imageFormat, isInstaller, cfg.Eve.Serial, cfg.Eve.TelnetPort,
-		cfg.Eve.QemuConfig.MonitorPort, cfg.Eve.QemuConfig.NetDevSocketPort, cfg.Eve.HostFwd, cfg.Eve.Accel, cfg.Eve.QemuFileToSave, cfg.Eve.Log,
+		cfg.Eve.QemuConfig.MonitorPort, cfg.Eve.QemuConfig.NetDevSocketPort, cfg.Eve.HostFwd, accel, cfg.Eve.QemuFileToSave, cfg.Eve.Log,
 		cfg.Eve.Pid, netModel, cfg.IsSdnEnabled(), tapInterface, usbImagePath, cfg.Eve.TPM, false); err != nil {
 		log.Errorf("cannot start eve: %s", err.Error())
 	} else {
@@ -123,36 +169,63 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 	return nil
 }
 
+// sdnMgmtSubnet returns management subnet of SDN VM set in sdn.ipv4-subnet or
+// the free one of slot of context, so every isolated context uses its own subnet
+func sdnMgmtSubnet(cfg *EdenSetupArgs) (edensdn.SdnMgmtSubnet, error) {
+	if cfg.Sdn.IPv4Subnet != "" {
+		_, ipNet, err := net.ParseCIDR(cfg.Sdn.IPv4Subnet)
+		if err != nil || ipNet.IP.To4() == nil {
+			return edensdn.SdnMgmtSubnet{}, fmt.Errorf("invalid IPv4 subnet of SDN %q", cfg.Sdn.IPv4Subnet)
+		}
+		// the same offset as in subnets selected automatically
+		dhcpStart := make(net.IP, net.IPv4len)
+		copy(dhcpStart, ipNet.IP.To4())
+		dhcpStart[3] += 10
+		return edensdn.SdnMgmtSubnet{IPNet: ipNet, DHCPStart: dhcpStart}, nil
+	}
+	nets, err := utils.GetSubnetsNotUsed(cfg.Eden.Slot + 1)
+	if err != nil {
+		return edensdn.SdnMgmtSubnet{}, fmt.Errorf("failed to get unused IP subnet: %w", err)
+	}
+	mgmtNet := nets[cfg.Eden.Slot]
+	return edensdn.SdnMgmtSubnet{IPNet: mgmtNet.Subnet, DHCPStart: mgmtNet.FirstAddress}, nil
+}
+
 // StartEdenSDN : starts Eden-SDN VM and applies the provided network model.
-func (openEVEC *OpenEVEC) StartEdenSDN(netModel sdnapi.NetworkModel) error {
+// Waiting for SDN to start is aborted when ctx is done.
+func (openEVEC *OpenEVEC) StartEdenSDN(ctx context.Context, netModel sdnapi.NetworkModel) error {
 	cfg := openEVEC.cfg
-	nets, err := utils.GetSubnetsNotUsed(1)
+	mgmtSubnet, err := sdnMgmtSubnet(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get unused IP subnet: %w", err)
+		return err
+	}
+	accel, err := cfg.QemuAccelerator()
+	if err != nil {
+		return err
 	}
 	sdnConfig := edensdn.SdnVMConfig{
-		Architecture: cfg.Eve.Arch,
-		Acceleration: cfg.Eve.Accel,
-		HostOS:       cfg.Eve.QemuOS,
-		ImagePath:    cfg.Sdn.ImageFile,
-		ConfigDir:    cfg.Sdn.ConfigDir,
-		CPU:          cfg.Sdn.CPU,
-		RAM:          cfg.Sdn.RAM,
-		NetModel:     netModel,
-		TelnetPort:   uint16(cfg.Sdn.TelnetPort),
-		SSHPort:      uint16(cfg.Sdn.SSHPort),
-		SSHKeyPath:   sdnSSHKeyPath(cfg.Sdn.SourceDir),
-		MgmtPort:     uint16(cfg.Sdn.MgmtPort),
-		MgmtSubnet: edensdn.SdnMgmtSubnet{
-			IPNet:     nets[0].Subnet,
-			DHCPStart: nets[0].FirstAddress,
-		},
+		Architecture:   cfg.Eve.Arch,
+		Accelerator:    accel,
+		HostOS:         cfg.Eve.QemuOS,
+		ImagePath:      cfg.Sdn.ImageFile,
+		ConfigDir:      cfg.Sdn.ConfigDir,
+		CPU:            cfg.Sdn.CPU,
+		RAM:            cfg.Sdn.RAM,
+		NetModel:       netModel,
+		TelnetPort:     uint16(cfg.Sdn.TelnetPort),
+		SSHPort:        uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath:     sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:       uint16(cfg.Sdn.MgmtPort),
+		MgmtSubnet:     mgmtSubnet,
 		NetDevBasePort: uint16(cfg.Eve.QemuConfig.NetDevSocketPort),
 		PidFile:        cfg.Sdn.PidFile,
 		ConsoleLogFile: cfg.Sdn.ConsoleLogFile,
 		EnableIPv6:     cfg.Sdn.EnableIPv6,
 		IPv6Subnet:     cfg.Sdn.IPv6Subnet,
 	}
+	if utils.IsDryRun() {
+		return sdnDryRun(sdnConfig)
+	}
 	sdnVMRunner, err := edensdn.GetSdnVMRunner(cfg.Eve.DevModel, sdnConfig)
 	if err != nil {
 		return fmt.Errorf("failed to get SDN VM runner: %w", err)
@@ -164,20 +237,23 @@ func (openEVEC *OpenEVEC) StartEdenSDN(netModel sdnapi.NetworkModel) error {
 	}
 	log.Infof("SDN is starting")
 	// Wait for SDN to start and apply network model.
-	startTime := time.Now()
 	client := &edensdn.SdnClient{
 		SSHPort:  uint16(cfg.Sdn.SSHPort),
 		MgmtPort: uint16(cfg.Sdn.MgmtPort),
 	}
-	for time.Since(startTime) < SdnStartTimeout {
-		time.Sleep(2 * time.Second)
+	waitCtx, cancel := context.WithTimeout(ctx, cfg.Timeouts.SdnStart)
+	defer cancel()
+	for {
+		if waitErr := utils.SleepContext(waitCtx, 2*time.Second); waitErr != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("waiting for SDN to start: %w", ctx.Err())
+			}
+			return fmt.Errorf("timeout waiting for SDN to start: %w", err)
+		}
 		if _, err = client.GetSdnStatus(); err == nil {
 			break
 		}
 	}
-	if err != nil {
-		return fmt.Errorf("timeout waiting for SDN to start: %w", err)
-	}
 	err = client.ApplyNetworkModel(netModel)
 	if err != nil {
 		return fmt.Errorf("failed to apply network model: %w", err)
@@ -186,8 +262,26 @@ func (openEVEC *OpenEVEC) StartEdenSDN(netModel sdnapi.NetworkModel) error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) StopEve(vmName string) error {
+// StopEve stops EVE running pre-eve-stop and post-eve-stop hooks
+func (openEVEC *OpenEVEC) StopEve(ctx context.Context, vmName string) error {
+	if err := openEVEC.runHooks(ctx, HookPreEveStop); err != nil {
+		return err
+	}
+	if err := openEVEC.stopEve(vmName); err != nil {
+		return err
+	}
+	return openEVEC.runHooks(ctx, HookPostEveStop)
+}
+
+func (openEVEC *OpenEVEC) stopEve(vmName string) error {
 	cfg := openEVEC.cfg
+	if cfg.Eve.DevModel == defaults.DefaultPhysicalModel {
+		if err := eden.StopEVEPhysical(cfg.Eve.Physical.PowerOff); err != nil {
+			return fmt.Errorf("cannot stop eve: %w", err)
+		}
+		log.Infof("EVE is stopping on physical device")
+		return nil
+	}
 	if cfg.Eve.Remote {
 		log.Debug("Cannot stop remote EVE")
 		return nil
@@ -252,9 +346,9 @@ func (openEVEC *OpenEVEC) VersionEve() error {
 
 func (openEVEC *OpenEVEC) StatusEve(vmName string) error {
 	cfg := openEVEC.cfg
-	statusAdam, err := eden.StatusAdam()
+	statusAdam, err := eden.StatusAdam(cfg.Containers().Adam)
 	if err == nil && statusAdam != "container doesn't exist" {
-		if err := openEVEC.eveStatusRemote(); err != nil {
+		if err := openEVEC.eveStatusRemote(false); err != nil {
 			return err
 		}
 	}
@@ -309,31 +403,37 @@ func (openEVEC *OpenEVEC) GetEveIP(ifName string) string {
 	return ""
 }
 
-func (openEVEC *OpenEVEC) eveLastRequests() (string, error) {
+// eveLastRequest returns the last request of EVE to controller with client IP and number of requests found
+func (openEVEC *OpenEVEC) eveLastRequest() (*RequestRecord, int, error) {
 	log.Debugf("Will try to obtain info from ADAM")
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
-		return "", fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+		return nil, 0, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
 	var lastRequest *types.APIRequest
+	count := 0
 	var handleRequest = func(request *types.APIRequest) bool {
+		count++
 		if request.ClientIP != "" {
 			lastRequest = request
 		}
 		return false
 	}
 	if err := ctrl.RequestLastCallback(dev.GetID(), map[string]string{"UUID": dev.GetID().String()}, handleRequest); err != nil {
-		return "", err
+		return nil, 0, err
 	}
 	if lastRequest == nil {
-		return "", nil
+		return nil, count, nil
 	}
-	return strings.Split(lastRequest.ClientIP, ":")[0], nil
+	return NewRequestRecord(lastRequest), count, nil
 }
 
 func (openEVEC *OpenEVEC) ConsoleEve(host string) error {
 	cfg := openEVEC.cfg
+	if cfg.Eve.DevModel == defaults.DefaultPhysicalModel {
+		return eden.ConsoleEVEPhysical(cfg.Eve.Physical.Console)
+	}
 	if cfg.Eve.Remote {
 		return fmt.Errorf("cannot telnet to remote EVE")
 	}
@@ -344,34 +444,134 @@ func (openEVEC *OpenEVEC) ConsoleEve(host string) error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) SSHEve(commandToRun string) error {
-	cfg := openEVEC.cfg
-	if _, err := os.Stat(cfg.Eden.SSHKey); !os.IsNotExist(err) {
-		changer := &adamChanger{}
-		ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
-		if err != nil {
-			return fmt.Errorf("cannot get controller or dev, please start them and onboard: %w", err)
+// ConsoleEveWeb serves page with terminal connected to console of EVE on listen address
+// until ctx is done, all opened pages share the console
+func (openEVEC *OpenEVEC) ConsoleEveWeb(ctx context.Context, host, listen string) error {
+	console, err := openConsole(openEVEC.cfg, host)
+	if err != nil {
+		return err
+	}
+	return eden.ServeWebConsole(ctx, console, listen, openEVEC.cfg.Eve.Name)
+}
+
+// openConsole connects to console of EVE defined by cfg: telnet on host for QEMU
+// or console command for physical device
+func openConsole(cfg *EdenSetupArgs, host string) (io.ReadWriteCloser, error) {
+	switch {
+	case cfg.Eve.DevModel == defaults.DefaultPhysicalModel:
+		if strings.TrimSpace(cfg.Eve.Physical.Console) == "" {
+			return nil, fmt.Errorf("console command for physical device is not defined, please set eve.physical.console in config")
 		}
-		b, err := os.ReadFile(ctrl.GetVars().SSHKey)
-		switch {
-		case err != nil:
-			return fmt.Errorf("error reading sshKey file %s: %w", ctrl.GetVars().SSHKey, err)
+		return eden.StartCommandConsole(cfg.Eve.Physical.Console)
+	case cfg.Eve.Remote:
+		return nil, fmt.Errorf("cannot telnet to remote EVE")
+	default:
+		return eden.DialTelnetConsole(net.JoinHostPort(host, strconv.Itoa(cfg.Eve.TelnetPort)))
+	}
+}
+
+// ConsoleEveAll attaches to consoles of EVE of nodes (contexts) in multiplexed console in terminal,
+// all contexts are used if nodes are not set, output of every node is written into log in logDir
+// (temporary directory if empty)
+func (openEVEC *OpenEVEC) ConsoleEveAll(ctx context.Context, host string, nodes []string, logDir string) error {
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return fmt.Errorf("multiplexed console requires terminal")
+	}
+	edenCtx, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	contexts := edenCtx.ListContexts()
+	if len(nodes) == 0 {
+		nodes = contexts
+	}
+	var consoles []eden.ConsoleNode
+	for _, node := range nodes {
+		if !slices.Contains(contexts, node) {
+			return fmt.Errorf("context %s not found, create it with 'eden config add %s'", node, node)
 		}
-		dev.SetConfigItem("debug.enable.ssh", string(b))
-		if err = ctrl.ConfigSync(dev); err != nil {
-			return err
+		cfg := openEVEC.cfg
+		if node != cfg.ConfigName {
+			if cfg, err = LoadConfig(utils.GetConfig(node)); err != nil {
+				return fmt.Errorf("cannot load config of %s: %w", node, err)
+			}
 		}
-		if err = openEVEC.SdnForwardSSHToEve(commandToRun); err != nil {
-			return err
+		if cfg.Eve.Remote && len(nodes) > 1 {
+			log.Infof("Skip %s with remote EVE", node)
+			continue
 		}
-	} else {
-		return fmt.Errorf("SSH key problem: %w", err)
+		console, err := openConsole(cfg, host)
+		consoles = append(consoles, eden.ConsoleNode{Name: node, Console: console, Err: err})
+	}
+	if logDir == "" {
+		logDir = filepath.Join(os.TempDir(), fmt.Sprintf("eden-console-%s", time.Now().Format("20060102-150405")))
+	}
+	cols, rows, err := term.GetSize(outFd)
+	if err != nil {
+		return fmt.Errorf("cannot get size of terminal: %w", err)
+	}
+	mc, err := eden.NewMultiConsole(consoles, logDir, os.Stdout, cols, rows)
+	if err != nil {
+		return err
+	}
+	oldState, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("cannot set terminal into raw mode: %w", err)
 	}
+	defer func() {
+		_ = term.Restore(inFd, oldState)
+		log.Infof("Logs of consoles are in %s", logDir)
+	}()
 
-	return nil
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer func() {
+		signal.Stop(resize)
+		close(resize)
+	}()
+	go func() {
+		for range resize {
+			if cols, rows, err := term.GetSize(outFd); err == nil {
+				mc.Resize(cols, rows)
+			}
+		}
+	}()
+	return mc.Run(ctx, os.Stdin)
+}
+
+func (openEVEC *OpenEVEC) SSHEve(commandToRun string) error {
+	if err := openEVEC.enableSSHEve(); err != nil {
+		return err
+	}
+	return openEVEC.SdnForwardSSHToEve(commandToRun)
+}
+
+// enableSSHEve configures EVE to accept ssh connections with key of eden
+func (openEVEC *OpenEVEC) enableSSHEve() error {
+	cfg := openEVEC.cfg
+	if _, err := os.Stat(cfg.Eden.SSHKey); os.IsNotExist(err) {
+		return fmt.Errorf("SSH key problem: %w", err)
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("cannot get controller or dev, please start them and onboard: %w", err)
+	}
+	b, err := os.ReadFile(ctrl.GetVars().SSHKey)
+	if err != nil {
+		return fmt.Errorf("error reading sshKey file %s: %w", ctrl.GetVars().SSHKey, err)
+	}
+	dev.SetConfigItem("debug.enable.ssh", string(b))
+	return ctrl.ConfigSync(dev)
 }
 
-func (openEVEC *OpenEVEC) ResetEve() error {
+// ResetEve resets config of EVE in controller to the initial one,
+// with disk it also restarts EVE VM from pristine image waiting for its onboarding until ctx is done
+func (openEVEC *OpenEVEC) ResetEve(ctx context.Context, disk bool) error {
+	if disk {
+		return openEVEC.resetEveDisk(ctx)
+	}
 	certsUUID := openEVEC.cfg.Eve.CertsUUID
 	edenDir, err := utils.DefaultEdenDir()
 	if err != nil {
@@ -429,6 +629,48 @@ func (openEVEC *OpenEVEC) NewEpochEve(eveConfigFromFile bool) error {
 	return nil
 }
 
+// setLinkStateEve brings interfaces of local EVE VM up or down
+func (openEVEC *OpenEVEC) setLinkStateEve(vmName string, eveIfNames []string, up bool) error {
+	cfg := openEVEC.cfg
+	var err error
+	switch cfg.Eve.DevModel {
+	case defaults.DefaultVBoxModel:
+		for _, ifName := range eveIfNames {
+			err = eden.SetLinkStateVbox(vmName, ifName, up)
+		}
+	case defaults.DefaultQemuModel:
+		for _, ifName := range eveIfNames {
+			err = eden.SetLinkStateQemu(cfg.Eve.QemuConfig.MonitorPort, ifName, up)
+		}
+	default:
+		return fmt.Errorf("link operations are %w '%s'", ErrDevmodelUnsupported, cfg.Eve.DevModel)
+	}
+	return err
+}
+
+// EveInterfaceNames returns names of interfaces inside EVE VM, one per port
+// of network model of SDN or eth0 and eth1 without SDN
+func (openEVEC *OpenEVEC) EveInterfaceNames() ([]string, error) {
+	cfg := openEVEC.cfg
+	if !cfg.IsSdnEnabled() {
+		return []string{"eth0", "eth1"}, nil
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	netModel, err := client.GetNetworkModel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network model: %w", err)
+	}
+	var eveIfNames []string
+	for i := range netModel.Ports {
+		eveIfNames = append(eveIfNames, fmt.Sprintf("eth%d", i))
+	}
+	return eveIfNames, nil
+}
+
 func (openEVEC *OpenEVEC) NewLinkEve(command, eveInterfaceName, vmName string) error {
 	cfg := openEVEC.cfg
 	var err error
@@ -439,39 +681,11 @@ func (openEVEC *OpenEVEC) NewLinkEve(command, eveInterfaceName, vmName string) e
 	var eveIfNames []string
 	if eveInterfaceName != "" {
 		eveIfNames = append(eveIfNames, eveInterfaceName)
-	} else {
-		if cfg.IsSdnEnabled() {
-			client := &edensdn.SdnClient{
-				SSHPort:    uint16(cfg.Sdn.SSHPort),
-				SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
-				MgmtPort:   uint16(cfg.Sdn.MgmtPort),
-			}
-			netModel, err := client.GetNetworkModel()
-			if err != nil {
-				return fmt.Errorf("failed to get network model: %w", err)
-			}
-			for i := range netModel.Ports {
-				eveIfNames = append(eveIfNames, fmt.Sprintf("eth%d", i))
-			}
-		} else {
-			eveIfNames = []string{"eth0", "eth1"}
-		}
+	} else if eveIfNames, err = openEVEC.EveInterfaceNames(); err != nil {
+		return err
 	}
 	if command == "up" || command == "down" {
-		bringUp := command == "up"
-		switch cfg.Eve.DevModel {
-		case defaults.DefaultVBoxModel:
-			for _, ifName := range eveIfNames {
-				err = eden.SetLinkStateVbox(vmName, ifName, bringUp)
-			}
-		case defaults.DefaultQemuModel:
-			for _, ifName := range eveIfNames {
-				err = eden.SetLinkStateQemu(cfg.Eve.QemuConfig.MonitorPort, ifName, bringUp)
-			}
-		default:
-			return fmt.Errorf("link operations are not supported for devmodel '%s'", cfg.Eve.DevModel)
-		}
-		if err != nil {
+		if err = openEVEC.setLinkStateEve(vmName, eveIfNames, command == "up"); err != nil {
 			return err
 		}
 		// continue to print the new link state of every interface after the update
@@ -486,7 +700,7 @@ func (openEVEC *OpenEVEC) NewLinkEve(command, eveInterfaceName, vmName string) e
 	case defaults.DefaultQemuModel:
 		linkStates, err = eden.GetLinkStatesQemu(cfg.Eve.QemuConfig.MonitorPort, eveIfNames)
 	default:
-		return fmt.Errorf("link operations are not supported for devmodel '%s'", cfg.Eve.DevModel)
+		return fmt.Errorf("link operations are %w '%s'", ErrDevmodelUnsupported, cfg.Eve.DevModel)
 	}
 	if err != nil {
 		return err
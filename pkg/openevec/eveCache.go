@@ -0,0 +1,47 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// EveCacheList prints entries of cache of images of EVE
+func (openEVEC *OpenEVEC) EveCacheList() error {
+	entries, err := utils.ListEveCache()
+	if err != nil {
+		return fmt.Errorf("ListEveCache: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tLAST USED")
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Name, humanize.Bytes(uint64(entry.Size)), humanize.Time(entry.LastUsed))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d entries, %s\n", len(entries), humanize.Bytes(uint64(total)))
+	return nil
+}
+
+// EveCachePrune removes entries of cache of images of EVE not used for olderThan
+// (all entries if olderThan is 0)
+func (openEVEC *OpenEVEC) EveCachePrune(olderThan time.Duration) error {
+	removed, err := utils.PruneEveCache(olderThan)
+	var total int64
+	for _, entry := range removed {
+		total += entry.Size
+		fmt.Printf("removed %s\n", entry.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("PruneEveCache: %w", err)
+	}
+	fmt.Printf("%d entries removed, %s freed\n", len(removed), humanize.Bytes(uint64(total)))
+	return nil
+}
@@ -0,0 +1,164 @@
+package openevec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// rebootsDir returns directory with forensics of unexpected reboots of device for the current context
+func (openEVEC *OpenEVEC) rebootsDir(dev *device.Ctx) (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultArtifactsDir, configName, dev.GetID().String(), "reboots"), nil
+}
+
+// rebootLogs returns logs around every reboot in events, logs of device are read once for all of them
+func rebootLogs(ctrl controller.Cloud, dev *device.Ctx, events []*eve.RebootEvent) ([]bytes.Buffer, error) {
+	logs := make([]bytes.Buffer, len(events))
+	if err := ctrl.LogLastCallback(dev.GetID(), nil, func(le *elog.FullLogEntry) bool {
+		ts := le.GetTimestamp().AsTime()
+		var data []byte
+		for i, event := range events {
+			if ts.Before(event.Time.Add(-defaults.DefaultRebootLogWindow)) ||
+				ts.After(event.Time.Add(defaults.DefaultRebootLogWindow)) {
+				continue
+			}
+			if data == nil {
+				var err error
+				if data, err = protojson.Marshal(le); err != nil {
+					log.Debugf("cannot marshal log: %s", err)
+					return false
+				}
+			}
+			logs[i].Write(data)
+			logs[i].WriteString("\n")
+		}
+		return false
+	}); err != nil {
+		return nil, fmt.Errorf("LogLastCallback: %w", err)
+	}
+	return logs, nil
+}
+
+// saveRebootForensics stores reason, stack and log window around reboot into dir
+func saveRebootForensics(event *eve.RebootEvent, logs []byte, dir string) error {
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return err
+	}
+	reason := fmt.Sprintf("time: %s\nboot reason: %s\nreboot reason: %s\n",
+		event.Time.Format(time.RFC3339), event.BootReason, event.Reason)
+	if err := os.WriteFile(filepath.Join(tmpDir, "reason.txt"), []byte(reason), 0644); err != nil {
+		return err
+	}
+	if event.Stack != "" {
+		if err := os.WriteFile(filepath.Join(tmpDir, "stack.txt"), []byte(event.Stack), 0644); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "logs.json"), logs, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dir)
+}
+
+// collectRebootForensics saves forensics for unexpected reboots found by detector
+// which were not saved before
+func (openEVEC *OpenEVEC) collectRebootForensics(ctrl controller.Cloud, dev *device.Ctx, detector *eve.RebootDetector) error {
+	rebootsDir, err := openEVEC.rebootsDir(dev)
+	if err != nil {
+		return err
+	}
+	var events []*eve.RebootEvent
+	var dirs []string
+	for _, event := range detector.Unexpected() {
+		dir := filepath.Join(rebootsDir, event.Time.UTC().Format("20060102T150405Z"))
+		if _, err := os.Stat(dir); err == nil {
+			continue
+		}
+		log.Warnf("EVE rebooted unexpectedly at %s (%s): %s",
+			event.Time.Format(time.RFC3339), event.BootReason, event.Reason)
+		events = append(events, event)
+		dirs = append(dirs, dir)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	logs, err := rebootLogs(ctrl, dev, events)
+	if err != nil {
+		return err
+	}
+	for i, event := range events {
+		if err := saveRebootForensics(event, logs[i].Bytes(), dirs[i]); err != nil {
+			return fmt.Errorf("cannot save reboot forensics: %w", err)
+		}
+		log.Infof("Reboot forensics saved into %s", dirs[i])
+	}
+	return nil
+}
+
+// savedReboots returns directories with saved forensics of unexpected reboots sorted by time
+func (openEVEC *OpenEVEC) savedReboots(dev *device.Ctx) ([]string, error) {
+	rebootsDir, err := openEVEC.rebootsDir(dev)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(rebootsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && filepath.Ext(entry.Name()) != ".tmp" {
+			dirs = append(dirs, filepath.Join(rebootsDir, entry.Name()))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// eveStatusReboots prints information about unexpected reboots of EVE
+func (openEVEC *OpenEVEC) eveStatusReboots(dev *device.Ctx) {
+	dirs, err := openEVEC.savedReboots(dev)
+	if err != nil {
+		log.Debugf("savedReboots: %s", err)
+		return
+	}
+	if len(dirs) == 0 {
+		return
+	}
+	last := dirs[len(dirs)-1]
+	reason, err := os.ReadFile(filepath.Join(last, "reason.txt"))
+	if err != nil {
+		log.Debugf("cannot read reboot reason: %s", err)
+	}
+	fmt.Printf("%s EVE unexpected reboots: %d, forensics of the last one in %s\n", statusBad(), len(dirs), last)
+	for _, line := range bytes.Split(bytes.TrimSpace(reason), []byte("\n")) {
+		fmt.Printf("\t%s\n", line)
+	}
+}
@@ -0,0 +1,133 @@
+package openevec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Hooks run before and after operations of eden, names are keys in hooks section of config
+const (
+	HookPreSetup     = "pre-setup"
+	HookPostSetup    = "post-setup"
+	HookPreEveStart  = "pre-eve-start"
+	HookPostEveStart = "post-eve-start"
+	HookPreEveStop   = "pre-eve-stop"
+	HookPostEveStop  = "post-eve-stop"
+	HookPostOnboard  = "post-onboard"
+	HookPreClean     = "pre-clean"
+	HookPostClean    = "post-clean"
+)
+
+// PluginEnvHook is environment variable with name of hook passed to commands of hooks
+const PluginEnvHook = "EDEN_HOOK"
+
+// HooksConfig stores hooks of context: commands run with sh or URLs of webhooks
+// which receive POST request with name of hook and PluginContext in JSON
+type HooksConfig struct {
+	PreSetup     []string `mapstructure:"pre-setup"`
+	PostSetup    []string `mapstructure:"post-setup"`
+	PreEveStart  []string `mapstructure:"pre-eve-start"`
+	PostEveStart []string `mapstructure:"post-eve-start"`
+	PreEveStop   []string `mapstructure:"pre-eve-stop"`
+	PostEveStop  []string `mapstructure:"post-eve-stop"`
+	PostOnboard  []string `mapstructure:"post-onboard"`
+	PreClean     []string `mapstructure:"pre-clean"`
+	PostClean    []string `mapstructure:"post-clean"`
+}
+
+// get returns commands and webhooks of hook with name
+func (hooks *HooksConfig) get(name string) []string {
+	v := reflect.ValueOf(hooks).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("mapstructure") == name {
+			return v.Field(i).Interface().([]string)
+		}
+	}
+	return nil
+}
+
+// hookPayload is body of request to webhook
+type hookPayload struct {
+	Hook    string         `json:"hook"`
+	Context *PluginContext `json:"context"`
+}
+
+// isWebhook returns true if hook is URL of webhook rather than command
+func isWebhook(hook string) bool {
+	return strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://")
+}
+
+// runHooks runs commands and webhooks of hook with name one by one, the first failed one
+// stops others, each one is limited with timeouts.hook of config
+func (openEVEC *OpenEVEC) runHooks(ctx context.Context, name string) error {
+	hooks := openEVEC.cfg.Hooks.get(name)
+	if len(hooks) == 0 {
+		return nil
+	}
+	if utils.IsDryRun() {
+		for _, hook := range hooks {
+			utils.DryRunf("run %s hook %q", name, hook)
+		}
+		return nil
+	}
+	pluginCtx, err := openEVEC.PluginContext()
+	if err != nil {
+		return fmt.Errorf("cannot get context for %s hook: %w", name, err)
+	}
+	env, cleanup, err := contextEnv(pluginCtx)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	env = append(env, PluginEnvHook+"="+name)
+	for _, hook := range hooks {
+		log.Infof("Running %s hook: %s", name, hook)
+		hookCtx, cancel := context.WithTimeout(ctx, openEVEC.cfg.Timeouts.Hook)
+		if isWebhook(hook) {
+			err = callWebhook(hookCtx, hook, hookPayload{Hook: name, Context: pluginCtx})
+		} else {
+			cmd := exec.CommandContext(hookCtx, "sh", "-c", hook)
+			cmd.Env = env
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			err = cmd.Run()
+		}
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", name, hook, err)
+		}
+	}
+	return nil
+}
+
+// callWebhook sends payload to url, status other than 2xx is an error
+func callWebhook(ctx context.Context, url string, payload hookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package openevec_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	. "github.com/onsi/gomega"
+)
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	var payload struct {
+		Hook    string                 `json:"hook"`
+		Context openevec.PluginContext `json:"context"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	out := filepath.Join(t.TempDir(), "out")
+	cfg := &openevec.EdenSetupArgs{ConfigName: "hooks"}
+	// remote EVE is not stopped by eden, only hooks run
+	cfg.Eve.Remote = true
+	cfg.Timeouts.Hook = time.Minute
+	cfg.Hooks.PreEveStop = []string{`echo "$EDEN_HOOK $EDEN_CONFIG" > ` + out}
+	cfg.Hooks.PostEveStop = []string{server.URL}
+	g.Expect(openevec.CreateOpenEVEC(cfg).StopEve(context.Background(), "")).To(Succeed())
+	g.Expect(os.ReadFile(out)).To(BeEquivalentTo("pre-eve-stop hooks\n"))
+	g.Expect(payload.Hook).To(Equal("post-eve-stop"))
+	g.Expect(payload.Context.Name).To(Equal("hooks"))
+
+	// failed pre hook stops operation
+	payload.Hook = ""
+	cfg.Hooks.PreEveStop = []string{"exit 1"}
+	g.Expect(openevec.CreateOpenEVEC(cfg).StopEve(context.Background(), "")).To(MatchError(ContainSubstring(`pre-eve-stop hook "exit 1" failed`)))
+	g.Expect(payload.Hook).To(BeEmpty())
+}
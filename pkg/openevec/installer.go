@@ -0,0 +1,175 @@
+package openevec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/netboot"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+)
+
+// ipxeBootloader puts iPXE bootloader into tftpDir and returns its name inside
+// of it: bootloader defined by user or downloaded one for arch of EVE
+func ipxeBootloader(tftpDir, bootloader, arch string) (string, error) {
+	if bootloader != "" {
+		name := filepath.Base(bootloader)
+		if err := utils.CopyFile(bootloader, filepath.Join(tftpDir, name)); err != nil {
+			return "", fmt.Errorf("cannot copy bootloader %s: %w", bootloader, err)
+		}
+		return name, nil
+	}
+	url := defaults.DefaultIPXEBootloaderAmd64
+	if arch == "arm64" {
+		url = defaults.DefaultIPXEBootloaderArm64
+	}
+	name := fmt.Sprintf("ipxe-%s.efi", arch)
+	file := filepath.Join(tftpDir, name)
+	if _, err := os.Stat(file); err == nil {
+		return name, nil
+	}
+	log.Infof("download iPXE bootloader from %s", url)
+	if err := utils.DownloadFile(file, url); err != nil {
+		return "", fmt.Errorf("cannot download bootloader from %s: %w", url, err)
+	}
+	return name, nil
+}
+
+// InstallerNetboot boots EVE installer prepared with 'eden setup --netboot'
+// over network: it runs proxy DHCP and TFTP servers on serverIP (IP of EVE
+// network by default) until interrupted, iPXE script and installer are served
+// by eserver
+func (openEVEC *OpenEVEC) InstallerNetboot(ctx context.Context, serverIP, bootloader string) error {
+	cfg := openEVEC.cfg
+	tftpDir := filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "tftp")
+	if _, err := os.Stat(filepath.Join(tftpDir, "ipxe.efi.cfg")); err != nil {
+		return fmt.Errorf("no iPXE script found, please run 'eden setup --netboot' first: %w", err)
+	}
+	if serverIP == "" {
+		serverIP = cfg.Adam.CertsEVEIP
+	}
+	ip := net.ParseIP(serverIP)
+	if ip.To4() == nil {
+		return fmt.Errorf("invalid IPv4 address of netboot server: %s", serverIP)
+	}
+	bootloaderName, err := ipxeBootloader(tftpDir, bootloader, cfg.Eve.Arch)
+	if err != nil {
+		return err
+	}
+	if err := eden.StartEServer(cfg.Containers().EServer, cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist,
+		cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
+		return fmt.Errorf("cannot start eserver: %w", err)
+	}
+	configPrefix := cfg.ConfigName
+	if configPrefix == defaults.DefaultContext {
+		configPrefix = ""
+	}
+	server := &netboot.Server{
+		IP:         ip,
+		TFTPRoot:   tftpDir,
+		Bootloader: bootloaderName,
+		ScriptURL: fmt.Sprintf("http://%s:%d/%s/ipxe.efi.cfg",
+			cfg.Adam.CertsEVEIP, cfg.Eden.EServer.Port, path.Join("eserver", configPrefix)),
+	}
+	log.Info("boot your device from network, press Ctrl+C to stop netboot server")
+	return server.Run(ctx)
+}
+
+// confirmWriteDevice checks if installer can be written into block device,
+// writing into not removable device or without confirmation requires force
+func confirmWriteDevice(device string, force bool) error {
+	dev, err := utils.InspectBlockDevice(device)
+	if err != nil {
+		return err
+	}
+	if force {
+		return nil
+	}
+	if !dev.Removable {
+		return fmt.Errorf("%s is not removable, use --force to write into it", dev)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("confirmation is required to write into %s, use --force to write without it", dev)
+	}
+	w := &wizard{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+	yes, err := w.confirm(fmt.Sprintf("All data on %s will be lost, continue", dev), false, nil)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		return fmt.Errorf("writing into %s is canceled", dev.Path)
+	}
+	return nil
+}
+
+// InstallerUSB generates installer of EVE with config of context overridden
+// by conf and saves it into output file or writes it into USB stick if output
+// is block device. Writing into device is confirmed interactively unless force is set.
+func (openEVEC *OpenEVEC) InstallerUSB(output string, conf utils.InstallerConfig, force bool) error {
+	cfg := openEVEC.cfg
+	if cfg.Eve.Source != "" || cfg.Eve.CustomInstaller.Path != "" {
+		return fmt.Errorf("installer can be generated only from EVE image in registry")
+	}
+	if conf.NetConf == "" {
+		conf.NetConf = cfg.Eve.UsbNetConfFile
+	}
+	eveDesc := utils.EVEDescription{
+		Arch:     cfg.Eve.Arch,
+		Platform: cfg.Eve.Platform,
+		HV:       cfg.Eve.HV,
+		Registry: cfg.Eve.Registry,
+		Tag:      cfg.Eve.Tag,
+	}
+	image, err := eveDesc.Image()
+	if err != nil {
+		return err
+	}
+	if _, err := verifyEveImage(*cfg, image); err != nil {
+		return err
+	}
+	configDir, err := os.MkdirTemp("", "eve-installer-config")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(configDir)
+	if err := utils.PrepareInstallerConfig(cfg.Eden.CertsDir, configDir, conf); err != nil {
+		return fmt.Errorf("cannot prepare config of installer: %w", err)
+	}
+	eveDesc.ConfigPath = configDir
+
+	isDevice := false
+	if info, err := os.Stat(output); err == nil && info.Mode()&os.ModeDevice != 0 {
+		isDevice = true
+	}
+	imageFile := output
+	if isDevice {
+		// device is checked before long generation of installer
+		if err := confirmWriteDevice(output, force); err != nil {
+			return err
+		}
+		tmpDir, err := os.MkdirTemp("", "eve-installer")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		imageFile = filepath.Join(tmpDir, "installer.raw")
+	}
+	if err := utils.DownloadEveInstaller(eveDesc, imageFile); err != nil {
+		return fmt.Errorf("cannot generate installer: %w", err)
+	}
+	if isDevice {
+		if err := utils.WriteImageToDevice(imageFile, output); err != nil {
+			return err
+		}
+	}
+	log.Infof("installer of EVE %s is written into %s", image, output)
+	return nil
+}
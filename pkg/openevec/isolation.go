@@ -0,0 +1,228 @@
+package openevec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// contextPortStep is distance between ports of isolated contexts with
+// neighbouring slots, default ports differ modulo it, so shifted ports
+// of one context do not collide with ports of another one
+const contextPortStep = 1000
+
+// maxContextSlot keeps shifted ports (telnet port of EVE is the highest) in range
+const maxContextSlot = 47
+
+// Containers returns names of docker containers of eden services of context,
+// isolated contexts run their own services named after context
+func (cfg *EdenSetupArgs) Containers() eden.Containers {
+	containers := eden.DefaultContainers()
+	if cfg.Eden.Slot == 0 || cfg.ConfigName == "" {
+		return containers
+	}
+	suffix := "_" + strings.ToLower(cfg.ConfigName)
+	containers.Adam += suffix
+	containers.Redis += suffix
+	containers.Registry += suffix
+	containers.EServer += suffix
+	return containers
+}
+
+// contextSlots returns contexts by slots they use, except of context exclude,
+// slot 0 is shared by not isolated contexts
+func contextSlots(exclude string) (map[int][]string, error) {
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return nil, fmt.Errorf("load context error: %w", err)
+	}
+	slots := make(map[int][]string)
+	if _, err := os.Stat(filepath.Dir(context.GetCurrentConfig())); os.IsNotExist(err) {
+		return slots, nil
+	}
+	for _, name := range context.ListContexts() {
+		if name == exclude {
+			continue
+		}
+		context.Current = name
+		data, err := os.ReadFile(context.GetCurrentConfig())
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		var config struct {
+			Eden struct {
+				Slot int `yaml:"slot"`
+			} `yaml:"eden"`
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("cannot parse config of context %s: %w", name, err)
+		}
+		slots[config.Eden.Slot] = append(slots[config.Eden.Slot], name)
+	}
+	return slots, nil
+}
+
+// contextPath renames components of path p inside eden root or eden directory
+// which begin with one of prefixes of context names from, to begin with name
+func contextPath(p, root, edenDir string, from []string, name string) string {
+	rename := func(rel string) string {
+		parts := strings.Split(rel, string(filepath.Separator))
+		for i, part := range parts {
+			for _, prefix := range from {
+				if strings.HasPrefix(part, prefix+"-") {
+					parts[i] = name + strings.TrimPrefix(part, prefix)
+					break
+				}
+			}
+		}
+		return filepath.Join(parts...)
+	}
+	if p == "" {
+		return p
+	}
+	if !filepath.IsAbs(p) {
+		return rename(p)
+	}
+	for _, dir := range []string{root, edenDir} {
+		if dir == "" {
+			continue
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return filepath.Join(dir, rename(rel))
+	}
+	return p
+}
+
+// shiftHostPort shifts port of address in host:port format
+func shiftHostPort(address string, shift int) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return address
+	}
+	return net.JoinHostPort(host, strconv.Itoa(portNum+shift))
+}
+
+// shiftIPv6Subnet shifts the last 16 bits of prefix of IPv6 /64 subnet
+func shiftIPv6Subnet(subnet string, shift int) string {
+	ip, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil || ip.To4() != nil {
+		return subnet
+	}
+	prefix := ipNet.IP.To16()
+	value := (int(prefix[6])<<8 | int(prefix[7])) + shift
+	prefix[6], prefix[7] = byte(value>>8), byte(value)
+	return ipNet.String()
+}
+
+// shiftIPv4Subnet returns IPv4 subnet moved by shift subnets of the same size,
+// e.g. 192.168.10.0/24 shifted by 2 is 192.168.12.0/24
+func shiftIPv4Subnet(subnet string, shift int) string {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil || ipNet.IP.To4() == nil {
+		return subnet
+	}
+	ones, bits := ipNet.Mask.Size()
+	value := binary.BigEndian.Uint32(ipNet.IP.To4()) + uint32(int64(shift)<<(bits-ones))
+	prefix := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(prefix, value)
+	return (&net.IPNet{IP: prefix, Mask: ipNet.Mask}).String()
+}
+
+// isolateContext moves host resources of context in cfg into ones derived from
+// name and slot: files and directories, ports and docker containers of services,
+// so the context can run next to other ones
+func isolateContext(cfg *EdenSetupArgs, name string, slot int) error {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	from := []string{defaults.DefaultContext}
+	if cfg.ConfigName != "" && cfg.ConfigName != name {
+		from = append(from, cfg.ConfigName)
+	}
+	rename := func(p string) string {
+		return contextPath(p, cfg.Eden.Root, edenDir, from, name)
+	}
+
+	cfg.Eden.CertsDir = rename(cfg.Eden.CertsDir)
+	cfg.Eden.SSHKey = rename(cfg.Eden.SSHKey)
+	cfg.Eden.Images.EServerImageDist = rename(cfg.Eden.Images.EServerImageDist)
+	cfg.Eden.EServer.Images.EServerImageDist = rename(cfg.Eden.EServer.Images.EServerImageDist)
+	cfg.Adam.CA = rename(cfg.Adam.CA)
+	cfg.Adam.Dist = rename(cfg.Adam.Dist)
+	cfg.Adam.Redis.Dist = rename(cfg.Adam.Redis.Dist)
+	cfg.Redis.Dist = rename(cfg.Redis.Dist)
+	cfg.Registry.Dist = rename(cfg.Registry.Dist)
+	cfg.Eve.Cert = rename(cfg.Eve.Cert)
+	cfg.Eve.DeviceCert = rename(cfg.Eve.DeviceCert)
+	cfg.Eve.Dist = rename(cfg.Eve.Dist)
+	cfg.Eve.ImageFile = rename(cfg.Eve.ImageFile)
+	cfg.Eve.QemuConfigPath = rename(cfg.Eve.QemuConfigPath)
+	cfg.Eve.QemuFileToSave = rename(cfg.Eve.QemuFileToSave)
+	cfg.Eve.Pid = rename(cfg.Eve.Pid)
+	cfg.Eve.Log = rename(cfg.Eve.Log)
+	for i, firmware := range cfg.Eve.QemuFirmware {
+		cfg.Eve.QemuFirmware[i] = rename(firmware)
+	}
+	cfg.Sdn.ConfigDir = rename(cfg.Sdn.ConfigDir)
+	cfg.Sdn.ImageFile = rename(cfg.Sdn.ImageFile)
+	// files of SDN are not named after context in shared mode
+	cfg.Sdn.PidFile = filepath.Join(filepath.Dir(cfg.Sdn.PidFile), fmt.Sprintf("%s-sdn.pid", name))
+	cfg.Sdn.ConsoleLogFile = filepath.Join(filepath.Dir(cfg.Sdn.ConsoleLogFile), fmt.Sprintf("%s-sdn-console.log", name))
+
+	shift := (slot - cfg.Eden.Slot) * contextPortStep
+	cfg.Adam.Port += shift
+	cfg.Adam.Redis.Port += shift
+	cfg.Adam.Redis.Eden = shiftHostPort(cfg.Adam.Redis.Eden, shift)
+	cfg.Redis.Port += shift
+	cfg.Registry.Port += shift
+	cfg.Eden.EServer.Port += shift
+	cfg.Eve.TelnetPort += shift
+	cfg.Eve.QemuConfig.MonitorPort += shift
+	cfg.Eve.QemuConfig.NetDevSocketPort += shift
+	cfg.Sdn.TelnetPort += shift
+	cfg.Sdn.SSHPort += shift
+	cfg.Sdn.MgmtPort += shift
+	cfg.Sdn.IPv6Subnet = shiftIPv6Subnet(cfg.Sdn.IPv6Subnet, slot-cfg.Eden.Slot)
+	cfg.Sdn.IPv4Subnet = shiftIPv4Subnet(cfg.Sdn.IPv4Subnet, slot-cfg.Eden.Slot)
+	hostFwd := make(map[string]string, len(cfg.Eve.HostFwd))
+	for hostPort, guestPort := range cfg.Eve.HostFwd {
+		if port, err := strconv.Atoi(hostPort); err == nil {
+			hostPort = strconv.Itoa(port + shift)
+		}
+		hostFwd[hostPort] = guestPort
+	}
+	cfg.Eve.HostFwd = hostFwd
+
+	cfg.Eden.Slot = slot
+	cfg.ConfigName = name
+	cfg.Eve.Name = strings.ToLower(name)
+	cfg.Adam.Redis.RemoteURL = fmt.Sprintf("%s:%d", cfg.Containers().Redis, defaults.DefaultRedisPort)
+	return nil
+}
+
+// IsolateContext moves host resources of context in cfg to be saved with name
+// into its own ones using the first slot not used by other contexts of host,
+// the slot is reserved in port registry
+func IsolateContext(cfg *EdenSetupArgs, name string) error {
+	return allocatePorts(cfg, name, true)
+}
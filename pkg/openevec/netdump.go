@@ -0,0 +1,147 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// netdumpIntervalItem is config item which defines how often EVE publishes
+// netdumps after onboarding, we decrease it temporarily to trigger new netdump
+const netdumpIntervalItem = "netdump.topic.postonboard.interval"
+
+// netdumpEnableItem is config item which enables netdumps, it is set temporarily too
+const netdumpEnableItem = "netdump.enable"
+
+// listNetdumps returns names of netdump tarballs stored on EVE sorted from the oldest
+func (openEVEC *OpenEVEC) listNetdumps() ([]string, error) {
+	out, err := openEVEC.SdnForwardSSHToEveOutput(fmt.Sprintf("ls -1tr %s", eve.NetdumpDir))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list netdumps: %w", err)
+	}
+	var netdumps []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, ".tgz") {
+			netdumps = append(netdumps, line)
+		}
+	}
+	return netdumps, nil
+}
+
+// triggerNetdump decreases interval of netdump publishing and waits for new netdump
+// to appear on EVE, previous values of config items are restored after that
+func (openEVEC *OpenEVEC) triggerNetdump(ctx context.Context, existing []string, interval, timeout time.Duration) ([]string, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	items := map[string]string{
+		netdumpEnableItem:   "true",
+		netdumpIntervalItem: strconv.Itoa(int(interval.Seconds())),
+	}
+	oldItems := make(map[string]string)
+	for item, value := range items {
+		if old, ok := dev.GetConfigItems()[item]; ok {
+			oldItems[item] = old
+		}
+		dev.SetConfigItem(item, value)
+	}
+	// restore previous values even if sync fails, as it may be applied partially
+	defer func() {
+		for item := range items {
+			if old, ok := oldItems[item]; ok {
+				dev.SetConfigItem(item, old)
+			} else {
+				delete(dev.GetConfigItems(), item)
+			}
+		}
+		if err := ctrl.ConfigSync(dev); err != nil {
+			log.Errorf("cannot restore %s and %s: %s", netdumpEnableItem, netdumpIntervalItem, err)
+		}
+	}()
+	if err = ctrl.ConfigSync(dev); err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for _, el := range existing {
+		known[el] = true
+	}
+	log.Infof("Waiting for new netdump from EVE (up to %s)", timeout)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if err := utils.SleepContext(ctx, openEVEC.cfg.Timeouts.PollInterval); err != nil {
+			return nil, err
+		}
+		netdumps, err := openEVEC.listNetdumps()
+		if err != nil {
+			log.Debug(err)
+			continue
+		}
+		for _, el := range netdumps {
+			if !known[el] {
+				return netdumps, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no new netdump published by EVE during %s", timeout)
+}
+
+// EdenNetdump downloads last netdumps from EVE into outputDir, unpacks them
+// and prints summary of every netdump
+func (openEVEC *OpenEVEC) EdenNetdump(ctx context.Context, outputDir string, last int, trigger bool, interval, timeout time.Duration) error {
+	if err := openEVEC.enableSSHEve(); err != nil {
+		return err
+	}
+	netdumps, err := openEVEC.listNetdumps()
+	if err != nil {
+		return err
+	}
+	if trigger {
+		if timeout == 0 {
+			timeout = openEVEC.cfg.Timeouts.Netdump
+		}
+		if netdumps, err = openEVEC.triggerNetdump(ctx, netdumps, interval, timeout); err != nil {
+			return err
+		}
+	}
+	if len(netdumps) == 0 {
+		return fmt.Errorf("no netdumps found in %s on EVE", eve.NetdumpDir)
+	}
+	if last > 0 && len(netdumps) > last {
+		netdumps = netdumps[len(netdumps)-last:]
+	}
+	// show the newest first
+	for i, j := 0, len(netdumps)-1; i < j; i, j = i+1, j-1 {
+		netdumps[i], netdumps[j] = netdumps[j], netdumps[i]
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	for _, netdump := range netdumps {
+		localFile := filepath.Join(outputDir, netdump)
+		if err := openEVEC.SdnForwardSCPFromEve(path.Join(eve.NetdumpDir, netdump), localFile); err != nil {
+			return fmt.Errorf("cannot download %s: %w", netdump, err)
+		}
+		dir, err := eve.UnpackNetdump(localFile, strings.TrimSuffix(localFile, ".tgz"))
+		if err != nil {
+			return fmt.Errorf("cannot unpack %s: %w", netdump, err)
+		}
+		fmt.Printf("=== %s (unpacked into %s)\n", netdump, dir)
+		if err := eve.NetdumpSummary(dir, os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
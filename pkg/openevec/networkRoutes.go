@@ -0,0 +1,381 @@
+package openevec
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// findNetworkInstance returns config of network instance with name niName
+func findNetworkInstance(ctrl controller.Cloud, dev *device.Ctx, niName string) (*config.NetworkInstanceConfig, error) {
+	for _, el := range dev.GetNetworkInstances() {
+		ni, err := ctrl.GetNetworkInstanceConfig(el)
+		if err != nil {
+			return nil, fmt.Errorf("no network in cloud %s: %w", el, err)
+		}
+		if ni.Displayname == niName {
+			return ni, nil
+		}
+	}
+	return nil, fmt.Errorf("not found network with name %s", niName)
+}
+
+// incNetworkInstanceVersion increments version of network instance to make
+// EVE apply changes of its config
+func incNetworkInstanceVersion(ni *config.NetworkInstanceConfig) error {
+	version, err := strconv.Atoi(ni.Uuidandversion.Version)
+	if err != nil {
+		return fmt.Errorf("cannot parse version of network %s: %w", ni.Displayname, err)
+	}
+	ni.Uuidandversion.Version = strconv.Itoa(version + 1)
+	return nil
+}
+
+// lastNetworkInstanceInfo returns the last info about network instance sent
+// by EVE or nil if there is no one
+func lastNetworkInstanceInfo(ctrl controller.Cloud, dev *device.Ctx, niUUID string) (*info.ZInfoNetworkInstance, error) {
+	var niInfo *info.ZInfoNetworkInstance
+	q := map[string]string{"InfoContent.Niinfo.NetworkID": niUUID}
+	if err := ctrl.InfoLastCallback(dev.GetID(), q, func(im *info.ZInfoMsg) bool {
+		niInfo = im.GetNiinfo()
+		return false
+	}); err != nil {
+		return nil, fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	return niInfo, nil
+}
+
+// NetworkRouteAdd adds static route to destination network via gateway
+// and/or port into network instance with name niName, route with the same
+// destination is replaced
+func (openEVEC *OpenEVEC) NetworkRouteAdd(niName, destination, gateway, port string) error {
+	route, err := expect.NetInstanceStaticRoute(destination, gateway, port)
+	if err != nil {
+		return err
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ni, err := findNetworkInstance(ctrl, dev, niName)
+	if err != nil {
+		return err
+	}
+	if ni.InstType != config.ZNetworkInstType_ZnetInstLocal {
+		return fmt.Errorf("static routes are supported only for local network")
+	}
+	replaced := false
+	for i, el := range ni.StaticRoutes {
+		if el.DestinationNetwork == destination {
+			ni.StaticRoutes[i] = route
+			replaced = true
+		}
+	}
+	if !replaced {
+		ni.StaticRoutes = append(ni.StaticRoutes, route)
+	}
+	if err = incNetworkInstanceVersion(ni); err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("route to %s added into network %s", destination, niName)
+	return nil
+}
+
+// NetworkRouteDelete removes static route to destination network from
+// network instance with name niName
+func (openEVEC *OpenEVEC) NetworkRouteDelete(niName, destination string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ni, err := findNetworkInstance(ctrl, dev, niName)
+	if err != nil {
+		return err
+	}
+	var routes []*config.IPRoute
+	for _, el := range ni.StaticRoutes {
+		if el.DestinationNetwork != destination {
+			routes = append(routes, el)
+		}
+	}
+	if len(routes) == len(ni.StaticRoutes) {
+		return fmt.Errorf("not found route to %s in network %s", destination, niName)
+	}
+	ni.StaticRoutes = routes
+	if err = incNetworkInstanceVersion(ni); err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("route to %s deleted from network %s", destination, niName)
+	return nil
+}
+
+// NetworkRouteLs prints static routes of network instance with name niName
+// and whether EVE reports them as applied, routes added by EVE itself
+// (connected and default ones) are printed as well
+func (openEVEC *OpenEVEC) NetworkRouteLs(niName string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ni, err := findNetworkInstance(ctrl, dev, niName)
+	if err != nil {
+		return err
+	}
+	niInfo, err := lastNetworkInstanceInfo(ctrl, dev, ni.Uuidandversion.Uuid)
+	if err != nil {
+		return err
+	}
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err = fmt.Fprintln(w, "DESTINATION\tGATEWAY\tPORT\tSTATE(ADAM)\tSTATE(EVE)"); err != nil {
+		return err
+	}
+	reported := niInfo.GetIpRoutes()
+	seen := make(map[int]bool)
+	for _, route := range ni.StaticRoutes {
+		state := "UNKNOWN"
+		if niInfo != nil {
+			state = "NOT_APPLIED"
+			for i, el := range reported {
+				if el.DestinationNetwork == route.DestinationNetwork &&
+					(route.Gateway == "" || el.Gateway == route.Gateway) {
+					state = "APPLIED"
+					seen[i] = true
+				}
+			}
+		}
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", route.DestinationNetwork,
+			orDash(route.Gateway), orDash(route.Port), "IN_CONFIG", state); err != nil {
+			return err
+		}
+	}
+	for i, el := range reported {
+		if seen[i] {
+			continue
+		}
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", el.DestinationNetwork,
+			orDash(el.Gateway), orDash(el.Port), "-", "APPLIED"); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// netInstAppInterface is interface of application connected to network instance
+type netInstAppInterface struct {
+	app   *config.AppInstanceConfig
+	index int
+}
+
+// netInstAppInterfaces returns interfaces of applications connected to
+// network instance with UUID niUUID
+func netInstAppInterfaces(ctrl controller.Cloud, dev *device.Ctx, niUUID string) ([]netInstAppInterface, error) {
+	var result []netInstAppInterface
+	for _, appID := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(appID)
+		if err != nil {
+			return nil, fmt.Errorf("no app in cloud %s: %w", appID, err)
+		}
+		for i, intf := range app.Interfaces {
+			if intf.NetworkId == niUUID {
+				result = append(result, netInstAppInterface{app: app, index: i})
+			}
+		}
+	}
+	return result, nil
+}
+
+// NetworkReservationAdd reserves ip in network instance with name niName for
+// application with name appName, optionally with MAC address of its interface,
+// application is purged to apply it
+func (openEVEC *OpenEVEC) NetworkReservationAdd(niName, appName, ip, mac string) error {
+	if mac != "" {
+		if _, err := net.ParseMAC(mac); err != nil {
+			return fmt.Errorf("invalid MAC address %s: %w", mac, err)
+		}
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ni, err := findNetworkInstance(ctrl, dev, niName)
+	if err != nil {
+		return err
+	}
+	if ni.InstType != config.ZNetworkInstType_ZnetInstLocal || ni.Ip == nil {
+		return fmt.Errorf("IP reservations are supported only for local network")
+	}
+	_, subnet, err := net.ParseCIDR(ni.Ip.Subnet)
+	if err != nil {
+		return fmt.Errorf("invalid subnet of network %s: %w", niName, err)
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil || !subnet.Contains(addr) {
+		return fmt.Errorf("IP %s is not in subnet %s of network %s", ip, ni.Ip.Subnet, niName)
+	}
+	if addr.Equal(net.ParseIP(ni.Ip.Gateway)) {
+		return fmt.Errorf("IP %s is gateway of network %s", ip, niName)
+	}
+	interfaces, err := netInstAppInterfaces(ctrl, dev, ni.Uuidandversion.Uuid)
+	if err != nil {
+		return err
+	}
+	var intf *config.NetworkAdapter
+	var app *config.AppInstanceConfig
+	for _, el := range interfaces {
+		current := el.app.Interfaces[el.index]
+		if el.app.Displayname == appName {
+			if intf == nil {
+				intf, app = current, el.app
+			}
+			continue
+		}
+		if current.Addr != "" && net.ParseIP(current.Addr).Equal(addr) {
+			return fmt.Errorf("IP %s is already reserved for app %s", ip, el.app.Displayname)
+		}
+	}
+	if intf == nil {
+		return fmt.Errorf("app %s is not connected to network %s", appName, niName)
+	}
+	intf.Addr = ip
+	if mac != "" {
+		intf.MacAddress = mac
+	}
+	if app.Purge == nil {
+		app.Purge = &config.InstanceOpsCmd{Counter: 0}
+	}
+	app.Purge.Counter++
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("IP %s reserved for app %s in network %s", ip, appName, niName)
+	return nil
+}
+
+// NetworkReservationDelete removes IP reservation of application with name
+// appName in network instance with name niName
+func (openEVEC *OpenEVEC) NetworkReservationDelete(niName, appName string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ni, err := findNetworkInstance(ctrl, dev, niName)
+	if err != nil {
+		return err
+	}
+	interfaces, err := netInstAppInterfaces(ctrl, dev, ni.Uuidandversion.Uuid)
+	if err != nil {
+		return err
+	}
+	for _, el := range interfaces {
+		intf := el.app.Interfaces[el.index]
+		if el.app.Displayname != appName || intf.Addr == "" {
+			continue
+		}
+		intf.Addr = ""
+		if el.app.Purge == nil {
+			el.app.Purge = &config.InstanceOpsCmd{Counter: 0}
+		}
+		el.app.Purge.Counter++
+		if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+			return fmt.Errorf("setControllerAndDev: %w", err)
+		}
+		log.Infof("IP reservation of app %s in network %s deleted", appName, niName)
+		return nil
+	}
+	return fmt.Errorf("not found IP reservation of app %s in network %s", appName, niName)
+}
+
+// NetworkReservationLs prints IP addresses reserved for applications in
+// network instance with name niName and addresses assigned to them by EVE
+func (openEVEC *OpenEVEC) NetworkReservationLs(niName string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	ni, err := findNetworkInstance(ctrl, dev, niName)
+	if err != nil {
+		return err
+	}
+	niInfo, err := lastNetworkInstanceInfo(ctrl, dev, ni.Uuidandversion.Uuid)
+	if err != nil {
+		return err
+	}
+	interfaces, err := netInstAppInterfaces(ctrl, dev, ni.Uuidandversion.Uuid)
+	if err != nil {
+		return err
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err = fmt.Fprintln(w, "APP\tMAC\tIP(ADAM)\tIP(EVE)\tSTATE"); err != nil {
+		return err
+	}
+	for _, el := range interfaces {
+		intf := el.app.Interfaces[el.index]
+		mac := intf.MacAddress
+		if mac == "" {
+			// MAC address generated by EVE is reported in info of application
+			q := map[string]string{"InfoContent.Ainfo.AppID": el.app.Uuidandversion.Uuid}
+			if err = ctrl.InfoLastCallback(dev.GetID(), q, func(im *info.ZInfoMsg) bool {
+				if network := im.GetAinfo().GetNetwork(); len(network) > el.index {
+					mac = network[el.index].MacAddr
+				}
+				return false
+			}); err != nil {
+				return fmt.Errorf("fail in get InfoLastCallback: %w", err)
+			}
+		}
+		assigned := "-"
+		for _, assignment := range niInfo.GetIpAssignments() {
+			if mac != "" && assignment.MacAddress == mac && len(assignment.IpAddress) > 0 {
+				assigned = assignment.IpAddress[0]
+			}
+		}
+		reserved, state := intf.Addr, "DYNAMIC"
+		switch {
+		case reserved == "":
+			reserved = "-"
+		case assigned == "-":
+			state = "UNKNOWN"
+		case net.ParseIP(assigned).Equal(net.ParseIP(reserved)):
+			state = "APPLIED"
+		default:
+			state = "NOT_APPLIED"
+		}
+		if mac == "" {
+			mac = "-"
+		}
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			el.app.Displayname, mac, reserved, assigned, state); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -1,41 +1,74 @@
 package openevec
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
 	log "github.com/sirupsen/logrus"
 )
 
-func (openEVEC *OpenEVEC) OnboardEve(eveUUID string) error {
-
+// prepareOnboard returns controller and device of context, device is nil if
+// it is not registered in controller yet
+func (openEVEC *OpenEVEC) prepareOnboard(eveUUID string) (controller.Cloud, *device.Ctx, error) {
 	edenDir, err := utils.DefaultEdenDir()
 	if err != nil {
-		return fmt.Errorf("error getting default eden dir %w", err)
+		return nil, nil, fmt.Errorf("error getting default eden dir %w", err)
 	}
 	if err = utils.TouchFile(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", eveUUID))); err != nil {
-		return fmt.Errorf("error getting file %w", err)
+		return nil, nil, fmt.Errorf("error getting file %w", err)
 	}
 	changer := &adamChanger{}
 	ctrl, err := changer.getController()
 	if err != nil {
-		return fmt.Errorf("error fetching controller %w", err)
+		return nil, nil, fmt.Errorf("error fetching controller %w", err)
 	}
 	vars, err := InitVarsFromConfig(openEVEC.cfg)
 	if err != nil {
-		return fmt.Errorf("InitVarsFromConfig error: %w", err)
+		return nil, nil, fmt.Errorf("InitVarsFromConfig error: %w", err)
 	}
 	ctrl.SetVars(vars)
 	dev, err := ctrl.GetDeviceCurrent()
-	if err != nil || dev == nil {
+	if err != nil {
+		return ctrl, nil, nil
+	}
+	return ctrl, dev, nil
+}
+
+// newEdgeNode returns new device to register in controller
+func newEdgeNode(vars *utils.ConfigVars) *device.Ctx {
+	dev := device.CreateEdgeNode()
+	dev.SetSerial(vars.EveSerial)
+	dev.SetOnboardKey(vars.EveCert)
+	dev.SetDevModel(vars.DevModel)
+	return dev
+}
+
+// OnboardEve registers EVE in controller and waits for its onboarding up to
+// timeouts.onboard of config or until ctx is done
+func (openEVEC *OpenEVEC) OnboardEve(ctx context.Context, eveUUID string) error {
+	ctrl, dev, err := openEVEC.prepareOnboard(eveUUID)
+	if err != nil {
+		return err
+	}
+	if dev == nil {
 		// create new one if not exists
-		dev = device.CreateEdgeNode()
-		dev.SetSerial(vars.EveSerial)
-		dev.SetOnboardKey(vars.EveCert)
-		dev.SetDevModel(vars.DevModel)
-		err = ctrl.OnBoardDev(dev)
+		dev = newEdgeNode(ctrl.GetVars())
+		ctx, cancel := context.WithTimeout(ctx, openEVEC.cfg.Timeouts.Onboard)
+		defer cancel()
+		err = ctrl.OnBoardDevWait(ctx, dev, nil)
+		if errors.Is(err, controller.ErrOnboardTimeout) {
+			return fmt.Errorf("%w in %s. You may try to run 'eden eve onboard' command again "+
+				"in several minutes. If not successful see logs of adam/eve", ErrNotOnboarded, openEVEC.cfg.Timeouts.Onboard)
+		}
 		if err != nil {
 			return fmt.Errorf("error onboarding %w", err)
 		}
@@ -46,5 +79,184 @@ func (openEVEC *OpenEVEC) OnboardEve(eveUUID string) error {
 	log.Info("onboarded")
 	log.Info("device UUID: ", dev.GetID().String())
 
+	return openEVEC.runHooks(ctx, HookPostOnboard)
+}
+
+// adamLogEvent is event of onboarding found in logs of Adam
+type adamLogEvent int
+
+const (
+	adamLogOther adamLogEvent = iota
+	// adamLogRequest is request of EVE processed by Adam
+	adamLogRequest
+	// adamLogTLSError is failed TLS handshake (EVE does not trust Adam or vice versa)
+	adamLogTLSError
+	// adamLogCertError is registration rejected due to onboarding certificate or serial
+	adamLogCertError
+)
+
+// classifyAdamLogLine returns event of onboarding logged by Adam in line
+func classifyAdamLogLine(line string) adamLogEvent {
+	l := strings.ToLower(line)
+	switch {
+	case strings.Contains(l, "tls handshake error"):
+		return adamLogTLSError
+	case strings.Contains(l, "register") || strings.Contains(l, "onboard"):
+		for _, failure := range []string{"not found", "mismatch", "invalid", "unknown", "error", "fail", "reject"} {
+			if strings.Contains(l, failure) {
+				return adamLogCertError
+			}
+		}
+		return adamLogRequest
+	case strings.Contains(l, "/api/v"):
+		return adamLogRequest
+	}
+	return adamLogOther
+}
+
+// onboardWatcher observes stages of onboarding in Adam logs
+type onboardWatcher struct {
+	// adamContainer is container to read logs from, empty for remote Adam
+	adamContainer string
+	since         time.Time
+	trafficSeen   bool
+	tlsErrors     []string
+	certErrors    []string
+}
+
+// process processes lines of Adam logs reporting the first traffic from EVE
+func (w *onboardWatcher) process(lines []string) {
+	for _, line := range lines {
+		event := classifyAdamLogLine(line)
+		switch event {
+		case adamLogOther:
+			continue
+		case adamLogTLSError:
+			w.tlsErrors = append(w.tlsErrors, line)
+		case adamLogCertError:
+			w.certErrors = append(w.certErrors, line)
+		}
+		if !w.trafficSeen {
+			w.trafficSeen = true
+			log.Info("[1/3] traffic of EVE seen by controller")
+		}
+	}
+}
+
+// poll reads logs of Adam produced since the previous call
+func (w *onboardWatcher) poll() error {
+	if w.adamContainer == "" {
+		return nil
+	}
+	now := time.Now()
+	var buf bytes.Buffer
+	if err := utils.ContainerLogsSince(w.adamContainer, w.since, &buf); err != nil {
+		log.Debugf("cannot read logs of %s: %s", w.adamContainer, err)
+		return nil
+	}
+	w.since = now
+	w.process(strings.Split(buf.String(), "\n"))
 	return nil
 }
+
+// diagnostics returns description of the most probable reason of failed onboarding
+func (w *onboardWatcher) diagnostics(cfg *EdenSetupArgs) []string {
+	const maxLines = 5
+	last := func(lines []string) []string {
+		if len(lines) > maxLines {
+			return lines[len(lines)-maxLines:]
+		}
+		return lines
+	}
+	switch {
+	case len(w.certErrors) > 0:
+		return append([]string{fmt.Sprintf(
+			"registration of EVE is rejected: onboarding certificate or serial of EVE does not match the ones "+
+				"registered in controller (eve.serial %s, certificate %s), regenerate image of EVE with 'eden setup'",
+			cfg.Eve.Serial, filepath.Join(cfg.Eden.CertsDir, "onboard.cert.pem"))}, last(w.certErrors)...)
+	case len(w.tlsErrors) > 0:
+		return append([]string{
+			"TLS handshake of EVE with controller failed: root certificate or server name in config of EVE " +
+				"does not match certificate of Adam, regenerate certificates and image of EVE with 'eden setup'"},
+			last(w.tlsErrors)...)
+	case w.trafficSeen:
+		return []string{"EVE reached controller but did not register: see logs of Adam with 'docker logs " +
+			w.adamContainer + "' and console of EVE"}
+	case w.adamContainer == "":
+		return []string{"EVE is not registered in remote controller: check that EVE is running and can reach the controller"}
+	}
+	return []string{fmt.Sprintf(
+		"no traffic of EVE seen by controller: check that EVE is running ('eden eve status'), "+
+			"address %s:%d of Adam is reachable from EVE and 'server' in config of EVE points to it",
+		cfg.Adam.CertsEVEIP, cfg.Adam.Port)}
+}
+
+// OnboardEveWait onboards EVE reporting stages of onboarding (traffic of EVE
+// seen, device certificate issued, first info received) and prints diagnostics
+// if onboarding does not complete in timeout (timeouts.onboard of config if zero).
+// Waiting is aborted when ctx is done.
+func (openEVEC *OpenEVEC) OnboardEveWait(ctx context.Context, eveUUID string, timeout time.Duration) error {
+	cfg := openEVEC.cfg
+	if timeout == 0 {
+		timeout = cfg.Timeouts.Onboard
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctrl, dev, err := openEVEC.prepareOnboard(eveUUID)
+	if err != nil {
+		return err
+	}
+	watcher := &onboardWatcher{since: time.Now()}
+	if !cfg.Adam.Remote.Enabled {
+		watcher.adamContainer = cfg.Containers().Adam
+	}
+	printDiagnostics := func() {
+		for _, line := range watcher.diagnostics(cfg) {
+			fmt.Println(line)
+		}
+	}
+	if dev == nil {
+		log.Infof("waiting for onboarding of EVE up to %s", timeout)
+		dev = newEdgeNode(ctrl.GetVars())
+		err = ctrl.OnBoardDevWait(ctx, dev, watcher.poll)
+		if errors.Is(err, controller.ErrOnboardTimeout) {
+			printDiagnostics()
+			return fmt.Errorf("%w in %s", ErrNotOnboarded, timeout)
+		}
+		if err != nil {
+			return fmt.Errorf("error onboarding %w", err)
+		}
+		if !watcher.trafficSeen {
+			log.Info("[1/3] traffic of EVE seen by controller")
+		}
+	} else {
+		log.Info("[1/3] EVE is already registered in controller")
+	}
+	log.Infof("[2/3] device certificate issued, device UUID: %s", dev.GetID())
+	infoReceived := false
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, func(_ *info.ZInfoMsg) bool {
+		infoReceived = true
+		return true
+	}); err != nil {
+		log.Debugf("InfoLastCallback: %s", err)
+	}
+	if !infoReceived {
+		if err := ctrl.InfoWatch(ctx, dev.GetID(), nil, func(_ *info.ZInfoMsg) bool {
+			return true
+		}); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.Debugf("InfoWatch: %s", err)
+			fmt.Println("EVE is registered, but no info received from it: EVE cannot get config from controller " +
+				"or send info to it, see logs of EVE with 'eden log' or its console")
+			return fmt.Errorf("no info received from EVE in %s", timeout)
+		}
+	}
+	log.Info("[3/3] first info received from EVE")
+	if err = ctrl.StateUpdate(dev); err != nil {
+		return fmt.Errorf("error fetching state %w", err)
+	}
+	log.Info("onboarded")
+	return openEVEC.runHooks(ctx, HookPostOnboard)
+}
@@ -0,0 +1,121 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// eveOverlayFile returns qcow2 overlay of eve.image-file used by EVE VM of context,
+// overlays are named by contexts, so contexts can share one image
+func (openEVEC *OpenEVEC) eveOverlayFile() (string, error) {
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(openEVEC.cfg.Eve.ImageFile), fmt.Sprintf("%s-overlay.qcow2", configName)), nil
+}
+
+// prepareEveOverlay returns overlay to run EVE VM on, it is created if it does
+// not exist, recreate is set or image was changed (e.g. by setup) after overlay creation
+func (openEVEC *OpenEVEC) prepareEveOverlay(recreate bool) (string, error) {
+	overlayFile, err := openEVEC.eveOverlayFile()
+	if err != nil {
+		return "", err
+	}
+	imageFile, err := filepath.Abs(openEVEC.cfg.Eve.ImageFile)
+	if err != nil {
+		return "", err
+	}
+	imageInfo, err := os.Stat(imageFile)
+	if err != nil {
+		return "", fmt.Errorf("cannot stat image of EVE: %w", err)
+	}
+	overlayInfo, err := os.Stat(overlayFile)
+	switch {
+	case recreate:
+	case os.IsNotExist(err):
+	case err != nil:
+		return "", err
+	case imageInfo.ModTime().After(overlayInfo.ModTime()):
+		log.Warnf("image of EVE %s is newer than overlay, recreating overlay", imageFile)
+	default:
+		backingFile, err := eden.BackingFileQemu(overlayFile)
+		if err != nil {
+			return "", err
+		}
+		if backingFile == imageFile {
+			return overlayFile, nil
+		}
+		log.Warnf("overlay is backed by %s instead of %s, recreating overlay", backingFile, imageFile)
+	}
+	imageFormat, err := utils.ImageFormat(imageFile)
+	if err != nil {
+		return "", err
+	}
+	if err := eden.CreateOverlayQemu(imageFile, imageFormat, overlayFile); err != nil {
+		return "", err
+	}
+	log.Infof("overlay of EVE image created: %s", overlayFile)
+	return overlayFile, nil
+}
+
+// resetEveDisk restarts EVE VM on new overlay, so EVE boots from pristine image
+// again, and onboards it into controller as new device
+func (openEVEC *OpenEVEC) resetEveDisk(ctx context.Context) error {
+	cfg := openEVEC.cfg
+	if cfg.Eve.DevModel != defaults.DefaultQemuModel || cfg.Eve.Remote || !cfg.Eve.QemuConfig.Overlay {
+		return fmt.Errorf("reset of disk is supported only for local EVE with devmodel %s and eve.qemu.overlay enabled",
+			defaults.DefaultQemuModel)
+	}
+	if err := openEVEC.StopEve(ctx, ""); err != nil {
+		return fmt.Errorf("cannot stop EVE: %w", err)
+	}
+	changer := &adamChanger{}
+	ctrl, err := changer.getController()
+	if err != nil {
+		return fmt.Errorf("getController: %w", err)
+	}
+	// EVE with new disk registers with new device certificate
+	if devUUID, err := ctrl.DeviceGetByOnboardUUID(cfg.Eve.CertsUUID); err == nil {
+		if err := ctrl.DeviceRemove(devUUID); err != nil {
+			return fmt.Errorf("cannot remove device %s: %w", devUUID, err)
+		}
+		log.Infof("device %s removed from controller", devUUID)
+	}
+	// snapshots of VM are stored inside overlay
+	if configFile, err := openEVEC.snapshotConfigFile(defaults.DefaultEveSnapshot); err == nil {
+		if err := os.RemoveAll(filepath.Dir(configFile)); err != nil {
+			return fmt.Errorf("cannot remove snapshots: %w", err)
+		}
+	}
+	if cfg.Eve.TPM {
+		if err := os.RemoveAll(filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "swtpm")); err != nil {
+			return fmt.Errorf("cannot remove state of swtpm: %w", err)
+		}
+	}
+	if _, err := openEVEC.prepareEveOverlay(true); err != nil {
+		return err
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", cfg.Eve.CertsUUID))); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := openEVEC.StartEve(ctx, "", ""); err != nil {
+		return fmt.Errorf("cannot start EVE: %w", err)
+	}
+	if err := openEVEC.OnboardEve(ctx, cfg.Eve.CertsUUID); err != nil {
+		return fmt.Errorf("cannot onboard EVE: %w", err)
+	}
+	log.Info("reset of disk done")
+	return nil
+}
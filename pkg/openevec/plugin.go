@@ -0,0 +1,179 @@
+package openevec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// PluginPrefix is prefix of executables on PATH which are run as subcommands of eden
+const PluginPrefix = "eden-"
+
+// Environment variables with context passed to plugins
+const (
+	PluginEnvConfigFile  = "EDEN_CONFIG_FILE"
+	PluginEnvHome        = "EDEN_HOME"
+	PluginEnvBin         = "EDEN_BIN"
+	PluginEnvProg        = "EDEN_PROG"
+	PluginEnvVersion     = "EDEN_VERSION"
+	PluginEnvContextDump = "EDEN_CONTEXT_DUMP"
+)
+
+// PluginContext is dump of context passed to plugins and hooks in file set in EDEN_CONTEXT_DUMP
+type PluginContext struct {
+	Name       string `json:"name"`
+	ConfigFile string `json:"config-file"`
+	Root       string `json:"root"`
+	BinDir     string `json:"bin-dir"`
+	Version    string `json:"version"`
+	DevModel   string `json:"devmodel"`
+	Arch       string `json:"arch"`
+	AdamIP     string `json:"adam-ip"`
+	AdamPort   string `json:"adam-port"`
+	CertsDir   string `json:"certs-dir"`
+	SSHKey     string `json:"ssh-key"`
+}
+
+// FindPlugin returns executable of plugin for the longest sequence of args which are not flags
+// (e.g. eden-foo-bar for "foo bar") and number of args consumed by its name
+func FindPlugin(args []string) (string, int) {
+	var names []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		names = append(names, arg)
+	}
+	for n := len(names); n > 0; n-- {
+		if path, err := exec.LookPath(PluginPrefix + strings.Join(names[:n], "-")); err == nil {
+			return path, n
+		}
+	}
+	return "", 0
+}
+
+// ListPlugins returns executables of plugins found on PATH, plugins with the same
+// name in later directories of PATH are shadowed and not returned
+func ListPlugins() []string {
+	found := map[string]string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, PluginPrefix) || len(name) == len(PluginPrefix) {
+				continue
+			}
+			if _, ok := found[name]; ok {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+				continue
+			}
+			found[name] = filepath.Join(dir, name)
+		}
+	}
+	var plugins []string
+	for _, path := range found {
+		plugins = append(plugins, path)
+	}
+	sort.Strings(plugins)
+	return plugins
+}
+
+// PluginContext returns dump of context of openEVEC for plugins
+func (openEVEC *OpenEVEC) PluginContext() (*PluginContext, error) {
+	cfg := openEVEC.cfg
+	name, err := openEVEC.contextName()
+	if err != nil {
+		return nil, err
+	}
+	vars, err := InitVarsFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("InitVarsFromConfig error: %w", err)
+	}
+	return &PluginContext{
+		Name:       name,
+		ConfigFile: utils.GetConfig(name),
+		Root:       cfg.Eden.Root,
+		BinDir:     cfg.Eden.BinDir,
+		Version:    defaults.EdenVersion,
+		DevModel:   cfg.Eve.DevModel,
+		Arch:       cfg.Eve.Arch,
+		AdamIP:     vars.AdamIP,
+		AdamPort:   vars.AdamPort,
+		CertsDir:   cfg.Eden.CertsDir,
+		SSHKey:     cfg.Eden.SSHKey,
+	}, nil
+}
+
+// contextEnv returns environment with pluginCtx (if not nil) in variables and in file
+// with PluginContext in JSON, cleanup removes the file
+func contextEnv(pluginCtx *PluginContext) (env []string, cleanup func(), err error) {
+	env = os.Environ()
+	if prog, err := os.Executable(); err == nil {
+		env = append(env, PluginEnvProg+"="+prog)
+	}
+	env = append(env, PluginEnvVersion+"="+defaults.EdenVersion)
+	if pluginCtx == nil {
+		return env, func() {}, nil
+	}
+	dump, err := os.CreateTemp("", "eden-context-*.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.Remove(dump.Name()) }
+	err = json.NewEncoder(dump).Encode(pluginCtx)
+	if closeErr := dump.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("cannot save context: %w", err)
+	}
+	env = append(env,
+		// nested calls of eden use the same context
+		defaults.DefaultConfigEnv+"="+pluginCtx.Name,
+		PluginEnvConfigFile+"="+pluginCtx.ConfigFile,
+		PluginEnvHome+"="+pluginCtx.Root,
+		PluginEnvBin+"="+pluginCtx.BinDir,
+		PluginEnvContextDump+"="+dump.Name(),
+	)
+	return env, cleanup, nil
+}
+
+// RunPlugin runs executable of plugin with args passing pluginCtx (if not nil) in environment
+// and in file with PluginContext in JSON, exit code of plugin is returned
+func RunPlugin(plugin string, args []string, pluginCtx *PluginContext) (int, error) {
+	env, cleanup, err := contextEnv(pluginCtx)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+	log.Debugf("run plugin %s %s", plugin, strings.Join(args, " "))
+	cmd := exec.Command(plugin, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("cannot run plugin %s: %w", plugin, err)
+	}
+	return 0, nil
+}
@@ -0,0 +1,49 @@
+package openevec_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	. "github.com/onsi/gomega"
+)
+
+func TestPlugins(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	// plugin saves context dump and exits with code of its first argument
+	// only builtins of shell are used as PATH contains only plugins
+	script := "#!/bin/sh\nread -r dump < \"$EDEN_CONTEXT_DUMP\"\necho \"$dump\" > " + out + "\nexit $1\n"
+	g.Expect(os.WriteFile(filepath.Join(dir, "eden-foo"), []byte(script), 0755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "eden-foo-bar"), []byte(script), 0755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "eden-data"), nil, 0644)).To(Succeed())
+	t.Setenv("PATH", dir)
+
+	plugin, consumed := openevec.FindPlugin([]string{"foo", "bar", "3"})
+	g.Expect(plugin).To(Equal(filepath.Join(dir, "eden-foo-bar")))
+	g.Expect(consumed).To(Equal(2))
+	plugin, consumed = openevec.FindPlugin([]string{"foo", "--bar"})
+	g.Expect(plugin).To(Equal(filepath.Join(dir, "eden-foo")))
+	g.Expect(consumed).To(Equal(1))
+	plugin, _ = openevec.FindPlugin([]string{"data"})
+	g.Expect(plugin).To(BeEmpty())
+
+	g.Expect(openevec.ListPlugins()).To(Equal([]string{
+		filepath.Join(dir, "eden-foo"),
+		filepath.Join(dir, "eden-foo-bar"),
+	}))
+
+	pluginCtx := &openevec.PluginContext{Name: "test", DevModel: "ZedVirtual-4G"}
+	code, err := openevec.RunPlugin(filepath.Join(dir, "eden-foo"), []string{"3"}, pluginCtx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(code).To(Equal(3))
+	data, err := os.ReadFile(out)
+	g.Expect(err).ToNot(HaveOccurred())
+	var dump openevec.PluginContext
+	g.Expect(json.Unmarshal(data, &dump)).To(Succeed())
+	g.Expect(dump).To(Equal(*pluginCtx))
+}
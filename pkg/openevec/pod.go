@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/pkg/controller/eapps"
@@ -66,7 +68,67 @@ func processVLANs(vlans []string) (map[string]int, error) {
 	return m, nil
 }
 
+// buildImageName returns name of image built from directory dir
+func buildImageName(dir string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, filepath.Base(dir))
+	name = strings.Trim(name, "-_.")
+	if name == "" {
+		name = "app"
+	}
+	return name
+}
+
+// PodBuild builds image from Dockerfile in directory dir for architecture of EVE,
+// loads it into local registry and returns link to deploy it from there
+func (openEVEC *OpenEVEC) PodBuild(dir string) (string, error) {
+	cfg := openEVEC.cfg
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(absDir, "Dockerfile")); err != nil {
+		return "", fmt.Errorf("no Dockerfile in %s: %w", absDir, err)
+	}
+	// unique tag, so EVE does not reuse image of the previous build
+	image := fmt.Sprintf("%s:build-%d", buildImageName(absDir), time.Now().Unix())
+	log.Infof("Build image %s from %s", image, absDir)
+	if err := utils.CreateImage(absDir, image, fmt.Sprintf("linux/%s", cfg.Eve.Arch)); err != nil {
+		return "", fmt.Errorf("cannot build %s: %w", image, err)
+	}
+	registry := fmt.Sprintf("%s:%d", cfg.Registry.IP, cfg.Registry.Port)
+	if utils.IsDryRun() {
+		// image is neither built nor pushed
+		_, err := utils.LoadRegistry(image, registry)
+		return fmt.Sprintf("docker://%s", image), err
+	}
+	if exists, err := utils.HasImage(image); err != nil {
+		return "", err
+	} else if !exists {
+		return "", fmt.Errorf("build of %s failed, see output of docker above", image)
+	}
+	hash, err := utils.LoadRegistry(image, registry)
+	if err != nil {
+		return "", fmt.Errorf("cannot load %s into local registry %s (is it started with 'eden registry start'?): %w",
+			image, registry, err)
+	}
+	log.Infof("Image %s loaded into local registry with manifest hash %s", image, hash)
+	return fmt.Sprintf("docker://%s", image), nil
+}
+
 func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetupArgs) error {
+	if pc.ShowConfig {
+		// keep stdout for config only
+		log.SetOutput(os.Stderr)
+		utils.SetDryRunOutput(os.Stderr)
+	}
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -132,6 +194,7 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 		registryToUse = ""
 	}
 	opts = append(opts, expect.WithRegistry(registryToUse))
+	opts = append(opts, expect.WithRegistryAuth(openEVEC.cfg.Eden.RegistryAuth))
 	if pc.NoHyper {
 		opts = append(opts, expect.WithVirtualizationMode(config.VmMode_NOHYPER))
 	}
@@ -140,32 +203,65 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 	opts = append(opts, expect.WithDatastoreOverride(pc.DatastoreOverride))
 	opts = append(opts, expect.WithStartDelay(pc.StartDelay))
 	opts = append(opts, expect.WithPinCpus(pc.PinCpus))
+	var probe *eve.AppProbe
+	if pc.Probe != "" {
+		if probe, err = eve.ParseAppProbe(pc.Probe); err != nil {
+			return err
+		}
+	}
 	expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, pc.Name, opts...)
 	appInstanceConfig := expectation.Application()
 	dev.SetApplicationInstanceConfig(append(dev.GetApplicationInstances(), appInstanceConfig.Uuidandversion.Uuid))
+	if pc.ShowConfig {
+		data, err := podConfigJSON(ctrl, appInstanceConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	if utils.IsDryRun() {
+		utils.DryRunf("send config of pod %s with %s to controller", appInstanceConfig.Displayname, appLink)
+		if probe != nil {
+			utils.DryRunf("save probe %s of pod %s", pc.Probe, appInstanceConfig.Displayname)
+		}
+		return nil
+	}
 	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
 		return fmt.Errorf("setControllerAndDev: %w", err)
 	}
 	log.Infof("deploy pod %s with %s request sent", appInstanceConfig.Displayname, appLink)
+	if probe != nil {
+		if err = openEVEC.setAppProbe(appInstanceConfig.Displayname, probe); err != nil {
+			return fmt.Errorf("cannot save probe: %w", err)
+		}
+	}
 	return nil
 }
 
-func (openEVEC *OpenEVEC) PodPs(outputFormat types.OutputFormat) error {
-	changer := &adamChanger{}
-	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+func (openEVEC *OpenEVEC) PodPs(outputFormat types.OutputFormat, offline bool) error {
+	ctrl, dev, err := openEVEC.getControllerAndDev(offline)
 	if err != nil {
-		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+		return err
 	}
 	state := eve.Init(ctrl, dev)
-	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+	twin := newTwinRecorder()
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, twin.infoCallback(state.InfoCallback())); err != nil {
 		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
 	}
-	if err := ctrl.MetricLastCallback(dev.GetID(), nil, state.MetricCallback()); err != nil {
+	if err := ctrl.MetricLastCallback(dev.GetID(), nil, twin.metricCallback(state.MetricCallback())); err != nil {
 		return fmt.Errorf("fail in get MetricLastCallback: %w", err)
 	}
+	if !offline {
+		if err := openEVEC.setAppsHealth(state); err != nil {
+			log.Warnf("cannot run app probes: %s", err)
+		}
+	}
 	if err := state.PodsList(outputFormat); err != nil {
 		return err
 	}
+	if !offline {
+		openEVEC.updateTwin(ctrl, dev, twin)
+	}
 	return nil
 }
 
@@ -339,6 +435,9 @@ func (openEVEC *OpenEVEC) PodDelete(appName string, deleteVolumes bool) (bool, e
 			if err = changer.setControllerAndDev(ctrl, dev); err != nil {
 				return false, fmt.Errorf("setControllerAndDev: %w", err)
 			}
+			if err = openEVEC.setAppProbe(appName, nil); err != nil {
+				log.Warnf("cannot remove probe of app %s: %s", appName, err)
+			}
 			log.Infof("app %s delete done", appName)
 			return false, nil
 		}
@@ -599,7 +698,7 @@ func diskToStruct(path string) (*edgeRegistry.Disk, error) {
 	}, nil
 }
 
-func (openEVEC *OpenEVEC) PodPublish(appName, kernelFile, initrdFile, rootFile, formatStr, arch string, local bool, disks []string) error {
+func (openEVEC *OpenEVEC) PodPublish(ctx context.Context, appName, kernelFile, initrdFile, rootFile, formatStr, arch string, local bool, disks []string) error {
 	var (
 		rootDisk     *edgeRegistry.Disk
 		kernelSource *edgeRegistry.FileSource
@@ -608,7 +707,6 @@ func (openEVEC *OpenEVEC) PodPublish(appName, kernelFile, initrdFile, rootFile,
 		err          error
 	)
 	cfg := openEVEC.cfg
-	ctx := context.TODO()
 	if local {
 		_, remoteTarget, err = utils.NewRegistryHTTP(ctx)
 		if err != nil {
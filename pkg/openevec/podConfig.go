@@ -0,0 +1,79 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eve-api/go/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// podConfigView is config of pod as it is pushed to controller: app instance with
+// volumes, content trees and network instances it refers to
+type podConfigView struct {
+	AppInstance      json.RawMessage   `json:"appInstance"`
+	Volumes          []json.RawMessage `json:"volumes,omitempty"`
+	ContentTrees     []json.RawMessage `json:"contentTrees,omitempty"`
+	NetworkInstances []json.RawMessage `json:"networkInstances,omitempty"`
+}
+
+// podConfigJSON returns config of app and of entities it refers to in ctrl as JSON
+func podConfigJSON(ctrl controller.Cloud, app *config.AppInstanceConfig) ([]byte, error) {
+	marshal := func(m proto.Message) (json.RawMessage, error) {
+		data, err := protojson.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal config: %w", err)
+		}
+		return data, nil
+	}
+	view := podConfigView{}
+	var err error
+	if view.AppInstance, err = marshal(app); err != nil {
+		return nil, err
+	}
+	var contentTrees []string
+	for _, volumeRef := range app.GetVolumeRefList() {
+		volume, err := ctrl.GetVolume(volumeRef.GetUuid())
+		if err != nil {
+			return nil, fmt.Errorf("no volume %s found in controller: %w", volumeRef.GetUuid(), err)
+		}
+		data, err := marshal(volume)
+		if err != nil {
+			return nil, err
+		}
+		view.Volumes = append(view.Volumes, data)
+		contentTreeID := volume.GetOrigin().GetDownloadContentTreeID()
+		if contentTreeID == "" || slices.Contains(contentTrees, contentTreeID) {
+			continue
+		}
+		contentTrees = append(contentTrees, contentTreeID)
+		contentTree, err := ctrl.GetContentTree(contentTreeID)
+		if err != nil {
+			return nil, fmt.Errorf("no content tree %s found in controller: %w", contentTreeID, err)
+		}
+		if data, err = marshal(contentTree); err != nil {
+			return nil, err
+		}
+		view.ContentTrees = append(view.ContentTrees, data)
+	}
+	var networkInstances []string
+	for _, intf := range app.GetInterfaces() {
+		if slices.Contains(networkInstances, intf.GetNetworkId()) {
+			continue
+		}
+		networkInstances = append(networkInstances, intf.GetNetworkId())
+		networkInstance, err := ctrl.GetNetworkInstanceConfig(intf.GetNetworkId())
+		if err != nil {
+			return nil, fmt.Errorf("no network instance %s found in controller: %w", intf.GetNetworkId(), err)
+		}
+		data, err := marshal(networkInstance)
+		if err != nil {
+			return nil, err
+		}
+		view.NetworkInstances = append(view.NetworkInstances, data)
+	}
+	return json.MarshalIndent(view, "", "  ")
+}
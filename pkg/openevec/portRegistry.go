@@ -0,0 +1,293 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// PortReservation is slot of ports reserved in port registry by contexts of workspace (eden directory),
+// slot 0 is default ports shared by all not isolated contexts of workspace
+type PortReservation struct {
+	Slot      int            `json:"slot"`
+	Workspace string         `json:"workspace"`
+	User      string         `json:"user"`
+	Contexts  []string       `json:"contexts"`
+	Ports     map[string]int `json:"ports"`
+	Reserved  time.Time      `json:"reserved"`
+}
+
+// portRegistry is registry of ports of contexts shared by all workspaces of user,
+// ports used by other users on host are detected when they are allocated
+type portRegistry struct {
+	Reservations []*PortReservation `json:"reservations"`
+}
+
+// PortRegistryPath returns path of file of port registry shared by workspaces of user:
+// in runtime directory of user (XDG_RUNTIME_DIR) or in the default eden directory
+// inside home directory unless set with EDEN_PORT_REGISTRY
+func PortRegistryPath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(defaults.DefaultPortRegistryEnv)); path != "" {
+		return path, nil
+	}
+	if runtimeDir := strings.TrimSpace(os.Getenv("XDG_RUNTIME_DIR")); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "eden", defaults.DefaultPortRegistryFile), nil
+	}
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(usr.HomeDir, defaults.DefaultEdenHomeDir, defaults.DefaultPortRegistryFile), nil
+}
+
+func (registry *portRegistry) slot(slot int) *PortReservation {
+	for _, reservation := range registry.Reservations {
+		if reservation.Slot == slot {
+			return reservation
+		}
+	}
+	return nil
+}
+
+// release removes context name of workspace from reservations, reservations without contexts are removed
+func (registry *portRegistry) release(workspace, name string) {
+	var reservations []*PortReservation
+	for _, reservation := range registry.Reservations {
+		if reservation.Workspace == workspace {
+			reservation.Contexts = slices.DeleteFunc(reservation.Contexts, func(el string) bool { return el == name })
+			if len(reservation.Contexts) == 0 {
+				continue
+			}
+		}
+		reservations = append(reservations, reservation)
+	}
+	registry.Reservations = reservations
+}
+
+// prune removes reservations of workspaces which do not exist anymore
+func (registry *portRegistry) prune() {
+	registry.Reservations = slices.DeleteFunc(registry.Reservations, func(reservation *PortReservation) bool {
+		_, err := os.Stat(reservation.Workspace)
+		return os.IsNotExist(err)
+	})
+}
+
+// updatePortRegistry runs update with port registry locked for other eden processes
+// and saves changes of update if it succeeds
+func updatePortRegistry(update func(registry *portRegistry) error) error {
+	path, err := PortRegistryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("cannot create directory of port registry: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open port registry %s: %w", path, err)
+	}
+	defer file.Close()
+	// registry created by older eden in shared directory may be writable by others
+	if err := file.Chmod(0600); err != nil {
+		return fmt.Errorf("cannot restrict permissions of port registry %s: %w", path, err)
+	}
+	unlock, err := utils.LockFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot lock port registry %s: %w", path, err)
+	}
+	defer unlock()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("cannot read port registry %s: %w", path, err)
+	}
+	registry := &portRegistry{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, registry); err != nil {
+			return fmt.Errorf("cannot parse port registry %s: %w", path, err)
+		}
+	}
+	registry.prune()
+	if err := update(registry); err != nil {
+		return err
+	}
+	if data, err = json.MarshalIndent(registry, "", "  "); err != nil {
+		return err
+	}
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("cannot write port registry %s: %w", path, err)
+	}
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("cannot write port registry %s: %w", path, err)
+	}
+	return nil
+}
+
+// contextPorts returns ports on host used by services and EVE of context in cfg
+func contextPorts(cfg *EdenSetupArgs) map[string]int {
+	ports := map[string]int{
+		"adam":         cfg.Adam.Port,
+		"redis":        cfg.Redis.Port,
+		"eserver":      cfg.Eden.EServer.Port,
+		"registry":     cfg.Registry.Port,
+		"eve-telnet":   cfg.Eve.TelnetPort,
+		"qemu-monitor": cfg.Eve.QemuConfig.MonitorPort,
+		"qemu-netdev":  cfg.Eve.QemuConfig.NetDevSocketPort,
+		"sdn-telnet":   cfg.Sdn.TelnetPort,
+		"sdn-ssh":      cfg.Sdn.SSHPort,
+		"sdn-mgmt":     cfg.Sdn.MgmtPort,
+	}
+	for hostPort, guestPort := range cfg.Eve.HostFwd {
+		var port int
+		if _, err := fmt.Sscan(hostPort, &port); err == nil {
+			ports["eve-"+guestPort] = port
+		}
+	}
+	for name, port := range ports {
+		if port <= 0 {
+			delete(ports, name)
+		}
+	}
+	return ports
+}
+
+// portsFree checks that ports shifted by shift can be listened on host
+func portsFree(ports map[string]int, shift int) bool {
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port+shift))
+		if err != nil {
+			return false
+		}
+		listener.Close()
+	}
+	return true
+}
+
+// allocatePorts reserves ports for context in cfg to be saved with name in port registry:
+// default ports if they are not reserved by other workspace and context is not isolated,
+// otherwise ports of the first slot not reserved by other contexts which are free on host.
+// Default ports used on host are not checked if workspace already has contexts, they may
+// run services of workspace started before port registry
+func allocatePorts(cfg *EdenSetupArgs, name string, isolated bool) error {
+	workspace, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	userName := ""
+	if usr, err := user.Current(); err == nil {
+		userName = usr.Username
+	}
+	localSlots, err := contextSlots(name)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stat(utils.GetConfig(name))
+	hasContexts := len(localSlots[0]) > 0 || err == nil
+	return updatePortRegistry(func(registry *portRegistry) error {
+		// context may be added again, e.g. with --force
+		registry.release(workspace, name)
+		reserve := func() {
+			registry.Reservations = append(registry.Reservations, &PortReservation{
+				Slot:      cfg.Eden.Slot,
+				Workspace: workspace,
+				User:      userName,
+				Contexts:  []string{name},
+				Ports:     contextPorts(cfg),
+				Reserved:  time.Now(),
+			})
+		}
+		if !isolated && cfg.Eden.Slot == 0 {
+			reservation := registry.slot(0)
+			switch {
+			case reservation != nil && reservation.Workspace == workspace:
+				reservation.Contexts = append(reservation.Contexts, name)
+				return nil
+			case reservation == nil && (hasContexts || portsFree(contextPorts(cfg), 0)):
+				reserve()
+				return nil
+			case reservation != nil:
+				log.Infof("Default ports are reserved by %s in %s, context %s gets own ones",
+					reservation.User, reservation.Workspace, name)
+			default:
+				log.Infof("Default ports are used on host, context %s gets own ones", name)
+			}
+		}
+		for slot := 1; slot <= maxContextSlot; slot++ {
+			if _, used := localSlots[slot]; used || registry.slot(slot) != nil {
+				continue
+			}
+			if !portsFree(contextPorts(cfg), (slot-cfg.Eden.Slot)*contextPortStep) {
+				continue
+			}
+			if err := isolateContext(cfg, name, slot); err != nil {
+				return err
+			}
+			reserve()
+			log.Infof("Context %s uses ports of slot %d", name, slot)
+			return nil
+		}
+		return fmt.Errorf("all %d slots of isolated contexts are used", maxContextSlot)
+	})
+}
+
+// AllocatePorts reserves ports for context in cfg to be saved with name in port registry,
+// context uses default ports shared with other contexts of workspace unless they are reserved
+// by other workspace or used on host, in that case context is isolated
+func AllocatePorts(cfg *EdenSetupArgs, name string) error {
+	return allocatePorts(cfg, name, false)
+}
+
+// ReleasePorts removes context with name of workspace from port registry
+func ReleasePorts(name string) error {
+	workspace, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	return updatePortRegistry(func(registry *portRegistry) error {
+		registry.release(workspace, name)
+		return nil
+	})
+}
+
+// PortReservations returns reservations of port registry sorted by slots
+func PortReservations() ([]*PortReservation, error) {
+	var reservations []*PortReservation
+	err := updatePortRegistry(func(registry *portRegistry) error {
+		reservations = registry.Reservations
+		return nil
+	})
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].Slot < reservations[j].Slot })
+	return reservations, err
+}
+
+// PrintPortReservations prints reservations of port registry
+func PrintPortReservations() error {
+	reservations, err := PortReservations()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "SLOT\tUSER\tWORKSPACE\tCONTEXTS\tPORTS")
+	for _, reservation := range reservations {
+		var ports []string
+		for name, port := range reservation.Ports {
+			ports = append(ports, fmt.Sprintf("%s=%d", name, port))
+		}
+		sort.Strings(ports)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", reservation.Slot, reservation.User, reservation.Workspace,
+			strings.Join(reservation.Contexts, ","), strings.Join(ports, ","))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,151 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// probeTimeout is timeout for one run of app probe
+const probeTimeout = 5 * time.Second
+
+// probesFile returns file with health probes of apps for the current context
+func (openEVEC *OpenEVEC) probesFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultProbesDirectory, fmt.Sprintf("%s.yml", configName)), nil
+}
+
+// loadAppProbes returns probes of apps indexed by app name
+func (openEVEC *OpenEVEC) loadAppProbes() (map[string]*eve.AppProbe, error) {
+	probes := make(map[string]*eve.AppProbe)
+	fileName, err := openEVEC.probesFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return probes, nil
+		}
+		return nil, fmt.Errorf("cannot read probes: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &probes); err != nil {
+		return nil, fmt.Errorf("cannot parse probes %s: %w", fileName, err)
+	}
+	return probes, nil
+}
+
+func (openEVEC *OpenEVEC) saveAppProbes(probes map[string]*eve.AppProbe) error {
+	fileName, err := openEVEC.probesFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(probes)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, data, 0644)
+}
+
+// setAppProbe saves probe for app, nil probe removes it
+func (openEVEC *OpenEVEC) setAppProbe(appName string, probe *eve.AppProbe) error {
+	probes, err := openEVEC.loadAppProbes()
+	if err != nil {
+		return err
+	}
+	if probe == nil {
+		if _, ok := probes[appName]; !ok {
+			return nil
+		}
+		delete(probes, appName)
+	} else {
+		probes[appName] = probe
+	}
+	return openEVEC.saveAppProbes(probes)
+}
+
+// checkAppProbe runs probe through port forwarding to EVE interface
+func (openEVEC *OpenEVEC) checkAppProbe(probe *eve.AppProbe) error {
+	ip, port, closeFwd, err := openEVEC.forwardEvePort(probe.EveIfName, probe.Port)
+	if err != nil {
+		return err
+	}
+	defer closeFwd()
+	return probe.Check(ip, port, probeTimeout)
+}
+
+// appHealth returns health of app to show in pod ps
+func (openEVEC *OpenEVEC) appHealth(probe *eve.AppProbe) string {
+	if err := openEVEC.checkAppProbe(probe); err != nil {
+		log.Debugf("probe %s failed: %s", probe, err)
+		return "unhealthy"
+	}
+	return "healthy"
+}
+
+// setAppsHealth runs probes of apps and fills their health in state
+func (openEVEC *OpenEVEC) setAppsHealth(state *eve.State) error {
+	probes, err := openEVEC.loadAppProbes()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(probes))
+	for name := range probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		state.SetAppHealth(name, openEVEC.appHealth(probes[name]))
+	}
+	return nil
+}
+
+// PodProbe runs health probe of app, with wait set it repeats probe
+// until it succeeds or timeout (timeouts.app-probe of config if zero) expires
+func (openEVEC *OpenEVEC) PodProbe(ctx context.Context, appName string, wait bool, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = openEVEC.cfg.Timeouts.AppProbe
+	}
+	probes, err := openEVEC.loadAppProbes()
+	if err != nil {
+		return err
+	}
+	probe, ok := probes[appName]
+	if !ok {
+		return fmt.Errorf("no probe defined for app %s, use --probe flag of 'eden pod deploy'", appName)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err = openEVEC.checkAppProbe(probe)
+		if err == nil {
+			log.Infof("app %s is healthy (%s)", appName, probe)
+			return nil
+		}
+		if !wait || time.Now().After(deadline) {
+			return fmt.Errorf("app %s is unhealthy (%s): %w", appName, probe, err)
+		}
+		log.Debugf("app %s is not healthy yet: %s", appName, err)
+		if err := utils.SleepContext(ctx, openEVEC.cfg.Timeouts.PollInterval); err != nil {
+			return err
+		}
+	}
+}
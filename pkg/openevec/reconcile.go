@@ -0,0 +1,295 @@
+package openevec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// GitApplySource is git repository with resources for 'eden apply'
+type GitApplySource struct {
+	URL string
+	// Ref is branch, tag or commit to use, default branch of repository if empty
+	Ref string
+	// Subdir is directory with resources inside repository
+	Subdir string
+}
+
+// ParseGitApplySource parses source in format [git::]<URL>[//<subdir>][?ref=<ref>],
+// URL without git:: prefix must end with .git, ok is false for other sources
+func ParseGitApplySource(source string) (src GitApplySource, ok bool) {
+	rest, prefixed := strings.CutPrefix(source, "git::")
+	rest, src.Ref, _ = strings.Cut(rest, "?ref=")
+	scheme, address, found := strings.Cut(rest, "://")
+	if !found {
+		scheme, address = "", rest
+	}
+	address, src.Subdir, _ = strings.Cut(address, "//")
+	if !prefixed && !strings.HasSuffix(address, ".git") {
+		return GitApplySource{}, false
+	}
+	src.URL = address
+	if found {
+		src.URL = scheme + "://" + address
+	}
+	return src, true
+}
+
+// applyFilesDigest returns digest of content of files with resources of path
+func applyFilesDigest(path string) (string, error) {
+	files, err := applyFiles(path)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, fileName := range files {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %d\n", fileName, len(data))
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
+
+// syncApplySource returns directory or file with resources of source and revision of
+// them, git repository is cloned or updated inside eden directory
+func (openEVEC *OpenEVEC) syncApplySource(source string) (string, string, error) {
+	src, ok := ParseGitApplySource(source)
+	if !ok {
+		digest, err := applyFilesDigest(source)
+		return source, digest, err
+	}
+	stateFile, err := openEVEC.applyStateFile()
+	if err != nil {
+		return "", "", err
+	}
+	dir := strings.TrimSuffix(stateFile, ".json") + "-src"
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", "", err
+		}
+		if _, stderr, err := utils.RunCommandAndWait("git", "clone", "-q", src.URL, dir); err != nil {
+			return "", "", fmt.Errorf("cannot clone %s: %w: %s", src.URL, err, stderr)
+		}
+	} else if _, stderr, err := utils.RunCommandAndWait("git", "-C", dir, "remote", "set-url", "origin", src.URL); err != nil {
+		return "", "", fmt.Errorf("cannot set url of %s: %w: %s", dir, err, stderr)
+	}
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, stderr, err := utils.RunCommandAndWait("git", "-C", dir, "fetch", "-q", "origin", ref); err != nil {
+		return "", "", fmt.Errorf("cannot fetch %s of %s: %w: %s", ref, src.URL, err, stderr)
+	}
+	if _, stderr, err := utils.RunCommandAndWait("git", "-C", dir, "checkout", "-q", "--force", "FETCH_HEAD"); err != nil {
+		return "", "", fmt.Errorf("cannot checkout %s of %s: %w: %s", ref, src.URL, err, stderr)
+	}
+	revision, stderr, err := utils.RunCommandAndWait("git", "-C", dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", "", fmt.Errorf("cannot get revision of %s: %w: %s", src.URL, err, stderr)
+	}
+	return filepath.Join(dir, src.Subdir), strings.TrimSpace(revision), nil
+}
+
+// ReconcileArgs are arguments of continuous reconcile
+type ReconcileArgs struct {
+	Prune    bool
+	Interval time.Duration
+	// MetricsAddr is address to serve metrics of reconcile in Prometheus format, empty to disable
+	MetricsAddr string
+	// DiagnosticsAddr is address to serve pprof and expvar endpoints, empty to disable,
+	// they are served together with metrics if it is the same as MetricsAddr
+	DiagnosticsAddr string
+	// Events is file to append events of reconcile into (inside artifacts of the current context by default)
+	Events string
+}
+
+// ReconcileEvent is result of one pass of reconcile
+type ReconcileEvent struct {
+	Time     time.Time `json:"time"`
+	Revision string    `json:"revision,omitempty"`
+	// Drift is set if state of controller diverged from declared one after previous pass
+	Drift   bool     `json:"drift"`
+	Actions []string `json:"actions,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// reconcileMetrics are counters of reconcile served in Prometheus text format
+type reconcileMetrics struct {
+	mu          sync.Mutex
+	runs        int
+	drifts      int
+	errors      int
+	actions     int
+	lastSuccess time.Time
+}
+
+func (m *reconcileMetrics) record(event *ReconcileEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs++
+	m.actions += len(event.Actions)
+	if event.Drift {
+		m.drifts++
+	}
+	if event.Error != "" {
+		m.errors++
+	} else {
+		m.lastSuccess = event.Time
+	}
+}
+
+func (m *reconcileMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	var lastSuccess float64
+	if !m.lastSuccess.IsZero() {
+		lastSuccess = float64(m.lastSuccess.Unix())
+	}
+	for _, metric := range []struct {
+		name, help, kind string
+		value            float64
+	}{
+		{"eden_reconcile_runs_total", "Passes of reconcile.", "counter", float64(m.runs)},
+		{"eden_reconcile_drifts_total", "Passes of reconcile which found drift from declared state.", "counter", float64(m.drifts)},
+		{"eden_reconcile_errors_total", "Passes of reconcile which failed.", "counter", float64(m.errors)},
+		{"eden_reconcile_actions_total", "Actions performed by reconcile.", "counter", float64(m.actions)},
+		{"eden_reconcile_last_success_timestamp_seconds", "Time of the last successful pass of reconcile.", "gauge", lastSuccess},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", metric.name, metric.help,
+			metric.name, metric.kind, metric.name, metric.value)
+	}
+}
+
+func (openEVEC *OpenEVEC) reconcileEventsFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultArtifactsDir, configName, "reconcile", "events.json"), nil
+}
+
+func appendReconcileEvent(fileName string, event *ReconcileEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// reconcileOnce applies resources of source once
+func (openEVEC *OpenEVEC) reconcileOnce(source string, prune bool) *ReconcileEvent {
+	event := &ReconcileEvent{Time: time.Now()}
+	path, revision, err := openEVEC.syncApplySource(source)
+	event.Revision = revision
+	if err == nil {
+		var actions []ApplyAction
+		actions, err = openEVEC.applyPath(path, prune, false)
+		for _, action := range actions {
+			event.Actions = append(event.Actions, action.String())
+		}
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// Reconcile keeps controller state in sync with resources declared in source (directory,
+// file or git repository) applying them every interval until interrupted, drift from
+// declared state is logged and recorded into events and metrics
+func (openEVEC *OpenEVEC) Reconcile(ctx context.Context, source string, args ReconcileArgs) error {
+	if args.Interval <= 0 {
+		return fmt.Errorf("interval of reconcile must be positive")
+	}
+	if args.Events == "" {
+		var err error
+		if args.Events, err = openEVEC.reconcileEventsFile(); err != nil {
+			return err
+		}
+	}
+	metrics := &reconcileMetrics{}
+	if args.MetricsAddr != "" {
+		listener, err := net.Listen("tcp", args.MetricsAddr)
+		if err != nil {
+			return fmt.Errorf("cannot serve metrics: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics)
+		if args.DiagnosticsAddr == args.MetricsAddr {
+			registerDiagnostics(mux)
+		}
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("metrics server: %s", err)
+			}
+		}()
+		defer server.Close()
+		log.Infof("Metrics of reconcile are served on http://%s/metrics", listener.Addr())
+	}
+	if args.DiagnosticsAddr != "" && args.DiagnosticsAddr != args.MetricsAddr {
+		stop, err := serveDiagnostics(args.DiagnosticsAddr)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	log.Infof("Reconcile %s every %s, events are saved into %s", source, args.Interval, args.Events)
+
+	ticker := time.NewTicker(args.Interval)
+	defer ticker.Stop()
+	lastRevision := ""
+	for first := true; ; first = false {
+		event := openEVEC.reconcileOnce(source, args.Prune)
+		// changes of declared resources are not drift, so only passes of the same revision are compared
+		event.Drift = !first && len(event.Actions) > 0 && event.Revision == lastRevision
+		lastRevision = event.Revision
+		switch {
+		case event.Error != "":
+			log.Errorf("Reconcile failed: %s", event.Error)
+		case event.Drift:
+			log.Warnf("Drift from declared state repaired: %s", strings.Join(event.Actions, "; "))
+		}
+		metrics.record(event)
+		if err := appendReconcileEvent(args.Events, event); err != nil {
+			log.Errorf("cannot save event: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("Reconcile stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
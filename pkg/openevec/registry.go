@@ -17,7 +17,7 @@ func (openEVEC *OpenEVEC) RegistryStart() error {
 		return fmt.Errorf("cannot obtain executable path: %w", err)
 	}
 	log.Infof("Executable path: %s", command)
-	if err := eden.StartRegistry(regCfg.Port, regCfg.Tag, regCfg.Dist,
+	if err := eden.StartRegistry(cfg.Containers().Registry, regCfg.Port, regCfg.Tag, regCfg.Dist,
 		cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		return fmt.Errorf("cannot start registry: %w", err)
 	}
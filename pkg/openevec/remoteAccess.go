@@ -0,0 +1,178 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// RemoteAccessItems returns config items which control remote access to EVE (ssh, console of
+// EVE and VNC consoles of apps) with values to enable or disable it, sshKey is public key for ssh
+func RemoteAccessItems(enable bool, sshKey string) map[string]string {
+	if !enable {
+		return map[string]string{
+			"debug.enable.ssh":     "",
+			"debug.enable.console": "false",
+			"app.allow.vnc":        "false",
+		}
+	}
+	return map[string]string{
+		"debug.enable.ssh":     strings.TrimSpace(sshKey),
+		"debug.enable.console": "true",
+		"app.allow.vnc":        "true",
+	}
+}
+
+// ConfigItemsAcknowledged checks if EVE reported expected values of config items in status,
+// items with empty expected value are also acknowledged if EVE does not report them
+func ConfigItemsAcknowledged(expected map[string]string, status *info.ZInfoConfigItemStatus) (bool, error) {
+	for key, value := range expected {
+		item, ok := status.GetConfigItems()[key]
+		if !ok {
+			if unknown, ok := status.GetUnknownConfigItems()[key]; ok {
+				return false, fmt.Errorf("config item %s is unknown to EVE: %s", key, unknown.GetError())
+			}
+			if value != "" {
+				return false, nil
+			}
+			continue
+		}
+		if item.GetError() != "" {
+			return false, fmt.Errorf("config item %s is not accepted by EVE: %s", key, item.GetError())
+		}
+		if strings.TrimSpace(item.GetValue()) != value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (openEVEC *OpenEVEC) remoteAccessLogFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultArtifactsDir, configName, "remote-access.log"), nil
+}
+
+// logRemoteAccess appends record about change of remote access into log of the current context
+func (openEVEC *OpenEVEC) logRemoteAccess(action string, changes []ConfigItemChange, acknowledged string) error {
+	logFile, err := openEVEC.remoteAccessLogFile()
+	if err != nil {
+		return err
+	}
+	who := "unknown"
+	if usr, err := user.Current(); err == nil {
+		who = usr.Username
+	}
+	var changed []string
+	for _, change := range changes {
+		changed = append(changed, change.Key)
+	}
+	if len(changed) == 0 {
+		changed = append(changed, "none")
+	}
+	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open log of remote access: %w", err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s user=%s action=%s changed=%s acknowledged=%s\n",
+		time.Now().UTC().Format(time.RFC3339), who, action, strings.Join(changed, ","), acknowledged)
+	return err
+}
+
+// RemoteAccessSet enables or disables remote access to EVE as a group of config items, waits up
+// to timeout for EVE to acknowledge them (skipped if timeout is zero) and logs result
+func (openEVEC *OpenEVEC) RemoteAccessSet(ctx context.Context, enable bool, sshKeyFile string, timeout time.Duration) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	action, sshKey := "disable", ""
+	if enable {
+		action = "enable"
+		if sshKeyFile == "" {
+			sshKeyFile = ctrl.GetVars().SSHKey
+		}
+		b, err := os.ReadFile(sshKeyFile)
+		if err != nil {
+			return fmt.Errorf("error reading sshKey file %s: %w", sshKeyFile, err)
+		}
+		sshKey = string(b)
+	}
+	items := RemoteAccessItems(enable, sshKey)
+	changes, err := openEVEC.setConfigItems("", items)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		log.Infof("%s changed", change.Key)
+	}
+	if timeout == 0 {
+		return openEVEC.logRemoteAccess(action, changes, "skipped")
+	}
+	log.Infof("Waiting for EVE to acknowledge remote access %s (up to %s)", action, timeout)
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := deviceConfigItemStatus(ctrl, dev)
+		if err != nil {
+			return err
+		}
+		ok, err := ConfigItemsAcknowledged(items, status)
+		if err != nil {
+			if logErr := openEVEC.logRemoteAccess(action, changes, "error"); logErr != nil {
+				log.Error(logErr)
+			}
+			return err
+		}
+		if ok {
+			log.Infof("Remote access %sd", action)
+			return openEVEC.logRemoteAccess(action, changes, "true")
+		}
+		if time.Now().After(deadline) {
+			if err := openEVEC.logRemoteAccess(action, changes, "false"); err != nil {
+				log.Error(err)
+			}
+			return fmt.Errorf("EVE did not acknowledge remote access %s during %s", action, timeout)
+		}
+		if err := utils.SleepContext(ctx, openEVEC.cfg.Timeouts.PollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// RemoteAccessStatus prints config items of remote access with values acknowledged by EVE
+func (openEVEC *OpenEVEC) RemoteAccessStatus(outputFormat types.OutputFormat) error {
+	var keys []string
+	for key := range RemoteAccessItems(false, "") {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if err := openEVEC.ConfigItemsGet("", keys, outputFormat); err != nil {
+		return err
+	}
+	if logFile, err := openEVEC.remoteAccessLogFile(); err == nil && outputFormat == types.OutputFormatLines {
+		fmt.Printf("Changes are logged into %s\n", logFile)
+	}
+	return nil
+}
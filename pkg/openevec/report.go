@@ -0,0 +1,374 @@
+package openevec
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// reportEvent is change of state of EVE object inside device info timeline
+type reportEvent struct {
+	Time   time.Time
+	Object string
+	State  string
+}
+
+// reportPoint is value of metric at time
+type reportPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// reportChart is series of device metric rendered as SVG chart
+type reportChart struct {
+	Title  string
+	Unit   string
+	Points []reportPoint
+}
+
+// reportFile is file of bundle linked from its index
+type reportFile struct {
+	Name string
+	Size int64
+}
+
+// runReport is content of index of bundle created with eden report
+type runReport struct {
+	Title    string
+	Created  time.Time
+	Versions string
+	Suite    *tests.Report
+	Timeline []reportEvent
+	Charts   []*reportChart
+	Files    []reportFile
+	Errors   []string
+}
+
+const (
+	reportChartWidth  = 600
+	reportChartHeight = 150
+)
+
+// SVG renders chart as inline SVG polyline scaled to range of values
+func (chart *reportChart) SVG() template.HTML {
+	if len(chart.Points) < 2 {
+		return ""
+	}
+	start, end := chart.Points[0].Time, chart.Points[len(chart.Points)-1].Time
+	low, high := chart.Points[0].Value, chart.Points[0].Value
+	for _, point := range chart.Points {
+		low = min(low, point.Value)
+		high = max(high, point.Value)
+	}
+	var points []string
+	for _, point := range chart.Points {
+		x := 0.0
+		if end.After(start) {
+			x = float64(point.Time.Sub(start)) / float64(end.Sub(start)) * reportChartWidth
+		}
+		y := float64(reportChartHeight) / 2
+		if high > low {
+			y = reportChartHeight - (point.Value-low)/(high-low)*reportChartHeight
+		}
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return template.HTML(fmt.Sprintf(
+		`<svg width="%d" height="%d" viewBox="0 -5 %d %d"><polyline fill="none" stroke="#0969da" stroke-width="2" points="%s"/></svg>`+
+			`<div class="axis">%s … %s, %.2f … %.2f %s</div>`,
+		reportChartWidth, reportChartHeight+10, reportChartWidth, reportChartHeight+10, strings.Join(points, " "),
+		start.Format(time.RFC3339), end.Format(time.RFC3339), low, high, template.HTMLEscapeString(chart.Unit)))
+}
+
+// infoState returns object and its state described by info message
+func infoState(im *info.ZInfoMsg) (object, state string) {
+	object = einfo.ZInfoObjectKey(im)
+	switch {
+	case im.GetDinfo() != nil:
+		state = im.GetDinfo().GetState().String()
+	case im.GetAinfo() != nil:
+		object = fmt.Sprintf("app %s", im.GetAinfo().GetAppName())
+		state = im.GetAinfo().GetState().String()
+	case im.GetNiinfo() != nil:
+		object = fmt.Sprintf("network %s", im.GetNiinfo().GetDisplayname())
+		state = im.GetNiinfo().GetState().String()
+	case im.GetVinfo() != nil:
+		object = fmt.Sprintf("volume %s", im.GetVinfo().GetDisplayName())
+		state = im.GetVinfo().GetState().String()
+	}
+	return object, state
+}
+
+// reportTimeline returns changes of states of objects in info messages in order of time
+func reportTimeline(messages []*info.ZInfoMsg) []reportEvent {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].GetAtTimeStamp().AsTime().Before(messages[j].GetAtTimeStamp().AsTime())
+	})
+	var timeline []reportEvent
+	states := make(map[string]string)
+	for _, im := range messages {
+		object, state := infoState(im)
+		if state == "" {
+			continue
+		}
+		if last, ok := states[object]; ok && last == state {
+			continue
+		}
+		states[object] = state
+		timeline = append(timeline, reportEvent{
+			Time:   im.GetAtTimeStamp().AsTime(),
+			Object: object,
+			State:  state,
+		})
+	}
+	return timeline
+}
+
+// reportCharts returns charts of memory, CPU and network usage of device from metric messages
+func reportCharts(messages []*metrics.ZMetricMsg) []*reportChart {
+	memory := &reportChart{Title: "Memory used", Unit: "MB"}
+	cpu := &reportChart{Title: "CPU time", Unit: "s"}
+	network := &reportChart{Title: "Network traffic", Unit: "MB"}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].GetAtTimeStamp().AsTime().Before(messages[j].GetAtTimeStamp().AsTime())
+	})
+	for _, mm := range messages {
+		dm := mm.GetDm()
+		if dm == nil {
+			continue
+		}
+		at := mm.GetAtTimeStamp().AsTime()
+		if dm.GetMemory() != nil {
+			memory.Points = append(memory.Points, reportPoint{at, float64(dm.GetMemory().GetUsedMem())})
+		}
+		if dm.GetCpuMetric() != nil {
+			cpu.Points = append(cpu.Points, reportPoint{at, float64(dm.GetCpuMetric().GetTotal())})
+		}
+		var traffic uint64
+		for _, nm := range dm.GetNetwork() {
+			traffic += nm.GetTxBytes() + nm.GetRxBytes()
+		}
+		network.Points = append(network.Points, reportPoint{at, float64(traffic) / 1024 / 1024})
+	}
+	return []*reportChart{memory, cpu, network}
+}
+
+// reportDevice fills timeline and charts of report from the last info and metric messages of device
+func (openEVEC *OpenEVEC) reportDevice(c *collector, r *runReport, tail uint) {
+	ctrl, dev, err := openEVEC.getControllerAndDev(false)
+	if err != nil {
+		c.fail("device timeline", err)
+		return
+	}
+	var infoMessages []*info.ZInfoMsg
+	err = ctrl.InfoLastCallback(dev.GetID(), nil, func(im *info.ZInfoMsg) bool {
+		infoMessages = append(infoMessages, im)
+		return false
+	})
+	if err != nil {
+		c.fail("device timeline", err)
+	}
+	var metricMessages []*metrics.ZMetricMsg
+	err = ctrl.MetricLastCallback(dev.GetID(), nil, func(mm *metrics.ZMetricMsg) bool {
+		metricMessages = append(metricMessages, mm)
+		if tail > 0 && len(metricMessages) > int(tail) {
+			metricMessages = metricMessages[1:]
+		}
+		return false
+	})
+	if err != nil {
+		c.fail("device metrics", err)
+	}
+	r.Timeline = reportTimeline(infoMessages)
+	if tail > 0 && len(r.Timeline) > int(tail) {
+		r.Timeline = r.Timeline[len(r.Timeline)-int(tail):]
+	}
+	r.Charts = reportCharts(metricMessages)
+}
+
+// reportSuite merges runs of tests from results files into report saved inside bundle
+func reportSuite(c *collector, results []string) *tests.Report {
+	if len(results) == 0 {
+		return nil
+	}
+	suite := &tests.Report{File: filepath.Join(c.dir, "suite", "report.html"), Title: "Test results"}
+	for _, file := range results {
+		runs, err := tests.ReadResults(file)
+		if err != nil {
+			c.fail(file, err)
+			continue
+		}
+		suite.Runs = append(suite.Runs, runs...)
+	}
+	sort.SliceStable(suite.Runs, func(i, j int) bool {
+		return suite.Runs[i].Start.Before(suite.Runs[j].Start)
+	})
+	if len(suite.Runs) > 0 {
+		suite.Start = suite.Runs[0].Start
+	}
+	if err := suite.Write(); err != nil {
+		c.fail("suite", err)
+	}
+	return suite
+}
+
+const runReportTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; }
+.axis { color: #666; font-size: small; margin-bottom: 1em; }
+.PASS { color: #1a7f37; font-weight: bold; }
+.FAIL { color: #cf222e; font-weight: bold; }
+.SKIP { color: #9a6700; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Created {{.Created.Format "2006-01-02 15:04:05 MST"}}</p>
+<h2>Environment</h2>
+<pre>{{.Versions}}</pre>
+{{- with .Suite}}
+<h2>Test results</h2>
+<p>{{len .Runs}} test runs,
+<span class="PASS">{{.Count "PASS"}} passed</span>,
+<span class="FAIL">{{.Count "FAIL"}} failed</span>,
+<span class="SKIP">{{.Count "SKIP"}} skipped</span>,
+<a href="suite/report.html">phases and logs</a></p>
+<table>
+<tr><th>Test</th><th>Status</th><th>Duration</th><th>Resources</th></tr>
+{{- range .Runs}}{{range .Scripts}}
+<tr><td>{{.Name}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{.Duration}}</td><td>{{.Resources}}</td></tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+{{- if .Timeline}}
+<h2>Device timeline</h2>
+<table>
+<tr><th>Time</th><th>Object</th><th>State</th></tr>
+{{- range .Timeline}}
+<tr><td>{{.Time.Format "2006-01-02 15:04:05"}}</td><td>{{.Object}}</td><td>{{.State}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Charts}}{{if .SVG}}
+<h2>{{.Title}}</h2>
+{{.SVG}}
+{{- end}}{{end}}
+<h2>Files</h2>
+<ul>
+{{- range .Files}}
+<li><a href="{{.Name}}">{{.Name}}</a> ({{.Size}} bytes)</li>
+{{- end}}
+</ul>
+{{- if .Errors}}
+<h2>Problems</h2>
+<pre>{{range .Errors}}{{.}}
+{{end}}</pre>
+{{- end}}
+</body>
+</html>
+`
+
+// Render writes HTML index of bundle into buf
+func (r *runReport) Render(buf *bytes.Buffer) error {
+	tmpl, err := template.New("report").Parse(runReportTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(buf, r)
+}
+
+// EdenReport assembles bundle for test or demo session with HTML index: results of tests
+// from results files (saved by eden test --report or verbose output of tests),
+// device info timeline, metrics charts, SDN traffic, console logs and versions
+// of environment. Bundle is saved as tar.gz archive if output ends with .tar.gz
+// or .tgz and into directory output otherwise.
+func (openEVEC *OpenEVEC) EdenReport(output string, results []string, tail uint, pcapDuration time.Duration) error {
+	archive := strings.HasSuffix(output, ".tar.gz") || strings.HasSuffix(output, ".tgz")
+	stageDir := output
+	if archive {
+		dir, err := os.MkdirTemp("", "eden-report")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		stageDir = dir
+	} else if err := os.MkdirAll(stageDir, 0755); err != nil {
+		return err
+	}
+	c := &collector{dir: stageDir}
+	r := &runReport{
+		Title:   fmt.Sprintf("eden report %s", openEVEC.cfg.Eve.Name),
+		Created: time.Now(),
+	}
+
+	log.Info("Collecting test results")
+	r.Suite = reportSuite(c, results)
+	log.Info("Collecting eden config and versions")
+	openEVEC.collectConfig(c)
+	openEVEC.collectVersions(c)
+	if versions, err := os.ReadFile(filepath.Join(stageDir, "versions.txt")); err == nil {
+		r.Versions = string(versions)
+	}
+	log.Info("Collecting controller and device state")
+	openEVEC.collectController(c, tail)
+	openEVEC.reportDevice(c, r, tail)
+	log.Info("Collecting EVE VM state")
+	openEVEC.collectQemu(c)
+	log.Info("Collecting SDN state")
+	openEVEC.collectSdn(c, pcapDuration)
+
+	err := filepath.WalkDir(stageDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(stageDir, path)
+		if err != nil {
+			return err
+		}
+		r.Files = append(r.Files, reportFile{Name: filepath.ToSlash(name), Size: fi.Size()})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot list files of bundle: %w", err)
+	}
+	r.Errors = c.errors
+	var index bytes.Buffer
+	if err := r.Render(&index); err != nil {
+		return fmt.Errorf("cannot render report: %w", err)
+	}
+	c.write("index.html", index.Bytes())
+
+	if !archive {
+		log.Infof("Report saved into %s", filepath.Join(output, "index.html"))
+		return nil
+	}
+	bundleName := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(output), ".tgz"), ".tar.gz")
+	if err := utils.CreateTarGz(output, []utils.FileToSave{{Location: stageDir, Destination: bundleName}}); err != nil {
+		return fmt.Errorf("cannot create archive: %w", err)
+	}
+	log.Infof("Report saved into %s", output)
+	return nil
+}
@@ -0,0 +1,244 @@
+package openevec
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestRecord is request of EVE to controller with config served by controller at that moment
+type RequestRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	ClientIP  string    `json:"clientIP"`
+	Forwarded string    `json:"forwarded,omitempty"`
+	// Config is set for requests of config
+	Config *ServedConfig `json:"config,omitempty"`
+}
+
+// ServedConfig describes config of device served by controller
+type ServedConfig struct {
+	Version int `json:"version"`
+	// Size is size of marshaled config in bytes, without envelope added by controller
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+	// File is name of file with recorded config inside directory of requests
+	File string `json:"file,omitempty"`
+}
+
+// RequestsArgs are arguments of 'eden controller requests'
+type RequestsArgs struct {
+	// Follow waits for new requests after printing existing ones
+	Follow bool
+	// Record appends requests and served configs into directory of requests of the current context
+	Record bool
+	// Recorded prints previously recorded requests instead of ones from controller
+	Recorded bool
+	Format   types.OutputFormat
+}
+
+// IsConfigRequest checks if URL of request is request of config
+func IsConfigRequest(requestURL string) bool {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+	base := path.Base(u.Path)
+	return base == "config" || base == "compound-config"
+}
+
+// NewRequestRecord converts APIRequest saved by controller into RequestRecord
+func NewRequestRecord(request *types.APIRequest) *RequestRecord {
+	return &RequestRecord{
+		Timestamp: request.Timestamp,
+		Method:    request.Method,
+		URL:       request.URL,
+		ClientIP:  strings.Split(request.ClientIP, ":")[0],
+		Forwarded: request.Forwarded,
+	}
+}
+
+// String returns one line representation of request
+func (r *RequestRecord) String() string {
+	line := fmt.Sprintf("%s %s %s from %s", r.Timestamp.Format(time.RFC3339), r.Method, r.URL, r.ClientIP)
+	if r.Config != nil {
+		line += fmt.Sprintf(" [config version %d, %d bytes, sha256 %s]", r.Config.Version, r.Config.Size, r.Config.SHA256)
+	}
+	return line
+}
+
+func (openEVEC *OpenEVEC) requestsDir() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultArtifactsDir, configName, "requests"), nil
+}
+
+// servedConfig returns config of device currently served by controller, config is saved
+// into configs inside dir if it is not empty and config with the same hash is not saved yet
+func (openEVEC *OpenEVEC) servedConfig(dir string) (*ServedConfig, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	data, err := ctrl.GetConfigBytes(dev, false)
+	if err != nil {
+		return nil, fmt.Errorf("GetConfigBytes error: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	served := &ServedConfig{
+		Version: dev.GetConfigVersion(),
+		Size:    len(data),
+		SHA256:  hex.EncodeToString(hash[:])[:12],
+	}
+	if dir == "" {
+		return served, nil
+	}
+	served.File = filepath.Join("configs", fmt.Sprintf("%d-%s.json", served.Version, served.SHA256))
+	fileName := filepath.Join(dir, served.File)
+	if _, err := os.Stat(fileName); err == nil {
+		return served, nil
+	}
+	devConfig, err := ctrl.GetConfigBytes(dev, true)
+	if err != nil {
+		return nil, fmt.Errorf("GetConfigBytes error: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(fileName, devConfig, 0644); err != nil {
+		return nil, fmt.Errorf("cannot save config: %w", err)
+	}
+	return served, nil
+}
+
+func printRequestRecord(record *RequestRecord, outputFormat types.OutputFormat) error {
+	switch outputFormat {
+	case types.OutputFormatJSON:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case types.OutputFormatLines:
+		fmt.Println(record)
+	default:
+		return fmt.Errorf("unknown output format: %v", outputFormat)
+	}
+	return nil
+}
+
+// printRecordedRequests prints requests recorded into dir
+func printRecordedRequests(dir string, outputFormat types.OutputFormat) error {
+	f, err := os.Open(filepath.Join(dir, "requests.json"))
+	if err != nil {
+		return fmt.Errorf("cannot open recorded requests: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record RequestRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("cannot parse recorded request: %w", err)
+		}
+		if err := printRequestRecord(&record, outputFormat); err != nil {
+			return err
+		}
+		if record.Config != nil && record.Config.File != "" && outputFormat == types.OutputFormatLines {
+			fmt.Printf("\tconfig: %s\n", filepath.Join(dir, record.Config.File))
+		}
+	}
+	return scanner.Err()
+}
+
+// ControllerRequests prints requests of EVE to controller, requests of config are annotated with
+// config served by controller while following new requests, with args.Record requests are appended
+// into requests.json and served configs are saved into configs inside directory of requests of context
+func (openEVEC *OpenEVEC) ControllerRequests(args RequestsArgs) error {
+	dir, err := openEVEC.requestsDir()
+	if err != nil {
+		return err
+	}
+	if args.Recorded {
+		return printRecordedRequests(dir, args.Format)
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	var recordFile *os.File
+	recordDir := ""
+	if args.Record {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		recordFile, err = os.OpenFile(filepath.Join(dir, "requests.json"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("cannot open file to record requests: %w", err)
+		}
+		defer recordFile.Close()
+		recordDir = dir
+		log.Infof("Requests are recorded into %s", dir)
+	}
+	var handlerErr error
+	process := func(request *types.APIRequest, served bool) bool {
+		record := NewRequestRecord(request)
+		// config of the past requests is unknown, only the current one is served now
+		if served && IsConfigRequest(record.URL) {
+			if record.Config, handlerErr = openEVEC.servedConfig(recordDir); handlerErr != nil {
+				return true
+			}
+		}
+		if handlerErr = printRequestRecord(record, args.Format); handlerErr != nil {
+			return true
+		}
+		if recordFile != nil {
+			data, err := json.Marshal(record)
+			if err != nil {
+				handlerErr = err
+				return true
+			}
+			if _, handlerErr = recordFile.Write(append(data, '\n')); handlerErr != nil {
+				return true
+			}
+		}
+		return false
+	}
+	query := map[string]string{"UUID": dev.GetID().String()}
+	if err := ctrl.RequestLastCallback(dev.GetID(), query, func(request *types.APIRequest) bool {
+		return process(request, false)
+	}); err != nil {
+		return fmt.Errorf("RequestLastCallback error: %w", err)
+	}
+	if handlerErr != nil || !args.Follow {
+		return handlerErr
+	}
+	if err := ctrl.RequestChecker(dev.GetID(), query, func(request *types.APIRequest) bool {
+		return process(request, true)
+	}, erequest.RequestNew, 0); err != nil {
+		return fmt.Errorf("RequestChecker error: %w", err)
+	}
+	return handlerErr
+}
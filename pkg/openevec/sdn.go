@@ -27,6 +27,23 @@ func (openEVEC *OpenEVEC) SdnForwardSCPFromEve(remoteFilePath, localFilePath str
 	return openEVEC.SdnForwardCmd("", "eth0", 22, "scp", strings.Fields(arguments)...)
 }
 
+// SdnForwardSSHToEveOutput runs command on EVE using ssh and returns its output
+func (openEVEC *OpenEVEC) SdnForwardSSHToEveOutput(commandToRun string) (string, error) {
+	cfg := openEVEC.cfg
+	fwdIP, fwdPort, closeFwd, err := openEVEC.forwardEvePort("eth0", 22)
+	if err != nil {
+		return "", err
+	}
+	defer closeFwd()
+	arguments := fmt.Sprintf("-o IdentitiesOnly=yes -o ConnectTimeout=5 -o StrictHostKeyChecking=no -i %s "+
+		"-p %d root@%s %s", sdnSSSHKeyPrivate(cfg.Eden.SSHKey), fwdPort, fwdIP, commandToRun)
+	stdout, stderr, err := utils.RunCommandAndWait("ssh", strings.Fields(arguments)...)
+	if err != nil {
+		return "", fmt.Errorf("ssh command %q failed: %w (%s)", commandToRun, err, strings.TrimSpace(stderr))
+	}
+	return stdout, nil
+}
+
 func sdnSSSHKeyPrivate(sshKeyPub string) string {
 	extension := filepath.Ext(sshKeyPub)
 	// we store the pub key in config
@@ -45,34 +62,74 @@ func (openEVEC *OpenEVEC) SdnForwardCmd(fromEp string, eveIfName string, targetP
 	const fwdIPLabel = "FWD_IP"
 	const fwdPortLabel = "FWD_PORT"
 
+	if fromEp != "" && !cfg.Eve.Remote {
+		if !cfg.IsSdnEnabled() {
+			log.Warnf("Cannot execute command from an endpoint without SDN running, " +
+				"argument \"from-ep\" will be ignored")
+		} else {
+			// Running command from an endpoint inside SDN VM, no tunneling is needed.
+			targetIP := openEVEC.GetEveIP(eveIfName)
+			if targetIP == "" {
+				return fmt.Errorf("no IP address found to be assigned to EVE interface %s",
+					eveIfName)
+			}
+			client := &edensdn.SdnClient{
+				SSHPort:    uint16(cfg.Sdn.SSHPort),
+				SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+				MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+			}
+			fwdPort := strconv.Itoa(targetPort)
+			for i := range args {
+				args[i] = strings.ReplaceAll(args[i], fwdIPLabel, targetIP)
+				args[i] = strings.ReplaceAll(args[i], fwdPortLabel, fwdPort)
+			}
+			err := client.RunCmdFromEndpoint(fromEp, cmd, args...)
+			if err != nil {
+				return fmt.Errorf("command %s %s run inside endpoint %s failed: %w",
+					cmd, strings.Join(args, " "), fromEp, err)
+			}
+			return nil
+		}
+	}
+
+	fwdIP, fwdPort, closeFwd, err := openEVEC.forwardEvePort(eveIfName, targetPort)
+	if err != nil {
+		return err
+	}
+	defer closeFwd()
+	for i := range args {
+		args[i] = strings.ReplaceAll(args[i], fwdIPLabel, fwdIP)
+		args[i] = strings.ReplaceAll(args[i], fwdPortLabel, strconv.Itoa(fwdPort))
+	}
+	err = utils.RunCommandForeground(cmd, args...)
+	if err != nil {
+		return fmt.Errorf("command %s %s failed: %w", cmd, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// forwardEvePort returns IP and port accessible from the host which lead to targetPort
+// on EVE interface eveIfName. Returned closeFwd must be called to release forwarding.
+func (openEVEC *OpenEVEC) forwardEvePort(eveIfName string, targetPort int) (ip string, port int, closeFwd func(), err error) {
+	cfg := openEVEC.cfg
+	closeFwd = func() {}
+
 	// Case 1: EVE is running remotely (on Raspberry Pi, Gcp, etc.)
 	if cfg.Eve.Remote {
 		// Get IP address used by the target EVE interface.
 		// (look at network info published by EVE)
 		ip := openEVEC.GetEveIP(eveIfName)
 		if ip == "" {
-			return fmt.Errorf("failed to obtain IP address for EVE interface %s", eveIfName)
-		}
-		for i := range args {
-			args[i] = strings.ReplaceAll(args[i], fwdIPLabel, ip)
-			args[i] = strings.ReplaceAll(args[i], fwdPortLabel, strconv.Itoa(targetPort))
+			return "", 0, nil, fmt.Errorf("failed to obtain IP address for EVE interface %s", eveIfName)
 		}
-		err := utils.RunCommandForeground(cmd, args...)
-		if err != nil {
-			return fmt.Errorf("command %s failed: %w", cmd, err)
-		}
-		return nil
+		return ip, targetPort, closeFwd, nil
 	}
 
 	// Case 2: EVE is running inside VM on this host, but without SDN in between
 	if !cfg.IsSdnEnabled() {
-		if fromEp != "" {
-			log.Warnf("Cannot execute command from an endpoint without SDN running, " +
-				"argument \"from-ep\" will be ignored")
-		}
 		// Network model is static and consists of two EVE interfaces.
 		if eveIfName != "eth0" && eveIfName != "eth1" {
-			return fmt.Errorf("unknown EVE interface: %s", eveIfName)
+			return "", 0, nil, fmt.Errorf("unknown EVE interface: %s", eveIfName)
 		}
 		// Find out what the targetPort is (statically) mapped to in the host.
 		targetHostPort := -1
@@ -98,20 +155,10 @@ func (openEVEC *OpenEVEC) SdnForwardCmd(fromEp string, eveIfName string, targetP
 			}
 		}
 		if targetHostPort == -1 {
-			return fmt.Errorf("target EVE interface and port (%s, %d) are not port-forwarded "+
+			return "", 0, nil, fmt.Errorf("target EVE interface and port (%s, %d) are not port-forwarded "+
 				"by config (see eve.hostfwd)", eveIfName, targetPort)
 		}
-		// Redirect command to localhost and the forwarded port.
-		fwdPort := strconv.Itoa(targetHostPort)
-		for i := range args {
-			args[i] = strings.ReplaceAll(args[i], fwdIPLabel, "127.0.0.1")
-			args[i] = strings.ReplaceAll(args[i], fwdPortLabel, fwdPort)
-		}
-		err := utils.RunCommandForeground(cmd, args...)
-		if err != nil {
-			return fmt.Errorf("command %s failed: %w", cmd, err)
-		}
-		return nil
+		return "127.0.0.1", targetHostPort, closeFwd, nil
 	}
 
 	// Case 3: EVE is running inside VM on this host, with networking provided by SDN VM
@@ -121,7 +168,7 @@ func (openEVEC *OpenEVEC) SdnForwardCmd(fromEp string, eveIfName string, targetP
 	// (look at the ARP tables inside SDN VM)
 	targetIP := openEVEC.GetEveIP(eveIfName)
 	if targetIP == "" {
-		return fmt.Errorf("no IP address found to be assigned to EVE interface %s",
+		return "", 0, nil, fmt.Errorf("no IP address found to be assigned to EVE interface %s",
 			eveIfName)
 	}
 	client := &edensdn.SdnClient{
@@ -129,41 +176,16 @@ func (openEVEC *OpenEVEC) SdnForwardCmd(fromEp string, eveIfName string, targetP
 		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
 		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
 	}
-	if fromEp != "" {
-		// Running command from an endpoint inside SDN VM, no tunneling is needed.
-		fwdPort := strconv.Itoa(targetPort)
-		for i := range args {
-			args[i] = strings.ReplaceAll(args[i], fwdIPLabel, targetIP)
-			args[i] = strings.ReplaceAll(args[i], fwdPortLabel, fwdPort)
-		}
-		err := client.RunCmdFromEndpoint(fromEp, cmd, args...)
-		if err != nil {
-			return fmt.Errorf("command %s %s run inside endpoint %s failed: %w",
-				cmd, strings.Join(args, " "), fromEp, err)
-		}
-		return nil
-	}
 	// Temporarily establish port forwarding using SSH.
 	localPort, err := utils.FindUnusedPort()
 	if err != nil {
-		return fmt.Errorf("failed to find unused port number: %w", err)
+		return "", 0, nil, fmt.Errorf("failed to find unused port number: %w", err)
 	}
 	closeTunnel, err := client.SSHPortForwarding(localPort, uint16(targetPort), targetIP)
 	if err != nil {
-		return fmt.Errorf("failed to establish SSH port forwarding: %w", err)
-	}
-	defer closeTunnel()
-	// Redirect command to localhost and the forwarded port.
-	fwdPort := strconv.Itoa(int(localPort))
-	for i := range args {
-		args[i] = strings.ReplaceAll(args[i], fwdIPLabel, "127.0.0.1")
-		args[i] = strings.ReplaceAll(args[i], fwdPortLabel, fwdPort)
-	}
-	err = utils.RunCommandForeground(cmd, args...)
-	if err != nil {
-		return fmt.Errorf("command %s %s failed: %w", cmd, strings.Join(args, " "), err)
+		return "", 0, nil, fmt.Errorf("failed to establish SSH port forwarding: %w", err)
 	}
-	return nil
+	return "127.0.0.1", int(localPort), closeTunnel, nil
 }
 
 func (openEVEC *OpenEVEC) SdnStatus() error {
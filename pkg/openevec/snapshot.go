@@ -0,0 +1,308 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// snapshotConfigFile returns file with config of device in controller saved together with snapshot of EVE VM
+func (openEVEC *OpenEVEC) snapshotConfigFile(name string) (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultSnapshotsDir, configName, fmt.Sprintf("%s.json", name)), nil
+}
+
+// snapshotSwtpmDir returns directory with state of swtpm saved together with snapshot of EVE VM
+func (openEVEC *OpenEVEC) snapshotSwtpmDir(name string) (string, error) {
+	configFile, err := openEVEC.snapshotConfigFile(name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(configFile, ".json") + ".swtpm", nil
+}
+
+// swtpmStateDir returns directory with state of swtpm of EVE VM
+func (openEVEC *OpenEVEC) swtpmStateDir() string {
+	return filepath.Join(filepath.Dir(openEVEC.cfg.Eve.ImageFile), "swtpm")
+}
+
+func (openEVEC *OpenEVEC) checkSnapshotSupported() error {
+	if openEVEC.cfg.Eve.DevModel != defaults.DefaultQemuModel || openEVEC.cfg.Eve.Remote {
+		return fmt.Errorf("snapshots are supported only for local EVE with devmodel %s", defaults.DefaultQemuModel)
+	}
+	return nil
+}
+
+// SnapshotExistsEve checks if snapshot with name was saved
+func (openEVEC *OpenEVEC) SnapshotExistsEve(name string) (bool, error) {
+	configFile, err := openEVEC.snapshotConfigFile(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(configFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// saveSnapshotQemu saves snapshot of EVE VM with name, state of swtpm is saved together with it
+// while VM is paused, so disks, memory and TPM of snapshot are consistent
+func (openEVEC *OpenEVEC) saveSnapshotQemu(name string) error {
+	monitorPort := openEVEC.cfg.Eve.QemuConfig.MonitorPort
+	if !openEVEC.cfg.Eve.TPM {
+		if err := eden.SaveSnapshotQemu(monitorPort, name); err != nil {
+			return fmt.Errorf("cannot save snapshot %s: %w", name, err)
+		}
+		return nil
+	}
+	swtpmDir, err := openEVEC.snapshotSwtpmDir(name)
+	if err != nil {
+		return err
+	}
+	if err := eden.PauseQemu(monitorPort); err != nil {
+		return fmt.Errorf("cannot pause EVE VM: %w", err)
+	}
+	defer func() {
+		if err := eden.ResumeQemu(monitorPort); err != nil {
+			log.Errorf("cannot resume EVE VM: %s", err)
+		}
+	}()
+	if err := eden.SaveSnapshotQemu(monitorPort, name); err != nil {
+		return fmt.Errorf("cannot save snapshot %s: %w", name, err)
+	}
+	if err := eden.CopySWTPMState(openEVEC.swtpmStateDir(), swtpmDir); err != nil {
+		return fmt.Errorf("cannot save state of swtpm for snapshot %s: %w", name, err)
+	}
+	return nil
+}
+
+// loadSnapshotQemu restores EVE VM from snapshot with name. With TPM EVE VM and swtpm are
+// restarted to restore state of swtpm saved with snapshot before VM starts to use it
+func (openEVEC *OpenEVEC) loadSnapshotQemu(ctx context.Context, name string) error {
+	monitorPort := openEVEC.cfg.Eve.QemuConfig.MonitorPort
+	if !openEVEC.cfg.Eve.TPM {
+		if err := eden.LoadSnapshotQemu(monitorPort, name); err != nil {
+			return fmt.Errorf("cannot restore snapshot %s: %w", name, err)
+		}
+		return nil
+	}
+	swtpmDir, err := openEVEC.snapshotSwtpmDir(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(swtpmDir); err != nil {
+		log.Warnf("Snapshot %s has no state of swtpm, sealed keys of EVE may not match: %s", name, err)
+		if err := eden.LoadSnapshotQemu(monitorPort, name); err != nil {
+			return fmt.Errorf("cannot restore snapshot %s: %w", name, err)
+		}
+		return nil
+	}
+	if err := openEVEC.StopEve(ctx, ""); err != nil {
+		return fmt.Errorf("cannot stop EVE: %w", err)
+	}
+	// wait for killed processes to release disks and state of swtpm
+	if err := utils.SleepContext(ctx, defaults.DefaultRepeatTimeout); err != nil {
+		return err
+	}
+	if err := eden.CopySWTPMState(swtpmDir, openEVEC.swtpmStateDir()); err != nil {
+		return fmt.Errorf("cannot restore state of swtpm from snapshot %s: %w", name, err)
+	}
+	if err := openEVEC.StartEve(ctx, "", ""); err != nil {
+		return fmt.Errorf("cannot start EVE: %w", err)
+	}
+	// monitor of VM is available shortly after start
+	start := time.Now()
+	for {
+		err := eden.LoadSnapshotQemu(monitorPort, name)
+		if err == nil {
+			return nil
+		}
+		if time.Since(start) > openEVEC.cfg.Timeouts.SnapshotRestore {
+			return fmt.Errorf("cannot restore snapshot %s: %w", name, err)
+		}
+		if err := utils.SleepContext(ctx, time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+// SnapshotSaveEve saves state of EVE VM into snapshot with name together with config of device in controller
+func (openEVEC *OpenEVEC) SnapshotSaveEve(name string) error {
+	if err := openEVEC.checkSnapshotSupported(); err != nil {
+		return err
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	devConfig, err := ctrl.GetConfigBytes(dev, true)
+	if err != nil {
+		return fmt.Errorf("GetConfigBytes: %w", err)
+	}
+	configFile, err := openEVEC.snapshotConfigFile(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return err
+	}
+	if err := openEVEC.saveSnapshotQemu(name); err != nil {
+		return err
+	}
+	// config is saved last as it marks snapshot as complete
+	if err := os.WriteFile(configFile, devConfig, 0644); err != nil {
+		return fmt.Errorf("cannot save config of snapshot: %w", err)
+	}
+	log.Infof("Snapshot %s saved", name)
+	return nil
+}
+
+// eveDiskFile returns disk image EVE VM runs on, snapshots of VM are stored inside it
+func (openEVEC *OpenEVEC) eveDiskFile() (string, error) {
+	if openEVEC.cfg.Eve.QemuConfig.Overlay {
+		return openEVEC.eveOverlayFile()
+	}
+	return openEVEC.cfg.Eve.ImageFile, nil
+}
+
+// SnapshotExportEve exports disk of snapshot with name into standalone qcow2 image file
+func (openEVEC *OpenEVEC) SnapshotExportEve(name, file string) error {
+	if err := openEVEC.checkSnapshotSupported(); err != nil {
+		return err
+	}
+	diskFile, err := openEVEC.eveDiskFile()
+	if err != nil {
+		return err
+	}
+	// -U allows to read disk while it is used by running EVE VM
+	_, stderr, err := utils.RunCommandAndWait("qemu-img", "convert", "-U", "-f", "qcow2", "-O", "qcow2",
+		"-l", "snapshot.name="+name, diskFile, file)
+	if err != nil {
+		return fmt.Errorf("cannot export snapshot %s: %w (%s)", name, err, stderr)
+	}
+	return nil
+}
+
+// SnapshotRestoreEve restores state of EVE VM and config of device in controller from snapshot
+// with name and waits for EVE to reconnect to controller until ctx is done
+func (openEVEC *OpenEVEC) SnapshotRestoreEve(ctx context.Context, name string) error {
+	if err := openEVEC.checkSnapshotSupported(); err != nil {
+		return err
+	}
+	configFile, err := openEVEC.snapshotConfigFile(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("cannot read config of snapshot %s: %w", name, err)
+	}
+	var devConfig config.EdgeDevConfig
+	if err := protojson.Unmarshal(data, &devConfig); err != nil {
+		return fmt.Errorf("cannot unmarshal config of snapshot: %w", err)
+	}
+	configBytes, err := proto.Marshal(&devConfig)
+	if err != nil {
+		return fmt.Errorf("cannot marshal config of snapshot: %w", err)
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	// config is restored before EVE to not let restored EVE see config changed after snapshot
+	if err := ctrl.ConfigSet(dev.GetID(), configBytes); err != nil {
+		return fmt.Errorf("ConfigSet: %w", err)
+	}
+	start := time.Now()
+	if err := openEVEC.loadSnapshotQemu(ctx, name); err != nil {
+		return err
+	}
+	if ctrl, dev, err = changer.getControllerAndDevFromConfig(openEVEC.cfg); err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	if err := ctrl.StateUpdate(dev); err != nil {
+		return fmt.Errorf("StateUpdate: %w", err)
+	}
+	// EVE restored from snapshot reconnects to controller
+	for connected := false; !connected; {
+		if time.Since(start) > openEVEC.cfg.Timeouts.SnapshotRestore {
+			return fmt.Errorf("no requests from EVE during %s after restore of snapshot", openEVEC.cfg.Timeouts.SnapshotRestore)
+		}
+		if err := utils.SleepContext(ctx, openEVEC.cfg.Timeouts.PollInterval); err != nil {
+			return err
+		}
+		if err := ctrl.RequestLastCallback(dev.GetID(), map[string]string{"UUID": dev.GetID().String()},
+			func(request *types.APIRequest) bool {
+				connected = connected || request.Timestamp.After(start)
+				return false
+			}); err != nil {
+			return fmt.Errorf("RequestLastCallback: %w", err)
+		}
+	}
+	log.Infof("Snapshot %s restored in %s", name, time.Since(start).Round(time.Second))
+	return nil
+}
+
+// SnapshotDeleteEve removes snapshot with name
+func (openEVEC *OpenEVEC) SnapshotDeleteEve(name string) error {
+	if err := openEVEC.checkSnapshotSupported(); err != nil {
+		return err
+	}
+	configFile, err := openEVEC.snapshotConfigFile(name)
+	if err != nil {
+		return err
+	}
+	if err := eden.DeleteSnapshotQemu(openEVEC.cfg.Eve.QemuConfig.MonitorPort, name); err != nil {
+		return fmt.Errorf("cannot delete snapshot %s: %w", name, err)
+	}
+	if err := os.Remove(configFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if swtpmDir, err := openEVEC.snapshotSwtpmDir(name); err == nil {
+		if err := os.RemoveAll(swtpmDir); err != nil {
+			return err
+		}
+	}
+	log.Infof("Snapshot %s deleted", name)
+	return nil
+}
+
+// SnapshotListEve prints names of saved snapshots
+func (openEVEC *OpenEVEC) SnapshotListEve() error {
+	configFile, err := openEVEC.snapshotConfigFile("")
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(filepath.Dir(configFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			fmt.Println(name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,594 @@
+package openevec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/testutils"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// SoakApp is app of workload kept deployed during soak run
+type SoakApp struct {
+	Name     string   `yaml:"name"`
+	Image    string   `yaml:"image"`
+	Memory   string   `yaml:"memory"`
+	Cpus     uint32   `yaml:"cpus"`
+	Publish  []string `yaml:"publish"`
+	Networks []string `yaml:"networks"`
+	Registry string   `yaml:"registry"`
+}
+
+// SoakInvariants are conditions checked periodically during soak run
+type SoakInvariants struct {
+	// AppsRunning requires all apps of workload to be in RUNNING state
+	AppsRunning bool `yaml:"apps-running"`
+	// NoReboots requires EVE not to reboot since start of soak run
+	NoReboots bool `yaml:"no-reboots"`
+	// MemoryBelow is maximal percentage of memory of EVE used
+	MemoryBelow float64 `yaml:"memory-below"`
+	// Metrics are expressions on the last device metric in form of alert rules,
+	// e.g. '.dm.memory.usedMem < 3000'
+	Metrics []string `yaml:"metrics"`
+}
+
+// SoakSpec defines workload and invariants of soak run
+type SoakSpec struct {
+	Workload   []SoakApp      `yaml:"workload"`
+	Invariants SoakInvariants `yaml:"invariants"`
+}
+
+// LoadSoakSpec reads and validates soak specification from yaml file
+func LoadSoakSpec(fileName string) (*SoakSpec, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var spec SoakSpec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", fileName, err)
+	}
+	for i, app := range spec.Workload {
+		if app.Name == "" || app.Image == "" {
+			return nil, fmt.Errorf("app %d of workload: name and image are required", i)
+		}
+	}
+	for _, expr := range spec.metricExprs() {
+		if _, err := emetric.ParseExpr(expr); err != nil {
+			return nil, err
+		}
+	}
+	return &spec, nil
+}
+
+// metricExprs returns expressions to check on device metrics
+func (spec *SoakSpec) metricExprs() []string {
+	exprs := spec.Invariants.Metrics
+	if spec.Invariants.MemoryBelow > 0 {
+		exprs = append([]string{fmt.Sprintf(".dm.memory.usedPercentage < %v", spec.Invariants.MemoryBelow)}, exprs...)
+	}
+	return exprs
+}
+
+// appNames returns names of apps of workload
+func (spec *SoakSpec) appNames() []string {
+	var names []string
+	for _, app := range spec.Workload {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+// SoakArgs defines duration and period of checks of soak run
+type SoakArgs struct {
+	Duration time.Duration
+	Check    time.Duration
+	// Report is file to save timeline of checks into
+	Report string
+	// Cleanup removes apps of workload after soak run
+	Cleanup bool
+	// Redeploy is period of deletion and deployment of apps of workload, 0 to keep them deployed
+	Redeploy time.Duration
+	// RedeployTimeout is time for apps to become running after redeployment
+	RedeployTimeout time.Duration
+	// Rules is yaml file with alert rules evaluated against every device metric,
+	// rule with fail action stops soak run with error
+	Rules string
+	// DiagnosticsAddr is address to serve pprof and expvar endpoints, empty to disable
+	DiagnosticsAddr string
+}
+
+// SoakCheck is result of one check of invariants
+type SoakCheck struct {
+	Time time.Time `json:"time"`
+	// Redeploy marks check done after redeployment of workload
+	Redeploy bool     `json:"redeploy,omitempty"`
+	Failures []string `json:"failures,omitempty"`
+	// Alerts are alert rules with fail action fired since the previous check
+	Alerts []string `json:"alerts,omitempty"`
+}
+
+// SoakReport is timeline of checks of soak run
+type SoakReport struct {
+	Spec   string      `json:"spec"`
+	Start  time.Time   `json:"start"`
+	End    time.Time   `json:"end,omitempty"`
+	Checks []SoakCheck `json:"checks"`
+}
+
+// Failed returns number of failed checks
+func (r *SoakReport) Failed() int {
+	failed := 0
+	for _, check := range r.Checks {
+		if len(check.Failures) > 0 || len(check.Alerts) > 0 {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (r *SoakReport) save(file string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
+
+// soakState is state of EVE fed by info and metrics received during soak run
+type soakState struct {
+	mu         sync.Mutex
+	state      *eve.State
+	reboots    *eve.RebootDetector
+	lastMetric *metrics.ZMetricMsg
+	// alerts evaluates alert rules against every received metric, nil without rules
+	alerts *emetric.AlertEvaluator
+	// failedAlerts are alerts with fail action fired since the last check
+	failedAlerts []*emetric.Alert
+	// failed is signaled when alert with fail action fires
+	failed chan struct{}
+}
+
+func newSoakState(rules *emetric.AlertRules) *soakState {
+	s := &soakState{reboots: eve.NewRebootDetector(), failed: make(chan struct{}, 1)}
+	if rules != nil {
+		s.alerts = emetric.NewAlertEvaluator(rules)
+	}
+	return s
+}
+
+// processInfo feeds info message into state
+func (s *soakState) processInfo(im *info.ZInfoMsg) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.InfoCallback()(im)
+	s.reboots.Process(im)
+	return false
+}
+
+// processMetric feeds metric message into state and runs actions of alert rules fired by it
+func (s *soakState) processMetric(mm *metrics.ZMetricMsg) bool {
+	s.mu.Lock()
+	s.state.MetricCallback()(mm)
+	if mm.GetDm() == nil {
+		s.mu.Unlock()
+		return false
+	}
+	s.lastMetric = mm
+	var alerts []*emetric.Alert
+	if s.alerts != nil {
+		var err error
+		if alerts, err = s.alerts.Process(mm); err != nil {
+			log.Errorf("cannot evaluate alert rules: %s", err)
+		}
+	}
+	s.mu.Unlock()
+	for _, alert := range alerts {
+		switch alert.Rule.Action {
+		case emetric.AlertActionWebhook:
+			log.Warn(alert)
+			if err := alert.PostWebhook(); err != nil {
+				log.Errorf("cannot send alert: %s", err)
+			}
+		case emetric.AlertActionFail:
+			log.Error(alert)
+			s.mu.Lock()
+			s.failedAlerts = append(s.failedAlerts, alert)
+			s.mu.Unlock()
+			select {
+			case s.failed <- struct{}{}:
+			default:
+			}
+		default:
+			log.Warn(alert)
+		}
+	}
+	return false
+}
+
+// check evaluates invariants of spec against state
+func (s *soakState) check(spec *SoakSpec, start time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var failures []string
+	if spec.Invariants.AppsRunning && len(spec.Workload) > 0 {
+		if err := testutils.AppsInState("RUNNING", spec.appNames()...)(s.state); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if spec.Invariants.NoReboots {
+		for _, event := range s.reboots.Since(start) {
+			failures = append(failures, fmt.Sprintf("EVE rebooted at %s (%s): %s",
+				event.Time.Format(time.RFC3339), event.BootReason, event.Reason))
+		}
+	}
+	for _, expr := range spec.metricExprs() {
+		if s.lastMetric == nil {
+			failures = append(failures, "no device metrics received")
+			break
+		}
+		rule, err := emetric.ParseExpr(expr)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		matched, err := rule.Match(s.lastMetric)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", expr, err))
+		} else if !matched {
+			failures = append(failures, fmt.Sprintf("%s does not hold", expr))
+		}
+	}
+	return failures
+}
+
+// reset replaces state of EVE, e.g. after redeployment of apps
+func (s *soakState) reset(state *eve.State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+}
+
+// appsRunning checks if all apps with names are running
+func (s *soakState) appsRunning(names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return testutils.AppsInState("RUNNING", names...)(s.state)
+}
+
+// takeFailedAlerts returns alerts with fail action fired since the previous call
+func (s *soakState) takeFailedAlerts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var alerts []string
+	for _, alert := range s.failedAlerts {
+		alerts = append(alerts, alert.String())
+	}
+	s.failedAlerts = nil
+	return alerts
+}
+
+// soakTarget is EVE soak run deploys workload onto and watches
+type soakTarget interface {
+	// deploy deploys apps not deployed yet
+	deploy(apps []SoakApp) error
+	// remove deletes apps
+	remove(apps []SoakApp) error
+	// state returns state of EVE initialized from config of controller
+	state() (*eve.State, error)
+	// watch starts feeding of info and metrics of EVE into s until ctx is done
+	watch(ctx context.Context, s *soakState) error
+}
+
+// adamSoakTarget is EVE managed by controller of the current context
+type adamSoakTarget struct {
+	openEVEC *OpenEVEC
+}
+
+// soakReportFile returns file in artifacts of the current context to save report into
+func (openEVEC *OpenEVEC) soakReportFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultArtifactsDir, configName, "soak",
+		fmt.Sprintf("%s.json", time.Now().Format("20060102-150405"))), nil
+}
+
+func (t *adamSoakTarget) deploy(apps []SoakApp) error {
+	openEVEC := t.openEVEC
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	deployed := make(map[string]bool)
+	for _, el := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			return fmt.Errorf("no app in cloud %s: %w", el, err)
+		}
+		deployed[app.Displayname] = true
+	}
+	for _, app := range apps {
+		if deployed[app.Name] {
+			log.Infof("app %s is already deployed", app.Name)
+			continue
+		}
+		pc := PodConfig{
+			Name:        app.Name,
+			Registry:    app.Registry,
+			Networks:    app.Networks,
+			PortPublish: app.Publish,
+			AppMemory:   app.Memory,
+			AppCpus:     app.Cpus,
+			VncDisplay:  -1,
+			DiskSize:    humanize.Bytes(0),
+			VolumeSize:  humanize.IBytes(defaults.DefaultVolumeSize),
+			VolumeType:  "qcow2",
+			DirectLoad:  true,
+		}
+		if pc.Registry == "" {
+			pc.Registry = "remote"
+		}
+		if pc.AppMemory == "" {
+			pc.AppMemory = humanize.Bytes(defaults.DefaultAppMem * 1024)
+		}
+		if pc.AppCpus == 0 {
+			pc.AppCpus = defaults.DefaultAppCPU
+		}
+		if err := openEVEC.PodDeploy(app.Image, pc, openEVEC.cfg); err != nil {
+			return fmt.Errorf("cannot deploy app %s: %w", app.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t *adamSoakTarget) remove(apps []SoakApp) error {
+	for _, app := range apps {
+		if _, err := t.openEVEC.PodDelete(app.Name, true); err != nil {
+			return fmt.Errorf("cannot delete app %s: %w", app.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t *adamSoakTarget) state() (*eve.State, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(t.openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	return eve.Init(ctrl, dev), nil
+}
+
+func (t *adamSoakTarget) watch(ctx context.Context, s *soakState) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(t.openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, s.processInfo); err != nil {
+		return fmt.Errorf("InfoLastCallback: %w", err)
+	}
+	if err := ctrl.MetricLastCallback(dev.GetID(), nil, s.processMetric); err != nil {
+		return fmt.Errorf("MetricLastCallback: %w", err)
+	}
+	go func() {
+		if err := ctrl.InfoWatch(ctx, dev.GetID(), nil, s.processInfo); err != nil && ctx.Err() == nil {
+			log.Errorf("InfoWatch: %s", err)
+		}
+	}()
+	go func() {
+		if err := ctrl.MetricWatch(ctx, dev.GetID(), nil, s.processMetric); err != nil && ctx.Err() == nil {
+			log.Errorf("MetricWatch: %s", err)
+		}
+	}()
+	return nil
+}
+
+// Soak keeps workload of spec deployed for duration and checks invariants periodically,
+// workload is redeployed and checked to run again every args.Redeploy if it is set.
+// Timeline of checks is saved into report. It returns error if any check failed
+// or alert rule with fail action fired. Run stops early when ctx is done.
+func (openEVEC *OpenEVEC) Soak(ctx context.Context, specFile string, args SoakArgs) error {
+	if args.Report == "" {
+		var err error
+		if args.Report, err = openEVEC.soakReportFile(); err != nil {
+			return err
+		}
+	}
+	return runSoak(ctx, specFile, args, &adamSoakTarget{openEVEC: openEVEC})
+}
+
+// redeploySoakWorkload deletes apps of workload, deploys them again and waits
+// for them to run. It returns failures of redeployment and true if ctx is done.
+func redeploySoakWorkload(ctx context.Context, spec *SoakSpec, target soakTarget, state *soakState,
+	timeout time.Duration) ([]string, bool) {
+	log.Info("Redeploying workload")
+	if err := target.remove(spec.Workload); err != nil {
+		return []string{err.Error()}, false
+	}
+	if err := target.deploy(spec.Workload); err != nil {
+		return []string{err.Error()}, false
+	}
+	newState, err := target.state()
+	if err != nil {
+		return []string{err.Error()}, false
+	}
+	state.reset(newState)
+	poll := time.NewTicker(time.Second)
+	defer poll.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		err := state.appsRunning(spec.appNames())
+		if err == nil {
+			log.Info("Workload is running after redeployment")
+			return nil, false
+		}
+		select {
+		case <-ctx.Done():
+			return nil, true
+		case <-timer.C:
+			return []string{fmt.Sprintf("workload is not running %s after redeployment: %s", timeout, err)}, false
+		case <-poll.C:
+		}
+	}
+}
+
+// runSoak executes soak run defined in specFile against target
+func runSoak(ctx context.Context, specFile string, args SoakArgs, target soakTarget) error {
+	spec, err := LoadSoakSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("cannot load soak spec: %w", err)
+	}
+	if args.Check <= 0 {
+		return fmt.Errorf("period of checks must be positive")
+	}
+	if args.Redeploy > 0 && args.RedeployTimeout <= 0 {
+		return fmt.Errorf("timeout of redeployment must be positive")
+	}
+	var rules *emetric.AlertRules
+	if args.Rules != "" {
+		if rules, err = emetric.LoadAlertRules(args.Rules); err != nil {
+			return fmt.Errorf("cannot load alert rules: %w", err)
+		}
+	}
+	if args.DiagnosticsAddr != "" {
+		stop, err := serveDiagnostics(args.DiagnosticsAddr)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if err := target.deploy(spec.Workload); err != nil {
+		return err
+	}
+	if args.Cleanup {
+		defer func() {
+			if err := target.remove(spec.Workload); err != nil {
+				log.Error(err)
+			}
+		}()
+	}
+	state := newSoakState(rules)
+	if state.state, err = target.state(); err != nil {
+		return err
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := target.watch(watchCtx, state); err != nil {
+		return err
+	}
+	report := &SoakReport{Spec: specFile, Start: time.Now()}
+	log.Infof("Soak run for %s with checks every %s, report is saved into %s", args.Duration, args.Check, args.Report)
+
+	var alerts []string
+	var deadline <-chan time.Time
+	if args.Duration > 0 {
+		deadline = time.After(args.Duration)
+	}
+	ticker := time.NewTicker(args.Check)
+	defer ticker.Stop()
+	var redeploy <-chan time.Time
+	if args.Redeploy > 0 && len(spec.Workload) > 0 {
+		redeployTicker := time.NewTicker(args.Redeploy)
+		defer redeployTicker.Stop()
+		redeploy = redeployTicker.C
+	}
+Loop:
+	for {
+		var check SoakCheck
+		select {
+		case <-deadline:
+			break Loop
+		case <-ctx.Done():
+			log.Warn("Soak run interrupted")
+			break Loop
+		case <-state.failed:
+			// check records fired alerts and stops the run
+		case <-redeploy:
+			failures, interrupted := redeploySoakWorkload(ctx, spec, target, state, args.RedeployTimeout)
+			if interrupted {
+				log.Warn("Soak run interrupted")
+				break Loop
+			}
+			check.Redeploy = true
+			check.Failures = failures
+		case <-ticker.C:
+		}
+		check.Time = time.Now()
+		check.Failures = append(check.Failures, state.check(spec, report.Start)...)
+		check.Alerts = state.takeFailedAlerts()
+		if len(check.Failures) > 0 {
+			log.Errorf("Invariants failed: %s", strings.Join(check.Failures, "; "))
+		} else {
+			log.Infof("Invariants hold (%s elapsed)", time.Since(report.Start).Round(time.Second))
+		}
+		report.Checks = append(report.Checks, check)
+		if err := report.save(args.Report); err != nil {
+			log.Errorf("cannot save report: %s", err)
+		}
+		if len(check.Alerts) > 0 {
+			alerts = check.Alerts
+			log.Error("Soak run stopped by alert")
+			break Loop
+		}
+	}
+	report.End = time.Now()
+	if err := report.save(args.Report); err != nil {
+		return fmt.Errorf("cannot save report: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSTATUS\tFAILURES")
+	for _, check := range report.Checks {
+		status := "OK"
+		if len(check.Failures) > 0 || len(check.Alerts) > 0 {
+			status = "FAIL"
+		}
+		if check.Redeploy {
+			status += " (redeploy)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Time.Format(time.RFC3339), status,
+			strings.Join(append(check.Alerts, check.Failures...), "; "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	log.Infof("Report saved into %s", args.Report)
+	if len(alerts) > 0 {
+		return fmt.Errorf("soak run stopped by %s", strings.Join(alerts, "; "))
+	}
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package openevec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeSoakTarget feeds metrics and info about deployed apps into state instead of EVE
+type fakeSoakTarget struct {
+	metrics []*metrics.ZMetricMsg
+	// reportApps makes target report deployed apps as running
+	reportApps bool
+	stop       chan struct{}
+
+	dev      *device.Ctx
+	mu       sync.Mutex
+	deployed map[string]string
+	deploys  int
+}
+
+func (t *fakeSoakTarget) deploy(apps []SoakApp) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.deployed == nil {
+		t.deployed = make(map[string]string)
+	}
+	t.deploys++
+	for _, app := range apps {
+		// new app instance gets new id on every deployment
+		t.deployed[app.Name] = fmt.Sprintf("%s-%d", app.Name, t.deploys)
+	}
+	return nil
+}
+
+func (t *fakeSoakTarget) remove(apps []SoakApp) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, app := range apps {
+		delete(t.deployed, app.Name)
+	}
+	return nil
+}
+
+func (t *fakeSoakTarget) state() (*eve.State, error) {
+	if t.dev == nil {
+		t.dev = device.CreateEdgeNode()
+	}
+	return eve.Init(nil, t.dev), nil
+}
+
+func (t *fakeSoakTarget) watch(ctx context.Context, s *soakState) error {
+	go func() {
+		for _, mm := range t.metrics {
+			s.processMetric(mm)
+		}
+	}()
+	if !t.reportApps {
+		return nil
+	}
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			t.mu.Lock()
+			var infos []*info.ZInfoMsg
+			var appInstances []*info.ZInfoAppInstance
+			for name, id := range t.deployed {
+				appInstances = append(appInstances, &info.ZInfoAppInstance{Uuid: id, Name: name})
+				infos = append(infos, &info.ZInfoMsg{
+					Ztype:       info.ZInfoTypes_ZiApp,
+					DevId:       t.dev.GetID().String(),
+					AtTimeStamp: timestamppb.Now(),
+					InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{
+						AppID: id, AppName: name, State: info.ZSwState_RUNNING}},
+				})
+			}
+			t.mu.Unlock()
+			infos = append(infos, &info.ZInfoMsg{
+				Ztype:       info.ZInfoTypes_ZiDevice,
+				DevId:       t.dev.GetID().String(),
+				AtTimeStamp: timestamppb.Now(),
+				InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{
+					State: info.ZDeviceState_ZDEVICE_STATE_ONLINE, AppInstances: appInstances}},
+			})
+			for _, im := range infos {
+				s.processInfo(im)
+			}
+		}
+	}()
+	return nil
+}
+
+func memoryMetric(at time.Time, usedPercentage float64) *metrics.ZMetricMsg {
+	return &metrics.ZMetricMsg{
+		AtTimeStamp: timestamppb.New(at),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory: &metrics.MemoryMetric{UsedPercentage: usedPercentage},
+		}},
+	}
+}
+
+func TestSoakFailAlert(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "invariants.yml")
+	g.Expect(os.WriteFile(specFile, []byte("invariants:\n  no-reboots: true\n"), 0644)).To(Succeed())
+	rulesFile := filepath.Join(dir, "alerts.yml")
+	g.Expect(os.WriteFile(rulesFile, []byte(`rules:
+  - name: memory-leak
+    expr: .dm.memory.usedPercentage > 90
+    for: 1m
+    action: fail
+`), 0644)).To(Succeed())
+
+	now := time.Now()
+	target := &fakeSoakTarget{metrics: []*metrics.ZMetricMsg{
+		memoryMetric(now, 95),
+		memoryMetric(now.Add(30*time.Second), 50),
+		memoryMetric(now.Add(time.Minute), 95),
+		memoryMetric(now.Add(2*time.Minute), 96),
+	}}
+	args := SoakArgs{
+		Duration: time.Minute,
+		Check:    time.Hour,
+		Rules:    rulesFile,
+		Report:   filepath.Join(dir, "report.json"),
+	}
+	start := time.Now()
+	err := runSoak(context.Background(), specFile, args, target)
+	g.Expect(err).To(MatchError(ContainSubstring("alert memory-leak")))
+	g.Expect(err).To(MatchError(ContainSubstring("value 96")))
+	// run is stopped by alert rather than by duration
+	g.Expect(time.Since(start)).To(BeNumerically("<", args.Duration))
+	g.Expect(args.Report).To(BeARegularFile())
+}
+
+func TestSoakAlertNotFired(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "invariants.yml")
+	g.Expect(os.WriteFile(specFile, []byte("invariants:\n  no-reboots: true\n"), 0644)).To(Succeed())
+	rulesFile := filepath.Join(dir, "alerts.yml")
+	g.Expect(os.WriteFile(rulesFile, []byte(`rules:
+  - name: memory-leak
+    expr: .dm.memory.usedPercentage > 90
+    action: fail
+  - name: memory-high
+    expr: .dm.memory.usedPercentage > 40
+    action: log
+`), 0644)).To(Succeed())
+
+	now := time.Now()
+	target := &fakeSoakTarget{metrics: []*metrics.ZMetricMsg{
+		memoryMetric(now, 50),
+		memoryMetric(now.Add(time.Minute), 60),
+	}}
+	args := SoakArgs{
+		Duration: 200 * time.Millisecond,
+		Check:    50 * time.Millisecond,
+		Rules:    rulesFile,
+		Report:   filepath.Join(dir, "report.json"),
+	}
+	g.Expect(runSoak(context.Background(), specFile, args, target)).To(Succeed())
+}
+
+func TestSoakRedeploy(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "invariants.yml")
+	g.Expect(os.WriteFile(specFile, []byte(`workload:
+  - name: nginx
+    image: docker://nginx
+invariants:
+  apps-running: true
+`), 0644)).To(Succeed())
+
+	target := &fakeSoakTarget{reportApps: true, stop: make(chan struct{})}
+	defer close(target.stop)
+	args := SoakArgs{
+		Duration:        2500 * time.Millisecond,
+		Check:           time.Hour,
+		Redeploy:        500 * time.Millisecond,
+		RedeployTimeout: 5 * time.Second,
+		Report:          filepath.Join(dir, "report.json"),
+	}
+	g.Expect(runSoak(context.Background(), specFile, args, target)).To(Succeed())
+
+	data, err := os.ReadFile(args.Report)
+	g.Expect(err).NotTo(HaveOccurred())
+	var report SoakReport
+	g.Expect(json.Unmarshal(data, &report)).To(Succeed())
+	g.Expect(report.Checks).NotTo(BeEmpty())
+	for _, check := range report.Checks {
+		g.Expect(check.Redeploy).To(BeTrue())
+		g.Expect(check.Failures).To(BeEmpty())
+	}
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	g.Expect(target.deploys).To(Equal(len(report.Checks) + 1))
+}
+
+func TestSoakRedeployNotRunning(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "invariants.yml")
+	g.Expect(os.WriteFile(specFile, []byte(`workload:
+  - name: nginx
+    image: docker://nginx
+`), 0644)).To(Succeed())
+
+	args := SoakArgs{
+		Duration:        1500 * time.Millisecond,
+		Check:           time.Hour,
+		Redeploy:        100 * time.Millisecond,
+		RedeployTimeout: time.Second,
+		Report:          filepath.Join(dir, "report.json"),
+	}
+	err := runSoak(context.Background(), specFile, args, &fakeSoakTarget{})
+	g.Expect(err).To(MatchError(ContainSubstring("checks failed")))
+}
@@ -1,6 +1,7 @@
 package openevec
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -20,7 +21,7 @@ func (openEVEC *OpenEVEC) StartAdam() error {
 		cfg.Adam.Redis.RemoteURL = ""
 	}
 
-	if err := eden.StartAdam(cfg.Adam.Port, cfg.Adam.Dist, cfg.Adam.Force, cfg.Adam.Tag,
+	if err := eden.StartAdam(cfg.Containers().Adam, cfg.Adam.Port, cfg.Adam.Dist, cfg.Adam.Force, cfg.Adam.Tag,
 		cfg.Adam.Redis.RemoteURL, cfg.Adam.APIv1, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		return fmt.Errorf("cannot start adam: %w", err)
 	}
@@ -29,7 +30,7 @@ func (openEVEC *OpenEVEC) StartAdam() error {
 }
 
 func (openEVEC *OpenEVEC) GetAdamStatus() (string, error) {
-	statusAdam, err := eden.StatusAdam()
+	statusAdam, err := eden.StatusAdam(openEVEC.cfg.Containers().Adam)
 	if err != nil {
 		return "", fmt.Errorf("cannot obtain status of adam: %w", err)
 	} else {
@@ -39,7 +40,7 @@ func (openEVEC *OpenEVEC) GetAdamStatus() (string, error) {
 
 func (openEVEC *OpenEVEC) StartRedis() error {
 	cfg := openEVEC.cfg
-	if err := eden.StartRedis(cfg.Redis.Port, cfg.Adam.Redis.Dist, cfg.Redis.Force, cfg.Redis.Tag,
+	if err := eden.StartRedis(cfg.Containers().Redis, cfg.Redis.Port, cfg.Adam.Redis.Dist, cfg.Redis.Force, cfg.Redis.Tag,
 		cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		return fmt.Errorf("cannot start redis: %w", err)
 	}
@@ -49,7 +50,7 @@ func (openEVEC *OpenEVEC) StartRedis() error {
 
 func (openEVEC *OpenEVEC) StartRegistry() error {
 	cfg := openEVEC.cfg
-	if err := eden.StartRegistry(cfg.Registry.Port, cfg.Registry.Tag, cfg.Registry.Dist,
+	if err := eden.StartRegistry(cfg.Containers().Registry, cfg.Registry.Port, cfg.Registry.Tag, cfg.Registry.Dist,
 		cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		return fmt.Errorf("cannot start registry: %w", err)
 	}
@@ -59,7 +60,7 @@ func (openEVEC *OpenEVEC) StartRegistry() error {
 
 func (openEVEC *OpenEVEC) StartEServer() error {
 	cfg := openEVEC.cfg
-	if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist,
+	if err := eden.StartEServer(cfg.Containers().EServer, cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist,
 		cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag, cfg.Eden.EnableIPv6, cfg.Eden.IPv6Subnet); err != nil {
 		return fmt.Errorf("cannot start eserver: %w", err)
 	}
@@ -67,7 +68,8 @@ func (openEVEC *OpenEVEC) StartEServer() error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) StartEden(vmName, zedControlURL, tapInterface string) error {
+// StartEden starts containers of eden and EVE, ctx cancels waiting for EVE dependencies to start
+func (openEVEC *OpenEVEC) StartEden(ctx context.Context, vmName, zedControlURL, tapInterface string) error {
 	cfg := openEVEC.cfg
 	// Note that custom installer only works with zedcloud controller.
 	useZedcloud := cfg.Eve.CustomInstaller.Path != "" || zedControlURL != ""
@@ -94,7 +96,7 @@ func (openEVEC *OpenEVEC) StartEden(vmName, zedControlURL, tapInterface string)
 		return nil
 	}
 
-	if err := openEVEC.StartEve(vmName, tapInterface); err != nil {
+	if err := openEVEC.StartEve(ctx, vmName, tapInterface); err != nil {
 		return fmt.Errorf("cannot start eve %w", err)
 	}
 	log.Infof("EVE is starting")
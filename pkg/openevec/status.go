@@ -13,6 +13,7 @@ import (
 	"github.com/lf-edge/eden/pkg/eden"
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -22,39 +23,42 @@ const (
 	xmark    = "✘"
 )
 
-func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
+func (openEVEC *OpenEVEC) Status(vmName string, allConfigs, offline bool) error {
 	cfg := openEVEC.cfg
-	statusAdam, err := eden.StatusAdam()
+	if offline {
+		return openEVEC.statusOffline(allConfigs)
+	}
+	statusAdam, err := eden.StatusAdam(cfg.Containers().Adam)
 	if err != nil {
 		return fmt.Errorf("%s cannot obtain status of adam: %w", statusWarn(), err)
 	} else {
 		fmt.Printf("%s Adam status: %s\n", representContainerStatus(lastWord(statusAdam)), statusAdam)
 		fmt.Printf("\tAdam is expected at https://%s:%d\n", cfg.Adam.CertsIP, cfg.Adam.Port)
-		fmt.Printf("\tFor local Adam you can run 'docker logs %s' to see logs\n", defaults.DefaultAdamContainerName)
+		fmt.Printf("\tFor local Adam you can run 'docker logs %s' to see logs\n", cfg.Containers().Adam)
 	}
-	statusRegistry, err := eden.StatusRegistry()
+	statusRegistry, err := eden.StatusRegistry(cfg.Containers().Registry)
 	if err != nil {
 		return fmt.Errorf("%s cannot obtain status of registry: %w", statusWarn(), err)
 	} else {
 		fmt.Printf("%s Registry status: %s\n", representContainerStatus(lastWord(statusRegistry)), statusRegistry)
 		fmt.Printf("\tRegistry is expected at https://%s:%d\n", cfg.Registry.IP, cfg.Registry.Port)
-		fmt.Printf("\tFor local registry you can run 'docker logs %s' to see logs\n", defaults.DefaultRegistryContainerName)
+		fmt.Printf("\tFor local registry you can run 'docker logs %s' to see logs\n", cfg.Containers().Registry)
 	}
-	statusRedis, err := eden.StatusRedis()
+	statusRedis, err := eden.StatusRedis(cfg.Containers().Redis)
 	if err != nil {
 		return fmt.Errorf("%s cannot obtain status of redis: %w", statusWarn(), err)
 	} else {
 		fmt.Printf("%s Redis status: %s\n", representContainerStatus(lastWord(statusRedis)), statusRedis)
 		fmt.Printf("\tRedis is expected at %s\n", cfg.Adam.Redis.Eden)
-		fmt.Printf("\tFor local Redis you can run 'docker logs %s' to see logs\n", defaults.DefaultRedisContainerName)
+		fmt.Printf("\tFor local Redis you can run 'docker logs %s' to see logs\n", cfg.Containers().Redis)
 	}
-	statusEServer, err := eden.StatusEServer()
+	statusEServer, err := eden.StatusEServer(cfg.Containers().EServer)
 	if err != nil {
 		return fmt.Errorf("%s cannot obtain status of redis: %s", statusWarn(), err)
 	} else {
 		fmt.Printf("%s EServer process status: %s\n", representContainerStatus(lastWord(statusEServer)), statusEServer)
 		fmt.Printf("\tEServer is expected at http://%s:%d from EVE\n", cfg.Eden.EServer.IP, cfg.Eden.EServer.Port)
-		fmt.Printf("\tFor local EServer you can run 'docker logs %s' to see logs\n", defaults.DefaultEServerContainerName)
+		fmt.Printf("\tFor local EServer you can run 'docker logs %s' to see logs\n", cfg.Containers().EServer)
 	}
 	fmt.Println()
 	context, err := utils.ContextLoad()
@@ -72,6 +76,7 @@ func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
 			if err != nil {
 				return err
 			}
+			localCfg.ConfigName = configName
 			localOpenEVEC := CreateOpenEVEC(localCfg)
 			eveUUID := localCfg.Eve.CertsUUID
 			edenDir, err := utils.DefaultEdenDir()
@@ -91,7 +96,7 @@ func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
 			}
 			fmt.Println()
 			if statusAdam != "container doesn't exist" {
-				if err := localOpenEVEC.eveStatusRemote(); err != nil {
+				if err := localOpenEVEC.eveStatusRemote(false); err != nil {
 					return err
 				}
 			}
@@ -115,21 +120,52 @@ func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
 	return nil
 }
 
+// statusOffline prints status of EVE from offline twin without access to adam
+func (openEVEC *OpenEVEC) statusOffline(allConfigs bool) error {
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	currentContext := context.Current
+	for _, el := range context.ListContexts() {
+		if el == currentContext || allConfigs {
+			fmt.Printf("--- context: %s ---\n", el)
+			localCfg, err := LoadConfig(utils.GetConfig(el))
+			if err != nil {
+				return err
+			}
+			localCfg.ConfigName = el
+			localOpenEVEC := CreateOpenEVEC(localCfg)
+			if savedAt, err := localOpenEVEC.twinSavedAt(); err != nil {
+				fmt.Printf("%s EVE offline data: not found\n", statusWarn())
+			} else {
+				fmt.Printf("%s EVE offline data saved at: %s\n", statusOK(), savedAt.Format(time.RFC3339))
+				if err := localOpenEVEC.eveStatusRemote(true); err != nil {
+					return err
+				}
+			}
+			fmt.Println("------")
+		}
+	}
+	return nil
+}
+
 func (openEVEC *OpenEVEC) eveRequestsAdam() {
-	if ip, err := openEVEC.eveLastRequests(); err != nil {
+	if request, count, err := openEVEC.eveLastRequest(); err != nil {
 		fmt.Printf("%s EVE Request IP: error: %s\n", statusBad(), err)
+	} else if request == nil {
+		fmt.Printf("%s EVE Request IP: not found\n", statusWarn())
 	} else {
-		if ip == "" {
-			fmt.Printf("%s EVE Request IP: not found\n", statusWarn())
-		}
-		fmt.Printf("%s EVE Request IP: %s\n", statusOK(), ip)
+		fmt.Printf("%s EVE Request IP: %s\n", statusOK(), request.ClientIP)
+		fmt.Printf("\tlast request: %s %s at %s (%s ago), %d requests found\n",
+			request.Method, request.URL, request.Timestamp.Format(time.RFC3339),
+			time.Since(request.Timestamp).Round(time.Second), count)
 	}
 }
 
-func (openEVEC *OpenEVEC) eveStatusRemote() error {
+func (openEVEC *OpenEVEC) eveStatusRemote(offline bool) error {
 	log.Debugf("Will try to obtain info from ADAM")
-	changer := &adamChanger{}
-	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	ctrl, dev, err := openEVEC.getControllerAndDev(offline)
 	if err != nil {
 		log.Debugf("getControllerAndDev: %s", err)
 		fmt.Printf("%s EVE status: undefined (no onboarded EVE)\n", statusWarn())
@@ -137,10 +173,16 @@ func (openEVEC *OpenEVEC) eveStatusRemote() error {
 	}
 
 	eveState := eve.Init(ctrl, dev)
-	if err = ctrl.InfoLastCallback(dev.GetID(), nil, eveState.InfoCallback()); err != nil {
+	twin := newTwinRecorder()
+	reboots := eve.NewRebootDetector()
+	onInfo := eveState.InfoCallback()
+	if err = ctrl.InfoLastCallback(dev.GetID(), nil, twin.infoCallback(func(im *info.ZInfoMsg) bool {
+		reboots.Process(im)
+		return onInfo(im)
+	})); err != nil {
 		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
 	}
-	if err = ctrl.MetricLastCallback(dev.GetID(), nil, eveState.MetricCallback()); err != nil {
+	if err = ctrl.MetricLastCallback(dev.GetID(), nil, twin.metricCallback(eveState.MetricCallback())); err != nil {
 		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
 	}
 	if lastDInfo := eveState.InfoAndMetrics().GetDinfo(); lastDInfo != nil {
@@ -172,6 +214,15 @@ func (openEVEC *OpenEVEC) eveStatusRemote() error {
 	} else {
 		fmt.Printf("%s EVE memory: %s\n", statusWarn(), "waiting for info...")
 	}
+	if !offline {
+		if err := openEVEC.collectRebootForensics(ctrl, dev, reboots); err != nil {
+			log.Warnf("cannot collect reboot forensics: %s", err)
+		}
+	}
+	openEVEC.eveStatusReboots(dev)
+	if !offline {
+		openEVEC.updateTwin(ctrl, dev, twin)
+	}
 	return nil
 }
 
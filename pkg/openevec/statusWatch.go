@@ -0,0 +1,256 @@
+package openevec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// statusWatchHighlight is minimal time to highlight changed values of status in watch mode
+const statusWatchHighlight = 30 * time.Second
+
+// StatusEntry is one value of status compared between refreshes of watch of status
+type StatusEntry struct {
+	Key   string
+	Value string
+	// Mark is colorized mark of value (ok, warning or bad)
+	Mark string
+	// Volatile values change on every refresh (e.g. time of the last info), so they are only shown
+	Volatile bool
+}
+
+// StatusEvent is transition of value of status
+type StatusEvent struct {
+	Time time.Time `json:"time"`
+	Key  string    `json:"key"`
+	From string    `json:"from,omitempty"`
+	To   string    `json:"to"`
+}
+
+// StatusWatchArgs are arguments of watch of status
+type StatusWatchArgs struct {
+	Interval time.Duration
+	// JSON prints transitions of status as JSON events, one per line, instead of refreshing screen
+	JSON bool
+}
+
+// StatusTransitions returns events of values of entries which differ from values in prev,
+// all not volatile values are reported if prev is nil (the first refresh)
+func StatusTransitions(prev map[string]string, entries []StatusEntry, t time.Time) []StatusEvent {
+	var events []StatusEvent
+	for _, entry := range entries {
+		if entry.Volatile {
+			continue
+		}
+		from, found := prev[entry.Key]
+		if prev != nil && found && from == entry.Value {
+			continue
+		}
+		events = append(events, StatusEvent{Time: t, Key: entry.Key, From: from, To: entry.Value})
+	}
+	return events
+}
+
+// containerStatusEntry returns status of container of service or error of its obtaining as entry
+func containerStatusEntry(key, value string, err error) StatusEntry {
+	if err != nil {
+		return StatusEntry{Key: key, Value: fmt.Sprintf("error: %s", err), Mark: statusWarn()}
+	}
+	return StatusEntry{Key: key, Value: value, Mark: representContainerStatus(lastWord(value))}
+}
+
+// statusSnapshot collects status of services and of EVE of contexts as 'eden status' shows it,
+// but without printing it and without collecting of reboot forensics
+func (openEVEC *OpenEVEC) statusSnapshot(vmName string, allConfigs bool) ([]StatusEntry, error) {
+	cfg := openEVEC.cfg
+	containers := cfg.Containers()
+	statusAdam, adamErr := eden.StatusAdam(containers.Adam)
+	entries := []StatusEntry{containerStatusEntry("adam", statusAdam, adamErr)}
+	for _, service := range []struct {
+		key    string
+		status func(string) (string, error)
+		name   string
+	}{
+		{"registry", eden.StatusRegistry, containers.Registry},
+		{"redis", eden.StatusRedis, containers.Redis},
+		{"eserver", eden.StatusEServer, containers.EServer},
+	} {
+		value, err := service.status(service.name)
+		entries = append(entries, containerStatusEntry(service.key, value, err))
+	}
+	// do not wait for controller which cannot be reached
+	adamExists := adamErr == nil && statusAdam != "container doesn't exist"
+	edenCtx, err := utils.ContextLoad()
+	if err != nil {
+		return nil, fmt.Errorf("load context error: %w", err)
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range edenCtx.ListContexts() {
+		if name != edenCtx.Current && !allConfigs {
+			continue
+		}
+		localCfg, err := LoadConfig(utils.GetConfig(name))
+		if err != nil {
+			return nil, err
+		}
+		localCfg.ConfigName = name
+		add := func(key, value, mark string, volatile bool) {
+			entries = append(entries, StatusEntry{Key: name + "/" + key, Value: value, Mark: mark, Volatile: volatile})
+		}
+		fi, err := os.Stat(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", localCfg.Eve.CertsUUID)))
+		switch {
+		case err != nil:
+			add("eve-state", "not onboarded", statusWarn(), false)
+		case fi.Size() > 0:
+			add("eve-state", "registered", statusOK(), false)
+		default:
+			add("eve-state", "onboarding", statusWarn(), false)
+		}
+		if !localCfg.Eve.Remote {
+			evePid := localCfg.Eve.Pid
+			if name == cfg.ConfigName {
+				evePid = cfg.Eve.Pid
+			}
+			var process string
+			switch localCfg.Eve.DevModel {
+			case defaults.DefaultVBoxModel:
+				process, err = eden.StatusEVEVBox(vmName)
+			case defaults.DefaultParallelsModel:
+				process, err = eden.StatusEVEParallels(vmName)
+			default:
+				process, err = eden.StatusEVEQemu(evePid)
+			}
+			if err != nil {
+				add("eve-process", fmt.Sprintf("error: %s", err), statusWarn(), false)
+			} else {
+				add("eve-process", process, representProcessStatus(process), false)
+			}
+		}
+		if !adamExists {
+			continue
+		}
+		ctrl, dev, err := CreateOpenEVEC(localCfg).getControllerAndDev(false)
+		if err != nil {
+			add("eve-status", "no onboarded EVE", statusWarn(), false)
+			continue
+		}
+		eveState := eve.Init(ctrl, dev)
+		if err := ctrl.InfoLastCallback(dev.GetID(), nil, eveState.InfoCallback()); err != nil {
+			add("eve-status", fmt.Sprintf("error: %s", err), statusBad(), false)
+			continue
+		}
+		dinfo := eveState.InfoAndMetrics().GetDinfo()
+		if dinfo == nil {
+			add("eve-status", "waiting for info", statusWarn(), false)
+			continue
+		}
+		add("eve-device-state", dinfo.GetState().String(), statusOK(), false)
+		var partitions []string
+		for _, sw := range dinfo.GetSwList() {
+			partition := fmt.Sprintf("%s %s %s", sw.GetPartitionLabel(), sw.GetShortVersion(), sw.GetUserStatus())
+			if sw.GetActivated() {
+				partition += " (active)"
+			}
+			partitions = append(partitions, partition)
+		}
+		add("eve-partitions", strings.Join(partitions, "; "), statusOK(), false)
+		var ips []string
+		for _, nw := range dinfo.GetNetwork() {
+			ips = append(ips, nw.GetIPAddrs()...)
+		}
+		add("eve-ips", strings.Join(ips, "; "), statusOK(), false)
+		lastInfo := time.Unix(eveState.InfoAndMetrics().GetLastInfoTime().GetSeconds(), 0)
+		mark := statusOK()
+		if time.Since(lastInfo) > 10*time.Minute {
+			mark = statusBad()
+		}
+		add("eve-last-info", fmt.Sprintf("%s ago", time.Since(lastInfo).Round(time.Second)), mark, true)
+	}
+	return entries, nil
+}
+
+// printStatusWatch prints entries of status highlighting values changed after since
+// with their previous values
+func printStatusWatch(w io.Writer, entries []StatusEntry, changed map[string]StatusEvent, since time.Time) error {
+	highlight := color.New(color.Bold, color.ReverseVideo)
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	for _, entry := range entries {
+		value := entry.Value
+		if event, ok := changed[entry.Key]; ok && event.Time.After(since) && event.From != "" {
+			value = fmt.Sprintf("%s (was: %s)", highlight.Sprint(entry.Value), event.From)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.Mark, entry.Key, value)
+	}
+	return tw.Flush()
+}
+
+// StatusWatch refreshes status of services and of EVE of contexts every interval until ctx is done,
+// changed values are highlighted or printed as JSON events if args.JSON is set
+func (openEVEC *OpenEVEC) StatusWatch(ctx context.Context, vmName string, allConfigs bool, args StatusWatchArgs) error {
+	if args.Interval <= 0 {
+		return fmt.Errorf("interval of watch must be positive")
+	}
+	highlight := statusWatchHighlight
+	if args.Interval > highlight {
+		highlight = args.Interval
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	if args.JSON {
+		// keep stdout for events only
+		log.SetOutput(os.Stderr)
+	}
+	ticker := time.NewTicker(args.Interval)
+	defer ticker.Stop()
+	var prev map[string]string
+	changed := make(map[string]StatusEvent)
+	for {
+		now := time.Now()
+		entries, err := openEVEC.statusSnapshot(vmName, allConfigs)
+		if err != nil {
+			return err
+		}
+		events := StatusTransitions(prev, entries, now)
+		for _, event := range events {
+			changed[event.Key] = event
+		}
+		if args.JSON {
+			for _, event := range events {
+				if err := encoder.Encode(event); err != nil {
+					return err
+				}
+			}
+		} else {
+			// move cursor to the top left corner and clear screen
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("Every %s: eden status\t%s\n\n", args.Interval, now.Format(time.RFC3339))
+			if err := printStatusWatch(os.Stdout, entries, changed, now.Add(-highlight)); err != nil {
+				return err
+			}
+		}
+		prev = make(map[string]string, len(entries))
+		for _, entry := range entries {
+			prev[entry.Key] = entry.Value
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package openevec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	. "github.com/onsi/gomega"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	now := time.Now()
+	entries := []openevec.StatusEntry{
+		{Key: "adam", Value: "container is running"},
+		{Key: "default/eve-state", Value: "onboarding"},
+		{Key: "default/eve-last-info", Value: "5s ago", Volatile: true},
+	}
+
+	// all not volatile values are reported on the first refresh
+	events := openevec.StatusTransitions(nil, entries, now)
+	g.Expect(events).To(Equal([]openevec.StatusEvent{
+		{Time: now, Key: "adam", To: "container is running"},
+		{Time: now, Key: "default/eve-state", To: "onboarding"},
+	}))
+
+	prev := map[string]string{
+		"adam":                  "container is running",
+		"default/eve-state":     "onboarding",
+		"default/eve-last-info": "5s ago",
+	}
+	g.Expect(openevec.StatusTransitions(prev, entries, now)).To(BeEmpty())
+
+	entries[1].Value = "registered"
+	entries[2].Value = "10s ago"
+	entries = append(entries, openevec.StatusEntry{Key: "default/eve-ips", Value: "10.0.2.15"})
+	events = openevec.StatusTransitions(prev, entries, now)
+	g.Expect(events).To(Equal([]openevec.StatusEvent{
+		{Time: now, Key: "default/eve-state", From: "onboarding", To: "registered"},
+		{Time: now, Key: "default/eve-ips", To: "10.0.2.15"},
+	}))
+}
@@ -1,15 +1,21 @@
 package openevec
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/artifacts"
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/tests"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
 )
 
 type TestArgs struct {
@@ -19,12 +25,34 @@ type TestArgs struct {
 	TestRun      string
 	TestTimeout  string
 	TestList     string
+	ListFormat   types.OutputFormat
+	ListMetadata bool
 	TestProg     string
 	TestScenario string
 	FailScenario string
 	CurDir       string
 	ConfigFile   string
 	Verbosity    string
+	Nodes        []string
+	DevModels    []string
+	MatrixPrefix string
+	MatrixKeep   bool
+	LogDir       string
+	Resume       bool
+	Seed         int64
+	Watch        bool
+	Report       string
+	Snapshot     string
+	Resources    time.Duration
+	// Profile is directory to save CPU and heap profiles of eden and test binaries into
+	Profile string
+
+	Bench          string
+	BenchThreshold float64
+	BenchUpdate    bool
+	BenchFail      bool
+
+	ArtifactsUpload ArtifactsUploadConfig
 }
 
 func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
@@ -94,19 +122,191 @@ func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
 	return &cv, nil
 }
 
-func Test(tstCfg *TestArgs) error {
+// enableTestReport enables HTML report of tests with links to artifacts of the current context
+func enableTestReport(tstCfg *TestArgs) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	report := tests.EnableReport(tstCfg.Report, fmt.Sprintf("eden test %s", filepath.Base(dir)))
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	for _, artifacts := range []string{defaults.DefaultArtifactsDir, defaults.DefaultCheckpointsDir} {
+		path := filepath.Join(edenDir, artifacts, context.Current)
+		if _, err := os.Stat(path); err == nil {
+			report.AddArtifact(path)
+		}
+	}
+	return nil
+}
+
+// enableArtifactsUpload enables upload of artifacts of the current context
+// into object storage when tests fail
+func enableArtifactsUpload(ctx context.Context, tstCfg *TestArgs) error {
+	cfg := tstCfg.ArtifactsUpload
+	uploader, err := artifacts.NewUploader(ctx, artifacts.Config{
+		Provider:    cfg.Provider,
+		Bucket:      cfg.Bucket,
+		Prefix:      cfg.Prefix,
+		Endpoint:    cfg.Endpoint,
+		Region:      cfg.Region,
+		Credentials: cfg.Credentials,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create uploader of artifacts: %w", err)
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	edenContext, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	paths := make(map[string]string)
+	for _, dir := range []string{defaults.DefaultArtifactsDir, defaults.DefaultCheckpointsDir} {
+		paths[dir] = filepath.Join(edenDir, dir, edenContext.Current)
+	}
+	prefix := path.Join(cfg.Prefix, edenContext.Current, time.Now().UTC().Format("20060102-150405"))
+	tests.EnableArtifactsUpload(uploader, prefix, paths)
+	if tstCfg.Snapshot == "" {
+		return nil
+	}
+	edenCfg, err := LoadConfig(tstCfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+	openEVEC := CreateOpenEVEC(edenCfg)
+	// failed test started from snapshot, so it is uploaded to reproduce the failure
+	tests.EnableSnapshotUpload(func(file string) error {
+		exists, err := openEVEC.SnapshotExistsEve(tstCfg.Snapshot)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("snapshot %s is not saved", tstCfg.Snapshot)
+		}
+		return openEVEC.SnapshotExportEve(tstCfg.Snapshot, file)
+	})
+	return nil
+}
+
+// Test runs tests, ctx cancels resets of EVE between tests and uploads of artifacts
+func Test(ctx context.Context, tstCfg *TestArgs) error {
+	if tstCfg.Profile != "" {
+		dir, err := filepath.Abs(tstCfg.Profile)
+		if err != nil {
+			return err
+		}
+		stop, err := startProfiling(dir)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		tests.EnableProfile(dir)
+	}
+	if tstCfg.TestRun != "" && tstCfg.TestEscript == "" && tstCfg.TestList == "" && !tstCfg.TestOpts {
+		if err := selectEscripts(tstCfg, os.Stdin, os.Stdout, term.IsTerminal(int(os.Stdin.Fd()))); err != nil {
+			return err
+		}
+	}
+	if len(tstCfg.Nodes) > 0 || len(tstCfg.DevModels) > 0 {
+		var err error
+		if len(tstCfg.DevModels) > 0 {
+			err = testDevModels(tstCfg)
+		} else {
+			err = testNodes(tstCfg)
+		}
+		if tstCfg.CurDir != "" {
+			if err := os.Chdir(tstCfg.CurDir); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	if tstCfg.Report != "" {
+		if err := enableTestReport(tstCfg); err != nil {
+			return err
+		}
+	}
+	if tstCfg.ArtifactsUpload.Provider != "" {
+		if err := enableArtifactsUpload(ctx, tstCfg); err != nil {
+			return err
+		}
+	}
+	var benchResults string
+	if tstCfg.Bench != "" {
+		var err error
+		if benchResults, err = enableBench(); err != nil {
+			return err
+		}
+	}
+	if tstCfg.Seed != 0 {
+		if err := os.Setenv(defaults.DefaultTestSeedEnv, strconv.FormatInt(tstCfg.Seed, 10)); err != nil {
+			return err
+		}
+	}
+	if tstCfg.Resources > 0 {
+		if err := os.Setenv(defaults.DefaultTestUsageEnv, tstCfg.Resources.String()); err != nil {
+			return err
+		}
+	}
+	if tstCfg.Resume {
+		// test binaries of escripts inherit environment
+		if err := os.Setenv(defaults.DefaultTestResumeEnv, "1"); err != nil {
+			return err
+		}
+	}
+	reset := false
+	if tstCfg.Snapshot != "" {
+		if tstCfg.Watch {
+			return fmt.Errorf("snapshot is not supported with watch")
+		}
+		var err error
+		if reset, err = enableSnapshotReset(ctx, tstCfg); err != nil {
+			return err
+		}
+	}
 	switch {
+	case tstCfg.Watch:
+		if err := testWatch(ctx, tstCfg); err != nil {
+			return err
+		}
+	case tstCfg.TestList != "" && tstCfg.ListMetadata:
+		if err := listTestsMetadata(tstCfg.TestList, tstCfg.ListFormat); err != nil {
+			return err
+		}
 	case tstCfg.TestList != "":
 		tests.RunTest(tstCfg.TestProg, []string{"-test.list", tstCfg.TestList}, "", tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
 	case tstCfg.TestOpts:
 		tests.RunTest(tstCfg.TestProg, []string{"-h"}, "", tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
+	case tstCfg.TestEscript != "" && reset:
+		if err := runEscriptsWithReset(tstCfg); err != nil {
+			return err
+		}
 	case tstCfg.TestEscript != "":
 		tests.RunTest("eden.escript.test", []string{"-test.run", "TestEdenScripts/" + tstCfg.TestEscript}, tstCfg.TestArgs, tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
 	case tstCfg.TestRun != "":
+		if reset {
+			if err := tests.ResetBeforeTest(); err != nil {
+				return err
+			}
+		}
 		tests.RunTest(tstCfg.TestProg, []string{"-test.run", tstCfg.TestRun}, tstCfg.TestArgs, tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
 	default:
 		tests.RunScenario(tstCfg.TestScenario, tstCfg.TestArgs, tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
 	}
+	if benchResults != "" {
+		if err := compareBench(tstCfg, benchResults); err != nil {
+			return err
+		}
+	}
 
 	if tstCfg.CurDir != "" {
 		err := os.Chdir(tstCfg.CurDir)
@@ -0,0 +1,82 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/tests"
+	log "github.com/sirupsen/logrus"
+)
+
+// enableBench creates file for measurements of escripts and passes it
+// to test binaries with environment
+func enableBench() (string, error) {
+	f, err := os.CreateTemp("", "eden-bench-*.jsonl")
+	if err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Setenv(defaults.DefaultTestBenchEnv, f.Name()); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// compareBench compares measurements of escripts with baseline and prints them,
+// baseline is created from measurements if it does not exist or update is requested
+func compareBench(tstCfg *TestArgs, resultsFile string) error {
+	defer os.Remove(resultsFile)
+	results, err := tests.ReadMeasurements(resultsFile)
+	if err != nil {
+		return fmt.Errorf("cannot read measurements: %w", err)
+	}
+	if len(results) == 0 {
+		log.Warn("no measurements recorded by bench command of escripts")
+		return nil
+	}
+	baseline, err := tests.ReadBaseline(tstCfg.Bench)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	comparisons := tests.CompareMeasurements(baseline, results, tstCfg.BenchThreshold/100)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "SCRIPT\tNAME\tBASELINE\tVALUE\tCHANGE\tSTATUS")
+	regressions := 0
+	for _, c := range comparisons {
+		base, change, status := "-", "-", "new"
+		if c.Baseline != nil {
+			base = fmt.Sprintf("%.3f%s", *c.Baseline, c.Unit)
+			change = fmt.Sprintf("%+.1f%%", c.Change*100)
+			status = "ok"
+			if c.Regression {
+				status = "REGRESSION"
+				regressions++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.3f%s\t%s\t%s\n", c.Script, c.Name, base, c.Value, c.Unit, change, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if tstCfg.BenchUpdate || baseline == nil {
+		if err := tests.WriteBaseline(tstCfg.Bench, tests.MergeBaseline(baseline, results)); err != nil {
+			return fmt.Errorf("cannot write baseline: %w", err)
+		}
+		log.Infof("Baseline saved into %s", tstCfg.Bench)
+		return nil
+	}
+	if regressions > 0 {
+		msg := fmt.Sprintf("%d measurements regressed more than %g%% against %s", regressions, tstCfg.BenchThreshold, tstCfg.Bench)
+		if tstCfg.BenchFail {
+			return fmt.Errorf("%s", msg)
+		}
+		log.Warn(msg)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// DevModelResult is result of tests run against EVE with one devmodel
+type DevModelResult struct {
+	DevModel  string        `json:"devmodel"`
+	Context   string        `json:"context"`
+	Provision string        `json:"provision"`
+	Tests     string        `json:"tests"`
+	Teardown  string        `json:"teardown"`
+	Duration  time.Duration `json:"duration"`
+	LogFile   string        `json:"log"`
+}
+
+// matrixStepResult converts error of step into result shown in report
+func matrixStepResult(err error) string {
+	if err != nil {
+		return fmt.Sprintf("FAIL (%s)", err)
+	}
+	return "PASS"
+}
+
+// runDevModel provisions environment with devModel, runs tests inside it and tears it down
+func runDevModel(edenBin string, tstCfg *TestArgs, devModel, logDir string) *DevModelResult {
+	context := fmt.Sprintf("%s-%s", tstCfg.MatrixPrefix, devModel)
+	result := &DevModelResult{
+		DevModel:  devModel,
+		Context:   context,
+		Provision: "SKIP",
+		Tests:     "SKIP",
+		Teardown:  "SKIP",
+		LogFile:   filepath.Join(logDir, fmt.Sprintf("%s.log", devModel)),
+	}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+	logFile, err := os.Create(result.LogFile)
+	if err != nil {
+		result.Provision = matrixStepResult(err)
+		return result
+	}
+	defer logFile.Close()
+	prefixWriter := utils.NewPrefixWriter(os.Stdout, fmt.Sprintf("[%s] ", devModel), nil)
+	defer prefixWriter.Flush()
+	w := io.MultiWriter(logFile, prefixWriter)
+
+	// provisioning and teardown run from the directory eden was started in
+	// as config generation depends on it, tests run in the directory of tests
+	rootDir := tstCfg.CurDir
+	provision := [][]string{
+		{"config", "add", context, "--devmodel", devModel, "--force"},
+		{"setup"},
+		{"start"},
+		{"eve", "onboard"},
+	}
+	for _, step := range provision {
+		if err := runEdenWithContext(edenBin, step, context, rootDir, w); err != nil {
+			result.Provision = matrixStepResult(fmt.Errorf("eden %s: %w", strings.Join(step, " "), err))
+			break
+		}
+		result.Provision = matrixStepResult(nil)
+	}
+	if result.Provision == matrixStepResult(nil) {
+		result.Tests = matrixStepResult(runEdenWithContext(edenBin, nodeTestArgs(tstCfg), context, "", w))
+	}
+	if tstCfg.MatrixKeep {
+		return result
+	}
+	teardown := [][]string{
+		{"stop"},
+		{"clean", "--current-context"},
+		{"config", "delete", context},
+	}
+	result.Teardown = matrixStepResult(nil)
+	for _, step := range teardown {
+		if err := runEdenWithContext(edenBin, step, context, rootDir, w); err != nil {
+			result.Teardown = matrixStepResult(fmt.Errorf("eden %s: %w", strings.Join(step, " "), err))
+			break
+		}
+	}
+	return result
+}
+
+// testDevModels runs tests sequentially against EVE with every devmodel
+// in dedicated contexts and prints comparative report
+func testDevModels(tstCfg *TestArgs) error {
+	if tstCfg.TestList != "" || tstCfg.TestOpts {
+		return fmt.Errorf("listing of tests is not supported with devmodels")
+	}
+	if tstCfg.Watch {
+		return fmt.Errorf("watch is not supported with devmodels")
+	}
+	if tstCfg.Bench != "" {
+		return fmt.Errorf("benchmark is not supported with devmodels")
+	}
+	if tstCfg.Snapshot != "" {
+		return fmt.Errorf("snapshot is not supported with devmodels")
+	}
+	if tstCfg.Report != "" {
+		return fmt.Errorf("report is not supported with devmodels, use report.json saved into --log-dir")
+	}
+	for _, devModel := range tstCfg.DevModels {
+		if _, err := models.GetDevModelByName(devModel); err != nil {
+			return fmt.Errorf("devmodel %s: %w", devModel, err)
+		}
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	originalContext := context.Current
+	edenBin, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find eden binary: %w", err)
+	}
+	logDir := tstCfg.LogDir
+	if logDir == "" {
+		logDir = filepath.Join(os.TempDir(), fmt.Sprintf("eden-test-devmodels-%s", time.Now().Format("20060102-150405")))
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	log.Infof("Running tests against devmodels %s, logs in %s", strings.Join(tstCfg.DevModels, ","), logDir)
+
+	var results []*DevModelResult
+	for _, devModel := range tstCfg.DevModels {
+		results = append(results, runDevModel(edenBin, tstCfg, devModel, logDir))
+	}
+	// adding of context switches the current one, so return it back
+	context.SetContext(originalContext)
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	reportFile := filepath.Join(logDir, "report.json")
+	if err := os.WriteFile(reportFile, data, 0644); err != nil {
+		return err
+	}
+	failed := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "DEVMODEL\tPROVISION\tTESTS\tTEARDOWN\tDURATION\tLOG")
+	for _, result := range results {
+		if result.Tests != matrixStepResult(nil) {
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", result.DevModel, result.Provision, result.Tests,
+			result.Teardown, result.Duration.Round(time.Second), result.LogFile)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	log.Infof("Report saved into %s", reportFile)
+	if failed > 0 {
+		return fmt.Errorf("tests failed on %d of %d devmodels", failed, len(results))
+	}
+	return nil
+}
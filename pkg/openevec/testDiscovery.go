@@ -0,0 +1,56 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/tests"
+)
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// listTestsMetadata prints scenarios and escripts found in the current directory
+// with their metadata, filter is regular expression applied to names, suites and tags
+func listTestsMetadata(filter string, outputFormat types.OutputFormat) error {
+	re, err := regexp.Compile(filter)
+	if err != nil {
+		return fmt.Errorf("cannot compile regexp %q: %w", filter, err)
+	}
+	entries, err := tests.DiscoverTests(".", re)
+	if err != nil {
+		return fmt.Errorf("cannot discover tests: %w", err)
+	}
+	switch outputFormat {
+	case types.OutputFormatJSON:
+		if entries == nil {
+			entries = []*tests.TestEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "    ")
+		if err != nil {
+			return err
+		}
+		//nolint:forbidigo
+		fmt.Println(string(data))
+		return nil
+	case types.OutputFormatLines:
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprintln(tw, "SUITE\tKIND\tNAME\tTAGS\tOWNER\tDURATION\tREQUIRES")
+		for _, entry := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Suite, entry.Kind, entry.Name,
+				orDash(strings.Join(entry.Tags, ",")), orDash(entry.Owner), orDash(entry.Duration),
+				orDash(strings.Join(entry.Requires, ",")))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unimplemented output format")
+}
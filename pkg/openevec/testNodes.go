@@ -0,0 +1,162 @@
+package openevec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// nodeTestResult is result of tests run against one EVE node
+type nodeTestResult struct {
+	node     string
+	err      error
+	duration time.Duration
+	logFile  string
+}
+
+// nodeTestArgs returns arguments of eden to run the same tests against one node
+func nodeTestArgs(tstCfg *TestArgs) []string {
+	args := []string{"test", "-v", tstCfg.Verbosity}
+	flags := []struct {
+		name  string
+		value string
+	}{
+		{"--escript", tstCfg.TestEscript},
+		{"--prog", tstCfg.TestProg},
+		{"--run", tstCfg.TestRun},
+		{"--timeout", tstCfg.TestTimeout},
+		{"--args", tstCfg.TestArgs},
+		{"--scenario", tstCfg.TestScenario},
+		{"--fail_scenario", tstCfg.FailScenario},
+	}
+	for _, fl := range flags {
+		if fl.value != "" {
+			args = append(args, fl.name, fl.value)
+		}
+	}
+	if tstCfg.Seed != 0 {
+		args = append(args, "--seed", strconv.FormatInt(tstCfg.Seed, 10))
+	}
+	if tstCfg.Resume {
+		args = append(args, "--resume")
+	}
+	return args
+}
+
+// runNodeTest runs tests against node in separate eden process using context of node,
+// so every node uses its own config and device in controller
+func runNodeTest(edenBin string, args []string, node, logDir string, out io.Writer, mu *sync.Mutex) *nodeTestResult {
+	result := &nodeTestResult{node: node, logFile: filepath.Join(logDir, fmt.Sprintf("%s.log", node))}
+	start := time.Now()
+	defer func() {
+		result.duration = time.Since(start)
+	}()
+	logFile, err := os.Create(result.logFile)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer logFile.Close()
+	prefixWriter := utils.NewPrefixWriter(out, fmt.Sprintf("[%s] ", node), mu)
+	defer prefixWriter.Flush()
+	result.err = runEdenWithContext(edenBin, args, node, "", io.MultiWriter(logFile, prefixWriter))
+	return result
+}
+
+// runEdenWithContext runs eden with args in separate process using context,
+// empty dir means the current directory
+func runEdenWithContext(edenBin string, args []string, context, dir string, w io.Writer) error {
+	cmd := exec.Command(edenBin, append(append([]string{}, args...), "--config", context)...)
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", defaults.DefaultConfigEnv, context))
+	return cmd.Run()
+}
+
+// testNodes runs the same tests concurrently against EVE nodes defined by contexts
+// and prints aggregated results
+func testNodes(tstCfg *TestArgs) error {
+	if tstCfg.TestList != "" || tstCfg.TestOpts {
+		return fmt.Errorf("listing of tests is not supported with nodes")
+	}
+	if tstCfg.Watch {
+		return fmt.Errorf("watch is not supported with nodes")
+	}
+	if tstCfg.Bench != "" {
+		return fmt.Errorf("benchmark is not supported with nodes")
+	}
+	if tstCfg.Snapshot != "" {
+		return fmt.Errorf("snapshot is not supported with nodes")
+	}
+	if tstCfg.Report != "" {
+		return fmt.Errorf("report is not supported with nodes, logs are saved into --log-dir")
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	known := make(map[string]bool)
+	for _, el := range context.ListContexts() {
+		known[el] = true
+	}
+	for _, node := range tstCfg.Nodes {
+		if !known[node] {
+			return fmt.Errorf("context %s not found, create it with 'eden config add %s'", node, node)
+		}
+	}
+	edenBin, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find eden binary: %w", err)
+	}
+	logDir := tstCfg.LogDir
+	if logDir == "" {
+		logDir = filepath.Join(os.TempDir(), fmt.Sprintf("eden-test-nodes-%s", time.Now().Format("20060102-150405")))
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	args := nodeTestArgs(tstCfg)
+	log.Infof("Running tests against %d nodes, logs in %s", len(tstCfg.Nodes), logDir)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make([]*nodeTestResult, len(tstCfg.Nodes))
+	for i, node := range tstCfg.Nodes {
+		wg.Add(1)
+		go func(i int, node string) {
+			defer wg.Done()
+			results[i] = runNodeTest(edenBin, args, node, logDir, os.Stdout, &mu)
+		}(i, node)
+	}
+	wg.Wait()
+
+	failed := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "NODE\tRESULT\tDURATION\tLOG")
+	for _, result := range results {
+		status := "PASS"
+		if result.err != nil {
+			status = fmt.Sprintf("FAIL (%s)", result.err)
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.node, status, result.duration.Round(time.Second), result.logFile)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("tests failed on %d of %d nodes", failed, len(results))
+	}
+	return nil
+}
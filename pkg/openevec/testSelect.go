@@ -0,0 +1,108 @@
+package openevec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/tests"
+	log "github.com/sirupsen/logrus"
+)
+
+// EscriptNames returns names of escripts of test suite in dir
+func EscriptNames(dir string) ([]string, error) {
+	scripts, err := suiteEscripts(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, script := range scripts {
+		names = append(names, script.Name)
+	}
+	return names, nil
+}
+
+// suiteEscripts returns escripts in testdata of test suite in dir
+func suiteEscripts(dir string) ([]*tests.TestEntry, error) {
+	entries, err := tests.DiscoverTests(dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot discover tests: %w", err)
+	}
+	var scripts []*tests.TestEntry
+	for _, entry := range entries {
+		if entry.Kind == tests.KindScript && filepath.Dir(entry.Path) == filepath.Join(dir, "testdata") {
+			scripts = append(scripts, entry)
+		}
+	}
+	return scripts, nil
+}
+
+// pickTests asks to select entries to run by their numbers, empty answer selects all of them
+func pickTests(entries []*tests.TestEntry, in io.Reader, out io.Writer) ([]*tests.TestEntry, error) {
+	fmt.Fprintln(out, "Several escripts match:")
+	for i, entry := range entries {
+		description := ""
+		if entry.Description != "" {
+			description = " - " + entry.Description
+		}
+		fmt.Fprintf(out, "%3d) %s%s\n", i+1, entry.Name, description)
+	}
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprint(out, "Select escripts to run (numbers separated with commas or spaces, empty for all): ")
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if err != nil && (err != io.EOF || answer == "") {
+			return nil, fmt.Errorf("no escripts selected: %w", err)
+		}
+		if answer == "" {
+			return entries, nil
+		}
+		var selected []*tests.TestEntry
+		for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' }) {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 1 || n > len(entries) {
+				selected = nil
+				fmt.Fprintf(out, "\t%q is not a number from 1 to %d\n", field, len(entries))
+				break
+			}
+			selected = append(selected, entries[n-1])
+		}
+		if len(selected) > 0 {
+			return selected, nil
+		}
+	}
+}
+
+// selectEscripts resolves pattern of --run into escripts of test suite in the current directory
+// with name or tag matching it with tests.FuzzyMatch. If several escripts match, user picks ones to run
+// if interactive is set, otherwise all of them run. Pattern is left as regular expression of tests
+// of test binary if no escript matches
+func selectEscripts(tstCfg *TestArgs, in io.Reader, out io.Writer, interactive bool) error {
+	scripts, err := suiteEscripts(".")
+	if err != nil {
+		return err
+	}
+	matched := tests.MatchTests(scripts, tstCfg.TestRun)
+	if len(matched) == 0 {
+		return nil
+	}
+	if len(matched) > 1 && interactive {
+		if matched, err = pickTests(matched, in, out); err != nil {
+			return err
+		}
+	}
+	var names, quoted []string
+	for _, entry := range matched {
+		names = append(names, entry.Name)
+		quoted = append(quoted, regexp.QuoteMeta(entry.Name))
+	}
+	log.Infof("Run escripts matching %q: %s", tstCfg.TestRun, strings.Join(names, ", "))
+	tstCfg.TestEscript = fmt.Sprintf("^(%s)$", strings.Join(quoted, "|"))
+	tstCfg.TestRun = ""
+	return nil
+}
@@ -0,0 +1,116 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// statelessScenario checks if scenario file is marked stateless
+func statelessScenario(scenario string) (bool, error) {
+	if scenario == "" {
+		return false, nil
+	}
+	if _, err := os.Stat(scenario); os.IsNotExist(err) {
+		// resolved as RunScenario does
+		scenario = utils.ResolveAbsPath(scenario)
+	}
+	data, err := os.ReadFile(scenario)
+	if err != nil {
+		return false, fmt.Errorf("cannot read scenario: %w", err)
+	}
+	entry := &tests.TestEntry{Kind: tests.KindScenario}
+	tests.ParseTestMetadata(entry, data)
+	return entry.Stateless, nil
+}
+
+// statelessSelection checks if tests selected to run are stateless: escripts
+// matching --escript must be marked stateless themselves or run with stateless
+// scenario, other tests are stateless if their scenario is marked stateless
+func statelessSelection(tstCfg *TestArgs) (bool, error) {
+	stateless, err := statelessScenario(tstCfg.TestScenario)
+	if err != nil {
+		return false, err
+	}
+	if tstCfg.TestEscript == "" {
+		return stateless, nil
+	}
+	filter, err := regexp.Compile(tstCfg.TestEscript)
+	if err != nil {
+		return false, fmt.Errorf("invalid escript regexp: %w", err)
+	}
+	entries, err := tests.DiscoverTests(".", nil)
+	if err != nil {
+		return false, fmt.Errorf("cannot discover tests: %w", err)
+	}
+	selected := 0
+	for _, entry := range entries {
+		if entry.Kind != tests.KindScript || !filter.MatchString(entry.Name) {
+			continue
+		}
+		if !entry.Stateless && !stateless {
+			log.Infof("Escript %s is not marked stateless", entry.Name)
+			return false, nil
+		}
+		selected++
+	}
+	return selected > 0, nil
+}
+
+// enableSnapshotReset enables restore of EVE from snapshot before every selected
+// test if they are stateless, snapshot is saved from the current state of EVE if it
+// does not exist. It returns false if the selected tests are not stateless.
+func enableSnapshotReset(ctx context.Context, tstCfg *TestArgs) (bool, error) {
+	stateless, err := statelessSelection(tstCfg)
+	if err != nil {
+		return false, err
+	}
+	if !stateless {
+		log.Infof("Selected tests are not marked stateless, snapshot %s is not used", tstCfg.Snapshot)
+		return false, nil
+	}
+	cfg, err := LoadConfig(tstCfg.ConfigFile)
+	if err != nil {
+		return false, err
+	}
+	openEVEC := CreateOpenEVEC(cfg)
+	exists, err := openEVEC.SnapshotExistsEve(tstCfg.Snapshot)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		log.Infof("Saving snapshot %s of EVE to restore it between tests", tstCfg.Snapshot)
+		if err := openEVEC.SnapshotSaveEve(tstCfg.Snapshot); err != nil {
+			return false, err
+		}
+	}
+	tests.EnableReset(func() error {
+		return openEVEC.SnapshotRestoreEve(ctx, tstCfg.Snapshot)
+	})
+	return true, nil
+}
+
+// runEscriptsWithReset runs escripts matching --escript one by one resetting EVE before each of them
+func runEscriptsWithReset(tstCfg *TestArgs) error {
+	filter, err := regexp.Compile(tstCfg.TestEscript)
+	if err != nil {
+		return fmt.Errorf("invalid escript regexp: %w", err)
+	}
+	scripts, err := watchedScripts(watchScriptsDir, filter)
+	if err != nil {
+		return err
+	}
+	for _, script := range scripts {
+		if err := tests.ResetBeforeTest(); err != nil {
+			return err
+		}
+		tests.RunTest("eden.escript.test", []string{"-test.run", fmt.Sprintf("TestEdenScripts/^%s$", regexp.QuoteMeta(script))},
+			tstCfg.TestArgs, tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
+	}
+	return nil
+}
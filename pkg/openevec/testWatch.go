@@ -0,0 +1,182 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// watchScriptsDir is directory with escripts of the test directory
+	watchScriptsDir = "testdata"
+	// watchEscriptBin is test binary running escripts
+	watchEscriptBin = "eden.escript.test"
+	// watchDebounce is time to wait for more changes before running scripts
+	watchDebounce = time.Second
+)
+
+// watchedScripts returns names of escripts in dir matching filter
+func watchedScripts(dir string, filter *regexp.Regexp) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	var scripts []string
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		if filter.MatchString(name) {
+			scripts = append(scripts, name)
+		}
+	}
+	return scripts, nil
+}
+
+// affectedScripts returns scripts affected by change of file: the script itself
+// or scripts using changed helper binary, all scripts are affected by change of
+// escript binary or eden itself
+func affectedScripts(file, scriptsDir, binDir string, filter *regexp.Regexp) ([]string, error) {
+	name := filepath.Base(file)
+	switch filepath.Dir(file) {
+	case scriptsDir:
+		if !strings.HasSuffix(name, ".txt") {
+			return nil, nil
+		}
+		script := strings.TrimSuffix(name, ".txt")
+		if _, err := os.Stat(file); err != nil || !filter.MatchString(script) {
+			return nil, nil
+		}
+		return []string{script}, nil
+	case binDir:
+		scripts, err := watchedScripts(scriptsDir, filter)
+		if err != nil || name == watchEscriptBin || name == "eden" {
+			return scripts, err
+		}
+		var affected []string
+		for _, script := range scripts {
+			data, err := os.ReadFile(filepath.Join(scriptsDir, script+".txt"))
+			if err != nil {
+				return nil, err
+			}
+			if strings.Contains(string(data), name) {
+				affected = append(affected, script)
+			}
+		}
+		return affected, nil
+	}
+	return nil, nil
+}
+
+// runWatchedScripts runs escripts against the running environment,
+// failures do not stop watching
+func runWatchedScripts(tstCfg *TestArgs, scripts []string) {
+	sort.Strings(scripts)
+	for i := range scripts {
+		scripts[i] = regexp.QuoteMeta(scripts[i])
+	}
+	log.Infof("Running %s", strings.Join(scripts, ", "))
+	tests.RunTest(watchEscriptBin, []string{"-test.run", fmt.Sprintf("TestEdenScripts/^(%s)$", strings.Join(scripts, "|"))},
+		tstCfg.TestArgs, tstCfg.TestTimeout, "", tstCfg.ConfigFile, tstCfg.Verbosity)
+	log.Infof("Waiting for changes")
+}
+
+// testWatch watches escripts of the test directory matching --escript and helper
+// binaries and reruns affected scripts after they change until interrupted
+func testWatch(ctx context.Context, tstCfg *TestArgs) error {
+	if tstCfg.TestList != "" || tstCfg.TestOpts || tstCfg.TestRun != "" {
+		return fmt.Errorf("watch is supported only for escripts")
+	}
+	filter, err := regexp.Compile(tstCfg.TestEscript)
+	if err != nil {
+		return fmt.Errorf("invalid escript regexp: %w", err)
+	}
+	scriptsDir, err := filepath.Abs(watchScriptsDir)
+	if err != nil {
+		return err
+	}
+	vars, err := utils.InitVars()
+	if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+	binDir, err := filepath.Abs(utils.ResolveAbsPath(vars.EdenBinDir))
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	// watch directories instead of files to notice files replaced by editors and builds
+	for _, dir := range []string{scriptsDir, binDir} {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("cannot watch %s: %w", dir, err)
+		}
+	}
+
+	if tstCfg.TestEscript != "" {
+		scripts, err := watchedScripts(scriptsDir, filter)
+		if err != nil {
+			return err
+		}
+		if len(scripts) > 0 {
+			runWatchedScripts(tstCfg, scripts)
+		}
+	}
+	log.Infof("Watching %s and %s for changes, press Ctrl+C to stop", scriptsDir, binDir)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("watcher closed")
+			}
+			log.Errorf("watch error: %s", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("watcher closed")
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			log.Debugf("%s changed", event.Name)
+			pending[event.Name] = true
+			// wait for save or build to finish
+			timer.Reset(watchDebounce)
+		case <-timer.C:
+			affected := make(map[string]bool)
+			for file := range pending {
+				scripts, err := affectedScripts(file, scriptsDir, binDir, filter)
+				if err != nil {
+					log.Errorf("cannot find scripts affected by %s: %s", file, err)
+				}
+				for _, script := range scripts {
+					affected[script] = true
+				}
+			}
+			pending = make(map[string]bool)
+			if len(affected) == 0 {
+				continue
+			}
+			var scripts []string
+			for script := range affected {
+				scripts = append(scripts, script)
+			}
+			runWatchedScripts(tstCfg, scripts)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// twinConfigFile is the file inside twin directory with device config in json
+const twinConfigFile = "config.json"
+
+// contextName returns name of the current context
+func (openEVEC *OpenEVEC) contextName() (string, error) {
+	if openEVEC.cfg.ConfigName != "" {
+		return openEVEC.cfg.ConfigName, nil
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return "", fmt.Errorf("load context error: %w", err)
+	}
+	return context.Current, nil
+}
+
+// twinDir returns directory of offline device twin for the current context
+func (openEVEC *OpenEVEC) twinDir() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	configName, err := openEVEC.contextName()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultTwinDirectory, configName), nil
+}
+
+// writeTwinFile saves data into dir with name and modification time defined by timestamp,
+// so file loader will process files in the same order as controller did
+func writeTwinFile(dir string, ts time.Time, data []byte) error {
+	fileName := filepath.Join(dir, fmt.Sprintf("%d:%09d", ts.Unix(), ts.Nanosecond()))
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		return err
+	}
+	return os.Chtimes(fileName, ts, ts)
+}
+
+// DeviceTwin is the latest state of device saved for offline commands
+type DeviceTwin struct {
+	// Config is device config in json
+	Config []byte
+	// Info are the last info messages of every object
+	Info   []*info.ZInfoMsg
+	Metric *metrics.ZMetricMsg
+	// Logs is the tail of logs in order of their arrival
+	Logs []*elog.FullLogEntry
+}
+
+// SaveTwin saves twin of device with devUUID into dir replacing the previous one
+func SaveTwin(dir string, devUUID uuid.UUID, twin *DeviceTwin) error {
+	// write into temporary directory and replace the old twin at the end
+	// to not leave partially written twin
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	devDir := filepath.Join(tmpDir, devUUID.String())
+	for _, el := range []string{"info", "metrics", "logs"} {
+		if err := os.MkdirAll(filepath.Join(devDir, el), 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, twinConfigFile), twin.Config, 0644); err != nil {
+		return err
+	}
+	for _, im := range twin.Info {
+		data, err := proto.Marshal(im)
+		if err != nil {
+			return fmt.Errorf("cannot marshal info: %w", err)
+		}
+		if err := writeTwinFile(filepath.Join(devDir, "info"), im.GetAtTimeStamp().AsTime(), data); err != nil {
+			return err
+		}
+	}
+	if twin.Metric != nil {
+		data, err := proto.Marshal(twin.Metric)
+		if err != nil {
+			return fmt.Errorf("cannot marshal metric: %w", err)
+		}
+		if err := writeTwinFile(filepath.Join(devDir, "metrics"), twin.Metric.GetAtTimeStamp().AsTime(), data); err != nil {
+			return err
+		}
+	}
+	for _, le := range twin.Logs {
+		data, err := protojson.Marshal(le)
+		if err != nil {
+			return fmt.Errorf("cannot marshal log: %w", err)
+		}
+		if err := writeTwinFile(filepath.Join(devDir, "logs"), le.GetTimestamp().AsTime(), data); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dir)
+}
+
+// LoadTwin returns read-only controller and device reconstructed from twin saved in dir
+func LoadTwin(dir string, vars *utils.ConfigVars) (controller.Cloud, *device.Ctx, error) {
+	data, err := os.ReadFile(filepath.Join(dir, twinConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("no offline twin found for context, run online command (e.g. 'eden status') first")
+		}
+		return nil, nil, fmt.Errorf("cannot read offline twin: %w", err)
+	}
+	var deviceConfig config.EdgeDevConfig
+	if err := json.Unmarshal(data, &deviceConfig); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	vars.AdamOfflineDir = dir
+	return controller.CloudPrepareOffline(vars, &deviceConfig)
+}
+
+// twinRecorder collects the last info of every object and the last metric from messages
+// processed by online commands, so twin is saved without reading them from controller again
+type twinRecorder struct {
+	keys       []string
+	lastInfo   map[string]*info.ZInfoMsg
+	lastMetric *metrics.ZMetricMsg
+}
+
+func newTwinRecorder() *twinRecorder {
+	return &twinRecorder{lastInfo: make(map[string]*info.ZInfoMsg)}
+}
+
+// infoCallback records info message and passes it to handler
+func (r *twinRecorder) infoCallback(handler einfo.HandlerFunc) einfo.HandlerFunc {
+	return func(im *info.ZInfoMsg) bool {
+		key := einfo.ZInfoObjectKey(im)
+		if _, ok := r.lastInfo[key]; !ok {
+			r.keys = append(r.keys, key)
+		}
+		r.lastInfo[key] = im
+		return handler(im)
+	}
+}
+
+// metricCallback records metric message and passes it to handler
+func (r *twinRecorder) metricCallback(handler emetric.HandlerFunc) emetric.HandlerFunc {
+	return func(mm *metrics.ZMetricMsg) bool {
+		r.lastMetric = mm
+		return handler(mm)
+	}
+}
+
+// saveTwin persists config, messages collected by recorder and tail of logs
+// into the twin directory of current context
+func (openEVEC *OpenEVEC) saveTwin(ctrl controller.Cloud, dev *device.Ctx, recorder *twinRecorder) error {
+	twinDir, err := openEVEC.twinDir()
+	if err != nil {
+		return err
+	}
+	twin := &DeviceTwin{Metric: recorder.lastMetric}
+	for _, key := range recorder.keys {
+		twin.Info = append(twin.Info, recorder.lastInfo[key])
+	}
+	// tail of logs is read from the newest entry, so the rest of history is not read
+	if err := ctrl.LogChecker(dev.GetID(), map[string]string{}, func(le *elog.FullLogEntry) bool {
+		twin.Logs = append(twin.Logs, le)
+		return false
+	}, elog.LogTail(defaults.DefaultTwinLogTail), 0); err != nil {
+		return fmt.Errorf("LogChecker: %w", err)
+	}
+	if twin.Config, err = ctrl.GetConfigBytes(dev, true); err != nil {
+		return fmt.Errorf("GetConfigBytes: %w", err)
+	}
+	if err := SaveTwin(twinDir, dev.GetID(), twin); err != nil {
+		return err
+	}
+	log.Debugf("offline twin saved into %s", twinDir)
+	return nil
+}
+
+// getTwinControllerAndDev returns read-only controller and device
+// reconstructed from the offline twin of current context
+func (openEVEC *OpenEVEC) getTwinControllerAndDev() (controller.Cloud, *device.Ctx, error) {
+	twinDir, err := openEVEC.twinDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	vars, err := InitVarsFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("InitVarsFromConfig error: %w", err)
+	}
+	return LoadTwin(twinDir, vars)
+}
+
+// twinSavedAt returns time of the last save of offline twin
+func (openEVEC *OpenEVEC) twinSavedAt() (time.Time, error) {
+	twinDir, err := openEVEC.twinDir()
+	if err != nil {
+		return time.Time{}, err
+	}
+	fi, err := os.Stat(filepath.Join(twinDir, twinConfigFile))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// getControllerAndDev returns controller and device from adam
+// or from the offline twin if offline is set
+func (openEVEC *OpenEVEC) getControllerAndDev(offline bool) (controller.Cloud, *device.Ctx, error) {
+	if offline {
+		ctrl, dev, err := openEVEC.getTwinControllerAndDev()
+		if err != nil {
+			return nil, nil, fmt.Errorf("getTwinControllerAndDev: %w", err)
+		}
+		if savedAt, err := openEVEC.twinSavedAt(); err == nil {
+			log.Infof("Using offline data saved at %s", savedAt.Format(time.RFC3339))
+		}
+		return ctrl, dev, nil
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	return ctrl, dev, nil
+}
+
+// updateTwin saves offline twin and only logs a warning in case of error
+// as it must not break online commands
+func (openEVEC *OpenEVEC) updateTwin(ctrl controller.Cloud, dev *device.Ctx, recorder *twinRecorder) {
+	if err := openEVEC.saveTwin(ctrl, dev, recorder); err != nil {
+		log.Warnf("cannot save offline twin: %s", err)
+	}
+}
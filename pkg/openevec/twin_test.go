@@ -0,0 +1,107 @@
+package openevec_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	. "github.com/onsi/gomega"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTwinRoundTrip(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	devUUID := uuid.FromStringOrNil("1b4a1b4a-8c4a-4b1e-9b4a-1b4a1b4a1b4a")
+	devConfig, err := json.Marshal(&config.EdgeDevConfig{
+		Id:          &config.UUIDandVersion{Uuid: devUUID.String(), Version: "3"},
+		ProductName: "ZedVirtual-4G",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	now := time.Now().Truncate(time.Second)
+	twin := &openevec.DeviceTwin{
+		Config: devConfig,
+		Info: []*info.ZInfoMsg{
+			{
+				Ztype:       info.ZInfoTypes_ZiDevice,
+				DevId:       devUUID.String(),
+				AtTimeStamp: timestamppb.New(now.Add(-2 * time.Second)),
+				InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{State: info.ZDeviceState_ZDEVICE_STATE_ONLINE}},
+			},
+			{
+				Ztype:       info.ZInfoTypes_ZiApp,
+				DevId:       devUUID.String(),
+				AtTimeStamp: timestamppb.New(now.Add(-time.Second)),
+				InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{AppID: "app", AppName: "nginx"}},
+			},
+		},
+		Metric: &metrics.ZMetricMsg{
+			DevID:       devUUID.String(),
+			AtTimeStamp: timestamppb.New(now),
+			MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+				Memory: &metrics.MemoryMetric{UsedMem: 512},
+			}},
+		},
+	}
+	for i, content := range []string{"first", "second"} {
+		twin.Logs = append(twin.Logs, &elog.FullLogEntry{LogEntry: logs.LogEntry{
+			Content:   content,
+			Timestamp: timestamppb.New(now.Add(time.Duration(i-3) * time.Second)),
+		}})
+	}
+
+	dir := filepath.Join(t.TempDir(), "twin")
+	oldUUID := uuid.FromStringOrNil("2c5b2c5b-9d5b-4c2f-8c5b-2c5b2c5b2c5b")
+	g.Expect(openevec.SaveTwin(dir, oldUUID, &openevec.DeviceTwin{Config: devConfig})).To(Succeed())
+	g.Expect(openevec.SaveTwin(dir, devUUID, twin)).To(Succeed())
+	// the previous twin is replaced
+	g.Expect(filepath.Join(dir, oldUUID.String())).NotTo(BeADirectory())
+
+	ctrl, dev, err := openevec.LoadTwin(dir, &utils.ConfigVars{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dev.GetID()).To(Equal(devUUID))
+	g.Expect(dev.GetDevModel()).To(Equal("ZedVirtual-4G"))
+
+	loadedInfo := make(map[info.ZInfoTypes]*info.ZInfoMsg)
+	g.Expect(ctrl.InfoLastCallback(devUUID, nil, func(im *info.ZInfoMsg) bool {
+		loadedInfo[im.GetZtype()] = im
+		return false
+	})).To(Succeed())
+	g.Expect(loadedInfo).To(HaveLen(2))
+	g.Expect(loadedInfo[info.ZInfoTypes_ZiDevice].GetDinfo().GetState()).To(Equal(info.ZDeviceState_ZDEVICE_STATE_ONLINE))
+	g.Expect(loadedInfo[info.ZInfoTypes_ZiApp].GetAinfo().GetAppName()).To(Equal("nginx"))
+
+	var loadedMetric *metrics.ZMetricMsg
+	g.Expect(ctrl.MetricLastCallback(devUUID, nil, func(mm *metrics.ZMetricMsg) bool {
+		loadedMetric = mm
+		return false
+	})).To(Succeed())
+	g.Expect(loadedMetric.GetDm().GetMemory().GetUsedMem()).To(Equal(uint32(512)))
+	g.Expect(loadedMetric.GetAtTimeStamp().AsTime()).To(BeTemporally("==", now))
+
+	var loadedLogs []string
+	g.Expect(ctrl.LogChecker(devUUID, map[string]string{}, func(le *elog.FullLogEntry) bool {
+		loadedLogs = append(loadedLogs, le.GetContent())
+		return false
+	}, elog.LogTail(10), 0)).To(Succeed())
+	g.Expect(loadedLogs).To(Equal([]string{"first", "second"}))
+}
+
+func TestLoadTwinMissing(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	_, _, err := openevec.LoadTwin(t.TempDir(), &utils.ConfigVars{})
+	g.Expect(err).To(MatchError(ContainSubstring("no offline twin found")))
+}
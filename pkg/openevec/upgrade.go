@@ -0,0 +1,423 @@
+package openevec
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// EdenVersionInfo describes build of eden
+type EdenVersionInfo struct {
+	Version     string `json:"version" yaml:"version"`
+	StateFormat int    `json:"state-format" yaml:"state-format"`
+	OS          string `json:"os" yaml:"os"`
+	Arch        string `json:"arch" yaml:"arch"`
+}
+
+// CurrentVersion returns description of running eden
+func CurrentVersion() EdenVersionInfo {
+	return EdenVersionInfo{
+		Version:     defaults.EdenVersion,
+		StateFormat: defaults.StateFormat,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+	}
+}
+
+// PrintVersion prints version of eden and format of state it uses
+func PrintVersion(format types.OutputFormat) error {
+	info := CurrentVersion()
+	switch format {
+	case types.OutputFormatJSON:
+		data, err := json.Marshal(info)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case types.OutputFormatYAML:
+		data, err := yaml.Marshal(info)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	default:
+		fmt.Printf("eden %s (%s/%s), state format %d\n", info.Version, info.OS, info.Arch, info.StateFormat)
+	}
+	return nil
+}
+
+// UpgradeOptions defines release of eden to install with EdenUpgrade
+type UpgradeOptions struct {
+	// Version is tag of release, the latest release is used if empty
+	Version string
+	// SHA256 is expected checksum of archive of release, checksum published with release is used if empty
+	SHA256 string
+	// PublicKey is base64 DER of ed25519 key to verify signature of release with,
+	// the key pinned into build of eden is used if empty
+	PublicKey string
+	// Repo is GitHub repository with releases of eden
+	Repo string
+	// GitHubURL and APIURL are addresses of GitHub and its API
+	GitHubURL string
+	APIURL    string
+	// Executable is binary of eden to replace, the running one if empty
+	Executable string
+	// Force installs release even if it is the current one or cannot read state of context
+	Force bool
+}
+
+// upgradeBackupSuffix is suffix of copy of the previous binary of eden used by EdenRollback
+const upgradeBackupSuffix = ".old"
+
+// releaseArchiveName returns name of archive with eden for os and arch uploaded into releases
+func releaseArchiveName() string {
+	return fmt.Sprintf("eden.%s.%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+}
+
+// upgradeHTTPTimeout limits requests to GitHub including download of archive of release
+const upgradeHTTPTimeout = 10 * time.Minute
+
+var upgradeHTTPClient = &http.Client{Timeout: upgradeHTTPTimeout}
+
+// httpGet returns body of url, status other than 200 is an error
+func httpGet(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := upgradeHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// latestRelease returns tag of the most recent published release of repo
+func latestRelease(ctx context.Context, apiURL, repo string) (string, error) {
+	body, err := httpGet(ctx, fmt.Sprintf("%s/repos/%s/releases", apiURL, repo))
+	if err != nil {
+		return "", fmt.Errorf("cannot list releases: %w", err)
+	}
+	defer body.Close()
+	var releases []struct {
+		TagName string `json:"tag_name"`
+		Draft   bool   `json:"draft"`
+	}
+	if err := json.NewDecoder(body).Decode(&releases); err != nil {
+		return "", fmt.Errorf("cannot parse releases: %w", err)
+	}
+	// releases are sorted from the newest one
+	for _, release := range releases {
+		if !release.Draft {
+			return release.TagName, nil
+		}
+	}
+	return "", fmt.Errorf("no releases found in %s", repo)
+}
+
+// releaseChecksum returns checksum published next to archive of release
+func releaseChecksum(ctx context.Context, archiveURL string) (string, error) {
+	body, err := httpGet(ctx, archiveURL+".sha256")
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	// sha256sum format: <checksum>  <file>
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file")
+	}
+	return fields[0], nil
+}
+
+// releasePublicKey decodes base64 DER of ed25519 public key which signs releases
+func releasePublicKey(key string) (ed25519.PublicKey, error) {
+	if key == "" {
+		key = defaults.EdenReleaseKey
+	}
+	if key == "" {
+		return nil, fmt.Errorf("no key to verify signature of release is pinned into this build of eden, set it with --public-key")
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode public key: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key: %w", err)
+	}
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is %T, not ed25519", pub)
+	}
+	return edPub, nil
+}
+
+// releaseSignature returns base64 ed25519 signature of archive published next to it
+func releaseSignature(ctx context.Context, archiveURL string) ([]byte, error) {
+	body, err := httpGet(ctx, archiveURL+".sig")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, 1024))
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode signature: %w", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("signature has %d bytes instead of %d", len(sig), ed25519.SignatureSize)
+	}
+	return sig, nil
+}
+
+// extractEden saves binary of eden from archive of release into file dst
+func extractEden(archive io.Reader, dst string) error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	// ./eden is a symlink to the binary in dist/bin of archive
+	names := []string{
+		fmt.Sprintf("dist/bin/eden-%s-%s", runtime.GOOS, runtime.GOARCH),
+		"eden",
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("eden binary not found in archive")
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || (name != names[0] && name != names[1]) {
+			continue
+		}
+		f, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
+
+// binaryVersion runs version command of binary of eden, binaries without
+// the command are older than versioned state format and use format 0
+func binaryVersion(binary string) (EdenVersionInfo, error) {
+	out, err := exec.Command(binary, "version", "--output", "json").Output()
+	if err != nil {
+		if errors.As(err, new(*exec.ExitError)) {
+			return EdenVersionInfo{Version: "unknown"}, nil
+		}
+		return EdenVersionInfo{}, err
+	}
+	var info EdenVersionInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		return EdenVersionInfo{}, fmt.Errorf("cannot parse version of %s: %w", binary, err)
+	}
+	return info, nil
+}
+
+// checkStateFormat returns error if eden with version info cannot read state of context
+func (openEVEC *OpenEVEC) checkStateFormat(info EdenVersionInfo) error {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	format, err := utils.StateFormatOf(edenDir, openEVEC.cfg.Eve.CertsUUID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// no state saved by context yet
+			return nil
+		}
+		return err
+	}
+	if format > info.StateFormat {
+		return fmt.Errorf("state of context has format %d, eden %s supports formats up to %d",
+			format, info.Version, info.StateFormat)
+	}
+	return nil
+}
+
+// copyExecutable copies binary src into dst keeping its mode
+func copyExecutable(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := utils.CopyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Chmod(dst, info.Mode())
+}
+
+// upgradeExecutable returns binary of eden to replace, symlinks are resolved
+func upgradeExecutable(executable string) (string, error) {
+	if executable == "" {
+		var err error
+		if executable, err = os.Executable(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.EvalSymlinks(executable)
+}
+
+// EdenUpgrade downloads release of eden, verifies its checksum, signature and ability to read
+// state of context and replaces binary of eden with it keeping the previous one for EdenRollback
+func (openEVEC *OpenEVEC) EdenUpgrade(ctx context.Context, opts UpgradeOptions) error {
+	executable, err := upgradeExecutable(opts.Executable)
+	if err != nil {
+		return fmt.Errorf("cannot find eden binary: %w", err)
+	}
+	version := opts.Version
+	if version == "" {
+		if version, err = latestRelease(ctx, opts.APIURL, opts.Repo); err != nil {
+			return err
+		}
+	}
+	if version == defaults.EdenVersion && !opts.Force {
+		log.Infof("eden %s is already installed", version)
+		return nil
+	}
+	archiveURL := fmt.Sprintf("%s/%s/releases/download/%s/%s", opts.GitHubURL, opts.Repo, version, releaseArchiveName())
+	checksum := opts.SHA256
+	if checksum == "" {
+		if checksum, err = releaseChecksum(ctx, archiveURL); err != nil {
+			return fmt.Errorf("no checksum of release %s, set it with --sha256: %w", version, err)
+		}
+	}
+	publicKey, err := releasePublicKey(opts.PublicKey)
+	if err != nil {
+		return err
+	}
+	signature, err := releaseSignature(ctx, archiveURL)
+	if err != nil {
+		return fmt.Errorf("no signature of release %s: %w", version, err)
+	}
+
+	log.Infof("Downloading %s", archiveURL)
+	body, err := httpGet(ctx, archiveURL)
+	if err != nil {
+		return fmt.Errorf("cannot download release %s: %w", version, err)
+	}
+	defer body.Close()
+	// new binary is created next to the current one to replace it with rename
+	archive, err := os.CreateTemp(filepath.Dir(executable), ".eden-release-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(archive, io.TeeReader(body, hash)); err != nil {
+		return fmt.Errorf("cannot download release %s: %w", version, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, checksum) {
+		return fmt.Errorf("checksum mismatch of release %s: expected %s, got %s", version, checksum, sum)
+	}
+	data, err := os.ReadFile(archive.Name())
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, data, signature) {
+		return fmt.Errorf("signature of release %s does not match its archive", version)
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	newBinary := executable + ".new"
+	if err := extractEden(archive, newBinary); err != nil {
+		return fmt.Errorf("cannot extract release %s: %w", version, err)
+	}
+	defer os.Remove(newBinary)
+
+	info, err := binaryVersion(newBinary)
+	if err != nil {
+		return fmt.Errorf("cannot run eden %s: %w", version, err)
+	}
+	if info.Version == "unknown" {
+		info.Version = version
+	}
+	if err := openEVEC.checkStateFormat(info); err != nil {
+		if !opts.Force {
+			return fmt.Errorf("eden %s is not compatible with context: %w", version, err)
+		}
+		log.Warn(err)
+	}
+
+	if err := copyExecutable(executable, executable+upgradeBackupSuffix); err != nil {
+		return fmt.Errorf("cannot save previous eden binary: %w", err)
+	}
+	if err := os.Rename(newBinary, executable); err != nil {
+		return fmt.Errorf("cannot replace eden binary: %w", err)
+	}
+	log.Infof("eden %s is installed into %s, use 'eden upgrade --rollback' to return to %s",
+		version, executable, defaults.EdenVersion)
+	return nil
+}
+
+// EdenRollback returns binary of eden saved by the last EdenUpgrade, the replaced
+// binary is saved instead, so the next rollback repeats the upgrade
+func EdenRollback(executable string) error {
+	executable, err := upgradeExecutable(executable)
+	if err != nil {
+		return fmt.Errorf("cannot find eden binary: %w", err)
+	}
+	backup := executable + upgradeBackupSuffix
+	if _, err := os.Stat(backup); err != nil {
+		return fmt.Errorf("no previous eden binary to roll back to: %w", err)
+	}
+	current := executable + ".prev"
+	if err := copyExecutable(executable, current); err != nil {
+		return err
+	}
+	if err := os.Rename(backup, executable); err != nil {
+		return fmt.Errorf("cannot restore eden binary: %w", err)
+	}
+	if err := os.Rename(current, backup); err != nil {
+		return err
+	}
+	log.Infof("Previous eden binary is restored into %s", executable)
+	return nil
+}
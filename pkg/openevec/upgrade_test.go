@@ -0,0 +1,116 @@
+package openevec_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	. "github.com/onsi/gomega"
+)
+
+// releaseArchive returns archive of release with eden binary which prints its version
+func releaseArchive(t *testing.T, version string) []byte {
+	script := fmt.Sprintf("#!/bin/sh\necho '{\"version\":\"%s\",\"state-format\":1}'\n", version)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	name := fmt.Sprintf("./dist/bin/eden-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if err := tw.WriteHeader(&tar.Header{Name: "./eden", Typeflag: tar.TypeSymlink, Linkname: name[2:]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len(script))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(script)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEdenUpgrade(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	archive := releaseArchive(t, "9.9.9")
+	archivePath := fmt.Sprintf("/lf-edge/eden/releases/download/9.9.9/eden.%s.%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/lf-edge/eden/releases", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"tag_name":"10.0.0","draft":true},{"tag_name":"9.9.9"}]`)
+	})
+	mux.HandleFunc(archivePath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	mux.HandleFunc(archivePath+".sha256", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%x  eden.tar.gz\n", sha256.Sum256(archive))
+	})
+	pub, priv, err := ed25519.GenerateKey(nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	g.Expect(err).ToNot(HaveOccurred())
+	mux.HandleFunc(archivePath+".sig", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, base64.StdEncoding.EncodeToString(ed25519.Sign(priv, archive)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	executable := filepath.Join(t.TempDir(), "eden")
+	g.Expect(os.WriteFile(executable, []byte("old"), 0755)).To(Succeed())
+	opts := openevec.UpgradeOptions{
+		Repo:       "lf-edge/eden",
+		GitHubURL:  server.URL,
+		APIURL:     server.URL,
+		Executable: executable,
+	}
+	openEVEC := openevec.CreateOpenEVEC(&openevec.EdenSetupArgs{})
+
+	// no key is pinned into test binary
+	g.Expect(openEVEC.EdenUpgrade(context.Background(), opts)).To(MatchError(ContainSubstring("no key to verify signature")))
+
+	// signature made by another key keeps the binary
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	otherDer, err := x509.MarshalPKIXPublicKey(otherPub)
+	g.Expect(err).ToNot(HaveOccurred())
+	opts.PublicKey = base64.StdEncoding.EncodeToString(otherDer)
+	g.Expect(openEVEC.EdenUpgrade(context.Background(), opts)).To(MatchError(ContainSubstring("signature of release 9.9.9 does not match")))
+	g.Expect(os.ReadFile(executable)).To(BeEquivalentTo("old"))
+
+	// wrong pinned checksum keeps the binary
+	opts.PublicKey = base64.StdEncoding.EncodeToString(der)
+	opts.SHA256 = "0000"
+	g.Expect(openEVEC.EdenUpgrade(context.Background(), opts)).To(MatchError(ContainSubstring("checksum mismatch")))
+	g.Expect(os.ReadFile(executable)).To(BeEquivalentTo("old"))
+
+	// latest non-draft release with published checksum
+	opts.SHA256 = ""
+	g.Expect(openEVEC.EdenUpgrade(context.Background(), opts)).To(Succeed())
+	g.Expect(os.ReadFile(executable)).To(ContainSubstring("9.9.9"))
+	g.Expect(os.ReadFile(executable + ".old")).To(BeEquivalentTo("old"))
+	g.Expect(executable + ".new").ToNot(BeAnExistingFile())
+
+	g.Expect(openevec.EdenRollback(executable)).To(Succeed())
+	g.Expect(os.ReadFile(executable)).To(BeEquivalentTo("old"))
+	g.Expect(os.ReadFile(executable + ".old")).To(ContainSubstring("9.9.9"))
+	info, err := os.Stat(executable)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+}
@@ -41,18 +41,32 @@ func (openEVEC *OpenEVEC) DownloadEve() error {
 		Format:      format,
 		ImageSizeMB: cfg.Eve.ImageSizeMB,
 	}
+	image, err := eveDesc.Image()
+	if err != nil {
+		return err
+	}
+	digests, err := verifyEveImage(*cfg, image)
+	if err != nil {
+		return err
+	}
 	if err := utils.DownloadEveLive(eveDesc, cfg.Eve.ImageFile); err != nil {
 		return err
 	}
 	if err := utils.DownloadUEFI(eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
 		return err
 	}
+	if err := utils.VerifyUEFI(eveDesc, filepath.Dir(cfg.Eve.ImageFile), qemuFirmwareFiles(cfg.Eve.QemuFirmware)); err != nil {
+		return fmt.Errorf("verification of UEFI failed: %w", err)
+	}
+	if err := saveEveDigests(*cfg, digests, append([]string{cfg.Eve.ImageFile}, qemuFirmwareFiles(cfg.Eve.QemuFirmware)...)...); err != nil {
+		return err
+	}
 	log.Infof(model.DiskReadyMessage(), cfg.Eve.ImageFile)
 	fmt.Println(cfg.Eve.ImageFile)
 	return nil
 }
 
-func (openEVEC *OpenEVEC) OciImage(fileToSave, image, registry string, isLocal bool) error {
+func (openEVEC *OpenEVEC) OciImage(ctx context.Context, fileToSave, image, registry string, isLocal bool) error {
 	var imageManifest []byte
 	var err error
 	ref, err := name.ParseReference(image)
@@ -61,7 +75,7 @@ func (openEVEC *OpenEVEC) OciImage(fileToSave, image, registry string, isLocal b
 	}
 	var img v1.Image
 	if !isLocal {
-		desc, err := remote.Get(ref)
+		desc, err := remote.Get(ref, remote.WithContext(ctx))
 		if err != nil {
 			return err
 		}
@@ -70,14 +84,12 @@ func (openEVEC *OpenEVEC) OciImage(fileToSave, image, registry string, isLocal b
 			return err
 		}
 	} else {
-		ctx := context.Background()
 		cli, err := client.NewClientWithOpts(client.FromEnv)
 		if err != nil {
 			return err
 		}
 		cli.NegotiateAPIVersion(ctx)
-		options := daemon.WithClient(cli)
-		img, err = daemon.Image(ref, options)
+		img, err = daemon.Image(ref, daemon.WithClient(cli), daemon.WithContext(ctx))
 		if err != nil {
 			return err
 		}
@@ -240,3 +252,44 @@ func (openEVEC *OpenEVEC) UploadGit(absPath, object, branch, directoryToSave str
 	fmt.Println(result)
 	return nil
 }
+
+// ConvertImage converts image of EVE (input, image of context if empty) into
+// output (input with extension of format if empty) of format, which is chosen
+// by devmodel of context if empty
+func (openEVEC *OpenEVEC) ConvertImage(input, output, format string) error {
+	cfg := openEVEC.cfg
+	if input == "" {
+		input = cfg.Eve.ImageFile
+	}
+	if format == "" {
+		switch cfg.Eve.DevModel {
+		case defaults.DefaultGCPModel:
+			format = utils.ImageFormatGCP
+		case defaults.DefaultVBoxModel:
+			format = utils.ImageFormatOVA
+		default:
+			return fmt.Errorf("no default format of image for devmodel %s, use --format", cfg.Eve.DevModel)
+		}
+	}
+	if output == "" {
+		ext := format
+		if format == utils.ImageFormatGCP {
+			ext = "tar.gz"
+		}
+		output = strings.TrimSuffix(input, filepath.Ext(input)) + "." + ext
+	}
+	if output == input {
+		return fmt.Errorf("output image must differ from input one: %s", input)
+	}
+	vm := utils.ImageVM{
+		Name:     cfg.Eve.Name,
+		CPUs:     cfg.Eve.QemuCpus,
+		MemoryMB: cfg.Eve.QemuMemory,
+	}
+	if err := utils.ConvertImage(input, output, format, vm); err != nil {
+		return fmt.Errorf("cannot convert %s into %s: %w", input, format, err)
+	}
+	log.Infof("Image of EVE in %s format saved into %s", format, output)
+	fmt.Println(output)
+	return nil
+}
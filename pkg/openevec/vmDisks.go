@@ -0,0 +1,93 @@
+package openevec
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// vmDisks returns additional disks of EVE VM defined by disk-layout of config
+// or by count of disks, files of disks with extension ext are placed near
+// image of EVE
+func vmDisks(cfg EdenSetupArgs, ext string) ([]utils.VMDisk, error) {
+	layout := cfg.Eve.DiskLayout
+	if len(layout) == 0 {
+		for ind := 0; ind < cfg.Eve.Disks; ind++ {
+			layout = append(layout, DiskConfig{})
+		}
+	}
+	var disks []utils.VMDisk
+	for ind, disk := range layout {
+		if !utils.IsValidDiskBus(disk.Bus) {
+			return nil, fmt.Errorf("unsupported bus %s of disk %d", disk.Bus, ind+1)
+		}
+		if disk.SizeMB == 0 {
+			disk.SizeMB = cfg.Eve.ImageSizeMB
+		}
+		if disk.Format == "" {
+			disk.Format = "qcow2"
+		}
+		disks = append(disks, utils.VMDisk{
+			File:   filepath.Join(filepath.Dir(cfg.Eve.ImageFile), fmt.Sprintf("eve-disk-%d.%s", ind+1, ext)),
+			Format: disk.Format,
+			SizeMB: disk.SizeMB,
+			Bus:    disk.Bus,
+		})
+	}
+	return disks, nil
+}
+
+// guestDiskNames returns names of disks of layout inside of EVE VM, the first
+// disk attached with default interface is image of EVE (or installer)
+func guestDiskNames(arch string, layout []DiskConfig) []string {
+	defaultBus := utils.DiskBusSATA
+	if arch == "arm64" {
+		defaultBus = utils.DiskBusVirtio
+	}
+	prefix := func(bus string) string {
+		if bus == "" {
+			bus = defaultBus
+		}
+		switch bus {
+		case utils.DiskBusVirtio:
+			return "vd"
+		case utils.DiskBusNVMe:
+			return "nvme"
+		}
+		return "sd"
+	}
+	counts := map[string]int{prefix(defaultBus): 1}
+	var names []string
+	for _, disk := range layout {
+		p := prefix(disk.Bus)
+		if p == "nvme" {
+			names = append(names, fmt.Sprintf("nvme%dn1", counts[p]))
+		} else {
+			names = append(names, fmt.Sprintf("%s%c", p, 'a'+counts[p]))
+		}
+		counts[p]++
+	}
+	return names
+}
+
+// PersistGrubOptions returns grub options for installer of EVE to place
+// persist onto disks of layout marked as persist, ZFS pool is created if
+// there are several such disks
+func PersistGrubOptions(arch string, layout []DiskConfig) []string {
+	var persist []string
+	for ind, name := range guestDiskNames(arch, layout) {
+		if layout[ind].Persist {
+			persist = append(persist, name)
+		}
+	}
+	if len(persist) == 0 {
+		return nil
+	}
+	args := "eve_persist_disk=" + strings.Join(persist, ",")
+	if len(persist) > 1 {
+		args += " eve_persist_type=zfs"
+	}
+	return []string{fmt.Sprintf("set_global dom0_extra_args \"$dom0_extra_args %s\"", args)}
+}
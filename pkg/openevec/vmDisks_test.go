@@ -0,0 +1,25 @@
+package openevec_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	. "github.com/onsi/gomega"
+)
+
+func TestPersistGrubOptions(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	layout := []openevec.DiskConfig{
+		{Bus: "virtio", Persist: true},
+		{Bus: "nvme"},
+		{Persist: true},
+		{Bus: "nvme", Persist: true},
+	}
+	g.Expect(openevec.PersistGrubOptions("amd64", layout)).To(Equal([]string{
+		"set_global dom0_extra_args \"$dom0_extra_args eve_persist_disk=vda,sdb,nvme1n1 eve_persist_type=zfs\""}))
+	g.Expect(openevec.PersistGrubOptions("arm64", layout[2:3])).To(Equal([]string{
+		"set_global dom0_extra_args \"$dom0_extra_args eve_persist_disk=vdb\""}))
+	g.Expect(openevec.PersistGrubOptions("amd64", layout[1:2])).To(BeEmpty())
+}
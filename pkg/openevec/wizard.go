@@ -0,0 +1,196 @@
+package openevec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/models"
+)
+
+// wizard asks questions in out and reads answers from in
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask repeats question until answer passes validate, empty answer selects def
+func (w *wizard) ask(question, def string, validate func(answer string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", question)
+		}
+		line, err := w.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if err != nil && (!errors.Is(err, io.EOF) || answer == "") {
+			return "", fmt.Errorf("no answer for %q: %w", question, err)
+		}
+		if answer == "" {
+			answer = def
+		}
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				fmt.Fprintf(w.out, "\t%s\n", err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// choose asks to select one of options, which is checked with check if it is not nil
+func (w *wizard) choose(question string, options []string, def string, check func(option string) error) (string, error) {
+	var chosen string
+	_, err := w.ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, ", ")), def, func(answer string) error {
+		for _, option := range options {
+			if strings.EqualFold(option, answer) {
+				chosen = option
+				if check != nil {
+					return check(option)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", answer, strings.Join(options, ", "))
+	})
+	return chosen, err
+}
+
+// askInt asks for number in range from min to max, max is not checked if it is 0
+func (w *wizard) askInt(question string, def, min, max int) (int, error) {
+	var value int
+	_, err := w.ask(question, strconv.Itoa(def), func(answer string) error {
+		var err error
+		if value, err = strconv.Atoi(answer); err != nil {
+			return fmt.Errorf("%q is not a number", answer)
+		}
+		if value < min || (max > 0 && value > max) {
+			if max > 0 {
+				return fmt.Errorf("%d is not in range from %d to %d", value, min, max)
+			}
+			return fmt.Errorf("%d is less than %d", value, min)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// confirm asks yes or no question, answer is checked with check if it is not nil
+func (w *wizard) confirm(question string, def bool, check func(yes bool) error) (bool, error) {
+	var yes bool
+	defAnswer := "n"
+	if def {
+		defAnswer = "y"
+	}
+	_, err := w.ask(question+" (y/n)", defAnswer, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			yes = true
+		case "n", "no":
+			yes = false
+		default:
+			return fmt.Errorf("answer y or n")
+		}
+		if check != nil {
+			return check(yes)
+		}
+		return nil
+	})
+	return yes, err
+}
+
+// warn prints result of check of host if it found a problem
+func (w *wizard) warn(check doctorCheck) {
+	if check.level != doctorOK {
+		fmt.Fprintln(w.out, check)
+	}
+}
+
+// checkDevModel rejects devmodels which cannot run on host
+func checkDevModel(devModel string) error {
+	switch devModel {
+	case defaults.DefaultParallelsModel:
+		if runtime.GOOS != "darwin" {
+			return fmt.Errorf("%s is supported only on macOS", devModel)
+		}
+	case defaults.DefaultVBoxModel:
+		if _, err := exec.LookPath("VBoxManage"); err != nil {
+			return fmt.Errorf("%s requires VirtualBox, VBoxManage is not found", devModel)
+		}
+	}
+	return nil
+}
+
+// ConfigWizard asks in out for devmodel, version and arch of EVE, resources of VM,
+// SDN and TPM and sets them in cfg, choices are validated against capabilities of host
+func ConfigWizard(cfg *EdenSetupArgs, in io.Reader, out io.Writer) error {
+	w := &wizard{in: bufio.NewReader(in), out: out}
+	var err error
+
+	if cfg.Eve.DevModel, err = w.choose("Device model", models.DevModelTypes(), cfg.Eve.DevModel, checkDevModel); err != nil {
+		return err
+	}
+	qemu := cfg.Eve.DevModel == defaults.DefaultQemuModel
+	virtual := qemu || cfg.Eve.DevModel == defaults.DefaultVBoxModel || cfg.Eve.DevModel == defaults.DefaultParallelsModel
+	if qemu {
+		w.warn(doctorKVM())
+		w.warn(doctorTool("qemu", qemuCommand(cfg.Eve.Arch), "install QEMU"))
+	}
+
+	if cfg.Eve.Arch, err = w.choose("Architecture of EVE", []string{"amd64", "arm64"}, cfg.Eve.Arch, nil); err != nil {
+		return err
+	}
+	if qemu && cfg.Eve.Arch != runtime.GOARCH {
+		fmt.Fprintf(out, "%s EVE for %s will be emulated on %s host and will be slow\n", statusWarn(), cfg.Eve.Arch, runtime.GOARCH)
+	}
+	if cfg.Eve.Tag, err = w.ask("Version of EVE", cfg.Eve.Tag, nil); err != nil {
+		return err
+	}
+
+	if virtual {
+		if cfg.Eve.QemuCpus, err = w.askInt("CPUs of EVE", cfg.Eve.QemuCpus, 1, runtime.NumCPU()); err != nil {
+			return err
+		}
+		maxMemory := 0
+		if total, err := memInfo("MemTotal"); err == nil {
+			maxMemory = int(total / humanize.MiByte)
+		}
+		if cfg.Eve.QemuMemory, err = w.askInt("Memory of EVE (MB)", cfg.Eve.QemuMemory, 1, maxMemory); err != nil {
+			return err
+		}
+		maxDisk := 0
+		if free, _, err := freeSpace(filepath.Dir(cfg.Eve.ImageFile)); err == nil {
+			maxDisk = int(free / humanize.MiByte)
+		}
+		if cfg.Eve.ImageSizeMB, err = w.askInt("Disk of EVE (MB)", cfg.Eve.ImageSizeMB, 1, maxDisk); err != nil {
+			return err
+		}
+	}
+
+	if qemu {
+		sdn, err := w.confirm("Emulate networks of EVE with SDN", !cfg.Sdn.Disable, nil)
+		if err != nil {
+			return err
+		}
+		cfg.Sdn.Disable = !sdn
+		if cfg.Eve.TPM, err = w.confirm("Emulate TPM with swtpm", cfg.Eve.TPM, func(yes bool) error {
+			if _, err := exec.LookPath("swtpm"); yes && err != nil {
+				return fmt.Errorf("swtpm is not found, install it or answer n")
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
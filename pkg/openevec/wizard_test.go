@@ -0,0 +1,53 @@
+package openevec_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfigWizard(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	cfg := &openevec.EdenSetupArgs{}
+	cfg.Eve.DevModel = defaults.DefaultQemuModel
+	cfg.Eve.Arch = "amd64"
+	cfg.Eve.Tag = defaults.DefaultEVETag
+	cfg.Eve.QemuCpus = 1
+	cfg.Eve.ImageFile = filepath.Join(t.TempDir(), "images", "eve.qcow2")
+	answers := strings.Join([]string{
+		"unknown", // not a devmodel, asked again
+		"",        // default devmodel
+		"ARM64",
+		"0.0.1",
+		"",
+		"many", // not a number, asked again
+		"512",
+		"64",
+		"y",
+		"n",
+	}, "\n") + "\n"
+	var out bytes.Buffer
+	g.Expect(openevec.ConfigWizard(cfg, strings.NewReader(answers), &out)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring(`"unknown" is not one of`))
+	g.Expect(out.String()).To(ContainSubstring(`"many" is not a number`))
+	g.Expect(cfg.Eve.DevModel).To(Equal(defaults.DefaultQemuModel))
+	g.Expect(cfg.Eve.Arch).To(Equal("arm64"))
+	g.Expect(cfg.Eve.Tag).To(Equal("0.0.1"))
+	g.Expect(cfg.Eve.QemuCpus).To(Equal(1))
+	g.Expect(cfg.Eve.QemuMemory).To(Equal(512))
+	g.Expect(cfg.Eve.ImageSizeMB).To(Equal(64))
+	g.Expect(cfg.Sdn.Disable).To(BeFalse())
+	g.Expect(cfg.Eve.TPM).To(BeFalse())
+
+	// input ends before all questions are answered
+	cfg = &openevec.EdenSetupArgs{}
+	cfg.Eve.DevModel = defaults.DefaultGeneralModel
+	g.Expect(openevec.ConfigWizard(cfg, strings.NewReader("\n"), &out)).To(MatchError(ContainSubstring("no answer")))
+}
@@ -0,0 +1,232 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// findSystemAdapter returns ID and config of system adapter of device with
+// logicalLabel or empty ID if not found
+func findSystemAdapter(ctrl controller.Cloud, dev *device.Ctx, logicalLabel string) (string, *config.SystemAdapter, error) {
+	for _, id := range dev.GetSystemAdapters() {
+		adapter, err := ctrl.GetSystemAdapter(id)
+		if err != nil {
+			return "", nil, fmt.Errorf("no system adapter in cloud %s: %w", id, err)
+		}
+		if adapter.Name == logicalLabel {
+			return id, adapter, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// EdgeNodeWwanSet sets APNs, SIM slot and other cellular options of wwan port,
+// system adapter for the port is created if it does not exist
+func (openEVEC *OpenEVEC) EdgeNodeWwanSet(controllerMode string, port models.CellularPort) error {
+	network, err := port.NetworkConfig()
+	if err != nil {
+		return err
+	}
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	if _, err := ctrl.GetNetworkConfig(network.Id); err == nil {
+		if err := ctrl.RemoveNetworkConfig(network.Id); err != nil {
+			return fmt.Errorf("RemoveNetworkConfig: %w", err)
+		}
+	} else {
+		dev.SetNetworkConfig(append(dev.GetNetworks(), network.Id))
+	}
+	if err := ctrl.AddNetworkConfig(network); err != nil {
+		return fmt.Errorf("AddNetworkConfig: %w", err)
+	}
+	id, adapter, err := findSystemAdapter(ctrl, dev, port.LogicalLabel)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		adapterID, err := uuid.NewV4()
+		if err != nil {
+			return err
+		}
+		adapter = &config.SystemAdapter{Name: port.LogicalLabel}
+		if err := ctrl.AddSystemAdapter(adapterID.String(), adapter); err != nil {
+			return fmt.Errorf("AddSystemAdapter: %w", err)
+		}
+		dev.SetSystemAdaptersConfig(append(dev.GetSystemAdapters(), adapterID.String()))
+	}
+	adapter.NetworkUUID = network.Id
+	adapter.Uplink = port.Uplink
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev error: %w", err)
+	}
+	log.Infof("cellular config of %s is set", port.LogicalLabel)
+	return nil
+}
+
+// EdgeNodeWwanRemove removes system adapter of wwan port with logicalLabel
+// and its cellular config
+func (openEVEC *OpenEVEC) EdgeNodeWwanRemove(controllerMode, logicalLabel string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	id, _, err := findSystemAdapter(ctrl, dev, logicalLabel)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("not found system adapter %s", logicalLabel)
+	}
+	var adapters []string
+	for _, el := range dev.GetSystemAdapters() {
+		if el != id {
+			adapters = append(adapters, el)
+		}
+	}
+	dev.SetSystemAdaptersConfig(adapters)
+	networkID := models.CellularNetworkID(logicalLabel)
+	var networks []string
+	for _, el := range dev.GetNetworks() {
+		if el != networkID {
+			networks = append(networks, el)
+		}
+	}
+	dev.SetNetworkConfig(networks)
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev error: %w", err)
+	}
+	log.Infof("cellular port %s removed", logicalLabel)
+	return nil
+}
+
+// wwanPortState is cellular config of port and its status reported by EVE
+type wwanPortState struct {
+	LogicalLabel string          `json:"logicallabel"`
+	Config       json.RawMessage `json:"config"`
+	Status       json.RawMessage `json:"status,omitempty"`
+	config       *config.CellularConfig
+	status       *info.ZCellularStatus
+}
+
+// EdgeNodeWwanGet prints cellular config of wwan ports and their status
+// from the last info of device
+func (openEVEC *OpenEVEC) EdgeNodeWwanGet(controllerMode string, outputFormat types.OutputFormat) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	var lastDInfo *info.ZInfoDevice
+	if err = ctrl.InfoLastCallback(dev.GetID(), map[string]string{"devId": dev.GetID().String()}, func(im *info.ZInfoMsg) bool {
+		if im.GetZtype() == info.ZInfoTypes_ZiDevice {
+			lastDInfo = im.GetDinfo()
+		}
+		return false
+	}); err != nil {
+		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	var ports []*wwanPortState
+	for _, id := range dev.GetSystemAdapters() {
+		adapter, err := ctrl.GetSystemAdapter(id)
+		if err != nil {
+			return fmt.Errorf("no system adapter in cloud %s: %w", id, err)
+		}
+		network, err := ctrl.GetNetworkConfig(adapter.NetworkUUID)
+		if err != nil || network.GetWireless().GetType() != config.WirelessType_Cellular {
+			continue
+		}
+		state := &wwanPortState{LogicalLabel: adapter.Name, config: &config.CellularConfig{}}
+		if cellularCfg := network.GetWireless().GetCellularCfg(); len(cellularCfg) > 0 {
+			state.config = cellularCfg[0]
+		}
+		if status := lastDInfo.GetSystemAdapter().GetStatus(); len(status) > int(lastDInfo.GetSystemAdapter().GetCurrentIndex()) {
+			for _, p := range status[lastDInfo.GetSystemAdapter().GetCurrentIndex()].GetPorts() {
+				if p.GetName() == adapter.Name {
+					state.status = p.GetWirelessStatus().GetCellular()
+				}
+			}
+		}
+		ports = append(ports, state)
+	}
+	switch outputFormat {
+	case types.OutputFormatJSON:
+		for _, port := range ports {
+			if port.Config, err = protojson.Marshal(port.config); err != nil {
+				return err
+			}
+			if port.status != nil {
+				if port.Status, err = protojson.Marshal(port.status); err != nil {
+					return err
+				}
+			}
+		}
+		result, err := json.MarshalIndent(ports, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(result))
+		return nil
+	case types.OutputFormatLines:
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		if _, err = fmt.Fprintln(w, "PORT\tSIM_SLOT\tAPNS\tMODULE\tPROVIDERS\tERROR"); err != nil {
+			return err
+		}
+		for _, port := range ports {
+			var apns []string
+			for _, ap := range port.config.GetAccessPoints() {
+				apns = append(apns, fmt.Sprintf("%d:%s", ap.GetSimSlot(), ap.GetApn()))
+			}
+			module, providers, statusErr := "-", "-", "-"
+			if port.status != nil {
+				module = port.status.GetCellularModule()
+				var names []string
+				for _, provider := range port.status.GetProviders() {
+					if provider.GetCurrentServing() {
+						names = append(names, provider.GetDescription()+"*")
+					} else {
+						names = append(names, provider.GetDescription())
+					}
+				}
+				providers = strings.Join(names, ",")
+				for _, e := range []string{port.status.GetConfigError(), port.status.GetProbeError()} {
+					if e != "" {
+						statusErr = e
+					}
+				}
+			}
+			if _, err = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", port.LogicalLabel,
+				port.config.GetActivatedSimSlot(), strings.Join(apns, ","), module, providers, statusErr); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unimplemented output format")
+}
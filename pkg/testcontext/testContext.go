@@ -1,6 +1,7 @@
 package testcontext
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -223,7 +224,7 @@ func (tc *TestContext) NewEdgeNode(opts ...EdgeNodeOption) *device.Ctx {
 // ConfigSync send config to controller
 func (tc *TestContext) ConfigSync(edgeNode *device.Ctx) {
 	if edgeNode.GetState() == device.NotOnboarded {
-		if err := tc.GetController().OnBoardDev(edgeNode); err != nil {
+		if err := tc.GetController().OnBoardDev(context.Background(), edgeNode); err != nil {
 			log.Fatalf("OnBoardDev %s", err)
 		}
 	} else {
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Measurement is a value measured by escript in benchmark mode
+type Measurement struct {
+	Script         string  `json:"script"`
+	Name           string  `json:"name"`
+	Value          float64 `json:"value"`
+	Unit           string  `json:"unit,omitempty"`
+	HigherIsBetter bool    `json:"higher_is_better,omitempty"`
+}
+
+// Key identifies measurement in baseline
+func (m Measurement) Key() string {
+	return m.Script + "/" + m.Name
+}
+
+var measurementsMu sync.Mutex
+
+// AppendMeasurement appends measurement as JSON line into file
+func AppendMeasurement(file string, m Measurement) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	measurementsMu.Lock()
+	defer measurementsMu.Unlock()
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadMeasurements reads measurements appended into file with AppendMeasurement,
+// values measured several times (e.g. with -test.count) are averaged
+func ReadMeasurements(file string) ([]Measurement, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var measurements []Measurement
+	counts := make(map[string]int)
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var m Measurement
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return nil, fmt.Errorf("cannot parse measurement: %w", err)
+		}
+		i, ok := index[m.Key()]
+		if !ok {
+			index[m.Key()] = len(measurements)
+			counts[m.Key()] = 1
+			measurements = append(measurements, m)
+			continue
+		}
+		// running average
+		counts[m.Key()]++
+		measurements[i].Value += (m.Value - measurements[i].Value) / float64(counts[m.Key()])
+	}
+	return measurements, scanner.Err()
+}
+
+// ReadBaseline reads baseline file written by WriteBaseline
+func ReadBaseline(file string) ([]Measurement, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var baseline []Measurement
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("cannot parse baseline %s: %w", file, err)
+	}
+	return baseline, nil
+}
+
+// WriteBaseline writes measurements sorted by keys into baseline file
+func WriteBaseline(file string, measurements []Measurement) error {
+	sorted := append([]Measurement{}, measurements...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key() < sorted[j].Key()
+	})
+	data, err := json.MarshalIndent(sorted, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// MergeBaseline returns baseline with measurements replaced by results
+func MergeBaseline(baseline, results []Measurement) []Measurement {
+	merged := make(map[string]Measurement)
+	for _, m := range baseline {
+		merged[m.Key()] = m
+	}
+	for _, m := range results {
+		merged[m.Key()] = m
+	}
+	result := make([]Measurement, 0, len(merged))
+	for _, m := range merged {
+		result = append(result, m)
+	}
+	return result
+}
+
+// Comparison is result of comparison of measurement with baseline
+type Comparison struct {
+	Measurement
+	// Baseline is the value from baseline, nil for new measurements
+	Baseline *float64
+	// Change is relative change of value against baseline
+	Change float64
+	// Regression is true if value changed for the worse more than threshold
+	Regression bool
+}
+
+// CompareMeasurements compares results with baseline, threshold is maximal
+// allowed relative change for the worse (e.g. 0.1 for 10%)
+func CompareMeasurements(baseline, results []Measurement, threshold float64) []Comparison {
+	base := make(map[string]Measurement)
+	for _, m := range baseline {
+		base[m.Key()] = m
+	}
+	comparisons := make([]Comparison, 0, len(results))
+	for _, m := range results {
+		c := Comparison{Measurement: m}
+		if b, ok := base[m.Key()]; ok {
+			value := b.Value
+			c.Baseline = &value
+			if b.Value != 0 {
+				c.Change = (m.Value - b.Value) / b.Value
+			}
+			if m.HigherIsBetter {
+				c.Regression = -c.Change > threshold
+			} else {
+				c.Regression = c.Change > threshold
+			}
+		}
+		comparisons = append(comparisons, c)
+	}
+	sort.Slice(comparisons, func(i, j int) bool {
+		return comparisons[i].Key() < comparisons[j].Key()
+	})
+	return comparisons
+}
@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of discovered tests
+const (
+	KindScenario = "scenario" // file with list of tests to run by 'eden test -s'
+	KindScript   = "script"   // escript to run by 'eden test -e'
+)
+
+// scenarioSuffix is suffix of scenario files inside suites
+const scenarioSuffix = ".tests.txt"
+
+// TestEntry describes discovered scenario or escript with its metadata.
+// Metadata is defined by lines in form '# @<key>: <value>' inside the file
+// (before the first embedded file of escript), supported keys are
+// tags, owner, duration, requires, description and stateless.
+type TestEntry struct {
+	Suite       string   `json:"suite"`
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Path        string   `json:"path"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+	Duration    string   `json:"duration,omitempty"`
+	Requires    []string `json:"requires,omitempty"`
+	// Stateless scenario lets EVE be restored from snapshot between its tests
+	Stateless bool `json:"stateless,omitempty"`
+}
+
+var (
+	metadataRe  = regexp.MustCompile(`^#\s*@(\w+):\s*(.*)$`)
+	conditionRe = regexp.MustCompile(`^\[!([^\]]+)\]\s+(skip|stop)\b`)
+)
+
+func splitList(value string) []string {
+	var result []string
+	for _, el := range strings.Split(value, ",") {
+		if el = strings.TrimSpace(el); el != "" {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, el := range list {
+			if el == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// ParseTestMetadata fills metadata of entry from content of scenario or escript,
+// conditions checked by escript before skipping or stopping are added to requires
+func ParseTestMetadata(entry *TestEntry, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --") {
+			// embedded files of escript begin
+			break
+		}
+		if match := conditionRe.FindStringSubmatch(line); match != nil {
+			entry.Requires = appendUnique(entry.Requires, match[1])
+			continue
+		}
+		match := metadataRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		value := strings.TrimSpace(match[2])
+		switch strings.ToLower(match[1]) {
+		case "tags":
+			entry.Tags = appendUnique(entry.Tags, splitList(value)...)
+		case "owner":
+			entry.Owner = value
+		case "duration":
+			entry.Duration = value
+		case "requires":
+			entry.Requires = appendUnique(entry.Requires, splitList(value)...)
+		case "description":
+			entry.Description = value
+		case "stateless":
+			entry.Stateless = value == "true"
+		}
+	}
+}
+
+// newTestEntry reads metadata of test from path, suite is named by its directory
+func newTestEntry(suiteDir, kind, name, path string) (*TestEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	suiteDir, err = filepath.Abs(suiteDir)
+	if err != nil {
+		return nil, err
+	}
+	suite := filepath.Base(suiteDir)
+	entry := &TestEntry{Suite: suite, Kind: kind, Name: name, Path: path}
+	ParseTestMetadata(entry, data)
+	return entry, nil
+}
+
+// DiscoverTests looks for scenarios and escripts of test suites inside root
+// and returns the ones with name, suite or tag matching filter
+func DiscoverTests(root string, filter *regexp.Regexp) ([]*TestEntry, error) {
+	var entries []*TestEntry
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && (info.Name() == "go-internal" || strings.HasPrefix(info.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		dir := filepath.Dir(path)
+		var entry *TestEntry
+		switch {
+		case strings.HasSuffix(info.Name(), scenarioSuffix):
+			entry, err = newTestEntry(dir, KindScenario, info.Name(), path)
+		case filepath.Base(dir) == "testdata" && filepath.Ext(info.Name()) == ".txt":
+			entry, err = newTestEntry(filepath.Dir(dir), KindScript,
+				strings.TrimSuffix(info.Name(), ".txt"), path)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if filter == nil || entryMatches(entry, filter) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Suite != entries[j].Suite {
+			return entries[i].Suite < entries[j].Suite
+		}
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+func entryMatches(entry *TestEntry, filter *regexp.Regexp) bool {
+	if filter.MatchString(entry.Name) || filter.MatchString(entry.Suite) {
+		return true
+	}
+	for _, tag := range entry.Tags {
+		if filter.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FuzzyMatch reports whether s matches pattern ignoring case: pattern with wildcards (*, ?, [...])
+// matches as glob anywhere in s, other pattern matches if its characters appear in s in the same order
+func FuzzyMatch(pattern, s string) bool {
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, err := filepath.Match("*"+strings.Trim(pattern, "*")+"*", s)
+		return err == nil && matched
+	}
+	for _, r := range pattern {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
+
+// MatchTests returns entries with name or tag matching pattern with FuzzyMatch,
+// entries with name equal to pattern are returned alone if there are any
+func MatchTests(entries []*TestEntry, pattern string) []*TestEntry {
+	var exact, matched []*TestEntry
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name, pattern) {
+			exact = append(exact, entry)
+			continue
+		}
+		match := FuzzyMatch(pattern, entry.Name)
+		for _, tag := range entry.Tags {
+			match = match || FuzzyMatch(pattern, tag)
+		}
+		if match {
+			matched = append(matched, entry)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return matched
+}
@@ -1,8 +1,10 @@
 package tests
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -64,10 +66,17 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 		}()
 
 		resultArgs := append(args, strings.Fields(testArgs)...)
+		resultArgs = append(resultArgs, profileArgs(testApp)...)
 		log.Debugf("Test: %s %s", path, strings.Join(resultArgs, " "))
 		tst := exec.Command(path, resultArgs...)
 		tst.Stdout = os.Stdout
 		tst.Stderr = os.Stderr
+		var output bytes.Buffer
+		if report != nil {
+			// the same writer for both outputs to keep order of lines
+			tst.Stdout = io.MultiWriter(os.Stdout, &output)
+			tst.Stderr = tst.Stdout
+		}
 		tst.Env = append(os.Environ(), fmt.Sprintf("%s=%s",
 			defaults.DefaultConfigEnv, viper.Get("eve.name")))
 
@@ -76,7 +85,8 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 			targs = fmt.Sprintf("%s -test.timeout=%s",
 				targs, testTimeout)
 		}
-		if verbosity != "info" {
+		if verbosity != "info" || report != nil {
+			// report is built from verbose output of tests
 			targs = fmt.Sprintf("%s -test.v", targs)
 		}
 
@@ -87,9 +97,23 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 					defaults.DefaultTestArgsEnv, targs))
 		}
 
+		start := time.Now()
 		err = tst.Run()
 		close(done)
 
+		if report != nil {
+			report.AddRun(fmt.Sprintf("%s %s", testApp, strings.Join(resultArgs, " ")), start, err, output.Bytes())
+			if err := report.Write(); err != nil {
+				log.Errorf("cannot write report: %s", err)
+			} else {
+				log.Infof("Report saved into %s", report.File)
+			}
+		}
+
+		if err != nil && artifactsUpload != nil {
+			uploadArtifacts(testName(testApp, resultArgs), start)
+		}
+
 		if err != nil && failScenario != "" {
 			log.Debug("failScenario: ", failScenario)
 			RunScenario("", "", testTimeout, "",
@@ -174,6 +198,11 @@ func RunScenario(testScenario string, testArgs string, testTimeout string, failS
 				log.Info(targs[i])
 			}
 		}
+		if targs[0] != "" {
+			if err := ResetBeforeTest(); err != nil {
+				log.Fatal(err)
+			}
+		}
 		RunTest(targs[0], targs[1:], testArgs, testTimeout,
 			failScenario, configFile, verbosity)
 	}
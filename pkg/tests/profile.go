@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// profileDir is directory to save profiles of test binaries into,
+// it is enabled with EnableProfile and used by RunTest
+var profileDir string
+
+// profileRuns numbers runs of test binaries to keep their profiles apart
+var profileRuns atomic.Int32
+
+// EnableProfile enables saving of CPU and heap profiles of test binaries run by RunTest into dir
+func EnableProfile(dir string) {
+	profileDir = dir
+}
+
+// profileArgs returns arguments of test binary to save its profiles into profileDir
+func profileArgs(testApp string) []string {
+	if profileDir == "" {
+		return nil
+	}
+	name := filepath.Join(profileDir, fmt.Sprintf("%s-%d", filepath.Base(testApp), profileRuns.Add(1)))
+	return []string{"-test.cpuprofile=" + name + "-cpu.pprof", "-test.memprofile=" + name + "-heap.pprof"}
+}
@@ -0,0 +1,369 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Statuses of tests inside report
+const (
+	StatusPass = "PASS"
+	StatusFail = "FAIL"
+	StatusSkip = "SKIP"
+)
+
+// PhaseResult is phase of escript (started with comment line) with its duration
+type PhaseResult struct {
+	Heading  string
+	Duration time.Duration
+}
+
+// ScriptResult is result of one test (or escript) inside test binary
+type ScriptResult struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+	Phases   []PhaseResult
+	Log      string
+	// Resources is summary of resources used by escript if they were sampled
+	Resources string
+}
+
+// RunResult is result of one run of test binary
+type RunResult struct {
+	Command  string
+	Start    time.Time
+	Duration time.Duration
+	Status   string
+	Error    string
+	Scripts  []*ScriptResult
+	Output   string
+}
+
+// Report collects results of test binaries run and renders them into self-contained HTML file
+type Report struct {
+	File      string
+	Title     string
+	Start     time.Time
+	Runs      []*RunResult
+	Artifacts []string
+
+	mu sync.Mutex
+}
+
+// report is enabled with EnableReport and filled by RunTest
+var report *Report
+
+// EnableReport enables saving of results of tests run by RunTest and RunScenario into HTML file
+func EnableReport(file, title string) *Report {
+	report = &Report{File: file, Title: title, Start: time.Now()}
+	return report
+}
+
+// AddArtifact adds link to collected artifacts into report
+func (r *Report) AddArtifact(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Artifacts = append(r.Artifacts, path)
+}
+
+// AddRun adds result of test binary run with its output into report
+func (r *Report) AddRun(command string, start time.Time, err error, output []byte) {
+	run := &RunResult{
+		Command:  command,
+		Start:    start,
+		Duration: time.Since(start),
+		Status:   StatusPass,
+		Scripts:  ParseTestOutput(output),
+		Output:   string(output),
+	}
+	if err != nil {
+		run.Status = StatusFail
+		run.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Runs = append(r.Runs, run)
+}
+
+var (
+	testHeaderRe = regexp.MustCompile(`^=== (?:RUN|CONT|NAME|PAUSE)\s+(\S+)`)
+	testResultRe = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)`)
+	phaseRe      = regexp.MustCompile(`^\s*(#.*) \(([\d.]+)s\)$`)
+	resourcesRe  = regexp.MustCompile(`^\s*resources: (.+)$`)
+)
+
+func parseSeconds(s string) time.Duration {
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// ParseTestOutput extracts results of tests and phases of escripts from
+// verbose output of test binary, tests with subtests are not included
+func ParseTestOutput(output []byte) []*ScriptResult {
+	results := make(map[string]*ScriptResult)
+	var order []string
+	get := func(name string) *ScriptResult {
+		if result, ok := results[name]; ok {
+			return result
+		}
+		result := &ScriptResult{Name: name}
+		results[name] = result
+		order = append(order, name)
+		return result
+	}
+	var current *ScriptResult
+	var logs = make(map[string]*strings.Builder)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := testHeaderRe.FindStringSubmatch(line); match != nil {
+			current = get(match[1])
+			continue
+		}
+		if match := testResultRe.FindStringSubmatch(line); match != nil {
+			result := get(match[2])
+			result.Status = match[1]
+			result.Duration = parseSeconds(match[3])
+			current = nil
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if match := phaseRe.FindStringSubmatch(line); match != nil {
+			current.Phases = append(current.Phases, PhaseResult{
+				Heading:  strings.TrimSpace(match[1]),
+				Duration: parseSeconds(match[2]),
+			})
+		}
+		if match := resourcesRe.FindStringSubmatch(line); match != nil {
+			current.Resources = match[1]
+		}
+		if _, ok := logs[current.Name]; !ok {
+			logs[current.Name] = &strings.Builder{}
+		}
+		logs[current.Name].WriteString(line)
+		logs[current.Name].WriteString("\n")
+	}
+	var scripts []*ScriptResult
+	for _, name := range order {
+		result := results[name]
+		if result.Status == "" {
+			continue
+		}
+		// skip parents of subtests as their results are aggregated ones
+		parent := false
+		for _, other := range order {
+			if strings.HasPrefix(other, name+"/") {
+				parent = true
+				break
+			}
+		}
+		if parent {
+			continue
+		}
+		if l, ok := logs[name]; ok {
+			result.Log = l.String()
+		}
+		scripts = append(scripts, result)
+	}
+	return scripts
+}
+
+const reportTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; }
+.PASS { color: #1a7f37; font-weight: bold; }
+.FAIL { color: #cf222e; font-weight: bold; }
+.SKIP { color: #9a6700; font-weight: bold; }
+.bar { background: #54aeff; height: 10px; display: inline-block; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Started {{.Start.Format "2006-01-02 15:04:05 MST"}}, {{len .Runs}} test runs,
+<span class="PASS">{{.Count "PASS"}} passed</span>,
+<span class="FAIL">{{.Count "FAIL"}} failed</span>,
+<span class="SKIP">{{.Count "SKIP"}} skipped</span></p>
+{{- if .Artifacts}}
+<h2>Artifacts</h2>
+<ul>
+{{- range .Artifacts}}
+<li><a href="{{fileURL .}}">{{.}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- range $i, $run := .Runs}}
+<h2>Run {{inc $i}}: <span class="{{$run.Status}}">{{$run.Status}}</span></h2>
+<p><code>{{$run.Command}}</code><br>
+Started {{$run.Start.Format "15:04:05"}}, duration {{duration $run.Duration}}{{if $run.Error}}, error: {{$run.Error}}{{end}}</p>
+{{- if $run.Scripts}}
+<table>
+<tr><th>Test</th><th>Status</th><th>Duration</th><th>Phases</th><th>Resources</th></tr>
+{{- range $run.Scripts}}
+<tr>
+<td>{{.Name}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{duration .Duration}}</td>
+<td>
+{{- $total := .Duration}}
+{{- range .Phases}}
+<div><span class="bar" style="width: {{percent .Duration $total}}px"></span> {{.Heading}} ({{duration .Duration}})</div>
+{{- end}}
+{{- if .Log}}
+<details><summary>log</summary><pre>{{.Log}}</pre></details>
+{{- end}}
+</td>
+<td>{{.Resources}}</td>
+</tr>
+{{- end}}
+</table>
+{{- end}}
+<details><summary>output</summary><pre>{{$run.Output}}</pre></details>
+{{- end}}
+</body>
+</html>
+`
+
+// Count returns number of tests with status in all runs
+func (r *Report) Count(status string) int {
+	count := 0
+	for _, run := range r.Runs {
+		for _, script := range run.Scripts {
+			if script.Status == status {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+var reportFuncs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+	// percent returns width of phase bar as percent of total duration scaled to 200px
+	"percent": func(d, total time.Duration) int {
+		if total <= 0 {
+			return 0
+		}
+		return int(200 * d / total)
+	},
+	"fileURL": func(path string) template.URL {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		return template.URL("file://" + filepath.ToSlash(path))
+	},
+}
+
+// Render writes HTML report into buf
+func (r *Report) Render(buf *bytes.Buffer) error {
+	tmpl, err := template.New("report").Funcs(reportFuncs).Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return tmpl.Execute(buf, r)
+}
+
+// Write renders report into its file, it is called after every run,
+// so the report is available even if tests exit with failure
+func (r *Report) Write() error {
+	var buf bytes.Buffer
+	if err := r.Render(&buf); err != nil {
+		return fmt.Errorf("cannot render report: %w", err)
+	}
+	if dir := filepath.Dir(r.File); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(r.File, buf.Bytes()); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	results, err := json.MarshalIndent(r, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("cannot marshal results: %w", err)
+	}
+	return writeFileAtomic(r.ResultsFile(), results)
+}
+
+// writeFileAtomic replaces file with data, so readers never see partially written file
+func writeFileAtomic(file string, data []byte) error {
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
+
+// ResultsFile returns file next to HTML file of report with its results in JSON,
+// which can be included into bundle of eden report
+func (r *Report) ResultsFile() string {
+	ext := filepath.Ext(r.File)
+	if ext == ".json" {
+		return r.File + ".json"
+	}
+	return strings.TrimSuffix(r.File, ext) + ".json"
+}
+
+// ReadResults reads runs of tests from file with results of report in JSON
+// or from file with verbose output of test binary
+func ReadResults(file string) ([]*RunResult, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if json.Valid(data) {
+		var r Report
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("cannot parse results %s: %w", file, err)
+		}
+		return r.Runs, nil
+	}
+	run := &RunResult{
+		Command: filepath.Base(file),
+		Status:  StatusPass,
+		Scripts: ParseTestOutput(data),
+		Output:  string(data),
+	}
+	if info, err := os.Stat(file); err == nil {
+		run.Start = info.ModTime()
+	}
+	for _, script := range run.Scripts {
+		run.Duration += script.Duration
+		if script.Status == StatusFail {
+			run.Status = StatusFail
+		}
+	}
+	// output is written while tests run, so they started before modification
+	run.Start = run.Start.Add(-run.Duration)
+	return []*RunResult{run}, nil
+}
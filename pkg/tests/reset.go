@@ -0,0 +1,24 @@
+package tests
+
+import (
+	"fmt"
+)
+
+// resetFunc is enabled with EnableReset and called by RunScenario before every test
+var resetFunc func() error
+
+// EnableReset enables reset of EVE (e.g. restore from snapshot) before every test run by RunScenario
+func EnableReset(reset func() error) {
+	resetFunc = reset
+}
+
+// ResetBeforeTest resets EVE if reset is enabled
+func ResetBeforeTest() error {
+	if resetFunc == nil {
+		return nil
+	}
+	if err := resetFunc(); err != nil {
+		return fmt.Errorf("cannot reset EVE before test: %w", err)
+	}
+	return nil
+}
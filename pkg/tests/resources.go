@@ -0,0 +1,331 @@
+package tests
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// clockTicks is USER_HZ used for CPU times in /proc
+const clockTicks = 100
+
+// sectorSize is size of sector in /proc/diskstats
+const sectorSize = 512
+
+// ResourceUsage is usage of resources of the host and EVE VM during run of script.
+// The host ones include usage by other scripts run in parallel.
+type ResourceUsage struct {
+	// Host is set if resources of the host were sampled
+	Host bool
+	// HostCPU is average utilization of CPUs of the host in percent
+	HostCPU       float64
+	HostMemAvg    uint64
+	HostMemPeak   uint64
+	HostDiskRead  uint64
+	HostDiskWrite uint64
+
+	// VM is set if process of EVE VM was sampled
+	VM        bool
+	VMCPU     time.Duration
+	VMMemPeak uint64
+	// QMP is set if counters of EVE VM were collected from QEMU
+	QMP         bool
+	VMDiskRead  uint64
+	VMDiskWrite uint64
+	VMExits     uint64
+}
+
+func megabytes(bytes uint64) string {
+	return fmt.Sprintf("%dMB", bytes/1024/1024)
+}
+
+// String returns usage as space-separated key=value pairs
+func (u ResourceUsage) String() string {
+	var fields []string
+	if u.Host {
+		fields = append(fields,
+			fmt.Sprintf("host_cpu=%.1f%%", u.HostCPU),
+			"host_mem_avg="+megabytes(u.HostMemAvg),
+			"host_mem_peak="+megabytes(u.HostMemPeak),
+			"host_disk_read="+megabytes(u.HostDiskRead),
+			"host_disk_write="+megabytes(u.HostDiskWrite))
+	}
+	if u.VM {
+		fields = append(fields,
+			fmt.Sprintf("eve_cpu=%.1fs", u.VMCPU.Seconds()),
+			"eve_mem_peak="+megabytes(u.VMMemPeak))
+	}
+	if u.QMP {
+		fields = append(fields,
+			"eve_disk_read="+megabytes(u.VMDiskRead),
+			"eve_disk_write="+megabytes(u.VMDiskWrite))
+		if u.VMExits > 0 {
+			fields = append(fields, fmt.Sprintf("eve_exits=%d", u.VMExits))
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// hostCPU returns total and idle CPU time of the host from /proc/stat
+func hostCPU() (total, idle uint64, err error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("unexpected /proc/stat format")
+	}
+	for i, field := range fields[1:] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		total += value
+		// idle and iowait
+		if i == 3 || i == 4 {
+			idle += value
+		}
+	}
+	return total, idle, nil
+}
+
+// hostMemUsed returns memory of the host not available for new processes
+func hostMemUsed() (uint64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err == nil {
+			values[strings.TrimSuffix(fields[0], ":")] = value * 1024
+		}
+	}
+	total, ok := values["MemTotal"]
+	if !ok {
+		return 0, fmt.Errorf("no MemTotal in /proc/meminfo")
+	}
+	return total - values["MemAvailable"], scanner.Err()
+}
+
+// hostDisk returns bytes read and written by disks of the host from /proc/diskstats,
+// partitions are skipped as their I/O is counted for their disks
+func hostDisk() (read, written uint64, err error) {
+	f, err := os.Open("/proc/diskstats")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join("/sys/block", fields[2])); err != nil {
+			continue
+		}
+		sectorsRead, _ := strconv.ParseUint(fields[5], 10, 64)
+		sectorsWritten, _ := strconv.ParseUint(fields[9], 10, 64)
+		read += sectorsRead * sectorSize
+		written += sectorsWritten * sectorSize
+	}
+	return read, written, scanner.Err()
+}
+
+// processCPU returns CPU time of process with pid from /proc
+func processCPU(pid int) (time.Duration, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// skip pid and command, which may contain spaces
+	end := strings.LastIndexByte(string(data), ')')
+	if end < 0 {
+		return 0, fmt.Errorf("unexpected stat format of process %d", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected stat format of process %d", pid)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(utime+stime) * time.Second / clockTicks, nil
+}
+
+// processRSS returns resident memory of process with pid from /proc
+func processRSS(pid int) (uint64, error) {
+	f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			return value * 1024, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no VmRSS of process %d", pid)
+}
+
+// qmpMu serializes connections of samplers of scripts to QMP socket,
+// as QEMU serves one client of socket at a time
+var qmpMu sync.Mutex
+
+// qemuStats collects counters of EVE VM from qmpSocket
+func qemuStats(qmpSocket string) (*utils.QemuStats, error) {
+	qmpMu.Lock()
+	defer qmpMu.Unlock()
+	return utils.QemuStatsQMP(qmpSocket)
+}
+
+// readPid returns pid of process from pidFile
+func readPid(pidFile string) (int, error) {
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// ResourceSampler samples resources of the host and EVE VM from start until Stop
+type ResourceSampler struct {
+	vmPid     int
+	qmpSocket string
+
+	cpuTotal, cpuIdle uint64
+	diskRead          uint64
+	diskWrite         uint64
+	vmCPU             time.Duration
+	qmpStats          *utils.QemuStats
+
+	mu       sync.Mutex
+	usage    ResourceUsage
+	memSum   uint64
+	memCount uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// sample updates memory usage of the host and EVE VM
+func (s *ResourceSampler) sample() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if used, err := hostMemUsed(); err == nil {
+		s.memSum += used
+		s.memCount++
+		s.usage.HostMemPeak = max(s.usage.HostMemPeak, used)
+	}
+	if s.usage.VM {
+		if rss, err := processRSS(s.vmPid); err == nil {
+			s.usage.VMMemPeak = max(s.usage.VMMemPeak, rss)
+		}
+	}
+}
+
+// StartResourceSampler starts sampling of resources of the host every interval. Resources of
+// EVE VM are sampled if pidFile of its QEMU process is not empty and counters of QEMU are
+// collected with QMP if qmpSocket is not empty. Unavailable resources are skipped.
+func StartResourceSampler(interval time.Duration, pidFile, qmpSocket string) *ResourceSampler {
+	s := &ResourceSampler{
+		qmpSocket: qmpSocket,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	var err error
+	if s.cpuTotal, s.cpuIdle, err = hostCPU(); err == nil {
+		s.usage.Host = true
+		s.diskRead, s.diskWrite, _ = hostDisk()
+	}
+	if pidFile != "" {
+		if s.vmPid, err = readPid(pidFile); err == nil {
+			if s.vmCPU, err = processCPU(s.vmPid); err == nil {
+				s.usage.VM = true
+			}
+		}
+	}
+	if qmpSocket != "" {
+		s.qmpStats, _ = qemuStats(qmpSocket)
+	}
+	s.sample()
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.sample()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Stop stops sampling and returns usage of resources since start
+func (s *ResourceSampler) Stop() ResourceUsage {
+	close(s.stop)
+	<-s.done
+	s.sample()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage := s.usage
+	if s.memCount > 0 {
+		usage.HostMemAvg = s.memSum / s.memCount
+	}
+	if usage.Host {
+		if total, idle, err := hostCPU(); err == nil && total > s.cpuTotal {
+			usage.HostCPU = 100 * (1 - float64(idle-s.cpuIdle)/float64(total-s.cpuTotal))
+		}
+		if read, written, err := hostDisk(); err == nil {
+			usage.HostDiskRead = read - min(read, s.diskRead)
+			usage.HostDiskWrite = written - min(written, s.diskWrite)
+		}
+	}
+	if usage.VM {
+		// process is gone if EVE VM was restarted during script
+		if vmCPU, err := processCPU(s.vmPid); err == nil && vmCPU >= s.vmCPU {
+			usage.VMCPU = vmCPU - s.vmCPU
+		}
+	}
+	if s.qmpStats != nil {
+		if stats, err := qemuStats(s.qmpSocket); err == nil {
+			usage.QMP = true
+			usage.VMDiskRead = stats.DiskReadBytes - min(stats.DiskReadBytes, s.qmpStats.DiskReadBytes)
+			usage.VMDiskWrite = stats.DiskWriteBytes - min(stats.DiskWriteBytes, s.qmpStats.DiskWriteBytes)
+			usage.VMExits = stats.Exits - min(stats.Exits, s.qmpStats.Exits)
+		}
+	}
+	return usage
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/artifacts"
+	log "github.com/sirupsen/logrus"
+)
+
+// upload describes artifacts to upload into object storage when tests fail
+type upload struct {
+	uploader artifacts.Uploader
+	prefix   string
+	paths    map[string]string
+	snapshot func(file string) error
+}
+
+// artifactsUpload is enabled with EnableArtifactsUpload and used by RunTest
+var artifactsUpload *upload
+
+// EnableArtifactsUpload enables upload of paths (files or directories) into objects
+// with names beginning with prefix followed by name of failed test and keys of paths
+// when test binary run by RunTest fails. Only files modified during the failed run
+// are uploaded, report is uploaded too if enabled
+func EnableArtifactsUpload(uploader artifacts.Uploader, prefix string, paths map[string]string) {
+	artifactsUpload = &upload{uploader: uploader, prefix: prefix, paths: paths}
+}
+
+// EnableSnapshotUpload enables upload of snapshot of EVE disk together with
+// artifacts of failed tests, export saves snapshot into qcow2 file
+func EnableSnapshotUpload(export func(file string) error) {
+	if artifactsUpload != nil {
+		artifactsUpload.snapshot = export
+	}
+}
+
+var testNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._/-]+`)
+
+// testName returns name of test run by RunTest with args to group its artifacts,
+// it is built from name of test binary and pattern of -test.run
+func testName(testApp string, args []string) string {
+	name := filepath.Base(testApp)
+	for i, arg := range args {
+		run := ""
+		if strings.HasPrefix(arg, "-test.run=") {
+			run = strings.TrimPrefix(arg, "-test.run=")
+		} else if arg == "-test.run" && i+1 < len(args) {
+			run = args[i+1]
+		}
+		// keep only literal parts of pattern, e.g. TestEdenScripts/^name$ -> TestEdenScripts/name
+		if run = strings.Trim(path.Clean("/"+testNameUnsafe.ReplaceAllString(run, "")), "/"); run != "" {
+			name = path.Join(name, run)
+		}
+	}
+	return name
+}
+
+// uploadArtifacts uploads artifacts of failed test with name modified since start
+// and prints their URLs
+func uploadArtifacts(name string, start time.Time) {
+	prefix := path.Join(artifactsUpload.prefix, name)
+	paths := make(map[string]string)
+	for key, p := range artifactsUpload.paths {
+		paths[key] = p
+	}
+	if report != nil {
+		paths[filepath.Base(report.File)] = report.File
+	}
+	if artifactsUpload.snapshot != nil {
+		dir, err := os.MkdirTemp("", "eden-snapshot-")
+		if err != nil {
+			log.Errorf("cannot create directory for snapshot: %s", err)
+		} else {
+			defer os.RemoveAll(dir)
+			file := filepath.Join(dir, "snapshot.qcow2")
+			if err := artifactsUpload.snapshot(file); err != nil {
+				log.Errorf("cannot export snapshot of EVE: %s", err)
+			} else {
+				paths[filepath.Base(file)] = file
+			}
+		}
+	}
+	keys := make([]string, 0, len(paths))
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var uploaded []artifacts.Uploaded
+	for _, key := range keys {
+		p := paths[key]
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		if len(uploaded) == 0 {
+			log.Infof("Uploading artifacts of failed test %s", name)
+		}
+		u, err := artifacts.UploadPathSince(context.Background(), artifactsUpload.uploader, p, path.Join(prefix, key), start)
+		if err != nil {
+			log.Errorf("cannot upload artifacts: %s", err)
+		}
+		if u.Files > 0 {
+			uploaded = append(uploaded, u)
+		}
+	}
+	if len(uploaded) == 0 {
+		return
+	}
+	fmt.Printf("Artifacts of failed test %s:\n", name)
+	for _, u := range uploaded {
+		fmt.Printf("\t%s (%d files): %s\n", u.Path, u.Files, u.URL)
+	}
+}
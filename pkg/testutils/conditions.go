@@ -0,0 +1,103 @@
+package testutils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/eve"
+)
+
+// Kinds of objects of EVE
+const (
+	KindApp     = "app"
+	KindVolume  = "volume"
+	KindNetwork = "network"
+)
+
+// StateRemoved is expected state of objects removed from EVE
+const StateRemoved = "-"
+
+// states returns EVE states of objects of kind by their names
+func states(kind string, state *eve.State) map[string]string {
+	result := make(map[string]string)
+	switch kind {
+	case KindApp:
+		for _, app := range state.Applications() {
+			result[app.Name] = app.EVEState
+		}
+	case KindVolume:
+		for _, vol := range state.Volumes() {
+			result[vol.Name] = vol.EveState
+		}
+	case KindNetwork:
+		for _, net := range state.Networks() {
+			result[net.Name] = net.EveState
+		}
+	}
+	return result
+}
+
+// stateMatches checks if state of object of kind matches the expected one
+func stateMatches(kind, actual, expected string) bool {
+	if actual == expected {
+		return true
+	}
+	// PURGING and RESTARTING are not published by EVE since 12.3
+	return kind == KindApp && actual == "HALTING" &&
+		(expected == "PURGING" || expected == "RESTARTING")
+}
+
+// InState returns Condition satisfied when all objects of kind with names
+// are in the expected state of EVE, StateRemoved means they are not reported by EVE
+func InState(kind, expected string, names ...string) Condition {
+	return func(state *eve.State) error {
+		if state.InfoAndMetrics().GetDinfo() == nil {
+			return fmt.Errorf("no info from device received")
+		}
+		current := states(kind, state)
+		var pending []string
+		for _, name := range names {
+			actual, ok := current[name]
+			switch {
+			case expected == StateRemoved && ok:
+				pending = append(pending, fmt.Sprintf("%s %s is in state %s", kind, name, actual))
+			case expected == StateRemoved:
+			case !ok:
+				pending = append(pending, fmt.Sprintf("no %s %s found", kind, name))
+			case !stateMatches(kind, actual, expected):
+				pending = append(pending, fmt.Sprintf("%s %s is in state %s", kind, name, actual))
+			}
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("expected %s: %s", expected, strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
+
+// AppsInState returns Condition satisfied when apps are in the expected state
+func AppsInState(expected string, names ...string) Condition {
+	return InState(KindApp, expected, names...)
+}
+
+// VolumesInState returns Condition satisfied when volumes are in the expected state
+func VolumesInState(expected string, names ...string) Condition {
+	return InState(KindVolume, expected, names...)
+}
+
+// NetworksInState returns Condition satisfied when networks are in the expected state
+func NetworksInState(expected string, names ...string) Condition {
+	return InState(KindNetwork, expected, names...)
+}
+
+// All returns Condition satisfied when all conditions are satisfied
+func All(conditions ...Condition) Condition {
+	return func(state *eve.State) error {
+		for _, cond := range conditions {
+			if err := cond(state); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,156 @@
+// Package testutils provides waiting and asserting on state of EVE reported
+// to controller, which can be used by escript helper binaries and Go tests.
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// Condition checks state of EVE, it returns nil if condition is satisfied
+// or error describing why it is not satisfied yet
+type Condition func(state *eve.State) error
+
+// StateChange is state of object received from EVE at time
+type StateChange struct {
+	State string
+	Time  time.Time
+}
+
+// Waiter feeds info messages of EVE from controller into state
+// and waits for conditions on it
+type Waiter struct {
+	ctrl  controller.Cloud
+	dev   *device.Ctx
+	state *eve.State
+
+	// Out receives notifications about changes of states of objects if not nil
+	Out io.Writer
+
+	mu      sync.Mutex
+	history map[string][]StateChange
+}
+
+// NewWaiter creates Waiter for device, existing info messages are fed into
+// state unless onlyNew is set
+func NewWaiter(ctrl controller.Cloud, dev *device.Ctx, onlyNew bool) (*Waiter, error) {
+	w := &Waiter{
+		ctrl:    ctrl,
+		dev:     dev,
+		state:   eve.Init(ctrl, dev),
+		history: make(map[string][]StateChange),
+	}
+	if !onlyNew {
+		if err := ctrl.InfoLastCallback(dev.GetID(), nil, w.process); err != nil {
+			return nil, fmt.Errorf("InfoLastCallback: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// State returns state of EVE fed by waiter, it must not be used while Wait is running
+func (w *Waiter) State() *eve.State {
+	return w.state
+}
+
+// History returns changes of states of object with kind (app, volume or network) and name
+func (w *Waiter) History(kind, name string) []StateChange {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]StateChange{}, w.history[historyKey(kind, name)]...)
+}
+
+func historyKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// record saves state of object into history if it changed
+func (w *Waiter) record(kind, name, state string) {
+	key := historyKey(kind, name)
+	changes := w.history[key]
+	if len(changes) > 0 && changes[len(changes)-1].State == state {
+		return
+	}
+	w.history[key] = append(changes, StateChange{State: state, Time: time.Now()})
+	if w.Out != nil {
+		fmt.Fprintln(w.Out, utils.AddTimestampf("\t%s %s state changed to %s", kind, name, state))
+	}
+}
+
+// process feeds info message into state and records changes of states of objects
+func (w *Waiter) process(im *info.ZInfoMsg) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.processLocked(im)
+	return false
+}
+
+func (w *Waiter) processLocked(im *info.ZInfoMsg) {
+	w.state.InfoCallback()(im)
+	for _, app := range w.state.Applications() {
+		w.record(KindApp, app.Name, app.EVEState)
+	}
+	for _, vol := range w.state.Volumes() {
+		w.record(KindVolume, vol.Name, vol.EveState)
+	}
+	for _, net := range w.state.Networks() {
+		w.record(KindNetwork, net.Name, net.EveState)
+	}
+}
+
+// Wait waits for condition to be satisfied by state of EVE until ctx is done,
+// in this case error wraps error of ctx and describes the last unsatisfied state.
+// State is not updated after Wait returns.
+func (w *Waiter) Wait(ctx context.Context, cond Condition) error {
+	w.mu.Lock()
+	lastErr := cond(w.state)
+	w.mu.Unlock()
+	if lastErr == nil {
+		return nil
+	}
+	// watch is canceled when Wait returns, so it does not outlive Wait
+	// even if ctx has no deadline
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopped := false
+	done := make(chan error, 1)
+	go func() {
+		done <- w.ctrl.InfoWatch(watchCtx, w.dev.GetID(), nil, func(im *info.ZInfoMsg) bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if stopped {
+				return true
+			}
+			w.processLocked(im)
+			lastErr = cond(w.state)
+			return lastErr == nil
+		})
+	}()
+	select {
+	case <-ctx.Done():
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		stopped = true
+		return fmt.Errorf("%w: %s", ctx.Err(), lastErr)
+	case err := <-done:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		stopped = true
+		if lastErr == nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("InfoWatch: %w", err)
+		}
+		return lastErr
+	}
+}
@@ -0,0 +1,106 @@
+package testutils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/testutils"
+	"github.com/lf-edge/eve-api/go/info"
+	. "github.com/onsi/gomega"
+	uuid "github.com/satori/go.uuid"
+)
+
+// fakeCloud delivers info messages sent into infos to watchers
+type fakeCloud struct {
+	controller.Cloud
+	infos    chan *info.ZInfoMsg
+	watchers chan struct{}
+}
+
+func newFakeCloud() *fakeCloud {
+	return &fakeCloud{infos: make(chan *info.ZInfoMsg), watchers: make(chan struct{}, 1)}
+}
+
+func (c *fakeCloud) InfoLastCallback(_ uuid.UUID, _ map[string]string, _ einfo.HandlerFunc) error {
+	return nil
+}
+
+func (c *fakeCloud) InfoWatch(ctx context.Context, _ uuid.UUID, _ map[string]string, handler einfo.HandlerFunc) error {
+	defer func() { c.watchers <- struct{}{} }()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case im := <-c.infos:
+			if handler(im) {
+				return nil
+			}
+		}
+	}
+}
+
+func newTestWaiter(t *testing.T) (*testutils.Waiter, *fakeCloud, *info.ZInfoMsg) {
+	ctrl := newFakeCloud()
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.FromStringOrNil("1b4a1b4a-8c4a-4b1e-9b4a-1b4a1b4a1b4a"))
+	w, err := testutils.NewWaiter(ctrl, dev, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := &info.ZInfoMsg{
+		Ztype:       info.ZInfoTypes_ZiDevice,
+		DevId:       dev.GetID().String(),
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{State: info.ZDeviceState_ZDEVICE_STATE_ONLINE}},
+	}
+	return w, ctrl, im
+}
+
+func deviceOnline(state *eve.State) error {
+	if state.InfoAndMetrics().GetDinfo().GetState() != info.ZDeviceState_ZDEVICE_STATE_ONLINE {
+		return errors.New("device is not online")
+	}
+	return nil
+}
+
+func TestWaiterSatisfied(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	w, ctrl, im := newTestWaiter(t)
+	go func() { ctrl.infos <- im }()
+	g.Expect(w.Wait(context.Background(), deviceOnline)).To(Succeed())
+	g.Eventually(ctrl.watchers).Should(Receive())
+	// condition already satisfied by state does not wait for info
+	g.Expect(w.Wait(context.Background(), deviceOnline)).To(Succeed())
+}
+
+func TestWaiterTimeout(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	w, ctrl, _ := newTestWaiter(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := w.Wait(ctx, deviceOnline)
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+	g.Expect(err).To(MatchError(ContainSubstring("device is not online")))
+	g.Eventually(ctrl.watchers).Should(Receive())
+}
+
+func TestWaiterCanceled(t *testing.T) {
+	t.Parallel()
+	g := NewGomegaWithT(t)
+
+	w, ctrl, _ := newTestWaiter(t)
+	// ctx without deadline must stop watching of info when canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	g.Expect(w.Wait(ctx, deviceOnline)).To(MatchError(context.Canceled))
+	g.Eventually(ctrl.watchers).Should(Receive())
+}
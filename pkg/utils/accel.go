@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+)
+
+const (
+	// QemuAccelAuto selects the best accelerator available on the host
+	QemuAccelAuto = "auto"
+	// QemuAccelKVM is Kernel-based Virtual Machine of Linux
+	QemuAccelKVM = "kvm"
+	// QemuAccelHVF is Hypervisor.framework of macOS
+	QemuAccelHVF = "hvf"
+	// QemuAccelWHPX is Windows Hypervisor Platform
+	QemuAccelWHPX = "whpx"
+	// QemuAccelTCG is tuned software emulation
+	QemuAccelTCG = "tcg"
+)
+
+// AccelCapabilities are virtualization capabilities of the host
+type AccelCapabilities struct {
+	KVM    bool // /dev/kvm can be opened for read and write
+	HVF    bool // Hypervisor.framework is supported
+	WHPX   bool // QEMU supports Windows Hypervisor Platform
+	Nested bool // VMs can run inside of accelerated VM
+}
+
+// String returns capabilities in human-readable form
+func (caps AccelCapabilities) String() string {
+	return fmt.Sprintf("kvm: %t, hvf: %t, whpx: %t, nested: %t",
+		caps.KVM, caps.HVF, caps.WHPX, caps.Nested)
+}
+
+// Best returns the best accelerator for VM of arch (the host one if empty),
+// VM of another architecture than the host one can only be emulated with TCG
+func (caps AccelCapabilities) Best(arch string) string {
+	arch = strings.ToLower(arch)
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	switch {
+	case arch != runtime.GOARCH:
+		return QemuAccelTCG
+	case caps.KVM:
+		return QemuAccelKVM
+	case caps.HVF:
+		return QemuAccelHVF
+	case caps.WHPX && arch == "amd64":
+		return QemuAccelWHPX
+	default:
+		return QemuAccelTCG
+	}
+}
+
+// DetectAccel probes virtualization capabilities of the host
+func DetectAccel() AccelCapabilities {
+	return AccelCapabilities{
+		KVM:    kvmSupported(),
+		HVF:    QemuHVFSupported(),
+		WHPX:   whpxSupported(),
+		Nested: nestedSupported(),
+	}
+}
+
+func kvmSupported() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	f, err := os.OpenFile("/dev/kvm", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+func whpxSupported() bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	out, _, err := RunCommandAndWait("qemu-system-x86_64", "-accel", "help")
+	return err == nil && strings.Contains(out, QemuAccelWHPX)
+}
+
+// nestedSupported checks if nested virtualization is enabled in KVM module
+func nestedSupported() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	for _, module := range []string{"kvm_intel", "kvm_amd"} {
+		data, err := os.ReadFile(filepath.Join("/sys/module", module, "parameters", "nested"))
+		if err != nil {
+			continue
+		}
+		value := strings.TrimSpace(string(data))
+		return value == "Y" || value == "1"
+	}
+	return false
+}
+
+// QemuAccelerator returns accelerator to run VM of arch with: tuned TCG if tcgMode
+// requires emulation, the configured accelerator (detected one if auto) if accel
+// is enabled and empty string for emulation without tuning otherwise
+func QemuAccelerator(tcgMode, arch, accelerator string, accel bool) (string, error) {
+	tcg, err := QemuTCG(tcgMode, arch)
+	if err != nil {
+		return "", err
+	}
+	if tcg {
+		return QemuAccelTCG, nil
+	}
+	if !accel {
+		return "", nil
+	}
+	accelerator, err = ParseQemuAccelerator(accelerator)
+	if err != nil {
+		return "", err
+	}
+	if accelerator == QemuAccelAuto {
+		return DetectAccel().Best(arch), nil
+	}
+	return accelerator, nil
+}
+
+// ParseQemuAccelerator checks and normalizes name of accelerator, empty one is auto
+func ParseQemuAccelerator(accelerator string) (string, error) {
+	switch accelerator = strings.ToLower(accelerator); accelerator {
+	case "":
+		return QemuAccelAuto, nil
+	case QemuAccelAuto, QemuAccelKVM, QemuAccelHVF, QemuAccelWHPX, QemuAccelTCG:
+		return accelerator, nil
+	default:
+		return "", fmt.Errorf("unknown accelerator %q (expected %s, %s, %s, %s or %s)", accelerator,
+			QemuAccelAuto, QemuAccelKVM, QemuAccelHVF, QemuAccelWHPX, QemuAccelTCG)
+	}
+}
+
+// QemuAccelOptions returns QEMU options of machine and CPU of VM of arch (the host one
+// if empty) run with accelerator, VM is emulated without tuning if accelerator is empty
+func QemuAccelOptions(arch, accelerator string) (string, error) {
+	arch = strings.ToLower(arch)
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	switch arch {
+	case "amd64":
+		switch accelerator {
+		case QemuAccelKVM:
+			return defaults.DefaultQemuAccelLinuxAmd64, nil
+		case QemuAccelHVF:
+			return defaults.DefaultQemuAccelDarwin, nil
+		case QemuAccelWHPX:
+			return defaults.DefaultQemuAccelWHPX, nil
+		case QemuAccelTCG:
+			return defaults.DefaultQemuTCGAmd64, nil
+		case "":
+			return defaults.DefaultQemuAmd64, nil
+		}
+	case "arm64":
+		switch accelerator {
+		case QemuAccelKVM:
+			return defaults.DefaultQemuAccelArm64, nil
+		case QemuAccelHVF:
+			return defaults.DefaultQemuAccelDarwinArm64, nil
+		case QemuAccelTCG:
+			return defaults.DefaultQemuTCGArm64, nil
+		case "":
+			return defaults.DefaultQemuArm64, nil
+		}
+	default:
+		return "", fmt.Errorf("architecture not supported: %s", arch)
+	}
+	return "", fmt.Errorf("accelerator %s is not supported for %s", accelerator, arch)
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+)
+
+// foreignArch returns architecture supported by eden which differs from the host one
+func foreignArch() string {
+	if runtime.GOARCH == "amd64" {
+		return "arm64"
+	}
+	return "amd64"
+}
+
+func TestAccelCapabilitiesBest(t *testing.T) {
+	// WHPX accelerates only amd64 VMs
+	whpxHost := QemuAccelTCG
+	if runtime.GOARCH == "amd64" {
+		whpxHost = QemuAccelWHPX
+	}
+	tests := []struct {
+		name string
+		caps AccelCapabilities
+		arch string
+		want string
+	}{
+		{name: "kvm", caps: AccelCapabilities{KVM: true, HVF: true}, arch: runtime.GOARCH, want: QemuAccelKVM},
+		{name: "hvf", caps: AccelCapabilities{HVF: true}, arch: runtime.GOARCH, want: QemuAccelHVF},
+		{name: "whpx", caps: AccelCapabilities{WHPX: true}, arch: runtime.GOARCH, want: whpxHost},
+		{name: "host arch if empty", caps: AccelCapabilities{KVM: true}, arch: "", want: QemuAccelKVM},
+		{name: "arch is case insensitive", caps: AccelCapabilities{KVM: true}, arch: strings.ToUpper(runtime.GOARCH), want: QemuAccelKVM},
+		{name: "nothing available", caps: AccelCapabilities{Nested: true}, arch: runtime.GOARCH, want: QemuAccelTCG},
+		{name: "foreign arch is emulated", caps: AccelCapabilities{KVM: true, HVF: true, WHPX: true}, arch: foreignArch(), want: QemuAccelTCG},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.caps.Best(tt.arch); got != tt.want {
+				t.Errorf("Best(%q) of %s = %q, want %q", tt.arch, tt.caps, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQemuAccelerator(t *testing.T) {
+	tests := []struct {
+		accelerator string
+		want        string
+		wantErr     bool
+	}{
+		{accelerator: "", want: QemuAccelAuto},
+		{accelerator: "auto", want: QemuAccelAuto},
+		{accelerator: "KVM", want: QemuAccelKVM},
+		{accelerator: "hvf", want: QemuAccelHVF},
+		{accelerator: "Whpx", want: QemuAccelWHPX},
+		{accelerator: "tcg", want: QemuAccelTCG},
+		{accelerator: "xen", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accelerator, func(t *testing.T) {
+			got, err := ParseQemuAccelerator(tt.accelerator)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQemuAccelerator(%q) error = %v, wantErr %t", tt.accelerator, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseQemuAccelerator(%q) = %q, want %q", tt.accelerator, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQemuAccelerator(t *testing.T) {
+	tests := []struct {
+		name        string
+		tcgMode     string
+		arch        string
+		accelerator string
+		accel       bool
+		want        string
+		wantErr     bool
+	}{
+		{name: "tcg on", tcgMode: QemuTCGOn, accelerator: QemuAccelKVM, accel: true, want: QemuAccelTCG},
+		{name: "foreign arch falls back to tcg", tcgMode: QemuTCGAuto, arch: foreignArch(), accelerator: QemuAccelKVM, accel: true, want: QemuAccelTCG},
+		{name: "foreign arch without tcg", tcgMode: QemuTCGOff, arch: foreignArch(), accel: true, wantErr: true},
+		{name: "configured accelerator", tcgMode: QemuTCGAuto, accelerator: "HVF", accel: true, want: QemuAccelHVF},
+		{name: "acceleration disabled", tcgMode: QemuTCGOff, accelerator: QemuAccelKVM, accel: false, want: ""},
+		{name: "unknown accelerator", tcgMode: QemuTCGAuto, accelerator: "xen", accel: true, wantErr: true},
+		{name: "unknown tcg mode", tcgMode: "maybe", accel: true, wantErr: true},
+		{name: "auto uses host capabilities", tcgMode: QemuTCGAuto, accelerator: QemuAccelAuto, accel: true,
+			want: DetectAccel().Best(runtime.GOARCH)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QemuAccelerator(tt.tcgMode, tt.arch, tt.accelerator, tt.accel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QemuAccelerator error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("QemuAccelerator = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQemuAccelOptions(t *testing.T) {
+	tests := []struct {
+		arch        string
+		accelerator string
+		want        string
+		wantErr     bool
+	}{
+		{arch: "amd64", accelerator: QemuAccelKVM, want: defaults.DefaultQemuAccelLinuxAmd64},
+		{arch: "AMD64", accelerator: QemuAccelHVF, want: defaults.DefaultQemuAccelDarwin},
+		{arch: "amd64", accelerator: QemuAccelWHPX, want: defaults.DefaultQemuAccelWHPX},
+		{arch: "amd64", accelerator: QemuAccelTCG, want: defaults.DefaultQemuTCGAmd64},
+		{arch: "amd64", accelerator: "", want: defaults.DefaultQemuAmd64},
+		{arch: "arm64", accelerator: QemuAccelKVM, want: defaults.DefaultQemuAccelArm64},
+		{arch: "arm64", accelerator: QemuAccelHVF, want: defaults.DefaultQemuAccelDarwinArm64},
+		{arch: "arm64", accelerator: QemuAccelTCG, want: defaults.DefaultQemuTCGArm64},
+		{arch: "arm64", accelerator: "", want: defaults.DefaultQemuArm64},
+		{arch: "arm64", accelerator: QemuAccelWHPX, wantErr: true},
+		{arch: "riscv64", accelerator: QemuAccelTCG, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arch+"/"+tt.accelerator, func(t *testing.T) {
+			got, err := QemuAccelOptions(tt.arch, tt.accelerator)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QemuAccelOptions(%q, %q) error = %v, wantErr %t", tt.arch, tt.accelerator, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("QemuAccelOptions(%q, %q) = %q, want %q", tt.arch, tt.accelerator, got, tt.want)
+			}
+		})
+	}
+	// empty arch is the host one
+	host, err := QemuAccelOptions(runtime.GOARCH, QemuAccelTCG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := QemuAccelOptions("", QemuAccelTCG); err != nil || got != host {
+		t.Errorf("QemuAccelOptions for host arch = %q (%v), want %q", got, err, host)
+	}
+}
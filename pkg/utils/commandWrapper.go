@@ -19,6 +19,10 @@ import (
 
 // RunCommandBackground command run in goroutine
 func RunCommandBackground(name string, logOutput io.Writer, args ...string) (pid int, err error) {
+	if dryRun {
+		dryRunCommand(name, args)
+		return 0, nil
+	}
 	cmd := exec.Command(name, args...)
 	if logOutput != nil {
 		stderr, err := cmd.StderrPipe()
@@ -55,6 +59,10 @@ func RunCommandBackground(name string, logOutput io.Writer, args ...string) (pid
 
 // RunCommandNohup run process in background
 func RunCommandNohup(name string, logFile string, pidFile string, args ...string) (err error) {
+	if dryRun {
+		dryRunCommand(name, args)
+		return nil
+	}
 	cmd := exec.Command(name, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	if logFile != "" {
@@ -119,6 +127,10 @@ func StopCommandWithPid(pidFile string) (err error) {
 	if err != nil {
 		return fmt.Errorf("cannot open pid file %s: %s", pidFile, err)
 	}
+	if dryRun {
+		DryRunf("stop process %s from pid file %s", strings.TrimSpace(string(content)), pidFile)
+		return nil
+	}
 	if err = os.Remove(pidFile); err != nil {
 		return fmt.Errorf("cannot delete pid file %s: %s", pidFile, err)
 	}
@@ -184,6 +196,10 @@ func RunCommandForegroundWithOpts(name string, args []string, opts ...CommandOpt
 }
 
 func runCommandForeground(name string, args []string, opts []CommandOpt) (err error) {
+	if dryRun {
+		dryRunCommand(name, args)
+		return nil
+	}
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan,
 		syscall.SIGHUP,
@@ -216,8 +232,12 @@ func RunCommandAndWait(name string, args ...string) (stdout string, stderr strin
 
 // RunCommandWithLogAndWait run process in foreground
 func RunCommandWithLogAndWait(name string, logLevel log.Level, args ...string) (err error) {
+	if dryRun {
+		dryRunCommand(name, args)
+		return nil
+	}
 	cmd := exec.Command(name, args...)
-	if log.IsLevelEnabled(logLevel) {
+	if LevelEnabled(logLevel) {
 		logWriter := log.StandardLogger().Out
 		cmd.Stdout = logWriter
 		cmd.Stderr = logWriter
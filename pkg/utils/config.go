@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
@@ -36,12 +37,15 @@ type ConfigVars struct {
 	AdamRemoteRedis   bool
 	AdamRedisURLEden  string
 	AdamRedisURLAdam  string
+	AdamOfflineDir    string
 	EveHV             string
 	EveSSID           string
 	EveUUID           string
 	EveName           string
 	EveRemote         bool
 	EveRemoteAddr     string
+	EveAccel          string
+	EvePid            string
 	EveQemuPorts      map[string]string
 	EveQemuConfig     string
 	EveDist           string
@@ -131,6 +135,12 @@ func InitVars() (*ConfigVars, error) {
 			LogLevel:          viper.GetString("eve.log-level"),
 			AdamLogLevel:      viper.GetString("eve.adam-log-level"),
 		}
+		if !vars.EveRemote && vars.DevModel == defaults.DefaultQemuModel {
+			// invalid modes are reported when EVE is started
+			vars.EveAccel, _ = QemuAccelerator(viper.GetString("eve.tcg"), vars.ZArch,
+				viper.GetString("eve.accelerator"), viper.GetBool("eve.accel"))
+			vars.EvePid = ResolveAbsPath(viper.GetString("eve.pid"))
+		}
 		viperAccessMutex.RUnlock()
 		redisPasswordFile := filepath.Join(globalCertsDir, defaults.DefaultRedisPasswordFile)
 		pwd, err := os.ReadFile(redisPasswordFile)
@@ -371,6 +381,12 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return runtime.GOOS
 		case "eve.accel":
 			return true
+		case "eve.tcg":
+			return defaults.DefaultQemuTCG
+		case "eve.accelerator":
+			return defaults.DefaultQemuAccelerator
+		case "eve.nested":
+			return false
 		case "eve.hv":
 			return defaults.DefaultEVEHV
 		case "eve.serial":
@@ -384,14 +400,17 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 		case "eve.log":
 			return fmt.Sprintf("%s-eve.log", strings.ToLower(context.Current))
 		case "eve.firmware":
-			if runtime.GOARCH == "amd64" {
-				return fmt.Sprintf("[%s %s]",
-					filepath.Join(imageDist, "eve", "firmware", "OVMF_CODE.fd"),
-					filepath.Join(imageDist, "eve", "firmware", "OVMF_VARS.fd"))
-			}
-			return fmt.Sprintf("[%s]", filepath.Join(imageDist, "eve", "firmware", "OVMF.fd"))
+			return fmt.Sprintf("[%s]", strings.Join(QemuFirmware(imageDist, runtime.GOARCH), " "))
 		case "eve.repo":
 			return defaults.DefaultEveRepo
+		case "eve.source":
+			return ""
+		case "eve.ref":
+			return ""
+		case "eve.digest":
+			return ""
+		case "eve.cosign-key":
+			return ""
 		case "eve.registry":
 			return defaults.DefaultEveRegistry
 		case "eve.tag":
@@ -408,6 +427,12 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return ""
 		case "eve.custom-installer.format":
 			return ""
+		case "eve.physical.power-on":
+			return ""
+		case "eve.physical.power-off":
+			return ""
+		case "eve.physical.console":
+			return ""
 		case "eve.dtb-part":
 			return ""
 		case "eve.config-part":
@@ -428,6 +453,8 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultQemuMonitorPort
 		case "eve.qemu.netdev-socket-port":
 			return defaults.DefaultQemuNetdevSocketPort
+		case "eve.qemu.overlay":
+			return true
 		case "eve.cpu":
 			return defaults.DefaultCpus
 		case "eve.ram":
@@ -438,6 +465,8 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultTPMEnabled
 		case "eve.disks":
 			return defaults.DefaultAdditionalDisks
+		case "eve.disk-layout":
+			return "[]"
 		case "eve.bootstrap-file":
 			return ""
 		case "eve.usbnetconf-file":
@@ -451,6 +480,18 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultEserverDist
 		case "eden.download":
 			return true
+		case "eden.artifacts-upload.provider":
+			return ""
+		case "eden.artifacts-upload.bucket":
+			return ""
+		case "eden.artifacts-upload.prefix":
+			return ""
+		case "eden.artifacts-upload.endpoint":
+			return ""
+		case "eden.artifacts-upload.region":
+			return ""
+		case "eden.artifacts-upload.credentials":
+			return ""
 		case "eden.eserver.eve-ip":
 			return defaults.DefaultDomain
 		case "eden.eserver.ip":
@@ -479,6 +520,8 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultTestScenario
 		case "eden.enable-ipv6":
 			return false
+		case "eden.slot":
+			return 0
 		case "eden.ipv6-subnet":
 			return defaults.DefaultDockerNetIPv6Subnet
 
@@ -543,6 +586,8 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 	var fm = template.FuncMap{
 		"parse":    parse,
 		"parsemap": parseMap,
+		// optional sections are empty in default config
+		"parsesection": func(string) string { return "{}" },
 	}
 	t := template.New("t").Funcs(fm)
 	_, err = t.Parse(templateString)
@@ -571,7 +616,7 @@ func generateConfigFileFromViperTemplate(filePath string, templateString string)
 	}
 	defer file.Close()
 
-	parse := func(inp string) interface{} {
+	get := func(inp string) interface{} {
 		result := viper.Get(inp)
 		if result != nil {
 			return result
@@ -580,15 +625,31 @@ func generateConfigFileFromViperTemplate(filePath string, templateString string)
 		return ""
 	}
 	parseMap := func(inp string) interface{} {
-		result, err := json.Marshal(parse(inp))
+		result, err := json.Marshal(get(inp))
 		if err != nil {
 			log.Fatalf("cannot parse %s: %s", inp, err)
 		}
 		return string(result)
 	}
+	// lists are rendered in JSON to be read back as lists by YAML parser
+	parse := func(inp string) interface{} {
+		result := get(inp)
+		if kind := reflect.ValueOf(result).Kind(); kind == reflect.Slice || kind == reflect.Map {
+			return parseMap(inp)
+		}
+		return result
+	}
+	// optional sections are rendered in JSON, missing ones are empty
+	parseSection := func(inp string) interface{} {
+		if viper.Get(inp) == nil {
+			return "{}"
+		}
+		return parseMap(inp)
+	}
 	var fm = template.FuncMap{
-		"parse":    parse,
-		"parsemap": parseMap,
+		"parse":        parse,
+		"parsemap":     parseMap,
+		"parsesection": parseSection,
 	}
 	t := template.New("t").Funcs(fm)
 	_, err = t.Parse(templateString)
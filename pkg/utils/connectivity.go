@@ -36,7 +36,7 @@ func (wc *writeCounter) Write(p []byte) (int, error) {
 }
 
 func (wc writeCounter) printProgress() {
-	if log.IsLevelEnabled(log.InfoLevel) {
+	if LevelEnabled(log.InfoLevel) {
 		fmt.Printf("\r%s", strings.Repeat(" ", 35))
 		fmt.Printf("\r%s %s complete", wc.message, humanize.Bytes(wc.total))
 	}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -13,6 +14,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"strings"
+	"time"
 
 	// Docker SDK (use consistent version)
 	"github.com/distribution/reference"
@@ -24,7 +26,9 @@ import (
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/registry"
 	"github.com/docker/go-connections/nat"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -91,6 +95,10 @@ func dockerVolumeName(containerName string) string {
 // RemoveGeneratedVolumeOfContainer remove volumes created by eden
 func RemoveGeneratedVolumeOfContainer(containerName string) error {
 	volumeName := dockerVolumeName(containerName)
+	if dryRun {
+		DryRunf("remove volume %s", volumeName)
+		return nil
+	}
 	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
@@ -102,6 +110,10 @@ func RemoveGeneratedVolumeOfContainer(containerName string) error {
 // CreateAndRunContainer run container with defined name from image with port and volume mapping and defined command
 func CreateAndRunContainer(containerName string, imageName string, portMap map[string]string,
 	volumeMap map[string]string, command []string, envs []string, enableIPv6 bool, ipv6Subnet string) error {
+	if dryRun {
+		dryRunContainer(containerName, imageName, portMap, volumeMap, command, envs)
+		return nil
+	}
 	log.Debugf("Try to start container from image %s with command %s", imageName, command)
 	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
@@ -214,6 +226,17 @@ func NormalizeRegistry(imageRef string) string {
 	// Handle docker:// prefix if present
 	imageRef = strings.TrimPrefix(imageRef, "docker://")
 
+	// Registry without repository (e.g. fqdn of datastore), not an image of Docker Hub
+	if !strings.Contains(imageRef, "/") {
+		host := strings.SplitN(imageRef, ":", 2)[0]
+		if strings.Contains(host, ".") || host == "localhost" {
+			if host == "docker.io" || host == "index.docker.io" {
+				return registry.IndexServer
+			}
+			return imageRef
+		}
+	}
+
 	// Parse the image reference using the current recommended function
 	ref, err := reference.ParseNormalizedNamed(imageRef)
 	if err == nil {
@@ -315,18 +338,22 @@ func getEncodedAuth(image string) (string, error) {
 	return base64.StdEncoding.EncodeToString(encodedJSON), nil
 }
 
-// get docker auth as plain text user and access token
-func GetDockerAuthPlain(fqdn string) (string, string, error) {
+// GetDockerCredentials returns credentials of registry with fqdn from docker config
+func GetDockerCredentials(fqdn string) (*RegistryCredentials, error) {
 	authConfig, err := getRegistryAuth(fqdn)
 	if err != nil {
-		return "", "", fmt.Errorf("GetDockerAuthPlain: failed to get docker auth config for fqdn %s: %w", fqdn, err)
+		return nil, fmt.Errorf("GetDockerCredentials: failed to get docker auth config for fqdn %s: %w", fqdn, err)
 	}
 
-	if authConfig.Password == "" && authConfig.Username == "" {
-		return "", "", fmt.Errorf("no Docker credentials found for fqdn %s", fqdn)
+	if authConfig.Password == "" && authConfig.Username == "" && authConfig.IdentityToken == "" {
+		return nil, fmt.Errorf("no Docker credentials found for fqdn %s", fqdn)
 	}
 	log.Infof("loaded docker credentials for: %s", fqdn)
-	return authConfig.Username, authConfig.Password, nil
+	return &RegistryCredentials{
+		User:          authConfig.Username,
+		Password:      authConfig.Password,
+		IdentityToken: authConfig.IdentityToken,
+	}, nil
 }
 
 // PullImage from docker
@@ -340,7 +367,20 @@ func PullImage(image string) error {
 	if err == nil {
 		return nil // Image already present
 	}
+	return pullImage(ctx, cli, image)
+}
 
+// PullImageUpdate pulls image from docker even if it is already present,
+// to update it to the one the tag points to in registry
+func PullImageUpdate(image string) error {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("client.NewClientWithOpts: %w", err)
+	}
+	return pullImage(context.Background(), cli, image)
+}
+
+func pullImage(ctx context.Context, cli *client.Client, image string) error {
 	authStr, err := getEncodedAuth(image)
 	if err != nil {
 		log.Warnf("Falling back to anonymous pull: %v", err)
@@ -376,10 +416,28 @@ func HasImage(image string) (bool, error) {
 	return false, nil
 }
 
+// ImageID returns ID of local image, which is hash of its content
+func ImageID(image string) (string, error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return "", fmt.Errorf("client.NewClientWithOpts: %w", err)
+	}
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("ImageInspectWithRaw: %w", err)
+	}
+	return inspect.ID, nil
+}
+
 // CreateImage create new image from directory with tag
 // If Dockerfile is inside the directory will use it
 // otherwise will create image from scratch
 func CreateImage(dir, tag, platform string) error {
+	if dryRun {
+		DryRunf("build image %s for %s from %s", tag, platform, dir)
+		return nil
+	}
 	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
@@ -514,110 +572,196 @@ func SaveImageToTar(image, tarFile string) error {
 	return nil
 }
 
-// StopContainer stop container and remove if remove is true
-func StopContainer(containerName string, remove bool) error {
-	ctx := context.Background()
+// ErrContainerNotFound is returned for containers which do not exist
+var ErrContainerNotFound = errors.New("container not found")
+
+// ContainerState is state of container reported by docker
+type ContainerState struct {
+	Name string
+	ID   string
+	// Status is one of created, running, paused, restarting, removing, exited or dead
+	Status string
+	// ExitCode is exit code of the last run of container which is not running
+	ExitCode int
+	// Health is status of health check (starting, healthy or unhealthy),
+	// empty if container has no health check
+	Health string
+}
+
+// Running returns true if container is running
+func (state *ContainerState) Running() bool {
+	return state.Status == "running"
+}
+
+// String returns description of state for status of eden
+func (state *ContainerState) String() string {
+	description := fmt.Sprintf("container with name %s is %s", state.Name, state.Status)
+	if state.Health != "" {
+		description = fmt.Sprintf("%s (%s)", description, state.Health)
+	}
+	return description
+}
+
+// InspectContainer returns state of container with containerName,
+// ErrContainerNotFound is returned if it does not exist
+func InspectContainer(containerName string) (*ContainerState, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer cli.Close()
+	info, err := cli.ContainerInspect(context.Background(), containerName)
+	if errdefs.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s", ErrContainerNotFound, containerName)
 	}
-
-	containers, err := cli.ContainerList(context.Background(), container.ListOptions{All: true})
 	if err != nil {
-		return err
+		if dryRun {
+			// docker may be not available, report container as not created
+			return nil, fmt.Errorf("%w: %s", ErrContainerNotFound, containerName)
+		}
+		return nil, fmt.Errorf("ContainerInspect: %w", err)
+	}
+	state := &ContainerState{Name: strings.TrimLeft(info.Name, "/"), ID: info.ID}
+	if info.State != nil {
+		state.Status = info.State.Status
+		state.ExitCode = info.State.ExitCode
+		if info.State.Health != nil {
+			state.Health = info.State.Health.Status
+		}
 	}
-	for _, cont := range containers {
-		isFound := false
-		for _, name := range cont.Names {
-			if strings.Contains(name, containerName) {
-				isFound = true
-				break
-			}
+	return state, nil
+}
+
+// WaitContainerReady waits for container to run and to pass its health check if it has one,
+// error with the last lines of logs of container is returned if it stops or is unhealthy
+func WaitContainerReady(containerName string, timeout time.Duration) error {
+	if dryRun {
+		return nil
+	}
+	failed := func(reason string) error {
+		var logs strings.Builder
+		if err := ContainerLogs(containerName, "20", &logs); err != nil {
+			return fmt.Errorf("container %s %s", containerName, reason)
 		}
-		if isFound {
-			if cont.State != "running" {
-				if remove {
-					if err = cli.ContainerRemove(ctx, cont.ID, container.RemoveOptions{}); err != nil {
-						return err
-					}
-				}
-				return nil
-			}
-			if remove {
-				if err = cli.ContainerRemove(ctx, cont.ID, container.RemoveOptions{Force: true}); err != nil {
-					return err
-				}
-			} else {
-				timeout := 10
-				//if err = cli.ContainerStop(ctx, cont.ID, &timeout); err != nil {
-				if err = cli.ContainerStop(ctx, cont.ID, container.StopOptions{
-					Timeout: &timeout,
-				}); err != nil {
-					return err
-				}
-			}
+		return fmt.Errorf("container %s %s, the last lines of its logs:\n%s", containerName, reason, logs.String())
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		state, err := InspectContainer(containerName)
+		if err != nil {
+			return err
+		}
+		switch {
+		case state.Status == "exited" || state.Status == "dead":
+			return failed(fmt.Sprintf("is %s with code %d", state.Status, state.ExitCode))
+		case state.Health == types.Unhealthy:
+			return failed("is unhealthy")
+		case state.Running() && (state.Health == "" || state.Health == types.Healthy):
 			return nil
 		}
+		if time.Now().After(deadline) {
+			return failed(fmt.Sprintf("is not ready in %s: %s", timeout, state))
+		}
+		time.Sleep(time.Second)
 	}
-	return fmt.Errorf("container not found")
 }
 
-// StateContainer return state of container if found or "" state if not found
-func StateContainer(containerName string) (state string, err error) {
+// StopContainer stop container and remove if remove is true
+func StopContainer(containerName string, remove bool) error {
+	if dryRun {
+		DryRunf("stop container %s (remove: %t)", containerName, remove)
+		return nil
+	}
+	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
-		return "", err
+		return err
 	}
-
-	containers, err := cli.ContainerList(context.Background(), container.ListOptions{All: true})
+	defer cli.Close()
+	state, err := InspectContainer(containerName)
 	if err != nil {
-		return "", err
+		return err
 	}
-	for _, cont := range containers {
-		isFound := false
-		for _, name := range cont.Names {
-			if strings.Contains(name, containerName) {
-				isFound = true
-				break
+	if !state.Running() {
+		if remove {
+			if err = cli.ContainerRemove(ctx, state.ID, container.RemoveOptions{}); err != nil {
+				return fmt.Errorf("ContainerRemove: %w", err)
 			}
 		}
-		if isFound {
-			return fmt.Sprintf("container with name %s is %s", strings.TrimLeft(cont.Names[0], "/"), cont.State), nil
+		return nil
+	}
+	if remove {
+		if err = cli.ContainerRemove(ctx, state.ID, container.RemoveOptions{Force: true}); err != nil {
+			return fmt.Errorf("ContainerRemove: %w", err)
 		}
+		return nil
 	}
-	return "", nil
+	timeout := 10
+	if err = cli.ContainerStop(ctx, state.ID, container.StopOptions{
+		Timeout: &timeout,
+	}); err != nil {
+		return fmt.Errorf("ContainerStop: %w", err)
+	}
+	return nil
 }
 
 // StartContainer start container with containerName
 func StartContainer(containerName string) error {
-	ctx := context.Background()
+	if dryRun {
+		DryRunf("start container %s", containerName)
+		return nil
+	}
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return err
 	}
-
-	containers, err := cli.ContainerList(context.Background(), container.ListOptions{All: true})
+	defer cli.Close()
+	state, err := InspectContainer(containerName)
 	if err != nil {
 		return err
 	}
-	for _, cont := range containers {
-		isFound := false
-		for _, name := range cont.Names {
-			if strings.Contains(name, containerName) {
-				isFound = true
-				break
-			}
-		}
-		if isFound {
-			if err = cli.ContainerStart(ctx, cont.ID, container.StartOptions{}); err != nil {
-				return err
-			}
-			break
-		}
+	if err = cli.ContainerStart(context.Background(), state.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("ContainerStart: %w", err)
 	}
 	return nil
 }
 
+// ContainerLogs writes stdout and stderr of container with containerName into w,
+// tail defines number of lines from the end of logs to write ("all" for all lines)
+func ContainerLogs(containerName string, tail string, w io.Writer) error {
+	return containerLogs(containerName, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Tail:       tail,
+	}, w)
+}
+
+// ContainerLogsSince writes stdout and stderr of container with containerName
+// produced after since into w
+func ContainerLogsSince(containerName string, since time.Time, w io.Writer) error {
+	return containerLogs(containerName, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      since.Format(time.RFC3339Nano),
+	}, w)
+}
+
+func containerLogs(containerName string, options container.LogsOptions, w io.Writer) error {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	out, err := cli.ContainerLogs(ctx, containerName, options)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = stdcopy.StdCopy(w, w, out)
+	return err
+}
+
 // writeToLog from the build response to the log
 func writeToLog(reader io.ReadCloser) error {
 	defer reader.Close()
@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Formats of images produced by ConvertImage
+const (
+	ImageFormatRaw  = "raw"
+	ImageFormatGCP  = "gcp"
+	ImageFormatVHD  = "vhd"
+	ImageFormatVMDK = "vmdk"
+	ImageFormatOVA  = "ova"
+)
+
+// gcpImageDisk is name of raw disk inside image GCP imports
+const gcpImageDisk = "disk.raw"
+
+const (
+	mebibyte = int64(1) << 20
+	gibibyte = int64(1) << 30
+)
+
+// ImageVM describes virtual machine saved into OVF descriptor of OVA image
+type ImageVM struct {
+	Name     string
+	CPUs     int
+	MemoryMB int
+}
+
+// AlignedImageSize returns virtual size of image of format to hold size bytes:
+// GCP accepts raw disks sized in whole GiB, Azure requires fixed VHD aligned
+// to MiB, VMDK and OVA are aligned to MiB for VMware and VirtualBox
+func AlignedImageSize(format string, size int64) (int64, error) {
+	var alignment int64
+	switch format {
+	case ImageFormatRaw, ImageFormatGCP:
+		alignment = gibibyte
+	case ImageFormatVHD, ImageFormatVMDK, ImageFormatOVA:
+		alignment = mebibyte
+	default:
+		return 0, fmt.Errorf("unsupported format of image: %s", format)
+	}
+	return (size + alignment - 1) / alignment * alignment, nil
+}
+
+// imageInfo returns format and virtual size of image reported by qemu-img
+func imageInfo(file string) (string, int64, error) {
+	out, stderr, err := RunCommandAndWait("qemu-img", "info", "--output=json", file)
+	if err != nil {
+		return "", 0, fmt.Errorf("qemu-img info %s: %w (%s)", file, err, stderr)
+	}
+	var info struct {
+		Format      string `json:"format"`
+		VirtualSize int64  `json:"virtual-size"`
+	}
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		return "", 0, fmt.Errorf("cannot parse info of %s: %w", file, err)
+	}
+	return info.Format, info.VirtualSize, nil
+}
+
+// ImageFormat returns format of image (e.g. qcow2 or raw) reported by qemu-img
+func ImageFormat(file string) (string, error) {
+	format, _, err := imageInfo(file)
+	return format, err
+}
+
+// ConvertImage converts disk image src (any format known to qemu-img) into dst
+// of format (one of ImageFormat*) with virtual size aligned by AlignedImageSize.
+// The image is extended with zeroes, EVE moves backup GPT header to the end of
+// disk when it grows its partitions. vm is used only for OVA.
+func ConvertImage(src, dst, format string, vm ImageVM) error {
+	srcFormat, size, err := imageInfo(src)
+	if err != nil {
+		return err
+	}
+	alignedSize, err := AlignedImageSize(format, size)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), ".convert-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	rawFile := filepath.Join(tmpDir, gcpImageDisk)
+	if err := RunCommandForeground("qemu-img", "convert", "-f", srcFormat, "-O", "raw", src, rawFile); err != nil {
+		return fmt.Errorf("cannot convert %s into raw: %w", src, err)
+	}
+	if err := os.Truncate(rawFile, alignedSize); err != nil {
+		return err
+	}
+
+	switch format {
+	case ImageFormatRaw:
+		return os.Rename(rawFile, dst)
+	case ImageFormatGCP:
+		return CreateGCPImage(dst, rawFile)
+	case ImageFormatVHD:
+		// Azure accepts only fixed VHD with exact size in footer
+		return RunCommandForeground("qemu-img", "convert", "-f", "raw", "-O", "vpc",
+			"-o", "subformat=fixed,force_size=on", rawFile, dst)
+	case ImageFormatVMDK:
+		return RunCommandForeground("qemu-img", "convert", "-f", "raw", "-O", "vmdk",
+			"-o", "subformat=streamOptimized", rawFile, dst)
+	}
+	diskName := strings.TrimSuffix(filepath.Base(dst), filepath.Ext(dst)) + "-disk1.vmdk"
+	vmdkFile := filepath.Join(tmpDir, diskName)
+	if err := RunCommandForeground("qemu-img", "convert", "-f", "raw", "-O", "vmdk",
+		"-o", "subformat=streamOptimized", rawFile, vmdkFile); err != nil {
+		return err
+	}
+	if err := os.Remove(rawFile); err != nil {
+		return err
+	}
+	return createOVA(dst, vmdkFile, alignedSize, vm)
+}
+
+// CreateGCPImage packs rawFile as disk.raw into gzipped tar file in the way
+// GCP image import requires (tar --format=oldgnu -czf <file> disk.raw)
+func CreateGCPImage(file, rawFile string) error {
+	info, err := os.Stat(rawFile)
+	if err != nil {
+		return err
+	}
+	disk, err := os.Open(rawFile)
+	if err != nil {
+		return err
+	}
+	defer disk.Close()
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{
+		Name: gcpImageDisk, Mode: 0644, Size: info.Size(), ModTime: info.ModTime(), Format: tar.FormatGNU,
+	}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, disk); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+var ovfTemplate = template.Must(template.New("ovf").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData">
+  <References>
+    <File ovf:id="file1" ovf:href="{{.Disk}}" ovf:size="{{.DiskFileSize}}"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:capacity="{{.Capacity}}" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+  </DiskSection>
+  <NetworkSection>
+    <Info>Logical networks</Info>
+    <Network ovf:name="NAT">
+      <Description>NAT network</Description>
+    </Network>
+  </NetworkSection>
+  <VirtualSystem ovf:id="{{.Name}}">
+    <Info>EVE virtual machine</Info>
+    <Name>{{.Name}}</Name>
+    <OperatingSystemSection ovf:id="101">
+      <Info>Guest operating system</Info>
+      <Description>Linux 64-Bit</Description>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>{{.Name}}</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>vmx-14 virtualbox-2.2</vssd:VirtualSystemType>
+      </System>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:ElementName>{{.CPUs}} virtual CPU</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.CPUs}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:ElementName>{{.MemoryMB}} MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.MemoryMB}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:ElementName>SATA controller</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>AHCI</rasd:ResourceSubType>
+        <rasd:ResourceType>20</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:ElementName>disk1</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AutomaticAllocation>true</rasd:AutomaticAllocation>
+        <rasd:Connection>NAT</rasd:Connection>
+        <rasd:ElementName>ethernet0</rasd:ElementName>
+        <rasd:InstanceID>5</rasd:InstanceID>
+        <rasd:ResourceSubType>E1000</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`))
+
+// OVFDescriptor returns OVF descriptor of vm with single disk of capacity
+// bytes saved in streamOptimized VMDK file disk of diskFileSize bytes
+func OVFDescriptor(vm ImageVM, disk string, diskFileSize, capacity int64) (string, error) {
+	var sb strings.Builder
+	err := ovfTemplate.Execute(&sb, struct {
+		ImageVM
+		Disk         string
+		DiskFileSize int64
+		Capacity     int64
+	}{vm, disk, diskFileSize, capacity})
+	return sb.String(), err
+}
+
+// createOVA saves vmdkFile with OVF descriptor and manifest into OVA (tar) file
+func createOVA(ovaFile, vmdkFile string, capacity int64, vm ImageVM) error {
+	info, err := os.Stat(vmdkFile)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(ovaFile), filepath.Ext(ovaFile))
+	descriptor, err := OVFDescriptor(vm, filepath.Base(vmdkFile), info.Size(), capacity)
+	if err != nil {
+		return err
+	}
+	ovfHash := sha256.Sum256([]byte(descriptor))
+	manifest := fmt.Sprintf("SHA256(%s.ovf)= %s\nSHA256(%s)= %s\n",
+		name, hex.EncodeToString(ovfHash[:]), filepath.Base(vmdkFile), SHA256SUM(vmdkFile))
+
+	f, err := os.Create(ovaFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	// OVF descriptor must be the first file of OVA
+	for _, entry := range []struct{ name, content string }{
+		{name + ".ovf", descriptor},
+		{name + ".mf", manifest},
+	} {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), Format: tar.FormatUSTAR,
+		}); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(tw, entry.content); err != nil {
+			return err
+		}
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name: filepath.Base(vmdkFile), Mode: 0644, Size: info.Size(), Format: tar.FormatUSTAR,
+	}); err != nil {
+		return err
+	}
+	disk, err := os.Open(vmdkFile)
+	if err != nil {
+		return err
+	}
+	defer disk.Close()
+	if _, err := io.Copy(tw, disk); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
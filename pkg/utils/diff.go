@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// DiffOptions controls rendering of differences by RenderDiff and RenderJSONDiff
+type DiffOptions struct {
+	// Context is number of unchanged lines shown around changed ones, all lines are shown if negative
+	Context int
+	// Color highlights removed and added lines and changed words inside of them
+	Color bool
+}
+
+// DefaultDiffOptions returns options with 3 lines of context and colors enabled
+// unless output is not terminal or colors are disabled (with --no-color or NO_COLOR)
+func DefaultDiffOptions() DiffOptions {
+	return DiffOptions{Context: 3, Color: !color.NoColor}
+}
+
+// diffStyle is set of colors of parts of diff
+type diffStyle struct {
+	header      *color.Color
+	hunk        *color.Color
+	removed     *color.Color
+	added       *color.Color
+	removedWord *color.Color
+	addedWord   *color.Color
+}
+
+func newDiffStyle(enabled bool) *diffStyle {
+	style := &diffStyle{
+		header:      color.New(color.Bold),
+		hunk:        color.New(color.FgCyan),
+		removed:     color.New(color.FgRed),
+		added:       color.New(color.FgGreen),
+		removedWord: color.New(color.FgRed, color.Bold, color.ReverseVideo),
+		addedWord:   color.New(color.FgGreen, color.Bold, color.ReverseVideo),
+	}
+	for _, c := range []*color.Color{style.header, style.hunk, style.removed, style.added, style.removedWord, style.addedWord} {
+		// colors are set explicitly by options, not by detection of terminal
+		if enabled {
+			c.EnableColor()
+		} else {
+			c.DisableColor()
+		}
+	}
+	return style
+}
+
+// diffOp is operation of edit script: ' ' keeps element, '-' removes element of a, '+' adds element of b
+type diffOp struct {
+	kind byte
+	a, b int
+}
+
+// diffSequences returns the shortest edit script turning a into b
+func diffSequences(a, b []string) []diffOp {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is length of the longest common subsequence of midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			switch {
+			case midA[i] == midB[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{' ', i, i})
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			ops = append(ops, diffOp{' ', prefix + i, prefix + j})
+			i++
+			j++
+		case j == len(midB) || (i < len(midA) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', prefix + i, prefix + j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', prefix + i, prefix + j})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, diffOp{' ', len(a) - suffix + k, len(b) - suffix + k})
+	}
+	return ops
+}
+
+// splitLines splits text into lines marking missing final newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "(missing final newline)\n"
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffHunks returns ranges of ops with changes and context lines around them
+func diffHunks(ops []diffOp, context int) [][2]int {
+	if context < 0 {
+		return [][2]int{{0, len(ops)}}
+	}
+	var hunks [][2]int
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start, end := max(i-context, 0), min(i+context+1, len(ops))
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1][1] {
+			hunks[len(hunks)-1][1] = end
+			continue
+		}
+		hunks = append(hunks, [2]int{start, end})
+	}
+	return hunks
+}
+
+var wordRe = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+
+// wordDiff returns old and new lines with words changed between them highlighted
+func wordDiff(oldLine, newLine string, style *diffStyle) (string, string) {
+	oldWords, newWords := wordRe.FindAllString(oldLine, -1), wordRe.FindAllString(newLine, -1)
+	var oldSb, newSb strings.Builder
+	// consecutive words of the same kind are colored together
+	write := func(sb *strings.Builder, c *color.Color, words []string) {
+		if len(words) > 0 {
+			sb.WriteString(c.Sprint(strings.Join(words, "")))
+		}
+	}
+	var oldRun, newRun []string
+	var oldChanged, newChanged bool
+	flush := func() {
+		if oldChanged {
+			write(&oldSb, style.removedWord, oldRun)
+		} else {
+			write(&oldSb, style.removed, oldRun)
+		}
+		if newChanged {
+			write(&newSb, style.addedWord, newRun)
+		} else {
+			write(&newSb, style.added, newRun)
+		}
+		oldRun, newRun = nil, nil
+	}
+	for _, op := range diffSequences(oldWords, newWords) {
+		switch op.kind {
+		case ' ':
+			if oldChanged || newChanged {
+				flush()
+				oldChanged, newChanged = false, false
+			}
+			oldRun = append(oldRun, oldWords[op.a])
+			newRun = append(newRun, newWords[op.b])
+		case '-':
+			if !oldChanged {
+				flush()
+				oldChanged, newChanged = true, true
+			}
+			oldRun = append(oldRun, oldWords[op.a])
+		case '+':
+			if !newChanged {
+				flush()
+				oldChanged, newChanged = true, true
+			}
+			newRun = append(newRun, newWords[op.b])
+		}
+	}
+	flush()
+	return oldSb.String(), newSb.String()
+}
+
+// renderChanges writes removed and added lines, lines replacing each other are diffed word by word
+func renderChanges(sb *strings.Builder, removed, added []string, style *diffStyle, colored bool) {
+	paired := 0
+	if colored {
+		paired = min(len(removed), len(added))
+	}
+	oldLines, newLines := make([]string, len(removed)), make([]string, len(added))
+	for i := range removed {
+		oldLines[i] = style.removed.Sprint("-" + removed[i])
+	}
+	for i := range added {
+		newLines[i] = style.added.Sprint("+" + added[i])
+	}
+	for i := 0; i < paired; i++ {
+		oldLine, newLine := wordDiff(removed[i], added[i], style)
+		oldLines[i] = style.removed.Sprint("-") + oldLine
+		newLines[i] = style.added.Sprint("+") + newLine
+	}
+	for _, line := range append(oldLines, newLines...) {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+}
+
+// RenderDiff returns unified diff turning text1 named name1 into text2 named name2,
+// empty string is returned if texts are equal
+func RenderDiff(name1, name2, text1, text2 string, opts DiffOptions) string {
+	if text1 == text2 {
+		return ""
+	}
+	style := newDiffStyle(opts.Color)
+	lines1, lines2 := splitLines(text1), splitLines(text2)
+	ops := diffSequences(lines1, lines2)
+	var sb strings.Builder
+	sb.WriteString(style.header.Sprintf("--- %s", name1) + "\n")
+	sb.WriteString(style.header.Sprintf("+++ %s", name2) + "\n")
+	for _, hunk := range diffHunks(ops, opts.Context) {
+		hunkOps := ops[hunk[0]:hunk[1]]
+		var countA, countB int
+		for _, op := range hunkOps {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		sb.WriteString(style.hunk.Sprintf("@@ -%d,%d +%d,%d @@", hunkOps[0].a+1, countA, hunkOps[0].b+1, countB) + "\n")
+		var removed, added []string
+		for _, op := range hunkOps {
+			switch op.kind {
+			case '-':
+				if len(added) > 0 {
+					renderChanges(&sb, removed, added, style, opts.Color)
+					removed, added = nil, nil
+				}
+				removed = append(removed, lines1[op.a])
+			case '+':
+				added = append(added, lines2[op.b])
+			default:
+				renderChanges(&sb, removed, added, style, opts.Color)
+				removed, added = nil, nil
+				sb.WriteString(" " + lines1[op.a] + "\n")
+			}
+		}
+		renderChanges(&sb, removed, added, style, opts.Color)
+	}
+	return sb.String()
+}
+
+// RenderJSONDiff returns multi-line representation of differences as FormatJSONDiff does,
+// with colors removed and added values are highlighted and changed values are diffed word by word
+func RenderJSONDiff(diffs []JSONDiff, opts DiffOptions) string {
+	if !opts.Color {
+		return FormatJSONDiff(diffs)
+	}
+	style := newDiffStyle(true)
+	var sb strings.Builder
+	for _, d := range diffs {
+		switch d.Kind {
+		case DiffAdded:
+			sb.WriteString(style.added.Sprint(d.String()))
+		case DiffRemoved:
+			sb.WriteString(style.removed.Sprint(d.String()))
+		default:
+			oldValue, newValue := wordDiff(JSONPathValueString(d.Old), JSONPathValueString(d.New), style)
+			sb.WriteString(fmt.Sprintf("~ %s: %s -> %s", d.Path, oldValue, newValue))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -15,6 +15,9 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// installerImageName is name of raw installer image generated from docker image of EVE
+const installerImageName = "installer.raw"
+
 // EVEDescription provides information about EVE to download
 type EVEDescription struct {
 	ConfigPath  string
@@ -59,27 +62,83 @@ func DownloadEveInstaller(eve EVEDescription, outputFile string) (err error) {
 	if err != nil {
 		return err
 	}
-	fileName, err := genEVEInstallerImage(image, filepath.Dir(outputFile), eve.ConfigPath)
+	if err := PullImage(image); err != nil {
+		return fmt.Errorf("ImagePull (%s): %s", image, err)
+	}
+	key, err := eveCacheKey(eve, image, eveCacheInstaller, eve.ConfigPath, "")
+	if err != nil {
+		return fmt.Errorf("eveCacheKey: %w", err)
+	}
+	dir, err := cachedEveDir(key, func(dir string) error {
+		_, err := genEVEInstallerImage(image, dir, eve.ConfigPath)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("genEVEImage: %s", err)
 	}
-	if err = CopyFile(fileName, outputFile); err != nil {
+	if err = CopyFile(filepath.Join(dir, installerImageName), outputFile); err != nil {
 		return fmt.Errorf("cannot copy image %s", err)
 	}
 	return nil
 }
 
+// uefiCacheDir returns entry of cache with uefi extracted from EVE-OS image
+func uefiCacheDir(eve EVEDescription) (string, error) {
+	image, err := eve.Image()
+	if err != nil {
+		return "", err
+	}
+	if err := PullImage(image); err != nil {
+		return "", fmt.Errorf("ImagePull (%s): %s", image, err)
+	}
+	key, err := eveCacheKey(eve, image, eveCacheUEFI, "", "")
+	if err != nil {
+		return "", fmt.Errorf("eveCacheKey: %w", err)
+	}
+	dir, err := cachedEveDir(key, func(dir string) error {
+		return ExtractFromImage(image, dir, "/bits/firmware")
+	})
+	if err != nil {
+		return "", fmt.Errorf("ExtractFromImage: %w", err)
+	}
+	return dir, nil
+}
+
 // DownloadUEFI downloads and extracts uefi from EVE-OS image
 func DownloadUEFI(eve EVEDescription, outputDir string) (err error) {
-	image, err := eve.Image()
+	dir, err := uefiCacheDir(eve)
 	if err != nil {
 		return err
 	}
-	if err := PullImage(image); err != nil {
-		return fmt.Errorf("ImagePull (%s): %s", image, err)
+	return copyCachedFiles(dir, outputDir)
+}
+
+// VerifyUEFI checks that firmware files exist and the ones inside outputDir
+// match uefi extracted from EVE-OS image by DownloadUEFI
+func VerifyUEFI(eve EVEDescription, outputDir string, firmware []string) error {
+	dir, err := uefiCacheDir(eve)
+	if err != nil {
+		return err
 	}
-	if err := ExtractFromImage(image, outputDir, "/bits/firmware"); err != nil {
-		return fmt.Errorf("ExtractFromImage: %w", err)
+	if outputDir, err = filepath.Abs(outputDir); err != nil {
+		return err
+	}
+	for _, f := range firmware {
+		if _, err := os.Stat(f); err != nil {
+			return fmt.Errorf("firmware %s: %w", f, err)
+		}
+		relPath, err := filepath.Rel(outputDir, f)
+		if err != nil || !filepath.IsLocal(relPath) {
+			log.Warnf("firmware %s is not extracted from EVE image, it is not verified", f)
+			continue
+		}
+		extracted := filepath.Join(dir, relPath)
+		if _, err := os.Stat(extracted); err != nil {
+			return fmt.Errorf("firmware %s is not found in EVE image: %w", relPath, err)
+		}
+		if sum, expected := SHA256SUM(f), SHA256SUM(extracted); sum != expected {
+			return fmt.Errorf("firmware %s differs from one in EVE image (SHA256 %s, expected %s)", f, sum, expected)
+		}
 	}
 	return nil
 }
@@ -106,10 +165,18 @@ func DownloadEveLive(eve EVEDescription, outputFile string) (err error) {
 	if eve.Format == "gcp" || eve.Format == "vdi" || eve.Format == "parallels" {
 		size = eve.ImageSizeMB
 	}
-	fileName, err := genEVELiveImage(image, filepath.Dir(outputFile), eve.Format, eve.Platform, eve.ConfigPath, size)
+	key, err := eveCacheKey(eve, image, eveCacheLive, eve.ConfigPath, fmt.Sprintf("%s %s %d", eve.Format, eve.Platform, size))
+	if err != nil {
+		return fmt.Errorf("eveCacheKey: %w", err)
+	}
+	dir, err := cachedEveDir(key, func(dir string) error {
+		_, err := genEVELiveImage(image, dir, eve.Format, eve.Platform, eve.ConfigPath, size)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("genEVEImage: %s", err)
 	}
+	fileName := liveImageName(dir, eve.Format)
 	if eve.Format == "parallels" {
 		dirForParallels := strings.TrimRight(outputFile, filepath.Ext(outputFile))
 		_ = os.Mkdir(dirForParallels, 0777)
@@ -157,7 +224,7 @@ func genEVEInstallerImage(image, outputDir string, configDir string) (fileName s
 	if configDir != "" {
 		volumeMap = map[string]string{"/in": configDir, "/out": outputDir}
 	}
-	fileName = filepath.Join(outputDir, "installer.raw")
+	fileName = filepath.Join(outputDir, installerImageName)
 	dockerCommand := "-f raw installer_raw"
 	u, err := RunDockerCommand(image, dockerCommand, volumeMap)
 	if err != nil {
@@ -167,16 +234,9 @@ func genEVEInstallerImage(image, outputDir string, configDir string) (fileName s
 	return fileName, nil
 }
 
-// genEVELiveImage downloads EVE live image from docker to outputDir with configDir (if defined)
-func genEVELiveImage(image, outputDir string, format string, platform string, configDir string, size int) (fileName string, err error) {
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", err
-	}
-	volumeMap := map[string]string{"/out": outputDir}
-	if configDir != "" {
-		volumeMap = map[string]string{"/in": configDir, "/out": outputDir}
-	}
-	fileName = filepath.Join(outputDir, "live.raw")
+// liveImageName returns name of live image of format generated into outputDir
+func liveImageName(outputDir string, format string) string {
+	fileName := filepath.Join(outputDir, "live.raw")
 	if format == "qcow2" {
 		fileName = fileName + "." + format
 	}
@@ -189,6 +249,19 @@ func genEVELiveImage(image, outputDir string, format string, platform string, co
 	if format == "parallels" {
 		fileName = fileName + ".parallels"
 	}
+	return fileName
+}
+
+// genEVELiveImage downloads EVE live image from docker to outputDir with configDir (if defined)
+func genEVELiveImage(image, outputDir string, format string, platform string, configDir string, size int) (fileName string, err error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+	volumeMap := map[string]string{"/out": outputDir}
+	if configDir != "" {
+		volumeMap = map[string]string{"/in": configDir, "/out": outputDir}
+	}
+	fileName = liveImageName(outputDir, format)
 	dockerCommand := fmt.Sprintf("-f %s live %d", format, size)
 	if size == 0 {
 		dockerCommand = fmt.Sprintf("-f %s live", format)
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	dryRun       bool
+	dryRunOutput io.Writer = os.Stdout
+)
+
+// SetDryRun enables mode in which commands and containers are printed
+// instead of being run
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// IsDryRun returns true if dry-run mode is enabled
+func IsDryRun() bool {
+	return dryRun
+}
+
+// SetDryRunOutput sets where actions skipped in dry-run mode are printed (stdout by default)
+func SetDryRunOutput(w io.Writer) {
+	dryRunOutput = w
+}
+
+// DryRunf prints action skipped in dry-run mode
+func DryRunf(format string, args ...interface{}) {
+	fmt.Fprintf(dryRunOutput, "[dry-run] "+format+"\n", args...)
+}
+
+// dryRunCommand prints command skipped in dry-run mode
+func dryRunCommand(name string, args []string) {
+	DryRunf("run: %s %s", name, strings.Join(args, " "))
+}
+
+// dryRunContainer prints container skipped in dry-run mode
+func dryRunContainer(containerName, imageName string, portMap, volumeMap map[string]string, command, envs []string) {
+	var ports, volumes []string
+	for intport, extport := range portMap {
+		ports = append(ports, fmt.Sprintf("%s->%s", extport, intport))
+	}
+	for dst, src := range volumeMap {
+		if src == "" {
+			src = "<generated volume>"
+		}
+		volumes = append(volumes, fmt.Sprintf("%s:%s", src, dst))
+	}
+	sort.Strings(ports)
+	sort.Strings(volumes)
+	description := fmt.Sprintf("run container %s from image %s", containerName, imageName)
+	for _, field := range []struct{ name, value string }{
+		{"ports", strings.Join(ports, ", ")},
+		{"volumes", strings.Join(volumes, ", ")},
+		{"environment", strings.Join(envs, " ")},
+		{"command", maskSecrets(command)},
+	} {
+		if field.value != "" {
+			description += fmt.Sprintf("\n\t%s: %s", field.name, field.value)
+		}
+	}
+	DryRunf("%s", description)
+}
+
+// userInfoRe matches credentials in URL
+var userInfoRe = regexp.MustCompile(`://[^/@\s]+@`)
+
+// maskSecrets returns command with passwords hidden
+func maskSecrets(command []string) string {
+	masked := make([]string, len(command))
+	for i, arg := range command {
+		if i > 0 && command[i-1] == "--requirepass" {
+			arg = "***"
+		}
+		masked[i] = userInfoRe.ReplaceAllString(arg, "://***@")
+	}
+	return strings.Join(masked, " ")
+}
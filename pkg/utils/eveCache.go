@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	log "github.com/sirupsen/logrus"
+)
+
+// Kinds of files of EVE generated from its docker image and saved in cache
+const (
+	eveCacheLive      = "live"
+	eveCacheInstaller = "installer"
+	eveCacheUEFI      = "uefi"
+)
+
+// eveCacheSums is file with SHA256 of files of cache entry
+const eveCacheSums = "SHA256SUMS"
+
+// Entries are generated in temporary directories with eveCacheTmpPrefix, which
+// are locked with file with eveCacheLockSuffix next to them while generated.
+// Temporary directories younger than eveCacheTmpGrace are never pruned to not
+// remove directory created but not yet locked.
+const (
+	eveCacheTmpPrefix  = ".tmp-"
+	eveCacheLockSuffix = ".lock"
+	eveCacheTmpGrace   = time.Hour
+)
+
+// EveCacheEntry is directory inside cache of EVE images
+type EveCacheEntry struct {
+	Name     string
+	Dir      string
+	Size     int64
+	LastUsed time.Time
+}
+
+// EveCacheDir returns directory of cache of images of EVE
+func EveCacheDir() (string, error) {
+	edenDir, err := DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultEveCacheDir), nil
+}
+
+// eveCacheKey returns name of cache entry of kind for eve: version of EVE to be
+// readable and hash of content of docker image (its ID), content of configDir
+// (if defined) and options used to generate files
+func eveCacheKey(eve EVEDescription, image, kind, configDir, options string) (string, error) {
+	version, err := eve.Version()
+	if err != nil {
+		return "", err
+	}
+	imageID, err := ImageID(image)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, strings.Join([]string{kind, imageID, options}, "\n"))
+	if configDir != "" {
+		configHash, err := SHA256SUMAll(configDir)
+		if err != nil {
+			return "", fmt.Errorf("cannot calculate hash of %s: %w", configDir, err)
+		}
+		_, _ = io.WriteString(hash, "\n"+configHash)
+	}
+	return fmt.Sprintf("%s-%s-%s", version, kind, hex.EncodeToString(hash.Sum(nil))[:16]), nil
+}
+
+// cachedEveDir returns directory of cache entry with key, on cache miss it is
+// filled by gen, so the entry is either complete or absent
+func cachedEveDir(key string, gen func(dir string) error) (string, error) {
+	cacheDir, err := EveCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir, key)
+	if _, err := os.Stat(dir); err == nil {
+		if err := verifyCacheSums(dir); err != nil {
+			return "", err
+		}
+		log.Infof("Use cached EVE files: %s", dir)
+		now := time.Now()
+		if err := os.Chtimes(dir, now, now); err != nil {
+			log.Warnf("cannot update time of usage of %s: %s", dir, err)
+		}
+		return dir, nil
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+	tmpDir, err := os.MkdirTemp(cacheDir, eveCacheTmpPrefix+key)
+	if err != nil {
+		return "", err
+	}
+	unlock, err := lockEveCacheTmp(tmpDir)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", err
+	}
+	defer unlock()
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		return "", err
+	}
+	if err := gen(tmpDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", err
+	}
+	if err := writeCacheSums(tmpDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		// the same entry may be saved by setup of another context
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return dir, nil
+		}
+		return "", err
+	}
+	log.Infof("EVE files saved into cache: %s", dir)
+	return dir, nil
+}
+
+// lockEveCacheTmp locks temporary directory of cache entry, so it is not pruned while generated
+func lockEveCacheTmp(tmpDir string) (unlock func(), err error) {
+	lockPath := tmpDir + eveCacheLockSuffix
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	unlockFile, err := LockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot lock %s: %w", lockPath, err)
+	}
+	return func() {
+		_ = os.Remove(lockPath)
+		unlockFile()
+		file.Close()
+	}, nil
+}
+
+// removeEveCacheTmp removes temporary directory of cache entry if it is not
+// generated by another process, removed is false if it is
+func removeEveCacheTmp(tmpDir string) (removed bool, err error) {
+	lockPath := tmpDir + eveCacheLockSuffix
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	unlock, ok, err := TryLockFile(file)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer unlock()
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return false, err
+	}
+	// lock file may be removed by process finished generation
+	if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// cacheSums returns SHA256 of files of cache entry by their paths relative to dir
+func cacheSums(dir string) (map[string]string, error) {
+	sums := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil || relPath == eveCacheSums {
+			return err
+		}
+		sums[relPath] = SHA256SUM(path)
+		return nil
+	})
+	return sums, err
+}
+
+// writeCacheSums saves SHA256 of files of cache entry into it
+func writeCacheSums(dir string) error {
+	sums, err := cacheSums(dir)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	for relPath, sum := range sums {
+		lines = append(lines, fmt.Sprintf("%s  %s\n", sum, relPath))
+	}
+	sort.Strings(lines)
+	return os.WriteFile(filepath.Join(dir, eveCacheSums), []byte(strings.Join(lines, "")), 0644)
+}
+
+// verifyCacheSums checks that files of cache entry are not changed since they
+// were generated, sums are saved for entries created without them
+func verifyCacheSums(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, eveCacheSums))
+	if os.IsNotExist(err) {
+		return writeCacheSums(dir)
+	}
+	if err != nil {
+		return err
+	}
+	sums, err := cacheSums(dir)
+	if err != nil {
+		return err
+	}
+	expected := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			expected[fields[1]] = fields[0]
+		}
+	}
+	for relPath, sum := range expected {
+		if sums[relPath] != sum {
+			return fmt.Errorf("file %s of cached EVE files %s is corrupted (SHA256 %s, expected %s), "+
+				"remove it with 'eden utils eve-cache prune --all'", relPath, dir, sums[relPath], sum)
+		}
+	}
+	return nil
+}
+
+// copyCachedFiles copies files of cache entry into outputDir
+func copyCachedFiles(dir, outputDir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil || relPath == eveCacheSums {
+			return err
+		}
+		return CopyFile(path, filepath.Join(outputDir, relPath))
+	})
+}
+
+// ListEveCache returns entries of cache of images of EVE sorted by time of usage
+func ListEveCache() ([]*EveCacheEntry, error) {
+	cacheDir, err := EveCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	items, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []*EveCacheEntry
+	for _, item := range items {
+		if strings.HasSuffix(item.Name(), eveCacheLockSuffix) {
+			continue
+		}
+		info, err := item.Info()
+		if err != nil {
+			return nil, err
+		}
+		entry := &EveCacheEntry{
+			Name:     item.Name(),
+			Dir:      filepath.Join(cacheDir, item.Name()),
+			LastUsed: info.ModTime(),
+		}
+		err = filepath.Walk(entry.Dir, func(_ string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				entry.Size += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// PruneEveCache removes entries of cache of images of EVE not used for
+// olderThan (all of them if olderThan is 0) and returns removed ones.
+// Entries being generated are kept.
+func PruneEveCache(olderThan time.Duration) ([]*EveCacheEntry, error) {
+	entries, err := ListEveCache()
+	if err != nil {
+		return nil, err
+	}
+	var removed []*EveCacheEntry
+	for _, entry := range entries {
+		if olderThan > 0 && time.Since(entry.LastUsed) < olderThan {
+			continue
+		}
+		if strings.HasPrefix(entry.Name, eveCacheTmpPrefix) {
+			if time.Since(entry.LastUsed) < eveCacheTmpGrace {
+				log.Debugf("skip %s: it may be generated now", entry.Dir)
+				continue
+			}
+			ok, err := removeEveCacheTmp(entry.Dir)
+			if err != nil {
+				return removed, fmt.Errorf("cannot remove %s: %w", entry.Dir, err)
+			}
+			if !ok {
+				log.Debugf("skip %s: it is generated now", entry.Dir)
+				continue
+			}
+			removed = append(removed, entry)
+			continue
+		}
+		if err := os.RemoveAll(entry.Dir); err != nil {
+			return removed, fmt.Errorf("cannot remove %s: %w", entry.Dir, err)
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
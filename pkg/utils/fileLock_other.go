@@ -0,0 +1,45 @@
+//go:build !unix
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockFileTimeout limits waiting for lock held by other process
+const lockFileTimeout = time.Minute
+
+// LockFile locks file exclusively for other processes with lock file created next to it,
+// as flock is not available, it blocks until lock is acquired or lockFileTimeout passes
+func LockFile(file *os.File) (unlock func(), err error) {
+	deadline := time.Now().Add(lockFileTimeout)
+	for {
+		unlock, ok, err := TryLockFile(file)
+		if err != nil || ok {
+			return unlock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s.lock is held for more than %s, remove it if no eden is running", file.Name(), lockFileTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// TryLockFile locks file exclusively for other processes with lock file created next to it
+// if it does not exist, ok is false if lock is held by another process
+func TryLockFile(file *os.File) (unlock func(), ok bool, err error) {
+	lockPath := file.Name() + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	lock.Close()
+	return func() {
+		_ = os.Remove(lockPath)
+	}, true, nil
+}
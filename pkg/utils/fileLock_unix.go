@@ -0,0 +1,33 @@
+//go:build unix
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// LockFile locks file exclusively for other processes, it blocks until lock is acquired
+func LockFile(file *os.File) (unlock func(), err error) {
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	}, nil
+}
+
+// TryLockFile locks file exclusively for other processes if it is not locked already,
+// ok is false if lock is held by another process
+func TryLockFile(file *os.File) (unlock func(), ok bool, err error) {
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	}, true, nil
+}
@@ -0,0 +1,399 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+)
+
+// InstallerConfig defines files of EVE config partition overridden in installer
+type InstallerConfig struct {
+	// Server is FQDN and port of controller
+	Server string
+	// RootCert is root certificate of controller
+	RootCert string
+	// DeviceCert and DeviceKey are certificate and key of device (DevID)
+	// used instead of onboarding
+	DeviceCert string
+	DeviceKey  string
+	// NetConf is file with DevicePortConfig saved as override.json
+	NetConf string
+	// Port is interface of device for management
+	Port string
+	// StaticIP is address with prefix length of Port, DHCP is used if empty
+	StaticIP   string
+	Gateway    string
+	DNSServers []string
+	// WPASupplicant is wpa_supplicant.conf with WiFi networks for wlan0
+	WPASupplicant string
+}
+
+// WifiNetwork is WiFi network defined in wpa_supplicant.conf
+type WifiNetwork struct {
+	SSID     string
+	Password string
+	Identity string
+}
+
+// ParseWPASupplicant returns networks defined in network blocks of
+// wpa_supplicant.conf, passphrases must be quoted as EVE derives PSK itself
+func ParseWPASupplicant(file string) ([]WifiNetwork, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var networks []WifiNetwork
+	var current *WifiNetwork
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "network=") {
+			current = &WifiNetwork{}
+			continue
+		}
+		if text == "}" {
+			if current == nil || current.SSID == "" {
+				return nil, fmt.Errorf("%s:%d: network without ssid", file, line)
+			}
+			networks = append(networks, *current)
+			current = nil
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if current == nil || !ok {
+			continue
+		}
+		quoted := strings.HasPrefix(value, `"`)
+		if quoted {
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid value of %s: %w", file, line, key, err)
+			}
+		}
+		switch key {
+		case "ssid":
+			current.SSID = value
+		case "psk", "password":
+			if !quoted {
+				return nil, fmt.Errorf("%s:%d: only quoted passphrase is supported in %s", file, line, key)
+			}
+			current.Password = value
+		case "identity":
+			current.Identity = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, fmt.Errorf("%s: network block is not closed", file)
+	}
+	return networks, nil
+}
+
+type dpcWifi struct {
+	KeyScheme int
+	SSID      string
+	Identity  string `json:",omitempty"`
+	Password  string
+}
+
+type dpcWireless struct {
+	WType int
+	Wifi  []dpcWifi
+}
+
+type dpcPort struct {
+	Dhcp        int
+	Free        bool
+	IfName      string
+	Name        string
+	IsMgmt      bool
+	AddrSubnet  string       `json:",omitempty"`
+	Gateway     string       `json:",omitempty"`
+	DNSServers  []string     `json:",omitempty"`
+	WirelessCfg *dpcWireless `json:",omitempty"`
+}
+
+type devicePortConfig struct {
+	Version int
+	Ports   []dpcPort
+}
+
+// DevicePortConfig types of EVE
+const (
+	dpcDhcpStatic    = 1
+	dpcDhcpClient    = 4
+	dpcWirelessWifi  = 2
+	dpcKeySchemePSK  = 1
+	dpcKeySchemeEAP  = 2
+	dpcWirelessPort  = "wlan0"
+	dpcManagementTag = "Management"
+)
+
+// InstallerPortConfig returns DevicePortConfig (override.json) with management
+// port configured with static IP (or DHCP) and wlan0 connected to WiFi networks
+func InstallerPortConfig(conf InstallerConfig, networks []WifiNetwork) ([]byte, error) {
+	port := dpcPort{
+		Dhcp:   dpcDhcpClient,
+		Free:   true,
+		IfName: conf.Port,
+		Name:   dpcManagementTag,
+		IsMgmt: true,
+	}
+	if conf.StaticIP != "" {
+		if _, _, err := net.ParseCIDR(conf.StaticIP); err != nil {
+			return nil, fmt.Errorf("static IP must be in CIDR notation: %w", err)
+		}
+		if conf.Gateway != "" && net.ParseIP(conf.Gateway) == nil {
+			return nil, fmt.Errorf("invalid gateway: %s", conf.Gateway)
+		}
+		for _, dns := range conf.DNSServers {
+			if net.ParseIP(dns) == nil {
+				return nil, fmt.Errorf("invalid DNS server: %s", dns)
+			}
+		}
+		port.Dhcp = dpcDhcpStatic
+		port.AddrSubnet = conf.StaticIP
+		port.Gateway = conf.Gateway
+		port.DNSServers = conf.DNSServers
+	}
+	dpc := devicePortConfig{Version: 1}
+	if len(networks) > 0 {
+		wireless := &dpcWireless{WType: dpcWirelessWifi}
+		for _, network := range networks {
+			keyScheme := dpcKeySchemePSK
+			if network.Identity != "" {
+				keyScheme = dpcKeySchemeEAP
+			}
+			wireless.Wifi = append(wireless.Wifi, dpcWifi{
+				KeyScheme: keyScheme,
+				SSID:      network.SSID,
+				Identity:  network.Identity,
+				Password:  network.Password,
+			})
+		}
+		wifiPort := dpcPort{
+			Dhcp:        dpcDhcpClient,
+			Free:        true,
+			IfName:      dpcWirelessPort,
+			Name:        dpcManagementTag + "Wifi",
+			IsMgmt:      true,
+			WirelessCfg: wireless,
+		}
+		if conf.Port == dpcWirelessPort {
+			wifiPort.Name = port.Name
+			wifiPort.Dhcp, wifiPort.AddrSubnet, wifiPort.Gateway, wifiPort.DNSServers =
+				port.Dhcp, port.AddrSubnet, port.Gateway, port.DNSServers
+			dpc.Ports = append(dpc.Ports, wifiPort)
+		} else {
+			dpc.Ports = append(dpc.Ports, port, wifiPort)
+		}
+	} else {
+		dpc.Ports = append(dpc.Ports, port)
+	}
+	return json.MarshalIndent(dpc, "", "\t")
+}
+
+// PrepareInstallerConfig copies EVE config from srcDir into dstDir and applies
+// overrides of conf on top of it
+func PrepareInstallerConfig(srcDir, dstDir string, conf InstallerConfig) error {
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+	if srcDir != "" {
+		if err := CopyFolder(srcDir, dstDir); err != nil {
+			return fmt.Errorf("cannot copy config from %s: %w", srcDir, err)
+		}
+	}
+	if conf.Server != "" {
+		if err := os.WriteFile(filepath.Join(dstDir, "server"), []byte(conf.Server), 0644); err != nil {
+			return err
+		}
+	}
+	if (conf.DeviceCert == "") != (conf.DeviceKey == "") {
+		return fmt.Errorf("both certificate and key of device must be defined")
+	}
+	for _, file := range []struct{ src, dst string }{
+		{conf.RootCert, "root-certificate.pem"},
+		{conf.DeviceCert, "device.cert.pem"},
+		{conf.DeviceKey, "device.key.pem"},
+	} {
+		if file.src == "" {
+			continue
+		}
+		if err := CopyFile(file.src, filepath.Join(dstDir, file.dst)); err != nil {
+			return fmt.Errorf("cannot copy %s: %w", file.src, err)
+		}
+	}
+	var portConfig []byte
+	var err error
+	switch {
+	case conf.StaticIP != "" || conf.WPASupplicant != "":
+		var networks []WifiNetwork
+		if conf.WPASupplicant != "" {
+			if networks, err = ParseWPASupplicant(conf.WPASupplicant); err != nil {
+				return err
+			}
+		}
+		if portConfig, err = InstallerPortConfig(conf, networks); err != nil {
+			return err
+		}
+	case conf.NetConf != "":
+		if portConfig, err = os.ReadFile(conf.NetConf); err != nil {
+			return err
+		}
+	}
+	if portConfig != nil {
+		if err := os.MkdirAll(filepath.Join(dstDir, "DevicePortConfig"), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dstDir, "DevicePortConfig", "override.json"), portConfig, 0644)
+	}
+	return nil
+}
+
+// BlockDevice is whole disk to write image into
+type BlockDevice struct {
+	// Path is path of device in /dev
+	Path string
+	// Name is name of device in /sys/block
+	Name      string
+	Model     string
+	Size      uint64
+	Removable bool
+}
+
+// String returns description of device
+func (d *BlockDevice) String() string {
+	removable := "not removable"
+	if d.Removable {
+		removable = "removable"
+	}
+	return fmt.Sprintf("%s (%s, %s, %s)", d.Path, strings.TrimSpace(d.Model), humanize.IBytes(d.Size), removable)
+}
+
+// sysBlockDir is directory of block devices in sysfs
+const sysBlockDir = "/sys/block"
+
+// readSysBlock returns trimmed content of file of block device in sysfs
+func readSysBlock(elem ...string) string {
+	data, err := os.ReadFile(filepath.Join(append([]string{sysBlockDir}, elem...)...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// InspectBlockDevice returns whole disk of device refusing partitions, mounted
+// devices and devices used by other ones (e.g. LVM, LUKS or RAID)
+func InspectBlockDevice(device string) (*BlockDevice, error) {
+	info, err := os.Stat(device)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeDevice == 0 {
+		return nil, fmt.Errorf("%s is not a device", device)
+	}
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(resolved)
+	if _, err := os.Stat(filepath.Join(sysBlockDir, name)); err != nil {
+		return nil, fmt.Errorf("%s is not a whole disk, partitions are not supported", device)
+	}
+	dev := &BlockDevice{
+		Path:      resolved,
+		Name:      name,
+		Model:     readSysBlock(name, "device", "model"),
+		Removable: readSysBlock(name, "removable") == "1",
+	}
+	if sectors, err := strconv.ParseUint(readSysBlock(name, "size"), 10, 64); err == nil {
+		dev.Size = sectors * 512
+	}
+	// names of disk and its partitions
+	names := []string{name}
+	entries, err := os.ReadDir(filepath.Join(sysBlockDir, name))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), name) && readSysBlock(name, entry.Name(), "partition") != "" {
+			names = append(names, entry.Name())
+		}
+	}
+	for _, n := range names {
+		holdersDir := filepath.Join(sysBlockDir, name, "holders")
+		if n != name {
+			holdersDir = filepath.Join(sysBlockDir, name, n, "holders")
+		}
+		holders, err := os.ReadDir(holdersDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if len(holders) > 0 {
+			return nil, fmt.Errorf("%s is used by %s (e.g. LVM, LUKS or RAID), release it first", n, holders[0].Name())
+		}
+	}
+	for _, file := range []string{"/proc/mounts", "/proc/swaps"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/") {
+				continue
+			}
+			mounted := fields[0]
+			if r, err := filepath.EvalSymlinks(mounted); err == nil {
+				mounted = r
+			}
+			for _, n := range names {
+				if filepath.Base(mounted) == n {
+					return nil, fmt.Errorf("%s is in use (%s), unmount it first", fields[0], fields[1])
+				}
+			}
+		}
+	}
+	return dev, nil
+}
+
+// WriteImageToDevice writes image into block device (e.g. USB stick) refusing
+// to write into device in use, see InspectBlockDevice
+func WriteImageToDevice(image, device string) error {
+	if _, err := InspectBlockDevice(device); err != nil {
+		return err
+	}
+	src, err := os.Open(image)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(device, os.O_WRONLY|os.O_SYNC, 0)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	log.Infof("writing %s into %s", image, device)
+	counter := &writeCounter{step: 10 * 1024 * 1024, message: "Writing..."}
+	buf := make([]byte, 4*1024*1024)
+	if _, err := io.CopyBuffer(dst, io.TeeReader(src, counter), buf); err != nil {
+		return fmt.Errorf("cannot write %s: %w", device, err)
+	}
+	fmt.Println()
+	return dst.Close()
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// ArtifactDigests are digests of artifacts of EVE verified during setup
+type ArtifactDigests struct {
+	Image             string            `yaml:"image"`
+	ImageDigest       string            `yaml:"image-digest"`
+	SignatureVerified bool              `yaml:"signature-verified"`
+	Files             map[string]string `yaml:"files"`
+}
+
+// localImageDigests returns digests of manifests image was pulled with
+func localImageDigests(image string) ([]string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image name %s: %w", image, err)
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("client.NewClientWithOpts: %w", err)
+	}
+	inspect, _, err := cli.ImageInspectWithRaw(context.Background(), image)
+	if err != nil {
+		return nil, fmt.Errorf("ImageInspectWithRaw: %w", err)
+	}
+	var digests []string
+	for _, repoDigest := range inspect.RepoDigests {
+		digestRef, err := name.NewDigest(repoDigest)
+		if err != nil || digestRef.Context().Name() != ref.Context().Name() {
+			continue
+		}
+		digests = append(digests, digestRef.DigestStr())
+	}
+	return digests, nil
+}
+
+// VerifyImage checks that docker image (already pulled) has digest published in
+// registry for its tag and expected digest (if not empty), its signature is
+// verified with cosign if cosignKey is not empty. It returns digest of image,
+// which is empty for not verified images built locally.
+func VerifyImage(image, expected, cosignKey string) (string, error) {
+	localDigests, err := localImageDigests(image)
+	if err != nil {
+		return "", err
+	}
+	if len(localDigests) == 0 {
+		if expected != "" || cosignKey != "" {
+			return "", fmt.Errorf("image %s was not pulled from registry, cannot verify it", image)
+		}
+		log.Warnf("image %s was not pulled from registry, its integrity is not verified", image)
+		return "", nil
+	}
+	digest := localDigests[0]
+	remoteDigest, err := crane.Digest(image)
+	if err != nil {
+		return "", fmt.Errorf("cannot get digest of %s from registry to verify it: %w", image, err)
+	}
+	if !slices.Contains(localDigests, remoteDigest) {
+		// tag may point to newer image than the one pulled before
+		log.Warnf("image %s differs from published one, pull it again", image)
+		if err := PullImageUpdate(image); err != nil {
+			return "", fmt.Errorf("ImagePull (%s): %w", image, err)
+		}
+		if localDigests, err = localImageDigests(image); err != nil {
+			return "", err
+		}
+	}
+	if !slices.Contains(localDigests, remoteDigest) {
+		return "", fmt.Errorf("digest of image %s %v differs from published one %s, "+
+			"remove it with 'docker rmi %s' and run setup again", image, localDigests, remoteDigest, image)
+	}
+	digest = remoteDigest
+	if expected != "" {
+		if !slices.Contains(localDigests, expected) {
+			return "", fmt.Errorf("digest of image %s %v differs from expected one %s", image, localDigests, expected)
+		}
+		digest = expected
+	}
+	if cosignKey != "" {
+		imageRef, err := name.ParseReference(image)
+		if err != nil {
+			return "", err
+		}
+		ref := fmt.Sprintf("%s@%s", imageRef.Context().Name(), digest)
+		if _, stderr, err := RunCommandAndWait("cosign", "verify", "--key", cosignKey, ref); err != nil {
+			return "", fmt.Errorf("cannot verify signature of %s with %s: %w (%s)", ref, cosignKey, err, stderr)
+		}
+		log.Infof("signature of %s verified", ref)
+	}
+	log.Infof("image %s verified, digest %s", image, digest)
+	return digest, nil
+}
+
+// SaveArtifactDigests calculates SHA256 of files and saves them together with
+// digests of image into file
+func SaveArtifactDigests(file string, digests *ArtifactDigests, files ...string) error {
+	if digests.Files == nil {
+		digests.Files = make(map[string]string)
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil {
+			return err
+		}
+		digests.Files[f] = SHA256SUM(f)
+	}
+	data, err := yaml.Marshal(digests)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DiffKind is a kind of difference found by DiffJSON
+type DiffKind int
+
+const (
+	// DiffChanged means that value exists in both documents but differs
+	DiffChanged DiffKind = iota
+	// DiffAdded means that value exists only in the new document
+	DiffAdded
+	// DiffRemoved means that value exists only in the old document
+	DiffRemoved
+)
+
+// JSONDiff describes one difference between two JSON documents
+type JSONDiff struct {
+	Path string
+	Kind DiffKind
+	Old  interface{}
+	New  interface{}
+}
+
+// String returns one-line representation of difference
+func (d JSONDiff) String() string {
+	switch d.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", d.Path, JSONPathValueString(d.New))
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", d.Path, JSONPathValueString(d.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", d.Path, JSONPathValueString(d.Old), JSONPathValueString(d.New))
+	}
+}
+
+// DiffJSON compares two decoded JSON values (as returned by json.Unmarshal into interface{})
+// and returns the list of differences with JSONPath-like paths. Objects are compared field by field,
+// arrays are compared element by element. Fields for which 'skip' returns true are not compared.
+func DiffJSON(a, b interface{}, skip func(field string) bool) []JSONDiff {
+	var result []JSONDiff
+	diffJSON("", a, b, skip, &result)
+	return result
+}
+
+// DiffJSONBytes decodes two JSON documents and compares them with DiffJSON
+func DiffJSONBytes(a, b []byte, skip func(field string) bool) ([]JSONDiff, error) {
+	var aVal, bVal interface{}
+	if err := json.Unmarshal(a, &aVal); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &bVal); err != nil {
+		return nil, err
+	}
+	return DiffJSON(aVal, bVal, skip), nil
+}
+
+func diffJSON(path string, a, b interface{}, skip func(field string) bool, result *[]JSONDiff) {
+	switch aVal := a.(type) {
+	case map[string]interface{}:
+		bVal, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]struct{})
+		for k := range aVal {
+			keys[k] = struct{}{}
+		}
+		for k := range bVal {
+			keys[k] = struct{}{}
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for k := range keys {
+			if skip != nil && skip(k) {
+				continue
+			}
+			sortedKeys = append(sortedKeys, k)
+		}
+		sort.Strings(sortedKeys)
+		for _, k := range sortedKeys {
+			fieldPath := path + "." + k
+			av, aOk := aVal[k]
+			bv, bOk := bVal[k]
+			switch {
+			case aOk && bOk:
+				diffJSON(fieldPath, av, bv, skip, result)
+			case aOk:
+				*result = append(*result, JSONDiff{Path: fieldPath, Kind: DiffRemoved, Old: av})
+			default:
+				*result = append(*result, JSONDiff{Path: fieldPath, Kind: DiffAdded, New: bv})
+			}
+		}
+		return
+	case []interface{}:
+		bVal, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(aVal) || i < len(bVal); i++ {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i < len(aVal) && i < len(bVal):
+				diffJSON(elemPath, aVal[i], bVal[i], skip, result)
+			case i < len(aVal):
+				*result = append(*result, JSONDiff{Path: elemPath, Kind: DiffRemoved, Old: aVal[i]})
+			default:
+				*result = append(*result, JSONDiff{Path: elemPath, Kind: DiffAdded, New: bVal[i]})
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		if path == "" {
+			path = "."
+		}
+		*result = append(*result, JSONDiff{Path: path, Kind: DiffChanged, Old: a, New: b})
+	}
+}
+
+// FormatJSONDiff returns multi-line representation of differences
+func FormatJSONDiff(diffs []JSONDiff) string {
+	var sb strings.Builder
+	for _, d := range diffs {
+		sb.WriteString(d.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type jsonPathElement struct {
+	field   string
+	index   int
+	isIdx   bool
+	iterate bool // [] or [*]
+}
+
+func parseJSONPath(path string) ([]jsonPathElement, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	var elements []jsonPathElement
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("malformed index in %q", path)
+			}
+			inner := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+			if inner == "" || inner == "*" {
+				elements = append(elements, jsonPathElement{isIdx: true, iterate: true})
+				continue
+			}
+			if unquoted := strings.Trim(inner, `'"`); unquoted != inner {
+				elements = append(elements, jsonPathElement{field: unquoted})
+				continue
+			}
+			ind, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("malformed index %q: %w", inner, err)
+			}
+			elements = append(elements, jsonPathElement{index: ind, isIdx: true})
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			elements = append(elements, jsonPathElement{field: path[:end]})
+			path = path[end:]
+		}
+	}
+	return elements, nil
+}
+
+func jsonPathField(obj map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := obj[field]; ok {
+		return v, true
+	}
+	// protojson and yaml names differ in case from names users type,
+	// so fall back to case-insensitive match
+	for k, v := range obj {
+		if strings.EqualFold(k, field) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// SelectJSONPath resolves JSONPath-like expression against decoded JSON value
+// (maps, slices and scalars as returned by json.Unmarshal into interface{}).
+// Supported syntax is a subset of JSONPath: optional leading '$', '.field',
+// '["field"]', '[N]' for index (negative counts from the end) and '[]' or '[*]'
+// to iterate over all elements of array or all values of object.
+// Missing fields produce no results rather than error.
+func SelectJSONPath(value interface{}, path string) ([]interface{}, error) {
+	elements, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	current := []interface{}{value}
+	for _, el := range elements {
+		var next []interface{}
+		for _, val := range current {
+			switch v := val.(type) {
+			case map[string]interface{}:
+				if !el.isIdx {
+					if found, ok := jsonPathField(v, el.field); ok {
+						next = append(next, found)
+					}
+				} else if el.iterate {
+					for _, item := range v {
+						next = append(next, item)
+					}
+				}
+			case []interface{}:
+				if !el.isIdx {
+					// allow to omit [] for arrays of objects
+					for _, item := range v {
+						if obj, ok := item.(map[string]interface{}); ok {
+							if found, ok := jsonPathField(obj, el.field); ok {
+								next = append(next, found)
+							}
+						}
+					}
+					continue
+				}
+				if el.iterate {
+					next = append(next, v...)
+					continue
+				}
+				ind := el.index
+				if ind < 0 {
+					ind += len(v)
+				}
+				if ind >= 0 && ind < len(v) {
+					next = append(next, v[ind])
+				}
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// ValidateJSONPath returns error if path is not valid expression for SelectJSONPath
+func ValidateJSONPath(path string) error {
+	_, err := parseJSONPath(path)
+	return err
+}
+
+// SelectJSONPathFromBytes decodes JSON from data and resolves path against it
+func SelectJSONPathFromBytes(data []byte, path string) ([]interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return SelectJSONPath(value, path)
+}
+
+// JSONPathValueString returns representation of value selected by SelectJSONPath:
+// strings are returned as is, other values are encoded as compact JSON
+func JSONPathValueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// LogFormatText is human-readable format of logs of eden
+	LogFormatText = "text"
+	// LogFormatJSON is format of logs of eden with JSON object per line, the same
+	// as one used for logs of EVE, so they can be ingested by the same pipelines
+	LogFormatJSON = "json"
+)
+
+const edenModule = "github.com/lf-edge/eden/"
+
+// levels of slog for levels of logrus without equivalent in slog
+const (
+	slogLevelTrace = slog.LevelDebug - 4
+	slogLevelFatal = slog.LevelError + 4
+	slogLevelPanic = slog.LevelError + 8
+)
+
+// SetUpLogFormat sets format of logs of logrus and levels of subsystems of eden.
+// Subsystem is a package of eden relative to pkg directory (e.g. controller/adam),
+// level of subsystem applies to its subpackages too, level applies to the rest of them.
+func SetUpLogFormat(format string, level log.Level, levels map[string]string) error {
+	var formatter log.Formatter
+	switch format {
+	case "", LogFormatText:
+		formatter = &log.TextFormatter{}
+	case LogFormatJSON:
+		formatter = &slogFormatter{}
+	default:
+		return fmt.Errorf("unsupported format of logs %q, use %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+	subsystems := make(map[string]log.Level, len(levels))
+	maxLevel := level
+	for subsystem, l := range levels {
+		lvl, err := log.ParseLevel(l)
+		if err != nil {
+			return fmt.Errorf("level of subsystem %s: %w", subsystem, err)
+		}
+		subsystems[strings.Trim(subsystem, "/")] = lvl
+		if lvl > maxLevel {
+			maxLevel = lvl
+		}
+	}
+	if len(subsystems) == 0 {
+		log.SetLevel(level)
+		log.SetFormatter(formatter)
+		return nil
+	}
+	// logger passes entries of the most verbose subsystem, formatter drops
+	// entries of other subsystems above their levels, callers are found by
+	// formatter itself, so reporting of caller stays as configured
+	log.SetLevel(maxLevel)
+	log.SetFormatter(&subsystemFormatter{formatter: formatter, level: level, levels: subsystems})
+	return nil
+}
+
+// LevelEnabled checks if entries with level are logged for subsystem of caller. It must be
+// used instead of log.IsLevelEnabled, which reports the most verbose level of subsystems
+func LevelEnabled(level log.Level) bool {
+	f, ok := log.StandardLogger().Formatter.(*subsystemFormatter)
+	if !ok {
+		return log.IsLevelEnabled(level)
+	}
+	pcs := make([]uintptr, 1)
+	// skip runtime.Callers and LevelEnabled
+	if runtime.Callers(2, pcs) == 0 {
+		return level <= f.level
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	return level <= f.levelOf(subsystemOf(&frame))
+}
+
+// logrusPackage is prefix of functions of logrus
+const logrusPackage = "github.com/sirupsen/logrus."
+
+// entryCaller returns frame of function which logged entry being formatted
+func entryCaller() *runtime.Frame {
+	pcs := make([]uintptr, 16)
+	// skip runtime.Callers, entryCaller and Format
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, logrusPackage) {
+			return &frame
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// subsystemOf returns subsystem of eden for function of caller
+func subsystemOf(caller *runtime.Frame) string {
+	if caller == nil {
+		return ""
+	}
+	function := caller.Function
+	pkg := function
+	if slash := strings.LastIndexByte(function, '/'); slash >= 0 {
+		if dot := strings.IndexByte(function[slash:], '.'); dot >= 0 {
+			pkg = function[:slash+dot]
+		}
+	} else if dot := strings.IndexByte(function, '.'); dot >= 0 {
+		pkg = function[:dot]
+	}
+	pkg = strings.TrimPrefix(pkg, edenModule)
+	return strings.TrimPrefix(pkg, "pkg/")
+}
+
+// subsystemFormatter drops entries with levels above the level of their subsystem
+// and formats the rest of them with formatter
+type subsystemFormatter struct {
+	formatter log.Formatter
+	level     log.Level
+	levels    map[string]log.Level
+}
+
+// levelOf returns level of subsystem with the longest matching prefix
+func (f *subsystemFormatter) levelOf(subsystem string) log.Level {
+	for {
+		if lvl, ok := f.levels[subsystem]; ok {
+			return lvl
+		}
+		slash := strings.LastIndexByte(subsystem, '/')
+		if slash < 0 {
+			return f.level
+		}
+		subsystem = subsystem[:slash]
+	}
+}
+
+// Format implements log.Formatter
+func (f *subsystemFormatter) Format(entry *log.Entry) ([]byte, error) {
+	caller := entry.Caller
+	if caller == nil {
+		caller = entryCaller()
+	}
+	subsystem := subsystemOf(caller)
+	if entry.Level > f.levelOf(subsystem) {
+		return nil, nil
+	}
+	e := *entry
+	if subsystem != "" {
+		e.Data = make(log.Fields, len(entry.Data)+1)
+		for k, v := range entry.Data {
+			e.Data[k] = v
+		}
+		e.Data["subsystem"] = subsystem
+	}
+	return f.formatter.Format(&e)
+}
+
+// slogFormatter formats entries of logrus with JSON handler of log/slog
+type slogFormatter struct{}
+
+func slogLevel(level log.Level) slog.Level {
+	switch level {
+	case log.PanicLevel:
+		return slogLevelPanic
+	case log.FatalLevel:
+		return slogLevelFatal
+	case log.ErrorLevel:
+		return slog.LevelError
+	case log.WarnLevel:
+		return slog.LevelWarn
+	case log.InfoLevel:
+		return slog.LevelInfo
+	case log.DebugLevel:
+		return slog.LevelDebug
+	default:
+		return slogLevelTrace
+	}
+}
+
+// replaceLevel names levels of slog as levels of logrus
+func replaceLevel(_ []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey {
+		return a
+	}
+	switch a.Value.Any().(slog.Level) {
+	case slogLevelTrace:
+		a.Value = slog.StringValue("TRACE")
+	case slogLevelFatal:
+		a.Value = slog.StringValue("FATAL")
+	case slogLevelPanic:
+		a.Value = slog.StringValue("PANIC")
+	}
+	return a
+}
+
+// Format implements log.Formatter
+func (f *slogFormatter) Format(entry *log.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level:       slogLevelTrace,
+		ReplaceAttr: replaceLevel,
+	})
+	record := slog.NewRecord(entry.Time, slogLevel(entry.Level), entry.Message, 0)
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := entry.Data[k]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		record.AddAttrs(slog.Any(k, v))
+	}
+	if err := handler.Handle(context.Background(), record); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
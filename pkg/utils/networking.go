@@ -220,6 +220,7 @@ func RepeatableAttempt(client *http.Client, req *http.Request) (response *http.R
 			}
 			wrongCode = true
 			buf, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
 			if err != nil {
 				log.Debugf("bad status: %s", resp.Status)
 			} else {
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixWriter writes lines into underlying writer with prefix added to every line.
+// Writers created with the same mutex do not mix parts of lines with each other.
+type PrefixWriter struct {
+	w      io.Writer
+	prefix []byte
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+// NewPrefixWriter creates PrefixWriter, mu is used to synchronize writes into w
+func NewPrefixWriter(w io.Writer, prefix string, mu *sync.Mutex) *PrefixWriter {
+	if mu == nil {
+		mu = &sync.Mutex{}
+	}
+	return &PrefixWriter{w: w, prefix: []byte(prefix), mu: mu}
+}
+
+// Write buffers p and writes all complete lines from buffer
+func (pw *PrefixWriter) Write(p []byte) (int, error) {
+	pw.buf.Write(p)
+	for {
+		ind := bytes.IndexByte(pw.buf.Bytes(), '\n')
+		if ind == -1 {
+			return len(p), nil
+		}
+		if err := pw.writeLine(pw.buf.Next(ind + 1)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes the rest of buffer not terminated with new line
+func (pw *PrefixWriter) Flush() error {
+	if pw.buf.Len() == 0 {
+		return nil
+	}
+	line := append([]byte{}, pw.buf.Next(pw.buf.Len())...)
+	return pw.writeLine(append(line, '\n'))
+}
+
+func (pw *PrefixWriter) writeLine(line []byte) error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if _, err := pw.w.Write(pw.prefix); err != nil {
+		return err
+	}
+	_, err := pw.w.Write(line)
+	return err
+}
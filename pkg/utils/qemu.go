@@ -2,24 +2,147 @@ package utils
 
 import (
 	"bytes"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"text/template"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 )
 
-//QemuSettings struct for pass into template
+// VMDisk is additional disk of VM
+type VMDisk struct {
+	File   string
+	Format string
+	SizeMB int
+	// Bus is bus to attach disk to (virtio, sata, nvme, scsi or usb),
+	// default interface of hypervisor is used if empty
+	Bus string
+}
+
+// Supported buses of additional disks
+const (
+	DiskBusVirtio = "virtio"
+	DiskBusSATA   = "sata"
+	DiskBusNVMe   = "nvme"
+	DiskBusSCSI   = "scsi"
+	DiskBusUSB    = "usb"
+)
+
+var qemuDiskDrivers = map[string]string{
+	DiskBusVirtio: "virtio-blk-pci",
+	DiskBusSATA:   "ide-hd",
+	DiskBusNVMe:   "nvme",
+	DiskBusSCSI:   "scsi-hd",
+	DiskBusUSB:    "usb-storage",
+}
+
+// IsValidDiskBus returns true if disks can be attached to bus
+func IsValidDiskBus(bus string) bool {
+	_, ok := qemuDiskDrivers[bus]
+	return ok || bus == ""
+}
+
+// QemuDriver returns QEMU device driver of disk for its bus
+func (disk VMDisk) QemuDriver() string {
+	return qemuDiskDrivers[disk.Bus]
+}
+
+// Modes of TCG emulation of VMs
+const (
+	// QemuTCGAuto emulates VM with TCG if its architecture differs from the host one
+	QemuTCGAuto = "auto"
+	// QemuTCGOn always emulates VM with TCG
+	QemuTCGOn = "on"
+	// QemuTCGOff never emulates VM with TCG
+	QemuTCGOff = "off"
+)
+
+// QemuTCG returns true if VM of architecture arch (the host one if empty) should run
+// with tuned TCG emulation instead of acceleration in mode (auto if empty).
+// Acceleration is not available for VM of another architecture than the host one,
+// so error is returned for it if TCG is off.
+func QemuTCG(mode, arch string) (bool, error) {
+	arch = strings.ToLower(arch)
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	crossArch := arch != runtime.GOARCH
+	switch strings.ToLower(mode) {
+	case QemuTCGAuto, "":
+		return crossArch, nil
+	case QemuTCGOn:
+		return true, nil
+	case QemuTCGOff:
+		if crossArch {
+			return false, fmt.Errorf("%s VM cannot be accelerated on %s host, set eve.tcg to %s or %s",
+				arch, runtime.GOARCH, QemuTCGAuto, QemuTCGOn)
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown TCG mode %q (expected %s, %s or %s)",
+			mode, QemuTCGAuto, QemuTCGOn, QemuTCGOff)
+	}
+}
+
+// QemuHVFSupported returns true if QEMU can accelerate VMs with Hypervisor.framework
+// of macOS host (Apple Silicon or Intel with VT-x)
+func QemuHVFSupported() bool {
+	if runtime.GOOS != "darwin" {
+		return false
+	}
+	out, _, err := RunCommandAndWait("sysctl", "-n", "kern.hv_support")
+	return err == nil && strings.TrimSpace(out) == "1"
+}
+
+// QemuHostFwdAddr returns address to bind ports forwarded from host into VM on qemuOS.
+// On macOS ports are bound to loopback only, as listening on all interfaces makes
+// the application firewall ask to allow incoming connections to QEMU on every start.
+func QemuHostFwdAddr(qemuOS string) string {
+	if strings.ToLower(qemuOS) == "darwin" {
+		return defaults.DefaultEVEHost
+	}
+	return ""
+}
+
+// QemuFirmware returns UEFI firmware files of EVE of architecture arch
+// (the host one if empty) inside of imageDist
+func QemuFirmware(imageDist, arch string) []string {
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	if strings.ToLower(arch) == "amd64" {
+		return []string{
+			filepath.Join(imageDist, "eve", "firmware", "OVMF_CODE.fd"),
+			filepath.Join(imageDist, "eve", "firmware", "OVMF_VARS.fd")}
+	}
+	return []string{filepath.Join(imageDist, "eve", "firmware", "OVMF.fd")}
+}
+
+// QemuSettings struct for pass into template
 type QemuSettings struct {
 	DTBDrive   string
 	Firmware   []string
-	Disks      []string
+	Disks      []VMDisk
 	MemoryMB   int
 	CPUs       int
 	USBSerials int
 	USBTablets int
 }
 
-//GenerateQemuConfig provides string representation of Qemu config
-//for QemuSettings object
+// HasSCSIDisks returns true if SCSI controller is required for disks
+func (settings QemuSettings) HasSCSIDisks() bool {
+	for _, disk := range settings.Disks {
+		if disk.Bus == DiskBusSCSI {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateQemuConfig provides string representation of Qemu config
+// for QemuSettings object
 func (settings QemuSettings) GenerateQemuConfig() ([]byte, error) {
 	t := template.New("t")
 	t, err := t.Parse(defaults.DefaultQemuTemplate)
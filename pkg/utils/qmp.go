@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// QemuStats are counters of VM collected from QEMU with QMP
+type QemuStats struct {
+	DiskReadBytes  uint64
+	DiskWriteBytes uint64
+	// Exits is number of exits of vCPUs into hypervisor, zero if QEMU
+	// does not provide statistics of accelerator (e.g. with TCG)
+	Exits uint64
+}
+
+// QemuStatsSocket returns QMP socket of VM with pidFile reserved for collection of statistics,
+// another QMP socket of VM is used by logger of events
+func QemuStatsSocket(pidFile string) string {
+	prefix := strings.TrimSuffix(filepath.Base(pidFile), filepath.Ext(pidFile))
+	return filepath.Join(filepath.Dir(pidFile), fmt.Sprintf("%s-qmp-stats.sock", prefix))
+}
+
+// qmpConn is connection to QMP socket of QEMU
+type qmpConn struct {
+	conn    net.Conn
+	decoder *json.Decoder
+}
+
+// qmpResponse is response to command, asynchronous events have only Event set
+type qmpResponse struct {
+	Return json.RawMessage `json:"return"`
+	Error  *struct {
+		Class string `json:"class"`
+		Desc  string `json:"desc"`
+	} `json:"error"`
+	Event string `json:"event"`
+}
+
+// execute runs QMP command with arguments and decodes its result into result if not nil
+func (q *qmpConn) execute(command string, arguments interface{}, result interface{}) error {
+	request := map[string]interface{}{"execute": command}
+	if arguments != nil {
+		request["arguments"] = arguments
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	if _, err := q.conn.Write(data); err != nil {
+		return fmt.Errorf("cannot send %s: %w", command, err)
+	}
+	for {
+		var response qmpResponse
+		if err := q.decoder.Decode(&response); err != nil {
+			return fmt.Errorf("cannot read response to %s: %w", command, err)
+		}
+		if response.Event != "" {
+			continue
+		}
+		if response.Error != nil {
+			return fmt.Errorf("%s: %s: %s", command, response.Error.Class, response.Error.Desc)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(response.Return, result)
+	}
+}
+
+// QemuStatsQMP collects counters of VM from QMP socket sockFile
+func QemuStatsQMP(sockFile string) (*QemuStats, error) {
+	conn, err := net.DialTimeout("unix", sockFile, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return nil, err
+	}
+	q := &qmpConn{conn: conn, decoder: json.NewDecoder(conn)}
+	var greeting map[string]interface{}
+	if err := q.decoder.Decode(&greeting); err != nil {
+		return nil, fmt.Errorf("cannot read QMP greeting: %w", err)
+	}
+	if err := q.execute("qmp_capabilities", nil, nil); err != nil {
+		return nil, err
+	}
+	var stats QemuStats
+	var blockStats []struct {
+		Stats struct {
+			ReadBytes  uint64 `json:"rd_bytes"`
+			WriteBytes uint64 `json:"wr_bytes"`
+		} `json:"stats"`
+	}
+	if err := q.execute("query-blockstats", nil, &blockStats); err != nil {
+		return nil, err
+	}
+	for _, device := range blockStats {
+		stats.DiskReadBytes += device.Stats.ReadBytes
+		stats.DiskWriteBytes += device.Stats.WriteBytes
+	}
+	// statistics of accelerator are available only with KVM in QEMU 7.1 or newer
+	var vcpuStats []struct {
+		Stats []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"stats"`
+	}
+	err = q.execute("query-stats", map[string]interface{}{
+		"target":    "vcpu",
+		"providers": []map[string]interface{}{{"provider": "kvm", "names": []string{"exits"}}},
+	}, &vcpuStats)
+	if err == nil {
+		for _, vcpu := range vcpuStats {
+			for _, stat := range vcpu.Stats {
+				var value uint64
+				if stat.Name == "exits" && json.Unmarshal(stat.Value, &value) == nil {
+					stats.Exits += value
+				}
+			}
+		}
+	}
+	return &stats, nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"regexp"
+	"sync"
+)
+
+// compiledRegexps caches regular expressions compiled by MatchRegexp
+var compiledRegexps sync.Map
+
+// MatchRegexp reports whether b contains any match of pattern. Compiled patterns
+// are cached, so queries matched against every message of stream compile them once.
+func MatchRegexp(pattern string, b []byte) (bool, error) {
+	if re, ok := compiledRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp).Match(b), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	compiledRegexps.Store(pattern, re)
+	return re.Match(b), nil
+}
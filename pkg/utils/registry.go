@@ -19,6 +19,10 @@ import (
 
 // LoadRegistry push image into registry
 func LoadRegistry(image, remote string) (string, error) {
+	if dryRun {
+		DryRunf("push image %s into registry %s", image, remote)
+		return "", nil
+	}
 	localImage, err := HasImage(image)
 	if err != nil {
 		return "", fmt.Errorf("error checking for local image %s: %v", image, err)
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// RegistryAuth defines credentials of registry to pull images of apps from:
+// user with password read from environment variable or file, docker credential
+// helper (e.g. ecr-login or gcr for short-lived tokens of ECR or GCR) or docker
+// config file. Secrets are never stored in config of eden.
+type RegistryAuth struct {
+	Registry     string `mapstructure:"registry"`
+	User         string `mapstructure:"user"`
+	PasswordEnv  string `mapstructure:"password-env"`
+	PasswordFile string `mapstructure:"password-file"`
+	Helper       string `mapstructure:"helper"`
+	DockerConfig string `mapstructure:"docker-config"`
+}
+
+// RegistryCredentials are credentials to access registry: user and password
+// or identity token, which is OAuth2 refresh token exchanged for bearer token
+// by the token service of registry
+type RegistryCredentials struct {
+	User          string
+	Password      string
+	IdentityToken string
+}
+
+// helperTokenUser is user returned by docker credential helpers with identity token as secret
+const helperTokenUser = "<token>"
+
+// Credentials returns credentials defined by auth for registry
+func (auth RegistryAuth) Credentials(registry string) (*RegistryCredentials, error) {
+	switch {
+	case auth.User != "" || auth.PasswordEnv != "" || auth.PasswordFile != "":
+		password, err := auth.password()
+		if err != nil {
+			return nil, err
+		}
+		return &RegistryCredentials{User: auth.User, Password: password}, nil
+	case auth.Helper != "":
+		return credentialsFromHelper(auth.Helper, registry)
+	case auth.DockerConfig != "":
+		return credentialsFromDockerConfig(auth.DockerConfig, registry)
+	}
+	return nil, fmt.Errorf("no user, helper or docker-config defined for registry %s", auth.Registry)
+}
+
+// password reads password of user from environment variable or file defined by auth
+func (auth RegistryAuth) password() (string, error) {
+	switch {
+	case auth.PasswordEnv != "" && auth.PasswordFile != "":
+		return "", fmt.Errorf("both password-env and password-file defined for registry %s", auth.Registry)
+	case auth.PasswordEnv != "":
+		password, ok := os.LookupEnv(auth.PasswordEnv)
+		if !ok || password == "" {
+			return "", fmt.Errorf("environment variable %s with password of registry %s is not set",
+				auth.PasswordEnv, auth.Registry)
+		}
+		return password, nil
+	case auth.PasswordFile != "":
+		data, err := os.ReadFile(auth.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("cannot read password of registry %s: %w", auth.Registry, err)
+		}
+		password := strings.TrimRight(string(data), "\r\n")
+		if password == "" {
+			return "", fmt.Errorf("file %s with password of registry %s is empty", auth.PasswordFile, auth.Registry)
+		}
+		return password, nil
+	}
+	return "", fmt.Errorf("no password-env or password-file defined for user %s of registry %s", auth.User, auth.Registry)
+}
+
+// credentialsFromHelper runs docker-credential-<helper> to get credentials of registry
+func credentialsFromHelper(helper, registry string) (*RegistryCredentials, error) {
+	program := "docker-credential-" + helper
+	cmd := exec.Command(program, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s get %s: %w (%s)", program, registry, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("%s get %s: %w", program, registry, err)
+	}
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return nil, fmt.Errorf("cannot parse output of %s: %w", program, err)
+	}
+	if creds.Username == helperTokenUser {
+		return &RegistryCredentials{IdentityToken: creds.Secret}, nil
+	}
+	return &RegistryCredentials{User: creds.Username, Password: creds.Secret}, nil
+}
+
+// credentialsFromDockerConfig reads credentials of registry from docker config file
+func credentialsFromDockerConfig(file, registry string) (*RegistryCredentials, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := config.LoadFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load docker config %s: %w", file, err)
+	}
+	cfg.Filename = filepath.Clean(file)
+	authConfig, err := cfg.GetAuthConfig(registry)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get credentials of %s from %s: %w", registry, file, err)
+	}
+	return &RegistryCredentials{
+		User:          authConfig.Username,
+		Password:      authConfig.Password,
+		IdentityToken: authConfig.IdentityToken,
+	}, nil
+}
+
+// FindRegistryAuth returns the first of auths defined for registry with fqdn
+// (docker:// prefix is allowed) or nil if there is no such one
+func FindRegistryAuth(fqdn string, auths []RegistryAuth) *RegistryAuth {
+	registry := NormalizeRegistry(fqdn)
+	for i := range auths {
+		if NormalizeRegistry(auths[i].Registry) == registry {
+			return &auths[i]
+		}
+	}
+	return nil
+}
+
+// GetRegistryCredentials returns credentials of registry with fqdn
+// (docker:// prefix is allowed) defined in auths, credentials of docker
+// are used if auths do not define the registry
+func GetRegistryCredentials(fqdn string, auths []RegistryAuth) (*RegistryCredentials, error) {
+	auth := FindRegistryAuth(fqdn, auths)
+	if auth == nil {
+		return GetDockerCredentials(fqdn)
+	}
+	registry := NormalizeRegistry(fqdn)
+	creds, err := auth.Credentials(registry)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("loaded credentials for %s from config", registry)
+	return creds, nil
+}
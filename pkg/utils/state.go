@@ -3,20 +3,23 @@ package utils
 import (
 	"bytes"
 	"fmt"
+	"github.com/lf-edge/eden/pkg/defaults"
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 	"os"
 	"path/filepath"
 	"text/template"
 )
 
 var defaultEnvState = `#generated by eden
+state-format: {{ .StateFormat }}
 qemu-config: {{ .QEMUConfig }}
 eve-dir: {{ .EveDir }}
 adam-dir: {{ .AdamDir }}
 eve-config: {{ .DeviceUUID }}
 `
 
-//StateObject is structure to save state in file
+// StateObject is structure to save state in file
 type StateObject struct {
 	EveConfig  string //if empty will not create/overwrite config file
 	EveDir     string
@@ -24,10 +27,13 @@ type StateObject struct {
 	EveUUID    string
 	DeviceUUID string
 	QEMUConfig string
+
+	StateFormat int
 }
 
-//GenerateStateFile generates state in file
+// GenerateStateFile generates state in file
 func GenerateStateFile(dirToSave string, state StateObject) error {
+	state.StateFormat = defaults.StateFormat
 	state.DeviceUUID = filepath.Join(dirToSave, fmt.Sprintf("devUUID-%s.json", state.DeviceUUID))
 	filePath := filepath.Join(dirToSave, fmt.Sprintf("state-%s.yml", state.EveUUID))
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
@@ -76,3 +82,19 @@ func GenerateStateFile(dirToSave string, state StateObject) error {
 	log.Debugf("state updated %s", filePath)
 	return nil
 }
+
+// StateFormatOf returns format of state file of EVE with eveUUID saved in dirToSave,
+// state files saved before the format was versioned have format 0
+func StateFormatOf(dirToSave string, eveUUID string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dirToSave, fmt.Sprintf("state-%s.yml", eveUUID)))
+	if err != nil {
+		return 0, err
+	}
+	var state struct {
+		StateFormat int `yaml:"state-format"`
+	}
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("cannot parse state: %w", err)
+	}
+	return state.StateFormat, nil
+}
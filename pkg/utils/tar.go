@@ -22,6 +22,9 @@ type FileToSave struct {
 // This is to prevent a DoS attack by unpacking a compressed file that is too big to be decompressed.
 const MaxDecompressedContentSize = 1024 * 1024 * 1024 // 1 GB
 
+// MaxDecompressedImageSize is the maximum size of a disk image that can be written to disk after decompression.
+const MaxDecompressedImageSize = 64 * MaxDecompressedContentSize // 64 GB
+
 // CreateTarGz generates tar.gz file in dstFile by putting files and directories described in paths
 func CreateTarGz(dstFile string, paths []FileToSave) error {
 	tarFile, err1 := os.Create(dstFile)
@@ -38,6 +41,10 @@ func CreateTarGz(dstFile string, paths []FileToSave) error {
 			if err != nil {
 				return err
 			}
+			if fi.Mode()&os.ModeSocket != 0 {
+				// sockets (e.g. of running swtpm) cannot be saved
+				return nil
+			}
 			hdr, err := tar.FileInfoHeader(fi, fi.Name())
 			if err != nil {
 				return err
@@ -88,6 +95,14 @@ func resolvePath(curPath string, paths []FileToSave) (string, error) {
 
 // UnpackTarGz observes tar.gz file in srcFile and extracts files and directories described in paths
 func UnpackTarGz(srcFile string, paths []FileToSave) error {
+	return UnpackTarGzWithLimit(srcFile, paths, MaxDecompressedContentSize)
+}
+
+// UnpackTarGzWithLimit is UnpackTarGz with limit of size of every extracted file
+func UnpackTarGzWithLimit(srcFile string, paths []FileToSave, limit int64) error {
+	if limit <= 0 {
+		return fmt.Errorf("limit of decompressed content size must be positive")
+	}
 	f, err := os.Open(srcFile)
 	if err != nil {
 		return err
@@ -117,7 +132,7 @@ func UnpackTarGz(srcFile string, paths []FileToSave) error {
 					continue
 				}
 			}
-			if err := os.Mkdir(newPath, os.FileMode(header.Mode)); err != nil {
+			if err := os.MkdirAll(newPath, os.FileMode(header.Mode)); err != nil {
 				return err
 			}
 		case tar.TypeReg:
@@ -126,12 +141,11 @@ func UnpackTarGz(srcFile string, paths []FileToSave) error {
 				return err
 			}
 			// Limit the size of the extracted file to prevent decompression bomb
-			limitReader := io.LimitReader(tarReader, MaxDecompressedContentSize+1)
-			bytesCopied, err := io.Copy(outFile, limitReader)
+			bytesCopied, err := io.Copy(outFile, io.LimitReader(tarReader, limit+1))
 			if err != nil {
 				return err
 			}
-			if bytesCopied > MaxDecompressedContentSize {
+			if bytesCopied > limit {
 				return errors.New("maximum decompressed content size exceeded")
 			}
 			outFile.Close()
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// SleepContext pauses for duration d or until ctx is done, it returns error of ctx in the latter case
+func SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -27,6 +27,10 @@ type Endpoints struct {
 	// NetbootServers : HTTP/TFTP servers providing artifacts needed to boot EVE OS
 	// over a network (using netboot/PXE + iPXE).
 	NetbootServers []NetbootServer `json:"netbootServers,omitempty"`
+	// RegistryServers : unreliable image datastores/registries, forwarding requests
+	// to an upstream datastore or registry with configurable failures injected.
+	// Can be used to test download retries and verification of images in EVE.
+	RegistryServers []RegistryServer `json:"registryServers,omitempty"`
 }
 
 // GetAll : returns all endpoints as one list.
@@ -54,6 +58,9 @@ func (eps Endpoints) GetAll() (all []Endpoint) {
 	for _, netBootSrv := range eps.NetbootServers {
 		all = append(all, netBootSrv.Endpoint)
 	}
+	for _, registrySrv := range eps.RegistryServers {
+		all = append(all, registrySrv.Endpoint)
+	}
 	return all
 }
 
@@ -483,6 +490,136 @@ type NetbootArtifact struct {
 	Entrypoint bool `json:"entrypoint"`
 }
 
+// RegistryServer : endpoint emulating an unreliable image datastore or registry.
+// Requests are forwarded to UpstreamURL (e.g. eserver or an OCI registry)
+// and responses are returned with faults injected as configured.
+// Note that redirects returned by the upstream server are passed to the client
+// as they are, i.e. requests following them bypass the registry server.
+type RegistryServer struct {
+	// Endpoint configuration.
+	Endpoint
+	// DNSClientConfig : DNS configuration to be applied for the registry server.
+	DNSClientConfig
+	// HTTPPort : port to listen for HTTP requests.
+	// Zero value can be used to disable HTTP.
+	HTTPPort uint16 `json:"httpPort"`
+	// HTTPSPort : port to listen for HTTPS requests.
+	// Zero value can be used to disable HTTPS.
+	HTTPSPort uint16 `json:"httpsPort"`
+	// CertPEM : Server certificate in the PEM format. Required for HTTPS.
+	CertPEM string `json:"certPEM"`
+	// KeyPEM : Server key in the PEM format. Required for HTTPS.
+	KeyPEM string `json:"keyPEM"`
+	// UpstreamURL : HTTP(s) URL of the datastore or registry to forward requests to.
+	UpstreamURL string `json:"upstreamURL"`
+	// Faults : failures injected into responses.
+	// All faults matching a request are applied.
+	Faults []RegistryFault `json:"faults"`
+}
+
+// ItemCategory
+func (e RegistryServer) ItemCategory() string {
+	return "registry-server"
+}
+
+// ReferencesFromItem
+func (e RegistryServer) ReferencesFromItem() []LogicalLabelRef {
+	refs := e.Endpoint.ReferencesFromItem()
+	for _, dns := range e.PrivateDNS {
+		refs = append(refs, LogicalLabelRef{
+			ItemType:         Endpoint{}.ItemType(),
+			ItemCategory:     DNSServer{}.ItemCategory(),
+			ItemLogicalLabel: dns,
+			// Avoids duplicate DNS servers within the same registry server.
+			RefKey: "registry-server-" + e.LogicalLabel,
+		})
+	}
+	return refs
+}
+
+// RegistryFault : failure injected by RegistryServer into responses.
+type RegistryFault struct {
+	// Type of the fault.
+	Type RegistryFaultType `json:"type"`
+	// PathRegexp : regular expression matched against the URL path of requests,
+	// e.g. "^/v2/.*/blobs/" to only affect layers of OCI images.
+	// Empty value matches all requests.
+	PathRegexp string `json:"pathRegexp"`
+	// Probability : percentage of matching requests to inject the fault into.
+	// Zero value is interpreted as 100 (every matching request).
+	Probability uint8 `json:"probability"`
+	// MaxCount : maximum number of requests to inject the fault into,
+	// e.g. to let a download succeed after a few failed attempts.
+	// Zero value means no limit.
+	MaxCount uint32 `json:"maxCount"`
+	// RateLimit : bandwidth of the response transfer in bytes per second.
+	// Used with RegistryFaultSlow.
+	RateLimit uint64 `json:"rateLimit"`
+	// AfterBytes : number of bytes of the response body transferred before
+	// the connection is reset (with RegistryFaultReset), or offset of the corrupted
+	// byte of the response body (with RegistryFaultCorrupt).
+	AfterBytes uint64 `json:"afterBytes"`
+	// RetryAfter : value of the Retry-After header in seconds.
+	// Used with RegistryFaultThrottle. Zero value omits the header.
+	RetryAfter uint32 `json:"retryAfter"`
+}
+
+// RegistryFaultType : type of failure injected by RegistryServer.
+type RegistryFaultType uint8
+
+const (
+	// RegistryFaultUnspecified : fault type is not specified.
+	RegistryFaultUnspecified RegistryFaultType = iota
+	// RegistryFaultSlow : transfer response body slowly (see RegistryFault.RateLimit).
+	RegistryFaultSlow
+	// RegistryFaultReset : close the connection in the middle of the response body
+	// transfer (see RegistryFault.AfterBytes).
+	RegistryFaultReset
+	// RegistryFaultThrottle : reply with 429 Too Many Requests without forwarding
+	// the request upstream (see RegistryFault.RetryAfter).
+	RegistryFaultThrottle
+	// RegistryFaultCorrupt : flip bits of one byte of the response body
+	// (see RegistryFault.AfterBytes), keeping the length of the content.
+	RegistryFaultCorrupt
+)
+
+// RegistryFaultTypeToString : convert RegistryFaultType to string representation
+// used in JSON.
+var RegistryFaultTypeToString = map[RegistryFaultType]string{
+	RegistryFaultUnspecified: "",
+	RegistryFaultSlow:        "slow",
+	RegistryFaultReset:       "reset",
+	RegistryFaultThrottle:    "throttle",
+	RegistryFaultCorrupt:     "corrupt",
+}
+
+// RegistryFaultTypeToID : get RegistryFaultType from a string representation.
+var RegistryFaultTypeToID = map[string]RegistryFaultType{
+	"":         RegistryFaultUnspecified,
+	"slow":     RegistryFaultSlow,
+	"reset":    RegistryFaultReset,
+	"throttle": RegistryFaultThrottle,
+	"corrupt":  RegistryFaultCorrupt,
+}
+
+// MarshalJSON marshals the enum as a quoted json string.
+func (s RegistryFaultType) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString(`"`)
+	buffer.WriteString(RegistryFaultTypeToString[s])
+	buffer.WriteString(`"`)
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON un-marshals a quoted json string to the enum value.
+func (s *RegistryFaultType) UnmarshalJSON(b []byte) error {
+	var j string
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*s = RegistryFaultTypeToID[j]
+	return nil
+}
+
 // ProxyAction : proxy action.
 type ProxyAction uint8
 
@@ -0,0 +1,34 @@
+package config
+
+import (
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// RegistrySrvConfig : registry server configuration formatted with JSON and passed
+// to registrysrv using the "-c" command line argument.
+type RegistrySrvConfig struct {
+	// ListenIPs : IP addresses to listen on.
+	// Leave empty to listen on all available interfaces instead of just
+	// the interfaces with the given host address.
+	ListenIPs []string `json:"listenIPs"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write registrysrv process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all requests logged.
+	Verbose bool `json:"verbose"`
+	// HTTPPort : port to listen for HTTP requests.
+	// Zero value can be used to disable HTTP.
+	HTTPPort uint16 `json:"httpPort"`
+	// HTTPSPort : port to listen for HTTPS requests.
+	// Zero value can be used to disable HTTPS.
+	HTTPSPort uint16 `json:"httpsPort"`
+	// CertPEM : Server certificate in the PEM format. Required for HTTPS.
+	CertPEM string `json:"certPEM"`
+	// KeyPEM : Server key in the PEM format. Required for HTTPS.
+	KeyPEM string `json:"keyPEM"`
+	// UpstreamURL : URL of the datastore or registry to forward requests to.
+	UpstreamURL string `json:"upstreamURL"`
+	// Faults : failures injected into responses.
+	Faults []sdnapi.RegistryFault `json:"faults"`
+}
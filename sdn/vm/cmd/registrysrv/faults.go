@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// errReset is returned from the response body to abort the transfer mid-stream.
+var errReset = errors.New("connection reset by registry server fault")
+
+// fault : configured failure with the number of times it was injected.
+type fault struct {
+	sdnapi.RegistryFault
+	pathRegexp *regexp.Regexp
+	sync.Mutex
+	count uint32
+}
+
+func newFault(config sdnapi.RegistryFault) (*fault, error) {
+	f := &fault{RegistryFault: config}
+	if config.PathRegexp != "" {
+		var err error
+		f.pathRegexp, err = regexp.Compile(config.PathRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path regexp %q: %w", config.PathRegexp, err)
+		}
+	}
+	return f, nil
+}
+
+// trigger decides if the fault should be injected into the response to the request.
+func (f *fault) trigger(r *http.Request) bool {
+	if f.pathRegexp != nil && !f.pathRegexp.MatchString(r.URL.Path) {
+		return false
+	}
+	f.Lock()
+	defer f.Unlock()
+	if f.MaxCount != 0 && f.count >= f.MaxCount {
+		return false
+	}
+	if f.Probability != 0 && f.Probability < 100 &&
+		rand.Intn(100) >= int(f.Probability) {
+		return false
+	}
+	f.count++
+	return true
+}
+
+// faultyBody wraps response body to inject faults into the transfer.
+type faultyBody struct {
+	io.ReadCloser
+	offset uint64
+	start  time.Time
+	// Zero if transfer is not slowed down.
+	rateLimit uint64
+	// Negative if the fault is not injected.
+	resetAfter int64
+	corruptAt  int64
+}
+
+func newFaultyBody(body io.ReadCloser) *faultyBody {
+	return &faultyBody{
+		ReadCloser: body,
+		start:      time.Now(),
+		resetAfter: -1,
+		corruptAt:  -1,
+	}
+}
+
+// Read from the upstream response body with faults injected.
+func (b *faultyBody) Read(p []byte) (n int, err error) {
+	if b.resetAfter >= 0 {
+		left := uint64(b.resetAfter) - b.offset
+		if left == 0 {
+			return 0, errReset
+		}
+		if uint64(len(p)) > left {
+			p = p[:left]
+		}
+	}
+	if b.rateLimit > 0 {
+		// Read at most 1/10 of the rate limit at once to keep the transfer smooth.
+		chunk := b.rateLimit/10 + 1
+		if uint64(len(p)) > chunk {
+			p = p[:chunk]
+		}
+	}
+	n, err = b.ReadCloser.Read(p)
+	if b.corruptAt >= 0 {
+		if at := uint64(b.corruptAt); at >= b.offset && at < b.offset+uint64(n) {
+			p[at-b.offset] ^= 0xff
+		}
+	}
+	b.offset += uint64(n)
+	if b.rateLimit > 0 {
+		expected := time.Duration(float64(b.offset) / float64(b.rateLimit) * float64(time.Second))
+		if wait := expected - time.Since(b.start); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return n, err
+}
+
+// injectFaults returns a response for the request if it should not be forwarded
+// upstream and a function applying faults to the upstream response otherwise.
+func injectFaults(faults []*fault, r *http.Request) (
+	handled func(w http.ResponseWriter), modify func(resp *http.Response)) {
+	var triggered []*fault
+	for _, f := range faults {
+		if f.trigger(r) {
+			log.Infof("Injecting %s fault into response for %s %s",
+				sdnapi.RegistryFaultTypeToString[f.Type], r.Method, r.URL.Path)
+			triggered = append(triggered, f)
+		}
+	}
+	for _, f := range triggered {
+		if f.Type == sdnapi.RegistryFaultThrottle {
+			retryAfter := f.RetryAfter
+			return func(w http.ResponseWriter) {
+				if retryAfter != 0 {
+					w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+				}
+				http.Error(w, http.StatusText(http.StatusTooManyRequests),
+					http.StatusTooManyRequests)
+			}, nil
+		}
+	}
+	if len(triggered) == 0 {
+		return nil, nil
+	}
+	return nil, func(resp *http.Response) {
+		body := newFaultyBody(resp.Body)
+		for _, f := range triggered {
+			switch f.Type {
+			case sdnapi.RegistryFaultSlow:
+				body.rateLimit = f.RateLimit
+			case sdnapi.RegistryFaultReset:
+				body.resetAfter = int64(f.AfterBytes)
+			case sdnapi.RegistryFaultCorrupt:
+				body.corruptAt = int64(f.AfterBytes)
+			}
+		}
+		resp.Body = body
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/registrysrv/config"
+	log "github.com/sirupsen/logrus"
+)
+
+type modifyResponseKey struct{}
+
+// newHandler returns handler forwarding requests to upstream with faults injected.
+func newHandler(upstream *url.URL, faults []*fault) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			r.SetXForwarded()
+		},
+		// Flush immediately, so that slowed down and aborted transfers
+		// are observed by the client as they happen.
+		FlushInterval: -1,
+		ModifyResponse: func(resp *http.Response) error {
+			modify, _ := resp.Request.Context().Value(modifyResponseKey{}).(func(*http.Response))
+			if modify != nil {
+				modify(resp)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Errorf("Failed to forward request %s %s: %v", r.Method, r.URL, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Debugf("Received request: %+v", r)
+		handled, modify := injectFaults(faults, r)
+		if handled != nil {
+			handled(w)
+			return
+		}
+		if modify != nil {
+			r = r.WithContext(context.WithValue(r.Context(), modifyResponseKey{}, modify))
+		}
+		// Copying of the response body aborted by errReset panics with
+		// http.ErrAbortHandler, which makes the server close the connection
+		// without completing the response.
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+func main() {
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/registrysrv.conf", "registry server config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var srvConfig config.RegistrySrvConfig
+	if err = json.Unmarshal(configBytes, &srvConfig); err != nil {
+		log.Fatalf("failed to unmarshal registry server config: %v", err)
+	}
+
+	// Process registry server config.
+	if srvConfig.LogFile != "" {
+		logFile, err := os.OpenFile(srvConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", srvConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if srvConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	if srvConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(srvConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", srvConfig.PidFile, err)
+		}
+		defer os.Remove(srvConfig.PidFile)
+	}
+
+	upstream, err := url.Parse(srvConfig.UpstreamURL)
+	if err != nil {
+		log.Fatalf("failed to parse upstream URL %s: %v", srvConfig.UpstreamURL, err)
+	}
+	var faults []*fault
+	for _, faultConfig := range srvConfig.Faults {
+		f, err := newFault(faultConfig)
+		if err != nil {
+			log.Fatalf("failed to process fault config: %v", err)
+		}
+		faults = append(faults, f)
+	}
+	handler := newHandler(upstream, faults)
+
+	if srvConfig.HTTPPort != 0 {
+		for _, listenIP := range srvConfig.ListenIPs {
+			srvAddr := net.JoinHostPort(listenIP, fmt.Sprintf("%d", srvConfig.HTTPPort))
+			go func(addr string) {
+				log.Debugf("HTTP server listening on %s", addr)
+				log.Fatalln(http.ListenAndServe(addr, handler))
+			}(srvAddr)
+		}
+	}
+
+	if srvConfig.HTTPSPort != 0 {
+		certFile, err := os.CreateTemp("", "registrysrv-*.cert")
+		if err != nil {
+			log.Fatalf("failed to create temporary file for the certificate: %v", err)
+		}
+		keyFile, err := os.CreateTemp("", "registrysrv-*.key")
+		if err != nil {
+			log.Fatalf("failed to create temporary file for the key: %v", err)
+		}
+		defer func() {
+			if err = os.Remove(certFile.Name()); err != nil {
+				log.Warnf("failed to remove temporary file %s: %v", certFile.Name(), err)
+			}
+			if err = os.Remove(keyFile.Name()); err != nil {
+				log.Warnf("failed to remove temporary file %s: %v", keyFile.Name(), err)
+			}
+		}()
+		if _, err = certFile.WriteString(srvConfig.CertPEM); err != nil {
+			log.Fatalf("failed to write server cert to file %s: %v", certFile.Name(), err)
+		}
+		if _, err = keyFile.WriteString(srvConfig.KeyPEM); err != nil {
+			log.Fatalf("failed to write server key to file %s: %v", keyFile.Name(), err)
+		}
+
+		for _, listenIP := range srvConfig.ListenIPs {
+			srvAddr := net.JoinHostPort(listenIP, fmt.Sprintf("%d", srvConfig.HTTPSPort))
+			go func(addr string) {
+				log.Debugf("HTTPS server listening on %s", addr)
+				log.Fatalln(
+					http.ListenAndServeTLS(addr, certFile.Name(), keyFile.Name(), handler))
+			}(srvAddr)
+		}
+	}
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught termination/interrupt signal: %v, exiting...", sig)
+}
@@ -114,6 +114,9 @@ func (a *agent) updateIntendedState() {
 	for _, httpSrv := range a.netModel.Endpoints.HTTPServers {
 		a.intendedState.PutSubGraph(a.getIntendedHttpSrvEp(httpSrv))
 	}
+	for _, registrySrv := range a.netModel.Endpoints.RegistryServers {
+		a.intendedState.PutSubGraph(a.getIntendedRegistrySrvEp(registrySrv))
+	}
 
 	//nolint:godox
 	// TODO: ntp servers, netboot servers
@@ -1197,6 +1200,28 @@ func (a *agent) getIntendedHttpSrvEp(httpSrv api.HTTPServer) dg.Graph {
 	return intendedCfg
 }
 
+func (a *agent) getIntendedRegistrySrvEp(registrySrv api.RegistryServer) dg.Graph {
+	graphArgs := dg.InitArgs{Name: endpointSGPrefix + registrySrv.LogicalLabel}
+	intendedCfg := dg.New(graphArgs)
+	a.putEpCommonConfig(intendedCfg, registrySrv.Endpoint, &registrySrv.DNSClientConfig)
+	nsName := a.endpointNsName(registrySrv.LogicalLabel)
+	vethName, _, _ := a.endpointVethName(registrySrv.LogicalLabel)
+	epIPs := a.getEndpointAllIPs(registrySrv.Endpoint)
+	intendedCfg.PutItem(configitems.RegistryServer{
+		ServerName:   registrySrv.LogicalLabel,
+		NetNamespace: nsName,
+		VethName:     vethName,
+		ListenIPs:    epIPs,
+		HTTPPort:     registrySrv.HTTPPort,
+		HTTPSPort:    registrySrv.HTTPSPort,
+		CertPEM:      registrySrv.CertPEM,
+		KeyPEM:       registrySrv.KeyPEM,
+		UpstreamURL:  registrySrv.UpstreamURL,
+		Faults:       registrySrv.Faults,
+	}, nil)
+	return intendedCfg
+}
+
 func (a *agent) putEpCommonConfig(graph dg.Graph, ep api.Endpoint, dnsClient *api.DNSClientConfig) {
 	vethName, inIfName, outIfName := a.endpointVethName(ep.LogicalLabel)
 	nsName := a.endpointNsName(ep.LogicalLabel)
@@ -1453,6 +1478,8 @@ func (a *agent) labeledItemToEndpoint(item *labeledItem) api.Endpoint {
 		return item.LabeledItem.(api.TransparentProxy).Endpoint
 	case api.NetbootServer{}.ItemCategory():
 		return item.LabeledItem.(api.NetbootServer).Endpoint
+	case api.RegistryServer{}.ItemCategory():
+		return item.LabeledItem.(api.RegistryServer).Endpoint
 	default:
 		log.Fatalf("Unexpected endpoint category: %s", item.category)
 	}
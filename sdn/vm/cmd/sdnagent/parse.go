@@ -11,7 +11,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/lf-edge/eden/sdn/vm/api"
@@ -69,7 +71,8 @@ func (a *agent) parseNetModel(netModel api.NetworkModel) (parsedModel parsedNetM
 	eps := netModel.Endpoints
 	items := a.slicesToLabeledItems(netModel.Ports, netModel.Bonds, netModel.Bridges,
 		netModel.Networks, eps.DNSServers, eps.NTPServers, eps.NetbootServers,
-		eps.HTTPServers, eps.ExplicitProxies, eps.TransparentProxies, eps.Clients)
+		eps.HTTPServers, eps.ExplicitProxies, eps.TransparentProxies, eps.Clients,
+		eps.RegistryServers)
 	parsedModel.items, err = a.parseLabeledItems(items)
 	if err != nil {
 		return
@@ -462,6 +465,11 @@ func (a *agent) validateEndpoints(netModel *parsedNetModel) (err error) {
 			return
 		}
 	}
+	for _, registrySrv := range netModel.Endpoints.RegistryServers {
+		if err = a.validateRegistryServer(registrySrv); err != nil {
+			return
+		}
+	}
 	for _, netbootSrv := range netModel.Endpoints.NetbootServers {
 		if err = a.validateEndpoint(netbootSrv.Endpoint); err != nil {
 			return
@@ -475,6 +483,56 @@ func (a *agent) validateEndpoints(netModel *parsedNetModel) (err error) {
 	return nil
 }
 
+func (a *agent) validateRegistryServer(registrySrv api.RegistryServer) (err error) {
+	if err = a.validateEndpoint(registrySrv.Endpoint); err != nil {
+		return
+	}
+	if registrySrv.HTTPPort == 0 && registrySrv.HTTPSPort == 0 {
+		return fmt.Errorf("registry server %s without port numbers",
+			registrySrv.LogicalLabel)
+	}
+	if registrySrv.HTTPPort != 0 && registrySrv.HTTPPort == registrySrv.HTTPSPort {
+		return fmt.Errorf("registry server %s with colliding ports",
+			registrySrv.LogicalLabel)
+	}
+	if registrySrv.CertPEM != "" {
+		if err = a.validateCertPEM(registrySrv.CertPEM, registrySrv.KeyPEM, false); err != nil {
+			return
+		}
+	} else if registrySrv.HTTPSPort != 0 {
+		return fmt.Errorf("registry server %s with HTTPS port but without certificate",
+			registrySrv.LogicalLabel)
+	}
+	upstream, err := url.Parse(registrySrv.UpstreamURL)
+	if err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") ||
+		upstream.Host == "" {
+		return fmt.Errorf("registry server %s with invalid upstream URL '%s'",
+			registrySrv.LogicalLabel, registrySrv.UpstreamURL)
+	}
+	for i, fault := range registrySrv.Faults {
+		switch fault.Type {
+		case api.RegistryFaultSlow:
+			if fault.RateLimit == 0 {
+				return fmt.Errorf("registry server %s with slow fault %d without rate limit",
+					registrySrv.LogicalLabel, i)
+			}
+		case api.RegistryFaultReset, api.RegistryFaultThrottle, api.RegistryFaultCorrupt:
+		default:
+			return fmt.Errorf("registry server %s with fault %d of unknown type",
+				registrySrv.LogicalLabel, i)
+		}
+		if fault.Probability > 100 {
+			return fmt.Errorf("registry server %s with fault %d of invalid probability %d",
+				registrySrv.LogicalLabel, i, fault.Probability)
+		}
+		if _, err = regexp.Compile(fault.PathRegexp); err != nil {
+			return fmt.Errorf("registry server %s with fault %d of invalid path regexp: %w",
+				registrySrv.LogicalLabel, i, err)
+		}
+	}
+	return nil
+}
+
 func (a *agent) validateEndpoint(endpoint api.Endpoint) (err error) {
 	if endpoint.IsDualStack() {
 		err = a.validateEndpointIPConfig(endpoint.LogicalLabel, endpoint.DualStack.IPv4,
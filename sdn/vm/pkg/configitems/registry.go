@@ -28,6 +28,7 @@ func RegisterItems(
 		{c: &IptablesChainConfigurator{}, t: IP6tablesChainTypename},
 		{c: &HttpProxyConfigurator{}, t: HTTPProxyTypename},
 		{c: &HttpServerConfigurator{}, t: HTTPServerTypename},
+		{c: &RegistryServerConfigurator{}, t: RegistryServerTypename},
 		{c: &TrafficControlConfigurator{MacLookup: macLookup}, t: TrafficControlTypename},
 		{c: &RadvdConfigurator{}, t: RadvdTypename},
 	}
@@ -0,0 +1,272 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	registrysrvcfg "github.com/lf-edge/eden/sdn/vm/cmd/registrysrv/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	registrySrvBinary  = "/bin/registrysrv"
+	registrySrvConfDir = "/etc/registrysrv"
+	registrySrvRunDir  = "/run/registrysrv"
+
+	registrySrvStartTimeout = 3 * time.Second
+	registrySrvStopTimeout  = 10 * time.Second
+)
+
+// RegistryServer : unreliable image datastore/registry server forwarding requests
+// upstream with faults injected.
+type RegistryServer struct {
+	// ServerName : logical name for the registry server.
+	ServerName string
+	// NetNamespace : network namespace where the server should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the server operates.
+	// (other types of interfaces are currently not supported)
+	// Can be empty (if the server is not associated with any particular interface).
+	VethName string
+	// ListenIPs : IP addresses on which the server should listen.
+	// Can be empty to listen on all available interfaces instead of just
+	// the interfaces with the given host addresses.
+	ListenIPs []net.IP
+	// HTTPPort : port to listen for HTTP requests.
+	// Zero value can be used to disable HTTP.
+	HTTPPort uint16
+	// HTTPSPort : port to listen for HTTPS requests.
+	// Zero value can be used to disable HTTPS.
+	HTTPSPort uint16
+	// CertPEM : Server certificate in the PEM format. Required for HTTPS.
+	CertPEM string
+	// KeyPEM : Server key in the PEM format. Required for HTTPS.
+	KeyPEM string
+	// UpstreamURL : URL of the datastore or registry to forward requests to.
+	UpstreamURL string
+	// Faults : failures injected into responses.
+	Faults []sdnapi.RegistryFault
+}
+
+// Name
+func (s RegistryServer) Name() string {
+	return s.ServerName
+}
+
+// Label
+func (s RegistryServer) Label() string {
+	return s.ServerName + " (registry server)"
+}
+
+// Type
+func (s RegistryServer) Type() string {
+	return RegistryServerTypename
+}
+
+// Equal is a comparison method for two equally-named RegistryServer instances.
+func (s RegistryServer) Equal(other depgraph.Item) bool {
+	s2 := other.(RegistryServer)
+	if len(s.Faults) != len(s2.Faults) {
+		return false
+	}
+	for i := range s.Faults {
+		if s.Faults[i] != s2.Faults[i] {
+			return false
+		}
+	}
+	return s.NetNamespace == s2.NetNamespace &&
+		s.VethName == s2.VethName &&
+		equalIPLists(s.ListenIPs, s2.ListenIPs) &&
+		s.HTTPPort == s2.HTTPPort &&
+		s.HTTPSPort == s2.HTTPSPort &&
+		s.CertPEM == s2.CertPEM &&
+		s.KeyPEM == s2.KeyPEM &&
+		s.UpstreamURL == s2.UpstreamURL
+}
+
+// External returns false.
+func (s RegistryServer) External() bool {
+	return false
+}
+
+// String describes the registry server.
+func (s RegistryServer) String() string {
+	return fmt.Sprintf("registry server: %#+v", s)
+}
+
+// Dependencies lists the (optional) veth and network namespace as dependencies.
+func (s RegistryServer) Dependencies() (deps []depgraph.Dependency) {
+	deps = append(deps, depgraph.Dependency{
+		RequiredItem: depgraph.ItemRef{
+			ItemType: NetNamespaceTypename,
+			ItemName: normNetNsName(s.NetNamespace),
+		},
+		Description: "Network namespace must exist",
+	})
+	if s.VethName != "" {
+		deps = append(deps, depgraph.Dependency{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: s.VethName,
+			},
+			Description: "veth interface must exist",
+		})
+	}
+	return deps
+}
+
+// RegistryServerConfigurator implements Configurator interface for RegistryServer.
+type RegistryServerConfigurator struct{}
+
+// Create starts registrysrv (see sdn/cmd/registrysrv).
+func (c *RegistryServerConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(RegistryServer)
+	if err := c.createRegistrySrvConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startRegistrySrv(config.ServerName, config.NetNamespace)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *RegistryServerConfigurator) createRegistrySrvConfFile(registrySrv RegistryServer) error {
+	if err := ensureDir(registrySrvConfDir); err != nil {
+		return err
+	}
+	serverName := registrySrv.ServerName
+	// Prepare configuration.
+	listenIPs := make([]string, 0, len(registrySrv.ListenIPs))
+	for _, ip := range registrySrv.ListenIPs {
+		listenIPs = append(listenIPs, ip.String())
+	}
+	config := registrysrvcfg.RegistrySrvConfig{
+		ListenIPs:   listenIPs,
+		LogFile:     registrySrvLogFile(serverName),
+		PidFile:     registrySrvPidFile(serverName),
+		Verbose:     true,
+		HTTPPort:    registrySrv.HTTPPort,
+		HTTPSPort:   registrySrv.HTTPSPort,
+		CertPEM:     registrySrv.CertPEM,
+		KeyPEM:      registrySrv.KeyPEM,
+		UpstreamURL: registrySrv.UpstreamURL,
+		Faults:      registrySrv.Faults,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	// Write configuration to file.
+	cfgPath := registrySrvConfigPath(serverName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *RegistryServerConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops registrysrv.
+func (c *RegistryServerConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(RegistryServer)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopRegistrySrv(config.ServerName)
+		if err == nil {
+			// ignore errors from here
+			_ = removeRegistrySrvConfFile(config.ServerName)
+			_ = removeRegistrySrvLogFile(config.ServerName)
+			_ = removeRegistrySrvPidFile(config.ServerName)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *RegistryServerConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func registrySrvConfigPath(srvName string) string {
+	return filepath.Join(registrySrvConfDir, srvName+".conf")
+}
+
+func registrySrvPidFile(srvName string) string {
+	return filepath.Join(registrySrvRunDir, srvName+".pid")
+}
+
+func registrySrvLogFile(srvName string) string {
+	return filepath.Join(registrySrvRunDir, srvName+".log")
+}
+
+func removeRegistrySrvConfFile(srvName string) error {
+	cfgPath := registrySrvConfigPath(srvName)
+	if err := os.Remove(cfgPath); err != nil {
+		err = fmt.Errorf("failed to remove registry server config %s: %w",
+			cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func removeRegistrySrvPidFile(srvName string) error {
+	pidPath := registrySrvPidFile(srvName)
+	if err := os.Remove(pidPath); err != nil {
+		err = fmt.Errorf("failed to remove registry server PID file %s: %w",
+			pidPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func removeRegistrySrvLogFile(srvName string) error {
+	logPath := registrySrvLogFile(srvName)
+	if err := os.Remove(logPath); err != nil {
+		err = fmt.Errorf("failed to remove registry server log file %s: %w",
+			logPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func startRegistrySrv(srvName, netNamespace string) error {
+	if err := ensureDir(registrySrvRunDir); err != nil {
+		return err
+	}
+	cfgPath := registrySrvConfigPath(srvName)
+	cmd := registrySrvBinary
+	args := []string{
+		"-c",
+		cfgPath,
+	}
+	pidFile := registrySrvPidFile(srvName)
+	return startProcess(netNamespace, cmd, args, pidFile, registrySrvStartTimeout, true)
+}
+
+func stopRegistrySrv(srvName string) error {
+	pidFile := registrySrvPidFile(srvName)
+	return stopProcess(pidFile, registrySrvStopTimeout)
+}
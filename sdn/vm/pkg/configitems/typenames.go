@@ -36,6 +36,8 @@ const (
 	HTTPProxyTypename = "HTTP-Proxy"
 	// HTTPServerTypename : typename for HTTP server.
 	HTTPServerTypename = "HTTP-Server"
+	// RegistryServerTypename : typename for unreliable datastore/registry server.
+	RegistryServerTypename = "Registry-Server"
 	// TrafficControlTypename : typename for TC rules applied to physical interface.
 	TrafficControlTypename = "Traffic-Control"
 	// RadvdTypename : typename for radvd - router advertisement daemon for IPv6.
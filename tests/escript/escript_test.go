@@ -5,16 +5,86 @@ import (
 	"flag"
 	log "github.com/sirupsen/logrus"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eden/tests/escript/go-internal/testscript"
 )
 
 var testData = flag.String("testdata", "testdata", "Test script directory")
 var failScenario = flag.String("fail_scenario", "failScenario.txt", "Scenario that runs after a test fails")
 var args = flag.String("args", "", "Flags to pass into test")
+var checkpoints = flag.String("checkpoints", "", "Directory to save checkpoints of scripts into (~/.eden/checkpoints/<context>/<suite> by default)")
+var seed = flag.Int64("seed", seedFromEnv(), "Seed of random source of rand command (chosen from the current time if zero)")
+var bench = flag.String("bench", os.Getenv(defaults.DefaultTestBenchEnv), "File to append measurements of bench command into")
+var outputLimit = flag.Int("output-limit", 0, "Bytes of output of every command kept in memory for stdout and stderr commands (16MiB if zero)")
+var outputSpill = flag.String("output-spill", "", "Directory to save complete output of commands exceeding output-limit into")
+var lazyFiles = flag.Bool("lazy-files", false, "Extract files of scripts into work directory only when commands reference them")
+var fixtures = flag.String("fixtures", "", "Directory to cache large fixtures of scripts referenced by digest into (~/.eden/fixtures by default)")
+var template = flag.String("template", "", "Directory with common fixtures copied into work directory of every script")
+var workdirPlacements = flag.String("workdir-placements", "", "Directories to place work directories of scripts into in format name=dir[:max-size],... (e.g. ram=/dev/shm:4GiB)")
+var scriptWorkdirs = flag.String("script-workdirs", "", "Placements of work directories of scripts in format script=placement,...")
+var resources = flag.Duration("resources", resourcesFromEnv(), "Sample resources of the host and EVE VM used by every script with this interval (disabled if zero)")
+var resume = flag.Bool("resume", os.Getenv(defaults.DefaultTestResumeEnv) != "", "Resume scripts from checkpoints of phases they failed at")
+
+// eveAccel is accelerator of EVE VM of the current config, empty if EVE is not a local VM
+var eveAccel string
+
+// seedFromEnv returns seed of rand command passed with environment by eden test
+func seedFromEnv() int64 {
+	seed, err := strconv.ParseInt(os.Getenv(defaults.DefaultTestSeedEnv), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return seed
+}
+
+// resourcesFromEnv returns interval of sampling of resources passed with environment by eden test
+func resourcesFromEnv() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv(defaults.DefaultTestUsageEnv))
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// checkpointsDir returns directory to save checkpoints of scripts of the suite with testdata
+func checkpointsDir(testData string) (string, error) {
+	if *checkpoints != "" {
+		return *checkpoints, nil
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	context := os.Getenv(defaults.DefaultConfigEnv)
+	if context == "" {
+		context = defaults.DefaultContext
+	}
+	suiteDir, err := filepath.Abs(filepath.Dir(testData))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultCheckpointsDir, context, filepath.Base(suiteDir)), nil
+}
+
+// fixturesDir returns directory to cache fixtures referenced by scripts
+func fixturesDir() (string, error) {
+	if *fixtures != "" {
+		return *fixtures, nil
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultFixturesDir), nil
+}
 
 func TestEdenScripts(t *testing.T) {
 	if _, err := os.Stat(*testData); os.IsNotExist(err) {
@@ -37,17 +107,115 @@ func TestEdenScripts(t *testing.T) {
 		}
 	}
 
+	checkpointDir, err := checkpointsDir(*testData)
+	if err != nil {
+		log.Fatalf("can't find checkpoints directory: %s\n", err)
+	}
+
+	fixtureDir, err := fixturesDir()
+	if err != nil {
+		log.Fatalf("can't find fixtures directory: %s\n", err)
+	}
+
+	var prepareTemplate func(dir string) error
+	if *template != "" {
+		prepareTemplate = func(dir string) error {
+			return testscript.CopyTree(*template, dir)
+		}
+	}
+
+	var measure func(script string, m testscript.Measurement)
+	if *bench != "" {
+		measure = func(script string, m testscript.Measurement) {
+			if err := tests.AppendMeasurement(*bench, tests.Measurement{
+				Script:         script,
+				Name:           m.Name,
+				Value:          m.Value,
+				Unit:           m.Unit,
+				HigherIsBetter: m.HigherIsBetter,
+			}); err != nil {
+				log.Errorf("cannot save measurement: %s", err)
+			}
+		}
+	}
+
+	placements, err := testscript.ParseWorkdirPlacements(*workdirPlacements)
+	if err != nil {
+		log.Fatalf("invalid -workdir-placements: %s", err)
+	}
+	scriptPlacements := make(map[string]string)
+	for _, item := range strings.Split(*scriptWorkdirs, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		script, placement, found := strings.Cut(item, "=")
+		if !found {
+			log.Fatalf("invalid -script-workdirs: %q is not in format script=placement", item)
+		}
+		scriptPlacements[script] = placement
+	}
+
+	timeoutScale := 0
+	var evePid string
+	if vars, err := utils.InitVars(); err != nil {
+		log.Warnf("cannot load config to check acceleration of EVE: %s", err)
+	} else if vars != nil {
+		eveAccel = vars.EveAccel
+		evePid = vars.EvePid
+	}
+	if eveAccel == utils.QemuAccelTCG {
+		timeoutScale = defaults.DefaultTCGTimeoutScale
+		log.Infof("EVE is emulated with TCG, timeouts are scaled by %d", timeoutScale)
+	}
+
+	var sampleResources func(script string) func() string
+	if *resources > 0 {
+		var qmpSocket string
+		if evePid != "" {
+			qmpSocket = utils.QemuStatsSocket(evePid)
+		}
+		sampleResources = func(script string) func() string {
+			sampler := tests.StartResourceSampler(*resources, evePid, qmpSocket)
+			return func() string {
+				return sampler.Stop().String()
+			}
+		}
+	}
+
 	log.Info("testData directory: ", *testData)
 	testscript.Run(t, testscript.Params{
-		Dir:       *testData,
-		Flags:     flagsParsed,
-		Condition: customConditions,
+		Dir:             *testData,
+		Flags:           flagsParsed,
+		Condition:       customConditions,
+		CheckpointDir:   checkpointDir,
+		Resume:          *resume,
+		Seed:            *seed,
+		Measure:         measure,
+		OutputLimit:     *outputLimit,
+		OutputSpillDir:  *outputSpill,
+		LazyFiles:       *lazyFiles,
+		FixturesDir:     fixtureDir,
+		PrepareTemplate: prepareTemplate,
+		TimeoutScale:    timeoutScale,
+		Resources:       sampleResources,
+
+		WorkdirPlacements: placements,
+		ScriptWorkdirs:    scriptPlacements,
 	})
+	for name, stats := range testscript.CacheStats() {
+		log.Debugf("cache of %s: %d hits, %d misses, %d shared", name, stats.Hits, stats.Misses, stats.Shared)
+	}
 }
 
 // Function adds additional condition(s) for testscripts:
 // - [env:<env-variable>] is satisfied if the environment variable has a non-empty string value assigned.
+// - [tcg] is satisfied if EVE is emulated with TCG (e.g. arm64 EVE on amd64 host).
+// - [kvm] is satisfied if EVE VM is accelerated with KVM.
 func customConditions(ts *testscript.TestScript, cond string) (bool, error) {
+	switch cond {
+	case utils.QemuAccelTCG, utils.QemuAccelKVM:
+		return eveAccel == cond, nil
+	}
 	if strings.HasPrefix(cond, "env:") {
 		env := cond[len("env:"):]
 		env = strings.TrimSpace(env)
@@ -6,6 +6,7 @@
 package par
 
 import (
+	"context"
 	"math/rand"
 	"sync"
 	"sync/atomic"
@@ -102,48 +103,90 @@ func (w *Work) runner() {
 	}
 }
 
-// Cache runs an action once per key and caches the result.
-type Cache struct {
-	m sync.Map
+// CacheStats are counters of calls of Cache.Do.
+type CacheStats struct {
+	Hits   int64 // calls returned cached result
+	Misses int64 // calls ran the action
+	Shared int64 // calls waited for the action run by another call
 }
 
-type cacheEntry struct {
-	done   uint32
-	mu     sync.Mutex
-	result interface{}
+// Cache runs an action once per key and caches its successful result.
+// Concurrent calls with the same key wait for the one running action
+// and share its result. Failed actions are not cached, so the next
+// call with the key runs the action again.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*cacheEntry[V]
+
+	hits, misses, shared atomic.Int64
+}
+
+type cacheEntry[V any] struct {
+	done   chan struct{}
+	result V
+	err    error
 }
 
-// Do calls the function f if and only if Do is being called for the first time with this key.
-// No call to Do with a given key returns until the one call to f returns.
-// Do returns the value returned by the one call to f.
-func (c *Cache) Do(key interface{}, f func() interface{}) interface{} {
-	entryIface, ok := c.m.Load(key)
-	if !ok {
-		entryIface, _ = c.m.LoadOrStore(key, new(cacheEntry))
+// Do calls the function f if and only if Do is being called for the first time with this key
+// or previous calls of f for the key failed. Calls of Do with a given key wait for the running
+// call to f to return, unless ctx is done, and return its result.
+func (c *Cache[K, V]) Do(ctx context.Context, key K, f func(context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[K]*cacheEntry[V])
 	}
-	e := entryIface.(*cacheEntry)
-	if atomic.LoadUint32(&e.done) == 0 {
-		e.mu.Lock()
-		if atomic.LoadUint32(&e.done) == 0 {
-			e.result = f()
-			atomic.StoreUint32(&e.done, 1)
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-e.done:
+			c.hits.Add(1)
+		default:
+			c.shared.Add(1)
+			select {
+			case <-e.done:
+			case <-ctx.Done():
+				var zero V
+				return zero, ctx.Err()
+			}
 		}
-		e.mu.Unlock()
+		return e.result, e.err
+	}
+	e := &cacheEntry[V]{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	e.result, e.err = f(ctx)
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
 	}
-	return e.result
+	close(e.done)
+	return e.result, e.err
 }
 
 // Get returns the cached result associated with key.
-// It returns nil if there is no such result.
+// It returns false if there is no such result.
 // If the result for key is being computed, Get does not wait for the computation to finish.
-func (c *Cache) Get(key interface{}) interface{} {
-	entryIface, ok := c.m.Load(key)
-	if !ok {
-		return nil
-	}
-	e := entryIface.(*cacheEntry)
-	if atomic.LoadUint32(&e.done) == 0 {
-		return nil
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		select {
+		case <-e.done:
+			if e.err == nil {
+				return e.result, true
+			}
+		default:
+		}
 	}
-	return e.result
+	var zero V
+	return zero, false
+}
+
+// Stats returns counters of calls of Do.
+func (c *Cache[K, V]) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Shared: c.shared.Load()}
 }
@@ -5,6 +5,9 @@
 package par
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -55,23 +58,75 @@ func TestWorkParallel(t *testing.T) {
 }
 
 func TestCache(t *testing.T) {
-	var cache Cache
+	var cache Cache[int, int]
+	ctx := context.Background()
 
 	n := 1
-	v := cache.Do(1, func() interface{} { n++; return n })
-	if v != 2 {
+	f := func(context.Context) (int, error) { n++; return n, nil }
+	if v, _ := cache.Do(ctx, 1, f); v != 2 {
 		t.Fatalf("cache.Do(1) did not run f")
 	}
-	v = cache.Do(1, func() interface{} { n++; return n })
-	if v != 2 {
+	if v, _ := cache.Do(ctx, 1, f); v != 2 {
 		t.Fatalf("cache.Do(1) ran f again!")
 	}
-	v = cache.Do(2, func() interface{} { n++; return n })
-	if v != 3 {
+	if v, _ := cache.Do(ctx, 2, f); v != 3 {
 		t.Fatalf("cache.Do(2) did not run f")
 	}
-	v = cache.Do(1, func() interface{} { n++; return n })
-	if v != 2 {
-		t.Fatalf("cache.Do(1) did not returned saved value from original cache.Do(1)")
+	if v, ok := cache.Get(1); !ok || v != 2 {
+		t.Fatalf("cache.Get(1) did not return saved value from original cache.Do(1)")
+	}
+	if stats := cache.Stats(); stats != (CacheStats{Hits: 1, Misses: 2}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	// failed results are not cached
+	if _, err := cache.Do(ctx, 3, func(context.Context) (int, error) { return 0, errors.New("failed") }); err == nil {
+		t.Fatalf("cache.Do(3) did not return error")
+	}
+	if v, err := cache.Do(ctx, 3, f); err != nil || v != 4 {
+		t.Fatalf("cache.Do(3) did not run f again after failure")
+	}
+}
+
+func TestCacheShared(t *testing.T) {
+	var cache Cache[string, int]
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls int32
+	go func() {
+		_, _ = cache.Do(context.Background(), "k", func(context.Context) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			return 42, nil
+		})
+	}()
+	<-started
+
+	// waiter gives up when its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cache.Do(ctx, "k", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := cache.Do(context.Background(), "k", nil); err != nil || v != 42 {
+				t.Errorf("unexpected shared result: %d %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("f called %d times", calls)
+	}
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Hits+stats.Shared != 11 {
+		t.Fatalf("unexpected stats: %+v", stats)
 	}
 }
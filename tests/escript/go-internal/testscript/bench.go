@@ -0,0 +1,76 @@
+package testscript
+
+import (
+	"strconv"
+	"time"
+)
+
+// Measurement is a value measured by bench command of script
+type Measurement struct {
+	Name  string
+	Value float64
+	Unit  string
+	// HigherIsBetter is true for values like throughput, false for durations
+	HigherIsBetter bool
+}
+
+// bench measures durations and records values for benchmark mode.
+func (ts *TestScript) cmdBench(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! bench")
+	}
+	if len(args) < 2 {
+		ts.Fatalf("usage: bench start|stop name or bench record [-higher] name value [unit]")
+	}
+	switch args[0] {
+	case "start":
+		if len(args) != 2 {
+			ts.Fatalf("usage: bench start name")
+		}
+		if ts.benchTimers == nil {
+			ts.benchTimers = make(map[string]time.Time)
+		}
+		ts.benchTimers[args[1]] = time.Now()
+	case "stop":
+		if len(args) != 2 {
+			ts.Fatalf("usage: bench stop name")
+		}
+		start, ok := ts.benchTimers[args[1]]
+		if !ok {
+			// timers are not saved into checkpoints
+			ts.Logf("bench %s was not started, nothing recorded\n", args[1])
+			return
+		}
+		delete(ts.benchTimers, args[1])
+		ts.measure(Measurement{Name: args[1], Value: time.Since(start).Seconds(), Unit: "s"})
+	case "record":
+		args = args[1:]
+		higher := false
+		if len(args) > 0 && args[0] == "-higher" {
+			higher = true
+			args = args[1:]
+		}
+		if len(args) != 2 && len(args) != 3 {
+			ts.Fatalf("usage: bench record [-higher] name value [unit]")
+		}
+		value, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			ts.Fatalf("invalid value of %s: %v", args[0], err)
+		}
+		m := Measurement{Name: args[0], Value: value, HigherIsBetter: higher}
+		if len(args) == 3 {
+			m.Unit = args[2]
+		}
+		ts.measure(m)
+	default:
+		ts.Fatalf("unknown bench command: %s", args[0])
+	}
+}
+
+// measure logs measurement and passes it into Params.Measure
+func (ts *TestScript) measure(m Measurement) {
+	ts.Logf("bench %s=%g%s\n", m.Name, m.Value, m.Unit)
+	if ts.params.Measure != nil {
+		ts.params.Measure(ts.name, m)
+	}
+}
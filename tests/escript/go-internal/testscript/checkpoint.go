@@ -0,0 +1,174 @@
+package testscript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+const (
+	checkpointStateFile = "state.json"
+	checkpointWorkDir   = "work"
+)
+
+// checkpoint holds state of script at the beginning of phase,
+// so the script can be resumed from this phase later
+type checkpoint struct {
+	// Phase is the number of phase (starting from 1) checkpoint was saved before
+	Phase int `json:"phase"`
+	// Heading is the comment line starting the phase
+	Heading string `json:"heading"`
+	// WorkDir is $WORK of the run saved checkpoint
+	WorkDir string `json:"workdir"`
+	// Cd is the current directory at the beginning of phase
+	Cd string `json:"cd"`
+	// Env is the environment at the beginning of phase
+	Env []string `json:"env"`
+	// Background is the number of background commands running at the beginning of phase
+	Background int `json:"background"`
+	// Seed is the seed of random source of rand command
+	Seed int64 `json:"seed"`
+	// RandCalls is the number of rand commands executed before the phase
+	RandCalls int `json:"rand_calls"`
+}
+
+// checkpointDir returns directory to store checkpoint of the script
+func (ts *TestScript) checkpointDir() string {
+	return filepath.Join(ts.params.CheckpointDir, ts.name)
+}
+
+// workdirFingerprint returns digest of names, modes, sizes and modification times
+// of files inside dir, which changes if any file is created, removed or written
+func workdirFingerprint(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %v %d %d\n", rel, info.Mode(), info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// saveCheckpoint stores environment and copy of $WORK before the phase starts,
+// copying of $WORK is skipped if it was not changed since the previous checkpoint
+func (ts *TestScript) saveCheckpoint(phase int, heading string) error {
+	data, err := json.MarshalIndent(&checkpoint{
+		Phase:      phase,
+		Heading:    heading,
+		WorkDir:    ts.workdir,
+		Cd:         ts.cd,
+		Env:        ts.env,
+		Background: len(ts.background),
+		Seed:       ts.params.Seed,
+		RandCalls:  ts.randCalls,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fingerprint, err := workdirFingerprint(ts.workdir)
+	if err != nil {
+		return err
+	}
+	dir := ts.checkpointDir()
+	if fingerprint == ts.savedWork {
+		stateFile := filepath.Join(dir, checkpointStateFile)
+		if err := os.WriteFile(stateFile+".tmp", data, 0644); err != nil {
+			return err
+		}
+		return os.Rename(stateFile+".tmp", stateFile)
+	}
+	ts.savedWork = ""
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, checkpointWorkDir), 0755); err != nil {
+		return err
+	}
+	if err := utils.CopyFolder(ts.workdir, filepath.Join(tmpDir, checkpointWorkDir)); err != nil {
+		return fmt.Errorf("cannot copy %s: %w", ts.workdir, err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, checkpointStateFile), data, 0644); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return err
+	}
+	ts.savedWork = fingerprint
+	return nil
+}
+
+// loadCheckpoint reads checkpoint of the script, it returns nil if there is no checkpoint
+func (ts *TestScript) loadCheckpoint() (*checkpoint, error) {
+	data, err := os.ReadFile(filepath.Join(ts.checkpointDir(), checkpointStateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("cannot parse checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// restoreCheckpoint replaces $WORK and environment with the ones saved in checkpoint,
+// paths inside the old $WORK are moved into the current one
+func (ts *TestScript) restoreCheckpoint(cp *checkpoint) error {
+	if err := os.RemoveAll(ts.workdir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ts.workdir, 0777); err != nil {
+		return err
+	}
+	if err := utils.CopyFolder(filepath.Join(ts.checkpointDir(), checkpointWorkDir), ts.workdir); err != nil {
+		return fmt.Errorf("cannot restore %s: %w", ts.workdir, err)
+	}
+	// $WORK is the same as the one in checkpoint until the phase changes it
+	ts.savedWork, _ = workdirFingerprint(ts.workdir)
+	relocate := func(s string) string {
+		return strings.ReplaceAll(s, cp.WorkDir, ts.workdir)
+	}
+	ts.cd = relocate(cp.Cd)
+	ts.randCalls = cp.RandCalls
+	ts.env = nil
+	ts.envMap = make(map[string]string)
+	for _, kv := range cp.Env {
+		kv = relocate(kv)
+		ts.env = append(ts.env, kv)
+		if i := strings.Index(kv, "="); i >= 0 {
+			ts.envMap[envvarname(kv[:i])] = kv[i+1:]
+		}
+	}
+	return nil
+}
+
+// removeCheckpoint removes checkpoint of the script after it passed
+func (ts *TestScript) removeCheckpoint() {
+	if ts.params.CheckpointDir == "" {
+		return
+	}
+	if err := os.RemoveAll(ts.checkpointDir()); err != nil {
+		ts.Logf("cannot remove checkpoint: %v", err)
+	}
+}
@@ -18,7 +18,6 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/lf-edge/eden/pkg/utils"
-	"github.com/lf-edge/eden/tests/escript/go-internal/internal/textutil"
 	"github.com/lf-edge/eden/tests/escript/go-internal/txtar"
 )
 
@@ -27,30 +26,44 @@ import (
 //
 // NOTE: If you make changes here, update doc.go.
 var scriptCmds = map[string]func(*TestScript, bool, []string){
-	"arg":     (*TestScript).cmdArg,
-	"cd":      (*TestScript).cmdCd,
-	"chmod":   (*TestScript).cmdChmod,
-	"cmp":     (*TestScript).cmdCmp,
-	"cmpenv":  (*TestScript).cmdCmpenv,
-	"cp":      (*TestScript).cmdCp,
-	"eden":    (*TestScript).cmdEden,
-	"env":     (*TestScript).cmdEnv,
-	"source":  (*TestScript).cmdSource,
-	"exec":    (*TestScript).cmdExec,
-	"exists":  (*TestScript).cmdExists,
-	"grep":    (*TestScript).cmdGrep,
-	"message": (*TestScript).cmdMsg,
-	"mkdir":   (*TestScript).cmdMkdir,
-	"rm":      (*TestScript).cmdRm,
-	"unquote": (*TestScript).cmdUnquote,
-	"skip":    (*TestScript).cmdSkip,
-	"stdin":   (*TestScript).cmdStdin,
-	"stderr":  (*TestScript).cmdStderr,
-	"stdout":  (*TestScript).cmdStdout,
-	"stop":    (*TestScript).cmdStop,
-	"symlink": (*TestScript).cmdSymlink,
-	"test":    (*TestScript).cmdTest,
-	"wait":    (*TestScript).cmdWait,
+	"arg":      (*TestScript).cmdArg,
+	"bench":    (*TestScript).cmdBench,
+	"cd":       (*TestScript).cmdCd,
+	"chmod":    (*TestScript).cmdChmod,
+	"cmp":      (*TestScript).cmdCmp,
+	"cmpenv":   (*TestScript).cmdCmpenv,
+	"cp":       (*TestScript).cmdCp,
+	"defer":    (*TestScript).cmdDefer,
+	"eden":     (*TestScript).cmdEden,
+	"env":      (*TestScript).cmdEnv,
+	"envfile":  (*TestScript).cmdEnvfile,
+	"source":   (*TestScript).cmdSource,
+	"exec":     (*TestScript).cmdExec,
+	"exists":   (*TestScript).cmdExists,
+	"grep":     (*TestScript).cmdGrep,
+	"grpc":     (*TestScript).cmdGRPC,
+	"http":     (*TestScript).cmdHTTP,
+	"kill":     (*TestScript).cmdKill,
+	"ln":       (*TestScript).cmdLn,
+	"message":  (*TestScript).cmdMsg,
+	"mkdir":    (*TestScript).cmdMkdir,
+	"rand":     (*TestScript).cmdRand,
+	"requires": (*TestScript).cmdRequires,
+	"rm":       (*TestScript).cmdRm,
+	"sdn":      (*TestScript).cmdSdn,
+	"unquote":  (*TestScript).cmdUnquote,
+	"skip":     (*TestScript).cmdSkip,
+	"stdin":    (*TestScript).cmdStdin,
+	"stderr":   (*TestScript).cmdStderr,
+	"stdout":   (*TestScript).cmdStdout,
+	"stop":     (*TestScript).cmdStop,
+	"symlink":  (*TestScript).cmdSymlink,
+	"tar":      (*TestScript).cmdTar,
+	"test":     (*TestScript).cmdTest,
+	"untar":    (*TestScript).cmdUntar,
+	"wait":     (*TestScript).cmdWait,
+	"waitport": (*TestScript).cmdWaitport,
+	"workdir":  (*TestScript).cmdWorkdir,
 }
 
 var timewait time.Duration
@@ -82,8 +95,12 @@ func (ts *TestScript) cmdCd(neg bool, args []string) {
 }
 
 func (ts *TestScript) cmdChmod(neg bool, args []string) {
-	if len(args) != 2 {
-		ts.Fatalf("usage: chmod mode file")
+	recursive := len(args) > 0 && args[0] == "-R"
+	if recursive {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		ts.Fatalf("usage: chmod [-R] mode file...")
 	}
 	mode, err := strconv.ParseInt(args[0], 8, 32)
 	if err != nil {
@@ -92,7 +109,16 @@ func (ts *TestScript) cmdChmod(neg bool, args []string) {
 	if mode > 0777 {
 		ts.Fatalf("unsupported file mode %.3o", mode)
 	}
-	err = os.Chmod(ts.MkAbs(args[1]), os.FileMode(mode))
+	for _, arg := range args[1:] {
+		if recursive {
+			err = chmodRecursive(ts.MkAbs(arg), os.FileMode(mode))
+		} else {
+			err = os.Chmod(ts.MkAbs(arg), os.FileMode(mode))
+		}
+		if err != nil {
+			break
+		}
+	}
 	if neg {
 		if err == nil {
 			ts.Fatalf("unexpected chmod success")
@@ -162,7 +188,7 @@ func (ts *TestScript) doCmdCmp(args []string, env bool) bool {
 		// update the script.
 	}
 
-	ts.Logf("[diff -%s +%s]\n%s\n", name1, name2, textutil.Diff(text1, text2))
+	ts.Logf("[diff -%s +%s]\n%s\n", name1, name2, utils.RenderDiff(name1, name2, text1, text2, utils.DefaultDiffOptions()))
 	return false
 }
 
@@ -171,8 +197,12 @@ func (ts *TestScript) cmdCp(neg bool, args []string) {
 	if neg {
 		ts.Fatalf("unsupported: ! cp")
 	}
+	recursive := len(args) > 0 && args[0] == "-r"
+	if recursive {
+		args = args[1:]
+	}
 	if len(args) < 2 {
-		ts.Fatalf("usage: cp src... dst")
+		ts.Fatalf("usage: cp [-r] src... dst")
 	}
 
 	dst := ts.MkAbs(args[len(args)-1])
@@ -199,6 +229,14 @@ func (ts *TestScript) cmdCp(neg bool, args []string) {
 			mode = 0666
 		default:
 			src = ts.MkAbs(arg)
+			if recursive {
+				targ := dst
+				if dstDir {
+					targ = filepath.Join(dst, filepath.Base(src))
+				}
+				ts.Check(copyRecursive(src, targ))
+				continue
+			}
 			info, err := os.Stat(src)
 			ts.Check(err)
 			mode = info.Mode() & 0777
@@ -611,9 +649,16 @@ func (ts *TestScript) cmdStdin(neg bool, args []string) {
 	if len(args) != 1 {
 		ts.Fatalf("usage: stdin filename")
 	}
-	data, err := os.ReadFile(ts.MkAbs(args[0]))
-	ts.Check(err)
-	ts.stdin = string(data)
+	switch args[0] {
+	case "stdout":
+		ts.stdin = ts.stdout
+	case "stderr":
+		ts.stdin = ts.stderr
+	default:
+		data, err := os.ReadFile(ts.MkAbs(args[0]))
+		ts.Check(err)
+		ts.stdin = string(data)
+	}
 }
 
 // stdout checks that the last go command standard output matches a regexp.
@@ -663,17 +708,33 @@ func (ts *TestScript) cmdSymlink(neg bool, args []string) {
 
 // Tait waits for background commands to exit, setting stderr and stdout to their result.
 func (ts *TestScript) cmdWait(neg bool, args []string) {
-	if len(args) > 1 {
-		ts.Fatalf("usage: wait [name]")
-	}
 	if neg {
 		ts.Fatalf("unsupported: ! wait")
 	}
-	if len(args) > 0 {
-		ts.waitBackgroundOne(args[0])
-	} else {
+	if len(args) == 0 {
 		ts.waitBackground(true)
+		return
 	}
+	// check all names before waiting for any of commands
+	for i, bgName := range args {
+		if ts.findBackground(bgName) == nil {
+			ts.Fatalf("unknown background process %q", bgName)
+		}
+		for _, prevName := range args[:i] {
+			if prevName == bgName {
+				ts.Fatalf("duplicate background process name %q", bgName)
+			}
+		}
+	}
+	// output of commands is concatenated in the order of names
+	var stdouts, stderrs []string
+	for _, bgName := range args {
+		ts.waitBackgroundOne(bgName)
+		stdouts = append(stdouts, ts.stdout)
+		stderrs = append(stderrs, ts.stderr)
+	}
+	ts.stdout = strings.Join(stdouts, "")
+	ts.stderr = strings.Join(stderrs, "")
 }
 
 func (ts *TestScript) waitBackgroundOne(bgName string) {
@@ -683,8 +744,8 @@ func (ts *TestScript) waitBackgroundOne(bgName string) {
 		return
 	}
 	<-bg.wait
-	ts.stdout = bg.cmd.Stdout.(*strings.Builder).String()
-	ts.stderr = bg.cmd.Stderr.(*strings.Builder).String()
+	ts.stdout = ts.collectOutput("stdout", bg.cmd.Stdout.(*outputBuffer))
+	ts.stderr = ts.collectOutput("stderr", bg.cmd.Stderr.(*outputBuffer))
 	if ts.stdout != "" {
 		fmt.Fprintf(&ts.log, "[stdout]\n%s", ts.stdout)
 	}
@@ -719,12 +780,12 @@ func (ts *TestScript) waitBackground(checkStatus bool) {
 		<-bg.wait
 		args := append([]string{filepath.Base(bg.cmd.Args[0])}, bg.cmd.Args[1:]...)
 		fmt.Fprintf(&ts.log, "[background] %s: %v\n", strings.Join(args, " "), bg.cmd.ProcessState)
-		cmdStdout := bg.cmd.Stdout.(*strings.Builder).String()
+		cmdStdout := ts.collectOutput("stdout", bg.cmd.Stdout.(*outputBuffer))
 		if cmdStdout != "" {
 			fmt.Fprintf(&ts.log, "[stdout]\n%s", cmdStdout)
 			stdouts = append(stdouts, cmdStdout)
 		}
-		cmdStderr := bg.cmd.Stderr.(*strings.Builder).String()
+		cmdStderr := ts.collectOutput("stderr", bg.cmd.Stderr.(*outputBuffer))
 		if cmdStderr != "" {
 			fmt.Fprintf(&ts.log, "[stderr]\n%s", cmdStderr)
 			stderrs = append(stderrs, cmdStderr)
@@ -0,0 +1,46 @@
+package testscript
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// deferredCmd is command queued by defer command
+type deferredCmd struct {
+	lineno int
+	args   []string
+}
+
+// defer queues command to run at the end of the script, even if the script fails.
+// Arguments are expanded when the command is queued, commands run in reverse order.
+func (ts *TestScript) cmdDefer(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! defer")
+	}
+	if len(args) == 0 {
+		ts.Fatalf("usage: defer command [args...]")
+	}
+	ts.deferredCmds = append(ts.deferredCmds, deferredCmd{lineno: ts.lineno, args: args})
+}
+
+// runDeferredCmds runs commands queued by defer command in reverse order,
+// the remaining commands still run if one of them fails.
+func (ts *TestScript) runDeferredCmds() {
+	if len(ts.deferredCmds) == 0 {
+		return
+	}
+	last := ts.deferredCmds[len(ts.deferredCmds)-1]
+	ts.deferredCmds = ts.deferredCmds[:len(ts.deferredCmds)-1]
+	// failed command aborts the test, so run the rest from defer
+	defer ts.runDeferredCmds()
+	if ts.ctxt.Err() != nil {
+		// context of the script is canceled on failure, but cleanup must run anyway
+		ts.ctxt, ts.cancel = context.WithCancel(context.Background())
+	}
+	ts.lineno = last.lineno
+	line := strings.Join(last.args, " ")
+	fmt.Printf("> [deferred] %s\n", line)
+	fmt.Fprintf(&ts.log, "> [deferred] %s\n", line)
+	ts.runCommand(last.args)
+}
@@ -110,14 +110,41 @@ when testing.Short() is false.
 
 Additional conditions can be added by passing a function to Params.Condition.
 
+Conditions required to run the script at all are listed by requires lines placed
+before the first command of the script, e.g.:
+
+	requires net exec:qemu-img !windows
+
+The requirements are checked with the environment of the test before the work
+directory of the script is set up. If any of them is not satisfied, the script
+is skipped and the unmet requirements are reported as the reason of the skip.
+
+The work directory of the script can be placed into a directory configured by
+Params.WorkdirPlacements (e.g. on tmpfs or on a fast volume) with workdir line
+placed before the first command of the script:
+
+	workdir ram 2GiB
+
+Placement "ram" is /dev/shm by default. The optional size limits the work
+directory further than the limit of the placement does, the script fails if its
+work directory exceeds the limit after any command.
+
 The predefined commands are:
 
+- bench start|stop name
+- bench record [-higher] name value [unit]
+  Record measurement for benchmark mode. "bench start" and "bench stop" record
+  the duration between them in seconds, "bench record" records the value
+  (e.g. throughput parsed from output). Lower values are better unless -higher
+  is given. Measurements are logged and passed into Params.Measure.
+
 - cd dir
   Change to the given directory for future commands.
 
-- chmod mode file
+- chmod [-R] mode file...
 
-  Change the permissions of file or directory to the given octal mode (000 to 777).
+  Change the permissions of files or directories to the given octal mode (000 to 777).
+  With -R the mode is changed for everything inside of directories too.
 
 - cmp file1 file2
   Check that the named files have the same content.
@@ -130,10 +157,19 @@ The predefined commands are:
   Like cmp, but environment variables in file2 are substituted before the
   comparison. For example, $GOOS is replaced by the target GOOS.
 
-- cp src... dst
+- cp [-r] src... dst
   Copy the listed files to the target file or existing directory.
   src can include "stdout" or "stderr" to use the standard output or standard error
   from the most recent exec, eden or test command.
+  With -r directories are copied with their content, and symbolic links are
+  copied as links.
+
+- defer command [args...]
+  Queue the command (with its condition and ! prefixes) to run at the end of the
+  script, even if the script fails, e.g. to delete pods or networks created by
+  the script. Arguments are expanded when the command is queued. Queued commands
+  run in reverse order, before background commands are stopped, and the rest of
+  them still run if one of them fails.
 
 - [!] eden [args...] [&]
   Run the given 'eden' executable program with the arguments.
@@ -143,6 +179,14 @@ The predefined commands are:
   With no arguments, print the environment (useful for debugging).
   Otherwise add the listed key=value pairs to the environment.
 
+- envfile file...
+  Add KEY=VALUE pairs from the files (e.g. written by a helper program) to the
+  environment. The file can be "stdout" or "stderr" to use the standard output or
+  standard error from the most recent command. Empty lines and lines starting with #
+  are skipped, lines can start with 'export'. Values can be quoted with single quotes
+  (taken as is) or with double quotes (Go escapes are interpreted). Unlike 'source',
+  the case of keys is preserved.
+
 - [!] exec program [args...] [&]
   Run the given executable program with the arguments.
   It must (or must not) succeed.
@@ -157,7 +201,7 @@ The predefined commands are:
 
   If the last token is '&word&` (where "word" is alphanumeric), the
   command runs in the background but has a name, and can be waited
-  for specifically by passing the word to 'wait'.
+  for specifically by passing the word to 'wait' or signaled with 'kill'.
 
   Standard input can be provided using the stdin command; this will be
   cleared after exec has been called.
@@ -170,6 +214,34 @@ The predefined commands are:
   The file's content must (or must not) match the regular expression pattern.
   For positive matches, -count=N specifies an exact number of matches to require.
 
+- [!] grpc call host:port package.Service/Method request.json [--header key:value]... [--tls] [--timeout duration]
+  Call unary method of gRPC server from the test process. Descriptors of the service
+  are requested with server reflection, so the server must register it. Request is
+  read from the file in JSON format. The call must (or must not) succeed. Response
+  is set as stdout in JSON format, status of the call as stderr and its code (e.g. OK,
+  NOT_FOUND) into GRPC_STATUS environment variable. The call times out after --timeout
+  (10s by default), connection uses TLS with --tls.
+
+- [!] http METHOD url [--header key:value]... [--body file] [--status code] [--retries n] [--delay duration] [--timeout duration]
+  Send HTTP request from the test process. The request must (or must not) succeed with
+  the expected status (--status, any 2xx by default). Body of response is set as
+  stdout, status line and headers of response as stderr, status code and body are
+  also set into HTTP_STATUS and HTTP_BODY environment variables. With --retries
+  the request is repeated after --delay (1s by default) until it gets the expected
+  result (failure for ! http). Every request times out after --timeout (10s by default).
+
+- kill [-signal name] name
+  Send signal (TERM by default) to the background command with the given name
+  (started with the '&name&' token), e.g. to stop 'eden log -f' gracefully. The
+  signal is given by name (HUP, INT, QUIT, KILL, TERM, USR1, USR2, with or without
+  SIG prefix) or number. Exit status of the command is checked by 'wait' as usual,
+  so commands which fail on the signal should be started with '!'.
+
+- ln [-s] target link
+  Create hard link (symbolic one with -s) to target. If link is an existing
+  directory, the link is created inside of it with the base name of target.
+  Target of symbolic link is interpreted relative to the directory of the link.
+
 - message message
   Print message.
 
@@ -182,14 +254,41 @@ The predefined commands are:
   txtar file markers.
   See also https://godoc.org/github.com/rogpeppe/go-internal/txtar#Unquote
 
+- rand name|port|cidr [args...] env_variable
+  Set environment variable to random value. Values are reproducible: they depend
+  only on Params.Seed (printed into the log), name of the script and number of call.
+  "rand name [prefix]" generates prefix followed by 8 lowercase letters and digits,
+  "rand port [min max]" generates TCP port (20000-39999 by default), the script
+  fails if the port is not free on the host,
+  "rand cidr [parent [bits]]" generates subnet with prefix length bits inside
+  parent subnet (/24 inside 10.0.0.0/8 by default).
+
 - rm file...
   Remove the listed files or directories.
 
+- sdn link up|down port
+- sdn outage port duration
+- sdn shape port off|key=value...
+- sdn pcap duration file
+- sdn fw allow|reject|drop [src=subnet] [dst=subnet] [proto=tcp|udp|icmp|any] [ports=port,...]
+- sdn fw clear
+  Change conditions of networks emulated by Eden-SDN through its management API.
+  Port is logical label of SDN port or name of EVE interface connected to it (e.g. eth0).
+  "sdn outage" puts the port down for the duration and up again.
+  "sdn shape" sets traffic control of the port: delay and jitter (durations),
+  loss, corrupt, duplicate and reorder (percent), rate (kilobytes per second),
+  queue and burst (kilobytes); "off" removes it.
+  "sdn pcap" captures traffic of SDN VM for the duration into file in pcap format.
+  "sdn fw" adds firewall rule taking precedence over existing ones, "sdn fw clear"
+  removes all rules.
+
 - skip [message]
   Mark the test skipped, including the message if given.
 
 - stdin file
   Set the standard input for the next exec command to the contents of the given file.
+  File can be "stdout" or "stderr" to use the standard output or standard error
+  from the previous exec or wait command.
 
 - [!] stderr [-count=N] pattern
   Apply the grep command (see above) to the standard error
@@ -205,18 +304,36 @@ The predefined commands are:
 - symlink file -> target
   Create file as a symlink to target. The -> (like in ls -l output) is required.
 
+- tar archive path...
+  Create tar archive of files and directories inside of the current directory,
+  preserving permissions and symbolic links. The archive is compressed with gzip
+  if its name ends with .gz or .tgz.
+
 - [!] test [args...] [&]
   Run the given 'eden' test executable program with the arguments.
   Behaves the same way as an 'exec'.
 
-- wait [command]
+- untar archive [dir]
+  Extract tar archive (compressed with gzip if its name ends with .gz or .tgz)
+  into dir or the current directory. Paths pointing outside of dir are rejected.
+
+- wait [name...]
   Wait for all 'exec', 'eden' and 'test' commands started in the background (with the '&'
   token) to exit, and display success or failure status for them.
   After a call to wait, the 'stderr' and 'stdout' commands will apply to the
   concatenation of the corresponding streams of the background commands,
   in the order in which those commands were started.
 
-  If an argument is specified, it waits for just that command.
+  If names are specified, it waits for just the commands started with the '&name&'
+  token, other commands keep running in the background. The 'stderr' and 'stdout'
+  commands then apply to the streams of the named commands, concatenated in the
+  order of names.
+
+- [!] waitport [-t timeout] host:port [--http path] [--status code]
+  Wait until the port accepts TCP connections or, with --http, until GET request
+  of the path gets the expected status (--status, any 2xx by default). The port is
+  probed every second for -t timeout (1m by default). With ! wait until the port
+  stops accepting connections or responding with the expected status.
 
 When TestEdenScripts runs a script and the script fails, by default TestEdenScripts shows
 the execution of the most recent phase of the script (since the last # comment)
@@ -0,0 +1,62 @@
+package testscript
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var envKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnvFile parses KEY=VALUE pairs of data in order of lines. Empty lines and
+// lines starting with # are skipped, lines can start with 'export', values can be
+// quoted with single quotes as is or with double quotes with Go escapes.
+func parseEnvFile(data string) ([][2]string, error) {
+	var pairs [][2]string
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !envKeyRe.MatchString(key) {
+			return nil, fmt.Errorf("line %d: %q is not in format KEY=VALUE", i+1, line)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value of %s: %w", i+1, key, err)
+			}
+			value = unquoted
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs, nil
+}
+
+// envfile loads KEY=VALUE pairs from files into the environment of the script.
+func (ts *TestScript) cmdEnvfile(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! envfile")
+	}
+	if len(args) == 0 {
+		ts.Fatalf("usage: envfile file...")
+	}
+	for _, arg := range args {
+		pairs, err := parseEnvFile(ts.ReadFile(arg))
+		if err != nil {
+			ts.Fatalf("cannot parse %s: %v", arg, err)
+		}
+		for _, pair := range pairs {
+			ts.Setenv(pair[0], pair[1])
+		}
+		fmt.Fprintf(&ts.log, "[%d variables loaded from %s]\n", len(pairs), arg)
+	}
+}
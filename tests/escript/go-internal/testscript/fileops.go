@@ -0,0 +1,244 @@
+package testscript
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// copyRecursive copies file, symlink or directory src into dst
+func copyRecursive(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		if err := os.MkdirAll(dst, info.Mode().Perm()|0700); err != nil {
+			return err
+		}
+		return CopyTree(src, dst)
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dst)
+	default:
+		return copyFile(src, dst)
+	}
+}
+
+// chmodRecursive changes mode of path and of everything inside of it, content of
+// directories is changed before directories themselves, so they can be made unreadable
+func chmodRecursive(path string, mode os.FileMode) error {
+	var paths []string
+	err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(paths) - 1; i >= 0; i-- {
+		if err := os.Chmod(paths[i], mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isGzip checks if archive is compressed by its name
+func isGzip(name string) bool {
+	return strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// writeTar writes paths relative to root with everything inside of them into tar archive w
+func writeTar(w io.Writer, root string, paths []string) error {
+	tw := tar.NewWriter(w)
+	for _, path := range paths {
+		err := filepath.WalkDir(filepath.Join(root, path), func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			var link string
+			if info.Mode()&fs.ModeSymlink != 0 {
+				if link, err = os.Readlink(file); err != nil {
+					return err
+				}
+			}
+			hdr, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(rel)
+			if info.IsDir() {
+				hdr.Name += "/"
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// extractTar extracts tar archive r into directory dst
+func extractTar(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("path %q of archive is outside of destination", hdr.Name)
+		}
+		target := filepath.Join(dst, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+			return err
+		}
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported type of %q in archive", hdr.Name)
+		}
+	}
+}
+
+// ln creates link to target, symbolic one with -s.
+func (ts *TestScript) cmdLn(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! ln")
+	}
+	symbolic := len(args) > 0 && args[0] == "-s"
+	if symbolic {
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		ts.Fatalf("usage: ln [-s] target link")
+	}
+	link := ts.MkAbs(args[1])
+	if info, err := os.Stat(link); err == nil && info.IsDir() {
+		link = filepath.Join(link, filepath.Base(args[0]))
+	}
+	if symbolic {
+		// target is interpreted relative to the directory of link as symlink command does
+		ts.Check(os.Symlink(args[0], link))
+		return
+	}
+	ts.Check(os.Link(ts.MkAbs(args[0]), link))
+}
+
+// tar creates archive of files and directories, compressed with gzip if its name ends with .gz or .tgz.
+func (ts *TestScript) cmdTar(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! tar")
+	}
+	if len(args) < 2 {
+		ts.Fatalf("usage: tar archive path...")
+	}
+	var paths []string
+	for _, arg := range args[1:] {
+		rel, err := filepath.Rel(ts.cd, ts.MkAbs(arg))
+		ts.Check(err)
+		if !filepath.IsLocal(rel) && rel != "." {
+			ts.Fatalf("tar: %s is outside of the current directory", arg)
+		}
+		paths = append(paths, rel)
+	}
+	f, err := os.Create(ts.MkAbs(args[0]))
+	ts.Check(err)
+	defer f.Close()
+	var w io.Writer = f
+	var gw *gzip.Writer
+	if isGzip(args[0]) {
+		gw = gzip.NewWriter(f)
+		w = gw
+	}
+	ts.Check(writeTar(w, ts.cd, paths))
+	if gw != nil {
+		ts.Check(gw.Close())
+	}
+	ts.Check(f.Close())
+}
+
+// untar extracts archive into directory (the current one by default).
+func (ts *TestScript) cmdUntar(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! untar")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		ts.Fatalf("usage: untar archive [dir]")
+	}
+	dst := ts.cd
+	if len(args) == 2 {
+		dst = ts.MkAbs(args[1])
+	}
+	f, err := os.Open(ts.MkAbs(args[0]))
+	ts.Check(err)
+	defer f.Close()
+	var r io.Reader = f
+	if isGzip(args[0]) {
+		gr, err := gzip.NewReader(f)
+		ts.Check(err)
+		defer gr.Close()
+		r = gr
+	}
+	ts.Check(extractTar(r, dst))
+}
@@ -0,0 +1,188 @@
+package testscript
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/eden/tests/escript/go-internal/par"
+	"github.com/lf-edge/eden/tests/escript/go-internal/txtar"
+)
+
+// fixturePrefix starts content of file of archive which references large
+// external fixture by digest instead of embedding it:
+//
+//	-- disk.img --
+//	fixture sha256:<hex digest> <url or path>
+const fixturePrefix = "fixture sha256:"
+
+// fixtureCache fetches every fixture once for concurrently running scripts.
+var fixtureCache par.Cache[string, string]
+
+// parseFixture returns digest and source of fixture referenced by data of file of archive.
+func parseFixture(data []byte) (digest, source string, ok bool) {
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, fixturePrefix) || strings.Contains(line, "\n") {
+		return "", "", false
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, fixturePrefix))
+	if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(fields[0]); err != nil {
+		return "", "", false
+	}
+	return strings.ToLower(fields[0]), fields[1], true
+}
+
+// fileDigest returns hex encoded sha256 digest of content of file.
+func fileDigest(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fetchFixture returns path of fixture with digest inside cacheDir,
+// it is fetched from source (URL or local path) if it is not cached yet
+// or content of cached file does not match digest. Cached files are read-only.
+func fetchFixture(ctx context.Context, cacheDir, digest, source string) (string, error) {
+	return fixtureCache.Do(ctx, digest, func(ctx context.Context) (string, error) {
+		name := filepath.Join(cacheDir, digest)
+		if got, err := fileDigest(name); err == nil {
+			if got == digest {
+				return name, nil
+			}
+			if err := os.Remove(name); err != nil {
+				return "", fmt.Errorf("cannot remove corrupted fixture %s: %w", name, err)
+			}
+		}
+		var r io.ReadCloser
+		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+			if err != nil {
+				return "", err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("cannot fetch fixture %s: %w", source, err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				resp.Body.Close()
+				return "", fmt.Errorf("cannot fetch fixture %s: %s", source, resp.Status)
+			}
+			r = resp.Body
+		} else {
+			f, err := os.Open(strings.TrimPrefix(source, "file://"))
+			if err != nil {
+				return "", fmt.Errorf("cannot open fixture: %w", err)
+			}
+			r = f
+		}
+		defer r.Close()
+		if err := os.MkdirAll(cacheDir, 0755); err != nil {
+			return "", err
+		}
+		tmp, err := os.CreateTemp(cacheDir, digest+".*.tmp")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(tmp.Name())
+		hash := sha256.New()
+		_, err = io.Copy(io.MultiWriter(tmp, hash), r)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot fetch fixture %s: %w", source, err)
+		}
+		if got := hex.EncodeToString(hash.Sum(nil)); got != digest {
+			return "", fmt.Errorf("digest of fixture %s is %s, expected %s", source, got, digest)
+		}
+		if err := os.Chmod(tmp.Name(), 0444); err != nil {
+			return "", err
+		}
+		return name, os.Rename(tmp.Name(), name)
+	})
+}
+
+// extractFile writes file of archive into name, fixtures are copied from the cache
+// so scripts modifying them do not change the cache.
+func (ts *TestScript) extractFile(name string, f txtar.File) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+	digest, source, ok := parseFixture(f.Data)
+	if !ok {
+		return os.WriteFile(name, f.Data, 0666)
+	}
+	if ts.params.FixturesDir == "" {
+		return fmt.Errorf("file %s references fixture, but directory of fixtures is not set", f.Name)
+	}
+	cached, err := fetchFixture(ts.ctxt, ts.params.FixturesDir, digest, source)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(cached)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// extractPending extracts files of archive not extracted yet for which
+// match returns true, files already existing in $WORK are kept.
+func (ts *TestScript) extractPending(match func(name, scriptName string) bool) {
+	var names []string
+	for name, f := range ts.pendingFiles {
+		if match(name, f.Name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := ts.pendingFiles[name]
+		delete(ts.pendingFiles, name)
+		if _, err := os.Stat(name); err == nil {
+			continue
+		}
+		ts.Check(ts.extractFile(name, *f))
+	}
+}
+
+// extractReferenced extracts files of archive mentioned in args of command.
+func (ts *TestScript) extractReferenced(args []string) {
+	if len(ts.pendingFiles) == 0 {
+		return
+	}
+	ts.extractPending(func(name, scriptName string) bool {
+		for _, arg := range args {
+			if strings.Contains(arg, name) || strings.Contains(arg, ts.expand(scriptName)) {
+				return true
+			}
+		}
+		return false
+	})
+}
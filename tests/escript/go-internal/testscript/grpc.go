@@ -0,0 +1,274 @@
+package testscript
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	grpcUsage          = "usage: grpc call host:port package.Service/Method request.json [--header key:value]... [--tls] [--timeout duration]"
+	grpcDefaultTimeout = 10 * time.Second
+	// env variable with status code of the last call
+	grpcStatusEnv = "GRPC_STATUS"
+)
+
+// grpcCall is unary call of grpc command
+type grpcCall struct {
+	target  string
+	service string
+	method  string
+	request []byte
+	headers metadata.MD
+	tls     bool
+	timeout time.Duration
+}
+
+// parseGRPCArgs parses arguments of grpc command, request file is read with readFile
+func parseGRPCArgs(args []string, readFile func(name string) ([]byte, error)) (*grpcCall, error) {
+	if len(args) == 0 || args[0] != "call" {
+		return nil, fmt.Errorf("%s", grpcUsage)
+	}
+	call := &grpcCall{headers: metadata.MD{}, timeout: grpcDefaultTimeout}
+	var positional []string
+	for args = args[1:]; len(args) > 0; args = args[1:] {
+		switch args[0] {
+		case "--tls":
+			call.tls = true
+		case "--header", "--timeout":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("no value of %s: %s", args[0], grpcUsage)
+			}
+			if args[0] == "--header" {
+				key, value, found := strings.Cut(args[1], ":")
+				if !found {
+					return nil, fmt.Errorf("header %q is not in format key:value", args[1])
+				}
+				call.headers.Append(strings.TrimSpace(key), strings.TrimSpace(value))
+			} else {
+				timeout, err := time.ParseDuration(args[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid timeout %q: %w", args[1], err)
+				}
+				call.timeout = timeout
+			}
+			args = args[1:]
+		default:
+			if strings.HasPrefix(args[0], "--") {
+				return nil, fmt.Errorf("unknown option %s: %s", args[0], grpcUsage)
+			}
+			positional = append(positional, args[0])
+		}
+	}
+	if len(positional) != 3 {
+		return nil, fmt.Errorf("%s", grpcUsage)
+	}
+	call.target = positional[0]
+	fullMethod := strings.TrimPrefix(positional[1], "/")
+	i := strings.LastIndex(fullMethod, "/")
+	if i <= 0 || i == len(fullMethod)-1 {
+		return nil, fmt.Errorf("method %q is not in format package.Service/Method", positional[1])
+	}
+	call.service, call.method = fullMethod[:i], fullMethod[i+1:]
+	var err error
+	if call.request, err = readFile(positional[2]); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// grpcResolveFiles requests descriptors of files with symbol and of their dependencies
+// with server reflection and returns registry of them
+func grpcResolveFiles(ctx context.Context, conn *grpc.ClientConn, symbol string) (*protoregistry.Files, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection: %w", err)
+	}
+	defer func() { _ = stream.CloseSend() }()
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+	request := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return fmt.Errorf("server reflection: %w", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("server reflection: %w", err)
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return fmt.Errorf("server reflection: %s", errResp.GetErrorMessage())
+		}
+		for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(data, fd); err != nil {
+				return fmt.Errorf("cannot parse file descriptor: %w", err)
+			}
+			protos[fd.GetName()] = fd
+		}
+		return nil
+	}
+	if err := request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	}); err != nil {
+		return nil, err
+	}
+	files := &protoregistry.Files{}
+	// register files after their dependencies, dependencies unknown to server
+	// (e.g. well-known types) are taken from files linked into the test binary
+	var register func(name string) error
+	register = func(name string) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		fd, ok := protos[name]
+		if !ok {
+			if err := request(&rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			}); err != nil {
+				global, globalErr := protoregistry.GlobalFiles.FindFileByPath(name)
+				if globalErr != nil {
+					return err
+				}
+				return files.RegisterFile(global)
+			}
+			if fd, ok = protos[name]; !ok {
+				return fmt.Errorf("no descriptor of file %s", name)
+			}
+		}
+		for _, dep := range fd.GetDependency() {
+			if err := register(dep); err != nil {
+				return err
+			}
+		}
+		file, err := protodesc.NewFile(fd, files)
+		if err != nil {
+			return fmt.Errorf("invalid descriptor of file %s: %w", name, err)
+		}
+		return files.RegisterFile(file)
+	}
+	names := make([]string, 0, len(protos))
+	for name := range protos {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if err := register(name); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// do invokes unary method and returns response as JSON
+func (call *grpcCall) do(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, call.timeout)
+	defer cancel()
+	creds := insecure.NewCredentials()
+	if call.tls {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.DialContext(ctx, call.target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	files, err := grpcResolveFiles(ctx, conn, call.service)
+	if err != nil {
+		return "", err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(call.service))
+	if err != nil {
+		return "", fmt.Errorf("service %s not found: %w", call.service, err)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return "", fmt.Errorf("%s is not a service", call.service)
+	}
+	method := service.Methods().ByName(protoreflect.Name(call.method))
+	if method == nil {
+		return "", fmt.Errorf("method %s not found in service %s", call.method, call.service)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return "", fmt.Errorf("streaming method %s is not supported", call.method)
+	}
+	req := dynamicpb.NewMessage(method.Input())
+	if err := (protojson.UnmarshalOptions{Resolver: dynamicResolver(files)}).Unmarshal(call.request, req); err != nil {
+		return "", fmt.Errorf("cannot parse request as %s: %w", method.Input().FullName(), err)
+	}
+	resp := dynamicpb.NewMessage(method.Output())
+	ctx = metadata.NewOutgoingContext(ctx, call.headers)
+	if err := conn.Invoke(ctx, fmt.Sprintf("/%s/%s", call.service, call.method), req, resp); err != nil {
+		return "", err
+	}
+	data, err := protojson.MarshalOptions{Resolver: dynamicResolver(files)}.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	// protojson randomizes whitespaces, so reformat response to match it in scripts
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return "", err
+	}
+	return out.String() + "\n", nil
+}
+
+// dynamicResolver resolves types of Any messages from files of server
+func dynamicResolver(files *protoregistry.Files) *protoregistry.Types {
+	types := &protoregistry.Types{}
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		messages := file.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			_ = types.RegisterMessage(dynamicpb.NewMessageType(messages.Get(i)))
+		}
+		return true
+	})
+	return types
+}
+
+// grpc invokes unary method of gRPC server described by its server reflection,
+// response is set as stdout in JSON format, status of call as stderr and env variable.
+func (ts *TestScript) cmdGRPC(neg bool, args []string) {
+	call, err := parseGRPCArgs(args, func(name string) ([]byte, error) {
+		return os.ReadFile(ts.MkAbs(name))
+	})
+	if err != nil {
+		ts.Fatalf("%v", err)
+	}
+	ts.stdout, err = call.do(ts.ctxt)
+	st := status.Convert(err)
+	// use canonical names of codes (e.g. NOT_FOUND) as grpcurl and other tools do
+	codeName := code.Code(st.Code()).String()
+	ts.stderr = fmt.Sprintf("%s: %s\n", codeName, st.Message())
+	if st.Message() == "" {
+		ts.stderr = codeName + "\n"
+	}
+	if ts.stdout != "" {
+		fmt.Fprintf(&ts.log, "[stdout]\n%s", ts.stdout)
+	}
+	fmt.Fprintf(&ts.log, "[stderr]\n%s", ts.stderr)
+	ts.Setenv(grpcStatusEnv, codeName)
+	switch {
+	case err == nil && neg:
+		ts.Fatalf("unexpected command success")
+	case err != nil && !neg:
+		ts.Fatalf("call failed: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package testscript
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	httpUsage          = "usage: http METHOD url [--header key:value]... [--body file] [--status code] [--retries n] [--delay duration] [--timeout duration]"
+	httpDefaultTimeout = 10 * time.Second
+	httpDefaultDelay   = time.Second
+	// env variables with status code and body of the last response
+	httpStatusEnv = "HTTP_STATUS"
+	httpBodyEnv   = "HTTP_BODY"
+)
+
+// httpRequest is request of http command with its expectations
+type httpRequest struct {
+	method  string
+	url     string
+	headers http.Header
+	body    []byte
+	// status is expected status code, any 2xx status is expected if it is 0
+	status  int
+	retries int
+	delay   time.Duration
+	timeout time.Duration
+}
+
+// parseHTTPArgs parses arguments of http command, body file is read with readFile
+func parseHTTPArgs(args []string, readFile func(name string) ([]byte, error)) (*httpRequest, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s", httpUsage)
+	}
+	req := &httpRequest{
+		method:  strings.ToUpper(args[0]),
+		headers: make(http.Header),
+		delay:   httpDefaultDelay,
+		timeout: httpDefaultTimeout,
+	}
+	args = args[1:]
+	for len(args) > 0 {
+		arg := args[0]
+		if !strings.HasPrefix(arg, "--") {
+			if req.url != "" {
+				return nil, fmt.Errorf("unexpected argument %q: %s", arg, httpUsage)
+			}
+			req.url = arg
+			args = args[1:]
+			continue
+		}
+		if len(args) < 2 {
+			return nil, fmt.Errorf("no value of %s: %s", arg, httpUsage)
+		}
+		value := args[1]
+		args = args[2:]
+		var err error
+		switch arg {
+		case "--header":
+			key, headerValue, found := strings.Cut(value, ":")
+			if !found {
+				return nil, fmt.Errorf("header %q is not in format key:value", value)
+			}
+			req.headers.Add(strings.TrimSpace(key), strings.TrimSpace(headerValue))
+		case "--body":
+			if req.body, err = readFile(value); err != nil {
+				return nil, err
+			}
+		case "--status":
+			if req.status, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid status %q: %w", value, err)
+			}
+		case "--retries":
+			if req.retries, err = strconv.Atoi(value); err != nil || req.retries < 0 {
+				return nil, fmt.Errorf("invalid number of retries %q", value)
+			}
+		case "--delay":
+			if req.delay, err = time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid delay %q: %w", value, err)
+			}
+		case "--timeout":
+			if req.timeout, err = time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid timeout %q: %w", value, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown option %s: %s", arg, httpUsage)
+		}
+	}
+	if req.url == "" {
+		return nil, fmt.Errorf("no url: %s", httpUsage)
+	}
+	return req, nil
+}
+
+// expected checks that status code is the expected one
+func (req *httpRequest) expected(status int) bool {
+	if req.status == 0 {
+		return status >= 200 && status < 300
+	}
+	return status == req.status
+}
+
+// do sends request once and returns status line with headers of response and its body
+func (req *httpRequest) do(ctx context.Context) (status int, head, body string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, req.timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, req.url, bytes.NewReader(req.body))
+	if err != nil {
+		return 0, "", "", err
+	}
+	httpReq.Header = req.headers.Clone()
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return 0, "", "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", resp.Proto, resp.Status)
+	keys := make([]string, 0, len(resp.Header))
+	for key := range resp.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range resp.Header[key] {
+			fmt.Fprintf(&sb, "%s: %s\n", key, value)
+		}
+	}
+	return resp.StatusCode, sb.String(), string(data), nil
+}
+
+// http sends HTTP request from the test process, body of response is set as stdout,
+// status line with headers as stderr, status code and body into env variables.
+func (ts *TestScript) cmdHTTP(neg bool, args []string) {
+	req, err := parseHTTPArgs(args, func(name string) ([]byte, error) {
+		return os.ReadFile(ts.MkAbs(name))
+	})
+	if err != nil {
+		ts.Fatalf("%v", err)
+	}
+	ts.stdout, ts.stderr = "", ""
+	var status int
+	for attempt := 0; ; attempt++ {
+		var head, body string
+		status, head, body, err = req.do(ts.ctxt)
+		ts.stdout, ts.stderr = body, head
+		if err != nil {
+			fmt.Fprintf(&ts.log, "[%s %s: %v]\n", req.method, req.url, err)
+		} else {
+			fmt.Fprintf(&ts.log, "[%s %s: %d]\n", req.method, req.url, status)
+		}
+		// with ! retries wait for failure of request
+		if (err == nil && req.expected(status)) != neg || attempt >= req.retries {
+			break
+		}
+		select {
+		case <-ts.ctxt.Done():
+			ts.Fatalf("test interrupted while running command")
+		case <-time.After(req.delay):
+		}
+	}
+	if ts.stdout != "" {
+		fmt.Fprintf(&ts.log, "[stdout]\n%s", ts.stdout)
+		if !strings.HasSuffix(ts.stdout, "\n") {
+			ts.log.WriteByte('\n')
+		}
+	}
+	ts.Setenv(httpStatusEnv, strconv.Itoa(status))
+	ts.Setenv(httpBodyEnv, ts.stdout)
+	ok := err == nil && req.expected(status)
+	switch {
+	case ok && neg:
+		ts.Fatalf("unexpected command success")
+	case !ok && !neg && err != nil:
+		ts.Fatalf("request failed: %v", err)
+	case !ok && !neg:
+		ts.Fatalf("unexpected status %d", status)
+	}
+}
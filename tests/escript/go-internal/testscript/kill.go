@@ -0,0 +1,61 @@
+package testscript
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const killUsage = "usage: kill [-signal name] name"
+
+// signals are names of signals accepted by kill command, without SIG prefix
+var signals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+// parseSignal returns signal by its name (e.g. TERM or SIGTERM) or number
+func parseSignal(name string) (os.Signal, error) {
+	if sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]; ok {
+		return sig, nil
+	}
+	if num, err := strconv.Atoi(name); err == nil && num > 0 {
+		return syscall.Signal(num), nil
+	}
+	return nil, fmt.Errorf("unknown signal %q", name)
+}
+
+// kill sends signal (TERM by default) to the named background command.
+func (ts *TestScript) cmdKill(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! kill")
+	}
+	sig, sigName := os.Signal(syscall.SIGTERM), "TERM"
+	if len(args) > 0 && args[0] == "-signal" {
+		if len(args) < 2 {
+			ts.Fatalf("no value of -signal: %s", killUsage)
+		}
+		var err error
+		if sig, err = parseSignal(args[1]); err != nil {
+			ts.Fatalf("%v", err)
+		}
+		sigName = args[1]
+		args = args[2:]
+	}
+	if len(args) != 1 {
+		ts.Fatalf("%s", killUsage)
+	}
+	bg := ts.findBackground(args[0])
+	if bg == nil {
+		ts.Fatalf("unknown background process %q", args[0])
+	}
+	if err := bg.cmd.Process.Signal(sig); err != nil {
+		ts.Fatalf("cannot send %s to %q: %v", sigName, args[0], err)
+	}
+	fmt.Fprintf(&ts.log, "[%s sent to %s]\n", sigName, args[0])
+}
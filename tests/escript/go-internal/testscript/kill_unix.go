@@ -0,0 +1,11 @@
+//go:build !windows
+
+package testscript
+
+import "syscall"
+
+func init() {
+	// signals which are not defined on Windows
+	signals["USR1"] = syscall.SIGUSR1
+	signals["USR2"] = syscall.SIGUSR2
+}
@@ -0,0 +1,133 @@
+package testscript
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// defaultOutputLimit is number of bytes of standard output and error
+// of every command retained in memory if Params.OutputLimit is zero.
+const defaultOutputLimit = 16 << 20
+
+// outputBuffer retains the last limit bytes written into it in a ring buffer,
+// so output of commands does not grow memory of runner unbounded.
+// If spillPath is not empty, complete output is saved into file once it
+// exceeds limit. It is safe to write and read it concurrently.
+type outputBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	start     int // position of the oldest byte in buf once it is full
+	limit     int
+	total     int64
+	spillPath string
+	spill     *os.File
+	spillErr  error
+}
+
+func newOutputBuffer(limit int, spillPath string) *outputBuffer {
+	if limit <= 0 {
+		limit = defaultOutputLimit
+	}
+	return &outputBuffer{limit: limit, spillPath: spillPath}
+}
+
+// Write implements io.Writer.
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	if b.spillPath != "" && b.spillErr == nil {
+		if b.spill == nil && b.total+int64(n) > int64(b.limit) {
+			// nothing is dropped yet, so buf holds complete output
+			if b.spillErr = os.MkdirAll(filepath.Dir(b.spillPath), 0755); b.spillErr == nil {
+				b.spill, b.spillErr = os.Create(b.spillPath)
+			}
+			if b.spillErr == nil {
+				_, b.spillErr = b.spill.Write(b.buf)
+			}
+		}
+		if b.spill != nil && b.spillErr == nil {
+			_, b.spillErr = b.spill.Write(p)
+		}
+	}
+	b.total += int64(n)
+	if free := b.limit - len(b.buf); free > 0 {
+		if free > len(p) {
+			free = len(p)
+		}
+		b.buf = append(b.buf, p[:free]...)
+		p = p[free:]
+	}
+	if len(p) >= b.limit {
+		copy(b.buf, p[len(p)-b.limit:])
+		b.start = 0
+		return n, nil
+	}
+	for len(p) > 0 {
+		copied := copy(b.buf[b.start:], p)
+		p = p[copied:]
+		b.start = (b.start + copied) % b.limit
+	}
+	return n, nil
+}
+
+// String returns retained tail of output.
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var sb strings.Builder
+	sb.Grow(len(b.buf))
+	sb.Write(b.buf[b.start:])
+	sb.Write(b.buf[:b.start])
+	return sb.String()
+}
+
+// close closes spill file and returns note about dropped output
+// to log, or empty string if complete output is retained.
+func (b *outputBuffer) close() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spill != nil {
+		if err := b.spill.Close(); err != nil && b.spillErr == nil {
+			b.spillErr = err
+		}
+		b.spill = nil
+	}
+	dropped := b.total - int64(len(b.buf))
+	if dropped == 0 {
+		return ""
+	}
+	switch {
+	case b.spillPath == "":
+		return fmt.Sprintf("%d bytes dropped, only the last %d bytes are kept", dropped, len(b.buf))
+	case b.spillErr != nil:
+		return fmt.Sprintf("%d bytes dropped, cannot save complete output into %s: %v", dropped, b.spillPath, b.spillErr)
+	default:
+		return fmt.Sprintf("%d bytes dropped, complete output is saved into %s", dropped, b.spillPath)
+	}
+}
+
+// newOutputBuffers returns buffers for standard output and error of command
+// executed at the current line of script.
+func (ts *TestScript) newOutputBuffers() (stdout, stderr *outputBuffer) {
+	spillPath := func(stream string) string {
+		if ts.params.OutputSpillDir == "" {
+			return ""
+		}
+		name := strings.ReplaceAll(ts.name, string(filepath.Separator), "_")
+		return filepath.Join(ts.params.OutputSpillDir, fmt.Sprintf("%s-%d-%s.log", name, ts.lineno, stream))
+	}
+	return newOutputBuffer(ts.params.OutputLimit, spillPath("stdout")),
+		newOutputBuffer(ts.params.OutputLimit, spillPath("stderr"))
+}
+
+// collectOutput returns retained output of command and logs if part of it was dropped.
+func (ts *TestScript) collectOutput(stream string, b *outputBuffer) string {
+	if note := b.close(); note != "" {
+		ts.Logf("[%s truncated] %s", stream, note)
+	}
+	return b.String()
+}
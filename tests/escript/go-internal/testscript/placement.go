@@ -0,0 +1,139 @@
+package testscript
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ramPlacement is name of placement of work directories backed by RAM,
+// it is available without configuration if defaultRAMDir exists.
+const ramPlacement = "ram"
+
+var defaultRAMDir = "/dev/shm"
+
+// WorkdirPlacement is directory to create work directories of scripts inside of
+// instead of WorkdirRoot, e.g. on tmpfs or on a fast volume.
+type WorkdirPlacement struct {
+	// Dir is the directory to create work directories inside of
+	Dir string
+	// MaxSize is limit of size of work directory in bytes checked after every command
+	// of the script, zero means no limit
+	MaxSize int64
+}
+
+// ParseWorkdirPlacements parses comma-separated list of placements in format
+// name=dir[:max-size], e.g. "ram=/dev/shm:4GiB,fast=/mnt/nvme".
+func ParseWorkdirPlacements(spec string) (map[string]WorkdirPlacement, error) {
+	placements := make(map[string]WorkdirPlacement)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, dir, found := strings.Cut(item, "=")
+		if !found || name == "" || dir == "" {
+			return nil, fmt.Errorf("placement %q is not in format name=dir[:max-size]", item)
+		}
+		var placement WorkdirPlacement
+		if i := strings.LastIndex(dir, ":"); i >= 0 {
+			size, err := humanize.ParseBytes(dir[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid max size of placement %s: %w", name, err)
+			}
+			dir, placement.MaxSize = dir[:i], int64(size)
+		}
+		placement.Dir = dir
+		placements[name] = placement
+	}
+	return placements, nil
+}
+
+// workdirPlacement returns placement of work directory of the script chosen by workdir
+// directive of the script or by Params.ScriptWorkdirs, nil is returned if it is not set.
+func (ts *TestScript) workdirPlacement() (*WorkdirPlacement, error) {
+	name := ts.params.ScriptWorkdirs[ts.name]
+	var maxSize int64
+	header := scriptHeader(string(ts.archive.Comment))
+	for _, lineno := range headerOrder(header) {
+		fields := header[lineno]
+		if fields[0] != "workdir" {
+			continue
+		}
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("usage: workdir placement [max-size]")
+		}
+		name = fields[1]
+		if len(fields) == 3 {
+			size, err := humanize.ParseBytes(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid max size of work directory: %w", err)
+			}
+			maxSize = int64(size)
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+	placement, ok := ts.params.WorkdirPlacements[name]
+	if !ok {
+		if _, err := os.Stat(defaultRAMDir); name != ramPlacement || err != nil {
+			return nil, fmt.Errorf("unknown placement of work directory %q", name)
+		}
+		placement.Dir = defaultRAMDir
+	}
+	// the script can only lower limit of placement
+	if maxSize > 0 && (placement.MaxSize == 0 || maxSize < placement.MaxSize) {
+		placement.MaxSize = maxSize
+	}
+	return &placement, nil
+}
+
+// dirSize returns total size of regular files inside of dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// file is removed while walking
+			return nil
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// checkWorkdirSize fails the script if its work directory exceeds the limit of its placement.
+func (ts *TestScript) checkWorkdirSize() {
+	if ts.workdirLimit <= 0 {
+		return
+	}
+	size, err := dirSize(ts.workdir)
+	if err != nil {
+		ts.Fatalf("cannot get size of work directory: %v", err)
+	}
+	if size > ts.workdirLimit {
+		ts.Fatalf("work directory takes %s exceeding the limit of %s",
+			humanize.IBytes(uint64(size)), humanize.IBytes(uint64(ts.workdirLimit)))
+	}
+}
+
+// workdir places work directory of the script, it is handled before the script starts,
+// so the command only checks its placement.
+func (ts *TestScript) cmdWorkdir(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! workdir")
+	}
+	ts.checkHeaderLine("workdir")
+}
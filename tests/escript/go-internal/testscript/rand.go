@@ -0,0 +1,141 @@
+package testscript
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+)
+
+const (
+	randNameLength = 8
+	randNameChars  = "abcdefghijklmnopqrstuvwxyz0123456789"
+	randPortMin    = 20000
+	randPortMax    = 39999
+	randCidrParent = "10.0.0.0/8"
+	randCidrBits   = 24
+)
+
+// randUsageRe matches scripts using rand command
+var randUsageRe = regexp.MustCompile(`(?m)^\s*(\[[^]]*\]\s*)?(!\s*)?rand\s`)
+
+// scriptSeed derives seed of the script from seed of the run and name of the script,
+// so values generated by the script do not depend on other scripts
+func scriptSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s", seed, name)
+	return int64(h.Sum64())
+}
+
+// nextRand returns random source for the next call of rand command,
+// it depends only on seed of the script and number of the call, so calls
+// are reproducible after resuming from checkpoint
+func (ts *TestScript) nextRand() *rand.Rand {
+	ts.randCalls++
+	return rand.New(rand.NewSource(scriptSeed(ts.randSeed, strconv.Itoa(ts.randCalls))))
+}
+
+// rand generates random value and set it into environment variable.
+func (ts *TestScript) cmdRand(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! rand")
+	}
+	if len(args) < 2 {
+		ts.Fatalf("usage: rand name|port|cidr [args...] env_variable")
+	}
+	kind, params, env := args[0], args[1:len(args)-1], args[len(args)-1]
+	r := ts.nextRand()
+	var value string
+	switch kind {
+	case "name":
+		if len(params) > 1 {
+			ts.Fatalf("usage: rand name [prefix] env_variable")
+		}
+		value = randName(r, params...)
+	case "port":
+		if len(params) != 0 && len(params) != 2 {
+			ts.Fatalf("usage: rand port [min max] env_variable")
+		}
+		min, max := randPortMin, randPortMax
+		if len(params) == 2 {
+			var err error
+			min, err = strconv.Atoi(params[0])
+			ts.Check(err)
+			max, err = strconv.Atoi(params[1])
+			ts.Check(err)
+		}
+		port, err := randPort(r, min, max)
+		ts.Check(err)
+		value = strconv.Itoa(port)
+	case "cidr":
+		if len(params) > 2 {
+			ts.Fatalf("usage: rand cidr [parent [bits]] env_variable")
+		}
+		parent, bits := randCidrParent, randCidrBits
+		if len(params) > 0 {
+			parent = params[0]
+		}
+		if len(params) > 1 {
+			var err error
+			bits, err = strconv.Atoi(params[1])
+			ts.Check(err)
+		}
+		cidr, err := randCidr(r, parent, bits)
+		ts.Check(err)
+		value = cidr
+	default:
+		ts.Fatalf("unknown kind of rand: %s", kind)
+	}
+	ts.Logf("%s=%s\n", env, value)
+	ts.Setenv(env, value)
+}
+
+// randName returns prefix followed by random lowercase letters and digits
+func randName(r *rand.Rand, prefix ...string) string {
+	b := make([]byte, randNameLength)
+	for i := range b {
+		b[i] = randNameChars[r.Intn(len(randNameChars))]
+	}
+	if len(prefix) > 0 {
+		return prefix[0] + string(b)
+	}
+	return string(b)
+}
+
+// randPort returns random TCP port from range [min, max] chosen only by r, so it does
+// not depend on ports used on the host, the chosen port is checked to be free
+func randPort(r *rand.Rand, min, max int) (int, error) {
+	if min <= 0 || max > 65535 || min > max {
+		return 0, fmt.Errorf("invalid range of ports: %d-%d", min, max)
+	}
+	port := min + r.Intn(max-min+1)
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return 0, fmt.Errorf("port %d chosen with the seed is not free, run with another seed: %w", port, err)
+	}
+	_ = l.Close()
+	return port, nil
+}
+
+// randCidr returns random subnet with prefix length bits inside parent subnet
+func randCidr(r *rand.Rand, parent string, bits int) (string, error) {
+	_, parentNet, err := net.ParseCIDR(parent)
+	if err != nil {
+		return "", err
+	}
+	parentBits, totalBits := parentNet.Mask.Size()
+	if bits < parentBits || bits > totalBits {
+		return "", fmt.Errorf("invalid prefix length %d for subnet %s", bits, parent)
+	}
+	subnets := new(big.Int).Lsh(big.NewInt(1), uint(bits-parentBits))
+	index := new(big.Int).Rand(r, subnets)
+	offset := new(big.Int).Lsh(index, uint(totalBits-bits))
+	ip := new(big.Int).Add(new(big.Int).SetBytes(parentNet.IP), offset)
+	ipBytes := make([]byte, len(parentNet.IP))
+	ip.FillBytes(ipBytes)
+	subnet := net.IPNet{IP: ipBytes, Mask: net.CIDRMask(bits, totalBits)}
+	return subnet.String(), nil
+}
@@ -0,0 +1,117 @@
+package testscript
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// headerDirectives are directives of script which apply to the whole script
+// and must be placed before its first command
+var headerDirectives = map[string]bool{"requires": true, "workdir": true}
+
+// scriptHeader returns fields of lines with directives placed before the first command
+// of script by numbers of these lines
+func scriptHeader(script string) map[int][]string {
+	header := make(map[int][]string)
+	for i, line := range strings.Split(script, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0 || strings.HasPrefix(fields[0], "#"):
+			continue
+		case headerDirectives[fields[0]]:
+			header[i+1] = fields
+		default:
+			return header
+		}
+	}
+	return header
+}
+
+// headerOrder returns numbers of lines of header in ascending order
+func headerOrder(header map[int][]string) []int {
+	numbers := make([]int, 0, len(header))
+	for lineno := range header {
+		numbers = append(numbers, lineno)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// scriptRequirements returns conditions listed by requires lines placed before the first
+// command of script and numbers of these lines
+func scriptRequirements(script string) ([]string, map[int]bool) {
+	header := scriptHeader(script)
+	var conds []string
+	lines := make(map[int]bool)
+	for _, lineno := range headerOrder(header) {
+		if fields := header[lineno]; fields[0] == "requires" {
+			conds = append(conds, fields[1:]...)
+			lines[lineno] = true
+		}
+	}
+	return conds, lines
+}
+
+// checkRequirements skips the script before setup of its work directory if conditions
+// listed by requires lines are not satisfied.
+func (ts *TestScript) checkRequirements() {
+	ts.loadArchive()
+	ts.headerLines = scriptHeader(string(ts.archive.Comment))
+	conds, _ := scriptRequirements(string(ts.archive.Comment))
+	if len(conds) == 0 {
+		return
+	}
+	if ts.envMap == nil {
+		// environment of script is not set up yet, so check conditions with one of the test
+		defer func() { ts.envMap = nil }()
+		ts.envMap = make(map[string]string)
+		for _, kv := range os.Environ() {
+			if i := strings.Index(kv, "="); i >= 0 {
+				ts.envMap[envvarname(kv[:i])] = kv[i+1:]
+			}
+		}
+	}
+	var unmet []string
+	for _, cond := range conds {
+		want := true
+		name := cond
+		if strings.HasPrefix(name, "!") {
+			want = false
+			name = strings.TrimSpace(name[1:])
+		}
+		if strings.HasPrefix(name, "stdout:") || strings.HasPrefix(name, "stderr:") {
+			ts.Fatalf("requirement %q cannot be checked before script runs", cond)
+		}
+		ok, err := ts.condition(name)
+		if err != nil {
+			ts.Fatalf("bad requirement %q: %v", cond, err)
+		}
+		if ok != want {
+			unmet = append(unmet, cond)
+		}
+	}
+	if len(unmet) > 0 {
+		ts.t.Skip(fmt.Sprintf("requirements not met: %s", strings.Join(unmet, " ")))
+	}
+}
+
+// checkHeaderLine fails if directive is not placed before the first command of the script.
+func (ts *TestScript) checkHeaderLine(directive string) {
+	if fields := ts.headerLines[ts.lineno]; len(fields) == 0 || fields[0] != directive {
+		ts.Fatalf("%s must be placed before the first command of the script", directive)
+	}
+}
+
+// requires lists conditions which must be satisfied to run the script, they are checked
+// before the script starts, so the command only checks its placement.
+func (ts *TestScript) cmdRequires(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! requires")
+	}
+	if len(args) == 0 {
+		ts.Fatalf("usage: requires condition...")
+	}
+	ts.checkHeaderLine("requires")
+}
@@ -0,0 +1,258 @@
+package testscript
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/utils"
+	model "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/spf13/viper"
+)
+
+const sdnUsage = `usage: sdn link up|down port
+       sdn outage port duration
+       sdn shape port off|key=value...
+       sdn pcap duration file
+       sdn fw allow|reject|drop [src=subnet] [dst=subnet] [proto=tcp|udp|icmp|any] [ports=port,...]
+       sdn fw clear`
+
+// sdnClient returns client of management API of Eden-SDN of the current config
+func (ts *TestScript) sdnClient() *edensdn.SdnClient {
+	if _, err := utils.InitVars(); err != nil {
+		ts.Fatalf("error reading config: %s\n", err)
+	}
+	if viper.GetBool("sdn.disable") || viper.GetBool("eve.remote") ||
+		viper.GetString("eve.devmodel") != defaults.DefaultQemuModel {
+		ts.Fatalf("SDN is not used by the current config")
+	}
+	sdnSourceDir := utils.ResolveAbsPath(viper.GetString("sdn.source-dir"))
+	return &edensdn.SdnClient{
+		SSHPort:    uint16(viper.GetInt("sdn.ssh-port")),
+		SSHKeyPath: filepath.Join(sdnSourceDir, "vm/cert/ssh/id_rsa"),
+		MgmtPort:   uint16(viper.GetInt("sdn.mgmt-port")),
+	}
+}
+
+// sdnPort returns port of network model by its logical label or name of EVE interface connected to it
+func (ts *TestScript) sdnPort(netModel *model.NetworkModel, name string) *model.Port {
+	for i := range netModel.Ports {
+		if netModel.Ports[i].LogicalLabel == name {
+			return &netModel.Ports[i]
+		}
+	}
+	if index, err := strconv.Atoi(strings.TrimPrefix(name, "eth")); err == nil &&
+		strings.HasPrefix(name, "eth") && index >= 0 && index < len(netModel.Ports) {
+		return &netModel.Ports[index]
+	}
+	ts.Fatalf("no SDN port %s found", name)
+	return nil
+}
+
+// sdnUpdate applies change of network model to Eden-SDN
+func (ts *TestScript) sdnUpdate(client *edensdn.SdnClient, change func(netModel *model.NetworkModel)) {
+	netModel, err := client.GetNetworkModel()
+	if err != nil {
+		ts.Fatalf("cannot get network model: %v", err)
+	}
+	change(&netModel)
+	if err := client.ApplyNetworkModel(netModel); err != nil {
+		ts.Fatalf("cannot apply network model: %v", err)
+	}
+}
+
+func (ts *TestScript) sdnSetLink(client *edensdn.SdnClient, port string, up bool) {
+	ts.sdnUpdate(client, func(netModel *model.NetworkModel) {
+		ts.sdnPort(netModel, port).AdminUP = up
+	})
+}
+
+// sdn changes conditions of networks emulated by Eden-SDN.
+func (ts *TestScript) cmdSdn(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! sdn")
+	}
+	if len(args) < 1 {
+		ts.Fatalf(sdnUsage)
+	}
+	client := ts.sdnClient()
+	switch args[0] {
+	case "link":
+		if len(args) != 3 || (args[1] != "up" && args[1] != "down") {
+			ts.Fatalf("usage: sdn link up|down port")
+		}
+		ts.sdnSetLink(client, args[2], args[1] == "up")
+		ts.Logf("SDN port %s is %s\n", args[2], args[1])
+	case "outage":
+		if len(args) != 3 {
+			ts.Fatalf("usage: sdn outage port duration")
+		}
+		duration, err := time.ParseDuration(args[2])
+		if err != nil {
+			ts.Fatalf("invalid duration: %v", err)
+		}
+		ts.sdnSetLink(client, args[1], false)
+		ts.Logf("SDN port %s is down for %s\n", args[1], duration)
+		select {
+		case <-time.After(duration):
+		case <-ts.ctxt.Done():
+		}
+		ts.sdnSetLink(client, args[1], true)
+	case "shape":
+		if len(args) < 3 {
+			ts.Fatalf("usage: sdn shape port off|key=value...")
+		}
+		tc, err := parseTrafficControl(args[2:])
+		if err != nil {
+			ts.Fatalf("%v", err)
+		}
+		ts.sdnUpdate(client, func(netModel *model.NetworkModel) {
+			ts.sdnPort(netModel, args[1]).TC = tc
+		})
+		ts.Logf("SDN port %s shaped: %s\n", args[1], strings.Join(args[2:], " "))
+	case "pcap":
+		if len(args) != 3 {
+			ts.Fatalf("usage: sdn pcap duration file")
+		}
+		duration, err := time.ParseDuration(args[1])
+		if err != nil {
+			ts.Fatalf("invalid duration: %v", err)
+		}
+		f, err := os.Create(ts.MkAbs(args[2]))
+		ts.Check(err)
+		defer f.Close()
+		ts.Check(client.CaptureTraffic(duration, f))
+	case "fw":
+		if len(args) < 2 {
+			ts.Fatalf(sdnUsage)
+		}
+		if args[1] == "clear" {
+			ts.sdnUpdate(client, func(netModel *model.NetworkModel) {
+				netModel.Firewall.Rules = nil
+			})
+			return
+		}
+		rule, err := parseFwRule(args[1], args[2:])
+		if err != nil {
+			ts.Fatalf("%v", err)
+		}
+		ts.sdnUpdate(client, func(netModel *model.NetworkModel) {
+			// rules are applied in order, the new one takes precedence
+			netModel.Firewall.Rules = append([]model.FwRule{rule}, netModel.Firewall.Rules...)
+		})
+	default:
+		ts.Fatalf(sdnUsage)
+	}
+}
+
+// parseTrafficControl parses traffic control in form of key=value pairs:
+// delay and jitter as durations, loss, corrupt, duplicate and reorder in percent,
+// rate in kilobytes per second, queue and burst in kilobytes. "off" disables shaping.
+func parseTrafficControl(args []string) (model.TrafficControl, error) {
+	var tc model.TrafficControl
+	if len(args) == 1 && args[0] == "off" {
+		return tc, nil
+	}
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return tc, fmt.Errorf("expected key=value, got %s", arg)
+		}
+		switch key {
+		case "delay", "jitter":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return tc, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if key == "delay" {
+				tc.Delay = uint32(d.Milliseconds())
+			} else {
+				tc.DelayJitter = uint32(d.Milliseconds())
+			}
+		case "loss", "corrupt", "duplicate", "reorder":
+			percent, err := strconv.ParseUint(value, 10, 8)
+			if err != nil || percent > 100 {
+				return tc, fmt.Errorf("invalid %s: expected percent", key)
+			}
+			switch key {
+			case "loss":
+				tc.LossProbability = uint8(percent)
+			case "corrupt":
+				tc.CorruptProbability = uint8(percent)
+			case "duplicate":
+				tc.DuplicateProbability = uint8(percent)
+			case "reorder":
+				tc.ReorderProbability = uint8(percent)
+			}
+		case "rate", "queue", "burst":
+			kbytes, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return tc, fmt.Errorf("invalid %s: expected kilobytes", key)
+			}
+			switch key {
+			case "rate":
+				tc.RateLimit = uint32(kbytes)
+			case "queue":
+				tc.QueueLimit = uint32(kbytes)
+			case "burst":
+				tc.BurstLimit = uint32(kbytes)
+			}
+		default:
+			return tc, fmt.Errorf("unknown traffic control %s", key)
+		}
+	}
+	if tc.RateLimit > 0 {
+		// queue and burst are mandatory with rate, default to one second and 1/10 of it
+		if tc.QueueLimit == 0 {
+			tc.QueueLimit = tc.RateLimit
+		}
+		if tc.BurstLimit == 0 {
+			tc.BurstLimit = max(tc.RateLimit/10, 1)
+		}
+	}
+	return tc, nil
+}
+
+// parseFwRule parses firewall rule with action and key=value pairs
+func parseFwRule(action string, args []string) (model.FwRule, error) {
+	var rule model.FwRule
+	fwAction, ok := model.FwActionToID[action]
+	if !ok || action == "" {
+		return rule, fmt.Errorf("unknown firewall action %s", action)
+	}
+	rule.Action = fwAction
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return rule, fmt.Errorf("expected key=value, got %s", arg)
+		}
+		switch key {
+		case "src":
+			rule.SrcSubnet = value
+		case "dst":
+			rule.DstSubnet = value
+		case "proto":
+			proto, ok := model.FwProtoToID[value]
+			if !ok {
+				return rule, fmt.Errorf("unknown protocol %s", value)
+			}
+			rule.Protocol = proto
+		case "ports":
+			for _, p := range strings.Split(value, ",") {
+				port, err := strconv.ParseUint(p, 10, 16)
+				if err != nil {
+					return rule, fmt.Errorf("invalid port %s", p)
+				}
+				rule.Ports = append(rule.Ports, uint16(port))
+			}
+		default:
+			return rule, fmt.Errorf("unknown firewall rule key %s", key)
+		}
+	}
+	return rule, nil
+}
@@ -79,6 +79,10 @@ func (nopTestDeps) ImportPath() string {
 	return ""
 }
 
+func (nopTestDeps) ModulePath() string {
+	return ""
+}
+
 func getTestingMain() *testing.M {
 	return testing.MainStart(nopTestDeps{}, nil, nil, nil, nil)
 }
@@ -32,7 +32,19 @@ import (
 	"github.com/lf-edge/eden/tests/escript/go-internal/txtar"
 )
 
-var execCache par.Cache
+// execCache caches results of lookups of programs for exec conditions.
+var execCache par.Cache[string, bool]
+
+// condCache caches results of built-in conditions which do not depend on script.
+var condCache par.Cache[string, bool]
+
+// CacheStats returns counters of caches of lookups of programs and conditions.
+func CacheStats() map[string]par.CacheStats {
+	return map[string]par.CacheStats{
+		"exec":      execCache.Stats(),
+		"condition": condCache.Stats(),
+	}
+}
 
 // If -testwork is specified, the test prints the name of the temp directory
 // and does not remove it when done, so that a programmer can
@@ -157,6 +169,76 @@ type Params struct {
 	// script.
 	UpdateScripts bool
 
+	// CheckpointDir specifies the directory to save state of scripts at the
+	// beginning of every phase (started with comment line), so scripts can be
+	// resumed from the phase they failed at. Checkpoint is removed after
+	// the script passed. Empty value disables checkpoints.
+	CheckpointDir string
+
+	// Resume specifies that scripts with checkpoints in CheckpointDir
+	// should skip phases before the saved one and restore its environment
+	// and $WORK instead of running from the beginning.
+	Resume bool
+
+	// Seed specifies seed of random source of rand command, values generated
+	// by the script depend only on it and name of the script.
+	// If zero, seed is chosen from the current time and printed into logs
+	// of scripts using rand command. Seed saved in checkpoint is used on resume.
+	Seed int64
+
+	// Measure is called, if not nil, with values measured by bench command
+	// of script with name. It may be called concurrently by scripts.
+	Measure func(script string, m Measurement)
+
+	// LazyFiles specifies that files of the script archive should be
+	// extracted into $WORK only when they are first used: mentioned in
+	// arguments of a command or read with ReadFile. Files used by
+	// commands only indirectly must be mentioned before such commands.
+	// Setup is called before files are extracted.
+	LazyFiles bool
+
+	// FixturesDir specifies the directory to cache large fixtures
+	// referenced by files of script archives in it. Content of such file
+	// is a single line "fixture sha256:<digest> <url or path>", fixture
+	// is fetched once and verified by digest.
+	FixturesDir string
+
+	// PrepareTemplate, if not nil, is called once before scripts start
+	// to fill the template directory dir with common fixtures. Content
+	// of dir is copied into $WORK of every script before files of the
+	// script archive are extracted, so files of archive override it.
+	PrepareTemplate func(dir string) error
+
+	// OutputLimit specifies number of bytes of standard output and error
+	// of every command retained in memory for stdout and stderr commands,
+	// older output is dropped. If zero, 16MiB are retained.
+	OutputLimit int
+
+	// OutputSpillDir specifies the directory to save complete output of
+	// commands exceeding OutputLimit into. Empty value disables saving.
+	OutputSpillDir string
+
+	// WorkdirPlacements specifies named directories (e.g. on tmpfs or on
+	// a fast volume) to create work directories of scripts inside of instead
+	// of WorkdirRoot, optionally with limit of their size. Scripts choose
+	// placement with workdir directive. Placement "ram" is /dev/shm by default.
+	WorkdirPlacements map[string]WorkdirPlacement
+
+	// ScriptWorkdirs specifies names of placements of work directories of
+	// scripts by names of scripts, workdir directive of script overrides it.
+	ScriptWorkdirs map[string]string
+
+	// TimeoutScale multiplies timeouts of commands given with -t and of
+	// waitport, e.g. when EVE is emulated and expected to be slower.
+	// Zero and one leave timeouts unchanged.
+	TimeoutScale int
+
+	// Resources is called, if not nil, when script with name starts and
+	// returns function called when the script ends, which returns summary
+	// of resources used during the script. The summary is logged with
+	// "resources:" prefix. It may be called concurrently by scripts.
+	Resources func(script string) func() string
+
 	Flags map[string]string
 }
 
@@ -228,6 +310,14 @@ func RunT(t T, p Params) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if p.Seed == 0 {
+		p.Seed = time.Now().UnixNano()
+	}
+	templateDir, err := prepareTemplate(testTempDir, p)
+	if err != nil {
+		t.Fatal(fmt.Sprintf("cannot prepare template of work directories: %v", err))
+	}
+	archives := parseArchives(files)
 	refCount := int32(len(files))
 	for _, file := range files {
 		file := file
@@ -236,16 +326,21 @@ func RunT(t T, p Params) {
 			t.Parallel()
 			ctx := context.Background()
 			ctxt, cancel := context.WithCancel(ctx)
+			parsed := archives[file]
 			ts := &TestScript{
 				t:             t,
 				testTempDir:   testTempDir,
+				templateDir:   templateDir,
+				archive:       parsed.archive,
 				name:          name,
 				file:          file,
 				params:        p,
+				randSeed:      scriptSeed(p.Seed, name),
 				ctxt:          ctxt,
 				cancel:        cancel,
 				deferred:      func() {},
 				scriptFiles:   make(map[string]string),
+				pendingFiles:  make(map[string]*txtar.File),
 				scriptUpdates: make(map[string]string),
 			}
 			defer func() {
@@ -256,9 +351,15 @@ func RunT(t T, p Params) {
 				if atomic.AddInt32(&refCount, -1) == 0 {
 					// This is the last subtest to finish. Remove the
 					// parent directory too.
+					if templateDir != "" {
+						_ = removeAll(templateDir)
+					}
 					_ = os.Remove(testTempDir)
 				}
 			}()
+			if parsed.err != nil {
+				ts.t.Fatal(parsed.err)
+			}
 			ts.run()
 		})
 	}
@@ -269,6 +370,7 @@ type TestScript struct {
 	params        Params
 	t             T
 	testTempDir   string
+	templateDir   string                      // template copied into $WORK, empty if none
 	workdir       string                      // temporary work dir ($WORK)
 	log           bytes.Buffer                // test execution log (printed at end of test)
 	mark          int                         // offset of next log truncation
@@ -287,9 +389,17 @@ type TestScript struct {
 	start         time.Time                   // time phase started
 	background    []backgroundCmd             // backgrounded 'exec' and 'go' commands
 	deferred      func()                      // deferred cleanup actions.
+	deferredCmds  []deferredCmd               // commands queued by 'defer' command
+	headerLines   map[int][]string            // directives placed before the first command by line numbers
+	workdirLimit  int64                       // limit of size of work directory, zero for no limit
 	archive       *txtar.Archive              // the testscript being run.
 	scriptFiles   map[string]string           // files stored in the txtar archive (absolute paths -> path in script)
+	pendingFiles  map[string]*txtar.File      // files of the txtar archive not extracted yet (absolute paths -> file)
 	scriptUpdates map[string]string           // updates to testscript files via UpdateScripts.
+	randSeed      int64                       // seed of the run for rand command
+	randCalls     int                         // number of rand commands executed
+	savedWork     string                      // fingerprint of $WORK copied into the last checkpoint
+	benchTimers   map[string]time.Time        // timers started by bench command
 
 	cancel context.CancelFunc
 	ctxt   context.Context // per TestScript context
@@ -304,8 +414,28 @@ type backgroundCmd struct {
 
 // setup sets up the test execution temporary directory and environment.
 // It returns the comment section of the txtar archive.
+// loadArchive parses archive of script unless it is parsed in advance by RunT.
+func (ts *TestScript) loadArchive() {
+	if ts.archive == nil {
+		a, err := txtar.ParseFile(ts.file)
+		ts.Check(err)
+		ts.archive = a
+	}
+}
+
 func (ts *TestScript) setup() string {
-	ts.workdir = filepath.Join(ts.testTempDir, "script-"+ts.name)
+	ts.loadArchive()
+	placement, err := ts.workdirPlacement()
+	ts.Check(err)
+	if placement != nil {
+		ts.Check(os.MkdirAll(placement.Dir, 0777))
+		// placement can be shared by runs, so name of work directory must be unique
+		ts.workdir, err = os.MkdirTemp(placement.Dir, "script-"+ts.name+"-")
+		ts.Check(err)
+		ts.workdirLimit = placement.MaxSize
+	} else {
+		ts.workdir = filepath.Join(ts.testTempDir, "script-"+ts.name)
+	}
 	ts.Check(os.MkdirAll(filepath.Join(ts.workdir, "tmp"), 0777))
 	env := &Env{
 		Vars: []string{
@@ -349,16 +479,11 @@ func (ts *TestScript) setup() string {
 		)
 	}
 	ts.cd = env.Cd
-	// Unpack archive.
-	a, err := txtar.ParseFile(ts.file)
-	ts.Check(err)
-	ts.archive = a
-	for _, f := range a.Files {
-		name := ts.MkAbs(ts.expand(f.Name))
-		ts.scriptFiles[name] = f.Name
-		ts.Check(os.MkdirAll(filepath.Dir(name), 0777))
-		ts.Check(os.WriteFile(name, f.Data, 0666))
+	if ts.templateDir != "" {
+		ts.Check(CopyTree(ts.templateDir, ts.workdir))
 	}
+	a := ts.archive
+	ts.Check(ts.extractFiles(a))
 	// Run any user-defined setup.
 	if ts.params.Setup != nil {
 		ts.Check(ts.params.Setup(env))
@@ -422,6 +547,17 @@ func (ts *TestScript) run() {
 	defer func() {
 		ts.deferred()
 	}()
+	// run commands queued by 'defer' if script fails
+	defer ts.runDeferredCmds()
+	ts.checkRequirements()
+	if ts.params.Resources != nil {
+		stop := ts.params.Resources(ts.name)
+		defer func() {
+			if summary := stop(); summary != "" {
+				ts.Logf("resources: %s", summary)
+			}
+		}()
+	}
 	script := ts.setup()
 
 	// With -v or -testwork, start log with full environment.
@@ -433,9 +569,27 @@ func (ts *TestScript) run() {
 	}
 	defer ts.applyScriptUpdates()
 
+	var resume *checkpoint
+	if ts.params.Resume && ts.params.CheckpointDir != "" {
+		var err error
+		resume, err = ts.loadCheckpoint()
+		ts.Check(err)
+		if resume == nil {
+			ts.Logf("no checkpoint found, running from the beginning\n")
+		} else if resume.Seed != 0 {
+			ts.params.Seed = resume.Seed
+			ts.randSeed = scriptSeed(resume.Seed, ts.name)
+		}
+	}
+	if randUsageRe.MatchString(script) {
+		// keep seed in the log to replay values of rand command
+		fmt.Fprintf(&ts.log, "rand seed: %d\n", ts.params.Seed)
+		ts.mark = ts.log.Len()
+	}
+	phase := 0
+
 	// Run script.
 	// See testdata/script/README for documentation of script form.
-Script:
 	for script != "" {
 		// Extract next line.
 		ts.lineno++
@@ -448,6 +602,25 @@ Script:
 
 		// # is a comment indicating the start of new phase.
 		if strings.HasPrefix(line, "#") {
+			phase++
+			switch {
+			case resume != nil && phase < resume.Phase:
+				// phase passed before the checkpoint
+				continue
+			case resume != nil && phase == resume.Phase:
+				ts.Check(ts.restoreCheckpoint(resume))
+				if line != resume.Heading {
+					ts.Logf("phase %d heading changed since checkpoint: %q\n", phase, resume.Heading)
+				}
+				if resume.Background > 0 {
+					ts.Logf("%d background commands started before phase %d are not restored\n", resume.Background, phase)
+				}
+				fmt.Fprintf(&ts.log, "resuming from phase %d\n", phase)
+			case ts.params.CheckpointDir != "":
+				if err := ts.saveCheckpoint(phase, line); err != nil {
+					ts.Logf("cannot save checkpoint: %v\n", err)
+				}
+			}
 			// If there was a previous phase, it succeeded,
 			// so rewind the log to delete its details (unless -v is in use).
 			// If nothing has happened at all since the mark,
@@ -464,6 +637,10 @@ Script:
 			continue
 		}
 
+		if resume != nil && phase < resume.Phase {
+			continue
+		}
+
 		// Parse input line. Ignore blanks entirely.
 		args := ts.parse(line)
 		if len(args) == 0 {
@@ -474,50 +651,8 @@ Script:
 		fmt.Printf("> %s\n", line)
 		fmt.Fprintf(&ts.log, "> %s\n", line)
 
-		// Command prefix [cond] means only run this command if cond is satisfied.
-		for strings.HasPrefix(args[0], "[") && strings.HasSuffix(args[0], "]") {
-			cond := args[0]
-			cond = cond[1 : len(cond)-1]
-			cond = strings.TrimSpace(cond)
-			args = args[1:]
-			if len(args) == 0 {
-				ts.Fatalf("missing command after condition")
-			}
-			want := true
-			if strings.HasPrefix(cond, "!") {
-				want = false
-				cond = strings.TrimSpace(cond[1:])
-			}
-			ok, err := ts.condition(cond)
-			if err != nil {
-				ts.Fatalf("bad condition %q: %v", cond, err)
-			}
-			if ok != want {
-				// Don't run rest of line.
-				continue Script
-			}
-		}
-
-		// Command prefix ! means negate the expectations about this command:
-		// go command should fail, match should not be found, etc.
-		neg := false
-		if args[0] == "!" {
-			neg = true
-			args = args[1:]
-			if len(args) == 0 {
-				ts.Fatalf("! on line by itself")
-			}
-		}
-
-		// Run command.
-		cmd := scriptCmds[args[0]]
-		if cmd == nil {
-			cmd = ts.params.Cmds[args[0]]
-		}
-		if cmd == nil {
-			ts.Fatalf("unknown command %q", args[0])
-		}
-		cmd(ts, neg, args[1:])
+		ts.runCommand(args)
+		ts.checkWorkdirSize()
 
 		// Command can ask script to stop early.
 		if ts.stopped {
@@ -527,11 +662,18 @@ Script:
 		}
 	}
 
+	ts.runDeferredCmds()
+
 	for _, bg := range ts.background {
 		interruptProcess(bg.cmd.Process)
 	}
 	ts.cmdWait(false, nil)
 
+	if resume != nil && phase < resume.Phase {
+		ts.Fatalf("phase %d to resume from not found in script", resume.Phase)
+	}
+	ts.removeCheckpoint()
+
 	// Final phase ended.
 	rewind()
 	markTime()
@@ -541,6 +683,55 @@ Script:
 	}
 }
 
+// runCommand runs command of parsed line of script with its condition and negation prefixes.
+func (ts *TestScript) runCommand(args []string) {
+	// Command prefix [cond] means only run this command if cond is satisfied.
+	for strings.HasPrefix(args[0], "[") && strings.HasSuffix(args[0], "]") {
+		cond := args[0]
+		cond = cond[1 : len(cond)-1]
+		cond = strings.TrimSpace(cond)
+		args = args[1:]
+		if len(args) == 0 {
+			ts.Fatalf("missing command after condition")
+		}
+		want := true
+		if strings.HasPrefix(cond, "!") {
+			want = false
+			cond = strings.TrimSpace(cond[1:])
+		}
+		ok, err := ts.condition(cond)
+		if err != nil {
+			ts.Fatalf("bad condition %q: %v", cond, err)
+		}
+		if ok != want {
+			// Don't run rest of line.
+			return
+		}
+	}
+
+	// Command prefix ! means negate the expectations about this command:
+	// go command should fail, match should not be found, etc.
+	neg := false
+	if args[0] == "!" {
+		neg = true
+		args = args[1:]
+		if len(args) == 0 {
+			ts.Fatalf("! on line by itself")
+		}
+	}
+
+	// Run command.
+	cmd := scriptCmds[args[0]]
+	if cmd == nil {
+		cmd = ts.params.Cmds[args[0]]
+	}
+	if cmd == nil {
+		ts.Fatalf("unknown command %q", args[0])
+	}
+	ts.extractReferenced(args[1:])
+	cmd(ts, neg, args[1:])
+}
+
 func hasFailed(t T) bool {
 	if t, ok := t.(TFailed); ok {
 		return t.Failed()
@@ -587,12 +778,17 @@ func (ts *TestScript) condition(cond string) (bool, error) {
 	switch cond {
 	case "short":
 		return testing.Short(), nil
-	case "net":
-		return testenv.HasExternalNetwork(), nil
-	case "link":
-		return testenv.HasLink(), nil
-	case "symlink":
-		return testenv.HasSymlink(), nil
+	case "net", "link", "symlink":
+		return condCache.Do(ts.ctxt, cond, func(context.Context) (bool, error) {
+			switch cond {
+			case "net":
+				return testenv.HasExternalNetwork(), nil
+			case "link":
+				return testenv.HasLink(), nil
+			default:
+				return testenv.HasSymlink(), nil
+			}
+		})
 	case runtime.GOOS, runtime.GOARCH:
 		return true, nil
 	default:
@@ -601,11 +797,10 @@ func (ts *TestScript) condition(cond string) (bool, error) {
 		}
 		if strings.HasPrefix(cond, "exec:") {
 			prog := cond[len("exec:"):]
-			ok := execCache.Do(prog, func() interface{} {
+			return execCache.Do(ts.ctxt, prog, func(context.Context) (bool, error) {
 				_, err := execpath.Look(prog, ts.Getenv)
-				return err == nil
-			}).(bool)
-			return ok, nil
+				return err == nil, nil
+			})
 		}
 		if strings.HasPrefix(cond, "stdout:") || strings.HasPrefix(cond, "stderr:") {
 			var pattern, source string
@@ -636,6 +831,10 @@ func (ts *TestScript) condition(cond string) (bool, error) {
 
 // abbrev abbreviates the actual work directory in the string s to the literal string "$WORK".
 func (ts *TestScript) abbrev(s string) string {
+	if ts.workdir == "" {
+		// script is skipped before setup
+		return s
+	}
 	s = strings.Replace(s, ts.workdir, "$WORK", -1)
 	if *testWork || ts.params.TestWork {
 		// Expose actual $WORK value in environment dump on first line of work script,
@@ -684,31 +883,31 @@ func (ts *TestScript) exec(command string, args ...string) (stdout, stderr strin
 	cmd.Dir = ts.cd
 	cmd.Env = append(ts.env, "PWD="+ts.cd)
 	cmd.Stdin = strings.NewReader(ts.stdin)
-	var stdoutBuf, stderrBuf strings.Builder
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
+	stdoutBuf, stderrBuf := ts.newOutputBuffers()
+	cmd.Stdout = stdoutBuf
+	cmd.Stderr = stderrBuf
 	if err = cmd.Start(); err == nil {
 		err = ctxWait(ctx, cmd)
 	}
 	ts.stdin = ""
-	return stdoutBuf.String(), stderrBuf.String(), err
+	return ts.collectOutput("stdout", stdoutBuf), ts.collectOutput("stderr", stderrBuf), err
 }
 
 // execBackground starts the given command line (an actual subprocess, not simulated)
 // in ts.cd with environment ts.env.
-func (ts *TestScript) execBackground(command string, args ...string) (*exec.Cmd, context.CancelFunc, *strings.Builder, *strings.Builder, error) {
+func (ts *TestScript) execBackground(command string, args ...string) (*exec.Cmd, context.CancelFunc, *outputBuffer, *outputBuffer, error) {
 	_, cmd, cancelFunc, err := ts.buildExecCmd(command, args...)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 	cmd.Dir = ts.cd
 	cmd.Env = append(ts.env, "PWD="+ts.cd)
-	var stdoutBuf, stderrBuf strings.Builder
+	stdoutBuf, stderrBuf := ts.newOutputBuffers()
 	cmd.Stdin = strings.NewReader(ts.stdin)
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
+	cmd.Stdout = stdoutBuf
+	cmd.Stderr = stderrBuf
 	ts.stdin = ""
-	return cmd, cancelFunc, &stdoutBuf, &stderrBuf, cmd.Start()
+	return cmd, cancelFunc, stdoutBuf, stderrBuf, cmd.Start()
 }
 
 func (ts *TestScript) buildExecCmd(command string, args ...string) (context.Context, *exec.Cmd, context.CancelFunc, error) {
@@ -725,10 +924,18 @@ func (ts *TestScript) buildExecCmd(command string, args ...string) (context.Cont
 	}
 	//ts.ctxt, _ = context.WithTimeout(context.Background(), timewait)
 	//return exec.CommandContext(ts.ctxt, command, args...), nil
-	ctx, cancelFunc := context.WithTimeout(ts.ctxt, timewait)
+	ctx, cancelFunc := context.WithTimeout(ts.ctxt, ts.scaleTimeout(timewait))
 	return ctx, exec.CommandContext(ctx, command, args...), cancelFunc, nil
 }
 
+// scaleTimeout applies TimeoutScale of params to timeout.
+func (ts *TestScript) scaleTimeout(timeout time.Duration) time.Duration {
+	if ts.params.TimeoutScale > 1 {
+		return timeout * time.Duration(ts.params.TimeoutScale)
+	}
+	return timeout
+}
+
 // BackgroundCmds returns a slice containing all the commands that have
 // been started in the background since the most recent wait command, or
 // the start of the script if wait has not been called.
@@ -870,6 +1077,7 @@ func (ts *TestScript) ReadFile(file string) string {
 		return ts.stderr
 	default:
 		file = ts.MkAbs(file)
+		ts.extractPending(func(name, _ string) bool { return name == file })
 		data, err := os.ReadFile(file)
 		ts.Check(err)
 		return string(data)
@@ -5,8 +5,17 @@
 package testscript
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -17,6 +26,12 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 func printArgs() int {
@@ -393,3 +408,862 @@ func (t *fakeT) Verbose() bool {
 func (t *fakeT) Failed() bool {
 	return t.failed
 }
+
+// TestCheckpointResume verifies that failed script is resumed from the phase
+// it failed at with environment and $WORK of this phase
+func TestCheckpointResume(t *testing.T) {
+	scriptDir := t.TempDir()
+	checkpointDir := t.TempDir()
+	script := `# prepare
+prepare
+env FOO=$WORK/bar
+mkdir data
+# check
+exists data
+check
+`
+	if err := os.WriteFile(filepath.Join(scriptDir, "resume.txt"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prepared := 0
+	fail := true
+	run := func(resume bool) (ft *fakeT) {
+		ft = &fakeT{ts: &TestScript{}}
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(ft, Params{
+			Dir:           scriptDir,
+			WorkdirRoot:   t.TempDir(),
+			CheckpointDir: checkpointDir,
+			Resume:        resume,
+			Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+				"prepare": func(ts *TestScript, neg bool, args []string) {
+					prepared++
+				},
+				"check": func(ts *TestScript, neg bool, args []string) {
+					if want := ts.MkAbs("bar"); ts.Getenv("FOO") != want {
+						ts.Fatalf("expected FOO to be %q; got %q", want, ts.Getenv("FOO"))
+					}
+					if fail {
+						ts.Fatalf("check failed")
+					}
+				},
+			},
+		})
+		return ft
+	}
+
+	if ft := run(false); !ft.failed {
+		t.Fatal("expected the first run to fail")
+	}
+	if _, err := os.Stat(filepath.Join(checkpointDir, "resume", checkpointStateFile)); err != nil {
+		t.Fatalf("expected checkpoint to be saved: %v", err)
+	}
+	fail = false
+	if ft := run(true); ft.failed {
+		t.Fatalf("expected resumed run to pass: %v", ft.failMsgs)
+	}
+	if prepared != 1 {
+		t.Fatalf("expected the first phase to run once; got %d", prepared)
+	}
+	if _, err := os.Stat(filepath.Join(checkpointDir, "resume")); !os.IsNotExist(err) {
+		t.Fatalf("expected checkpoint to be removed after pass: %v", err)
+	}
+}
+
+func TestRandSeed(t *testing.T) {
+	scriptDir := t.TempDir()
+	script := `# generate
+rand name eden- NAME
+rand port 30000 30100 PORT
+rand cidr 192.168.0.0/16 28 CIDR
+record
+`
+	if err := os.WriteFile(filepath.Join(scriptDir, "rand.txt"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(seed int64) string {
+		var values string
+		ft := &fakeT{ts: &TestScript{}}
+		RunT(ft, Params{
+			Dir:  scriptDir,
+			Seed: seed,
+			Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+				"record": func(ts *TestScript, neg bool, args []string) {
+					values = strings.Join([]string{ts.Getenv("NAME"), ts.Getenv("PORT"), ts.Getenv("CIDR")}, " ")
+				},
+			},
+		})
+		if ft.failed {
+			t.Fatalf("script failed: %v", ft.failMsgs)
+		}
+		return values
+	}
+	first := run(42)
+	if !regexp.MustCompile(`^eden-[a-z0-9]{8} 30[01]\d\d 192\.168\.\d+\.\d+/28$`).MatchString(first) {
+		t.Fatalf("unexpected values: %q", first)
+	}
+	if second := run(42); second != first {
+		t.Fatalf("expected the same values with the same seed; got %q and %q", first, second)
+	}
+	if other := run(43); other == first {
+		t.Fatalf("expected other values with other seed; got %q", other)
+	}
+}
+
+func TestRandPort(t *testing.T) {
+	port, err := randPort(rand.New(rand.NewSource(42)), 30000, 39999)
+	if err != nil {
+		t.Skipf("port chosen with the seed is used on the host: %v", err)
+	}
+	// the same port is chosen only by the seed while it is used on the host
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if busy, err := randPort(rand.New(rand.NewSource(42)), 30000, 39999); err == nil {
+		t.Fatalf("expected error for used port %d; got port %d", port, busy)
+	}
+	if _, err := randPort(rand.New(rand.NewSource(42)), 100, 10); err == nil {
+		t.Fatal("expected error for invalid range")
+	}
+}
+
+func TestParseSdnArgs(t *testing.T) {
+	tc, err := parseTrafficControl([]string{"delay=100ms", "jitter=10ms", "loss=5", "rate=1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.Delay != 100 || tc.DelayJitter != 10 || tc.LossProbability != 5 ||
+		tc.RateLimit != 1000 || tc.QueueLimit != 1000 || tc.BurstLimit != 100 {
+		t.Errorf("unexpected traffic control: %+v", tc)
+	}
+	if _, err := parseTrafficControl([]string{"loss=150"}); err == nil {
+		t.Error("expected error for loss above 100 percent")
+	}
+	rule, err := parseFwRule("drop", []string{"dst=10.0.0.0/8", "proto=tcp", "ports=80,443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Action != model.FwDrop || rule.Protocol != model.TCP || rule.DstSubnet != "10.0.0.0/8" ||
+		len(rule.Ports) != 2 || rule.Ports[1] != 443 {
+		t.Errorf("unexpected firewall rule: %+v", rule)
+	}
+}
+
+func TestOutputBuffer(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "out", "script-1-stdout.log")
+	b := newOutputBuffer(8, spillPath)
+	var written strings.Builder
+	for _, s := range []string{"abc", "defgh", "ijklmnopqrst", "uv", "w"} {
+		if n, err := b.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("unexpected result of write: %d %v", n, err)
+		}
+		written.WriteString(s)
+		all := written.String()
+		expected := all[len(all)-min(len(all), 8):]
+		if got := b.String(); got != expected {
+			t.Fatalf("expected retained %q, got %q", expected, got)
+		}
+	}
+	if note := b.close(); !strings.Contains(note, "15 bytes dropped") {
+		t.Errorf("unexpected note: %q", note)
+	}
+	data, err := os.ReadFile(spillPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != written.String() {
+		t.Errorf("expected complete output %q in spill file, got %q", written.String(), data)
+	}
+
+	small := newOutputBuffer(8, spillPath+".small")
+	small.Write([]byte("short"))
+	if note := small.close(); note != "" || small.String() != "short" {
+		t.Errorf("unexpected output %q with note %q", small.String(), note)
+	}
+	if _, err := os.Stat(spillPath + ".small"); !os.IsNotExist(err) {
+		t.Errorf("expected no spill file for output within limit")
+	}
+}
+
+func TestLazyFiles(t *testing.T) {
+	scriptDir := t.TempDir()
+	fixture := []byte("large fixture content\n")
+	digest := sha256.Sum256(fixture)
+	fixtureFile := filepath.Join(t.TempDir(), "fixture.img")
+	if err := os.WriteFile(fixtureFile, fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf(`extracted 0
+exists used.txt
+extracted 1
+cmp disk.img fixture.txt
+extracted 3
+cp used.txt disk.img
+
+-- used.txt --
+used
+-- unused.txt --
+unused
+-- fixture.txt --
+large fixture content
+-- disk.img --
+fixture sha256:%x %s
+`, digest, fixtureFile)
+	if err := os.WriteFile(filepath.Join(scriptDir, "lazy.txt"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fixturesDir := t.TempDir()
+	ft := &fakeT{ts: &TestScript{}}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(ft, Params{
+			Dir:         scriptDir,
+			WorkdirRoot: t.TempDir(),
+			LazyFiles:   true,
+			FixturesDir: fixturesDir,
+			Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+				"extracted": func(ts *TestScript, neg bool, args []string) {
+					entries, err := os.ReadDir(ts.MkAbs("."))
+					ts.Check(err)
+					files := 0
+					for _, entry := range entries {
+						if !entry.IsDir() {
+							files++
+						}
+					}
+					if strconv.Itoa(files) != args[0] {
+						ts.Fatalf("expected %s files extracted, got %d", args[0], files)
+					}
+				},
+			},
+		})
+	}()
+	if ft.failed {
+		t.Fatalf("script failed: %v", ft.failMsgs)
+	}
+	// script writing into extracted fixture does not change the cache
+	cached, err := os.ReadFile(filepath.Join(fixturesDir, fmt.Sprintf("%x", digest)))
+	if err != nil {
+		t.Fatalf("expected fixture to be cached: %v", err)
+	}
+	if !bytes.Equal(cached, fixture) {
+		t.Fatalf("cached fixture changed: %q", cached)
+	}
+	if _, _, ok := parseFixture([]byte("fixture sha256:1234 file")); ok {
+		t.Fatal("expected invalid digest not to be parsed as fixture")
+	}
+}
+
+func TestFixtureCacheVerified(t *testing.T) {
+	fixture := []byte("fixture with corrupted cache\n")
+	digest := fmt.Sprintf("%x", sha256.Sum256(fixture))
+	fixtureFile := filepath.Join(t.TempDir(), "fixture.img")
+	if err := os.WriteFile(fixtureFile, fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, digest), []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	name, err := fetchFixture(context.Background(), cacheDir, digest, fixtureFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, fixture) {
+		t.Fatalf("expected corrupted cache entry to be fetched again, got %q", data)
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm()&0222 != 0 {
+		t.Fatalf("expected cached fixture to be read-only, got %v", fi.Mode())
+	}
+}
+
+func TestPrepareTemplate(t *testing.T) {
+	scriptDir := t.TempDir()
+	for i := 0; i < 8; i++ {
+		script := fmt.Sprintf(`grep '^common$' common/fixture.txt
+grep '^overridden %d$' override.txt
+cp own.txt common/fixture.txt
+grep '^own %d$' common/fixture.txt
+
+-- override.txt --
+overridden %d
+-- own.txt --
+own %d
+`, i, i, i, i)
+		if err := os.WriteFile(filepath.Join(scriptDir, fmt.Sprintf("script%d.txt", i)), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prepared := 0
+	ft := &fakeT{ts: &TestScript{}}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(ft, Params{
+			Dir:         scriptDir,
+			WorkdirRoot: t.TempDir(),
+			PrepareTemplate: func(dir string) error {
+				prepared++
+				if err := os.MkdirAll(filepath.Join(dir, "common"), 0755); err != nil {
+					return err
+				}
+				if err := os.WriteFile(filepath.Join(dir, "common", "fixture.txt"), []byte("common\n"), 0644); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dir, "override.txt"), []byte("template\n"), 0644)
+			},
+		})
+	}()
+	if ft.failed {
+		t.Fatalf("script failed: %v", ft.failMsgs)
+	}
+	if prepared != 1 {
+		t.Fatalf("expected template to be prepared once, got %d", prepared)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/flaky" && requests < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.Method == http.MethodPost:
+			data, _ := io.ReadAll(r.Body)
+			w.Header().Set("Location", "/items/1")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, "%s %s", r.Header.Get("X-Test"), data)
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+		default:
+			fmt.Fprint(w, "hello")
+		}
+	}))
+	defer server.Close()
+
+	scriptDir := t.TempDir()
+	script := `http GET $SERVER/
+stdout '^hello$'
+stderr '200 OK'
+httpresult 200 hello
+http POST $SERVER/items --header X-Test:value --body item.json --status 201
+stdout '^value \{"name": "item"\}$'
+stderr 'Location: /items/1'
+! http GET $SERVER/missing
+httpresult 404 '404 page not found'
+http GET $SERVER/missing --status 404
+http GET $SERVER/flaky --retries 3 --delay 10ms
+stdout '^hello$'
+! http GET http://127.0.0.1:1/ --timeout 1s
+-- item.json --
+{"name": "item"}
+`
+	if err := os.WriteFile(filepath.Join(scriptDir, "http.txt"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ft := &fakeT{ts: &TestScript{}}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(ft, Params{
+			Dir: scriptDir,
+			Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+				"httpresult": func(ts *TestScript, neg bool, args []string) {
+					if ts.Getenv("HTTP_STATUS") != args[0] || strings.TrimSpace(ts.Getenv("HTTP_BODY")) != args[1] {
+						ts.Fatalf("unexpected status %q with body %q", ts.Getenv("HTTP_STATUS"), ts.Getenv("HTTP_BODY"))
+					}
+				},
+			},
+			Setup: func(env *Env) error {
+				env.Vars = append(env.Vars, "SERVER="+server.URL)
+				return nil
+			},
+		})
+	}()
+	if ft.failed {
+		t.Fatalf("script failed: %v", ft.failMsgs)
+	}
+
+	if _, err := parseHTTPArgs([]string{"GET"}, os.ReadFile); err == nil {
+		t.Error("expected error without url")
+	}
+	if _, err := parseHTTPArgs([]string{"GET", "http://host", "--header", "novalue"}, os.ReadFile); err == nil {
+		t.Error("expected error for header without value")
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("eden", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	scriptDir := t.TempDir()
+	script := `grpc call $SERVER grpc.health.v1.Health/Check empty.json
+stdout '"status": "SERVING"'
+stderr '^OK$'
+grpc call $SERVER /grpc.health.v1.Health/Check eden.json --header x-test:value
+stdout '"status": "NOT_SERVING"'
+! grpc call $SERVER grpc.health.v1.Health/Check unknown.json
+stderr '^NOT_FOUND: unknown service$'
+grpcstatus NOT_FOUND
+! grpc call $SERVER grpc.health.v1.Health/Missing empty.json
+stderr 'method Missing not found'
+! grpc call $SERVER grpc.health.v1.Health/Check invalid.json
+stderr 'cannot parse request as grpc.health.v1.HealthCheckRequest'
+-- empty.json --
+{}
+-- eden.json --
+{"service": "eden"}
+-- unknown.json --
+{"service": "unknown"}
+-- invalid.json --
+{"unknown": 1}
+`
+	if err := os.WriteFile(filepath.Join(scriptDir, "grpc.txt"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ft := &fakeT{ts: &TestScript{}}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(ft, Params{
+			Dir: scriptDir,
+			Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+				"grpcstatus": func(ts *TestScript, neg bool, args []string) {
+					if ts.Getenv("GRPC_STATUS") != args[0] {
+						ts.Fatalf("unexpected status %q", ts.Getenv("GRPC_STATUS"))
+					}
+				},
+			},
+			Setup: func(env *Env) error {
+				env.Vars = append(env.Vars, "SERVER="+listener.Addr().String())
+				return nil
+			},
+		})
+	}()
+	if ft.failed {
+		t.Fatalf("script failed: %v", ft.failMsgs)
+	}
+
+	if _, err := parseGRPCArgs([]string{"call", "host:1", "Service", "empty.json"}, os.ReadFile); err == nil {
+		t.Error("expected error for method without service")
+	}
+	if _, err := parseGRPCArgs([]string{"invoke", "host:1", "pkg.Service/Method", "empty.json"}, os.ReadFile); err == nil {
+		t.Error("expected error for unknown subcommand")
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	pairs, err := parseEnvFile("A=1\n\n# comment\nexport B=\"x\\ty\"\nC=\nD=a=b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{{"A", "1"}, {"B", "x\ty"}, {"C", ""}, {"D", "a=b"}}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("unexpected pairs %q, expected %q", pairs, want)
+	}
+	for _, data := range []string{"NOVALUE", "1KEY=value", "KEY=\"unterminated\\\""} {
+		if _, err := parseEnvFile(data); err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+}
+
+func TestExtractTarOutside(t *testing.T) {
+	for _, name := range []string{"../escaped.txt", "/abs.txt", "a/../../escaped.txt"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte("x")); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		dir := filepath.Join(t.TempDir(), "dst")
+		if err := extractTar(&buf, dir); err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "..", "escaped.txt")); err == nil {
+			t.Errorf("%q is extracted outside of destination", name)
+		}
+	}
+}
+
+func TestRequires(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		script  string
+		skipped bool
+		failed  bool
+	}{{
+		name:   "met",
+		script: "# description\nrequires exec:cat !exec:nonexistentprogram\nrequires custom\nexists $WORK\n",
+	}, {
+		name:    "unmet",
+		script:  "requires exec:cat\nrequires exec:nonexistentprogram\nexists $WORK\n",
+		skipped: true,
+	}, {
+		name:   "misplaced",
+		script: "exists $WORK\nrequires exec:cat\n",
+		failed: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			scriptDir, workdirRoot := t.TempDir(), t.TempDir()
+			if err := os.WriteFile(filepath.Join(scriptDir, "requires.txt"), []byte(tc.script), 0644); err != nil {
+				t.Fatal(err)
+			}
+			ft := &fakeT{ts: &TestScript{}}
+			func() {
+				defer func() {
+					if err := recover(); err != nil && err != errAbort {
+						panic(err)
+					}
+				}()
+				RunT(ft, Params{
+					Dir:         scriptDir,
+					WorkdirRoot: workdirRoot,
+					Condition: func(ts *TestScript, cond string) (bool, error) {
+						return cond == "custom", nil
+					},
+				})
+			}()
+			if ft.failed != tc.failed {
+				t.Errorf("unexpected failure %t of script: %v", ft.failed, ft.failMsgs)
+			}
+			// work directory is not created for skipped script
+			_, err := os.Stat(filepath.Join(workdirRoot, "script-requires"))
+			if skipped := os.IsNotExist(err); skipped != tc.skipped {
+				t.Errorf("unexpected skip %t of script", skipped)
+			}
+		})
+	}
+
+	conds, lines := scriptRequirements("# comment\n\nrequires a b\nrequires !c\nexec x\nrequires d\n")
+	if want := []string{"a", "b", "!c"}; !reflect.DeepEqual(conds, want) {
+		t.Errorf("unexpected requirements %q, expected %q", conds, want)
+	}
+	if want := map[int]bool{3: true, 4: true}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("unexpected lines of requirements %v, expected %v", lines, want)
+	}
+}
+
+func TestWorkdirPlacement(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		script         string
+		scriptWorkdirs map[string]string
+		failed         bool
+	}{{
+		name:   "directive",
+		script: "workdir fast\nwrite 2048\ninplacement\n",
+	}, {
+		name:           "params",
+		script:         "write 2048\ninplacement\n",
+		scriptWorkdirs: map[string]string{"placement": "fast"},
+	}, {
+		name:   "limit of script",
+		script: "workdir fast 1KiB\nwrite 512\nwrite 2048\n",
+		failed: true,
+	}, {
+		name:   "limit of placement",
+		script: "workdir limited\nwrite 2048\n",
+		failed: true,
+	}, {
+		name:   "unknown",
+		script: "workdir unknown\nwrite 1\n",
+		failed: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			scriptDir, placementDir := t.TempDir(), t.TempDir()
+			if err := os.WriteFile(filepath.Join(scriptDir, "placement.txt"), []byte(tc.script), 0644); err != nil {
+				t.Fatal(err)
+			}
+			ft := &fakeT{ts: &TestScript{}}
+			func() {
+				defer func() {
+					if err := recover(); err != nil && err != errAbort {
+						panic(err)
+					}
+				}()
+				RunT(ft, Params{
+					Dir: scriptDir,
+					WorkdirPlacements: map[string]WorkdirPlacement{
+						"fast":    {Dir: placementDir},
+						"limited": {Dir: placementDir, MaxSize: 1024},
+					},
+					ScriptWorkdirs: tc.scriptWorkdirs,
+					Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+						"write": func(ts *TestScript, neg bool, args []string) {
+							size, err := strconv.Atoi(args[0])
+							ts.Check(err)
+							ts.Check(os.WriteFile(ts.MkAbs("file"+args[0]), make([]byte, size), 0644))
+						},
+						"inplacement": func(ts *TestScript, neg bool, args []string) {
+							if filepath.Dir(ts.Getenv("WORK")) != placementDir {
+								ts.Fatalf("work directory %s is not placed into %s", ts.Getenv("WORK"), placementDir)
+							}
+						},
+					},
+				})
+			}()
+			if ft.failed != tc.failed {
+				t.Errorf("unexpected failure %t of script: %v", ft.failed, ft.failMsgs)
+			}
+			// work directory is removed from placement after the script
+			if entries, _ := os.ReadDir(placementDir); len(entries) > 0 {
+				t.Errorf("work directory is left in placement: %s", entries[0].Name())
+			}
+		})
+	}
+
+	placements, err := ParseWorkdirPlacements("ram=/dev/shm:4GiB, fast=/mnt/nvme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]WorkdirPlacement{
+		"ram":  {Dir: "/dev/shm", MaxSize: 4 << 30},
+		"fast": {Dir: "/mnt/nvme"},
+	}
+	if !reflect.DeepEqual(placements, want) {
+		t.Errorf("unexpected placements %v, expected %v", placements, want)
+	}
+	for _, spec := range []string{"ram", "ram=/dev/shm:lots", "=/tmp"} {
+		if _, err := ParseWorkdirPlacements(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestDefer(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		script string
+		failed bool
+		want   []string
+	}{{
+		name: "success",
+		script: `env NAME=second
+defer record first
+defer record $NAME
+env NAME=changed
+defer [exec:nonexistentprogram] record skipped
+defer [!exec:nonexistentprogram] record conditional
+defer ! fail
+record body
+`,
+		want: []string{"body", "conditional", "second", "first"},
+	}, {
+		name: "failure",
+		script: `defer record first
+defer fail
+defer record second
+record body
+fail
+record unreachable
+`,
+		failed: true,
+		want:   []string{"body", "second", "first"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			scriptDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(scriptDir, "defer.txt"), []byte(tc.script), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var records []string
+			ft := &fakeT{ts: &TestScript{}}
+			func() {
+				defer func() {
+					if err := recover(); err != nil && err != errAbort {
+						panic(err)
+					}
+				}()
+				RunT(ft, Params{
+					Dir: scriptDir,
+					Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+						"record": func(ts *TestScript, neg bool, args []string) {
+							records = append(records, args...)
+						},
+						"fail": func(ts *TestScript, neg bool, args []string) {
+							if !neg {
+								ts.Fatalf("failed")
+							}
+						},
+					},
+				})
+			}()
+			if ft.failed != tc.failed {
+				t.Errorf("unexpected failure %t of script: %v", ft.failed, ft.failMsgs)
+			}
+			if !reflect.DeepEqual(records, tc.want) {
+				t.Errorf("unexpected order of commands %q, expected %q", records, tc.want)
+			}
+		})
+	}
+}
+
+func TestWaitport(t *testing.T) {
+	defer func(interval time.Duration) { waitportInterval = interval }(waitportInterval)
+	waitportInterval = 10 * time.Millisecond
+
+	ready := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-ready:
+			fmt.Fprint(w, "ready")
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	// port which is free, but accepts connections only after a while
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	delayed := listener.Addr().String()
+	listener.Close()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(ready)
+		if listener, err := net.Listen("tcp", delayed); err == nil {
+			defer listener.Close()
+			<-time.After(5 * time.Second)
+		}
+	}()
+
+	scriptDir := t.TempDir()
+	script := `waitport -t 5s $SERVER
+waitport -t 5s $DELAYED
+waitport -t 5s $SERVER --http /health
+waitport -t 5s $SERVER --http /health --status 200
+! waitport -t 5s $SERVER --http /health --status 503
+! waitport -t 200ms 127.0.0.1:1
+`
+	if err := os.WriteFile(filepath.Join(scriptDir, "waitport.txt"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ft := &fakeT{ts: &TestScript{}}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(ft, Params{
+			Dir: scriptDir,
+			Setup: func(env *Env) error {
+				env.Vars = append(env.Vars,
+					"SERVER="+strings.TrimPrefix(server.URL, "http://"),
+					"DELAYED="+delayed)
+				return nil
+			},
+		})
+	}()
+	if ft.failed {
+		t.Fatalf("script failed: %v", ft.failMsgs)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"localhost"},
+		{"localhost:80", "--status", "200"},
+		{"-t", "forever", "localhost:80"},
+	} {
+		if _, err := parseWaitportArgs(args); err == nil {
+			t.Errorf("expected error for arguments %q", args)
+		}
+	}
+}
+
+// TestCheckpointUnchangedWork verifies that $WORK is not copied into checkpoint
+// again if the previous phase did not change it
+func TestCheckpointUnchangedWork(t *testing.T) {
+	scriptDir := t.TempDir()
+	checkpointDir := t.TempDir()
+	script := `# create
+mkdir data
+# keep
+record
+# check
+compare
+`
+	if err := os.WriteFile(filepath.Join(scriptDir, "unchanged.txt"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	savedWork := filepath.Join(checkpointDir, "unchanged", checkpointWorkDir)
+	var recorded os.FileInfo
+	ft := &fakeT{ts: &TestScript{}}
+	func() {
+		defer func() {
+			if err := recover(); err != nil && err != errAbort {
+				panic(err)
+			}
+		}()
+		RunT(ft, Params{
+			Dir:           scriptDir,
+			WorkdirRoot:   t.TempDir(),
+			CheckpointDir: checkpointDir,
+			Cmds: map[string]func(ts *TestScript, neg bool, args []string){
+				"record": func(ts *TestScript, neg bool, args []string) {
+					var err error
+					recorded, err = os.Stat(filepath.Join(savedWork, "data"))
+					ts.Check(err)
+				},
+				"compare": func(ts *TestScript, neg bool, args []string) {
+					current, err := os.Stat(filepath.Join(savedWork, "data"))
+					ts.Check(err)
+					if !os.SameFile(recorded, current) {
+						ts.Fatalf("unchanged $WORK is copied into checkpoint again")
+					}
+					cp, err := ts.loadCheckpoint()
+					ts.Check(err)
+					if cp.Phase != 3 {
+						ts.Fatalf("expected checkpoint of phase 3; got %d", cp.Phase)
+					}
+				},
+			},
+		})
+	}()
+	if ft.failed {
+		t.Fatalf("unexpected failure: %v", ft.failMsgs)
+	}
+}
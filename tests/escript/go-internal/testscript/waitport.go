@@ -0,0 +1,141 @@
+package testscript
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	waitportUsage          = "usage: waitport [-t timeout] host:port [--http path] [--status code]"
+	waitportDefaultTimeout = time.Minute
+	// waitportProbeTimeout limits time of one probe of port
+	waitportProbeTimeout = 5 * time.Second
+)
+
+// waitportInterval is delay between probes of port
+var waitportInterval = time.Second
+
+// waitportProbe checks readiness of port with TCP connection or HTTP request
+type waitportProbe struct {
+	addr    string
+	timeout time.Duration
+	// http is request sent to port if it is set, TCP connection is enough otherwise
+	http *httpRequest
+}
+
+// parseWaitportArgs parses arguments of waitport command
+func parseWaitportArgs(args []string) (*waitportProbe, error) {
+	probe := &waitportProbe{timeout: waitportDefaultTimeout}
+	var path string
+	var status int
+	for len(args) > 0 {
+		arg := args[0]
+		if !strings.HasPrefix(arg, "-") {
+			if probe.addr != "" {
+				return nil, fmt.Errorf("unexpected argument %q: %s", arg, waitportUsage)
+			}
+			probe.addr = arg
+			args = args[1:]
+			continue
+		}
+		if len(args) < 2 {
+			return nil, fmt.Errorf("no value of %s: %s", arg, waitportUsage)
+		}
+		value := args[1]
+		args = args[2:]
+		var err error
+		switch arg {
+		case "-t":
+			if probe.timeout, err = time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid timeout %q: %w", value, err)
+			}
+		case "--http":
+			path = value
+		case "--status":
+			if status, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid status %q: %w", value, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown option %s: %s", arg, waitportUsage)
+		}
+	}
+	if probe.addr == "" {
+		return nil, fmt.Errorf("no address: %s", waitportUsage)
+	}
+	if _, _, err := net.SplitHostPort(probe.addr); err != nil {
+		return nil, fmt.Errorf("address %q is not in format host:port: %w", probe.addr, err)
+	}
+	if status != 0 && path == "" {
+		return nil, fmt.Errorf("--status is set without --http: %s", waitportUsage)
+	}
+	if path != "" {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		probe.http = &httpRequest{
+			method:  "GET",
+			url:     fmt.Sprintf("http://%s%s", probe.addr, path),
+			status:  status,
+			timeout: waitportProbeTimeout,
+		}
+	}
+	return probe, nil
+}
+
+// ready probes port once and returns nil if it is ready
+func (probe *waitportProbe) ready(ctx context.Context) error {
+	if probe.http != nil {
+		status, _, _, err := probe.http.do(ctx)
+		if err != nil {
+			return err
+		}
+		if !probe.http.expected(status) {
+			return fmt.Errorf("unexpected status %d", status)
+		}
+		return nil
+	}
+	conn, err := (&net.Dialer{Timeout: waitportProbeTimeout}).DialContext(ctx, "tcp", probe.addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// waitport waits until port accepts connections or HTTP probe succeeds,
+// with ! it waits until port stops to be ready.
+func (ts *TestScript) cmdWaitport(neg bool, args []string) {
+	probe, err := parseWaitportArgs(args)
+	if err != nil {
+		ts.Fatalf("%v", err)
+	}
+	probe.timeout = ts.scaleTimeout(probe.timeout)
+	ctx, cancel := context.WithTimeout(ts.ctxt, probe.timeout)
+	defer cancel()
+	start := time.Now()
+	for {
+		err = probe.ready(ctx)
+		if (err == nil) != neg {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			if ts.ctxt.Err() != nil {
+				ts.Fatalf("test interrupted while running command")
+			}
+			if neg {
+				ts.Fatalf("%s is still ready after %s", probe.addr, probe.timeout)
+			}
+			ts.Fatalf("%s is not ready after %s: %v", probe.addr, probe.timeout, err)
+		case <-time.After(waitportInterval):
+		}
+	}
+	state := "ready"
+	if neg {
+		state = "not ready"
+	}
+	fmt.Fprintf(&ts.log, "[%s is %s after %s]\n", probe.addr, state, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,175 @@
+package testscript
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/lf-edge/eden/tests/escript/go-internal/txtar"
+)
+
+// templateDirName is the name of the directory inside the temporary
+// directory of the run which holds template of work directories.
+const templateDirName = ".template"
+
+// parallel calls f for every index in [0, n) using at most GOMAXPROCS
+// goroutines and returns the first error returned by f.
+func parallel(n int, f func(i int) error) error {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := f(i); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return firstErr
+}
+
+// parsedArchive is the result of parsing of script file.
+type parsedArchive struct {
+	archive *txtar.Archive
+	err     error
+}
+
+// parseArchives parses script files concurrently, errors are reported
+// by scripts themselves on setup.
+func parseArchives(files []string) map[string]parsedArchive {
+	parsed := make([]parsedArchive, len(files))
+	_ = parallel(len(files), func(i int) error {
+		parsed[i].archive, parsed[i].err = txtar.ParseFile(files[i])
+		return nil
+	})
+	result := make(map[string]parsedArchive, len(files))
+	for i, file := range files {
+		result[file] = parsed[i]
+	}
+	return result
+}
+
+// prepareTemplate creates template of work directories inside testTempDir
+// with Params.PrepareTemplate and returns its path, or empty string if
+// there is no template.
+func prepareTemplate(testTempDir string, p Params) (string, error) {
+	if p.PrepareTemplate == nil {
+		return "", nil
+	}
+	dir := filepath.Join(testTempDir, templateDirName)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+	if err := p.PrepareTemplate(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// CopyTree copies content of directory src into directory dst,
+// files are copied concurrently.
+func CopyTree(src, dst string) error {
+	var files []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			files = append(files, rel)
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return parallel(len(files), func(i int) error {
+		return copyFile(filepath.Join(src, files[i]), filepath.Join(dst, files[i]))
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// extractFiles writes files of archive into $WORK concurrently, or keeps
+// them pending with Params.LazyFiles.
+func (ts *TestScript) extractFiles(a *txtar.Archive) error {
+	// the last file of archive with the same name wins
+	last := make(map[string]int, len(a.Files))
+	var names []string
+	for i, f := range a.Files {
+		name := ts.MkAbs(ts.expand(f.Name))
+		ts.scriptFiles[name] = f.Name
+		if _, ok := last[name]; !ok {
+			names = append(names, name)
+		}
+		last[name] = i
+	}
+	if ts.params.LazyFiles {
+		for name, i := range last {
+			ts.pendingFiles[name] = &a.Files[i]
+		}
+		return nil
+	}
+	return parallel(len(names), func(i int) error {
+		return ts.extractFile(names[i], a.Files[last[names[i]]])
+	})
+}
@@ -1,6 +1,7 @@
 package kubevirt_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -42,13 +43,13 @@ func TestMain(m *testing.M) {
 
 	evec := openevec.CreateOpenEVEC(cfg)
 	configDir := filepath.Join(twoLevelsUp, "eve-config-dir")
-	if err := evec.SetupEden("config", configDir, "", "", "", []string{}, false, false); err != nil {
+	if err := evec.SetupEden(context.Background(), "config", configDir, "", "", "", []string{}, false, false); err != nil {
 		log.Fatalf("Failed to setup Eden: %v", err)
 	}
-	if err := evec.StartEden(defaults.DefaultVBoxVMName, "", ""); err != nil {
+	if err := evec.StartEden(context.Background(), defaults.DefaultVBoxVMName, "", ""); err != nil {
 		log.Fatalf("Start eden failed: %s", err)
 	}
-	if err := evec.OnboardEve(cfg.Eve.CertsUUID); err != nil {
+	if err := evec.OnboardEve(context.Background(), cfg.Eve.CertsUUID); err != nil {
 		log.Fatalf("Eve onboard failed: %s", err)
 	}
 
@@ -0,0 +1,67 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/adam/api"
+	"github.com/lf-edge/eden/pkg/openapi"
+)
+
+// TestAdamAPIGenerated checks that client of Adam is generated from the current specification
+func TestAdamAPIGenerated(t *testing.T) {
+	want, err := openapi.Generate(api.Spec, "api", "openapi.yaml")
+	if err != nil {
+		t.Fatalf("cannot generate client: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "pkg", "controller", "adam", "api", "client.gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("client.gen.go is out of date with openapi.yaml, run go generate ./pkg/controller/adam/api")
+	}
+}
+
+func TestAdamAPIClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/admin/device/dev%2F1/config":
+			if r.Header.Get("Accept") != "application/x-proto-binary" {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			_, _ = w.Write([]byte("config"))
+		case r.Method == http.MethodPost && r.URL.Path == "/admin/device":
+			if r.Header.Get("Content-Type") != "text/plain" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := api.NewClient(server.URL, server.Client().Do)
+	ctx := context.Background()
+
+	config, err := client.GetDeviceConfig(ctx, "dev/1")
+	if err != nil || string(config) != "config" {
+		t.Errorf("GetDeviceConfig() = %q, %v", config, err)
+	}
+	if _, err = client.CreateDevice(ctx, []byte("{}")); err != nil {
+		t.Errorf("CreateDevice() error = %v", err)
+	}
+	_, err = client.DeleteDevice(ctx, "dev")
+	var statusErr *api.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("DeleteDevice() error = %v, want status error %d", err, http.StatusNotFound)
+	}
+}
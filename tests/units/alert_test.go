@@ -0,0 +1,97 @@
+package templates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These tests verify the functionality of EDEN alert rules evaluated against metrics
+
+const alertRules = `
+rules:
+  - name: memory-high
+    expr: .dm.memory.usedPercentage > 80
+    for: 1m
+  - name: memory-critical
+    expr: .dm.memory.usedPercentage >= 95
+    action: fail
+`
+
+func memoryMetric(ts time.Time, usedPercentage float64) *metrics.ZMetricMsg {
+	return &metrics.ZMetricMsg{
+		AtTimeStamp: timestamppb.New(ts),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory: &metrics.MemoryMetric{UsedPercentage: usedPercentage},
+		}},
+	}
+}
+
+func TestAlertEvaluator(t *testing.T) {
+	rules, err := emetric.ParseAlertRules([]byte(alertRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluator := emetric.NewAlertEvaluator(rules)
+	start := time.Unix(1700000000, 0)
+	steps := []struct {
+		offset   time.Duration
+		value    float64
+		expected []string
+	}{
+		{offset: 0, value: 85},
+		{offset: 30 * time.Second, value: 85},
+		{offset: 60 * time.Second, value: 85, expected: []string{"memory-high"}},
+		{offset: 90 * time.Second, value: 85},
+		{offset: 120 * time.Second, value: 50},
+		{offset: 150 * time.Second, value: 96, expected: []string{"memory-critical"}},
+	}
+	for _, step := range steps {
+		alerts, err := evaluator.Process(memoryMetric(start.Add(step.offset), step.value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, alert := range alerts {
+			got = append(got, alert.Rule.Name)
+		}
+		if len(got) != len(step.expected) || (len(got) > 0 && got[0] != step.expected[0]) {
+			t.Errorf("at %s: got alerts %v, expected %v", step.offset, got, step.expected)
+		}
+	}
+}
+
+func TestAlertRulesMalformed(t *testing.T) {
+	for _, rules := range []string{
+		"rules: [{expr: '.dm.memory.usedPercentage'}]",
+		"rules: [{expr: '.dm.memory.usedPercentage > x'}]",
+		"rules: [{expr: '.dm.memory.usedPercentage > 1', action: webhook}]",
+		"rules: [{expr: '.dm.memory.usedPercentage > 1', for: forever}]",
+	} {
+		if _, err := emetric.ParseAlertRules([]byte(rules)); err == nil {
+			t.Errorf("expected error for %s", rules)
+		}
+	}
+}
+
+func TestParseExprMatch(t *testing.T) {
+	rule, err := emetric.ParseExpr(".dm.memory.usedPercentage < 90")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for value, expected := range map[float64]bool{50: true, 95: false} {
+		matched, err := rule.Match(memoryMetric(time.Now(), value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("expected %v for %v, got %v", expected, value, matched)
+		}
+	}
+	if _, err := emetric.ParseExpr(".dm.memory.usedPercentage"); err == nil {
+		t.Error("expected error for expression without operator")
+	}
+}
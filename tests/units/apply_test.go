@@ -0,0 +1,148 @@
+package templates
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+)
+
+// These tests verify loading of declarative resources and planning of actions of 'eden apply'
+
+const applyResources = `kind: Network
+name: n1
+spec:
+  subnet: 10.11.12.0/24
+---
+kind: App
+name: nginx
+spec:
+  image: docker://nginx
+  networks: [n1]
+  publish: ["8028:80"]
+---
+kind: ConfigItems
+spec:
+  timer.config.interval: "5"
+`
+
+func loadTestApplyResources(t *testing.T, data string) []*openevec.ApplyResource {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "env.yaml"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// files with other extensions are ignored
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("kind: Unknown"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resources, err := openevec.LoadApplyResources(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resources
+}
+
+func TestLoadApplyResources(t *testing.T) {
+	resources := loadTestApplyResources(t, applyResources)
+	if len(resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(resources))
+	}
+	network, ok := resources[0].Spec.(*openevec.ApplyNetworkSpec)
+	if !ok || network.Type != "local" || network.Subnet != "10.11.12.0/24" {
+		t.Errorf("unexpected network: %+v", resources[0].Spec)
+	}
+	app, ok := resources[1].Spec.(*openevec.ApplyAppSpec)
+	if !ok || app.Image != "docker://nginx" || !reflect.DeepEqual(app.Networks, []string{"n1"}) {
+		t.Errorf("unexpected app: %+v", resources[1].Spec)
+	}
+	if items := resources[2].Spec.(map[string]string); items["timer.config.interval"] != "5" {
+		t.Errorf("unexpected config items: %v", items)
+	}
+
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"unknown kind":  "kind: Cluster\nname: c1\n",
+		"unknown field": "kind: App\nname: a1\nspec:\n  image: docker://nginx\n  replicas: 2\n",
+		"no image":      "kind: App\nname: a1\nspec:\n  memory: 1G\n",
+		"duplicate":     "kind: Network\nname: n1\nspec:\n  type: switch\n---\nkind: Network\nname: n1\nspec:\n  type: switch\n",
+	} {
+		fileName := filepath.Join(dir, "bad.yaml")
+		if err := os.WriteFile(fileName, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openevec.LoadApplyResources(fileName); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	resources := loadTestApplyResources(t, applyResources)
+	state := &openevec.ApplyState{Resources: map[string]json.RawMessage{}}
+
+	// nothing exists
+	actions, err := openevec.PlanApply(resources, map[string]bool{}, map[string]string{}, state, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"create Network n1", "create App nginx", "create ConfigItem timer.config.interval"}
+	checkApplyActions(t, actions, expected)
+
+	// everything applied, network changed in place and not declared app is pruned
+	for _, r := range resources[:2] {
+		spec, _ := json.Marshal(r.Spec)
+		state.Resources[r.Kind+"/"+r.Name] = spec
+	}
+	state.Resources["App/old"] = json.RawMessage(`{"image":"docker://busybox"}`)
+	state.ConfigItems = []string{"timer.config.interval", "debug.enable.ssh"}
+	existing := map[string]bool{"Network/n1": true, "App/nginx": true, "App/old": true, "App/manual": true}
+	items := map[string]string{"timer.config.interval": "5", "debug.enable.ssh": "key"}
+	resources[0].Spec.(*openevec.ApplyNetworkSpec).Gateway = "10.11.12.254"
+	actions, err = openevec.PlanApply(resources, existing, items, state, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"update Network n1", "delete App old", "delete ConfigItem debug.enable.ssh"}
+	checkApplyActions(t, actions, expected)
+
+	// change of subnet requires recreation of network
+	resources[0].Spec.(*openevec.ApplyNetworkSpec).Subnet = "10.11.13.0/24"
+	actions, err = openevec.PlanApply(resources, existing, items, state, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkApplyActions(t, actions, []string{"replace Network n1"})
+}
+
+func checkApplyActions(t *testing.T, actions []openevec.ApplyAction, expected []string) {
+	t.Helper()
+	var result []string
+	for _, action := range actions {
+		result = append(result, action.String())
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected actions %v, got %v", expected, result)
+	}
+}
+
+func TestParseGitApplySource(t *testing.T) {
+	for source, expected := range map[string]openevec.GitApplySource{
+		"https://github.com/org/envs.git":                   {URL: "https://github.com/org/envs.git"},
+		"https://github.com/org/envs.git//demo?ref=v1":      {URL: "https://github.com/org/envs.git", Subdir: "demo", Ref: "v1"},
+		"git::https://example.com/envs//demo/edge?ref=main": {URL: "https://example.com/envs", Subdir: "demo/edge", Ref: "main"},
+		"git@github.com:org/envs.git":                       {URL: "git@github.com:org/envs.git"},
+	} {
+		src, ok := openevec.ParseGitApplySource(source)
+		if !ok || src != expected {
+			t.Errorf("%s: expected %+v, got %+v (%t)", source, expected, src, ok)
+		}
+	}
+	for _, source := range []string{"env/", "/tmp/env.yaml", "https://example.com/env.yaml"} {
+		if _, ok := openevec.ParseGitApplySource(source); ok {
+			t.Errorf("%s: not expected to be git source", source)
+		}
+	}
+}
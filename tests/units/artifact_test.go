@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eve-api/go/config"
+)
+
+func TestClassifyArtifact(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest v1.Manifest
+		kind     expect.ArtifactKind
+		format   config.Format
+	}{
+		{
+			name: "container",
+			manifest: v1.Manifest{
+				Config: v1.Descriptor{MediaType: types.OCIConfigJSON},
+				Layers: []v1.Descriptor{{MediaType: types.OCILayer}},
+			},
+			kind:   expect.ArtifactImage,
+			format: config.Format_CONTAINER,
+		},
+		{
+			name: "wasm",
+			manifest: v1.Manifest{
+				Config: v1.Descriptor{MediaType: expect.MediaTypeWasmConfig},
+				Layers: []v1.Descriptor{{MediaType: expect.MediaTypeWasmLayer}},
+			},
+			kind:   expect.ArtifactWasm,
+			format: config.Format_CONTAINER,
+		},
+		{
+			name: "oras disk",
+			manifest: v1.Manifest{
+				Config: v1.Descriptor{MediaType: "application/vnd.oci.empty.v1+json"},
+				Layers: []v1.Descriptor{{
+					MediaType:   "application/octet-stream",
+					Annotations: map[string]string{"org.opencontainers.image.title": "disk.qcow2"},
+				}},
+			},
+			kind:   expect.ArtifactDisk,
+			format: config.Format_QCOW2,
+		},
+		{
+			name: "unknown artifact",
+			manifest: v1.Manifest{
+				Config: v1.Descriptor{MediaType: "application/vnd.oci.empty.v1+json"},
+				Layers: []v1.Descriptor{{MediaType: "application/octet-stream"}},
+			},
+			kind:   expect.ArtifactImage,
+			format: config.Format_CONTAINER,
+		},
+	}
+	for _, tt := range tests {
+		kind, format := expect.ClassifyArtifact(&tt.manifest)
+		if kind != tt.kind || format != tt.format {
+			t.Errorf("%s: got %d/%s, want %d/%s", tt.name, kind, format, tt.kind, tt.format)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/openevec"
+)
+
+// These tests verify matching of PCRs received from device with templates of attestation policy
+
+func TestPCRTemplateMatch(t *testing.T) {
+	received := &types.PCRTemplate{
+		EveVersion:      "12.0.0-kvm-amd64",
+		FirmwareVersion: "OVMF",
+		PCRValues: []*types.PCRValue{
+			{Index: 0, Value: "aa00"},
+			{Index: 14, Value: "EE14"},
+		},
+	}
+	template := &types.PCRTemplate{EveVersion: received.EveVersion, FirmwareVersion: received.FirmwareVersion}
+	values, err := openevec.ParsePCRValues([]string{"0=aa00", "14=ee14"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	openevec.SetPCRValues(template, values)
+	if !template.Match(received) {
+		t.Errorf("template %+v must match", template)
+	}
+	if template.Match(nil) {
+		t.Error("template must not match absent PCRs")
+	}
+
+	values, _ = openevec.ParsePCRValues([]string{"14=0000"})
+	openevec.SetPCRValues(template, values)
+	if len(template.PCRValues) != 2 || template.Match(received) {
+		t.Errorf("template with overridden PCR 14 must not match: %+v", template.PCRValues)
+	}
+	values, _ = openevec.ParsePCRValues([]string{"14=*"})
+	openevec.SetPCRValues(template, values)
+	if !template.Match(received) {
+		t.Error("template with '*' must match any value")
+	}
+	values, _ = openevec.ParsePCRValues([]string{"7=*"})
+	openevec.SetPCRValues(template, values)
+	if template.Match(received) {
+		t.Error("template must not match if PCR is not received")
+	}
+
+	if (&types.PCRTemplate{EveVersion: "11.0.0"}).Match(received) {
+		t.Error("template for other EVE version must not match")
+	}
+	if !(&types.PCRTemplate{}).Match(received) {
+		t.Error("empty template must match any PCRs")
+	}
+
+	for _, value := range []string{"14", "x=00", "14="} {
+		if _, err := openevec.ParsePCRValues([]string{value}); err == nil {
+			t.Errorf("%s: expected error", value)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package templates
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/tests"
+)
+
+// These tests verify detection of regressions of measurements against baseline
+
+func TestCompareMeasurements(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bench.jsonl")
+	for _, m := range []tests.Measurement{
+		{Script: "nginx", Name: "deploy", Value: 100, Unit: "s"},
+		{Script: "nginx", Name: "deploy", Value: 140, Unit: "s"},
+		{Script: "download", Name: "speed", Value: 8, Unit: "MB/s", HigherIsBetter: true},
+		{Script: "download", Name: "time", Value: 50, Unit: "s"},
+		{Script: "boot", Name: "time", Value: 30, Unit: "s"},
+	} {
+		if err := tests.AppendMeasurement(file, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := tests.ReadMeasurements(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || results[0].Value != 120 {
+		t.Fatalf("expected values measured twice to be averaged: %+v", results)
+	}
+	baseline := []tests.Measurement{
+		{Script: "nginx", Name: "deploy", Value: 100, Unit: "s"},
+		{Script: "download", Name: "speed", Value: 10, Unit: "MB/s", HigherIsBetter: true},
+		{Script: "download", Name: "time", Value: 60, Unit: "s"},
+	}
+	expected := map[string]struct {
+		regression bool
+		new        bool
+	}{
+		"nginx/deploy":   {regression: true},
+		"download/speed": {regression: true},
+		"download/time":  {},
+		"boot/time":      {new: true},
+	}
+	for _, c := range tests.CompareMeasurements(baseline, results, 0.1) {
+		e := expected[c.Key()]
+		if c.Regression != e.regression || (c.Baseline == nil) != e.new {
+			t.Errorf("unexpected comparison of %s: %+v", c.Key(), c)
+		}
+	}
+	if merged := tests.MergeBaseline(baseline, results); len(merged) != 4 {
+		t.Errorf("expected 4 measurements in merged baseline, got %d", len(merged))
+	}
+}
@@ -0,0 +1,90 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/evecommon"
+)
+
+// These tests verify cellular config of wwan ports defined in file of device model
+
+func TestCellularPortsInDevModelFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "model.json")
+	content := `{"cellular": [{
+		"logicallabel": "wwan0",
+		"uplink": true,
+		"activatedSimSlot": 2,
+		"accessPoints": [
+			{"simSlot": 1, "apn": "internet"},
+			{"simSlot": 2, "apn": "iot.example", "authProtocol": "chap", "preferredRats": ["lte", "5gnr"], "forbidRoaming": true}
+		]
+	}]}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := models.GetDevModelByName("ZedVirtual-4G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.OverwriteDevModelFromFile(file, model); err != nil {
+		t.Fatal(err)
+	}
+	networkID := models.CellularNetworkID("wwan0")
+	var adapter *config.SystemAdapter
+	for _, el := range model.Adapters() {
+		if el.Name == "wwan0" {
+			adapter = el
+		}
+	}
+	if adapter == nil || adapter.NetworkUUID != networkID || !adapter.Uplink {
+		t.Fatalf("unexpected system adapter of wwan0: %v", adapter)
+	}
+	var cellular *config.CellularConfig
+	for _, el := range model.Networks() {
+		if el.Id == networkID {
+			cellular = el.GetWireless().GetCellularCfg()[0]
+		}
+	}
+	if cellular == nil {
+		t.Fatalf("no network config of wwan0")
+	}
+	if cellular.ActivatedSimSlot != 2 || len(cellular.AccessPoints) != 2 {
+		t.Fatalf("unexpected cellular config: %v", cellular)
+	}
+	ap := cellular.AccessPoints[1]
+	if ap.Apn != "iot.example" || ap.AuthProtocol != config.CellularAuthProtocol_CELLULAR_AUTH_PROTOCOL_CHAP ||
+		!ap.ForbidRoaming || len(ap.PreferredRats) != 2 ||
+		ap.PreferredRats[1] != evecommon.RadioAccessTechnology_RADIO_ACCESS_TECHNOLOGY_5GNR {
+		t.Errorf("unexpected access point: %v", ap)
+	}
+
+	for _, port := range []models.CellularPort{
+		{},
+		{LogicalLabel: "wwan0", AccessPoints: []models.CellularAccessPoint{{APN: "a"}, {APN: "b"}}},
+		{LogicalLabel: "wwan0", AccessPoints: []models.CellularAccessPoint{{APN: "a", AuthProtocol: "md5"}}},
+		{LogicalLabel: "wwan0", AccessPoints: []models.CellularAccessPoint{{APN: "a", PreferredRATs: []string{"6g"}}}},
+		{LogicalLabel: "wwan0", ActivatedSimSlot: 2, AccessPoints: []models.CellularAccessPoint{{SimSlot: 1, APN: "a"}}},
+	} {
+		if _, err := port.NetworkConfig(); err == nil {
+			t.Errorf("expected error for %+v", port)
+		}
+	}
+}
+
+func TestParseCellularAccessPoint(t *testing.T) {
+	ap, err := models.ParseCellularAccessPoint("2:iot.example")
+	if err != nil || ap.SimSlot != 2 || ap.APN != "iot.example" {
+		t.Errorf("unexpected access point %+v: %v", ap, err)
+	}
+	ap, err = models.ParseCellularAccessPoint("internet")
+	if err != nil || ap.SimSlot != 0 || ap.APN != "internet" {
+		t.Errorf("unexpected access point %+v: %v", ap, err)
+	}
+	if _, err = models.ParseCellularAccessPoint("x:internet"); err == nil {
+		t.Errorf("expected error for invalid SIM slot")
+	}
+}
@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// These tests verify the functionality of selective cleanup of eden
+
+func TestCleanContext(t *testing.T) {
+	t.Setenv("EDEN_HOME", t.TempDir())
+	dist := t.TempDir()
+	paths := map[string]string{
+		"eve":    filepath.Join(dist, "default-eve"),
+		"certs":  filepath.Join(dist, "default-certs"),
+		"images": filepath.Join(dist, "default-images"),
+		"config": filepath.Join(dist, "default-config_saved.yml"),
+	}
+	for _, p := range paths {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clean := func(opts eden.CleanOptions) {
+		// EVE is remote, so there is nothing to stop
+		if err := eden.CleanContext(paths["eve"], paths["certs"], paths["images"], "", "", "", "", paths["config"],
+			true, true, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(paths[name])
+		return err == nil
+	}
+
+	utils.SetDryRun(true)
+	clean(eden.CleanOptions{})
+	utils.SetDryRun(false)
+	for name := range paths {
+		if !exists(name) {
+			t.Errorf("%s is removed in dry-run mode", name)
+		}
+	}
+
+	clean(eden.CleanOptions{KeepImages: true, KeepState: true})
+	for name, kept := range map[string]bool{"eve": true, "images": true, "config": true, "certs": false} {
+		if exists(name) != kept {
+			t.Errorf("expected %s kept: %t", name, kept)
+		}
+	}
+
+	clean(eden.CleanOptions{})
+	for name := range paths {
+		if exists(name) {
+			t.Errorf("%s is not removed", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+)
+
+// These tests verify loading of config items from yaml file and records of their audit log
+
+func TestLoadConfigItemsFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "items.yaml")
+	data := "debug.default.loglevel: debug\ntimer.config.interval: 5\napp.allow.vnc: true\n"
+	if err := os.WriteFile(fileName, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	items, err := openevec.LoadConfigItemsFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"debug.default.loglevel": "debug",
+		"timer.config.interval":  "5",
+		"app.allow.vnc":          "true",
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected %v, got %v", expected, items)
+	}
+
+	if err := os.WriteFile(fileName, []byte("timer.config.interval: [5]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openevec.LoadConfigItemsFile(fileName); err == nil {
+		t.Error("expected error for non-scalar value")
+	}
+}
+
+func TestConfigItemAuditRecord(t *testing.T) {
+	when := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	for _, tc := range []struct {
+		change   openevec.ConfigItemChange
+		expected string
+	}{
+		{
+			change:   openevec.ConfigItemChange{Key: "timer.config.interval", New: "5"},
+			expected: `2024-05-06T07:08:09Z user=alice context=default device=dev set timer.config.interval="5"`,
+		},
+		{
+			change:   openevec.ConfigItemChange{Key: "timer.config.interval", Old: "5", New: "10"},
+			expected: `2024-05-06T07:08:09Z user=alice context=default device=dev set timer.config.interval="10" (was "5")`,
+		},
+		{
+			change:   openevec.ConfigItemChange{Key: "timer.config.interval", Old: "10", Unset: true},
+			expected: `2024-05-06T07:08:09Z user=alice context=default device=dev unset timer.config.interval (was "10")`,
+		},
+	} {
+		if record := tc.change.AuditRecord(when, "alice", "default", "dev"); record != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, record)
+		}
+	}
+}
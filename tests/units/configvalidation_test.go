@@ -0,0 +1,96 @@
+package templates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eve-api/go/config"
+)
+
+// These tests verify validation of device config before pushing it to controller
+
+func validEdgeDevConfig() *config.EdgeDevConfig {
+	return &config.EdgeDevConfig{
+		Datastores:  []*config.DatastoreConfig{{Id: "ds"}},
+		ContentInfo: []*config.ContentTree{{Uuid: "ct", DsId: "ds"}},
+		Volumes: []*config.Volume{{
+			Uuid: "vol",
+			Origin: &config.VolumeContentOrigin{
+				Type:                  config.VolumeContentOriginType_VCOT_DOWNLOAD,
+				DownloadContentTreeID: "ct",
+			},
+		}},
+		NetworkInstances: []*config.NetworkInstanceConfig{{
+			Uuidandversion: &config.UUIDandVersion{Uuid: "ni"},
+			InstType:       config.ZNetworkInstType_ZnetInstLocal,
+			Ip: &config.Ipspec{
+				Subnet:    "10.11.12.0/24",
+				Gateway:   "10.11.12.1",
+				Dns:       []string{"10.11.12.1"},
+				DhcpRange: &config.IpRange{Start: "10.11.12.2", End: "10.11.12.254"},
+			},
+			StaticRoutes: []*config.IPRoute{{DestinationNetwork: "10.0.0.0/8", Gateway: "10.11.12.100"}},
+		}},
+		Apps: []*config.AppInstanceConfig{{
+			Uuidandversion: &config.UUIDandVersion{Uuid: "app"},
+			Displayname:    "app",
+			VolumeRefList:  []*config.VolumeRef{{Uuid: "vol"}},
+			Interfaces: []*config.NetworkAdapter{{
+				Name:      "eth0",
+				NetworkId: "ni",
+				Acls: []*config.ACE{
+					{Id: 1, Matches: []*config.ACEMatch{{Type: "ip", Value: "10.0.0.0/8"}}},
+					{Id: 2, Matches: []*config.ACEMatch{{Type: "host", Value: "example.com"}}},
+				},
+			}},
+		}},
+	}
+}
+
+func TestValidateEdgeDevConfig(t *testing.T) {
+	if err := controller.ValidateEdgeDevConfig(validEdgeDevConfig()); err != nil {
+		t.Fatalf("unexpected error for valid config: %v", err)
+	}
+
+	for name, corrupt := range map[string]func(*config.EdgeDevConfig){
+		"dangling volume ref": func(c *config.EdgeDevConfig) {
+			c.Apps[0].VolumeRefList[0].Uuid = "missing"
+		},
+		"dangling content tree": func(c *config.EdgeDevConfig) {
+			c.Volumes[0].Origin.DownloadContentTreeID = "missing"
+		},
+		"dangling datastore": func(c *config.EdgeDevConfig) {
+			c.ContentInfo[0].DsId = "missing"
+		},
+		"dangling network instance": func(c *config.EdgeDevConfig) {
+			c.Apps[0].Interfaces[0].NetworkId = "missing"
+		},
+		"duplicate ACL id": func(c *config.EdgeDevConfig) {
+			c.Apps[0].Interfaces[0].Acls[1].Id = 1
+		},
+		"invalid ACL IP": func(c *config.EdgeDevConfig) {
+			c.Apps[0].Interfaces[0].Acls[0].Matches[0].Value = "10.0.0.0/33"
+		},
+		"invalid subnet": func(c *config.EdgeDevConfig) {
+			c.NetworkInstances[0].Ip.Subnet = "10.11.12.0"
+		},
+		"gateway out of subnet": func(c *config.EdgeDevConfig) {
+			c.NetworkInstances[0].Ip.Gateway = "10.11.13.1"
+		},
+		"invalid static route": func(c *config.EdgeDevConfig) {
+			c.NetworkInstances[0].StaticRoutes[0].DestinationNetwork = "10.0.0.0"
+		},
+		"duplicate volume": func(c *config.EdgeDevConfig) {
+			c.Volumes = append(c.Volumes, c.Volumes[0])
+		},
+	} {
+		devConfig := validEdgeDevConfig()
+		corrupt(devConfig)
+		err := controller.ValidateEdgeDevConfig(devConfig)
+		var validationErr *controller.ConfigValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Problems) != 1 {
+			t.Errorf("%s: expected exactly one problem, got %v", name, err)
+		}
+	}
+}
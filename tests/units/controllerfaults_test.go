@@ -0,0 +1,101 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eden"
+)
+
+// These tests verify injection of faults into responses of controller by proxy
+
+func TestControllerFaultProxy(t *testing.T) {
+	version := 0
+	controller := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version++
+		fmt.Fprintf(w, "config %d", version)
+	}))
+	defer controller.Close()
+	target, err := url.Parse(controller.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	faultsFile := filepath.Join(t.TempDir(), "faults.json")
+	proxy := httptest.NewServer(eden.NewControllerFaultProxy(target, faultsFile))
+	defer proxy.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(proxy.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+	setFaults := func(faults ...*eden.ControllerFault) {
+		t.Helper()
+		for i, f := range faults {
+			f.ID = fmt.Sprintf("f%d", i+1)
+		}
+		if err := eden.SaveControllerFaults(faultsFile, faults); err != nil {
+			t.Fatal(err)
+		}
+		// proxy reloads faults on change of modification time of file
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status, body := get("/api/v2/edgedevice/config"); status != http.StatusOK || body != "config 1" {
+		t.Fatalf("unexpected response without faults: %d %q", status, body)
+	}
+
+	setFaults(&eden.ControllerFault{Kind: eden.FaultError, Endpoint: "/config$", Status: 503, Count: 2})
+	for i := 0; i < 2; i++ {
+		if status, _ := get("/api/v2/edgedevice/config"); status != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", status)
+		}
+	}
+	if status, _ := get("/api/v2/edgedevice/config"); status != http.StatusOK {
+		t.Errorf("expected status 200 after count of fault is exhausted, got %d", status)
+	}
+
+	setFaults(&eden.ControllerFault{Kind: eden.FaultStale, Endpoint: "/config$"})
+	if _, body := get("/api/v2/edgedevice/config"); body != "config 2" {
+		t.Errorf("expected stale config 2, got %q", body)
+	}
+	if _, body := get("/api/v2/edgedevice/info"); body != "config 3" {
+		t.Errorf("expected not affected endpoint to be forwarded, got %q", body)
+	}
+
+	setFaults(&eden.ControllerFault{Kind: eden.FaultOversize, Endpoint: "/config$", Size: 1024})
+	if _, body := get("/api/v2/edgedevice/config"); len(body) != 1024 {
+		t.Errorf("expected body of 1024 bytes, got %d", len(body))
+	}
+
+	setFaults(&eden.ControllerFault{Kind: eden.FaultError, Endpoint: "", Status: 500, Until: time.Now().Add(-time.Minute)})
+	if status, _ := get("/api/v2/edgedevice/config"); status != http.StatusOK {
+		t.Errorf("expected expired fault to be ignored, got %d", status)
+	}
+
+	for _, f := range []*eden.ControllerFault{
+		{Kind: eden.FaultDelay, Endpoint: ".*"},
+		{Kind: eden.FaultError, Endpoint: ".*", Status: 200},
+		{Kind: eden.FaultOversize, Endpoint: ".*"},
+		{Kind: "drop", Endpoint: ".*"},
+		{Kind: eden.FaultStale, Endpoint: "("},
+	} {
+		if err := f.Validate(); err == nil {
+			t.Errorf("expected error for %+v", f)
+		}
+	}
+}
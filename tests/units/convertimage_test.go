@@ -0,0 +1,110 @@
+package templates
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+func TestAlignedImageSize(t *testing.T) {
+	tests := []struct {
+		format string
+		size   int64
+		want   int64
+	}{
+		{utils.ImageFormatRaw, 1, 1 << 30},
+		{utils.ImageFormatRaw, 8 << 30, 8 << 30},
+		{utils.ImageFormatGCP, (8 << 30) + 1, 9 << 30},
+		{utils.ImageFormatVHD, (4 << 30) + 512, (4 << 30) + (1 << 20)},
+		{utils.ImageFormatOVA, 1 << 20, 1 << 20},
+	}
+	for _, tt := range tests {
+		got, err := utils.AlignedImageSize(tt.format, tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("AlignedImageSize(%s, %d) = %d, want %d", tt.format, tt.size, got, tt.want)
+		}
+	}
+	if _, err := utils.AlignedImageSize("vdi", 1); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestOVFDescriptor(t *testing.T) {
+	descriptor, err := utils.OVFDescriptor(utils.ImageVM{Name: "eve", CPUs: 4, MemoryMB: 4096}, "eve-disk1.vmdk", 1234, 8<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope struct {
+		References struct {
+			File struct {
+				Href string `xml:"href,attr"`
+				Size int64  `xml:"size,attr"`
+			}
+		}
+		DiskSection struct {
+			Disk struct {
+				Capacity int64 `xml:"capacity,attr"`
+			}
+		}
+	}
+	if err := xml.Unmarshal([]byte(descriptor), &envelope); err != nil {
+		t.Fatalf("invalid OVF descriptor: %s", err)
+	}
+	if envelope.References.File.Href != "eve-disk1.vmdk" || envelope.References.File.Size != 1234 {
+		t.Errorf("unexpected reference to disk: %+v", envelope.References.File)
+	}
+	if envelope.DiskSection.Disk.Capacity != 8<<30 {
+		t.Errorf("unexpected capacity of disk: %d", envelope.DiskSection.Disk.Capacity)
+	}
+}
+
+func TestCreateGCPImage(t *testing.T) {
+	dir := t.TempDir()
+	rawFile := filepath.Join(dir, "live.raw")
+	content := bytes.Repeat([]byte("eve"), 1024)
+	if err := os.WriteFile(rawFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(dir, "live.tar.gz")
+	if err := utils.CreateGCPImage(image, rawFile); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("image is not gzipped: %s", err)
+	}
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// GCP requires the only file disk.raw in GNU tar
+	if hdr.Name != "disk.raw" || hdr.Format != tar.FormatGNU {
+		t.Errorf("unexpected file %s of format %s", hdr.Name, hdr.Format)
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Error("content of disk.raw differs from raw image")
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("expected only disk.raw in image: %v", err)
+	}
+}
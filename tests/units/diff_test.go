@@ -0,0 +1,68 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// These tests verify rendering of differences of texts
+
+func TestRenderDiff(t *testing.T) {
+	text1 := "a\nb\nc\nd\ne\nf\ng\nname: eve-1\n"
+	text2 := "a\nb\nc\nd\ne\nf\ng\nname: eve-2\nh\n"
+
+	if got := utils.RenderDiff("old", "new", text1, text1, utils.DiffOptions{Context: 3}); got != "" {
+		t.Errorf("diff of equal texts: %q", got)
+	}
+
+	expected := `--- old
++++ new
+@@ -6,3 +6,4 @@
+ f
+ g
+-name: eve-1
++name: eve-2
++h
+`
+	if got := utils.RenderDiff("old", "new", text1, text2, utils.DiffOptions{Context: 2}); got != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	got := utils.RenderDiff("old", "new", text1, text2, utils.DiffOptions{Context: -1})
+	if !strings.Contains(got, "@@ -1,8 +1,9 @@\n a\n") {
+		t.Errorf("diff with all lines of context:\n%s", got)
+	}
+
+	got = utils.RenderDiff("old", "new", "x\n", "x", utils.DiffOptions{})
+	if !strings.Contains(got, "+x(missing final newline)") {
+		t.Errorf("diff without final newline:\n%s", got)
+	}
+
+	// only changed word is highlighted in colored diff
+	got = utils.RenderDiff("old", "new", text1, text2, utils.DiffOptions{Context: 0, Color: true})
+	for _, s := range []string{"\x1b[31m-\x1b[0m\x1b[31mname: eve-\x1b[0m\x1b[31;1;7m1\x1b[0m",
+		"\x1b[32m+\x1b[0m\x1b[32mname: eve-\x1b[0m\x1b[32;1;7m2\x1b[0m", "\x1b[32m+h\x1b[0m"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("no %q in colored diff: %q", s, got)
+		}
+	}
+}
+
+func TestRenderJSONDiff(t *testing.T) {
+	diffs, err := utils.DiffJSONBytes([]byte(`{"name":"eve 1","state":1}`), []byte(`{"name":"eve 2","ip":"10.0.2.15"}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := utils.RenderJSONDiff(diffs, utils.DiffOptions{}), utils.FormatJSONDiff(diffs); got != expected {
+		t.Errorf("diff without colors %q differs from %q", got, expected)
+	}
+	got := utils.RenderJSONDiff(diffs, utils.DiffOptions{Color: true})
+	for _, s := range []string{"\x1b[32m+ .ip: 10.0.2.15\x1b[0m", "~ .name: \x1b[31meve \x1b[0m\x1b[31;1;7m1\x1b[0m -> ",
+		"\x1b[31m- .state: 1\x1b[0m"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("no %q in colored diff: %q", s, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/tests"
+)
+
+// These tests verify discovery of test suites and parsing of their metadata
+
+const discoveryScript = `{{$test := "test eden.lim.test -test.run TestLog"}}
+
+# @description: Check logs
+# @tags: lim, log
+# @owner: eden
+# @duration: 10m
+# @requires: sdn
+[!exec:ssh] stop
+eden pod deploy nginx
+
+-- ssh.sh --
+# @tags: ignored
+`
+
+func TestDiscoverTests(t *testing.T) {
+	root := t.TempDir()
+	suite := filepath.Join(root, "lim")
+	if err := os.MkdirAll(filepath.Join(suite, "testdata"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(suite, "eden.lim.tests.txt"):       "# @tags: smoke\n# @stateless: true\neden.escript.test -test.run TestEdenScripts/log_test\n",
+		filepath.Join(suite, "testdata", "log_test.txt"): discoveryScript,
+		filepath.Join(suite, "README.md"):                "# @tags: readme\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := tests.DiscoverTests(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	scenario, script := entries[0], entries[1]
+	if scenario.Kind != tests.KindScenario || scenario.Name != "eden.lim.tests.txt" ||
+		!reflect.DeepEqual(scenario.Tags, []string{"smoke"}) || !scenario.Stateless {
+		t.Errorf("unexpected scenario: %+v", scenario)
+	}
+	expected := &tests.TestEntry{
+		Suite:       "lim",
+		Kind:        tests.KindScript,
+		Name:        "log_test",
+		Path:        filepath.Join(suite, "testdata", "log_test.txt"),
+		Description: "Check logs",
+		Tags:        []string{"lim", "log"},
+		Owner:       "eden",
+		Duration:    "10m",
+		Requires:    []string{"sdn", "exec:ssh"},
+	}
+	if !reflect.DeepEqual(script, expected) {
+		t.Errorf("expected %+v, got %+v", expected, script)
+	}
+
+	entries, err = tests.DiscoverTests(root, regexp.MustCompile("^log$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "log_test" {
+		t.Errorf("expected filtering by tag to return log_test, got %+v", entries)
+	}
+}
+
+func TestMatchTests(t *testing.T) {
+	entries := []*tests.TestEntry{
+		{Name: "eclient"},
+		{Name: "eclients"},
+		{Name: "port_forward", Tags: []string{"networking"}},
+		{Name: "port_switch", Tags: []string{"networking"}},
+		{Name: "nginx", Tags: []string{"apps"}},
+	}
+	names := func(entries []*tests.TestEntry) []string {
+		var result []string
+		for _, entry := range entries {
+			result = append(result, entry.Name)
+		}
+		return result
+	}
+	for _, tc := range []struct {
+		pattern  string
+		expected []string
+	}{
+		{"port*forward", []string{"port_forward"}},
+		{"PFWD", []string{"port_forward"}},
+		{"port", []string{"port_forward", "port_switch"}},
+		{"network*", []string{"port_forward", "port_switch"}},
+		{"eclient", []string{"eclient"}},
+		{"ecl", []string{"eclient", "eclients"}},
+		{"vnc", nil},
+	} {
+		if got := names(tests.MatchTests(entries, tc.pattern)); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("pattern %q: expected %v, got %v", tc.pattern, tc.expected, got)
+		}
+	}
+}
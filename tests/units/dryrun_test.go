@@ -0,0 +1,44 @@
+package templates
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// These tests verify that images are not built or pushed in dry-run mode
+
+func TestDryRunImage(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	utils.SetDryRun(true)
+	utils.SetDryRunOutput(&out)
+	defer func() {
+		utils.SetDryRun(false)
+		utils.SetDryRunOutput(os.Stdout)
+	}()
+
+	if err := utils.CreateImage(dir, "app:build-1", "linux/amd64"); err != nil {
+		t.Fatal(err)
+	}
+	// Dockerfile is generated for build only
+	if _, err := os.Stat(filepath.Join(dir, "Dockerfile")); !os.IsNotExist(err) {
+		t.Errorf("Dockerfile is created in dry-run mode: %v", err)
+	}
+	// registry is not reachable, so push must not be tried
+	if _, err := utils.LoadRegistry("app:build-1", "127.0.0.1:1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []string{
+		"[dry-run] build image app:build-1 for linux/amd64 from " + dir,
+		"[dry-run] push image app:build-1 into registry 127.0.0.1:1",
+	} {
+		if !strings.Contains(out.String(), action) {
+			t.Errorf("expected %q in output:\n%s", action, out.String())
+		}
+	}
+}
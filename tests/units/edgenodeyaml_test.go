@@ -0,0 +1,63 @@
+package templates
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eve-api/go/config"
+	"google.golang.org/protobuf/proto"
+)
+
+// These tests verify round-trip of device config through its yaml representation
+
+func TestEdgeDevConfigYAMLRoundTrip(t *testing.T) {
+	devConfig := &config.EdgeDevConfig{
+		Id:         &config.UUIDandVersion{Uuid: "1b3b7b8c-4f4a-4a43-9b0c-6e2c3b1e8f00", Version: "5"},
+		DeviceName: "eve",
+		ConfigItems: []*config.ConfigItem{
+			{Key: "timer.config.interval", Value: "10"},
+			{Key: "debug.enable.ssh", Value: "true"},
+		},
+		NetworkInstances: []*config.NetworkInstanceConfig{{
+			Uuidandversion: &config.UUIDandVersion{Uuid: "ni", Version: "1"},
+			InstType:       config.ZNetworkInstType_ZnetInstLocal,
+			Ip:             &config.Ipspec{Subnet: "10.11.12.0/24", Gateway: "10.11.12.1"},
+		}},
+		ControllerEpoch: 1234567890123,
+	}
+	out, err := openevec.EdgeDevConfigToYAML(devConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := openevec.EdgeDevConfigToYAML(devConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, again) {
+		t.Errorf("yaml representation is not stable:\n%s\n%s", out, again)
+	}
+	if strings.Index(string(out), "id:") > strings.Index(string(out), "configItems:") {
+		t.Errorf("fields are not in order of definition:\n%s", out)
+	}
+	parsed, err := openevec.EdgeDevConfigFromYAML(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(parsed, devConfig) {
+		t.Errorf("config changed after round-trip:\n%v\n%v", parsed, devConfig)
+	}
+
+	// comments of previous file are kept for fields which still exist
+	previous := strings.Replace(string(out), "deviceName: eve",
+		"# name of device\ndeviceName: eve # not used by EVE", 1)
+	devConfig.DeviceName = "eve2"
+	out, err = openevec.EdgeDevConfigToYAML(devConfig, []byte(previous))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "# name of device\ndeviceName: eve2 # not used by EVE") {
+		t.Errorf("comments are not kept:\n%s", out)
+	}
+}
@@ -0,0 +1,33 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+)
+
+// These tests verify categories of errors returned by methods of OpenEVEC
+
+func TestErrorCodes(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		name string
+		exit int
+	}{
+		{errors.New("failed"), "error", 1},
+		{nil, "error", 1},
+		{fmt.Errorf("GetDeviceCurrent error: %w: no device found", openevec.ErrNotOnboarded), "not-onboarded", 3},
+		{fmt.Errorf("getController error: %w", openevec.ErrControllerUnreachable), "controller-unreachable", 4},
+		{fmt.Errorf("link operations are %w 'rpi'", openevec.ErrDevmodelUnsupported), "devmodel-unsupported", 5},
+		{fmt.Errorf("wait: %w", context.DeadlineExceeded), "timeout", 6},
+		{context.Canceled, "interrupted", 130},
+	} {
+		code := openevec.CodeOf(tc.err)
+		if code.Name != tc.name || code.ExitCode != tc.exit {
+			t.Errorf("unexpected code %+v of %v", code, tc.err)
+		}
+	}
+}
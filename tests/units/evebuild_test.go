@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// These tests verify that image of EVE built from source is not left from the previous build
+
+func TestCheckEveBuild(t *testing.T) {
+	dir := t.TempDir()
+	buildDir := filepath.Join(dir, "dist", "amd64", "0.0.0-master-1a2b3c4d-kvm-amd64")
+	if err := os.MkdirAll(buildDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(buildDir, "live.raw"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(buildDir, filepath.Join(dir, "dist", "amd64", "current")); err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(dir, "dist", "amd64", "current", "live.raw")
+	for _, version := range []string{"0.0.0-master-1a2b3c4d-kvm-amd64", "0.0.0-master-1a2b3c4d"} {
+		if err := eden.CheckEveBuild(image, dir, "amd64", version); err != nil {
+			t.Errorf("CheckEveBuild(%s): %s", version, err)
+		}
+	}
+	for _, version := range []string{"0.0.0-master-5e6f7a8b-kvm-amd64", "0.0.0-master-1a2b3c4d-xen-amd64"} {
+		if err := eden.CheckEveBuild(image, dir, "amd64", version); err == nil {
+			t.Errorf("CheckEveBuild(%s): expected error for image of another build", version)
+		}
+	}
+	if err := eden.CheckEveBuild(filepath.Join(dir, "dist", "amd64", "current", "absent.raw"), dir, "amd64",
+		"0.0.0-master-1a2b3c4d"); err == nil {
+		t.Error("CheckEveBuild: expected error for absent image")
+	}
+}
+
+func TestEveBuildVersion(t *testing.T) {
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make is not available")
+	}
+	dir := t.TempDir()
+	makefile := "version:\n\t@echo 0.0.0-master-1a2b3c4d-$(HV)-$(ZARCH)\n"
+	if err := os.WriteFile(filepath.Join(dir, "Makefile"), []byte(makefile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	version, err := eden.EveBuildVersion(utils.EVEDescription{Arch: "amd64", HV: "kvm"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "0.0.0-master-1a2b3c4d-kvm-amd64" {
+		t.Errorf("unexpected version: %s", version)
+	}
+}
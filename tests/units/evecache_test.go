@@ -0,0 +1,106 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+func TestPruneEveCache(t *testing.T) {
+	t.Setenv("EDEN_HOME", t.TempDir())
+	cacheDir, err := utils.EveCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(cacheDir, "13.4.0-kvm-amd64-live-0123456789abcdef")
+	recent := filepath.Join(cacheDir, "13.4.0-kvm-amd64-uefi-fedcba9876543210")
+	for _, dir := range []string{old, recent} {
+		if err := os.MkdirAll(filepath.Join(dir, "firmware"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "firmware", "OVMF.fd"), make([]byte, 1024), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lastUsed := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(old, lastUsed, lastUsed); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := utils.ListEveCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Dir != old || entries[0].Size != 1024 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	removed, err := utils.PruneEveCache(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Dir != old {
+		t.Fatalf("unexpected removed entries: %+v", removed)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("recent entry removed: %s", err)
+	}
+	if removed, err = utils.PruneEveCache(0); err != nil || len(removed) != 1 {
+		t.Errorf("cannot prune all entries: %v %+v", err, removed)
+	}
+}
+
+func TestPruneEveCacheGenerated(t *testing.T) {
+	t.Setenv("EDEN_HOME", t.TempDir())
+	cacheDir, err := utils.EveCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastUsed := time.Now().Add(-48 * time.Hour)
+	// young, locked and abandoned temporary directories of entries
+	young := filepath.Join(cacheDir, ".tmp-13.4.0-kvm-amd64-live-0123456789abcdef1")
+	locked := filepath.Join(cacheDir, ".tmp-13.4.0-kvm-amd64-live-0123456789abcdef2")
+	abandoned := filepath.Join(cacheDir, ".tmp-13.4.0-kvm-amd64-live-0123456789abcdef3")
+	for _, dir := range []string{young, locked, abandoned} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if dir != young {
+			if err := os.Chtimes(dir, lastUsed, lastUsed); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	lock, err := os.OpenFile(locked+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+	unlock, err := utils.LockFile(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	if err := os.WriteFile(abandoned+".lock", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := utils.PruneEveCache(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Dir != abandoned {
+		t.Fatalf("unexpected removed entries: %+v", removed)
+	}
+	for _, path := range []string{abandoned, abandoned + ".lock"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s is not removed: %v", path, err)
+		}
+	}
+	for _, dir := range []string{young, locked} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s removed: %s", dir, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package templates
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// These tests verify overrides of EVE config written into USB installer
+
+func TestParseWPASupplicant(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wpa_supplicant.conf")
+	content := `ctrl_interface=/var/run/wpa_supplicant
+network={
+	ssid="home"
+	psk="secret phrase"
+}
+# enterprise network
+network={
+	ssid="office"
+	key_mgmt=WPA-EAP
+	identity="user"
+	password="pass"
+}
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	networks, err := utils.ParseWPASupplicant(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []utils.WifiNetwork{
+		{SSID: "home", Password: "secret phrase"},
+		{SSID: "office", Password: "pass", Identity: "user"},
+	}
+	if !reflect.DeepEqual(networks, expected) {
+		t.Errorf("expected %+v, got %+v", expected, networks)
+	}
+
+	if err := os.WriteFile(file, []byte("network={\n\tssid=\"home\"\n\tpsk=0123456789abcdef\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ParseWPASupplicant(file); err == nil {
+		t.Errorf("expected error for not quoted psk")
+	}
+}
+
+func TestPrepareInstallerConfig(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "server"), []byte("mydomain.adam:3333"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "onboard.cert.pem"), []byte("onboard"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := utils.InstallerConfig{
+		Server:     "zedcloud.example.com",
+		Port:       "eth1",
+		StaticIP:   "192.168.1.10/24",
+		Gateway:    "192.168.1.1",
+		DNSServers: []string{"8.8.8.8"},
+	}
+	if err := utils.PrepareInstallerConfig(srcDir, dstDir, conf); err != nil {
+		t.Fatal(err)
+	}
+	for file, expected := range map[string]string{"server": conf.Server, "onboard.cert.pem": "onboard"} {
+		data, err := os.ReadFile(filepath.Join(dstDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("expected %s in %s, got %s", expected, file, data)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dstDir, "DevicePortConfig", "override.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dpc struct {
+		Ports []struct {
+			IfName     string
+			Dhcp       int
+			AddrSubnet string
+			Gateway    string
+			DNSServers []string
+			IsMgmt     bool
+		}
+	}
+	if err := json.Unmarshal(data, &dpc); err != nil {
+		t.Fatal(err)
+	}
+	if len(dpc.Ports) != 1 {
+		t.Fatalf("expected one port, got %s", data)
+	}
+	port := dpc.Ports[0]
+	if port.IfName != "eth1" || port.Dhcp != 1 || port.AddrSubnet != conf.StaticIP ||
+		port.Gateway != conf.Gateway || !reflect.DeepEqual(port.DNSServers, conf.DNSServers) || !port.IsMgmt {
+		t.Errorf("unexpected port config: %s", data)
+	}
+
+	conf.StaticIP = "192.168.1.10"
+	if err := utils.PrepareInstallerConfig("", t.TempDir(), conf); err == nil {
+		t.Errorf("expected error for static IP without prefix length")
+	}
+	if err := utils.PrepareInstallerConfig("", t.TempDir(), utils.InstallerConfig{DeviceCert: "device.cert.pem"}); err == nil {
+		t.Errorf("expected error for certificate of device without key")
+	}
+}
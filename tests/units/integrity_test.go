@@ -0,0 +1,40 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSaveArtifactDigests(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "live.qcow2")
+	if err := os.WriteFile(image, []byte("eve"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	digestsFile := filepath.Join(dir, "digests.yml")
+	digests := &utils.ArtifactDigests{Image: "lfedge/eve:13.4.0-kvm-amd64", ImageDigest: "sha256:0123"}
+	if err := utils.SaveArtifactDigests(digestsFile, digests, image); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(digestsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved utils.ArtifactDigests
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("eve"))
+	if saved.ImageDigest != "sha256:0123" || saved.Files[image] != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected digests: %+v", saved)
+	}
+	if err := utils.SaveArtifactDigests(digestsFile, digests, filepath.Join(dir, "absent")); err == nil {
+		t.Error("expected error for absent file")
+	}
+}
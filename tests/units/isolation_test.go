@@ -0,0 +1,199 @@
+package templates
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+)
+
+func TestIsolateContext(t *testing.T) {
+	edenHome := t.TempDir()
+	t.Setenv("EDEN_HOME", edenHome)
+	t.Setenv("EDEN_PORT_REGISTRY", filepath.Join(t.TempDir(), "ports.json"))
+	contextsDir := filepath.Join(edenHome, "contexts")
+	if err := os.MkdirAll(contextsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(contextsDir, "first.yml"), []byte("eden:\n  slot: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &openevec.EdenSetupArgs{ConfigName: "default"}
+	cfg.Eden.Root = "/eden/dist"
+	cfg.Eden.CertsDir = "/eden/dist/default-certs"
+	cfg.Eve.ImageFile = "/eden/dist/default-images/eve/live.img"
+	cfg.Eve.QemuFileToSave = filepath.Join(edenHome, "default-qemu.conf")
+	cfg.Eve.Dist = "default-eve"
+	cfg.Adam.Port = 3333
+	cfg.Adam.Redis.Eden = "192.168.1.2:6379"
+	cfg.Eve.HostFwd = map[string]string{"2222": "22"}
+	cfg.Sdn.PidFile = "/eden/dist/sdn.pid"
+	cfg.Sdn.IPv6Subnet = "fd59:9c46:bc86:2222::/64"
+
+	if err := openevec.IsolateContext(cfg, "second"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Eden.Slot != 2 {
+		t.Errorf("expected slot 2, got %d", cfg.Eden.Slot)
+	}
+	for _, check := range []struct{ actual, expected string }{
+		{cfg.Eden.CertsDir, "/eden/dist/second-certs"},
+		{cfg.Eve.ImageFile, "/eden/dist/second-images/eve/live.img"},
+		{cfg.Eve.QemuFileToSave, filepath.Join(edenHome, "second-qemu.conf")},
+		{cfg.Eve.Dist, "second-eve"},
+		{cfg.Adam.Redis.Eden, "192.168.1.2:8379"},
+		{cfg.Adam.Redis.RemoteURL, "eden_redis_second:6379"},
+		{cfg.Sdn.PidFile, "/eden/dist/second-sdn.pid"},
+		{cfg.Sdn.IPv6Subnet, "fd59:9c46:bc86:2224::/64"},
+		{cfg.Containers().Adam, "eden_adam_second"},
+		{cfg.Eve.HostFwd["4222"], "22"},
+	} {
+		if check.actual != check.expected {
+			t.Errorf("expected %s, got %s", check.expected, check.actual)
+		}
+	}
+	if cfg.Adam.Port != 5333 {
+		t.Errorf("expected adam port 5333, got %d", cfg.Adam.Port)
+	}
+}
+
+func TestIsolateContextSubnets(t *testing.T) {
+	t.Setenv("EDEN_HOME", t.TempDir())
+	t.Setenv("EDEN_PORT_REGISTRY", filepath.Join(t.TempDir(), "ports.json"))
+	isolate := func(name string) *openevec.EdenSetupArgs {
+		cfg := &openevec.EdenSetupArgs{ConfigName: "default"}
+		cfg.Sdn.IPv4Subnet = "192.168.100.0/24"
+		cfg.Sdn.IPv6Subnet = "fd59:9c46:bc86:2222::/64"
+		if err := openevec.IsolateContext(cfg, name); err != nil {
+			t.Fatal(err)
+		}
+		// the next context sees slot of this one as used
+		contextsDir := filepath.Join(os.Getenv("EDEN_HOME"), "contexts")
+		if err := os.MkdirAll(contextsDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		data := fmt.Sprintf("eden:\n  slot: %d\n", cfg.Eden.Slot)
+		if err := os.WriteFile(filepath.Join(contextsDir, name+".yml"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+	first, second := isolate("first"), isolate("second")
+	if first.Eden.Slot == second.Eden.Slot {
+		t.Fatalf("contexts share slot %d", first.Eden.Slot)
+	}
+	for _, subnets := range [][2]string{
+		{first.Sdn.IPv4Subnet, second.Sdn.IPv4Subnet},
+		{first.Sdn.IPv6Subnet, second.Sdn.IPv6Subnet},
+	} {
+		_, firstNet, err := net.ParseCIDR(subnets[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, secondNet, err := net.ParseCIDR(subnets[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if firstNet.Contains(secondNet.IP) || secondNet.Contains(firstNet.IP) {
+			t.Errorf("subnets of slots %d and %d overlap: %s and %s",
+				first.Eden.Slot, second.Eden.Slot, firstNet, secondNet)
+		}
+	}
+	if second.Sdn.IPv4Subnet != "192.168.102.0/24" {
+		t.Errorf("expected IPv4 subnet of slot 2 192.168.102.0/24, got %s", second.Sdn.IPv4Subnet)
+	}
+}
+
+func TestPortRegistryPath(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("EDEN_PORT_REGISTRY", "")
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	path, err := openevec.PortRegistryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(runtimeDir, "eden", "eden-port-registry.json") {
+		t.Errorf("unexpected path of port registry %s", path)
+	}
+}
+
+func TestAllocatePorts(t *testing.T) {
+	registryPath := filepath.Join(t.TempDir(), "ports.json")
+	// registry created by older eden is writable by others
+	if err := os.WriteFile(registryPath, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDEN_PORT_REGISTRY", registryPath)
+	newConfig := func() *openevec.EdenSetupArgs {
+		cfg := &openevec.EdenSetupArgs{ConfigName: "default"}
+		cfg.Adam.Port = 39333
+		cfg.Eve.TelnetPort = 37777
+		return cfg
+	}
+	allocate := func(workspace, name string) *openevec.EdenSetupArgs {
+		t.Setenv("EDEN_HOME", workspace)
+		cfg := newConfig()
+		if err := openevec.AllocatePorts(cfg, name); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+	release := func(workspace, name string) {
+		t.Setenv("EDEN_HOME", workspace)
+		if err := openevec.ReleasePorts(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second := t.TempDir(), t.TempDir()
+
+	// contexts of one workspace share default ports
+	for _, name := range []string{"default", "other"} {
+		if cfg := allocate(first, name); cfg.Eden.Slot != 0 || cfg.Adam.Port != 39333 {
+			t.Errorf("expected default ports for %s, got slot %d and adam port %d", name, cfg.Eden.Slot, cfg.Adam.Port)
+		}
+	}
+	// context of other workspace gets own ports
+	cfg := allocate(second, "default")
+	if cfg.Eden.Slot != 1 || cfg.Adam.Port != 40333 || cfg.Eve.TelnetPort != 38777 {
+		t.Errorf("expected ports of slot 1, got slot %d, adam port %d, telnet port %d",
+			cfg.Eden.Slot, cfg.Adam.Port, cfg.Eve.TelnetPort)
+	}
+	if cfg.Containers().Adam != "eden_adam_default" {
+		t.Errorf("expected own container of adam, got %s", cfg.Containers().Adam)
+	}
+
+	reservations, err := openevec.PortReservations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(registryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected port registry with mode 0600, got %v", info.Mode().Perm())
+	}
+	if len(reservations) != 2 {
+		t.Fatalf("expected 2 reservations, got %d", len(reservations))
+	}
+	if reservations[0].Workspace != first || strings.Join(reservations[0].Contexts, ",") != "default,other" {
+		t.Errorf("unexpected reservation of slot 0: %+v", reservations[0])
+	}
+	if reservations[1].Workspace != second || reservations[1].Ports["adam"] != 40333 {
+		t.Errorf("unexpected reservation of slot 1: %+v", reservations[1])
+	}
+
+	// default ports are reserved until all contexts of workspace are deleted
+	release(first, "default")
+	if cfg := allocate(second, "next"); cfg.Eden.Slot != 2 {
+		t.Errorf("expected slot 2, got %d", cfg.Eden.Slot)
+	}
+	release(first, "other")
+	if cfg := allocate(second, "last"); cfg.Eden.Slot != 0 {
+		t.Errorf("expected slot 0, got %d", cfg.Eden.Slot)
+	}
+}
@@ -0,0 +1,103 @@
+package templates
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// These tests verify the functionality of EDEN utils.SelectJSONPath
+// used to filter fields of info/config in JSON form
+
+const jsonPathDoc = `{
+  "dinfo": {
+    "network": [
+      {"devName": "eth0", "IPAddrs": ["10.0.0.2/24", "fe80::1/64"]},
+      {"devName": "eth1", "IPAddrs": ["10.1.0.2/24"]}
+    ]
+  },
+  "ztype": "ZiDevice"
+}`
+
+func TestSelectJSONPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{path: ".ztype", expected: []string{"ZiDevice"}},
+		{path: "$.ztype", expected: []string{"ZiDevice"}},
+		{path: ".dinfo.network[].devName", expected: []string{"eth0", "eth1"}},
+		{path: ".dinfo.network[*].devName", expected: []string{"eth0", "eth1"}},
+		{path: ".dinfo.network[1].devName", expected: []string{"eth1"}},
+		{path: ".dinfo.network[-1].devName", expected: []string{"eth1"}},
+		{path: ".dinfo.network[0].ipAddrs", expected: []string{`["10.0.0.2/24","fe80::1/64"]`}},
+		{path: ".dinfo.network[].IPAddrs[0]", expected: []string{"10.0.0.2/24", "10.1.0.2/24"}},
+		{path: `.dinfo["network"][0].devName`, expected: []string{"eth0"}},
+		{path: ".dinfo.network.devName", expected: []string{"eth0", "eth1"}},
+		{path: ".dinfo.absent", expected: nil},
+		{path: ".dinfo.network[5]", expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			values, err := utils.SelectJSONPathFromBytes([]byte(jsonPathDoc), tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range values {
+				got = append(got, utils.JSONPathValueString(v))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectJSONPathMalformed(t *testing.T) {
+	if _, err := utils.SelectJSONPathFromBytes([]byte(jsonPathDoc), ".dinfo.network[x]"); err == nil {
+		t.Error("expected error for malformed index")
+	}
+	if _, err := utils.SelectJSONPathFromBytes([]byte(jsonPathDoc), ".dinfo.network[0"); err == nil {
+		t.Error("expected error for unterminated index")
+	}
+	if err := utils.ValidateJSONPath(".dinfo.network[x]"); err == nil {
+		t.Error("expected validation error for malformed index")
+	}
+	if err := utils.ValidateJSONPath(".dinfo.network[].IPAddrs"); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestZInfoSelect(t *testing.T) {
+	res, err := einfo.ZInfoSelect(infoTest, []string{".niinfo.ipAssignments[].ipAddress[0]", ".devId"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		".niinfo.ipAssignments[].ipAddress[0]": {ipAddress1, ipAddress3},
+		".devId":                               {devID},
+	}
+	if !reflect.DeepEqual(map[string][]string(*res), expected) {
+		t.Errorf("got %v, expected %v", *res, expected)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	oldDoc := `{"state": "INSTALLED", "atTimeStamp": "1", "ips": ["10.0.0.2"], "gone": true}`
+	newDoc := `{"state": "RUNNING", "atTimeStamp": "2", "ips": ["10.0.0.2", "10.0.0.3"], "added": 1}`
+	diffs, err := utils.DiffJSONBytes([]byte(oldDoc), []byte(newDoc), einfo.IsNoisyInfoField)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `+ .added: 1
+- .gone: true
++ .ips[1]: 10.0.0.3
+~ .state: INSTALLED -> RUNNING
+`
+	if got := utils.FormatJSONDiff(diffs); got != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+}
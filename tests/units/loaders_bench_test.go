@@ -0,0 +1,146 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These benchmarks measure reading of streams of controller by loaders,
+// benchmarks of redis run only if address of redis is set in benchRedisEnv
+// (e.g. EDEN_BENCH_REDIS=localhost:6379), streams in database 15 are overwritten
+
+const benchRedisEnv = "EDEN_BENCH_REDIS"
+
+const benchMessages = 5000
+
+var benchDevID = uuid.NewV5(uuid.NamespaceOID, "eden-bench")
+
+func benchInfoMsg(i int) *info.ZInfoMsg {
+	return &info.ZInfoMsg{
+		Ztype: info.ZInfoTypes_ZiApp,
+		DevId: benchDevID.String(),
+		// timestamps are unique, cache deduplicates messages by them
+		AtTimeStamp: &timestamppb.Timestamp{Seconds: 1700000000, Nanos: int32(i)},
+		InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{
+			AppID:   uuid.NewV5(benchDevID, fmt.Sprint(i)).String(),
+			AppName: fmt.Sprintf("app%d", i),
+		}},
+	}
+}
+
+func BenchmarkInfoFind(b *testing.B) {
+	im := benchInfoMsg(1)
+	query := map[string]string{"InfoContent.Ainfo.AppName": "app[0-9]+"}
+	b.Run("find", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !einfo.ZInfoFind(im, query) {
+				b.Fatal("not matched")
+			}
+		}
+	})
+	// matching with compilation of pattern for every message as before
+	b.Run("compile", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if matched, _ := regexp.Match(query["InfoContent.Ainfo.AppName"], []byte(im.GetAinfo().AppName)); !matched {
+				b.Fatal("not matched")
+			}
+		}
+	})
+	b.Run("match", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if matched, _ := utils.MatchRegexp(query["InfoContent.Ainfo.AppName"], []byte(im.GetAinfo().AppName)); !matched {
+				b.Fatal("not matched")
+			}
+		}
+	})
+}
+
+func benchRedis(b *testing.B) (string, *redis.Client) {
+	addr := os.Getenv(benchRedisEnv)
+	if addr == "" {
+		b.Skipf("%s is not set", benchRedisEnv)
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, DB: 15})
+	b.Cleanup(func() { client.Close() })
+	return addr, client
+}
+
+func benchStreamGetters(stream string) types.StreamGetters {
+	getStream := func(uuid.UUID) string { return stream }
+	return types.StreamGetters{StreamInfo: getStream, StreamLogs: getStream, StreamMetrics: getStream}
+}
+
+func BenchmarkRedisLoader(b *testing.B) {
+	addr, client := benchRedis(b)
+	ctx := context.Background()
+	stream := "bench-info"
+	client.Del(ctx, stream)
+	for i := 0; i < benchMessages; i++ {
+		data, err := proto.Marshal(benchInfoMsg(i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]interface{}{"object": data}}).Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		loader := loaders.NewRedisLoader(addr, "", 15, benchStreamGetters(stream))
+		count := 0
+		if err := einfo.InfoLast(loader, map[string]string{"InfoContent.Ainfo.AppName": "app"}, einfo.ZInfoFind, func(*info.ZInfoMsg) bool {
+			count++
+			return false
+		}); err != nil {
+			b.Fatal(err)
+		}
+		if count != benchMessages {
+			b.Fatalf("expected %d messages, got %d", benchMessages, count)
+		}
+	}
+}
+
+func BenchmarkRedisCache(b *testing.B) {
+	addr, client := benchRedis(b)
+	ctx := context.Background()
+	stream := "bench-cache"
+	messages := make([][]byte, benchMessages)
+	for i := range messages {
+		data, err := protojson.Marshal(benchInfoMsg(i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		messages[i] = data
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.Del(ctx, stream)
+		cache := cachers.NewRedisCache(addr, "", 15, benchStreamGetters(stream))
+		// the second pass finds all messages in cache
+		for pass := 0; pass < 2; pass++ {
+			for _, data := range messages {
+				if err := cache.CheckAndSave(uuid.Nil, types.InfoType, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		if n := client.XLen(ctx, stream).Val(); n != benchMessages {
+			b.Fatalf("expected %d messages in stream, got %d", benchMessages, n)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// These tests verify format of logs of eden and levels of its subsystems
+
+func TestLogFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer func() {
+		_ = utils.SetUpLogFormat(utils.LogFormatText, log.InfoLevel, nil)
+	}()
+
+	if err := utils.SetUpLogFormat("xml", log.InfoLevel, nil); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if err := utils.SetUpLogFormat(utils.LogFormatJSON, log.InfoLevel, map[string]string{"controller": "loud"}); err == nil {
+		t.Error("expected error for unsupported level of subsystem")
+	}
+
+	if err := utils.SetUpLogFormat(utils.LogFormatJSON, log.InfoLevel, nil); err != nil {
+		t.Fatal(err)
+	}
+	log.WithField("device", "dev1").Warn("onboarding")
+	log.Debug("hidden")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %q", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "onboarding" || entry["device"] != "dev1" || entry["time"] == nil {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	buf.Reset()
+	if err := utils.SetUpLogFormat(utils.LogFormatJSON, log.WarnLevel, map[string]string{"tests": "debug", "tests/units/other": "error"}); err != nil {
+		t.Fatal(err)
+	}
+	log.Debug("shown")
+	log.Trace("hidden")
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %q", buf.String())
+	}
+	entry = nil
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if entry["level"] != "DEBUG" || entry["subsystem"] != "tests/units" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+	if log.StandardLogger().ReportCaller {
+		t.Error("levels of subsystems must not enable reporting of caller")
+	}
+	if !utils.LevelEnabled(log.DebugLevel) || utils.LevelEnabled(log.TraceLevel) {
+		t.Error("expected debug level enabled for subsystem tests/units")
+	}
+
+	buf.Reset()
+	if err := utils.SetUpLogFormat(utils.LogFormatText, log.InfoLevel, map[string]string{"tests/units": "error"}); err != nil {
+		t.Fatal(err)
+	}
+	log.Warn("hidden")
+	log.Error("failed")
+	if utils.LevelEnabled(log.WarnLevel) || !utils.LevelEnabled(log.ErrorLevel) {
+		t.Error("expected error level for subsystem tests/units")
+	}
+	if log.GetLevel() != log.InfoLevel {
+		t.Errorf("expected global level info, got %s", log.GetLevel())
+	}
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "subsystem=tests/units") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
@@ -0,0 +1,55 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+// These tests verify that tail of logs keeps order of entries and applies query
+
+func TestLogTail(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		source := "pillar"
+		if i%2 == 1 {
+			source = "kernel"
+		}
+		name := filepath.Join(dir, fmt.Sprintf("%d.json", i))
+		entry := fmt.Sprintf(`{"source":%q,"content":"message %d"}`, source, i)
+		if err := os.WriteFile(name, []byte(entry), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := start.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(name, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader := loaders.NewFileLoader(types.DirGetters{
+		LogsGetter: func(uuid.UUID) string { return dir },
+	})
+	var contents []string
+	handler := func(le *elog.FullLogEntry) bool {
+		contents = append(contents, le.Content)
+		return false
+	}
+	query := map[string]string{"source": "pillar"}
+	if err := elog.LogChecker(loader, uuid.Nil, query, handler, elog.LogTail(3), 0); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"message 4", "message 6", "message 8"}
+	if fmt.Sprint(contents) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, contents)
+	}
+	if query["source"] != "pillar" {
+		t.Errorf("query must not be modified")
+	}
+}
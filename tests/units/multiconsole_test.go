@@ -0,0 +1,116 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eden"
+)
+
+// These tests verify the functionality of multiplexed console of EVE nodes
+
+// syncBuffer is buffer safe for concurrent use as output of terminal
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor waits until get returns string containing s
+func waitFor(t *testing.T, what string, get func() string, s string) {
+	for i := 0; i < 50; i++ {
+		if strings.Contains(get(), s) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("no %q in %s: %q", s, what, get())
+}
+
+func TestMultiConsole(t *testing.T) {
+	var nodes []eden.ConsoleNode
+	for _, name := range []string{"node1", "node2"} {
+		console, err := eden.DialTelnetConsole(fakeTelnetConsole(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, eden.ConsoleNode{Name: name, Console: console})
+	}
+	nodes = append(nodes, eden.ConsoleNode{Name: "node3", Err: errors.New("EVE is not running")})
+
+	logDir := t.TempDir()
+	logOf := func(node string) func() string {
+		return func() string {
+			data, _ := os.ReadFile(filepath.Join(logDir, node+".log"))
+			return string(data)
+		}
+	}
+	out := &syncBuffer{}
+	mc, err := eden.NewMultiConsole(nodes, logDir, out, 80, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, input := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- mc.Run(context.Background(), in) }()
+
+	waitFor(t, "status bar", out.String, "1:node1* 2:node2 3:node3!")
+	waitFor(t, "log of node1", logOf("node1"), "login: ")
+	waitFor(t, "log of node2", logOf("node2"), "login: ")
+	waitFor(t, "status bar", out.String, "2:node2#")
+
+	// input goes to the shown node
+	if _, err := io.WriteString(input, "root\r"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "log of node1", logOf("node1"), "echo root")
+	waitFor(t, "terminal", out.String, "echo root")
+
+	// Ctrl-B n shows the next node
+	if _, err := io.WriteString(input, "\x02nadmin\r"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "log of node2", logOf("node2"), "echo admin")
+	waitFor(t, "status bar", out.String, "1:node1 2:node2*")
+	if strings.Contains(logOf("node1")(), "admin") {
+		t.Errorf("input of node2 is sent to node1: %q", logOf("node1")())
+	}
+
+	// Ctrl-B 3 shows node without console
+	if _, err := io.WriteString(input, "\x023"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "terminal", out.String, "console of node3 is not available: EVE is not running")
+
+	// Ctrl-B d detaches
+	if _, err := io.WriteString(input, "\x02d"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("console is not detached")
+	}
+}
@@ -0,0 +1,158 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/netboot"
+)
+
+// These tests verify proxy DHCP and TFTP servers used to boot EVE installer over network
+
+func pxeRequest(messageType byte, ipxe bool) *netboot.Packet {
+	req := &netboot.Packet{
+		Op:     1,
+		XID:    0x12345678,
+		CIAddr: net.IPv4zero,
+		YIAddr: net.IPv4zero,
+		SIAddr: net.IPv4zero,
+		GIAddr: net.IPv4zero,
+		CHAddr: net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56},
+		Options: map[byte][]byte{
+			netboot.OptionMessageType: {messageType},
+			netboot.OptionVendorClass: []byte("PXEClient:Arch:00007:UNDI:003016"),
+			netboot.OptionClientGUID:  {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+	}
+	if ipxe {
+		req.Options[netboot.OptionUserClass] = []byte("iPXE")
+	}
+	return req
+}
+
+func TestNetbootDHCPReply(t *testing.T) {
+	server := &netboot.Server{
+		IP:         net.ParseIP("192.168.0.2"),
+		Bootloader: "ipxe-amd64.efi",
+		ScriptURL:  "http://192.168.0.2:8888/eserver/ipxe.efi.cfg",
+	}
+	tests := []struct {
+		name        string
+		req         *netboot.Packet
+		messageType byte
+		file        string
+	}{
+		{name: "pxe", req: pxeRequest(netboot.DHCPDiscover, false), messageType: netboot.DHCPOffer, file: server.Bootloader},
+		{name: "ipxe", req: pxeRequest(netboot.DHCPDiscover, true), messageType: netboot.DHCPOffer, file: server.ScriptURL},
+		{name: "request", req: pxeRequest(netboot.DHCPRequest, false), messageType: netboot.DHCPAck, file: server.Bootloader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := netboot.ParsePacket(tt.req.Marshal())
+			if err != nil {
+				t.Fatal(err)
+			}
+			reply := server.Reply(req)
+			if reply == nil {
+				t.Fatal("no reply")
+			}
+			reply, err = netboot.ParsePacket(reply.Marshal())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply.MessageType() != tt.messageType {
+				t.Errorf("expected message type %d, got %d", tt.messageType, reply.MessageType())
+			}
+			if reply.File != tt.file || string(reply.Options[netboot.OptionBootfileName]) != tt.file {
+				t.Errorf("expected boot file %s, got %s", tt.file, reply.File)
+			}
+			if reply.XID != tt.req.XID || reply.CHAddr.String() != tt.req.CHAddr.String() {
+				t.Errorf("reply does not match request: %+v", reply)
+			}
+			if !reply.SIAddr.Equal(server.IP) || !reply.YIAddr.Equal(net.IPv4zero) {
+				t.Errorf("unexpected addresses in reply: %+v", reply)
+			}
+			if !bytes.Equal(reply.Options[netboot.OptionClientGUID], tt.req.Options[netboot.OptionClientGUID]) {
+				t.Errorf("GUID of client is not copied")
+			}
+		})
+	}
+
+	notPXE := pxeRequest(netboot.DHCPDiscover, false)
+	delete(notPXE.Options, netboot.OptionVendorClass)
+	if reply := server.Reply(notPXE); reply != nil {
+		t.Errorf("expected no reply to client without PXE, got %+v", reply)
+	}
+}
+
+func TestNetbootTFTP(t *testing.T) {
+	root := t.TempDir()
+	content := bytes.Repeat([]byte("0123456789abcdef"), 100)
+	if err := os.WriteFile(filepath.Join(root, "ipxe.efi"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	server := &netboot.Server{TFTPRoot: root, Timeout: time.Second}
+	go func() { _ = server.ServeTFTP(conn) }()
+
+	read := func(file string, options ...string) ([]byte, uint16) {
+		client, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		req := binary.BigEndian.AppendUint16(nil, 1)
+		for _, field := range append([]string{file, "octet"}, options...) {
+			req = append(append(req, field...), 0)
+		}
+		if _, err := client.WriteTo(req, conn.LocalAddr()); err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		buf := make([]byte, 2048)
+		for {
+			_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, addr, err := client.ReadFrom(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opcode := binary.BigEndian.Uint16(buf[:2])
+			switch opcode {
+			case 3:
+				block := binary.BigEndian.Uint16(buf[2:4])
+				data = append(data, buf[4:n]...)
+				_, _ = client.WriteTo(append(binary.BigEndian.AppendUint16(nil, 4), buf[2:4]...), addr)
+				if n-4 < 512 {
+					return data, block
+				}
+			case 6:
+				_, _ = client.WriteTo([]byte{0, 4, 0, 0}, addr)
+			default:
+				return nil, opcode
+			}
+		}
+	}
+
+	data, blocks := read("ipxe.efi")
+	if !bytes.Equal(data, content) {
+		t.Errorf("received %d bytes instead of %d", len(data), len(content))
+	}
+	if blocks != 4 {
+		t.Errorf("expected 4 blocks, got %d", blocks)
+	}
+	if data, _ := read("/ipxe.efi", "tsize", "0"); !bytes.Equal(data, content) {
+		t.Errorf("received %d bytes instead of %d with options", len(data), len(content))
+	}
+	if _, opcode := read("../ipxe.efi.cfg"); opcode != 5 {
+		t.Errorf("expected error for file outside of root, got opcode %d", opcode)
+	}
+}
@@ -0,0 +1,52 @@
+package templates
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eve"
+)
+
+// These tests verify summary of EVE network debug tarballs
+
+func TestNetdumpSummary(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"eve/version": "12.0.0\n",
+		"eve/dns.json": `{"Ports": [{"IfName": "eth0", "Logicallabel": "uplink", "Up": true,
+			"AddrInfoList": [{"Addr": "10.0.0.2"}], "DNSServers": ["10.0.0.1"], "LastError": ""}]}`,
+		"requests/ping/nettrace.json": `{"description": "controller ping",
+			"dnsQueries": [{"dnsQueryMsgs": [{"questions": [{"name": "zedcloud.local"}]}]}],
+			"dials": [{"dstAddress": "10.0.0.5:443", "dialErr": "connection refused"}],
+			"httpRequests": [{"reqMethod": "GET", "reqURL": "https://zedcloud.local/ping", "respStatusCode": 200}]}`,
+	}
+	for name, content := range files {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := eve.NetdumpSummary(dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+	summary := buf.String()
+	for _, expected := range []string{
+		"EVE version: 12.0.0",
+		"uplink", "10.0.0.2", "10.0.0.1",
+		"ping/nettrace.json: controller ping",
+		"dns query: zedcloud.local",
+		"dial 10.0.0.5:443 failed: connection refused",
+		"GET https://zedcloud.local/ping: 200",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("summary does not contain %q:\n%s", expected, summary)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/expect"
+)
+
+// These tests verify IP config of local network instances created by eden
+
+func TestNetInstanceIPSpec(t *testing.T) {
+	spec, err := expect.NetInstanceIPSpec("10.11.12.0/24", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Gateway != "10.11.12.1" || !reflect.DeepEqual(spec.Dns, []string{"10.11.12.1"}) {
+		t.Errorf("unexpected default gateway or DNS: %+v", spec)
+	}
+
+	spec, err = expect.NetInstanceIPSpec("10.11.12.0/24", "10.11.12.254",
+		[]string{"8.8.8.8", "1.1.1.1"}, "10.11.12.100-10.11.12.150")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Gateway != "10.11.12.254" || !reflect.DeepEqual(spec.Dns, []string{"8.8.8.8", "1.1.1.1"}) ||
+		spec.DhcpRange.Start != "10.11.12.100" || spec.DhcpRange.End != "10.11.12.150" {
+		t.Errorf("unexpected IP config: %+v", spec)
+	}
+
+	for _, tc := range []struct {
+		gateway   string
+		dns       []string
+		dhcpRange string
+	}{
+		{gateway: "10.11.13.1"},
+		{dns: []string{"dns.example.com"}},
+		{dhcpRange: "10.11.12.100"},
+		{dhcpRange: "10.11.12.150-10.11.12.100"},
+		{dhcpRange: "10.11.12.100-10.11.13.150"},
+	} {
+		if _, err := expect.NetInstanceIPSpec("10.11.12.0/24", tc.gateway, tc.dns, tc.dhcpRange); err == nil {
+			t.Errorf("expected error for %+v", tc)
+		}
+	}
+}
+
+func TestNetInstanceStaticRoute(t *testing.T) {
+	route, err := expect.NetInstanceStaticRoute("10.50.0.0/16", "10.11.12.5", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route.DestinationNetwork != "10.50.0.0/16" || route.Gateway != "10.11.12.5" {
+		t.Errorf("unexpected route: %+v", route)
+	}
+	if _, err := expect.NetInstanceStaticRoute("0.0.0.0/0", "", "uplink"); err != nil {
+		t.Errorf("unexpected error for default route via port: %s", err)
+	}
+	for _, tc := range [][3]string{
+		{"10.50.0.0", "10.11.12.5", ""},
+		{"10.50.0.0/16", "", ""},
+		{"10.50.0.0/16", "0.0.0.0", ""},
+		{"10.50.0.0/16", "gw", ""},
+	} {
+		if _, err := expect.NetInstanceStaticRoute(tc[0], tc[1], tc[2]); err == nil {
+			t.Errorf("expected error for %v", tc)
+		}
+	}
+}
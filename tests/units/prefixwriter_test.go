@@ -0,0 +1,27 @@
+package templates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// These tests verify prefixing of output lines used to show output of tests of several nodes
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := utils.NewPrefixWriter(&buf, "[node1] ", nil)
+	for _, part := range []string{"first li", "ne\nsecond line\nth", "ird"} {
+		if _, err := w.Write([]byte(part)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "[node1] first line\n[node1] second line\n[node1] third\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, expected %q", buf.String(), expected)
+	}
+}
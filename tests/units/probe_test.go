@@ -0,0 +1,42 @@
+package templates
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eve"
+)
+
+// These tests verify parsing of EDEN app health probes
+
+func TestParseAppProbe(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected *eve.AppProbe
+	}{
+		{spec: "http:8028", expected: &eve.AppProbe{Type: eve.ProbeHTTP, Port: 8028, Path: "/", EveIfName: "eth0"}},
+		{spec: "http:8028/health@eth1", expected: &eve.AppProbe{Type: eve.ProbeHTTP, Port: 8028, Path: "/health", EveIfName: "eth1"}},
+		{spec: "TCP:8027", expected: &eve.AppProbe{Type: eve.ProbeTCP, Port: 8027, EveIfName: "eth0"}},
+		{spec: "tcp:8027/path"},
+		{spec: "udp:8027"},
+		{spec: "http:port"},
+		{spec: "8027"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			probe, err := eve.ParseAppProbe(tt.spec)
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("expected error, got %v", probe)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(probe, tt.expected) {
+				t.Errorf("got %+v, expected %+v", probe, tt.expected)
+			}
+		})
+	}
+}
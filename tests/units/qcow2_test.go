@@ -0,0 +1,39 @@
+package templates
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eden"
+)
+
+func TestBackingFileQemu(t *testing.T) {
+	backingFile := "/home/user/dist/default-images/eve/live.img"
+	header := make([]byte, 512)
+	copy(header, "QFI\xfb")
+	binary.BigEndian.PutUint32(header[4:8], 3)
+	binary.BigEndian.PutUint64(header[8:16], 112)
+	binary.BigEndian.PutUint32(header[16:20], uint32(len(backingFile)))
+	copy(header[112:], backingFile)
+	overlayFile := filepath.Join(t.TempDir(), "default-overlay.qcow2")
+	if err := os.WriteFile(overlayFile, header, 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := eden.BackingFileQemu(overlayFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != backingFile {
+		t.Errorf("expected backing file %s, got %s", backingFile, result)
+	}
+	// image without backing file
+	binary.BigEndian.PutUint64(header[8:16], 0)
+	if err := os.WriteFile(overlayFile, header, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = eden.BackingFileQemu(overlayFile); err != nil || result != "" {
+		t.Errorf("unexpected result for image without backing file: %q, %v", result, err)
+	}
+}
@@ -0,0 +1,44 @@
+package templates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve-api/go/info"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These tests verify detection of unexpected reboots of EVE from device info
+
+func deviceInfo(rebootTime time.Time, bootReason info.BootReason) *info.ZInfoMsg {
+	return &info.ZInfoMsg{
+		Ztype: info.ZInfoTypes_ZiDevice,
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{
+			LastRebootTime:   timestamppb.New(rebootTime),
+			LastRebootReason: bootReason.String(),
+			LastBootReason:   bootReason,
+		}},
+	}
+}
+
+func TestRebootDetector(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	detector := eve.NewRebootDetector()
+	detector.Process(deviceInfo(start, info.BootReason_BOOT_REASON_FIRST))
+	detector.Process(deviceInfo(start.Add(time.Hour), info.BootReason_BOOT_REASON_WATCHDOG_PID))
+	// the same reboot reported in the next info must not be duplicated
+	detector.Process(deviceInfo(start.Add(time.Hour), info.BootReason_BOOT_REASON_WATCHDOG_PID))
+	detector.Process(deviceInfo(start.Add(2*time.Hour), info.BootReason_BOOT_REASON_REBOOT_CMD))
+	detector.Process(deviceInfo(start.Add(3*time.Hour), info.BootReason_BOOT_REASON_KERNEL))
+	detector.Process(&info.ZInfoMsg{Ztype: info.ZInfoTypes_ZiApp})
+
+	events := detector.Unexpected()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 unexpected reboots, got %d", len(events))
+	}
+	if events[0].BootReason != info.BootReason_BOOT_REASON_WATCHDOG_PID ||
+		events[1].BootReason != info.BootReason_BOOT_REASON_KERNEL {
+		t.Errorf("unexpected reboots: %v, %v", events[0].BootReason, events[1].BootReason)
+	}
+}
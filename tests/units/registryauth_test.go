@@ -0,0 +1,91 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+func TestNormalizeRegistry(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"docker://ghcr.io", "ghcr.io"},
+		{"docker://localhost:5000", "localhost:5000"},
+		{"docker://docker.io", "https://index.docker.io/v1/"},
+		{"nginx:1.25", "https://index.docker.io/v1/"},
+		{"ghcr.io/org/app:latest", "ghcr.io"},
+	}
+	for _, tt := range tests {
+		if got := utils.NormalizeRegistry(tt.ref); got != tt.want {
+			t.Errorf("NormalizeRegistry(%s) = %s, want %s", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestGetRegistryCredentials(t *testing.T) {
+	dir := t.TempDir()
+	dockerConfig := filepath.Join(dir, "config.json")
+	// base64 of "robot:secret"
+	data := `{"auths": {"gcr.io": {"auth": "cm9ib3Q6c2VjcmV0"}, "myregistry.azurecr.io": {"identitytoken": "refresh"}}}`
+	if err := os.WriteFile(dockerConfig, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// credential helper returning identity token
+	helper := filepath.Join(dir, "docker-credential-test")
+	if err := os.WriteFile(helper, []byte("#!/bin/sh\necho '{\"Username\": \"<token>\", \"Secret\": \"helper-refresh\"}'\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("EDEN_TEST_REGISTRY_PASSWORD", "from-env")
+	auths := []utils.RegistryAuth{
+		{Registry: "ghcr.io", User: "user", PasswordEnv: "EDEN_TEST_REGISTRY_PASSWORD"},
+		{Registry: "quay.io", User: "robot", PasswordFile: passwordFile},
+		{Registry: "gcr.io", DockerConfig: dockerConfig},
+		{Registry: "myregistry.azurecr.io", DockerConfig: dockerConfig},
+		{Registry: "registry.example.com", Helper: "test"},
+	}
+	tests := []struct {
+		fqdn string
+		want utils.RegistryCredentials
+	}{
+		{"docker://ghcr.io", utils.RegistryCredentials{User: "user", Password: "from-env"}},
+		{"docker://quay.io", utils.RegistryCredentials{User: "robot", Password: "from-file"}},
+		{"docker://gcr.io", utils.RegistryCredentials{User: "robot", Password: "secret"}},
+		{"docker://myregistry.azurecr.io", utils.RegistryCredentials{IdentityToken: "refresh"}},
+		{"docker://registry.example.com", utils.RegistryCredentials{IdentityToken: "helper-refresh"}},
+	}
+	for _, tt := range tests {
+		creds, err := utils.GetRegistryCredentials(tt.fqdn, auths)
+		if err != nil {
+			t.Fatalf("GetRegistryCredentials(%s): %s", tt.fqdn, err)
+		}
+		if *creds != tt.want {
+			t.Errorf("GetRegistryCredentials(%s) = %+v, want %+v", tt.fqdn, *creds, tt.want)
+		}
+	}
+	if auth := utils.FindRegistryAuth("docker://docker.io", auths); auth != nil {
+		t.Errorf("unexpected credentials for docker.io: %+v", auth)
+	}
+}
+
+func TestRegistryAuthPasswordErrors(t *testing.T) {
+	t.Setenv("EDEN_TEST_REGISTRY_PASSWORD", "")
+	for _, auth := range []utils.RegistryAuth{
+		{Registry: "ghcr.io", User: "user"},
+		{Registry: "ghcr.io", User: "user", PasswordEnv: "EDEN_TEST_REGISTRY_PASSWORD"},
+		{Registry: "ghcr.io", User: "user", PasswordFile: filepath.Join(t.TempDir(), "absent")},
+		{Registry: "ghcr.io", User: "user", PasswordEnv: "HOME", PasswordFile: "/etc/hostname"},
+	} {
+		if _, err := auth.Credentials("ghcr.io"); err == nil {
+			t.Errorf("expected error for %+v", auth)
+		}
+	}
+}
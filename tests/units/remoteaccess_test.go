@@ -0,0 +1,53 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// These tests verify checking of acknowledgement of remote access config items by EVE
+
+func TestConfigItemsAcknowledged(t *testing.T) {
+	enable := openevec.RemoteAccessItems(true, "ssh-rsa AAAA eden\n")
+	if enable["debug.enable.ssh"] != "ssh-rsa AAAA eden" {
+		t.Errorf("unexpected ssh key: %q", enable["debug.enable.ssh"])
+	}
+	disable := openevec.RemoteAccessItems(false, "")
+	if len(enable) != len(disable) {
+		t.Errorf("enable and disable must switch the same items: %v %v", enable, disable)
+	}
+
+	status := &info.ZInfoConfigItemStatus{ConfigItems: map[string]*info.ZInfoConfigItem{}}
+	for key, value := range enable {
+		status.ConfigItems[key] = &info.ZInfoConfigItem{Value: value}
+	}
+	if ok, err := openevec.ConfigItemsAcknowledged(enable, status); !ok || err != nil {
+		t.Errorf("enable must be acknowledged: %t %v", ok, err)
+	}
+	if ok, _ := openevec.ConfigItemsAcknowledged(disable, status); ok {
+		t.Error("disable must not be acknowledged while EVE reports enabled items")
+	}
+
+	// EVE does not report ssh key after it is removed
+	delete(status.ConfigItems, "debug.enable.ssh")
+	for key, value := range disable {
+		if value != "" {
+			status.ConfigItems[key] = &info.ZInfoConfigItem{Value: value}
+		}
+	}
+	if ok, err := openevec.ConfigItemsAcknowledged(disable, status); !ok || err != nil {
+		t.Errorf("disable must be acknowledged: %t %v", ok, err)
+	}
+
+	status.ConfigItems["app.allow.vnc"].Error = "invalid value"
+	if _, err := openevec.ConfigItemsAcknowledged(disable, status); err == nil {
+		t.Error("expected error for rejected item")
+	}
+	delete(status.ConfigItems, "app.allow.vnc")
+	status.UnknownConfigItems = map[string]*info.ZInfoConfigItem{"app.allow.vnc": {Error: "unknown"}}
+	if _, err := openevec.ConfigItemsAcknowledged(disable, status); err == nil {
+		t.Error("expected error for unknown item")
+	}
+}
@@ -0,0 +1,107 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/tests"
+)
+
+// These tests verify parsing of verbose output of test binaries into HTML report
+
+const verboseTestOutput = `=== RUN   TestEdenScripts
+=== RUN   TestEdenScripts/log_test
+=== PAUSE TestEdenScripts/log_test
+=== RUN   TestEdenScripts/app_test
+=== PAUSE TestEdenScripts/app_test
+=== CONT  TestEdenScripts/log_test
+=== CONT  TestEdenScripts/app_test
+=== NAME  TestEdenScripts/log_test
+    testscript.go:420:
+        # save config (1.500s)
+        > eden controller edge-node get-config
+        # check logs (10.250s)
+        > test eden.lim.test
+        PASS
+=== NAME  TestEdenScripts/app_test
+    testscript.go:420:
+        # deploy <app> (2.000s)
+        > eden pod deploy
+        FAIL: testdata/app_test.txt:5: unexpected command failure
+--- FAIL: TestEdenScripts (12.00s)
+    --- PASS: TestEdenScripts/log_test (11.75s)
+    --- FAIL: TestEdenScripts/app_test (2.01s)
+FAIL
+`
+
+func TestParseTestOutput(t *testing.T) {
+	scripts := tests.ParseTestOutput([]byte(verboseTestOutput))
+	if len(scripts) != 2 {
+		t.Fatalf("expected 2 scripts, got %d", len(scripts))
+	}
+	logTest, appTest := scripts[0], scripts[1]
+	if logTest.Name != "TestEdenScripts/log_test" || logTest.Status != tests.StatusPass ||
+		logTest.Duration != 11750*time.Millisecond {
+		t.Errorf("unexpected result of log_test: %+v", logTest)
+	}
+	if len(logTest.Phases) != 2 || logTest.Phases[1].Heading != "# check logs" ||
+		logTest.Phases[1].Duration != 10250*time.Millisecond {
+		t.Errorf("unexpected phases of log_test: %+v", logTest.Phases)
+	}
+	if appTest.Status != tests.StatusFail || !strings.Contains(appTest.Log, "unexpected command failure") ||
+		strings.Contains(appTest.Log, "check logs") {
+		t.Errorf("unexpected result of app_test: %+v", appTest)
+	}
+}
+
+func TestReportRender(t *testing.T) {
+	report := &tests.Report{Title: "eden test lim", Start: time.Now()}
+	report.AddRun("eden.escript.test -test.run TestEdenScripts/", time.Now(), errors.New("exit status 1"), []byte(verboseTestOutput))
+	report.AddArtifact("/tmp/artifacts")
+	if report.Count(tests.StatusPass) != 1 || report.Count(tests.StatusFail) != 1 {
+		t.Fatalf("unexpected counts: pass %d, fail %d", report.Count(tests.StatusPass), report.Count(tests.StatusFail))
+	}
+	var buf bytes.Buffer
+	if err := report.Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, expected := range []string{"TestEdenScripts/app_test", "# deploy &lt;app&gt;", "file:///tmp/artifacts", "exit status 1"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("report does not contain %q", expected)
+		}
+	}
+}
+
+func TestReadResults(t *testing.T) {
+	dir := t.TempDir()
+	report := &tests.Report{File: filepath.Join(dir, "report.html"), Title: "eden test lim", Start: time.Now()}
+	report.AddRun("eden.escript.test", time.Now(), errors.New("exit status 1"), []byte(verboseTestOutput))
+	if err := report.Write(); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := tests.ReadResults(report.ResultsFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Status != tests.StatusFail || len(runs[0].Scripts) != 2 {
+		t.Fatalf("unexpected runs from JSON results: %+v", runs)
+	}
+
+	outputFile := filepath.Join(dir, "output.txt")
+	if err := os.WriteFile(outputFile, []byte(verboseTestOutput), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runs, err = tests.ReadResults(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Status != tests.StatusFail || len(runs[0].Scripts) != 2 || runs[0].Duration == 0 {
+		t.Fatalf("unexpected runs from test output: %+v", runs)
+	}
+}
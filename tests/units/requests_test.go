@@ -0,0 +1,24 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+)
+
+// These tests verify detection of requests of config by 'eden controller requests'
+
+func TestIsConfigRequest(t *testing.T) {
+	for requestURL, expected := range map[string]bool{
+		"/api/v2/edgedevice/id/b2b8a7c4-1234-4f8c-9cd2-6b1f2b7d1e4a/config":          true,
+		"/api/v2/edgedevice/id/b2b8a7c4-1234-4f8c-9cd2-6b1f2b7d1e4a/compound-config": true,
+		"/api/v1/edgedevice/config?x=1":                                              true,
+		"/api/v2/edgedevice/id/b2b8a7c4-1234-4f8c-9cd2-6b1f2b7d1e4a/info":            false,
+		"/api/v2/edgedevice/certs":                                                   false,
+		"/api/v2/edgedevice/id/b2b8a7c4-1234-4f8c-9cd2-6b1f2b7d1e4a/config/extra":    false,
+	} {
+		if result := openevec.IsConfigRequest(requestURL); result != expected {
+			t.Errorf("%s: expected %t, got %t", requestURL, expected, result)
+		}
+	}
+}
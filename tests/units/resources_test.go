@@ -0,0 +1,131 @@
+package templates
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// serveQMP serves QMP socket with fixed block statistics of VM
+func serveQMP(t *testing.T, sockFile string, readBytes, writeBytes uint64) {
+	listener, err := net.Listen("unix", sockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			encoder := json.NewEncoder(conn)
+			_ = encoder.Encode(map[string]interface{}{"QMP": map[string]interface{}{}})
+			scanner := bufio.NewScanner(conn)
+			scanner.Split(scanJSON)
+			for scanner.Scan() {
+				var request struct {
+					Execute string `json:"execute"`
+				}
+				_ = json.Unmarshal(scanner.Bytes(), &request)
+				switch request.Execute {
+				case "query-blockstats":
+					_ = encoder.Encode(map[string]interface{}{"return": []interface{}{
+						map[string]interface{}{"stats": map[string]uint64{"rd_bytes": readBytes, "wr_bytes": writeBytes}},
+					}})
+				case "query-stats":
+					_ = encoder.Encode(map[string]interface{}{"error": map[string]string{
+						"class": "GenericError", "desc": "not supported"}})
+				default:
+					_ = encoder.Encode(map[string]interface{}{"event": "RESUME"})
+					_ = encoder.Encode(map[string]interface{}{"return": map[string]interface{}{}})
+				}
+			}
+			conn.Close()
+		}
+	}()
+}
+
+// scanJSON splits stream into JSON objects sent without separators
+func scanJSON(data []byte, atEOF bool) (int, []byte, error) {
+	depth := 0
+	for i, b := range data {
+		switch b {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1, data[:i+1], nil
+			}
+		}
+	}
+	return 0, nil, nil
+}
+
+func TestQemuStatsQMP(t *testing.T) {
+	sockFile := filepath.Join(t.TempDir(), "eve-qmp-stats.sock")
+	serveQMP(t, sockFile, 3<<20, 5<<20)
+	stats, err := utils.QemuStatsQMP(sockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.DiskReadBytes != 3<<20 || stats.DiskWriteBytes != 5<<20 || stats.Exits != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if got := utils.QemuStatsSocket("/tmp/eden/default-eve.pid"); got != "/tmp/eden/default-eve-qmp-stats.sock" {
+		t.Errorf("unexpected stats socket %s", got)
+	}
+}
+
+func TestResourceSampler(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resources are sampled from /proc")
+	}
+	// the test process plays role of EVE VM
+	pidFile := filepath.Join(t.TempDir(), "eve.pid")
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sampler := tests.StartResourceSampler(10*time.Millisecond, pidFile, "")
+	time.Sleep(50 * time.Millisecond)
+	usage := sampler.Stop()
+	if !usage.Host || !usage.VM || usage.QMP {
+		t.Fatalf("unexpected sampled resources %+v", usage)
+	}
+	if usage.HostMemPeak == 0 || usage.HostMemAvg > usage.HostMemPeak || usage.VMMemPeak == 0 {
+		t.Errorf("unexpected memory usage %+v", usage)
+	}
+	summary := usage.String()
+	for _, key := range []string{"host_cpu=", "host_mem_peak=", "eve_cpu=", "eve_mem_peak="} {
+		if !strings.Contains(summary, key) {
+			t.Errorf("summary %q does not contain %s", summary, key)
+		}
+	}
+	if strings.Contains(summary, "eve_disk_read=") {
+		t.Errorf("summary %q contains counters of QMP", summary)
+	}
+}
+
+func TestParseTestOutputResources(t *testing.T) {
+	output := `=== RUN   TestEdenScripts/app_test
+    testscript.go:520:
+        # deploy (1.000s)
+        resources: host_cpu=12.5% eve_cpu=3.0s
+--- PASS: TestEdenScripts/app_test (1.00s)
+`
+	scripts := tests.ParseTestOutput([]byte(output))
+	if len(scripts) != 1 || scripts[0].Resources != "host_cpu=12.5% eve_cpu=3.0s" {
+		t.Fatalf("unexpected results %+v", scripts)
+	}
+}
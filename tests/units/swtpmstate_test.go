@@ -0,0 +1,54 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eden"
+)
+
+// These tests verify copy of state of swtpm saved together with snapshots of EVE VM
+
+func TestCopySWTPMState(t *testing.T) {
+	stateDir := filepath.Join(t.TempDir(), "swtpm")
+	dstDir := filepath.Join(t.TempDir(), "clean.swtpm")
+	for dir, files := range map[string]map[string]string{
+		stateDir: {"tpm2-00.permall": "new", "swtpm.pid": "1", "swtpm.log": "log"},
+		dstDir:   {"tpm2-00.permall": "old", "tpm2-00.volatilestate": "old", "swtpm.log": "old log"},
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := eden.CopySWTPMState(stateDir, dstDir); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{"tpm2-00.permall": "new", "swtpm.log": "old log"} {
+		if data, err := os.ReadFile(filepath.Join(dstDir, name)); err != nil || string(data) != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", name, expected, data, err)
+		}
+	}
+	for _, name := range []string{"tpm2-00.volatilestate", "swtpm.pid"} {
+		if _, err := os.Stat(filepath.Join(dstDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s must not exist in copy", name)
+		}
+	}
+	if _, err := os.Stat(dstDir + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary directory must be removed")
+	}
+
+	// destination is kept if there is no state to copy
+	emptyDir := t.TempDir()
+	if err := eden.CopySWTPMState(emptyDir, dstDir); err == nil {
+		t.Error("expected error for empty state")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dstDir, "tpm2-00.permall")); string(data) != "new" {
+		t.Errorf("state is changed after failed copy: %q", data)
+	}
+}
@@ -0,0 +1,52 @@
+package templates
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+func TestQemuTCG(t *testing.T) {
+	otherArch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		otherArch = "amd64"
+	}
+	tests := []struct {
+		mode    string
+		arch    string
+		want    bool
+		wantErr bool
+	}{
+		{"", "", false, false},
+		{utils.QemuTCGAuto, runtime.GOARCH, false, false},
+		{utils.QemuTCGAuto, otherArch, true, false},
+		{utils.QemuTCGOn, runtime.GOARCH, true, false},
+		{utils.QemuTCGOff, runtime.GOARCH, false, false},
+		{utils.QemuTCGOff, otherArch, false, true},
+		{"fast", otherArch, false, true},
+	}
+	for _, tt := range tests {
+		got, err := utils.QemuTCG(tt.mode, tt.arch)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("QemuTCG(%q, %q) error = %v, want error %v", tt.mode, tt.arch, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("QemuTCG(%q, %q) = %v, want %v", tt.mode, tt.arch, got, tt.want)
+		}
+	}
+}
+
+func TestQemuFirmware(t *testing.T) {
+	firmware := utils.QemuFirmware("dist", "arm64")
+	if len(firmware) != 1 || filepath.Base(firmware[0]) != "OVMF.fd" {
+		t.Errorf("unexpected arm64 firmware %v", firmware)
+	}
+	firmware = utils.QemuFirmware("dist", "amd64")
+	if len(firmware) != 2 || filepath.Base(firmware[0]) != "OVMF_CODE.fd" ||
+		filepath.Base(firmware[1]) != "OVMF_VARS.fd" {
+		t.Errorf("unexpected amd64 firmware %v", firmware)
+	}
+}
@@ -0,0 +1,121 @@
+package templates
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These tests verify the functionality of terminal view of eden top
+
+// lastScreen returns output drawn after the last move of cursor to the top left corner
+func lastScreen(out string) string {
+	return out[strings.LastIndex(out, "\x1b[H"):]
+}
+
+// checkOrder checks that first is shown before second on screen
+func checkOrder(t *testing.T, screen, first, second string) {
+	t.Helper()
+	i, j := strings.Index(screen, first), strings.Index(screen, second)
+	if i < 0 || j < 0 || i > j {
+		t.Errorf("%s is not shown before %s:\n%s", first, second, screen)
+	}
+}
+
+func TestTopScreen(t *testing.T) {
+	dev := device.CreateEdgeNode()
+	state := eve.Init(nil, dev)
+	top := eve.NewTop(state)
+	infoCallback := state.InfoCallback()
+	metricCallback := top.MetricCallback()
+	now := timestamppb.New(time.Now())
+	am := []*metrics.AppMetric{}
+	for _, app := range []struct {
+		id, name string
+		memory   uint32
+	}{{id: "1", name: "small", memory: 10}, {id: "2", name: "large", memory: 500}} {
+		infoCallback(&info.ZInfoMsg{
+			Ztype:       info.ZInfoTypes_ZiApp,
+			DevId:       dev.GetID().String(),
+			AtTimeStamp: now,
+			InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{AppID: app.id, AppName: app.name,
+				State: info.ZSwState_RUNNING}},
+		})
+		am = append(am, &metrics.AppMetric{AppID: app.id, Cpu: &metrics.AppCpuMetric{},
+			Memory: &metrics.MemoryMetric{UsedMem: app.memory}})
+	}
+
+	var out syncBuffer
+	screen := eve.NewTopScreen(top, &out, 80, 24)
+	in, keys := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- screen.Run(context.Background(), in)
+	}()
+	waitFor(t, "screen", out.String, "waiting for metrics")
+	waitFor(t, "screen", out.String, "q quit")
+
+	screen.Update(func() {
+		metricCallback(&metrics.ZMetricMsg{
+			DevID:         dev.GetID().String(),
+			AtTimeStamp:   now,
+			MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{Memory: &metrics.MemoryMetric{}}},
+			Am:            am,
+		})
+	})
+	waitFor(t, "screen", out.String, "CPU%v")
+	// the same cpu usage, so applications are sorted by name
+	checkOrder(t, lastScreen(out.String()), "large", "small")
+
+	for _, key := range []string{"m", "m"} {
+		if _, err := keys.Write([]byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, "screen", func() string { return lastScreen(out.String()) }, "MEM USED^")
+	checkOrder(t, lastScreen(out.String()), "small", "large")
+	if _, err := keys.Write([]byte("r")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "screen", func() string { return lastScreen(out.String()) }, "MEM USEDv")
+	checkOrder(t, lastScreen(out.String()), "large", "small")
+
+	screen.Resize(20, 3)
+	for _, line := range strings.Split(lastScreen(out.String()), "\r\n") {
+		// drop escape sequences
+		for strings.Contains(line, "\x1b[") {
+			start := strings.Index(line, "\x1b[")
+			end := strings.IndexAny(line[start+2:], "HJKhlmr")
+			if end < 0 {
+				break
+			}
+			line = line[:start] + line[start+2+end+1:]
+		}
+		if len([]rune(line)) > 20 {
+			t.Errorf("line is not clipped to width of terminal: %q", line)
+		}
+	}
+
+	if _, err := keys.Write([]byte("q")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("top screen is not closed on q")
+	}
+	if !strings.HasSuffix(out.String(), "\x1b[?1049l") {
+		t.Error("alternate screen is not left on exit")
+	}
+}
@@ -0,0 +1,96 @@
+package templates
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/artifacts"
+)
+
+// These tests verify upload of artifacts into S3-compatible storage
+
+func TestUploadPathS3(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		objects[r.URL.EscapedPath()] = string(body)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(credentials, []byte("[default]\naws_access_key_id = AKIDEXAMPLE\naws_secret_access_key = secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	artifactsDir := filepath.Join(dir, "artifacts")
+	if err := os.MkdirAll(filepath.Join(artifactsDir, "reboots"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(artifactsDir, "reboots", "logs.json"), []byte("logs"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(dir, "report 1.html")
+	if err := os.WriteFile(report, []byte("report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	uploader, err := artifacts.NewUploader(context.Background(), artifacts.Config{
+		Provider:    artifacts.ProviderS3,
+		Bucket:      "tests",
+		Endpoint:    server.URL,
+		Credentials: credentials,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadedDir, err := artifacts.UploadPath(context.Background(), uploader, artifactsDir, "ci/default/artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploadedDir.Files != 1 || uploadedDir.URL != server.URL+"/tests/ci/default/artifacts/" {
+		t.Errorf("unexpected uploaded directory: %+v", uploadedDir)
+	}
+	uploadedFile, err := artifacts.UploadPath(context.Background(), uploader, report, "ci/default/report 1.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploadedFile.Files != 1 || uploadedFile.URL != server.URL+"/tests/ci/default/report%201.html" {
+		t.Errorf("unexpected uploaded file: %+v", uploadedFile)
+	}
+	// files of the previous runs are skipped
+	old := filepath.Join(artifactsDir, "reboots", "old.json")
+	if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := os.Chtimes(old, start.Add(-time.Hour), start.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	uploadedSince, err := artifacts.UploadPathSince(context.Background(), uploader, artifactsDir, "ci/default/failed/artifacts", start.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploadedSince.Files != 1 || objects["/tests/ci/default/failed/artifacts/reboots/old.json"] != "" {
+		t.Errorf("unexpected upload of old files: %+v", uploadedSince)
+	}
+	if objects["/tests/ci/default/artifacts/reboots/logs.json"] != "logs" ||
+		objects["/tests/ci/default/report%201.html"] != "report" {
+		t.Errorf("unexpected objects: %v", objects)
+	}
+}
@@ -0,0 +1,128 @@
+package templates
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"golang.org/x/net/websocket"
+)
+
+// These tests verify the functionality of web console bridging telnet console of EVE to WebSocket
+
+// fakeTelnetConsole accepts one connection, negotiates options of telnet as QEMU does,
+// prints prompt and echoes received lines
+func fakeTelnetConsole(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// IAC WILL ECHO, IAC WILL SGA, IAC SB ... IAC SE, then prompt
+		_, _ = conn.Write([]byte("\xff\xfb\x01\xff\xfb\x03\xff\xfa\x18\x01\xff\xf0login: "))
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\r')
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "\r\necho "+strings.TrimSpace(line)+"\r\n")
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// freeAddr returns address with free port on localhost
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// readUntil reads from ws until output contains s
+func readUntil(t *testing.T, ws *websocket.Conn, s string) string {
+	var output string
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for !strings.Contains(output, s) {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			t.Fatalf("no %q in output %q: %s", s, output, err)
+		}
+		output += string(data)
+	}
+	return output
+}
+
+func TestWebConsole(t *testing.T) {
+	console, err := eden.DialTelnetConsole(fakeTelnetConsole(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	listen := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- eden.ServeWebConsole(ctx, console, listen, "eve") }()
+
+	var ws *websocket.Conn
+	for i := 0; i < 50; i++ {
+		if ws, err = websocket.Dial("ws://"+listen+"/ws", "", "http://"+listen); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if output := readUntil(t, ws, "login: "); output != "login: " {
+		t.Errorf("telnet commands are not filtered: %q", output)
+	}
+	if err := websocket.Message.Send(ws, []byte("root\r")); err != nil {
+		t.Fatal(err)
+	}
+	readUntil(t, ws, "echo root")
+
+	// the second client gets scrollback
+	second, err := websocket.Dial("ws://"+listen+"/ws", "", "http://"+listen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	readUntil(t, second, "login: \r\necho root")
+
+	// pages of other sites cannot connect to console
+	if foreign, err := websocket.Dial("ws://"+listen+"/ws", "", "http://example.com"); err == nil {
+		foreign.Close()
+		t.Error("connection with foreign origin is accepted")
+	}
+
+	resp, err := http.Get("http://" + listen + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(page), "<title>eve console</title>") {
+		t.Errorf("unexpected page: %s", page)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("ServeWebConsole: %s", err)
+	}
+}
@@ -1,31 +1,23 @@
 package lim
 
 import (
-	"errors"
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
-	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/testcontext"
+	"github.com/lf-edge/eden/pkg/testutils"
 	"github.com/lf-edge/eden/pkg/utils"
-	"github.com/lf-edge/eve-api/go/info"
 )
 
-type volState struct {
-	state     string
-	timestamp time.Time
-}
-
 // This test wait for the volume's state with a timewait.
 var (
 	timewait = flag.Duration("timewait", time.Minute, "Timewait for items waiting")
 	newitems = flag.Bool("check-new", false, "Check only new info messages")
 	tc       *testcontext.TestContext
-	states   map[string][]volState
-	eveState *eve.State
 )
 
 // TestMain is used to provide setup and teardown for the rest of the
@@ -43,86 +35,11 @@ func TestMain(m *testing.M) {
 
 	tc.AddEdgeNodesFromDescription()
 
-	eveState = eve.Init(tc.GetController(), tc.GetEdgeNode())
-
-	tc.StartTrackingState(false)
-
 	res := m.Run()
 
 	os.Exit(res)
 }
 
-// checkNewLastState returns true if provided state not equals with last
-func checkNewLastState(volName, state string) bool {
-	volStates, ok := states[volName]
-	if ok {
-		lastState := volStates[len(volStates)-1]
-		if lastState.state != state {
-			return true
-		}
-	}
-	return false
-}
-
-func checkAndAppendState(volName, state string) {
-	if checkNewLastState(volName, state) {
-		states[volName] = append(states[volName], volState{state: state, timestamp: time.Now()})
-		fmt.Println(utils.AddTimestamp(fmt.Sprintf("\tvolName %s state changed to %s", volName, state)))
-	}
-}
-
-func checkState(eveState *eve.State, state string, volNames []string) error {
-	out := "\n"
-	if state == "-" {
-		foundAny := false
-		if eveState.InfoAndMetrics().GetDinfo() == nil {
-			//we need to wait for info
-			return nil
-		}
-		for _, vol := range eveState.Volumes() {
-			if _, inSlice := utils.FindEleInSlice(volNames, vol.Name); inSlice {
-				checkAndAppendState(vol.Name, vol.EveState)
-				foundAny = true
-			}
-		}
-		if foundAny {
-			return nil
-		}
-		for _, volName := range volNames {
-			out += fmt.Sprintf(
-				"no volume with %s found\n",
-				volName)
-		}
-		return errors.New(out)
-	}
-	for _, vol := range eveState.Volumes() {
-		if _, inSlice := utils.FindEleInSlice(volNames, vol.Name); inSlice {
-			checkAndAppendState(vol.Name, vol.EveState)
-		}
-	}
-	if len(states) == len(volNames) {
-		for _, volName := range volNames {
-			if !checkNewLastState(volName, state) {
-				out += fmt.Sprintf(
-					"volume %s state %s\n",
-					volName, state)
-			} else {
-				return nil
-			}
-		}
-		return errors.New(out)
-	}
-	return nil
-}
-
-// checkVol wait for info of ZInfoApp type with state
-func checkVol(state string, volNames []string) testcontext.ProcInfoFunc {
-	return func(msg *info.ZInfoMsg) error {
-		eveState.InfoCallback()(msg) //feed state with new info
-		return checkState(eveState, state, volNames)
-	}
-}
-
 // TestVolStatus wait for application reaching the selected state
 // with a timewait
 func TestVolStatus(t *testing.T) {
@@ -132,52 +49,32 @@ func TestVolStatus(t *testing.T) {
 	if len(args) == 0 {
 		t.Fatalf("Usage: %s [options] state vol_name...\n", os.Args[0])
 	} else {
-		secs := int(timewait.Seconds())
 		state := args[0]
-		t.Log(utils.AddTimestamp(fmt.Sprintf("volumes: '%s' state: '%s' secs: %d\n",
-			args[1:], state, secs)))
+		t.Log(utils.AddTimestamp(fmt.Sprintf("volumes: '%s' expected state: '%s' secs: %d\n",
+			args[1:], state, int(timewait.Seconds()))))
 
-		vols := args[1:]
-		if vols[len(vols)-1] == "&" {
-			vols = vols[:len(vols)-1]
-		}
-		states = make(map[string][]volState)
-		for _, el := range vols {
-			if el == "&" {
-				continue
-			}
-			states[el] = []volState{{state: "no info from controller", timestamp: time.Now()}}
+		names := args[1:]
+		if names[len(names)-1] == "&" {
+			names = names[:len(names)-1]
 		}
 
-		if !*newitems {
-			// observe existing info object and feed them into eveState object
-			if err := tc.GetController().InfoLastCallback(edgeNode.GetID(), nil, eveState.InfoCallback()); err != nil {
-				t.Fatal(err)
-			}
+		waiter, err := testutils.NewWaiter(tc.GetController(), edgeNode, *newitems)
+		if err != nil {
+			t.Fatal(err)
 		}
-
-		// we are done if our eveState object is in required state
-		if ready := checkState(eveState, state, vols); ready == nil {
-
-			tc.AddProcInfo(edgeNode, checkVol(state, vols))
-
-			callback := func() {
-				t.Errorf("ASSERTION FAILED (%s): expected volumes %s in %s state", time.Now().Format(time.RFC3339Nano), vols, state)
-				for k, v := range states {
-					t.Errorf("\tactual %s: %s", k, v[len(v)-1].state)
-					if checkNewLastState(k, state) {
-						t.Errorf("\thistory of states for %s:", k)
-						for _, st := range v {
-							t.Errorf("\t\tstate: %s received in: %s", st.state, st.timestamp.Format(time.RFC3339Nano))
-						}
-					}
+		waiter.Out = os.Stdout
+		ctx, cancel := context.WithTimeout(context.Background(), *timewait)
+		defer cancel()
+		if err := waiter.Wait(ctx, testutils.VolumesInState(state, names...)); err != nil {
+			t.Errorf("ASSERTION FAILED (%s): expected volumes %s in %s state: %s", time.Now().Format(time.RFC3339Nano), names, state, err)
+			for _, name := range names {
+				t.Errorf("\thistory of states for %s:", name)
+				for _, st := range waiter.History(testutils.KindVolume, name) {
+					t.Errorf("\t\tstate: %s received in: %s", st.State, st.Time.Format(time.RFC3339Nano))
 				}
 			}
-
-			tc.WaitForProcWithErrorCallback(secs, callback)
-
 		} else {
-			t.Log(utils.AddTimestamp(ready.Error()))
+			t.Log(utils.AddTimestampf("volumes %s are in state %s", names, state))
 		}
 
 		// sleep to reduce concurrency effects